@@ -0,0 +1,76 @@
+package v1
+
+import (
+	"net/http"
+
+	"xpanel/app/api/v1/helper"
+	"xpanel/app/dto"
+	"xpanel/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+var alertService = service.NewIAlertService()
+
+// ListAlertRules 告警规则及当前状态
+func (a *MonitorAPI) ListAlertRules(c *gin.Context) {
+	items, err := alertService.List()
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, items)
+}
+
+func (a *MonitorAPI) CreateAlertRule(c *gin.Context) {
+	var req dto.AlertRuleCreate
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := alertService.Create(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgCreateSuccess")
+}
+
+func (a *MonitorAPI) UpdateAlertRule(c *gin.Context) {
+	var req dto.AlertRuleUpdate
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := alertService.Update(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgUpdateSuccess")
+}
+
+func (a *MonitorAPI) DeleteAlertRule(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := alertService.Delete(req.ID); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgDeleteSuccess")
+}
+
+// MuteAlertRule 临时静默告警通知
+func (a *MonitorAPI) MuteAlertRule(c *gin.Context) {
+	var req dto.AlertRuleMute
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := alertService.Mute(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgUpdateSuccess")
+}
@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"net/http"
+
+	"xpanel/app/api/v1/helper"
+	"xpanel/app/dto"
+	"xpanel/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyAPI API Key 管理接口（仅管理员）
+type APIKeyAPI struct{}
+
+var apiKeyService = service.NewIAPIKeyService()
+
+// SearchAPIKey 分页查询 API Key
+func (a *APIKeyAPI) SearchAPIKey(c *gin.Context) {
+	var req dto.SearchWithPage
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	total, items, err := apiKeyService.SearchWithPage(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithPage(c, total, items)
+}
+
+// CreateAPIKey 创建 API Key，密钥明文仅在响应中返回一次
+func (a *APIKeyAPI) CreateAPIKey(c *gin.Context) {
+	var req dto.APIKeyCreate
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	result, err := apiKeyService.Create(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, result)
+}
+
+// UpdateAPIKey 更新 API Key 作用域、IP 允许列表、有效期与状态
+func (a *APIKeyAPI) UpdateAPIKey(c *gin.Context) {
+	var req dto.APIKeyUpdate
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := apiKeyService.Update(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgUpdateSuccess")
+}
+
+// DeleteAPIKey 删除 API Key
+func (a *APIKeyAPI) DeleteAPIKey(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := apiKeyService.Delete(req.ID); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgDeleteSuccess")
+}
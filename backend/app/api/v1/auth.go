@@ -16,6 +16,7 @@ import (
 type AuthAPI struct{}
 
 var authService = service.NewIAuthService()
+var sessionService = service.NewISessionService()
 
 // Login 用户登录
 func (a *AuthAPI) Login(c *gin.Context) {
@@ -34,13 +35,14 @@ func (a *AuthAPI) Login(c *gin.Context) {
 		}
 	}
 
+	req.IP, req.Agent = clientIP, helper.GetUserAgent(c)
 	info, err := authService.Login(req)
 	if err != nil {
 		if global.IPTracker != nil {
 			global.IPTracker.IncrementFail(clientIP)
 		}
 		needCaptcha := global.IPTracker != nil && global.IPTracker.NeedCaptcha(clientIP)
-		service.SaveLoginLog(clientIP, helper.GetUserAgent(c), err)
+		service.SaveLoginLog(req.Name, clientIP, helper.GetUserAgent(c), err)
 		helper.SuccessWithData(c, &dto.UserLoginInfo{NeedCaptcha: needCaptcha})
 		return
 	}
@@ -50,7 +52,7 @@ func (a *AuthAPI) Login(c *gin.Context) {
 	}
 
 	if info.Token != "" {
-		service.SaveLoginLog(clientIP, helper.GetUserAgent(c), nil)
+		service.SaveLoginLog(info.Name, clientIP, helper.GetUserAgent(c), nil)
 	}
 
 	helper.SuccessWithData(c, info)
@@ -105,8 +107,7 @@ func (a *AuthAPI) UpdatePassword(c *gin.Context) {
 		return
 	}
 
-	userName, _ := c.Get("userName")
-	if err := authService.UpdatePassword(userName.(string), req); err != nil {
+	if err := authService.UpdatePassword(c.GetString("userName"), req); err != nil {
 		helper.HandleError(c, err)
 		return
 	}
@@ -114,9 +115,162 @@ func (a *AuthAPI) UpdatePassword(c *gin.Context) {
 	helper.SuccessWithMsg(c, "MsgUpdateSuccess")
 }
 
-// Logout 退出登录
+// GetAccount 获取当前登录账户及权限
+func (a *AuthAPI) GetAccount(c *gin.Context) {
+	info, err := authService.GetAccount(c.GetString("userName"))
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, info)
+}
+
+// Logout 退出登录，吊销当前会话使 Token 立即失效
 func (a *AuthAPI) Logout(c *gin.Context) {
-	// JWT 是无状态的，客户端直接删除 token 即可
-	// 如需要服务端 token 黑名单，可在此实现
+	if err := service.RevokeSession(c.GetString("sessionID")); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
 	helper.SuccessWithMsg(c, "MsgLogoutSuccess")
 }
+
+// ListSessions 获取当前账户的登录会话
+func (a *AuthAPI) ListSessions(c *gin.Context) {
+	items, err := sessionService.List(c.GetString("userName"), c.GetString("sessionID"))
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, items)
+}
+
+// RevokeSession 吊销指定会话
+func (a *AuthAPI) RevokeSession(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := sessionService.Revoke(c.GetString("userName"), req.ID); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+// RevokeAllSessions 退出所有设备
+func (a *AuthAPI) RevokeAllSessions(c *gin.Context) {
+	var req dto.SessionRevokeAll
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	keepID := ""
+	if req.KeepCurrent {
+		keepID = c.GetString("sessionID")
+	}
+	if err := sessionService.RevokeAll(c.GetString("userName"), keepID); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+// MFALogin 两步验证登录：校验动态码或恢复码后签发 Token
+func (a *AuthAPI) MFALogin(c *gin.Context) {
+	var req dto.MFALogin
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	clientIP := helper.GetClientIP(c)
+	// 与密码登录共用失败计数，防止绕过验证码暴力尝试动态码
+	if global.IPTracker != nil && global.IPTracker.NeedCaptcha(clientIP) {
+		if req.CaptchaID == "" || req.Captcha == "" || !captcha.VerifyCaptcha(req.CaptchaID, req.Captcha) {
+			helper.SuccessWithData(c, &dto.UserLoginInfo{NeedCaptcha: true})
+			return
+		}
+	}
+
+	req.IP, req.Agent = clientIP, helper.GetUserAgent(c)
+	info, err := authService.MFALogin(req)
+	if err != nil {
+		if global.IPTracker != nil {
+			global.IPTracker.IncrementFail(clientIP)
+		}
+		service.SaveLoginLog(req.Name, clientIP, helper.GetUserAgent(c), err)
+		helper.HandleError(c, err)
+		return
+	}
+
+	if global.IPTracker != nil {
+		global.IPTracker.Clear(clientIP)
+	}
+	service.SaveLoginLog(info.Name, clientIP, helper.GetUserAgent(c), nil)
+	helper.SuccessWithData(c, info)
+}
+
+// GetMFAStatus 获取两步验证状态
+func (a *AuthAPI) GetMFAStatus(c *gin.Context) {
+	status, err := authService.GetMFAStatus(c.GetString("userName"))
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, status)
+}
+
+// InitMFA 生成两步验证密钥与 otpauth URI
+func (a *AuthAPI) InitMFA(c *gin.Context) {
+	info, err := authService.InitMFA(c.GetString("userName"))
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, info)
+}
+
+// BindMFA 确认绑定两步验证，返回一次性恢复码
+func (a *AuthAPI) BindMFA(c *gin.Context) {
+	var req dto.MFABind
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	codes, err := authService.BindMFA(c.GetString("userName"), req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, codes)
+}
+
+// DisableMFA 关闭两步验证
+func (a *AuthAPI) DisableMFA(c *gin.Context) {
+	var req dto.MFADisable
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := authService.DisableMFA(c.GetString("userName"), req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgUpdateSuccess")
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (a *AuthAPI) RegenerateRecoveryCodes(c *gin.Context) {
+	var req dto.MFAVerify
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	codes, err := authService.RegenerateRecoveryCodes(c.GetString("userName"), req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, codes)
+}
@@ -118,6 +118,53 @@ func (a *BackupAPI) DeleteBackupRecord(c *gin.Context) {
 	helper.SuccessWithMsg(c, "MsgDeleteSuccess")
 }
 
+// RestoreBackupRecord dryRun 时同步返回文件清单，否则后台恢复并返回任务 ID
+func (a *BackupAPI) RestoreBackupRecord(c *gin.Context) {
+	var req dto.BackupRecordRestore
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	restore := backupService.RestoreRecordAsync
+	if req.DryRun {
+		restore = backupService.RestoreRecord
+	}
+	result, err := restore(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, result)
+}
+
+func (a *BackupAPI) VerifyBackupRecord(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	task, err := backupService.VerifyRecordAsync(req.ID)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, map[string]string{"taskID": task.ID})
+}
+
+func (a *BackupAPI) PreviewBackupRetention(c *gin.Context) {
+	var req dto.BackupRetentionPreviewReq
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	preview, err := backupService.PreviewRetention(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, preview)
+}
+
 func (a *BackupAPI) ListStorageObjects(c *gin.Context) {
 	var req dto.BackupStorageReq
 	if err := helper.CheckBindAndValidate(&req, c); err != nil {
@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"xpanel/app/api/v1/helper"
+	"xpanel/app/dto"
+	"xpanel/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CertDeployAPI struct{}
+
+// ======================= 证书部署目标 =======================
+
+func (a *CertDeployAPI) ListCertificateDeployments(c *gin.Context) {
+	var req dto.CertificateDeploymentSearch
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	items, err := service.NewICertificateDeployService().List(req.CertificateID)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, items)
+}
+
+func (a *CertDeployAPI) CreateCertificateDeployment(c *gin.Context) {
+	var req dto.CertificateDeploymentCreate
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := service.NewICertificateDeployService().Create(req, helper.IsAdmin(c)); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+func (a *CertDeployAPI) UpdateCertificateDeployment(c *gin.Context) {
+	var req dto.CertificateDeploymentUpdate
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := service.NewICertificateDeployService().Update(req, helper.IsAdmin(c)); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+func (a *CertDeployAPI) DeleteCertificateDeployment(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := service.NewICertificateDeployService().Delete(req.ID); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+func (a *CertDeployAPI) RunCertificateDeployment(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := service.NewICertificateDeployService().Run(req.ID); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+// ======================= 部署记录 =======================
+
+func (a *CertDeployAPI) SearchCertificateDeployLogs(c *gin.Context) {
+	var req dto.SearchCertificateDeployLogReq
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	total, items, err := service.NewICertificateDeployService().SearchLogs(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithPage(c, total, items)
+}
@@ -20,7 +20,7 @@ func (a *CronjobAPI) CreateCronjob(c *gin.Context) {
 		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
 		return
 	}
-	if err := cronjobService.Create(req); err != nil {
+	if err := cronjobService.Create(req, helper.IsAdmin(c)); err != nil {
 		helper.HandleError(c, err)
 		return
 	}
@@ -33,7 +33,7 @@ func (a *CronjobAPI) UpdateCronjob(c *gin.Context) {
 		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
 		return
 	}
-	if err := cronjobService.Update(req); err != nil {
+	if err := cronjobService.Update(req, helper.IsAdmin(c)); err != nil {
 		helper.HandleError(c, err)
 		return
 	}
@@ -31,8 +31,12 @@ type ApiGroup struct {
 	HostSystemAPI
 	SSHKeyAPI
 	CertSyncAPI
+	CertDeployAPI
+	PrivateCAAPI
 	NotificationAPI
 	NezhaAgentAPI
+	PanelUserAPI
+	APIKeyAPI
 }
 
 // ApiGroupApp 全局 API 实例
@@ -42,6 +42,10 @@ func (a *FileAPI) GetFileContent(c *gin.Context) {
 		helper.HandleError(c, err)
 		return
 	}
+	if err := service.CheckPanelDataAccess(helper.IsAdmin(c), req.Path); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
 	svc := service.NewIFileService()
 	data, err := svc.GetContent(req)
 	if err != nil {
@@ -133,6 +137,10 @@ func (a *FileAPI) MoveFile(c *gin.Context) {
 		helper.HandleError(c, err)
 		return
 	}
+	if err := service.CheckPanelDataAccess(helper.IsAdmin(c), append(req.SrcPaths, req.DstPath)...); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
 	svc := service.NewIFileService()
 
 	// 判断是否可以瞬时完成（同分区单文件 rename）
@@ -254,6 +262,10 @@ func (a *FileAPI) CompressFile(c *gin.Context) {
 		helper.HandleError(c, err)
 		return
 	}
+	if err := service.CheckPanelDataAccess(helper.IsAdmin(c), req.Paths...); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
 	svc := service.NewIFileService()
 	taskName := fmt.Sprintf("压缩 %d 个文件 → %s", len(req.Paths), req.Name)
 	task := service.StartFileTask("compress", taskName, func() error {
@@ -269,6 +281,10 @@ func (a *FileAPI) DecompressFile(c *gin.Context) {
 		helper.HandleError(c, err)
 		return
 	}
+	if err := service.CheckPanelDataAccess(helper.IsAdmin(c), req.Path); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
 	svc := service.NewIFileService()
 	taskName := fmt.Sprintf("解压 %s → %s", filepath.Base(req.Path), filepath.Base(req.Dst))
 	task := service.StartFileTask("decompress", taskName, func() error {
@@ -590,6 +606,10 @@ func (a *FileAPI) DownloadFile(c *gin.Context) {
 	}
 
 	cleanPath := filepath.Clean(filePath)
+	if err := service.CheckPanelDataAccess(helper.IsAdmin(c), cleanPath); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
 	info, err := os.Stat(cleanPath)
 	if err != nil {
 		helper.ErrorWithDetail(c, http.StatusNotFound, "file not found")
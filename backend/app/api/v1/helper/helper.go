@@ -65,6 +65,11 @@ func HandleError(c *gin.Context, err error) {
 	}
 }
 
+// IsAdmin 当前请求的账户是否为管理员（以 root 执行命令等操作仅对管理员开放）
+func IsAdmin(c *gin.Context) bool {
+	return c.GetString("userRole") == constant.RoleAdmin
+}
+
 // CheckBindAndValidate 绑定 JSON 参数并校验
 func CheckBindAndValidate(req interface{}, c *gin.Context) error {
 	if err := c.ShouldBindJSON(req); err != nil {
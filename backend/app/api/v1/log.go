@@ -14,7 +14,10 @@ import (
 // LogAPI 日志接口
 type LogAPI struct{}
 
-var logService = service.NewILogService()
+var (
+	logService       = service.NewILogService()
+	logRotateService = service.NewILogRotateService()
+)
 
 // PageLoginLog 分页查询登录日志
 func (l *LogAPI) PageLoginLog(c *gin.Context) {
@@ -99,3 +102,52 @@ func (l *LogAPI) CleanSystemLog(c *gin.Context) {
 	}
 	helper.SuccessWithMsg(c, "MsgDeleteSuccess")
 }
+
+// GetLogRotatePolicy 获取全局或网站的日志轮转策略
+func (l *LogAPI) GetLogRotatePolicy(c *gin.Context) {
+	var req dto.LogRotatePolicyReq
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	data, err := logRotateService.GetPolicy(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, data)
+}
+
+// SaveLogRotatePolicy 保存全局或网站的日志轮转策略
+func (l *LogAPI) SaveLogRotatePolicy(c *gin.Context) {
+	var req dto.LogRotatePolicy
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := logRotateService.SavePolicy(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+// ListLogRotateFiles 列出纳入轮转的日志文件
+func (l *LogAPI) ListLogRotateFiles(c *gin.Context) {
+	data, err := logRotateService.ListFiles()
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, data)
+}
+
+// RunLogRotate 立即执行一次日志轮转
+func (l *LogAPI) RunLogRotate(c *gin.Context) {
+	data, err := logRotateService.Run()
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, data)
+}
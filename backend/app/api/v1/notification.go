@@ -119,3 +119,68 @@ func (a *NotificationAPI) DeleteNotification(c *gin.Context) {
 	}
 	helper.SuccessWithOutData(c)
 }
+
+var notificationChannelService = service.NewINotificationChannelService()
+
+// ListNotificationChannels 外发通知渠道列表（密钥不回显）
+func (a *NotificationAPI) ListNotificationChannels(c *gin.Context) {
+	items, err := notificationChannelService.List()
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, items)
+}
+
+func (a *NotificationAPI) CreateNotificationChannel(c *gin.Context) {
+	var req dto.NotificationChannelCreate
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := notificationChannelService.Create(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgCreateSuccess")
+}
+
+func (a *NotificationAPI) UpdateNotificationChannel(c *gin.Context) {
+	var req dto.NotificationChannelUpdate
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := notificationChannelService.Update(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgUpdateSuccess")
+}
+
+func (a *NotificationAPI) DeleteNotificationChannel(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := notificationChannelService.Delete(req.ID); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgDeleteSuccess")
+}
+
+// TestNotificationChannel 向渠道发送测试消息
+func (a *NotificationAPI) TestNotificationChannel(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := notificationChannelService.Test(req.ID); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
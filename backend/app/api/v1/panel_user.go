@@ -0,0 +1,101 @@
+package v1
+
+import (
+	"net/http"
+
+	"xpanel/app/api/v1/helper"
+	"xpanel/app/dto"
+	"xpanel/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PanelUserAPI 面板子账户管理接口（仅管理员）
+type PanelUserAPI struct{}
+
+var userService = service.NewIUserService()
+
+// SearchPanelUser 分页查询子账户
+func (a *PanelUserAPI) SearchPanelUser(c *gin.Context) {
+	var req dto.SearchWithPage
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	total, items, err := userService.SearchWithPage(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithPage(c, total, items)
+}
+
+// CreatePanelUser 创建子账户
+func (a *PanelUserAPI) CreatePanelUser(c *gin.Context) {
+	var req dto.UserCreate
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := userService.Create(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgCreateSuccess")
+}
+
+// UpdatePanelUser 更新子账户角色、模块与状态
+func (a *PanelUserAPI) UpdatePanelUser(c *gin.Context) {
+	var req dto.UserUpdate
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := userService.Update(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgUpdateSuccess")
+}
+
+// DeletePanelUser 删除子账户
+func (a *PanelUserAPI) DeletePanelUser(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := userService.Delete(req.ID); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgDeleteSuccess")
+}
+
+// ResetPanelUserPassword 重置子账户密码
+func (a *PanelUserAPI) ResetPanelUserPassword(c *gin.Context) {
+	var req dto.UserPasswordReset
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := userService.ResetPassword(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgUpdateSuccess")
+}
+
+// ResetPanelUserMFA 重置子账户两步验证
+func (a *PanelUserAPI) ResetPanelUserMFA(c *gin.Context) {
+	var req dto.UserResetMFA
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := userService.ResetMFA(req.ID); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithMsg(c, "MsgUpdateSuccess")
+}
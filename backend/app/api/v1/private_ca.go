@@ -0,0 +1,131 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"xpanel/app/api/v1/helper"
+	"xpanel/app/dto"
+	"xpanel/app/service"
+	"xpanel/buserr"
+	"xpanel/constant"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrivateCAAPI struct{}
+
+// ======================= 私有 CA =======================
+
+func (a *PrivateCAAPI) ListPrivateCA(c *gin.Context) {
+	items, err := service.NewIPrivateCAService().List()
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, items)
+}
+
+func (a *PrivateCAAPI) CreatePrivateCA(c *gin.Context) {
+	var req dto.PrivateCACreate
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := service.NewIPrivateCAService().Create(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+func (a *PrivateCAAPI) UpdatePrivateCA(c *gin.Context) {
+	var req dto.PrivateCAUpdate
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := service.NewIPrivateCAService().Update(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+func (a *PrivateCAAPI) DeletePrivateCA(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := service.NewIPrivateCAService().Delete(req.ID); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+func (a *PrivateCAAPI) PublishPrivateCACRL(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := service.NewIPrivateCAService().PublishCRL(req.ID); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+// ======================= 签发证书 =======================
+
+func (a *PrivateCAAPI) IssuePrivateCertificate(c *gin.Context) {
+	var req dto.PrivateCAIssue
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	info, err := service.NewIPrivateCAService().Issue(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, info)
+}
+
+func (a *PrivateCAAPI) ExportPrivateCertificate(c *gin.Context) {
+	var req dto.PrivateCAExport
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	data, filename, err := service.NewIPrivateCAService().Export(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "application/octet-stream", data)
+}
+
+// GetPrivateCACRL 公开的 CRL 分发点，供 nginx 以外的服务和客户端下载
+func (a *PrivateCAAPI) GetPrivateCACRL(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		helper.ErrorWithDetail(c, http.StatusNotFound, "not found")
+		return
+	}
+	data, err := service.NewIPrivateCAService().GetCRL(uint(id))
+	var bizErr buserr.BusinessError
+	if errors.As(err, &bizErr) && bizErr.Msg == constant.ErrPrivateCACRLNotFound {
+		helper.ErrorWithDetail(c, http.StatusNotFound, "not found")
+		return
+	}
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/pkix-crl", data)
+}
@@ -68,6 +68,10 @@ func (s *SettingAPI) RotateCredentialKey(c *gin.Context) {
 		return
 	}
 	global.LOG.Warnf("Credential encryption key rotated to %s", result.KeyID)
+	// 轮换凭据密钥视为安全事件，所有会话需重新登录
+	if err := service.RevokeAllSessions(); err != nil {
+		global.LOG.Errorf("Failed to revoke sessions after credential rotation: %v", err)
+	}
 	helper.SuccessWithData(c, result)
 }
 
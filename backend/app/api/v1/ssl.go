@@ -165,6 +165,19 @@ func (a *SSLAPI) RenewCertificate(c *gin.Context) {
 	helper.SuccessWithOutData(c)
 }
 
+func (a *SSLAPI) RevokeCertificate(c *gin.Context) {
+	var req dto.CertificateRevoke
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := service.NewICertificateService().Revoke(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
 func (a *SSLAPI) GetCertificateLog(c *gin.Context) {
 	var req dto.OperateByID
 	if err := helper.CheckBindAndValidate(&req, c); err != nil {
@@ -233,7 +246,7 @@ func (a *SSLAPI) CreateDnsAccount(c *gin.Context) {
 		helper.HandleError(c, err)
 		return
 	}
-	if err := service.NewIDnsAccountService().Create(req); err != nil {
+	if err := service.NewIDnsAccountService().Create(req, helper.IsAdmin(c)); err != nil {
 		helper.HandleError(c, err)
 		return
 	}
@@ -246,7 +259,7 @@ func (a *SSLAPI) UpdateDnsAccount(c *gin.Context) {
 		helper.HandleError(c, err)
 		return
 	}
-	if err := service.NewIDnsAccountService().Update(req); err != nil {
+	if err := service.NewIDnsAccountService().Update(req, helper.IsAdmin(c)); err != nil {
 		helper.HandleError(c, err)
 		return
 	}
@@ -283,7 +296,7 @@ func (a *SSLAPI) ImportAccounts(c *gin.Context) {
 		helper.HandleError(c, err)
 		return
 	}
-	if err := service.NewIAccountExportService().Import(req); err != nil {
+	if err := service.NewIAccountExportService().Import(req, helper.IsAdmin(c)); err != nil {
 		helper.HandleError(c, err)
 		return
 	}
@@ -1,6 +1,9 @@
 package v1
 
 import (
+	"net/http"
+	"strconv"
+
 	"xpanel/app/api/v1/helper"
 	"xpanel/app/dto"
 	"xpanel/app/service"
@@ -204,6 +207,319 @@ func (a *WebsiteAPI) GetWebsiteLog(c *gin.Context) {
 	helper.SuccessWithData(c, content)
 }
 
+func (a *WebsiteAPI) ListPHPVersions(c *gin.Context) {
+	versions, err := websiteService.ListPHPVersions()
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, versions)
+}
+
+func (a *WebsiteAPI) ListWebsiteLocations(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	items, err := websiteService.ListLocations(req.ID)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, items)
+}
+
+func (a *WebsiteAPI) SaveWebsiteLocations(c *gin.Context) {
+	var req dto.WebsiteLocationSave
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := websiteService.SaveLocations(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+func (a *WebsiteAPI) ListWebsiteUpstreams(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	items, err := websiteService.ListUpstreams(req.ID)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, items)
+}
+
+func (a *WebsiteAPI) SaveWebsiteUpstreams(c *gin.Context) {
+	var req dto.WebsiteUpstreamSave
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := websiteService.SaveUpstreams(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+func (a *WebsiteAPI) CheckWebsiteUpstreamHealth(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	items, err := websiteService.UpstreamHealth(req.ID)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, items)
+}
+
+func (a *WebsiteAPI) ListWebsiteWAFRuleSets(c *gin.Context) {
+	helper.SuccessWithData(c, websiteService.ListWAFRuleSets())
+}
+
+func (a *WebsiteAPI) GetWebsiteWAF(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	config, err := websiteService.GetWAF(req.ID)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, config)
+}
+
+func (a *WebsiteAPI) SaveWebsiteWAF(c *gin.Context) {
+	var req dto.WebsiteWAFConfig
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := websiteService.SaveWAF(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+func (a *WebsiteAPI) SearchWebsiteWAFLog(c *gin.Context) {
+	var req dto.WebsiteWAFLogSearch
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	total, items, err := websiteService.SearchWAFLog(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithPage(c, total, items)
+}
+
+func (a *WebsiteAPI) BanWebsiteWAFIP(c *gin.Context) {
+	var req dto.WebsiteWAFBanReq
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := websiteService.BanWAFIP(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+func (a *WebsiteAPI) GetWebsiteAccess(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	config, err := websiteService.GetAccess(req.ID)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, config)
+}
+
+func (a *WebsiteAPI) SaveWebsiteAccess(c *gin.Context) {
+	var req dto.WebsiteAccessConfig
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := websiteService.SaveAccess(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
+func (a *WebsiteAPI) CloneWebsite(c *gin.Context) {
+	var req dto.WebsiteClone
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	result, err := websiteService.Clone(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, result)
+}
+
+func (a *WebsiteAPI) PromoteWebsite(c *gin.Context) {
+	var req dto.WebsitePromote
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	result, err := websiteService.Promote(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, result)
+}
+
+func (a *WebsiteAPI) GetWebsiteDeploy(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	info, err := websiteService.GetDeploy(req.ID)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, info)
+}
+
+func (a *WebsiteAPI) SaveWebsiteDeploy(c *gin.Context) {
+	var req dto.WebsiteDeployConfig
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	info, err := websiteService.SaveDeploy(req, helper.IsAdmin(c))
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, info)
+}
+
+func (a *WebsiteAPI) RunWebsiteDeploy(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	record, err := websiteService.Deploy(req.ID)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, record)
+}
+
+func (a *WebsiteAPI) RollbackWebsiteDeploy(c *gin.Context) {
+	var req dto.WebsiteDeployRollback
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	record, err := websiteService.RollbackDeploy(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, record)
+}
+
+func (a *WebsiteAPI) SearchWebsiteDeployRecords(c *gin.Context) {
+	var req dto.WebsiteDeployRecordSearch
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	total, items, err := websiteService.SearchDeployRecords(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithPage(c, total, items)
+}
+
+func (a *WebsiteAPI) CheckWebsiteConfigDrift(c *gin.Context) {
+	var req dto.OperateByID
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	info, err := websiteService.CheckConfigDrift(req.ID)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, info)
+}
+
+func (a *WebsiteAPI) ResolveWebsiteConfigDrift(c *gin.Context) {
+	var req dto.WebsiteConfigDriftResolve
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	result, err := websiteService.ResolveConfigDrift(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, result)
+}
+
+// WebsiteDeployWebhook Git 仓库推送回调（公开路由，由签名认证）
+func (a *WebsiteAPI) WebsiteDeployWebhook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		helper.ErrorWithDetail(c, http.StatusNotFound, "not found")
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 5<<20)
+	body, err := c.GetRawData()
+	if err != nil {
+		helper.ErrorWithDetail(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	record, err := websiteService.TriggerDeployWebhook(uint(id), c.Request.Header, body)
+	if err != nil {
+		if service.IsDeploySignatureError(err) {
+			helper.ErrorWithDetail(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, record)
+}
+
 // --- Nginx 配置文件管理 ---
 
 func (a *WebsiteAPI) GetNginxMainConf(c *gin.Context) {
@@ -353,6 +669,42 @@ func (a *WebsiteAPI) AnalyzeNginxLog(c *gin.Context) {
 	helper.SuccessWithData(c, data)
 }
 
+func (a *WebsiteAPI) GetWebsiteAccessStats(c *gin.Context) {
+	var req dto.WebsiteAccessStatsReq
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	data, err := nginxLogService.SiteStats(req)
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, data)
+}
+
+func (a *WebsiteAPI) GetAccessStatsSetting(c *gin.Context) {
+	data, err := nginxLogService.GetStatsSetting()
+	if err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithData(c, data)
+}
+
+func (a *WebsiteAPI) UpdateAccessStatsSetting(c *gin.Context) {
+	var req dto.AccessStatsSetting
+	if err := helper.CheckBindAndValidate(&req, c); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	if err := nginxLogService.UpdateStatsSetting(req); err != nil {
+		helper.HandleError(c, err)
+		return
+	}
+	helper.SuccessWithOutData(c)
+}
+
 func (a *WebsiteAPI) DetectNginxSites(c *gin.Context) {
 	sites, err := nginxLogService.DetectSites()
 	if err != nil {
@@ -0,0 +1,52 @@
+package dto
+
+import "time"
+
+// AlertRuleCreate 创建告警规则
+type AlertRuleCreate struct {
+	Name           string  `json:"name" binding:"required,max=64"`
+	Metric         string  `json:"metric" binding:"required,oneof=cpu memory load disk temperature traffic"`
+	Target         string  `json:"target"`
+	Operator       string  `json:"operator" binding:"omitempty,oneof=gt lt"`
+	Threshold      float64 `json:"threshold"`
+	Hysteresis     float64 `json:"hysteresis" binding:"min=0"`
+	Duration       int     `json:"duration" binding:"min=0"`
+	SilenceStart   string  `json:"silenceStart"`
+	SilenceEnd     string  `json:"silenceEnd"`
+	NotifyRecovery bool    `json:"notifyRecovery"`
+}
+
+// AlertRuleUpdate 更新告警规则
+type AlertRuleUpdate struct {
+	ID uint `json:"id" binding:"required"`
+	AlertRuleCreate
+	Status string `json:"status" binding:"required,oneof=Enable Disable"`
+}
+
+// AlertRuleMute 临时静默告警，Minutes 为 0 表示取消静默
+type AlertRuleMute struct {
+	ID      uint `json:"id" binding:"required"`
+	Minutes int  `json:"minutes" binding:"min=0,max=43200"`
+}
+
+// AlertRuleInfo 告警规则及当前状态
+type AlertRuleInfo struct {
+	ID              uint       `json:"id"`
+	Name            string     `json:"name"`
+	Metric          string     `json:"metric"`
+	Target          string     `json:"target"`
+	Operator        string     `json:"operator"`
+	Threshold       float64    `json:"threshold"`
+	Hysteresis      float64    `json:"hysteresis"`
+	Duration        int        `json:"duration"`
+	SilenceStart    string     `json:"silenceStart"`
+	SilenceEnd      string     `json:"silenceEnd"`
+	NotifyRecovery  bool       `json:"notifyRecovery"`
+	Status          string     `json:"status"`
+	State           string     `json:"state"`
+	LastValue       float64    `json:"lastValue"`
+	PendingSince    *time.Time `json:"pendingSince"`
+	FiredAt         *time.Time `json:"firedAt"`
+	MutedUntil      *time.Time `json:"mutedUntil"`
+	LastEvaluatedAt *time.Time `json:"lastEvaluatedAt"`
+}
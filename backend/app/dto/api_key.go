@@ -0,0 +1,47 @@
+package dto
+
+import "time"
+
+// APIKeyCreate 创建 API Key
+type APIKeyCreate struct {
+	Name        string     `json:"name" binding:"required,max=64"`
+	Modules     []string   `json:"modules"`
+	Access      string     `json:"access" binding:"required,oneof=read write"`
+	AllowIPs    []string   `json:"allowIPs"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	Description string     `json:"description"`
+}
+
+// APIKeyUpdate 更新 API Key 作用域（不可更换密钥）
+type APIKeyUpdate struct {
+	ID          uint       `json:"id" binding:"required"`
+	Modules     []string   `json:"modules"`
+	Access      string     `json:"access" binding:"required,oneof=read write"`
+	AllowIPs    []string   `json:"allowIPs"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	Status      string     `json:"status" binding:"required,oneof=Enable Disable"`
+	Description string     `json:"description"`
+}
+
+// APIKeyInfo API Key 信息（不含密钥）
+type APIKeyInfo struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	KeyID       string     `json:"keyID"`
+	Modules     []string   `json:"modules"`
+	Access      string     `json:"access"`
+	AllowIPs    []string   `json:"allowIPs"`
+	Status      string     `json:"status"`
+	Description string     `json:"description"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+	LastUsedIP  string     `json:"lastUsedIP"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// APIKeyCreated 创建结果，Key 明文仅返回这一次
+type APIKeyCreated struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 // Login 登录请求
 type Login struct {
 	Name      string `json:"name" binding:"required"`
@@ -7,6 +9,10 @@ type Login struct {
 	CaptchaID string `json:"captchaID"`
 	Captcha   string `json:"captcha"`
 	Remember  bool   `json:"remember"`
+
+	// IP/Agent 由 API 层填入，用于登记会话
+	IP    string `json:"-"`
+	Agent string `json:"-"`
 }
 
 // InitUser 初始化用户（首次设置密码）
@@ -29,15 +35,72 @@ type CaptchaResponse struct {
 	ImageData string `json:"imageData"`
 }
 
-// MFALogin MFA 登录
+// MFALogin MFA 登录（Code 可为动态码或恢复码）
 type MFALogin struct {
-	Name     string `json:"name" binding:"required"`
+	Name      string `json:"name" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+	Code      string `json:"code" binding:"required"`
+	CaptchaID string `json:"captchaID"`
+	Captcha   string `json:"captcha"`
+	Remember  bool   `json:"remember"`
+
+	// IP/Agent 由 API 层填入，用于登记会话
+	IP    string `json:"-"`
+	Agent string `json:"-"`
+}
+
+// MFAStatus MFA 状态
+type MFAStatus struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recoveryCodesRemaining"`
+}
+
+// MFAInitInfo MFA 绑定信息（前端用 URI 生成二维码）
+type MFAInitInfo struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// MFABind 确认绑定 MFA
+type MFABind struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFAVerify 使用动态码或恢复码确认操作
+type MFAVerify struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFADisable 关闭 MFA
+type MFADisable struct {
 	Password string `json:"password" binding:"required"`
 	Code     string `json:"code" binding:"required"`
 }
 
+// MFARecoveryCodes 恢复码（仅生成时返回一次明文）
+type MFARecoveryCodes struct {
+	Codes []string `json:"codes"`
+}
+
 // PasswordUpdate 修改密码
 type PasswordUpdate struct {
 	OldPassword string `json:"oldPassword" binding:"required"`
 	NewPassword string `json:"newPassword" binding:"required,min=6"`
 }
+
+// SessionInfo 登录会话
+type SessionInfo struct {
+	ID         uint      `json:"id"`
+	IP         string    `json:"ip"`
+	Agent      string    `json:"agent"`
+	Remember   bool      `json:"remember"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// SessionRevokeAll 退出所有设备
+type SessionRevokeAll struct {
+	KeepCurrent bool `json:"keepCurrent"`
+}
@@ -10,6 +10,7 @@ type BackupAccountCreate struct {
 	Credential string `json:"credential"`
 	BackupPath string `json:"backupPath"`
 	Vars       string `json:"vars"`
+	QuotaBytes int64  `json:"quotaBytes"`
 }
 
 type BackupAccountUpdate struct {
@@ -20,6 +21,7 @@ type BackupAccountUpdate struct {
 	Credential string `json:"credential"`
 	BackupPath string `json:"backupPath"`
 	Vars       string `json:"vars"`
+	QuotaBytes int64  `json:"quotaBytes"`
 }
 
 type BackupAccountTest struct {
@@ -40,6 +42,7 @@ type BackupAccountInfo struct {
 	Bucket     string    `json:"bucket"`
 	BackupPath string    `json:"backupPath"`
 	Vars       string    `json:"vars"`
+	QuotaBytes int64     `json:"quotaBytes"`
 }
 
 type BackupRecordSearch struct {
@@ -64,6 +67,13 @@ type BackupRecordInfo struct {
 	SourcePath string    `json:"sourcePath"`
 	Status     string    `json:"status"`
 	Message    string    `json:"message"`
+	ChainID    uint      `json:"chainID"`
+	ParentID   uint      `json:"parentID"`
+	Level      int       `json:"level"`
+
+	VerifyStatus  string     `json:"verifyStatus"`
+	VerifyMessage string     `json:"verifyMessage"`
+	VerifiedAt    *time.Time `json:"verifiedAt"`
 }
 
 type BackupCreate struct {
@@ -74,6 +84,47 @@ type BackupCreate struct {
 	SourceDir string `json:"sourceDir"`
 }
 
+type BackupRecordRestore struct {
+	ID           uint   `json:"id" binding:"required"`
+	TargetPath   string `json:"targetPath"`
+	Password     string `json:"password"`
+	DryRun       bool   `json:"dryRun"`
+	SkipSnapshot bool   `json:"skipSnapshot"`
+}
+
+type BackupRecordRestoreResult struct {
+	TargetPath string   `json:"targetPath"`
+	Entries    []string `json:"entries"`
+	Total      int      `json:"total"`
+	Snapshot   string   `json:"snapshot"`
+	TaskID     string   `json:"taskID"`
+}
+
+type BackupRetentionPreviewReq struct {
+	CronjobID uint `json:"cronjobID"`
+	AccountID uint `json:"accountID"`
+}
+
+type BackupPruneItem struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Type      string    `json:"type"`
+	Name      string    `json:"name"`
+	AccountID uint      `json:"accountID"`
+	CronjobID uint      `json:"cronjobID"`
+	FileName  string    `json:"fileName"`
+	Size      int64     `json:"size"`
+	ChainID   uint      `json:"chainID"`
+	Reason    string    `json:"reason"` // retention / quota
+}
+
+type BackupRetentionPreview struct {
+	Items      []BackupPruneItem `json:"items"`
+	PruneBytes int64             `json:"pruneBytes"`
+	UsedBytes  int64             `json:"usedBytes"`
+	QuotaBytes int64             `json:"quotaBytes"`
+}
+
 type BackupStorageReq struct {
 	AccountID uint   `json:"accountID" binding:"required"`
 	Prefix    string `json:"prefix"`
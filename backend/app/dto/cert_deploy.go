@@ -0,0 +1,66 @@
+package dto
+
+import "time"
+
+// --- 证书部署目标 ---
+
+type CertificateDeploymentCreate struct {
+	CertificateID uint   `json:"certificateID" binding:"required"`
+	Name          string `json:"name" binding:"required,max=64"`
+	Type          string `json:"type" binding:"required,oneof=ssh local command"`
+	Enabled       bool   `json:"enabled"`
+	HostID        uint   `json:"hostID"`
+	Format        string `json:"format" binding:"omitempty,oneof=pem bundle pkcs12 jks"`
+	CertPath      string `json:"certPath"`
+	KeyPath       string `json:"keyPath"`
+	Password      string `json:"password"`
+	Alias         string `json:"alias"`
+	Command       string `json:"command"`
+}
+
+// CertificateDeploymentUpdate Password 为空时保留原密码
+type CertificateDeploymentUpdate struct {
+	ID       uint   `json:"id" binding:"required"`
+	Name     string `json:"name" binding:"required,max=64"`
+	Type     string `json:"type" binding:"required,oneof=ssh local command"`
+	Enabled  bool   `json:"enabled"`
+	HostID   uint   `json:"hostID"`
+	Format   string `json:"format" binding:"omitempty,oneof=pem bundle pkcs12 jks"`
+	CertPath string `json:"certPath"`
+	KeyPath  string `json:"keyPath"`
+	Password string `json:"password"`
+	Alias    string `json:"alias"`
+	Command  string `json:"command"`
+}
+
+type CertificateDeploymentInfo struct {
+	ID            uint       `json:"id"`
+	CertificateID uint       `json:"certificateID"`
+	Name          string     `json:"name"`
+	Type          string     `json:"type"`
+	Enabled       bool       `json:"enabled"`
+	HostID        uint       `json:"hostID"`
+	HostName      string     `json:"hostName"`
+	Format        string     `json:"format"`
+	CertPath      string     `json:"certPath"`
+	KeyPath       string     `json:"keyPath"`
+	PasswordSet   bool       `json:"passwordSet"`
+	Alias         string     `json:"alias"`
+	Command       string     `json:"command"`
+	LastDeployAt  *time.Time `json:"lastDeployAt"`
+	LastStatus    string     `json:"lastStatus"`
+	LastMessage   string     `json:"lastMessage"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+type CertificateDeploymentSearch struct {
+	CertificateID uint `json:"certificateID" binding:"required"`
+}
+
+// --- 部署记录 ---
+
+type SearchCertificateDeployLogReq struct {
+	PageInfo
+	CertificateID uint `json:"certificateID"`
+	DeploymentID  uint `json:"deploymentID"`
+}
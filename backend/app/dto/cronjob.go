@@ -15,6 +15,13 @@ type CronjobCreate struct {
 	SourceDir              string `json:"sourceDir"`
 	TargetAccountID        uint   `json:"targetAccountID"`
 	RetainCopies           uint   `json:"retainCopies"`
+	RetentionPolicy        string `json:"retentionPolicy"`
+	KeepDaily              uint   `json:"keepDaily"`
+	KeepWeekly             uint   `json:"keepWeekly"`
+	KeepMonthly            uint   `json:"keepMonthly"`
+	KeepYearly             uint   `json:"keepYearly"`
+	BackupMode             string `json:"backupMode"`
+	ChainLength            uint   `json:"chainLength"`
 	ExclusionRules         string `json:"exclusionRules"`
 	CompressFormat         string `json:"compressFormat"`
 	EncryptPassword        string `json:"encryptPassword"`
@@ -23,6 +30,8 @@ type CronjobCreate struct {
 	PostCommand            string `json:"postCommand"`
 	ComposeName            string `json:"composeName"`
 	ComposeOperation       string `json:"composeOperation"`
+	VerifyCronjobID        uint   `json:"verifyCronjobID"`
+	VerifyCount            uint   `json:"verifyCount"`
 }
 
 type CronjobUpdate struct {
@@ -39,6 +48,13 @@ type CronjobUpdate struct {
 	SourceDir              string `json:"sourceDir"`
 	TargetAccountID        uint   `json:"targetAccountID"`
 	RetainCopies           uint   `json:"retainCopies"`
+	RetentionPolicy        string `json:"retentionPolicy"`
+	KeepDaily              uint   `json:"keepDaily"`
+	KeepWeekly             uint   `json:"keepWeekly"`
+	KeepMonthly            uint   `json:"keepMonthly"`
+	KeepYearly             uint   `json:"keepYearly"`
+	BackupMode             string `json:"backupMode"`
+	ChainLength            uint   `json:"chainLength"`
 	ExclusionRules         string `json:"exclusionRules"`
 	CompressFormat         string `json:"compressFormat"`
 	EncryptPassword        string `json:"encryptPassword"`
@@ -47,6 +63,8 @@ type CronjobUpdate struct {
 	PostCommand            string `json:"postCommand"`
 	ComposeName            string `json:"composeName"`
 	ComposeOperation       string `json:"composeOperation"`
+	VerifyCronjobID        uint   `json:"verifyCronjobID"`
+	VerifyCount            uint   `json:"verifyCount"`
 }
 
 type CronjobSearch struct {
@@ -73,6 +91,13 @@ type CronjobInfo struct {
 	SourceDir              string    `json:"sourceDir"`
 	TargetAccountID        uint      `json:"targetAccountID"`
 	RetainCopies           uint      `json:"retainCopies"`
+	RetentionPolicy        string    `json:"retentionPolicy"`
+	KeepDaily              uint      `json:"keepDaily"`
+	KeepWeekly             uint      `json:"keepWeekly"`
+	KeepMonthly            uint      `json:"keepMonthly"`
+	KeepYearly             uint      `json:"keepYearly"`
+	BackupMode             string    `json:"backupMode"`
+	ChainLength            uint      `json:"chainLength"`
 	ExclusionRules         string    `json:"exclusionRules"`
 	CompressFormat         string    `json:"compressFormat"`
 	EncryptPasswordSet     bool      `json:"encryptPasswordSet"`
@@ -81,6 +106,8 @@ type CronjobInfo struct {
 	PostCommand            string    `json:"postCommand"`
 	ComposeName            string    `json:"composeName"`
 	ComposeOperation       string    `json:"composeOperation"`
+	VerifyCronjobID        uint      `json:"verifyCronjobID"`
+	VerifyCount            uint      `json:"verifyCount"`
 }
 
 type CronjobRecordSearch struct {
@@ -0,0 +1,33 @@
+package dto
+
+type LogRotatePolicyReq struct {
+	WebsiteID uint `json:"websiteID"` // 0 表示全局策略
+}
+
+type LogRotatePolicy struct {
+	WebsiteID uint `json:"websiteID"`
+	// UseGlobal 网站沿用全局策略；保存时为 true 会删除网站的单独策略
+	UseGlobal bool   `json:"useGlobal"`
+	Enable    bool   `json:"enable"`
+	Interval  string `json:"interval" binding:"required,oneof=daily weekly monthly none"`
+	MaxSize   int    `json:"maxSize" binding:"min=0,max=102400"` // MB
+	Keep      int    `json:"keep" binding:"min=1,max=365"`
+	MaxAge    int    `json:"maxAge" binding:"min=0,max=3650"` // 天
+	Compress  bool   `json:"compress"`
+}
+
+type LogRotateFile struct {
+	Category    string `json:"category"` // site | nginx | panel | ssl
+	WebsiteID   uint   `json:"websiteID"`
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	History     int    `json:"history"`     // 历史文件数
+	HistorySize int64  `json:"historySize"` // 历史文件占用
+}
+
+type LogRotateResult struct {
+	Rotated    []string `json:"rotated"`
+	Compressed int      `json:"compressed"`
+	Removed    int      `json:"removed"`
+	Failed     []string `json:"failed"`
+}
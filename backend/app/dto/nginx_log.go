@@ -73,3 +73,39 @@ type NginxLogDrilldownResp struct {
 	IPs  []RankItem `json:"ips"`
 	URLs []RankItem `json:"urls"`
 }
+
+// ---- 网站访问统计（后台采集的聚合数据） ----
+
+type WebsiteAccessStatsReq struct {
+	ID        uint   `json:"id" binding:"required"`
+	TimeRange string `json:"timeRange"` // 1h, 6h, 24h, 7d, 30d
+}
+
+type AccessStatPoint struct {
+	Time        string  `json:"time"`
+	Requests    int64   `json:"requests"`
+	Bytes       int64   `json:"bytes"`
+	Status2xx   int64   `json:"status2xx"`
+	Status3xx   int64   `json:"status3xx"`
+	Status4xx   int64   `json:"status4xx"`
+	Status5xx   int64   `json:"status5xx"`
+	UpstreamAvg float64 `json:"upstreamAvg"` // 毫秒
+	UpstreamMax float64 `json:"upstreamMax"` // 毫秒
+}
+
+type WebsiteAccessStats struct {
+	Period        string            `json:"period"` // minute | hour
+	TotalRequests int64             `json:"totalRequests"`
+	TotalBytes    int64             `json:"totalBytes"`
+	ErrorRate     float64           `json:"errorRate"`
+	UpstreamAvg   float64           `json:"upstreamAvg"` // 毫秒
+	UpstreamMax   float64           `json:"upstreamMax"` // 毫秒
+	Points        []AccessStatPoint `json:"points"`
+	TopURLs       []RankItem        `json:"topUrls"`
+	TopIPs        []RankItem        `json:"topIps"`
+}
+
+type AccessStatsSetting struct {
+	MinuteDays int `json:"minuteDays" binding:"required,min=1,max=30"` // 分钟粒度数据保留天数
+	StoreDays  int `json:"storeDays" binding:"required,min=1,max=365"` // 小时粒度数据与排行保留天数
+}
@@ -40,12 +40,44 @@ type NotificationSummary struct {
 }
 
 type NotificationPreferenceRule struct {
-	Center bool `json:"center"`
-	Badge  bool `json:"badge"`
-	Popup  bool `json:"popup"`
+	Center   bool   `json:"center"`
+	Badge    bool   `json:"badge"`
+	Popup    bool   `json:"popup"`
+	Channels []uint `json:"channels"` // 外发渠道 ID
 }
 
 type NotificationPreference struct {
 	Defaults NotificationPreferenceRule            `json:"defaults"`
 	Events   map[string]NotificationPreferenceRule `json:"events"`
 }
+
+type NotificationChannelCreate struct {
+	Name      string            `json:"name" binding:"required,max=64"`
+	Type      string            `json:"type" binding:"required,oneof=email webhook telegram dingtalk feishu wecom"`
+	Config    map[string]string `json:"config" binding:"required"`
+	RateLimit int               `json:"rateLimit" binding:"min=0,max=600"`
+}
+
+// NotificationChannelUpdate 密钥类配置留空表示保持不变
+type NotificationChannelUpdate struct {
+	ID        uint              `json:"id" binding:"required"`
+	Name      string            `json:"name" binding:"required,max=64"`
+	Type      string            `json:"type" binding:"required,oneof=email webhook telegram dingtalk feishu wecom"`
+	Config    map[string]string `json:"config"`
+	Status    string            `json:"status" binding:"required,oneof=Enable Disable"`
+	RateLimit int               `json:"rateLimit" binding:"min=0,max=600"`
+}
+
+// NotificationChannelInfo 渠道信息，密钥类配置不回显，仅标记是否已设置
+type NotificationChannelInfo struct {
+	ID         uint              `json:"id"`
+	Name       string            `json:"name"`
+	Type       string            `json:"type"`
+	Config     map[string]string `json:"config"`
+	SecretSet  []string          `json:"secretSet"`
+	Status     string            `json:"status"`
+	RateLimit  int               `json:"rateLimit"`
+	LastStatus string            `json:"lastStatus"`
+	LastError  string            `json:"lastError"`
+	LastSentAt *time.Time        `json:"lastSentAt"`
+}
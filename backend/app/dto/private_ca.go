@@ -0,0 +1,63 @@
+package dto
+
+import "time"
+
+// --- 私有 CA ---
+
+// PrivateCACreate ParentID 为 0 时生成根 CA，否则由该根 CA 签发中间 CA
+type PrivateCACreate struct {
+	Name         string `json:"name" binding:"required,max=64"`
+	ParentID     uint   `json:"parentID"`
+	CommonName   string `json:"commonName" binding:"required,max=64"`
+	Organization string `json:"organization" binding:"max=64"`
+	KeyType      string `json:"keyType" binding:"required,oneof=P256 P384 2048 3072 4096"`
+	ValidityDays int    `json:"validityDays" binding:"required,min=1,max=36500"`
+	CRLURL       string `json:"crlURL" binding:"omitempty,url"`
+	Description  string `json:"description"`
+}
+
+type PrivateCAUpdate struct {
+	ID          uint   `json:"id" binding:"required"`
+	Name        string `json:"name" binding:"required,max=64"`
+	CRLURL      string `json:"crlURL" binding:"omitempty,url"`
+	Description string `json:"description"`
+}
+
+type PrivateCAInfo struct {
+	ID           uint       `json:"id"`
+	Name         string     `json:"name"`
+	ParentID     uint       `json:"parentID"`
+	CommonName   string     `json:"commonName"`
+	Organization string     `json:"organization"`
+	KeyType      string     `json:"keyType"`
+	Pem          string     `json:"pem"`
+	SerialNumber string     `json:"serialNumber"`
+	Fingerprint  string     `json:"fingerprintSHA256"`
+	NotBefore    time.Time  `json:"notBefore"`
+	NotAfter     time.Time  `json:"notAfter"`
+	CRLURL       string     `json:"crlURL"`
+	CRLNumber    int64      `json:"crlNumber"`
+	CRLUpdatedAt *time.Time `json:"crlUpdatedAt"`
+	Description  string     `json:"description"`
+	IssuedCount  int64      `json:"issuedCount"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// PrivateCAIssue Names 为服务端证书额外的 DNS 名称或 IP，CommonName 为 IP 或域名时同样写入 SAN
+type PrivateCAIssue struct {
+	AuthorityID  uint     `json:"authorityID" binding:"required"`
+	Usage        string   `json:"usage" binding:"required,oneof=server client"`
+	CommonName   string   `json:"commonName" binding:"required,max=255"`
+	Names        []string `json:"names" binding:"max=100"`
+	KeyType      string   `json:"keyType" binding:"required,oneof=P256 P384 2048 3072 4096"`
+	ValidityDays int      `json:"validityDays" binding:"required,min=1,max=3650"`
+	AutoRenew    bool     `json:"autoRenew"`
+	Description  string   `json:"description"`
+}
+
+// PrivateCAExport 导出私有 CA 签发的证书与私钥，供 mTLS 客户端导入
+type PrivateCAExport struct {
+	ID       uint   `json:"id" binding:"required"`
+	Format   string `json:"format" binding:"required,oneof=bundle pkcs12"`
+	Password string `json:"password"`
+}
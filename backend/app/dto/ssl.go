@@ -51,6 +51,7 @@ type CertificateCreate struct {
 	Provider      string `json:"provider" binding:"required,oneof=dns http manual"`
 	AcmeAccountID uint   `json:"acmeAccountID"`
 	DnsAccountID  uint   `json:"dnsAccountID"`
+	DnsAlias      string `json:"dnsAlias"` // DNS 别名域，仅 dns 验证可用
 	WebsiteID     uint   `json:"websiteID"`
 	KeyType       string `json:"keyType"`
 	AutoRenew     bool   `json:"autoRenew"`
@@ -64,6 +65,16 @@ type CertificateUpdate struct {
 	Description   string `json:"description"`
 	PrimaryDomain string `json:"primaryDomain"`
 	OtherDomains  string `json:"otherDomains"`
+	// DnsAlias 为 nil 时不修改，空串表示关闭别名模式
+	DnsAlias *string `json:"dnsAlias"`
+}
+
+// CertificateRevoke Reason 为 RFC 5280 吊销原因码，ACME 仅接受 0 未指定、1 密钥泄露、3 从属关系变更、4 已被替代、5 停止使用
+type CertificateRevoke struct {
+	ID     uint `json:"id" binding:"required"`
+	Reason uint `json:"reason" binding:"oneof=0 1 3 4 5"`
+	// Renew 吊销后立即用新私钥重新签发；为 false 时同时关闭自动续签，避免被当作 CA 吊销自动补签
+	Renew bool `json:"renew"`
 }
 
 type CertificateUpload struct {
@@ -96,6 +107,7 @@ type CertificateInfo struct {
 	Type          string    `json:"type"`
 	AcmeAccountID uint      `json:"acmeAccountID"`
 	DnsAccountID  uint      `json:"dnsAccountID"`
+	DnsAlias      string    `json:"dnsAlias"`
 	WebsiteID     uint      `json:"websiteID"`
 	KeyType       string    `json:"keyType"`
 	AutoRenew     bool      `json:"autoRenew"`
@@ -116,6 +128,14 @@ type CertificateInfo struct {
 	SourceName    string    `json:"sourceName"`
 	NotBefore     time.Time `json:"notBefore"`
 	NotAfter      time.Time `json:"notAfter"`
+	// ARI 续签窗口与吊销状态
+	RenewalWindowStart    *time.Time `json:"renewalWindowStart"`
+	RenewalWindowEnd      *time.Time `json:"renewalWindowEnd"`
+	RenewalScheduledAt    *time.Time `json:"renewalScheduledAt"`
+	RenewalExplanationURL string     `json:"renewalExplanationURL"`
+	RevocationStatus      string     `json:"revocationStatus"`
+	RevokedAt             *time.Time `json:"revokedAt"`
+	RevocationCheckedAt   *time.Time `json:"revocationCheckedAt"`
 	// 关联
 	AcmeAccountEmail string `json:"acmeAccountEmail"`
 	DnsAccountName   string `json:"dnsAccountName"`
@@ -0,0 +1,52 @@
+package dto
+
+import "time"
+
+// UserCreate 创建子账户
+type UserCreate struct {
+	Name        string   `json:"name" binding:"required,max=64"`
+	Password    string   `json:"password" binding:"required,min=6"`
+	Role        string   `json:"role" binding:"required,oneof=admin operator readonly"`
+	Modules     []string `json:"modules"`
+	Description string   `json:"description"`
+}
+
+// UserUpdate 更新子账户（不含密码）
+type UserUpdate struct {
+	ID          uint     `json:"id" binding:"required"`
+	Role        string   `json:"role" binding:"required,oneof=admin operator readonly"`
+	Modules     []string `json:"modules"`
+	Status      string   `json:"status" binding:"required,oneof=Enable Disable"`
+	Description string   `json:"description"`
+}
+
+// UserPasswordReset 管理员重置子账户密码
+type UserPasswordReset struct {
+	ID       uint   `json:"id" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// PanelUserInfo 子账户信息
+type PanelUserInfo struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Role        string    `json:"role"`
+	Modules     []string  `json:"modules"`
+	Status      string    `json:"status"`
+	Description string    `json:"description"`
+	MFAEnabled  bool      `json:"mfaEnabled"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// AccountInfo 当前登录账户及其权限
+type AccountInfo struct {
+	Name    string   `json:"name"`
+	Role    string   `json:"role"`
+	Modules []string `json:"modules"`
+	Builtin bool     `json:"builtin"`
+}
+
+// UserResetMFA 管理员重置子账户两步验证
+type UserResetMFA struct {
+	ID uint `json:"id" binding:"required"`
+}
@@ -8,7 +8,7 @@ type WebsiteCreate struct {
 	PrimaryDomain string `json:"primaryDomain" binding:"required"`
 	Alias         string `json:"alias"`
 	Domains       string `json:"domains"`
-	Type          string `json:"type" binding:"required,oneof=static reverse_proxy"`
+	Type          string `json:"type" binding:"required,oneof=static reverse_proxy php"`
 	Remark        string `json:"remark"`
 	ConfigMode    string `json:"configMode" binding:"omitempty,oneof=managed source"`
 
@@ -19,6 +19,18 @@ type WebsiteCreate struct {
 	// Static
 	SiteDir string `json:"siteDir"`
 
+	// PHP
+	PHPVersion         string `json:"phpVersion"`
+	PHPFramework       string `json:"phpFramework" binding:"omitempty,oneof=none wordpress laravel thinkphp"`
+	PHPPoolUser        string `json:"phpPoolUser"`
+	PHPPM              string `json:"phpPm" binding:"omitempty,oneof=dynamic static ondemand"`
+	PHPMaxChildren     int    `json:"phpMaxChildren" binding:"omitempty,min=1"`
+	PHPStartServers    int    `json:"phpStartServers" binding:"omitempty,min=1"`
+	PHPMinSpareServers int    `json:"phpMinSpareServers" binding:"omitempty,min=1"`
+	PHPMaxSpareServers int    `json:"phpMaxSpareServers" binding:"omitempty,min=1"`
+	PHPOpenBasedir     string `json:"phpOpenBasedir"`
+	PHPUploadMaxSize   string `json:"phpUploadMaxSize"`
+
 	// Reverse proxy
 	ProxyPass string `json:"proxyPass"`
 
@@ -40,6 +52,18 @@ type WebsiteUpdate struct {
 	HttpPort  int `json:"httpPort"`
 	HttpsPort int `json:"httpsPort"`
 
+	// PHP
+	PHPVersion         string `json:"phpVersion"`
+	PHPFramework       string `json:"phpFramework" binding:"omitempty,oneof=none wordpress laravel thinkphp"`
+	PHPPoolUser        string `json:"phpPoolUser"`
+	PHPPM              string `json:"phpPm" binding:"omitempty,oneof=dynamic static ondemand"`
+	PHPMaxChildren     int    `json:"phpMaxChildren" binding:"omitempty,min=1"`
+	PHPStartServers    int    `json:"phpStartServers" binding:"omitempty,min=1"`
+	PHPMinSpareServers int    `json:"phpMinSpareServers" binding:"omitempty,min=1"`
+	PHPMaxSpareServers int    `json:"phpMaxSpareServers" binding:"omitempty,min=1"`
+	PHPOpenBasedir     string `json:"phpOpenBasedir"`
+	PHPUploadMaxSize   string `json:"phpUploadMaxSize"`
+
 	// Reverse proxy
 	ProxyPass string `json:"proxyPass"`
 	WebSocket bool   `json:"webSocket"`
@@ -51,6 +75,8 @@ type WebsiteUpdate struct {
 	HSTS          bool   `json:"hsts"`
 	Http2Enable   bool   `json:"http2Enable"`
 	SSLProtocols  string `json:"sslProtocols"`
+	ClientVerify  string `json:"clientVerify" binding:"omitempty,oneof=off on optional"`
+	ClientCAID    uint   `json:"clientCAID"`
 
 	// Security
 	BasicAuth     bool   `json:"basicAuth"`
@@ -86,6 +112,9 @@ type WebsiteUpdate struct {
 	DefaultServer bool   `json:"defaultServer"`
 	Remark        string `json:"remark"`
 
+	// 关联的数据库实例，克隆网站时可一并克隆；不传时保持原关联，传 0 解除关联
+	DatabaseID *uint `json:"databaseID"`
+
 	// Config mode
 	ConfigMode string `json:"configMode"`
 }
@@ -113,8 +142,10 @@ type WebsiteInfo struct {
 	Remark                string                     `json:"remark"`
 	SiteDir               string                     `json:"siteDir"`
 	NginxConfPath         string                     `json:"nginxConfPath"`
+	StagingOf             uint                       `json:"stagingOf"`
 	ConfigActive          bool                       `json:"configActive"`
 	ConfigIssues          []string                   `json:"configIssues"`
+	ConfigDrift           bool                       `json:"configDrift"` // 磁盘配置被面板外修改
 	ConfiguredCertificate *CertificateHealthSnapshot `json:"configuredCertificate,omitempty"`
 	CreatedAt             time.Time                  `json:"createdAt"`
 }
@@ -134,6 +165,17 @@ type WebsiteDetail struct {
 	HttpPort  int `json:"httpPort"`
 	HttpsPort int `json:"httpsPort"`
 
+	PHPVersion         string `json:"phpVersion"`
+	PHPFramework       string `json:"phpFramework"`
+	PHPPoolUser        string `json:"phpPoolUser"`
+	PHPPM              string `json:"phpPm"`
+	PHPMaxChildren     int    `json:"phpMaxChildren"`
+	PHPStartServers    int    `json:"phpStartServers"`
+	PHPMinSpareServers int    `json:"phpMinSpareServers"`
+	PHPMaxSpareServers int    `json:"phpMaxSpareServers"`
+	PHPOpenBasedir     string `json:"phpOpenBasedir"`
+	PHPUploadMaxSize   string `json:"phpUploadMaxSize"`
+
 	ProxyPass string `json:"proxyPass"`
 	WebSocket bool   `json:"webSocket"`
 
@@ -143,6 +185,8 @@ type WebsiteDetail struct {
 	HSTS          bool   `json:"hsts"`
 	Http2Enable   bool   `json:"http2Enable"`
 	SSLProtocols  string `json:"sslProtocols"`
+	ClientVerify  string `json:"clientVerify"`
+	ClientCAID    uint   `json:"clientCAID"`
 
 	BasicAuth        bool   `json:"basicAuth"`
 	BasicUser        string `json:"basicUser"`
@@ -172,6 +216,9 @@ type WebsiteDetail struct {
 	DefaultServer bool   `json:"defaultServer"`
 	Remark        string `json:"remark"`
 
+	StagingOf  uint `json:"stagingOf"`
+	DatabaseID uint `json:"databaseID"`
+
 	ConfigMode            string                     `json:"configMode"`
 	NginxConfPath         string                     `json:"nginxConfPath"`
 	ConfigActive          bool                       `json:"configActive"`
@@ -359,3 +406,293 @@ type WebsiteLogAlert struct {
 	Message string `json:"message"`
 	Count   int64  `json:"count"`
 }
+
+// --- PHP 运行时 ---
+
+type PHPVersionInfo struct {
+	Version string `json:"version"`
+	Binary  string `json:"binary"`
+	PoolDir string `json:"poolDir"`
+	Service string `json:"service"`
+}
+
+// --- 结构化 location ---
+
+type WebsiteLocationHeader struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value"`
+}
+
+type WebsiteLocationItem struct {
+	ID    uint   `json:"id"`
+	Path  string `json:"path" binding:"required"`
+	Match string `json:"match" binding:"omitempty,oneof=prefix priority exact regex regex_i"`
+	Type  string `json:"type" binding:"required,oneof=proxy static"`
+	Sort  int    `json:"sort"`
+
+	ProxyPass        string `json:"proxyPass"`
+	UpstreamID       uint   `json:"upstreamID"`
+	ProxyHost        string `json:"proxyHost"`
+	WebSocket        bool   `json:"webSocket"`
+	ConnectTimeout   int    `json:"connectTimeout" binding:"min=0"`
+	SendTimeout      int    `json:"sendTimeout" binding:"min=0"`
+	ReadTimeout      int    `json:"readTimeout" binding:"min=0"`
+	DisableBuffering bool   `json:"disableBuffering"`
+
+	RequestHeaders  []WebsiteLocationHeader `json:"requestHeaders" binding:"dive"`
+	ResponseHeaders []WebsiteLocationHeader `json:"responseHeaders" binding:"dive"`
+
+	CacheEnable bool   `json:"cacheEnable"`
+	CacheValid  string `json:"cacheValid"`
+
+	Root string `json:"root"`
+
+	ClientMaxBodySize string `json:"clientMaxBodySize"`
+	Remark            string `json:"remark"`
+}
+
+type WebsiteLocationSave struct {
+	WebsiteID uint                  `json:"websiteID" binding:"required"`
+	Locations []WebsiteLocationItem `json:"locations" binding:"dive"`
+}
+
+// --- 结构化 upstream ---
+
+type WebsiteUpstreamServer struct {
+	Address     string `json:"address" binding:"required"`
+	Weight      int    `json:"weight" binding:"min=0"`
+	MaxFails    int    `json:"maxFails" binding:"min=0"`
+	FailTimeout int    `json:"failTimeout" binding:"min=0"`
+	Backup      bool   `json:"backup"`
+	Down        bool   `json:"down"`
+}
+
+type WebsiteUpstreamItem struct {
+	ID         uint                    `json:"id"`
+	Name       string                  `json:"name" binding:"required"`
+	Balance    string                  `json:"balance" binding:"omitempty,oneof=round_robin least_conn ip_hash hash"`
+	HashKey    string                  `json:"hashKey"`
+	Keepalive  int                     `json:"keepalive" binding:"min=0"`
+	HealthPath string                  `json:"healthPath"`
+	Servers    []WebsiteUpstreamServer `json:"servers" binding:"required,min=1,dive"`
+	Remark     string                  `json:"remark"`
+}
+
+type WebsiteUpstreamSave struct {
+	WebsiteID uint                  `json:"websiteID" binding:"required"`
+	Upstreams []WebsiteUpstreamItem `json:"upstreams" binding:"dive"`
+}
+
+type WebsiteUpstreamServerHealth struct {
+	Address string `json:"address"`
+	Backup  bool   `json:"backup"`
+	Down    bool   `json:"down"`
+
+	// 主动探测
+	Reachable  bool   `json:"reachable"`
+	StatusCode int    `json:"statusCode"`
+	LatencyMS  int64  `json:"latencyMs"`
+	ProbeError string `json:"probeError"`
+
+	// 被动统计（最近的错误日志）
+	Failures      int64  `json:"failures"`
+	LastFailureAt string `json:"lastFailureAt"`
+	LastFailure   string `json:"lastFailure"`
+}
+
+type WebsiteUpstreamHealth struct {
+	ID        uint                          `json:"id"`
+	Name      string                        `json:"name"`
+	NoLive    int64                         `json:"noLive"`
+	Servers   []WebsiteUpstreamServerHealth `json:"servers"`
+	CheckedAt time.Time                     `json:"checkedAt"`
+}
+
+// --- WAF ---
+
+type WebsiteWAFRuleSet struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Target      string `json:"target"` // uri | user_agent
+}
+
+type WebsiteWAFConfig struct {
+	WebsiteID uint     `json:"websiteID" binding:"required"`
+	Enabled   bool     `json:"enabled"`
+	Rules     []string `json:"rules"`
+
+	IPWhitelist      []string `json:"ipWhitelist"`
+	IPBlacklist      []string `json:"ipBlacklist"`
+	UABlacklist      []string `json:"uaBlacklist"`
+	URLWhitelist     []string `json:"urlWhitelist"`
+	URLBlacklist     []string `json:"urlBlacklist"`
+	CountryWhitelist []string `json:"countryWhitelist"`
+	CountryBlacklist []string `json:"countryBlacklist"`
+
+	CCEnable bool `json:"ccEnable"`
+	CCRate   int  `json:"ccRate" binding:"min=0"`
+	CCBurst  int  `json:"ccBurst" binding:"min=0"`
+}
+
+type WebsiteWAFLogSearch struct {
+	PageInfo
+	WebsiteID uint   `json:"websiteID" binding:"required"`
+	IP        string `json:"ip"`
+	Reason    string `json:"reason"`
+	Keyword   string `json:"keyword"` // 匹配 URI 与 User-Agent
+}
+
+type WebsiteWAFLogItem struct {
+	Time      time.Time `json:"time"`
+	IP        string    `json:"ip"`
+	Country   string    `json:"country"`
+	Method    string    `json:"method"`
+	URI       string    `json:"uri"`
+	Status    int       `json:"status"`
+	UserAgent string    `json:"userAgent"`
+	Reason    string    `json:"reason"`
+	Banned    bool      `json:"banned"`
+}
+
+type WebsiteWAFBanReq struct {
+	IP   string `json:"ip" binding:"required"`
+	Jail string `json:"jail"`
+}
+
+// --- 限速与地域访问控制 ---
+
+type WebsiteRateLimitPath struct {
+	Path    string `json:"path" binding:"required"`
+	Rate    int    `json:"rate" binding:"required,min=1"`
+	Unit    string `json:"unit" binding:"omitempty,oneof=r/s r/m"`
+	Burst   int    `json:"burst" binding:"min=0"`
+	Nodelay bool   `json:"nodelay"`
+}
+
+type WebsiteGeoRule struct {
+	Type   string `json:"type" binding:"required,oneof=cidr country"`
+	Value  string `json:"value" binding:"required"`
+	Action string `json:"action" binding:"required,oneof=allow deny"`
+}
+
+type WebsiteAccessConfig struct {
+	WebsiteID uint `json:"websiteID" binding:"required"`
+
+	LimitReqEnable  bool                   `json:"limitReqEnable"`
+	LimitReqRate    int                    `json:"limitReqRate" binding:"min=0"`
+	LimitReqUnit    string                 `json:"limitReqUnit" binding:"omitempty,oneof=r/s r/m"`
+	LimitReqBurst   int                    `json:"limitReqBurst" binding:"min=0"`
+	LimitReqNodelay bool                   `json:"limitReqNodelay"`
+	LimitReqPaths   []WebsiteRateLimitPath `json:"limitReqPaths" binding:"dive"`
+
+	GeoEnable  bool             `json:"geoEnable"`
+	GeoDefault string           `json:"geoDefault" binding:"omitempty,oneof=allow deny"`
+	GeoRules   []WebsiteGeoRule `json:"geoRules" binding:"dive"`
+}
+
+// --- 克隆与推送 ---
+
+type WebsiteClone struct {
+	ID            uint   `json:"id" binding:"required"`
+	PrimaryDomain string `json:"primaryDomain" binding:"required"`
+	Alias         string `json:"alias"`
+	SiteDir       string `json:"siteDir"` // 空为 /var/www/<alias>
+
+	// copy 复制来源网站当前目录；snapshot 从来源网站的备份记录解压
+	FileSource     string `json:"fileSource" binding:"omitempty,oneof=copy snapshot"`
+	BackupRecordID uint   `json:"backupRecordID"`
+	BackupPassword string `json:"backupPassword"`
+
+	// 克隆来源网站关联的数据库，DatabaseName 为空时使用 <原库名>_staging
+	CloneDatabase bool   `json:"cloneDatabase"`
+	DatabaseName  string `json:"databaseName"`
+}
+
+type WebsiteCloneResult struct {
+	ID            uint   `json:"id"`
+	PrimaryDomain string `json:"primaryDomain"`
+	SiteDir       string `json:"siteDir"`
+
+	DatabaseID       uint   `json:"databaseID"`
+	DatabaseName     string `json:"databaseName"`
+	DatabaseUser     string `json:"databaseUser"`
+	DatabasePassword string `json:"databasePassword"` // 仅在克隆时返回一次
+	// 已改为使用克隆库的站点配置文件
+	DatabaseConfigFiles []string `json:"databaseConfigFiles"`
+}
+
+type WebsitePromote struct {
+	ID              uint     `json:"id" binding:"required"`
+	BackupAccountID uint     `json:"backupAccountID" binding:"required"`
+	Excludes        []string `json:"excludes"`    // 不推送的相对路径或通配符，如 .env、wp-config.php
+	DeleteExtra     bool     `json:"deleteExtra"` // 删除生产目录中 staging 不存在的文件
+}
+
+type WebsitePromoteResult struct {
+	ProductionID uint   `json:"productionID"`
+	BackupPath   string `json:"backupPath"`
+	Copied       int    `json:"copied"`
+	Removed      int    `json:"removed"`
+}
+
+// --- Git 部署 ---
+
+type WebsiteDeployConfig struct {
+	WebsiteID    uint   `json:"websiteID" binding:"required"`
+	Enabled      bool   `json:"enabled"`
+	RepoURL      string `json:"repoURL"`
+	Branch       string `json:"branch"`
+	SSHKey       string `json:"sshKey"`
+	BuildCommand string `json:"buildCommand"`
+	PublishDir   string `json:"publishDir"`
+	KeepReleases int    `json:"keepReleases" binding:"min=0,max=50"`
+	ResetSecret  bool   `json:"resetSecret"` // 重新生成 webhook 密钥
+}
+
+type WebsiteDeployRelease struct {
+	Name      string    `json:"name"`
+	Current   bool      `json:"current"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type WebsiteDeployInfo struct {
+	WebsiteDeployConfig
+	CurrentRelease string                 `json:"currentRelease"`
+	WebhookPath    string                 `json:"webhookPath"`
+	WebhookSecret  string                 `json:"webhookSecret,omitempty"` // 仅在生成时返回一次
+	HasSecret      bool                   `json:"hasSecret"`
+	Releases       []WebsiteDeployRelease `json:"releases"`
+}
+
+type WebsiteDeployRecordSearch struct {
+	PageInfo
+	WebsiteID uint `json:"websiteID" binding:"required"`
+}
+
+type WebsiteDeployRollback struct {
+	WebsiteID uint   `json:"websiteID" binding:"required"`
+	Release   string `json:"release" binding:"required"`
+}
+
+// --- 配置漂移 ---
+
+type WebsiteConfigDriftInfo struct {
+	WebsiteID  uint      `json:"websiteID"`
+	ConfPath   string    `json:"confPath"`
+	Drifted    bool      `json:"drifted"`
+	Missing    bool      `json:"missing"`
+	Diff       string    `json:"diff"` // 渲染结果 -> 磁盘文件的 unified diff
+	DetectedAt time.Time `json:"detectedAt"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+type WebsiteConfigDriftResolve struct {
+	WebsiteID uint   `json:"websiteID" binding:"required"`
+	Action    string `json:"action" binding:"required,oneof=adopt overwrite source"`
+}
+
+type WebsiteConfigDriftResolveResult struct {
+	Action  string   `json:"action"`
+	Adopted []string `json:"adopted"` // 并入 CustomNginx 的指令块
+	Dropped []string `json:"dropped"` // 无法并入、随重新渲染被丢弃的改动
+}
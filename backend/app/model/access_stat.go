@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// AccessStat 网站访问统计，由后台采集器增量读取访问日志后按分钟、小时两种粒度累计
+type AccessStat struct {
+	ID        uint      `gorm:"primarykey;autoIncrement" json:"id"`
+	WebsiteID uint      `gorm:"not null;index:idx_access_stat,unique" json:"websiteID"`
+	Period    string    `gorm:"not null;index:idx_access_stat,unique" json:"period"` // minute | hour
+	Timestamp time.Time `gorm:"not null;index:idx_access_stat,unique;index:idx_access_stat_ts" json:"timestamp"`
+	Requests  int64     `gorm:"not null;default:0" json:"requests"`
+	Bytes     int64     `gorm:"not null;default:0" json:"bytes"`
+	Status2xx int64     `gorm:"not null;default:0" json:"status2xx"`
+	Status3xx int64     `gorm:"not null;default:0" json:"status3xx"`
+	Status4xx int64     `gorm:"not null;default:0" json:"status4xx"`
+	Status5xx int64     `gorm:"not null;default:0" json:"status5xx"`
+	Status404 int64     `gorm:"not null;default:0" json:"status404"`
+	Threats   int64     `gorm:"not null;default:0" json:"threats"`
+	Crawlers  int64     `gorm:"not null;default:0" json:"crawlers"`
+	// 经过 upstream 的请求数与响应耗时（秒），日志未使用面板的统计格式时为 0
+	UpstreamCount int64   `gorm:"not null;default:0" json:"upstreamCount"`
+	UpstreamTime  float64 `gorm:"not null;default:0" json:"upstreamTime"`
+	UpstreamMax   float64 `gorm:"not null;default:0" json:"upstreamMax"`
+}
+
+// AccessStatTop 小时粒度的排行（url、ip、ua、threat、threat_ip、crawler），每批只累计批内前若干项
+type AccessStatTop struct {
+	ID        uint      `gorm:"primarykey;autoIncrement" json:"id"`
+	WebsiteID uint      `gorm:"not null;index:idx_access_stat_top,unique" json:"websiteID"`
+	Timestamp time.Time `gorm:"not null;index:idx_access_stat_top,unique;index:idx_access_stat_top_ts" json:"timestamp"`
+	Kind      string    `gorm:"not null;index:idx_access_stat_top,unique" json:"kind"`
+	Name      string    `gorm:"not null;index:idx_access_stat_top,unique" json:"name"`
+	Count     int64     `gorm:"not null;default:0" json:"count"`
+}
+
+// AccessLogCursor 访问日志的读取位置，Inode 变化说明日志已轮转
+type AccessLogCursor struct {
+	ID        uint      `gorm:"primarykey;autoIncrement" json:"id"`
+	WebsiteID uint      `gorm:"not null;uniqueIndex" json:"websiteID"`
+	Path      string    `json:"path"`
+	Inode     uint64    `json:"inode"`
+	Offset    int64     `json:"offset"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// AlertRule 监控阈值告警规则；状态字段由评估任务维护
+type AlertRule struct {
+	BaseModel
+	Name           string  `gorm:"type:varchar(64);not null;uniqueIndex" json:"name"`
+	Metric         string  `gorm:"type:varchar(32);not null" json:"metric"`             // cpu / memory / load / disk / temperature / traffic
+	Target         string  `gorm:"type:varchar(255)" json:"target"`                     // 磁盘挂载点、传感器名或网卡名
+	Operator       string  `gorm:"type:varchar(8);not null;default:gt" json:"operator"` // gt / lt
+	Threshold      float64 `gorm:"not null" json:"threshold"`
+	Hysteresis     float64 `gorm:"not null;default:0" json:"hysteresis"` // 恢复需回落到阈值另一侧的幅度
+	Duration       int     `gorm:"not null;default:0" json:"duration"`   // 持续超过阈值的秒数后才触发
+	SilenceStart   string  `gorm:"type:varchar(8)" json:"silenceStart"`  // 每日静默时段 HH:MM，可跨零点
+	SilenceEnd     string  `gorm:"type:varchar(8)" json:"silenceEnd"`
+	NotifyRecovery bool    `gorm:"not null;default:false" json:"notifyRecovery"`
+	Status         string  `gorm:"type:varchar(32);not null;default:Enable" json:"status"`
+
+	State           string     `gorm:"type:varchar(16);not null;default:ok" json:"state"` // ok / pending / firing
+	LastValue       float64    `json:"lastValue"`
+	PendingSince    *time.Time `json:"pendingSince"`
+	FiredAt         *time.Time `json:"firedAt"`
+	MutedUntil      *time.Time `json:"mutedUntil"`
+	LastEvaluatedAt *time.Time `json:"lastEvaluatedAt"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// APIKey 供自动化调用 REST API 的密钥；仅保存密钥哈希，明文只在创建时返回一次
+type APIKey struct {
+	BaseModel
+	Name        string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"name"`
+	KeyID       string     `gorm:"type:varchar(32);not null;uniqueIndex" json:"keyID"`
+	KeyHash     string     `gorm:"type:varchar(64);not null" json:"-"`
+	Modules     string     `gorm:"type:varchar(512)" json:"modules"`                     // 逗号分隔，为空表示全部业务模块
+	Access      string     `gorm:"type:varchar(16);not null;default:read" json:"access"` // read | write
+	AllowIPs    string     `gorm:"type:text" json:"allowIPs"`                            // 逗号分隔的 IP/CIDR，为空表示不限制
+	Status      string     `gorm:"type:varchar(32);not null;default:Enable" json:"status"`
+	Description string     `json:"description"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+	LastUsedIP  string     `gorm:"type:varchar(64)" json:"lastUsedIP"`
+}
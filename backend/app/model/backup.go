@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type BackupAccount struct {
 	BaseModel
 	Name       string `gorm:"not null" json:"name"`
@@ -8,7 +10,8 @@ type BackupAccount struct {
 	AccessKey  string `json:"-"`
 	Credential string `json:"-"`
 	BackupPath string `json:"backupPath"`
-	Vars       string `json:"vars"` // JSON: region, endpoint, etc.
+	Vars       string `json:"vars"`       // JSON: region, endpoint, etc.
+	QuotaBytes int64  `json:"quotaBytes"` // 备份占用上限，0 表示不限
 }
 
 type BackupRecord struct {
@@ -24,4 +27,12 @@ type BackupRecord struct {
 	SourcePath string `json:"sourcePath"`
 	Status     string `json:"status"` // success / failed
 	Message    string `json:"message"`
+	// 增量备份链：ChainID 为链首全量记录 ID（链首指向自身），ParentID 为上一层记录，Level 0 为全量
+	ChainID  uint `gorm:"index" json:"chainID"`
+	ParentID uint `gorm:"index" json:"parentID"`
+	Level    int  `json:"level"`
+	// 校验任务的试恢复结果
+	VerifyStatus  string     `json:"verifyStatus"` // success / failed，空表示未校验
+	VerifyMessage string     `gorm:"type:text" json:"verifyMessage"`
+	VerifiedAt    *time.Time `json:"verifiedAt"`
 }
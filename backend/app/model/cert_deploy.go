@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// CertificateDeployment 证书部署目标：申请、续签或同步更新证书后，把证书推送到本机服务以外的位置
+//   - ssh：通过主机管理中保存的凭据上传到远程主机，再在远端执行重载命令
+//   - local：按指定格式写入本机任意路径，再在本机执行重载命令
+//   - command：仅执行部署钩子，通过环境变量获取证书文件路径
+type CertificateDeployment struct {
+	BaseModel
+	CertificateID uint   `gorm:"not null;index" json:"certificateID"`
+	Name          string `gorm:"not null" json:"name"`
+	Type          string `gorm:"not null" json:"type"` // ssh | local | command
+	Enabled       bool   `gorm:"not null;default:true" json:"enabled"`
+	HostID        uint   `json:"hostID"`
+
+	// pem 时证书链写入 CertPath、私钥写入 KeyPath；bundle 为私钥+证书链的单个文件；
+	// pkcs12、jks 写入 CertPath 并用 Password 加密，Alias 为 JKS 条目别名
+	Format   string `gorm:"not null;default:pem" json:"format"` // pem | bundle | pkcs12 | jks
+	CertPath string `json:"certPath"`
+	KeyPath  string `json:"keyPath"`
+	Password string `json:"-"`
+	Alias    string `json:"alias"`
+	Command  string `gorm:"type:text" json:"command"` // ssh/local 为写入后的重载命令，command 为部署钩子
+
+	LastDeployAt *time.Time `json:"lastDeployAt"`
+	LastStatus   string     `json:"lastStatus"` // Success | Failed
+	LastMessage  string     `json:"lastMessage"`
+}
+
+// CertificateDeployLog 部署目标的一次执行记录
+type CertificateDeployLog struct {
+	BaseModel
+	DeploymentID  uint      `gorm:"not null;index" json:"deploymentID"`
+	CertificateID uint      `gorm:"not null;index" json:"certificateID"`
+	Trigger       string    `json:"trigger"` // apply | renew | sync | manual
+	Status        string    `json:"status"`  // Success | Failed
+	Log           string    `gorm:"type:text" json:"log"`
+	FinishedAt    time.Time `json:"finishedAt"`
+}
@@ -5,7 +5,7 @@ import "time"
 type Cronjob struct {
 	BaseModel
 	Name                   string `gorm:"not null" json:"name"`
-	Type                   string `gorm:"not null" json:"type"` // shell / website / database / directory / curl / compose
+	Type                   string `gorm:"not null" json:"type"` // shell / website / database / directory / curl / compose / verify
 	Spec                   string `gorm:"not null" json:"spec"`
 	Status                 string `gorm:"default:Enable" json:"status"`
 	EntryID                int    `json:"entryID"`
@@ -17,7 +17,14 @@ type Cronjob struct {
 	DBInstanceID           uint   `gorm:"index" json:"dbInstanceID"`
 	SourceDir              string `json:"sourceDir"`
 	TargetAccountID        uint   `json:"targetAccountID"`
-	RetainCopies           uint   `gorm:"default:7" json:"retainCopies"`
+	RetainCopies           uint   `gorm:"default:7" json:"retainCopies"`        // 增量模式下按备份链计数
+	RetentionPolicy        string `gorm:"default:count" json:"retentionPolicy"` // count 按份数 / gfs 按日周月年
+	KeepDaily              uint   `json:"keepDaily"`
+	KeepWeekly             uint   `json:"keepWeekly"`
+	KeepMonthly            uint   `json:"keepMonthly"`
+	KeepYearly             uint   `json:"keepYearly"`
+	BackupMode             string `gorm:"default:full" json:"backupMode"` // full / incremental，仅网站和目录备份
+	ChainLength            uint   `gorm:"default:7" json:"chainLength"`   // 每条增量链包含的备份数（含全量）
 	ExclusionRules         string `json:"exclusionRules"`
 	CompressFormat         string `gorm:"default:gzip" json:"compressFormat"` // gzip / zstd / xz
 	EncryptPassword        string `json:"-"`
@@ -25,7 +32,9 @@ type Cronjob struct {
 	PreCommand             string `gorm:"type:text" json:"preCommand"`
 	PostCommand            string `gorm:"type:text" json:"postCommand"`
 	ComposeName            string `json:"composeName"`
-	ComposeOperation       string `json:"composeOperation"` // pull / update
+	ComposeOperation       string `json:"composeOperation"`             // pull / update
+	VerifyCronjobID        uint   `json:"verifyCronjobID"`              // 校验哪个备份任务的记录，0 表示全部
+	VerifyCount            uint   `gorm:"default:1" json:"verifyCount"` // 每次校验最近的记录数
 }
 
 type CronjobRecord struct {
@@ -3,23 +3,37 @@ package model
 // LoginLog 登录日志
 type LoginLog struct {
 	BaseModel
-	IP      string `json:"ip" gorm:"type:varchar(64)"`
-	Agent   string `json:"agent" gorm:"type:varchar(512)"`
-	Status  string `json:"status" gorm:"type:varchar(64)"`
-	Message string `json:"message" gorm:"type:varchar(512)"`
+	UserName string `json:"userName" gorm:"type:varchar(64);index"`
+	IP       string `json:"ip" gorm:"type:varchar(64)"`
+	Agent    string `json:"agent" gorm:"type:varchar(512)"`
+	Status   string `json:"status" gorm:"type:varchar(64)"`
+	Message  string `json:"message" gorm:"type:varchar(512)"`
 }
 
 // OperationLog 操作日志
 type OperationLog struct {
 	BaseModel
-	Group   string `json:"group" gorm:"type:varchar(64)"`
-	Source  string `json:"source" gorm:"type:varchar(64)"`
-	Action  string `json:"action" gorm:"type:varchar(64)"`
-	IP      string `json:"ip" gorm:"type:varchar(64)"`
-	Path    string `json:"path" gorm:"type:varchar(256)"`
-	Method  string `json:"method" gorm:"type:varchar(16)"`
-	Body    string `json:"body" gorm:"type:text"`
-	Status  string `json:"status" gorm:"type:varchar(64)"`
-	Message string `json:"message" gorm:"type:text"`
-	Latency string `json:"latency" gorm:"type:varchar(32)"`
+	UserName string `json:"userName" gorm:"type:varchar(64);index"`
+	Group    string `json:"group" gorm:"type:varchar(64)"`
+	Source   string `json:"source" gorm:"type:varchar(64)"`
+	Action   string `json:"action" gorm:"type:varchar(64)"`
+	IP       string `json:"ip" gorm:"type:varchar(64)"`
+	Path     string `json:"path" gorm:"type:varchar(256)"`
+	Method   string `json:"method" gorm:"type:varchar(16)"`
+	Body     string `json:"body" gorm:"type:text"`
+	Status   string `json:"status" gorm:"type:varchar(64)"`
+	Message  string `json:"message" gorm:"type:text"`
+	Latency  string `json:"latency" gorm:"type:varchar(32)"`
+}
+
+// LogRotatePolicy 日志轮转策略；WebsiteID 为 0 的记录是全局策略，其余为网站的单独策略
+type LogRotatePolicy struct {
+	BaseModel
+	WebsiteID uint   `json:"websiteID" gorm:"not null;uniqueIndex"`
+	Enable    bool   `json:"enable"`
+	Interval  string `json:"interval" gorm:"type:varchar(16)"` // daily | weekly | monthly | none
+	MaxSize   int    `json:"maxSize"`                          // MB，0 表示不按大小触发
+	Keep      int    `json:"keep"`                             // 保留的历史文件数
+	MaxAge    int    `json:"maxAge"`                           // 天，0 表示不按时间清理
+	Compress  bool   `json:"compress"`
 }
@@ -14,3 +14,16 @@ type Notification struct {
 	Popup     bool       `gorm:"not null;default:false" json:"popup"`
 	ReadAt    *time.Time `gorm:"index" json:"readAt,omitempty"`
 }
+
+// NotificationChannel 外发通知渠道；Config 为 JSON 配置（含 SMTP 密码、机器人地址等），加密存储
+type NotificationChannel struct {
+	BaseModel
+	Name       string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"name"`
+	Type       string     `gorm:"type:varchar(32);not null" json:"type"` // email / webhook / telegram / dingtalk / feishu / wecom
+	Config     string     `gorm:"type:text" json:"-"`
+	Status     string     `gorm:"type:varchar(32);not null;default:Enable" json:"status"`
+	RateLimit  int        `gorm:"not null;default:20" json:"rateLimit"` // 每分钟最多发送条数
+	LastStatus string     `gorm:"type:varchar(32)" json:"lastStatus"`
+	LastError  string     `json:"lastError"`
+	LastSentAt *time.Time `json:"lastSentAt"`
+}
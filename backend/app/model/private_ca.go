@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// CertificateAuthority 面板管理的私有 CA，为内网服务签发服务端证书和 mTLS 客户端证书
+//   - ParentID 为 0 表示根 CA，否则为挂在该根 CA 下的中间 CA（中间 CA 只签发终端证书）
+//   - 私钥经凭据密钥环加密后入库
+type CertificateAuthority struct {
+	BaseModel
+	Name         string    `gorm:"not null;uniqueIndex" json:"name"`
+	ParentID     uint      `gorm:"not null;default:0;index" json:"parentID"`
+	CommonName   string    `gorm:"not null" json:"commonName"`
+	Organization string    `json:"organization"`
+	KeyType      string    `gorm:"not null" json:"keyType"` // P256 | P384 | 2048 | 3072 | 4096
+	Pem          string    `gorm:"type:text" json:"pem"`
+	PrivateKey   string    `gorm:"type:text" json:"-"`
+	SerialNumber string    `json:"serialNumber"`
+	Fingerprint  string    `json:"fingerprintSHA256"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+
+	// CRLURL 写入签发证书 CRL 分发点的地址，通常为面板公开的 /api/v1/pki/crl/<id>；为空时不写入
+	CRLURL       string     `json:"crlURL"`
+	CRLNumber    int64      `json:"crlNumber"`
+	CRLUpdatedAt *time.Time `json:"crlUpdatedAt"`
+	Description  string     `json:"description"`
+}
+
+// CertificateAuthorityRevocation 私有 CA 的吊销记录，用于生成 CRL。
+// 单独保存是因为被吊销的证书重新签发后，证书记录上的序列号与吊销状态会被新证书覆盖
+type CertificateAuthorityRevocation struct {
+	BaseModel
+	AuthorityID   uint      `gorm:"not null;index" json:"authorityID"`
+	CertificateID uint      `gorm:"index" json:"certificateID"`
+	SerialNumber  string    `gorm:"not null" json:"serialNumber"` // 十进制
+	Reason        int       `json:"reason"`
+	RevokedAt     time.Time `json:"revokedAt"`
+	NotAfter      time.Time `json:"notAfter"` // 证书过期后从 CRL 中移除
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Session 登录会话；JWT 的 jti 对应 TokenID，记录被删除即视为吊销
+type Session struct {
+	BaseModel
+	TokenID    string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	UserName   string    `gorm:"type:varchar(64);not null;index" json:"userName"`
+	IP         string    `gorm:"type:varchar(64)" json:"ip"`
+	Agent      string    `json:"agent"`
+	Remember   bool      `json:"remember"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `gorm:"index" json:"expiresAt"`
+}
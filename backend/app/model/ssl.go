@@ -19,7 +19,7 @@ type AcmeAccount struct {
 type DnsAccount struct {
 	BaseModel
 	Name          string `gorm:"not null" json:"name"`
-	Type          string `gorm:"not null" json:"type"` // CloudFlare | AliYun | DnsPod | TencentCloud | NameSilo | GoDaddy | HuaweiCloud
+	Type          string `gorm:"not null" json:"type"` // CloudFlare | AliYun | DnsPod | TencentCloud | NameSilo | GoDaddy | HuaweiCloud | Route53 | Gandi | PowerDNS | RFC2136 | Exec | HTTPReq
 	Authorization string `gorm:"type:text;not null" json:"-"`
 }
 
@@ -30,9 +30,10 @@ type Certificate struct {
 	PrimaryDomain                string     `gorm:"not null" json:"primaryDomain"`
 	Domains                      string     `json:"domains"`
 	Provider                     string     `gorm:"not null" json:"provider"`               // dns | http | manual
-	Type                         string     `gorm:"not null;default:autoApply" json:"type"` // autoApply | upload
+	Type                         string     `gorm:"not null;default:autoApply" json:"type"` // autoApply | upload | private
 	AcmeAccountID                uint       `json:"acmeAccountID"`
 	DnsAccountID                 uint       `json:"dnsAccountID"`
+	DnsAlias                     string     `json:"dnsAlias"` // 别名模式：TXT 记录写入 _acme-challenge.<DnsAlias>，各域名的 _acme-challenge CNAME 到该记录
 	WebsiteID                    uint       `gorm:"default:0" json:"websiteID"`
 	KeyType                      string     `gorm:"not null;default:2048" json:"keyType"`
 	Pem                          string     `gorm:"type:text" json:"-"`
@@ -43,7 +44,7 @@ type Certificate struct {
 	SerialNumber                 string     `json:"serialNumber"`
 	Fingerprint                  string     `gorm:"index" json:"fingerprintSHA256"`
 	DNSNames                     string     `gorm:"type:text" json:"dnsNames"`
-	SourceType                   string     `json:"sourceType"` // acme | upload | synced
+	SourceType                   string     `json:"sourceType"` // acme | upload | synced | private
 	SourceID                     uint       `json:"sourceID"`
 	SourceName                   string     `json:"sourceName"`
 	AutoRenew                    bool       `json:"autoRenew"`
@@ -51,11 +52,22 @@ type Certificate struct {
 	UpstreamAutoRenew            bool       `gorm:"default:false" json:"upstreamAutoRenew"`
 	UpstreamRenewalMetadataKnown bool       `gorm:"default:false" json:"upstreamRenewalMetadataKnown"`
 	UpstreamNextAutoRenewAt      *time.Time `json:"upstreamNextAutoRenewAt"`
+	RenewalWindowStart           *time.Time `json:"renewalWindowStart"` // CA 通过 ARI 建议的续签窗口
+	RenewalWindowEnd             *time.Time `json:"renewalWindowEnd"`
+	RenewalScheduledAt           *time.Time `json:"renewalScheduledAt"` // 在 ARI 窗口内随机选定的续签时间
+	RenewalInfoCheckAt           *time.Time `json:"renewalInfoCheckAt"` // 下次查询 ARI 的时间，遵循 CA 的 Retry-After
+	RenewalExplanationURL        string     `json:"renewalExplanationURL"`
+	RevocationStatus             string     `json:"revocationStatus"` // good | revoked | unknown，空表示尚未检查
+	RevocationReason             int        `json:"revocationReason"`
+	RevokedAt                    *time.Time `json:"revokedAt"`
+	RevocationCheckedAt          *time.Time `json:"revocationCheckedAt"`
 	ExpireDate                   time.Time  `json:"expireDate"`
 	StartDate                    time.Time  `json:"startDate"`
 	Status                       string     `gorm:"default:ready" json:"status"` // ready | applying | applied | error
 	Message                      string     `json:"message"`
 	Description                  string     `json:"description"`
+	AuthorityID                  uint       `gorm:"default:0;index" json:"authorityID"` // 私有 CA 签发的证书所属 CA
+	Usage                        string     `json:"usage"`                              // 私有 CA 签发的证书用途：server | client
 
 	AcmeAccount AcmeAccount `json:"acmeAccount" gorm:"-:migration"`
 	DnsAccount  DnsAccount  `json:"dnsAccount" gorm:"-:migration"`
@@ -0,0 +1,17 @@
+package model
+
+// User 面板子账户；内置管理员仍保存在 Setting 的 UserName/Password 中
+type User struct {
+	BaseModel
+	Name             string `gorm:"type:varchar(64);not null;uniqueIndex" json:"name"`
+	Password         string `gorm:"not null" json:"-"`
+	Role             string `gorm:"type:varchar(32);not null;default:readonly" json:"role"` // admin | operator | readonly
+	Modules          string `gorm:"type:varchar(512)" json:"modules"`                       // 逗号分隔，为空表示全部业务模块
+	Status           string `gorm:"type:varchar(32);not null;default:Enable" json:"status"`
+	Description      string `json:"description"`
+	MFAStatus        string `gorm:"column:mfa_status;type:varchar(32);default:Disable" json:"mfaStatus"`
+	MFASecret        string `gorm:"column:mfa_secret" json:"-"`
+	MFAPendingSecret string `gorm:"column:mfa_pending_secret" json:"-"`
+	MFARecoveryCodes string `gorm:"column:mfa_recovery_codes;type:text" json:"-"`
+	MFALastStep      int64  `gorm:"column:mfa_last_step;default:0" json:"-"`
+}
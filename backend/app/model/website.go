@@ -1,11 +1,13 @@
 package model
 
+import "time"
+
 type Website struct {
 	BaseModel
 	PrimaryDomain string `gorm:"not null;uniqueIndex" json:"primaryDomain"`
 	Domains       string `gorm:"type:text" json:"domains"`
 	Alias         string `gorm:"not null;uniqueIndex" json:"alias"`
-	Type          string `gorm:"not null;default:static" json:"type"`    // static | reverse_proxy
+	Type          string `gorm:"not null;default:static" json:"type"`    // static | reverse_proxy | php
 	Status        string `gorm:"not null;default:stopped" json:"status"` // running | stopped
 
 	// Listen ports (0 = default: 80 / 443)
@@ -15,6 +17,18 @@ type Website struct {
 	SiteDir   string `json:"siteDir"`
 	IndexFile string `gorm:"default:'index.html index.htm'" json:"indexFile"`
 
+	// PHP site: php-fpm pool per site (0 / empty = default)
+	PHPVersion         string `json:"phpVersion"`
+	PHPFramework       string `json:"phpFramework"` // none | wordpress | laravel | thinkphp
+	PHPPoolUser        string `json:"phpPoolUser"`
+	PHPPM              string `json:"phpPm"` // dynamic | static | ondemand
+	PHPMaxChildren     int    `json:"phpMaxChildren"`
+	PHPStartServers    int    `json:"phpStartServers"`
+	PHPMinSpareServers int    `json:"phpMinSpareServers"`
+	PHPMaxSpareServers int    `json:"phpMaxSpareServers"`
+	PHPOpenBasedir     string `json:"phpOpenBasedir"`
+	PHPUploadMaxSize   string `json:"phpUploadMaxSize"`
+
 	// Reverse proxy
 	ProxyPass string `json:"proxyPass"`
 	WebSocket bool   `gorm:"default:false" json:"webSocket"`
@@ -26,6 +40,8 @@ type Website struct {
 	HSTS          bool   `gorm:"default:false" json:"hsts"`
 	Http2Enable   bool   `gorm:"default:true" json:"http2Enable"`
 	SSLProtocols  string `gorm:"default:'TLSv1.2 TLSv1.3'" json:"sslProtocols"`
+	ClientVerify  string `gorm:"default:off" json:"clientVerify"` // off | on | optional，要求客户端出示 ClientCAID 签发的证书
+	ClientCAID    uint   `json:"clientCAID"`
 
 	// Security
 	BasicAuth     bool   `gorm:"default:false" json:"basicAuth"`
@@ -66,7 +82,145 @@ type Website struct {
 	DefaultServer bool   `gorm:"default:false" json:"defaultServer"`
 	Remark        string `json:"remark"`
 
+	// Staging: StagingOf = production site this one was cloned from; DatabaseID = linked database instance
+	StagingOf  uint `gorm:"default:0;index" json:"stagingOf"`
+	DatabaseID uint `gorm:"default:0" json:"databaseID"`
+
 	// Config management mode: "managed" (DB-driven) or "source" (direct file edit)
 	ConfigMode    string `gorm:"default:'managed'" json:"configMode"`
 	NginxConfPath string `gorm:"index" json:"nginxConfPath"`
 }
+
+// WebsiteLocation 网站的结构化 location，按 Sort 顺序渲染到 server 块中
+type WebsiteLocation struct {
+	BaseModel
+	WebsiteID uint   `gorm:"not null;index" json:"websiteID"`
+	Path      string `gorm:"not null" json:"path"`
+	Match     string `gorm:"not null;default:prefix" json:"match"` // prefix | priority(^~) | exact(=) | regex(~) | regex_i(~*)
+	Type      string `gorm:"not null;default:proxy" json:"type"`   // proxy | static
+	Sort      int    `gorm:"default:0" json:"sort"`
+
+	// Proxy：UpstreamID 非 0 时代理到该站点的结构化 upstream，否则使用 ProxyPass
+	ProxyPass        string `json:"proxyPass"`
+	UpstreamID       uint   `json:"upstreamID"`
+	ProxyHost        string `json:"proxyHost"` // Host 请求头，空为 $host
+	WebSocket        bool   `json:"webSocket"`
+	ConnectTimeout   int    `json:"connectTimeout"` // 秒，0 为默认值
+	SendTimeout      int    `json:"sendTimeout"`
+	ReadTimeout      int    `json:"readTimeout"`
+	DisableBuffering bool   `json:"disableBuffering"`
+
+	// Header rewrites JSON: [{"name":"X-Env","value":"prod"}]，空值表示移除
+	RequestHeaders  string `gorm:"type:text" json:"requestHeaders"`
+	ResponseHeaders string `gorm:"type:text" json:"responseHeaders"`
+
+	// proxy_cache
+	CacheEnable bool   `json:"cacheEnable"`
+	CacheValid  string `json:"cacheValid"` // 如 10m
+
+	// Static
+	Root string `json:"root"`
+
+	ClientMaxBodySize string `json:"clientMaxBodySize"`
+	Remark            string `json:"remark"`
+}
+
+// WebsiteUpstream 网站的结构化 upstream，渲染为 upstream <alias>_<name> 块
+type WebsiteUpstream struct {
+	BaseModel
+	WebsiteID  uint   `gorm:"not null;index" json:"websiteID"`
+	Name       string `gorm:"not null" json:"name"`
+	Balance    string `gorm:"not null;default:round_robin" json:"balance"` // round_robin | least_conn | ip_hash | hash
+	HashKey    string `json:"hashKey"`                                     // hash 模式的键，如 $request_uri
+	Keepalive  int    `json:"keepalive"`
+	HealthPath string `json:"healthPath"` // 主动探测的 HTTP 路径，空为仅探测 TCP 连接
+
+	// Servers JSON: [{"address":"10.0.0.1:8080","weight":1,"maxFails":3,"failTimeout":10,"backup":false,"down":false}]
+	Servers string `gorm:"type:text" json:"servers"`
+	Remark  string `json:"remark"`
+}
+
+// WebsiteWAF 网站的 WAF 配置，渲染为 nginx map/geo 规则链；列表字段均为换行分隔
+type WebsiteWAF struct {
+	BaseModel
+	WebsiteID uint   `gorm:"not null;uniqueIndex" json:"websiteID"`
+	Enabled   bool   `json:"enabled"`
+	Rules     string `json:"rules"` // 启用的内置规则集，逗号分隔：sqli,xss,traversal,...
+
+	IPWhitelist      string `gorm:"type:text" json:"ipWhitelist"` // IP 或 CIDR
+	IPBlacklist      string `gorm:"type:text" json:"ipBlacklist"`
+	UABlacklist      string `gorm:"type:text" json:"uaBlacklist"` // 不区分大小写的正则
+	URLWhitelist     string `gorm:"type:text" json:"urlWhitelist"`
+	URLBlacklist     string `gorm:"type:text" json:"urlBlacklist"`
+	CountryWhitelist string `json:"countryWhitelist"` // ISO 3166 国家代码
+	CountryBlacklist string `json:"countryBlacklist"`
+
+	// CC 防护：按客户端 IP 的 limit_req，白名单 IP 不计数
+	CCEnable bool `json:"ccEnable"`
+	CCRate   int  `json:"ccRate"` // 每秒请求数
+	CCBurst  int  `json:"ccBurst"`
+}
+
+// WebsiteAccess 网站的请求限速与地域访问控制；限速 zone 写入面板共享的 http 级 include
+type WebsiteAccess struct {
+	BaseModel
+	WebsiteID uint `gorm:"not null;uniqueIndex" json:"websiteID"`
+
+	// limit_req：全站限速，LimitReqPaths 中的路径使用各自的 zone 并不再计入全站限速
+	LimitReqEnable  bool   `json:"limitReqEnable"`
+	LimitReqRate    int    `json:"limitReqRate"`
+	LimitReqUnit    string `gorm:"default:r/s" json:"limitReqUnit"` // r/s | r/m
+	LimitReqBurst   int    `json:"limitReqBurst"`
+	LimitReqNodelay bool   `json:"limitReqNodelay"`
+	// LimitReqPaths JSON: [{"path":"/login","rate":10,"unit":"r/m","burst":5,"nodelay":false}]
+	LimitReqPaths string `gorm:"type:text" json:"limitReqPaths"`
+
+	// 地域访问控制：按规则匹配客户端地址，未命中时使用 GeoDefault
+	GeoEnable  bool   `json:"geoEnable"`
+	GeoDefault string `gorm:"default:allow" json:"geoDefault"` // allow | deny
+	// GeoRules JSON: [{"type":"country","value":"CN","action":"allow"},{"type":"cidr","value":"10.0.0.0/8","action":"deny"}]
+	GeoRules string `gorm:"type:text" json:"geoRules"`
+}
+
+// WebsiteDeploy 静态网站的 Git 部署配置：每次部署发布到 releases/<时间戳>，
+// 再原子切换 SiteDir/current 软链接
+type WebsiteDeploy struct {
+	BaseModel
+	WebsiteID uint `gorm:"not null;uniqueIndex" json:"websiteID"`
+	Enabled   bool `json:"enabled"`
+
+	RepoURL string `json:"repoURL"`
+	Branch  string `gorm:"default:main" json:"branch"`
+	SSHKey  string `json:"sshKey"` // ssh_key 中的密钥名称，HTTPS 公共仓库留空
+
+	// 构建命令在仓库目录中以 sh -c 执行，PublishDir 为发布的子目录（如 dist），空为仓库根目录
+	BuildCommand string `gorm:"type:text" json:"buildCommand"`
+	PublishDir   string `json:"publishDir"`
+	KeepReleases int    `gorm:"default:5" json:"keepReleases"`
+
+	WebhookSecret  string `json:"-"`
+	CurrentRelease string `json:"currentRelease"`
+}
+
+// WebsiteDeployRecord 一次部署或回滚的记录
+type WebsiteDeployRecord struct {
+	BaseModel
+	WebsiteID  uint      `gorm:"index" json:"websiteID"`
+	Trigger    string    `json:"trigger"` // manual | webhook | rollback
+	Status     string    `json:"status"`  // Running | Success | Failed
+	Release    string    `json:"release"`
+	Commit     string    `json:"commit"`
+	CommitMsg  string    `json:"commitMsg"`
+	Log        string    `gorm:"type:text" json:"log"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// WebsiteConfigDrift 托管网站磁盘上的 nginx 配置与面板渲染结果不一致的记录，一致时删除
+type WebsiteConfigDrift struct {
+	BaseModel
+	WebsiteID  uint      `gorm:"not null;uniqueIndex" json:"websiteID"`
+	Missing    bool      `json:"missing"`               // 配置文件已被删除
+	Diff       string    `gorm:"type:text" json:"diff"` // 渲染结果 -> 磁盘文件的 unified diff
+	DetectedAt time.Time `json:"detectedAt"`            // 首次发现漂移的时间
+	CheckedAt  time.Time `json:"checkedAt"`
+}
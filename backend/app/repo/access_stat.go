@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"time"
+
+	"xpanel/app/model"
+
+	"gorm.io/gorm"
+)
+
+type IAccessStatRepo interface {
+	// Aggregates
+	AddStats(items []model.AccessStat) error
+	AddTops(items []model.AccessStatTop) error
+	ListStats(websiteID uint, period string, start, end time.Time) ([]model.AccessStat, error)
+	SumTops(websiteID uint, kind string, start, end time.Time, limit int) ([]model.AccessStatTop, error)
+	CountTopNames(websiteID uint, kind string, start, end time.Time) (int64, error)
+	DeleteStatsBefore(period string, t time.Time) error
+	DeleteTopsBefore(t time.Time) error
+
+	// Cursor
+	GetCursor(websiteID uint) (model.AccessLogCursor, error)
+	SaveCursor(item *model.AccessLogCursor) error
+
+	DeleteByWebsite(websiteID uint) error
+}
+
+func NewIAccessStatRepo() IAccessStatRepo { return &AccessStatRepo{} }
+
+type AccessStatRepo struct{}
+
+// AddStats 将一批增量累加到对应的统计行，行不存在时创建
+func (r *AccessStatRepo) AddStats(items []model.AccessStat) error {
+	return getDB().Transaction(func(tx *gorm.DB) error {
+		for i := range items {
+			item := items[i]
+			result := tx.Model(&model.AccessStat{}).
+				Where("website_id = ? AND period = ? AND timestamp = ?", item.WebsiteID, item.Period, item.Timestamp).
+				Updates(map[string]interface{}{
+					"requests":       gorm.Expr("requests + ?", item.Requests),
+					"bytes":          gorm.Expr("bytes + ?", item.Bytes),
+					"status2xx":      gorm.Expr("status2xx + ?", item.Status2xx),
+					"status3xx":      gorm.Expr("status3xx + ?", item.Status3xx),
+					"status4xx":      gorm.Expr("status4xx + ?", item.Status4xx),
+					"status5xx":      gorm.Expr("status5xx + ?", item.Status5xx),
+					"status404":      gorm.Expr("status404 + ?", item.Status404),
+					"threats":        gorm.Expr("threats + ?", item.Threats),
+					"crawlers":       gorm.Expr("crawlers + ?", item.Crawlers),
+					"upstream_count": gorm.Expr("upstream_count + ?", item.UpstreamCount),
+					"upstream_time":  gorm.Expr("upstream_time + ?", item.UpstreamTime),
+					"upstream_max":   gorm.Expr("MAX(upstream_max, ?)", item.UpstreamMax),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				if err := tx.Create(&item).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (r *AccessStatRepo) AddTops(items []model.AccessStatTop) error {
+	return getDB().Transaction(func(tx *gorm.DB) error {
+		for i := range items {
+			item := items[i]
+			result := tx.Model(&model.AccessStatTop{}).
+				Where("website_id = ? AND timestamp = ? AND kind = ? AND name = ?", item.WebsiteID, item.Timestamp, item.Kind, item.Name).
+				Update("count", gorm.Expr("count + ?", item.Count))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				if err := tx.Create(&item).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (r *AccessStatRepo) ListStats(websiteID uint, period string, start, end time.Time) ([]model.AccessStat, error) {
+	var items []model.AccessStat
+	err := getDB().
+		Where("website_id = ? AND period = ? AND timestamp >= ? AND timestamp < ?", websiteID, period, start, end).
+		Order("timestamp ASC").
+		Find(&items).Error
+	return items, err
+}
+
+// SumTops 汇总时间范围内某类排行，按次数倒序返回前 limit 项
+func (r *AccessStatRepo) SumTops(websiteID uint, kind string, start, end time.Time, limit int) ([]model.AccessStatTop, error) {
+	var items []model.AccessStatTop
+	err := getDB().Model(&model.AccessStatTop{}).
+		Select("name, SUM(count) AS count").
+		Where("website_id = ? AND kind = ? AND timestamp >= ? AND timestamp < ?", websiteID, kind, start, end).
+		Group("name").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&items).Error
+	return items, err
+}
+
+func (r *AccessStatRepo) CountTopNames(websiteID uint, kind string, start, end time.Time) (int64, error) {
+	var count int64
+	err := getDB().Model(&model.AccessStatTop{}).
+		Where("website_id = ? AND kind = ? AND timestamp >= ? AND timestamp < ?", websiteID, kind, start, end).
+		Distinct("name").
+		Count(&count).Error
+	return count, err
+}
+
+func (r *AccessStatRepo) DeleteStatsBefore(period string, t time.Time) error {
+	return getDB().Where("period = ? AND timestamp < ?", period, t).Delete(&model.AccessStat{}).Error
+}
+
+func (r *AccessStatRepo) DeleteTopsBefore(t time.Time) error {
+	return getDB().Where("timestamp < ?", t).Delete(&model.AccessStatTop{}).Error
+}
+
+// GetCursor 返回网站访问日志的读取位置，未采集过时返回零值且不报错
+func (r *AccessStatRepo) GetCursor(websiteID uint) (model.AccessLogCursor, error) {
+	var item model.AccessLogCursor
+	err := getDB().Where("website_id = ?", websiteID).Limit(1).Find(&item).Error
+	item.WebsiteID = websiteID
+	return item, err
+}
+
+func (r *AccessStatRepo) SaveCursor(item *model.AccessLogCursor) error {
+	return getDB().Save(item).Error
+}
+
+func (r *AccessStatRepo) DeleteByWebsite(websiteID uint) error {
+	return getDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("website_id = ?", websiteID).Delete(&model.AccessStat{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("website_id = ?", websiteID).Delete(&model.AccessStatTop{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("website_id = ?", websiteID).Delete(&model.AccessLogCursor{}).Error
+	})
+}
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"xpanel/app/model"
+)
+
+// IAlertRuleRepo 告警规则仓库接口
+type IAlertRuleRepo interface {
+	GetList(opts ...DBOption) ([]model.AlertRule, error)
+	Get(opts ...DBOption) (model.AlertRule, error)
+	Create(rule *model.AlertRule) error
+	Update(id uint, updates map[string]interface{}) error
+	Delete(opts ...DBOption) error
+}
+
+func NewIAlertRuleRepo() IAlertRuleRepo {
+	return &AlertRuleRepo{}
+}
+
+type AlertRuleRepo struct{}
+
+func (r *AlertRuleRepo) GetList(opts ...DBOption) ([]model.AlertRule, error) {
+	var items []model.AlertRule
+	db := getDB().Model(&model.AlertRule{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	err := db.Order("id ASC").Find(&items).Error
+	return items, err
+}
+
+func (r *AlertRuleRepo) Get(opts ...DBOption) (model.AlertRule, error) {
+	var item model.AlertRule
+	db := getDB().Model(&model.AlertRule{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	err := db.First(&item).Error
+	return item, err
+}
+
+func (r *AlertRuleRepo) Create(rule *model.AlertRule) error {
+	return getDB().Create(rule).Error
+}
+
+func (r *AlertRuleRepo) Update(id uint, updates map[string]interface{}) error {
+	return getDB().Model(&model.AlertRule{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *AlertRuleRepo) Delete(opts ...DBOption) error {
+	db := getDB()
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	return db.Delete(&model.AlertRule{}).Error
+}
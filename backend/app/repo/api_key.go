@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"xpanel/app/model"
+
+	"gorm.io/gorm"
+)
+
+// IAPIKeyRepo API Key 仓库接口
+type IAPIKeyRepo interface {
+	Page(page, pageSize int, opts ...DBOption) (int64, []model.APIKey, error)
+	Get(opts ...DBOption) (model.APIKey, error)
+	Create(key *model.APIKey) error
+	Update(id uint, updates map[string]interface{}) error
+	Delete(opts ...DBOption) error
+}
+
+// NewIAPIKeyRepo 创建 API Key 仓库实例
+func NewIAPIKeyRepo() IAPIKeyRepo { return &APIKeyRepo{} }
+
+type APIKeyRepo struct{}
+
+func (r *APIKeyRepo) Page(page, pageSize int, opts ...DBOption) (int64, []model.APIKey, error) {
+	var (
+		items []model.APIKey
+		total int64
+	)
+	db := getDB().Model(&model.APIKey{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	db.Count(&total)
+	err := db.Offset((page - 1) * pageSize).Limit(pageSize).Order("created_at DESC").Find(&items).Error
+	return total, items, err
+}
+
+func (r *APIKeyRepo) Get(opts ...DBOption) (model.APIKey, error) {
+	var item model.APIKey
+	db := getDB().Model(&model.APIKey{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	err := db.First(&item).Error
+	return item, err
+}
+
+func (r *APIKeyRepo) Create(key *model.APIKey) error {
+	return getDB().Create(key).Error
+}
+
+func (r *APIKeyRepo) Update(id uint, updates map[string]interface{}) error {
+	return getDB().Model(&model.APIKey{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *APIKeyRepo) Delete(opts ...DBOption) error {
+	db := getDB()
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	return db.Delete(&model.APIKey{}).Error
+}
+
+// WithByKeyID 按 API Key 公开标识查询
+func WithByKeyID(keyID string) DBOption {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("key_id = ?", keyID)
+	}
+}
@@ -17,6 +17,7 @@ type IBackupRepo interface {
 	CreateRecord(r *model.BackupRecord) error
 	PageRecord(page, pageSize int, opts ...DBOption) (int64, []model.BackupRecord, error)
 	ListRecords(opts ...DBOption) ([]model.BackupRecord, error)
+	UpdateRecord(id uint, fields map[string]interface{}) error
 	DeleteRecord(id uint) error
 	GetRecord(id uint) (*model.BackupRecord, error)
 }
@@ -104,6 +105,10 @@ func (r *BackupRepo) ListRecords(opts ...DBOption) ([]model.BackupRecord, error)
 	return items, db.Order("created_at desc").Find(&items).Error
 }
 
+func (r *BackupRepo) UpdateRecord(id uint, fields map[string]interface{}) error {
+	return global.DB.Model(&model.BackupRecord{}).Where("id = ?", id).Updates(fields).Error
+}
+
 func (r *BackupRepo) DeleteRecord(id uint) error {
 	return global.DB.Delete(&model.BackupRecord{}, id).Error
 }
@@ -161,6 +166,12 @@ func WithBackupCronjobID(id uint) DBOption {
 	}
 }
 
+func WithBackupParentID(id uint) DBOption {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("parent_id = ?", id)
+	}
+}
+
 func protectBackupAccount(item *model.BackupAccount) error {
 	return protectFields(
 		secureField{Scope: "backup_accounts.access_key", Value: &item.AccessKey},
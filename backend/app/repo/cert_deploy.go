@@ -0,0 +1,155 @@
+package repo
+
+import (
+	"xpanel/app/model"
+)
+
+// --- CertificateDeployment Repo ---
+
+type ICertificateDeploymentRepo interface {
+	GetList(opts ...DBOption) ([]model.CertificateDeployment, error)
+	Get(opts ...DBOption) (model.CertificateDeployment, error)
+	Create(item *model.CertificateDeployment) error
+	Save(item *model.CertificateDeployment) error
+	Update(id uint, updates map[string]interface{}) error
+	Delete(opts ...DBOption) error
+	DeleteByCertificates(certIDs []uint) error
+
+	CreateLog(item *model.CertificateDeployLog) error
+	PageLogs(page, pageSize int, opts ...DBOption) (int64, []model.CertificateDeployLog, error)
+	TrimLogs(deploymentID uint, keep int) error
+	DeleteLogs(opts ...DBOption) error
+}
+
+func NewICertificateDeploymentRepo() ICertificateDeploymentRepo { return &CertificateDeploymentRepo{} }
+
+type CertificateDeploymentRepo struct{}
+
+func (r *CertificateDeploymentRepo) GetList(opts ...DBOption) ([]model.CertificateDeployment, error) {
+	var items []model.CertificateDeployment
+	db := getDB().Model(&model.CertificateDeployment{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	if err := db.Order("id ASC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if err := revealCertificateDeployment(&items[i]); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func (r *CertificateDeploymentRepo) Get(opts ...DBOption) (model.CertificateDeployment, error) {
+	var item model.CertificateDeployment
+	db := getDB().Model(&model.CertificateDeployment{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	if err := db.First(&item).Error; err != nil {
+		return item, err
+	}
+	return item, revealCertificateDeployment(&item)
+}
+
+func (r *CertificateDeploymentRepo) Create(item *model.CertificateDeployment) error {
+	stored := *item
+	if err := protectCertificateDeployment(&stored); err != nil {
+		return err
+	}
+	if err := getDB().Create(&stored).Error; err != nil {
+		return err
+	}
+	item.BaseModel = stored.BaseModel
+	return nil
+}
+
+func (r *CertificateDeploymentRepo) Save(item *model.CertificateDeployment) error {
+	stored := *item
+	if err := protectCertificateDeployment(&stored); err != nil {
+		return err
+	}
+	if err := getDB().Save(&stored).Error; err != nil {
+		return err
+	}
+	item.BaseModel = stored.BaseModel
+	return nil
+}
+
+func (r *CertificateDeploymentRepo) Update(id uint, updates map[string]interface{}) error {
+	protected, err := protectUpdates("certificate_deployments", updates)
+	if err != nil {
+		return err
+	}
+	return getDB().Model(&model.CertificateDeployment{}).Where("id = ?", id).Updates(protected).Error
+}
+
+func (r *CertificateDeploymentRepo) Delete(opts ...DBOption) error {
+	db := getDB()
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	return db.Delete(&model.CertificateDeployment{}).Error
+}
+
+// DeleteByCertificates 删除证书时一并清理其部署目标与执行记录
+func (r *CertificateDeploymentRepo) DeleteByCertificates(certIDs []uint) error {
+	if len(certIDs) == 0 {
+		return nil
+	}
+	if err := getDB().Where("certificate_id IN ?", certIDs).Delete(&model.CertificateDeployLog{}).Error; err != nil {
+		return err
+	}
+	return getDB().Where("certificate_id IN ?", certIDs).Delete(&model.CertificateDeployment{}).Error
+}
+
+func (r *CertificateDeploymentRepo) CreateLog(item *model.CertificateDeployLog) error {
+	return getDB().Create(item).Error
+}
+
+func (r *CertificateDeploymentRepo) PageLogs(page, pageSize int, opts ...DBOption) (int64, []model.CertificateDeployLog, error) {
+	var (
+		items []model.CertificateDeployLog
+		total int64
+	)
+	db := getDB().Model(&model.CertificateDeployLog{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return 0, nil, err
+	}
+	err := db.Offset((page - 1) * pageSize).Limit(pageSize).Order("id DESC").Find(&items).Error
+	return total, items, err
+}
+
+// TrimLogs 每个部署目标只保留最近 keep 条执行记录
+func (r *CertificateDeploymentRepo) TrimLogs(deploymentID uint, keep int) error {
+	var ids []uint
+	if err := getDB().Model(&model.CertificateDeployLog{}).Where("deployment_id = ?", deploymentID).
+		Order("id DESC").Offset(keep).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return getDB().Where("id IN ?", ids).Delete(&model.CertificateDeployLog{}).Error
+}
+
+func (r *CertificateDeploymentRepo) DeleteLogs(opts ...DBOption) error {
+	db := getDB()
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	return db.Delete(&model.CertificateDeployLog{}).Error
+}
+
+func protectCertificateDeployment(item *model.CertificateDeployment) error {
+	return protectFields(secureField{Scope: "certificate_deployments.password", Value: &item.Password})
+}
+
+func revealCertificateDeployment(item *model.CertificateDeployment) error {
+	return revealFields(secureField{Scope: "certificate_deployments.password", Value: &item.Password})
+}
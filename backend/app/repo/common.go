@@ -16,6 +16,13 @@ func WithByID(id uint) DBOption {
 	}
 }
 
+// WithByIDs 按多个 ID 查询
+func WithByIDs(ids []uint) DBOption {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id IN ?", ids)
+	}
+}
+
 // WithByKey 按 Key 查询（用于 Setting）
 func WithByKey(key string) DBOption {
 	return func(db *gorm.DB) *gorm.DB {
@@ -23,6 +30,13 @@ func WithByKey(key string) DBOption {
 	}
 }
 
+// WithByKeys 按多个 Key 查询（用于 Setting）
+func WithByKeys(keys ...string) DBOption {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("`key` IN ?", keys)
+	}
+}
+
 // WithByName 按 Name 查询
 func WithByName(name string) DBOption {
 	return func(db *gorm.DB) *gorm.DB {
@@ -54,6 +68,34 @@ func WithBySourceID(sourceID uint) DBOption {
 	}
 }
 
+// WithByCertificateID 按 CertificateID 查询
+func WithByCertificateID(certID uint) DBOption {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("certificate_id = ?", certID)
+	}
+}
+
+// WithByDeploymentID 按 DeploymentID 查询
+func WithByDeploymentID(deploymentID uint) DBOption {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("deployment_id = ?", deploymentID)
+	}
+}
+
+// WithByAuthorityID 按私有 CA 查询
+func WithByAuthorityID(authorityID uint) DBOption {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("authority_id = ?", authorityID)
+	}
+}
+
+// WithByParentID 按上级 ID 查询
+func WithByParentID(parentID uint) DBOption {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("parent_id = ?", parentID)
+	}
+}
+
 func WithBySourceLineage(sourceID uint, lineageUID string) DBOption {
 	return func(db *gorm.DB) *gorm.DB {
 		return db.Where("source_id = ? AND lineage_uid = ?", sourceID, lineageUID)
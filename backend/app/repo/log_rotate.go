@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"xpanel/app/model"
+)
+
+type ILogRotateRepo interface {
+	List() ([]model.LogRotatePolicy, error)
+	GetByWebsite(websiteID uint) (model.LogRotatePolicy, error)
+	Save(item *model.LogRotatePolicy) error
+	DeleteByWebsite(websiteID uint) error
+}
+
+func NewILogRotateRepo() ILogRotateRepo { return &LogRotateRepo{} }
+
+type LogRotateRepo struct{}
+
+func (r *LogRotateRepo) List() ([]model.LogRotatePolicy, error) {
+	var items []model.LogRotatePolicy
+	err := getDB().Order("website_id ASC").Find(&items).Error
+	return items, err
+}
+
+// GetByWebsite 返回策略，未配置时返回零值（ID 为 0）且不报错；websiteID 为 0 时返回全局策略
+func (r *LogRotateRepo) GetByWebsite(websiteID uint) (model.LogRotatePolicy, error) {
+	var item model.LogRotatePolicy
+	err := getDB().Where("website_id = ?", websiteID).Limit(1).Find(&item).Error
+	item.WebsiteID = websiteID
+	return item, err
+}
+
+func (r *LogRotateRepo) Save(item *model.LogRotatePolicy) error {
+	return getDB().Save(item).Error
+}
+
+func (r *LogRotateRepo) DeleteByWebsite(websiteID uint) error {
+	return getDB().Where("website_id = ?", websiteID).Delete(&model.LogRotatePolicy{}).Error
+}
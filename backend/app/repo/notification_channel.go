@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"xpanel/app/model"
+)
+
+// INotificationChannelRepo 外发通知渠道仓库接口
+type INotificationChannelRepo interface {
+	GetList(opts ...DBOption) ([]model.NotificationChannel, error)
+	Get(opts ...DBOption) (model.NotificationChannel, error)
+	Create(channel *model.NotificationChannel) error
+	Update(id uint, updates map[string]interface{}) error
+	Delete(opts ...DBOption) error
+}
+
+func NewINotificationChannelRepo() INotificationChannelRepo {
+	return &NotificationChannelRepo{}
+}
+
+type NotificationChannelRepo struct{}
+
+func (r *NotificationChannelRepo) GetList(opts ...DBOption) ([]model.NotificationChannel, error) {
+	var items []model.NotificationChannel
+	db := getDB().Model(&model.NotificationChannel{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	if err := db.Order("id ASC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if err := revealNotificationChannel(&items[i]); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func (r *NotificationChannelRepo) Get(opts ...DBOption) (model.NotificationChannel, error) {
+	var item model.NotificationChannel
+	db := getDB().Model(&model.NotificationChannel{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	if err := db.First(&item).Error; err != nil {
+		return item, err
+	}
+	return item, revealNotificationChannel(&item)
+}
+
+func (r *NotificationChannelRepo) Create(channel *model.NotificationChannel) error {
+	stored := *channel
+	if err := protectNotificationChannel(&stored); err != nil {
+		return err
+	}
+	if err := getDB().Create(&stored).Error; err != nil {
+		return err
+	}
+	channel.BaseModel = stored.BaseModel
+	return nil
+}
+
+func (r *NotificationChannelRepo) Update(id uint, updates map[string]interface{}) error {
+	protected, err := protectUpdates("notification_channels", updates)
+	if err != nil {
+		return err
+	}
+	return getDB().Model(&model.NotificationChannel{}).Where("id = ?", id).Updates(protected).Error
+}
+
+func (r *NotificationChannelRepo) Delete(opts ...DBOption) error {
+	db := getDB()
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	return db.Delete(&model.NotificationChannel{}).Error
+}
+
+func protectNotificationChannel(item *model.NotificationChannel) error {
+	return protectFields(secureField{Scope: "notification_channels.config", Value: &item.Config})
+}
+
+func revealNotificationChannel(item *model.NotificationChannel) error {
+	return revealFields(secureField{Scope: "notification_channels.config", Value: &item.Config})
+}
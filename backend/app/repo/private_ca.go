@@ -0,0 +1,120 @@
+package repo
+
+import (
+	"time"
+
+	"xpanel/app/model"
+)
+
+// --- CertificateAuthority Repo ---
+
+type ICertificateAuthorityRepo interface {
+	GetList(opts ...DBOption) ([]model.CertificateAuthority, error)
+	Get(opts ...DBOption) (model.CertificateAuthority, error)
+	Count(opts ...DBOption) (int64, error)
+	Create(item *model.CertificateAuthority) error
+	Update(id uint, updates map[string]interface{}) error
+	Delete(opts ...DBOption) error
+
+	CreateRevocation(item *model.CertificateAuthorityRevocation) error
+	// ListActiveRevocations 返回 CA 尚未过期证书的吊销记录
+	ListActiveRevocations(authorityID uint, now time.Time) ([]model.CertificateAuthorityRevocation, error)
+	DeleteRevocations(opts ...DBOption) error
+}
+
+func NewICertificateAuthorityRepo() ICertificateAuthorityRepo { return &CertificateAuthorityRepo{} }
+
+type CertificateAuthorityRepo struct{}
+
+func (r *CertificateAuthorityRepo) GetList(opts ...DBOption) ([]model.CertificateAuthority, error) {
+	var items []model.CertificateAuthority
+	db := getDB().Model(&model.CertificateAuthority{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	if err := db.Order("parent_id ASC, id ASC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if err := revealCertificateAuthority(&items[i]); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func (r *CertificateAuthorityRepo) Get(opts ...DBOption) (model.CertificateAuthority, error) {
+	var item model.CertificateAuthority
+	db := getDB().Model(&model.CertificateAuthority{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	if err := db.First(&item).Error; err != nil {
+		return item, err
+	}
+	return item, revealCertificateAuthority(&item)
+}
+
+func (r *CertificateAuthorityRepo) Count(opts ...DBOption) (int64, error) {
+	var count int64
+	db := getDB().Model(&model.CertificateAuthority{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	err := db.Count(&count).Error
+	return count, err
+}
+
+func (r *CertificateAuthorityRepo) Create(item *model.CertificateAuthority) error {
+	stored := *item
+	if err := protectCertificateAuthority(&stored); err != nil {
+		return err
+	}
+	if err := getDB().Create(&stored).Error; err != nil {
+		return err
+	}
+	item.BaseModel = stored.BaseModel
+	return nil
+}
+
+func (r *CertificateAuthorityRepo) Update(id uint, updates map[string]interface{}) error {
+	protected, err := protectUpdates("certificate_authorities", updates)
+	if err != nil {
+		return err
+	}
+	return getDB().Model(&model.CertificateAuthority{}).Where("id = ?", id).Updates(protected).Error
+}
+
+func (r *CertificateAuthorityRepo) Delete(opts ...DBOption) error {
+	db := getDB()
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	return db.Delete(&model.CertificateAuthority{}).Error
+}
+
+func (r *CertificateAuthorityRepo) CreateRevocation(item *model.CertificateAuthorityRevocation) error {
+	return getDB().Create(item).Error
+}
+
+func (r *CertificateAuthorityRepo) ListActiveRevocations(authorityID uint, now time.Time) ([]model.CertificateAuthorityRevocation, error) {
+	var items []model.CertificateAuthorityRevocation
+	err := getDB().Where("authority_id = ? AND not_after > ?", authorityID, now).Order("id ASC").Find(&items).Error
+	return items, err
+}
+
+func (r *CertificateAuthorityRepo) DeleteRevocations(opts ...DBOption) error {
+	db := getDB()
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	return db.Delete(&model.CertificateAuthorityRevocation{}).Error
+}
+
+func protectCertificateAuthority(item *model.CertificateAuthority) error {
+	return protectFields(secureField{Scope: "certificate_authorities.private_key", Value: &item.PrivateKey})
+}
+
+func revealCertificateAuthority(item *model.CertificateAuthority) error {
+	return revealFields(secureField{Scope: "certificate_authorities.private_key", Value: &item.PrivateKey})
+}
@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"time"
+
+	"xpanel/app/model"
+
+	"gorm.io/gorm"
+)
+
+// ISessionRepo 登录会话仓库接口
+type ISessionRepo interface {
+	GetList(opts ...DBOption) ([]model.Session, error)
+	Get(opts ...DBOption) (model.Session, error)
+	Create(session *model.Session) error
+	Update(id uint, updates map[string]interface{}) error
+	Delete(opts ...DBOption) error
+}
+
+// NewISessionRepo 创建会话仓库实例
+func NewISessionRepo() ISessionRepo { return &SessionRepo{} }
+
+type SessionRepo struct{}
+
+func (r *SessionRepo) GetList(opts ...DBOption) ([]model.Session, error) {
+	var items []model.Session
+	db := getDB().Model(&model.Session{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	err := db.Order("last_seen_at DESC").Find(&items).Error
+	return items, err
+}
+
+func (r *SessionRepo) Get(opts ...DBOption) (model.Session, error) {
+	var item model.Session
+	db := getDB().Model(&model.Session{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	err := db.First(&item).Error
+	return item, err
+}
+
+func (r *SessionRepo) Create(session *model.Session) error {
+	return getDB().Create(session).Error
+}
+
+func (r *SessionRepo) Update(id uint, updates map[string]interface{}) error {
+	return getDB().Model(&model.Session{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Delete 按条件删除会话；无条件时删除全部会话
+func (r *SessionRepo) Delete(opts ...DBOption) error {
+	db := getDB().Session(&gorm.Session{AllowGlobalUpdate: true})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	return db.Delete(&model.Session{}).Error
+}
+
+// WithByTokenID 按 JWT ID 查询会话
+func WithByTokenID(tokenID string) DBOption {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("token_id = ?", tokenID)
+	}
+}
+
+// WithByUserName 按用户名查询
+func WithByUserName(userName string) DBOption {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_name = ?", userName)
+	}
+}
+
+// WithExpiredBefore 查询在指定时间之前过期的记录
+func WithExpiredBefore(t time.Time) DBOption {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("expires_at < ?", t)
+	}
+}
+
+// WithNotTokenID 排除指定 JWT ID 的会话
+func WithNotTokenID(tokenID string) DBOption {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("token_id <> ?", tokenID)
+	}
+}
@@ -0,0 +1,114 @@
+package repo
+
+import (
+	"xpanel/app/model"
+)
+
+// IUserRepo 面板子账户仓库接口
+type IUserRepo interface {
+	Page(page, pageSize int, opts ...DBOption) (int64, []model.User, error)
+	GetList(opts ...DBOption) ([]model.User, error)
+	Get(opts ...DBOption) (model.User, error)
+	Create(user *model.User) error
+	Update(id uint, updates map[string]interface{}) error
+	Delete(opts ...DBOption) error
+}
+
+// NewIUserRepo 创建子账户仓库实例
+func NewIUserRepo() IUserRepo { return &UserRepo{} }
+
+type UserRepo struct{}
+
+func (r *UserRepo) Page(page, pageSize int, opts ...DBOption) (int64, []model.User, error) {
+	var (
+		items []model.User
+		total int64
+	)
+	db := getDB().Model(&model.User{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	db.Count(&total)
+	err := db.Offset((page - 1) * pageSize).Limit(pageSize).Order("created_at DESC").Find(&items).Error
+	if err == nil {
+		err = revealUsers(items)
+	}
+	return total, items, err
+}
+
+func (r *UserRepo) GetList(opts ...DBOption) ([]model.User, error) {
+	var items []model.User
+	db := getDB().Model(&model.User{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	err := db.Find(&items).Error
+	if err == nil {
+		err = revealUsers(items)
+	}
+	return items, err
+}
+
+func (r *UserRepo) Get(opts ...DBOption) (model.User, error) {
+	var item model.User
+	db := getDB().Model(&model.User{})
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	err := db.First(&item).Error
+	if err == nil {
+		err = revealUser(&item)
+	}
+	return item, err
+}
+
+func (r *UserRepo) Create(user *model.User) error {
+	stored := *user
+	if err := protectUser(&stored); err != nil {
+		return err
+	}
+	if err := getDB().Create(&stored).Error; err != nil {
+		return err
+	}
+	*user = stored
+	return revealUser(user)
+}
+
+func (r *UserRepo) Update(id uint, updates map[string]interface{}) error {
+	protected, err := protectUpdates("users", updates)
+	if err != nil {
+		return err
+	}
+	return getDB().Model(&model.User{}).Where("id = ?", id).Updates(protected).Error
+}
+
+func (r *UserRepo) Delete(opts ...DBOption) error {
+	db := getDB()
+	for _, opt := range opts {
+		db = opt(db)
+	}
+	return db.Delete(&model.User{}).Error
+}
+
+func protectUser(item *model.User) error {
+	return protectFields(
+		secureField{Scope: "users.mfa_secret", Value: &item.MFASecret},
+		secureField{Scope: "users.mfa_pending_secret", Value: &item.MFAPendingSecret},
+	)
+}
+
+func revealUser(item *model.User) error {
+	return revealFields(
+		secureField{Scope: "users.mfa_secret", Value: &item.MFASecret},
+		secureField{Scope: "users.mfa_pending_secret", Value: &item.MFAPendingSecret},
+	)
+}
+
+func revealUsers(items []model.User) error {
+	for i := range items {
+		if err := revealUser(&items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
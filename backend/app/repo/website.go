@@ -140,6 +140,254 @@ func WithLikeWebsite(info string) DBOption {
 	}
 }
 
+type IWebsiteLocationRepo interface {
+	ListByWebsite(websiteID uint) ([]model.WebsiteLocation, error)
+	ReplaceForWebsite(websiteID uint, items []model.WebsiteLocation) error
+	DeleteByWebsite(websiteID uint) error
+}
+
+func NewIWebsiteLocationRepo() IWebsiteLocationRepo { return &WebsiteLocationRepo{} }
+
+type WebsiteLocationRepo struct{}
+
+func (r *WebsiteLocationRepo) ListByWebsite(websiteID uint) ([]model.WebsiteLocation, error) {
+	var items []model.WebsiteLocation
+	err := getDB().Where("website_id = ?", websiteID).Order("sort ASC, id ASC").Find(&items).Error
+	return items, err
+}
+
+// ReplaceForWebsite 在事务中整体替换网站的 location 列表
+func (r *WebsiteLocationRepo) ReplaceForWebsite(websiteID uint, items []model.WebsiteLocation) error {
+	return getDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("website_id = ?", websiteID).Delete(&model.WebsiteLocation{}).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].ID = 0
+			items[i].WebsiteID = websiteID
+			if err := tx.Create(&items[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *WebsiteLocationRepo) DeleteByWebsite(websiteID uint) error {
+	return getDB().Where("website_id = ?", websiteID).Delete(&model.WebsiteLocation{}).Error
+}
+
+type IWebsiteUpstreamRepo interface {
+	ListByWebsite(websiteID uint) ([]model.WebsiteUpstream, error)
+	SaveForWebsite(websiteID uint, items []model.WebsiteUpstream) error
+	DeleteByWebsite(websiteID uint) error
+}
+
+func NewIWebsiteUpstreamRepo() IWebsiteUpstreamRepo { return &WebsiteUpstreamRepo{} }
+
+type WebsiteUpstreamRepo struct{}
+
+func (r *WebsiteUpstreamRepo) ListByWebsite(websiteID uint) ([]model.WebsiteUpstream, error) {
+	var items []model.WebsiteUpstream
+	err := getDB().Where("website_id = ?", websiteID).Order("id ASC").Find(&items).Error
+	return items, err
+}
+
+// SaveForWebsite 按 ID 更新已有 upstream、新建其余项并删除列表外的记录，
+// 保持 ID 不变以免 location 的引用失效
+func (r *WebsiteUpstreamRepo) SaveForWebsite(websiteID uint, items []model.WebsiteUpstream) error {
+	return getDB().Transaction(func(tx *gorm.DB) error {
+		keep := []uint{0}
+		for i := range items {
+			items[i].WebsiteID = websiteID
+			if items[i].ID > 0 {
+				if err := tx.Save(&items[i]).Error; err != nil {
+					return err
+				}
+			} else if err := tx.Create(&items[i]).Error; err != nil {
+				return err
+			}
+			keep = append(keep, items[i].ID)
+		}
+		return tx.Where("website_id = ? AND id NOT IN ?", websiteID, keep).Delete(&model.WebsiteUpstream{}).Error
+	})
+}
+
+func (r *WebsiteUpstreamRepo) DeleteByWebsite(websiteID uint) error {
+	return getDB().Where("website_id = ?", websiteID).Delete(&model.WebsiteUpstream{}).Error
+}
+
+type IWebsiteWAFRepo interface {
+	GetByWebsite(websiteID uint) (model.WebsiteWAF, error)
+	Save(item *model.WebsiteWAF) error
+	DeleteByWebsite(websiteID uint) error
+}
+
+func NewIWebsiteWAFRepo() IWebsiteWAFRepo { return &WebsiteWAFRepo{} }
+
+type WebsiteWAFRepo struct{}
+
+// GetByWebsite 返回网站的 WAF 配置，未配置时返回零值且不报错
+func (r *WebsiteWAFRepo) GetByWebsite(websiteID uint) (model.WebsiteWAF, error) {
+	var item model.WebsiteWAF
+	err := getDB().Where("website_id = ?", websiteID).Limit(1).Find(&item).Error
+	item.WebsiteID = websiteID
+	return item, err
+}
+
+func (r *WebsiteWAFRepo) Save(item *model.WebsiteWAF) error {
+	return getDB().Save(item).Error
+}
+
+func (r *WebsiteWAFRepo) DeleteByWebsite(websiteID uint) error {
+	return getDB().Where("website_id = ?", websiteID).Delete(&model.WebsiteWAF{}).Error
+}
+
+type IWebsiteAccessRepo interface {
+	List() ([]model.WebsiteAccess, error)
+	GetByWebsite(websiteID uint) (model.WebsiteAccess, error)
+	Save(item *model.WebsiteAccess) error
+	DeleteByWebsite(websiteID uint) error
+}
+
+func NewIWebsiteAccessRepo() IWebsiteAccessRepo { return &WebsiteAccessRepo{} }
+
+type WebsiteAccessRepo struct{}
+
+func (r *WebsiteAccessRepo) List() ([]model.WebsiteAccess, error) {
+	var items []model.WebsiteAccess
+	err := getDB().Order("website_id ASC").Find(&items).Error
+	return items, err
+}
+
+// GetByWebsite 返回网站的访问控制配置，未配置时返回零值且不报错
+func (r *WebsiteAccessRepo) GetByWebsite(websiteID uint) (model.WebsiteAccess, error) {
+	var item model.WebsiteAccess
+	err := getDB().Where("website_id = ?", websiteID).Limit(1).Find(&item).Error
+	item.WebsiteID = websiteID
+	return item, err
+}
+
+func (r *WebsiteAccessRepo) Save(item *model.WebsiteAccess) error {
+	return getDB().Save(item).Error
+}
+
+func (r *WebsiteAccessRepo) DeleteByWebsite(websiteID uint) error {
+	return getDB().Where("website_id = ?", websiteID).Delete(&model.WebsiteAccess{}).Error
+}
+
+type IWebsiteDeployRepo interface {
+	GetByWebsite(websiteID uint) (model.WebsiteDeploy, error)
+	Save(item *model.WebsiteDeploy) error
+	SetCurrentRelease(websiteID uint, release string) error
+	DeleteByWebsite(websiteID uint) error
+
+	CreateRecord(item *model.WebsiteDeployRecord) error
+	SaveRecord(item *model.WebsiteDeployRecord) error
+	PageRecords(websiteID uint, page, pageSize int) (int64, []model.WebsiteDeployRecord, error)
+	DeleteRecordsByWebsite(websiteID uint) error
+}
+
+func NewIWebsiteDeployRepo() IWebsiteDeployRepo { return &WebsiteDeployRepo{} }
+
+type WebsiteDeployRepo struct{}
+
+// GetByWebsite 返回网站的 Git 部署配置，未配置时返回零值且不报错
+func (r *WebsiteDeployRepo) GetByWebsite(websiteID uint) (model.WebsiteDeploy, error) {
+	var item model.WebsiteDeploy
+	if err := getDB().Where("website_id = ?", websiteID).Limit(1).Find(&item).Error; err != nil {
+		return item, err
+	}
+	item.WebsiteID = websiteID
+	return item, revealWebsiteDeploy(&item)
+}
+
+func (r *WebsiteDeployRepo) Save(item *model.WebsiteDeploy) error {
+	stored := *item
+	if err := protectWebsiteDeploy(&stored); err != nil {
+		return err
+	}
+	if err := getDB().Save(&stored).Error; err != nil {
+		return err
+	}
+	item.BaseModel = stored.BaseModel
+	return nil
+}
+
+func (r *WebsiteDeployRepo) SetCurrentRelease(websiteID uint, release string) error {
+	return getDB().Model(&model.WebsiteDeploy{}).Where("website_id = ?", websiteID).Update("current_release", release).Error
+}
+
+func (r *WebsiteDeployRepo) DeleteByWebsite(websiteID uint) error {
+	return getDB().Where("website_id = ?", websiteID).Delete(&model.WebsiteDeploy{}).Error
+}
+
+func (r *WebsiteDeployRepo) CreateRecord(item *model.WebsiteDeployRecord) error {
+	return getDB().Create(item).Error
+}
+
+func (r *WebsiteDeployRepo) SaveRecord(item *model.WebsiteDeployRecord) error {
+	return getDB().Save(item).Error
+}
+
+func (r *WebsiteDeployRepo) PageRecords(websiteID uint, page, pageSize int) (int64, []model.WebsiteDeployRecord, error) {
+	var total int64
+	var items []model.WebsiteDeployRecord
+	db := getDB().Model(&model.WebsiteDeployRecord{}).Where("website_id = ?", websiteID)
+	if err := db.Count(&total).Error; err != nil {
+		return 0, nil, err
+	}
+	if err := db.Offset((page - 1) * pageSize).Limit(pageSize).Order("id desc").Find(&items).Error; err != nil {
+		return 0, nil, err
+	}
+	return total, items, nil
+}
+
+func (r *WebsiteDeployRepo) DeleteRecordsByWebsite(websiteID uint) error {
+	return getDB().Where("website_id = ?", websiteID).Delete(&model.WebsiteDeployRecord{}).Error
+}
+
+type IWebsiteConfigDriftRepo interface {
+	List() ([]model.WebsiteConfigDrift, error)
+	GetByWebsite(websiteID uint) (model.WebsiteConfigDrift, error)
+	Save(item *model.WebsiteConfigDrift) error
+	DeleteByWebsite(websiteID uint) error
+}
+
+func NewIWebsiteConfigDriftRepo() IWebsiteConfigDriftRepo { return &WebsiteConfigDriftRepo{} }
+
+type WebsiteConfigDriftRepo struct{}
+
+func (r *WebsiteConfigDriftRepo) List() ([]model.WebsiteConfigDrift, error) {
+	var items []model.WebsiteConfigDrift
+	err := getDB().Order("website_id ASC").Find(&items).Error
+	return items, err
+}
+
+// GetByWebsite 返回网站的漂移记录，无漂移时返回零值且不报错
+func (r *WebsiteConfigDriftRepo) GetByWebsite(websiteID uint) (model.WebsiteConfigDrift, error) {
+	var item model.WebsiteConfigDrift
+	err := getDB().Where("website_id = ?", websiteID).Limit(1).Find(&item).Error
+	item.WebsiteID = websiteID
+	return item, err
+}
+
+func (r *WebsiteConfigDriftRepo) Save(item *model.WebsiteConfigDrift) error {
+	return getDB().Save(item).Error
+}
+
+func (r *WebsiteConfigDriftRepo) DeleteByWebsite(websiteID uint) error {
+	return getDB().Where("website_id = ?", websiteID).Delete(&model.WebsiteConfigDrift{}).Error
+}
+
+func protectWebsiteDeploy(item *model.WebsiteDeploy) error {
+	return protectFields(secureField{Scope: "website_deploys.webhook_secret", Value: &item.WebhookSecret})
+}
+
+func revealWebsiteDeploy(item *model.WebsiteDeploy) error {
+	return revealFields(secureField{Scope: "website_deploys.webhook_secret", Value: &item.WebhookSecret})
+}
+
 func protectWebsite(item *model.Website) error {
 	return protectFields(secureField{Scope: "websites.basic_password", Value: &item.BasicPassword})
 }
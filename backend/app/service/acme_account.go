@@ -80,8 +80,8 @@ func (s *AcmeAccountService) GetList() ([]dto.AcmeAccountInfo, error) {
 // --- DNS Account Service ---
 
 type IDnsAccountService interface {
-	Create(req dto.DnsAccountCreate) error
-	Update(req dto.DnsAccountUpdate) error
+	Create(req dto.DnsAccountCreate, admin bool) error
+	Update(req dto.DnsAccountUpdate, admin bool) error
 	Delete(id uint) error
 	GetList() ([]dto.DnsAccountInfo, error)
 }
@@ -94,7 +94,18 @@ func NewIDnsAccountService() IDnsAccountService {
 	return &DnsAccountService{dnsRepo: repo.NewIDnsAccountRepo()}
 }
 
-func (s *DnsAccountService) Create(req dto.DnsAccountCreate) error {
+// dnsProviderRequiresAdmin 自定义脚本提供商以 root 执行任意程序，仅管理员可创建或修改
+func dnsProviderRequiresAdmin(dnsType string) bool {
+	return dnsType == "Exec"
+}
+
+func (s *DnsAccountService) Create(req dto.DnsAccountCreate, admin bool) error {
+	if !admin && dnsProviderRequiresAdmin(req.Type) {
+		return buserr.New(constant.ErrPermissionDeny)
+	}
+	if err := sslutil.ValidateDNSParams(req.Type, req.Authorization); err != nil {
+		return buserr.WithDetail(constant.ErrInvalidParams, err.Error(), err)
+	}
 	authJSON, err := json.Marshal(req.Authorization)
 	if err != nil {
 		return buserr.WithDetail(constant.ErrInvalidParams, err.Error(), err)
@@ -111,11 +122,14 @@ func (s *DnsAccountService) Create(req dto.DnsAccountCreate) error {
 	return nil
 }
 
-func (s *DnsAccountService) Update(req dto.DnsAccountUpdate) error {
+func (s *DnsAccountService) Update(req dto.DnsAccountUpdate, admin bool) error {
 	existing, err := s.dnsRepo.Get(repo.WithByID(req.ID))
 	if err != nil {
 		return buserr.New(constant.ErrRecordNotFound)
 	}
+	if !admin && (dnsProviderRequiresAdmin(existing.Type) || dnsProviderRequiresAdmin(req.Type)) {
+		return buserr.New(constant.ErrPermissionDeny)
+	}
 	authorization, err := mergeDNSAuthorizationForUpdate(
 		existing.Type,
 		req.Type,
@@ -125,6 +139,13 @@ func (s *DnsAccountService) Update(req dto.DnsAccountUpdate) error {
 	if err != nil {
 		return buserr.WithDetail(constant.ErrInvalidParams, err.Error(), err)
 	}
+	merged := make(map[string]string)
+	if err := json.Unmarshal([]byte(authorization), &merged); err != nil {
+		return buserr.WithDetail(constant.ErrInvalidParams, err.Error(), err)
+	}
+	if err := sslutil.ValidateDNSParams(req.Type, merged); err != nil {
+		return buserr.WithDetail(constant.ErrInvalidParams, err.Error(), err)
+	}
 	updates := map[string]interface{}{
 		"name":          req.Name,
 		"type":          req.Type,
@@ -207,7 +228,7 @@ func mergeDNSAuthorizationForUpdate(
 
 type IAccountExportService interface {
 	Export() (*dto.AccountExport, error)
-	Import(data dto.AccountExport) error
+	Import(data dto.AccountExport, admin bool) error
 }
 
 type AccountExportService struct {
@@ -257,7 +278,7 @@ func (s *AccountExportService) Export() (*dto.AccountExport, error) {
 	return export, nil
 }
 
-func (s *AccountExportService) Import(data dto.AccountExport) error {
+func (s *AccountExportService) Import(data dto.AccountExport, admin bool) error {
 	imported := 0
 	for _, a := range data.AcmeAccounts {
 		account := model.AcmeAccount{
@@ -277,6 +298,10 @@ func (s *AccountExportService) Import(data dto.AccountExport) error {
 		imported++
 	}
 	for _, d := range data.DnsAccounts {
+		if !admin && dnsProviderRequiresAdmin(d.Type) {
+			global.LOG.Warnf("Skip importing DNS account %s: %s provider requires an admin", d.Name, d.Type)
+			continue
+		}
 		authJSON, _ := json.Marshal(d.Authorization)
 		account := model.DnsAccount{
 			Name:          d.Name,
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/security/credentials"
+)
+
+func TestExecDNSAccountRequiresAdmin(t *testing.T) {
+	setupCertificateStatusTest(t)
+	if err := global.DB.AutoMigrate(&model.DnsAccount{}); err != nil {
+		t.Fatal(err)
+	}
+	manager, _, err := credentials.LoadOrCreate(filepath.Join(t.TempDir(), "credential-keyring.json"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousCredentials := global.CREDENTIALS
+	global.CREDENTIALS = manager
+	t.Cleanup(func() { global.CREDENTIALS = previousCredentials })
+
+	script := filepath.Join(t.TempDir(), "dns-hook.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewIDnsAccountService()
+	create := dto.DnsAccountCreate{Name: "hook", Type: "Exec", Authorization: map[string]string{"command": script}}
+	var bizErr buserr.BusinessError
+	if err := svc.Create(create, false); !errors.As(err, &bizErr) || bizErr.Msg != constant.ErrPermissionDeny {
+		t.Fatalf("operators should not create exec DNS accounts, got %v", err)
+	}
+	if err := svc.Create(create, true); err != nil {
+		t.Fatal(err)
+	}
+	account, _ := repo.NewIDnsAccountRepo().Get(repo.WithByName("hook"))
+	update := dto.DnsAccountUpdate{ID: account.ID, Name: "hook", Type: "Exec", Authorization: map[string]string{"command": "/bin/sh"}}
+	if err := svc.Update(update, false); !errors.As(err, &bizErr) || bizErr.Msg != constant.ErrPermissionDeny {
+		t.Fatalf("operators should not edit exec DNS accounts, got %v", err)
+	}
+
+	imported := dto.AccountExport{DnsAccounts: []dto.DnsAccountExportItem{{Name: "imported", Type: "Exec", Authorization: map[string]string{"command": "/bin/sh"}}}}
+	if err := NewIAccountExportService().Import(imported, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.NewIDnsAccountRepo().Get(repo.WithByName("imported")); err == nil {
+		t.Fatal("operators should not import exec DNS accounts")
+	}
+}
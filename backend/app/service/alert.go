@@ -0,0 +1,456 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/load"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+// 告警状态
+const (
+	alertStateOK      = "ok"
+	alertStatePending = "pending"
+	alertStateFiring  = "firing"
+)
+
+// 告警指标
+const (
+	alertMetricCPU         = "cpu"
+	alertMetricMemory      = "memory"
+	alertMetricLoad        = "load"
+	alertMetricDisk        = "disk"
+	alertMetricTemperature = "temperature"
+	alertMetricTraffic     = "traffic"
+)
+
+var alertMetricLabels = map[string]string{
+	alertMetricCPU:         "CPU 使用率",
+	alertMetricMemory:      "内存使用率",
+	alertMetricLoad:        "负载",
+	alertMetricDisk:        "磁盘使用率",
+	alertMetricTemperature: "温度",
+	alertMetricTraffic:     "月流量使用",
+}
+
+var alertMetricUnits = map[string]string{
+	alertMetricTemperature: "°C",
+}
+
+var alertRuleRepo = repo.NewIAlertRuleRepo()
+
+// alertMetricReader 读取规则对应指标的当前值（测试中可替换）
+var alertMetricReader = readAlertMetric
+
+// alertEvalMu 防止上一轮评估未结束时重入
+var alertEvalMu sync.Mutex
+
+// IAlertService 监控阈值告警
+type IAlertService interface {
+	List() ([]dto.AlertRuleInfo, error)
+	Create(req dto.AlertRuleCreate) error
+	Update(req dto.AlertRuleUpdate) error
+	Delete(id uint) error
+	Mute(req dto.AlertRuleMute) error
+}
+
+type AlertService struct{}
+
+func NewIAlertService() IAlertService {
+	return &AlertService{}
+}
+
+func (s *AlertService) List() ([]dto.AlertRuleInfo, error) {
+	rules, err := alertRuleRepo.GetList()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]dto.AlertRuleInfo, 0, len(rules))
+	for _, rule := range rules {
+		items = append(items, dto.AlertRuleInfo{
+			ID:              rule.ID,
+			Name:            rule.Name,
+			Metric:          rule.Metric,
+			Target:          rule.Target,
+			Operator:        rule.Operator,
+			Threshold:       rule.Threshold,
+			Hysteresis:      rule.Hysteresis,
+			Duration:        rule.Duration,
+			SilenceStart:    rule.SilenceStart,
+			SilenceEnd:      rule.SilenceEnd,
+			NotifyRecovery:  rule.NotifyRecovery,
+			Status:          rule.Status,
+			State:           rule.State,
+			LastValue:       rule.LastValue,
+			PendingSince:    rule.PendingSince,
+			FiredAt:         rule.FiredAt,
+			MutedUntil:      rule.MutedUntil,
+			LastEvaluatedAt: rule.LastEvaluatedAt,
+		})
+	}
+	return items, nil
+}
+
+func (s *AlertService) Create(req dto.AlertRuleCreate) error {
+	if err := validateAlertRule(&req); err != nil {
+		return err
+	}
+	if _, err := alertRuleRepo.Get(repo.WithByName(req.Name)); err == nil {
+		return buserr.New(constant.ErrRecordExist)
+	}
+	rule := model.AlertRule{
+		Name:           req.Name,
+		Metric:         req.Metric,
+		Target:         req.Target,
+		Operator:       req.Operator,
+		Threshold:      req.Threshold,
+		Hysteresis:     req.Hysteresis,
+		Duration:       req.Duration,
+		SilenceStart:   req.SilenceStart,
+		SilenceEnd:     req.SilenceEnd,
+		NotifyRecovery: req.NotifyRecovery,
+		Status:         constant.StatusEnable,
+		State:          alertStateOK,
+	}
+	return alertRuleRepo.Create(&rule)
+}
+
+func (s *AlertService) Update(req dto.AlertRuleUpdate) error {
+	existing, err := alertRuleRepo.Get(repo.WithByID(req.ID))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	if err := validateAlertRule(&req.AlertRuleCreate); err != nil {
+		return err
+	}
+	if other, err := alertRuleRepo.Get(repo.WithByName(req.Name)); err == nil && other.ID != req.ID {
+		return buserr.New(constant.ErrRecordExist)
+	}
+	updates := map[string]interface{}{
+		"name":            req.Name,
+		"metric":          req.Metric,
+		"target":          req.Target,
+		"operator":        req.Operator,
+		"threshold":       req.Threshold,
+		"hysteresis":      req.Hysteresis,
+		"duration":        req.Duration,
+		"silence_start":   req.SilenceStart,
+		"silence_end":     req.SilenceEnd,
+		"notify_recovery": req.NotifyRecovery,
+		"status":          req.Status,
+	}
+	// 指标或阈值变化后重新开始评估，避免沿用旧条件下的状态
+	if req.Status != constant.StatusEnable || existing.Metric != req.Metric || existing.Target != req.Target ||
+		existing.Operator != req.Operator || existing.Threshold != req.Threshold {
+		updates["state"] = alertStateOK
+		updates["pending_since"] = nil
+		updates["fired_at"] = nil
+	}
+	return alertRuleRepo.Update(req.ID, updates)
+}
+
+func (s *AlertService) Delete(id uint) error {
+	if _, err := alertRuleRepo.Get(repo.WithByID(id)); err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	return alertRuleRepo.Delete(repo.WithByID(id))
+}
+
+func (s *AlertService) Mute(req dto.AlertRuleMute) error {
+	if _, err := alertRuleRepo.Get(repo.WithByID(req.ID)); err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	var until *time.Time
+	if req.Minutes > 0 {
+		t := time.Now().Add(time.Duration(req.Minutes) * time.Minute)
+		until = &t
+	}
+	return alertRuleRepo.Update(req.ID, map[string]interface{}{"muted_until": until})
+}
+
+// EvaluateAlertRules 评估所有启用的告警规则，由定时任务每分钟调用
+func EvaluateAlertRules() {
+	if !alertEvalMu.TryLock() {
+		return
+	}
+	defer alertEvalMu.Unlock()
+
+	rules, err := alertRuleRepo.GetList(repo.WithByStatus(constant.StatusEnable))
+	if err != nil {
+		global.LOG.Errorf("Load alert rules failed: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, rule := range rules {
+		value, err := alertMetricReader(rule)
+		if err != nil {
+			global.LOG.Debugf("Alert rule %s skipped: %v", rule.Name, err)
+			continue
+		}
+		updates := evaluateAlertRule(rule, value, now)
+		if err := alertRuleRepo.Update(rule.ID, updates); err != nil {
+			global.LOG.Errorf("Save alert rule %s state failed: %v", rule.Name, err)
+		}
+	}
+}
+
+// evaluateAlertRule 推进规则状态机：ok → pending（持续时间未满）→ firing → ok（回落超过滞回区间）
+func evaluateAlertRule(rule model.AlertRule, value float64, now time.Time) map[string]interface{} {
+	updates := map[string]interface{}{
+		"last_value":        value,
+		"last_evaluated_at": now,
+	}
+	breached := alertBreached(rule, value)
+	switch rule.State {
+	case alertStateFiring:
+		if alertRecovered(rule, value) {
+			updates["state"] = alertStateOK
+			updates["pending_since"] = nil
+			updates["fired_at"] = nil
+			if rule.NotifyRecovery && !alertSilenced(rule, now) {
+				notifyAlert(rule, value, false)
+			}
+		}
+	case alertStatePending:
+		if !breached {
+			updates["state"] = alertStateOK
+			updates["pending_since"] = nil
+		} else if rule.PendingSince == nil || now.Sub(*rule.PendingSince) >= time.Duration(rule.Duration)*time.Second {
+			fireAlert(rule, value, now, updates)
+		}
+	default:
+		if !breached {
+			break
+		}
+		if rule.Duration <= 0 {
+			fireAlert(rule, value, now, updates)
+		} else {
+			updates["state"] = alertStatePending
+			updates["pending_since"] = now
+		}
+	}
+	return updates
+}
+
+func fireAlert(rule model.AlertRule, value float64, now time.Time, updates map[string]interface{}) {
+	updates["state"] = alertStateFiring
+	updates["fired_at"] = now
+	if !alertSilenced(rule, now) {
+		notifyAlert(rule, value, true)
+	}
+}
+
+func alertBreached(rule model.AlertRule, value float64) bool {
+	if rule.Operator == "lt" {
+		return value < rule.Threshold
+	}
+	return value > rule.Threshold
+}
+
+func alertRecovered(rule model.AlertRule, value float64) bool {
+	if rule.Operator == "lt" {
+		return value >= rule.Threshold+rule.Hysteresis
+	}
+	return value <= rule.Threshold-rule.Hysteresis
+}
+
+// alertSilenced 临时静默或处于每日静默时段内时不发送通知，状态仍正常推进
+func alertSilenced(rule model.AlertRule, now time.Time) bool {
+	if rule.MutedUntil != nil && now.Before(*rule.MutedUntil) {
+		return true
+	}
+	start, okStart := parseClock(rule.SilenceStart)
+	end, okEnd := parseClock(rule.SilenceEnd)
+	if !okStart || !okEnd || start == end {
+		return false
+	}
+	current := now.Hour()*60 + now.Minute()
+	if start < end {
+		return current >= start && current < end
+	}
+	return current >= start || current < end
+}
+
+func notifyAlert(rule model.AlertRule, value float64, firing bool) {
+	subject := alertMetricLabels[rule.Metric]
+	if rule.Target != "" {
+		subject += "（" + rule.Target + "）"
+	}
+	unit := alertMetricUnits[rule.Metric]
+	if unit == "" {
+		unit = "%"
+	}
+	op := ">"
+	if rule.Operator == "lt" {
+		op = "<"
+	}
+	if firing {
+		content := fmt.Sprintf("%s 当前 %.1f%s，触发条件 %s %.1f%s", subject, value, unit, op, rule.Threshold, unit)
+		if rule.Duration > 0 {
+			content += fmt.Sprintf("，持续 %d 秒", rule.Duration)
+		}
+		CreateNotification(dto.NotificationCreate{
+			Type:      "error",
+			Event:     "monitor.alert.firing",
+			Title:     fmt.Sprintf("告警：%s", rule.Name),
+			Content:   content,
+			Source:    "monitor",
+			TargetURL: "/host/monitor",
+		})
+		return
+	}
+	CreateNotification(dto.NotificationCreate{
+		Type:      "success",
+		Event:     "monitor.alert.resolved",
+		Title:     fmt.Sprintf("告警恢复：%s", rule.Name),
+		Content:   fmt.Sprintf("%s 已恢复，当前 %.1f%s", subject, value, unit),
+		Source:    "monitor",
+		TargetURL: "/host/monitor",
+	})
+}
+
+func validateAlertRule(req *dto.AlertRuleCreate) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Target = strings.TrimSpace(req.Target)
+	if req.Operator == "" {
+		req.Operator = "gt"
+	}
+	if req.Name == "" {
+		return buserr.New(constant.ErrInvalidParams)
+	}
+	if req.Metric == alertMetricTraffic && req.Target == "" {
+		return buserr.WithDetail(constant.ErrInvalidParams, "traffic alert requires an interface", nil)
+	}
+	if req.Metric == alertMetricDisk && req.Target == "" {
+		req.Target = "/"
+	}
+	for _, clock := range []string{req.SilenceStart, req.SilenceEnd} {
+		if _, ok := parseClock(clock); clock != "" && !ok {
+			return buserr.WithDetail(constant.ErrInvalidParams, "invalid silence time: "+clock, nil)
+		}
+	}
+	return nil
+}
+
+func parseClock(value string) (int, bool) {
+	if value == "" {
+		return 0, false
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+func readAlertMetric(rule model.AlertRule) (float64, error) {
+	switch rule.Metric {
+	case alertMetricCPU:
+		if base, ok := latestMonitorBase(); ok {
+			return base.Cpu, nil
+		}
+		percent, err := cpu.Percent(time.Second, false)
+		if err != nil || len(percent) == 0 {
+			return 0, errors.New("cpu usage unavailable")
+		}
+		return percent[0], nil
+	case alertMetricMemory:
+		info, err := mem.VirtualMemory()
+		if err != nil {
+			return 0, err
+		}
+		return info.UsedPercent, nil
+	case alertMetricLoad:
+		avg, err := load.Avg()
+		if err != nil {
+			return 0, err
+		}
+		cores, _ := cpu.Counts(true)
+		if cores <= 0 {
+			cores = 1
+		}
+		return avg.Load1 / float64(cores) * 100, nil
+	case alertMetricDisk:
+		path := rule.Target
+		if path == "" {
+			path = "/"
+		}
+		usage, err := disk.Usage(path)
+		if err != nil {
+			return 0, err
+		}
+		return usage.UsedPercent, nil
+	case alertMetricTemperature:
+		var (
+			found   bool
+			highest float64
+		)
+		for _, sensor := range loadSensorTemps() {
+			if rule.Target != "" && sensor.Key != rule.Target {
+				continue
+			}
+			if !found || sensor.Temp > highest {
+				highest = sensor.Temp
+			}
+			found = true
+		}
+		if !found {
+			return 0, errors.New("no matching temperature sensor")
+		}
+		return highest, nil
+	case alertMetricTraffic:
+		return trafficUsedPercent(rule.Target, time.Now())
+	}
+	return 0, fmt.Errorf("unsupported metric %s", rule.Metric)
+}
+
+// latestMonitorBase 取最近一次监控采样，超过两个采集周期视为过期
+func latestMonitorBase() (model.MonitorBase, bool) {
+	var base model.MonitorBase
+	if global.MonitorDB == nil {
+		return base, false
+	}
+	interval := 300
+	if value, err := settingRepo.GetValueByKey("MonitorInterval"); err == nil {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			interval = parsed
+		}
+	}
+	cutoff := time.Now().Add(-2 * time.Duration(interval) * time.Second)
+	if err := global.MonitorDB.Where("created_at >= ?", cutoff).Order("created_at DESC").First(&base).Error; err != nil {
+		return base, false
+	}
+	return base, true
+}
+
+// trafficUsedPercent 当前计费周期内网卡流量占月度额度的百分比
+func trafficUsedPercent(interfaceName string, now time.Time) (float64, error) {
+	trafficRepo := repo.NewITrafficRepo()
+	config, err := trafficRepo.GetConfig(interfaceName)
+	if err != nil {
+		return 0, err
+	}
+	if config.MonthlyLimit == 0 {
+		return 0, errors.New("traffic quota not configured")
+	}
+	start, end := calcBillingPeriod(now, config.ResetDay)
+	sent, recv, err := trafficRepo.SumTraffic(interfaceName, start, end)
+	if err != nil {
+		return 0, err
+	}
+	return float64(sent+recv) / float64(config.MonthlyLimit) * 100, nil
+}
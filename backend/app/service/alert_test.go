@@ -0,0 +1,128 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/global"
+
+	"github.com/sirupsen/logrus"
+)
+
+func installAlertDB(t *testing.T) {
+	t.Helper()
+	installNotificationDB(t)
+	if err := global.DB.AutoMigrate(&model.AlertRule{}); err != nil {
+		t.Fatal(err)
+	}
+	previousLog, previousReader := global.LOG, alertMetricReader
+	global.LOG = logrus.New()
+	t.Cleanup(func() {
+		global.LOG = previousLog
+		alertMetricReader = previousReader
+	})
+}
+
+func TestAlertRuleFiresAfterDurationAndRecoversWithHysteresis(t *testing.T) {
+	installAlertDB(t)
+	if err := NewIAlertService().Create(dto.AlertRuleCreate{
+		Name: "cpu-high", Metric: "cpu", Threshold: 90, Hysteresis: 10, Duration: 300, NotifyRecovery: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	value := 95.0
+	alertMetricReader = func(model.AlertRule) (float64, error) { return value, nil }
+
+	EvaluateAlertRules()
+	rule, _ := alertRuleRepo.Get()
+	if rule.State != alertStatePending || rule.PendingSince == nil {
+		t.Fatalf("state after first breach = %s", rule.State)
+	}
+	// 模拟持续时间已满
+	past := time.Now().Add(-6 * time.Minute)
+	_ = alertRuleRepo.Update(rule.ID, map[string]interface{}{"pending_since": past})
+	EvaluateAlertRules()
+	rule, _ = alertRuleRepo.Get()
+	if rule.State != alertStateFiring {
+		t.Fatalf("state after duration = %s", rule.State)
+	}
+
+	// 处于滞回区间内不恢复
+	value = 85
+	EvaluateAlertRules()
+	if rule, _ = alertRuleRepo.Get(); rule.State != alertStateFiring {
+		t.Fatalf("recovered inside hysteresis band: %s", rule.State)
+	}
+	value = 75
+	EvaluateAlertRules()
+	if rule, _ = alertRuleRepo.Get(); rule.State != alertStateOK {
+		t.Fatalf("did not recover: %s", rule.State)
+	}
+
+	items, err := NewINotificationService().Recent(10)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("notifications = %#v, %v", items, err)
+	}
+	if items[0].Event != "monitor.alert.resolved" && items[1].Event != "monitor.alert.resolved" {
+		t.Fatalf("missing recovery notification: %#v", items)
+	}
+}
+
+func TestAlertRulePendingResetsWhenConditionClears(t *testing.T) {
+	now := time.Now()
+	rule := model.AlertRule{Threshold: 80, Duration: 60, State: alertStateOK}
+	updates := evaluateAlertRule(rule, 81, now)
+	if updates["state"] != alertStatePending {
+		t.Fatalf("updates = %#v", updates)
+	}
+	rule.State = alertStatePending
+	rule.PendingSince = &now
+	updates = evaluateAlertRule(rule, 79, now.Add(30*time.Second))
+	if updates["state"] != alertStateOK {
+		t.Fatalf("pending not reset: %#v", updates)
+	}
+	lower := model.AlertRule{Operator: "lt", Threshold: 10, State: alertStateOK}
+	if updates := evaluateAlertRule(lower, 20, now); updates["state"] != nil {
+		t.Fatalf("lt rule fired above threshold: %#v", updates)
+	}
+}
+
+func TestAlertSilenceWindows(t *testing.T) {
+	at := func(clock string) time.Time {
+		parsed, _ := time.ParseInLocation("15:04", clock, time.Local)
+		return time.Date(2026, 1, 1, parsed.Hour(), parsed.Minute(), 0, 0, time.Local)
+	}
+	overnight := model.AlertRule{SilenceStart: "23:00", SilenceEnd: "07:00"}
+	if !alertSilenced(overnight, at("02:30")) || alertSilenced(overnight, at("12:00")) {
+		t.Fatal("overnight silence window evaluated incorrectly")
+	}
+	daytime := model.AlertRule{SilenceStart: "09:00", SilenceEnd: "18:00"}
+	if !alertSilenced(daytime, at("09:00")) || alertSilenced(daytime, at("18:00")) {
+		t.Fatal("daytime silence window evaluated incorrectly")
+	}
+	until := at("12:00").Add(time.Hour)
+	muted := model.AlertRule{MutedUntil: &until}
+	if !alertSilenced(muted, at("12:30")) || alertSilenced(muted, at("13:30")) {
+		t.Fatal("mute evaluated incorrectly")
+	}
+}
+
+func TestAlertRuleValidation(t *testing.T) {
+	installAlertDB(t)
+	svc := NewIAlertService()
+	if err := svc.Create(dto.AlertRuleCreate{Name: "quota", Metric: "traffic", Threshold: 80}); err == nil {
+		t.Fatal("traffic rule without interface accepted")
+	}
+	if err := svc.Create(dto.AlertRuleCreate{Name: "disk", Metric: "disk", Threshold: 85, SilenceStart: "25:00", SilenceEnd: "07:00"}); err == nil {
+		t.Fatal("invalid silence time accepted")
+	}
+	if err := svc.Create(dto.AlertRuleCreate{Name: "disk", Metric: "disk", Threshold: 85}); err != nil {
+		t.Fatal(err)
+	}
+	rule, _ := alertRuleRepo.Get()
+	if rule.Target != "/" || rule.Operator != "gt" {
+		t.Fatalf("defaults not applied: %#v", rule)
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net"
+	"strings"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+)
+
+const (
+	apiKeyIDSize     = 6
+	apiKeySecretSize = 24
+	// apiKeyAccountPrefix 写入上下文与操作日志的账户名前缀，便于审计区分
+	apiKeyAccountPrefix = "api-key:"
+)
+
+var apiKeyRepo = repo.NewIAPIKeyRepo()
+
+// IAPIKeyService API Key 管理（仅管理员）
+type IAPIKeyService interface {
+	SearchWithPage(req dto.SearchWithPage) (int64, []dto.APIKeyInfo, error)
+	Create(req dto.APIKeyCreate) (*dto.APIKeyCreated, error)
+	Update(req dto.APIKeyUpdate) error
+	Delete(id uint) error
+}
+
+type APIKeyService struct{}
+
+func NewIAPIKeyService() IAPIKeyService {
+	return &APIKeyService{}
+}
+
+func (s *APIKeyService) SearchWithPage(req dto.SearchWithPage) (int64, []dto.APIKeyInfo, error) {
+	total, keys, err := apiKeyRepo.Page(req.Page, req.PageSize, repo.WithLikeName(req.Info))
+	if err != nil {
+		return 0, nil, err
+	}
+	items := make([]dto.APIKeyInfo, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, dto.APIKeyInfo{
+			ID:          key.ID,
+			Name:        key.Name,
+			KeyID:       key.KeyID,
+			Modules:     splitModules(key.Modules),
+			Access:      key.Access,
+			AllowIPs:    splitModules(key.AllowIPs),
+			Status:      key.Status,
+			Description: key.Description,
+			ExpiresAt:   key.ExpiresAt,
+			LastUsedAt:  key.LastUsedAt,
+			LastUsedIP:  key.LastUsedIP,
+			CreatedAt:   key.CreatedAt,
+		})
+	}
+	return total, items, nil
+}
+
+func (s *APIKeyService) Create(req dto.APIKeyCreate) (*dto.APIKeyCreated, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, buserr.New(constant.ErrInvalidParams)
+	}
+	if _, err := apiKeyRepo.Get(repo.WithByName(name)); err == nil {
+		return nil, buserr.New(constant.ErrRecordExist)
+	}
+	modules, err := normalizeModules(req.Modules)
+	if err != nil {
+		return nil, err
+	}
+	allowIPs, err := normalizeAllowIPs(req.AllowIPs)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := randomHex(apiKeyIDSize)
+	if err != nil {
+		return nil, buserr.WithErr(constant.ErrInternalServer, err)
+	}
+	secret, err := randomHex(apiKeySecretSize)
+	if err != nil {
+		return nil, buserr.WithErr(constant.ErrInternalServer, err)
+	}
+	key := model.APIKey{
+		Name:        name,
+		KeyID:       keyID,
+		KeyHash:     hashAPIKeySecret(secret),
+		Modules:     modules,
+		Access:      req.Access,
+		AllowIPs:    allowIPs,
+		Status:      constant.StatusEnable,
+		Description: req.Description,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if err := apiKeyRepo.Create(&key); err != nil {
+		return nil, buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	global.LOG.Infof("API key created: %s (%s)", name, req.Access)
+	return &dto.APIKeyCreated{
+		ID:   key.ID,
+		Name: key.Name,
+		Key:  constant.APIKeyPrefix + keyID + "_" + secret,
+	}, nil
+}
+
+func (s *APIKeyService) Update(req dto.APIKeyUpdate) error {
+	if _, err := apiKeyRepo.Get(repo.WithByID(req.ID)); err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	modules, err := normalizeModules(req.Modules)
+	if err != nil {
+		return err
+	}
+	allowIPs, err := normalizeAllowIPs(req.AllowIPs)
+	if err != nil {
+		return err
+	}
+	return apiKeyRepo.Update(req.ID, map[string]interface{}{
+		"modules":     modules,
+		"access":      req.Access,
+		"allow_ips":   allowIPs,
+		"expires_at":  req.ExpiresAt,
+		"status":      req.Status,
+		"description": req.Description,
+	})
+}
+
+func (s *APIKeyService) Delete(id uint) error {
+	if _, err := apiKeyRepo.Get(repo.WithByID(id)); err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	return apiKeyRepo.Delete(repo.WithByID(id))
+}
+
+// AuthenticateAPIKey 校验 API Key 及来源 IP，返回按作用域映射的账户权限：
+// 写权限映射为 operator，只读映射为 readonly，API Key 不能访问系统模块
+func AuthenticateAPIKey(raw, clientIP string) (*dto.AccountInfo, error) {
+	keyID, secret, ok := parseAPIKey(raw)
+	if !ok {
+		return nil, buserr.New(constant.ErrAPIKeyInvalid)
+	}
+	key, err := apiKeyRepo.Get(repo.WithByKeyID(keyID))
+	if err != nil {
+		return nil, buserr.New(constant.ErrAPIKeyInvalid)
+	}
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashAPIKeySecret(secret))) != 1 {
+		return nil, buserr.New(constant.ErrAPIKeyInvalid)
+	}
+	now := time.Now()
+	if key.Status != constant.StatusEnable || (key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)) {
+		return nil, buserr.New(constant.ErrAPIKeyInvalid)
+	}
+	if !ipAllowed(key.AllowIPs, clientIP) {
+		return nil, buserr.New(constant.ErrAPIKeyInvalid)
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= sessionTouchInterval || key.LastUsedIP != clientIP {
+		if err := apiKeyRepo.Update(key.ID, map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": clientIP,
+		}); err != nil {
+			global.LOG.Warnf("Failed to update API key usage: %v", err)
+		}
+	}
+
+	role := constant.RoleReadOnly
+	if key.Access == constant.APIKeyAccessWrite {
+		role = constant.RoleOperator
+	}
+	return &dto.AccountInfo{
+		Name:    apiKeyAccountPrefix + key.Name,
+		Role:    role,
+		Modules: splitModules(key.Modules),
+	}, nil
+}
+
+func parseAPIKey(raw string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(raw), constant.APIKeyPrefix)
+	if !ok {
+		return "", "", false
+	}
+	keyID, secret, ok := strings.Cut(rest, "_")
+	if !ok || keyID == "" || secret == "" {
+		return "", "", false
+	}
+	return keyID, secret, true
+}
+
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// normalizeAllowIPs 校验并规范化 IP/CIDR 允许列表
+func normalizeAllowIPs(entries []string) (string, error) {
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			entry = network.String()
+		} else if ip := net.ParseIP(entry); ip != nil {
+			entry = ip.String()
+		} else {
+			return "", buserr.WithDetail(constant.ErrInvalidParams, "invalid IP or CIDR: "+entry, nil)
+		}
+		result = append(result, entry)
+	}
+	return strings.Join(result, ","), nil
+}
+
+func ipAllowed(allowIPs, clientIP string) bool {
+	if strings.TrimSpace(allowIPs) == "" {
+		return true
+	}
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, entry := range strings.Split(allowIPs, ",") {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/constant"
+	"xpanel/global"
+)
+
+func TestAPIKeyAuthenticationScopes(t *testing.T) {
+	openPanelUserDatabase(t)
+	if err := global.DB.AutoMigrate(&model.APIKey{}); err != nil {
+		t.Fatal(err)
+	}
+	keys := NewIAPIKeyService()
+
+	created, err := keys.Create(dto.APIKeyCreate{
+		Name:     "ci",
+		Modules:  []string{constant.ModuleWebsite, constant.ModuleCronjob},
+		Access:   constant.APIKeyAccessWrite,
+		AllowIPs: []string{"10.0.0.0/8", " 192.168.1.5 "},
+	})
+	if err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	if _, err := keys.Create(dto.APIKeyCreate{Name: "ci", Access: constant.APIKeyAccessRead}); err == nil {
+		t.Fatal("duplicate key name accepted")
+	}
+	if _, err := keys.Create(dto.APIKeyCreate{Name: "bad", Access: constant.APIKeyAccessRead, AllowIPs: []string{"not-an-ip"}}); err == nil {
+		t.Fatal("invalid allowlist accepted")
+	}
+
+	account, err := AuthenticateAPIKey(created.Key, "10.1.2.3")
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if account.Name != "api-key:ci" || account.Role != constant.RoleOperator || len(account.Modules) != 2 {
+		t.Fatalf("account = %#v", account)
+	}
+	if _, err := AuthenticateAPIKey(created.Key, "192.168.1.5"); err != nil {
+		t.Fatalf("single allowed IP rejected: %v", err)
+	}
+	if _, err := AuthenticateAPIKey(created.Key, "172.16.0.1"); err == nil {
+		t.Fatal("key accepted from outside the allowlist")
+	}
+	if _, err := AuthenticateAPIKey(created.Key+"x", "10.1.2.3"); err == nil {
+		t.Fatal("tampered key accepted")
+	}
+
+	stored, err := apiKeyRepo.Get()
+	if err != nil || stored.LastUsedAt == nil || stored.LastUsedIP == "" {
+		t.Fatalf("last used not tracked: %#v, %v", stored, err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	if err := keys.Update(dto.APIKeyUpdate{
+		ID: created.ID, Access: constant.APIKeyAccessRead, Status: constant.StatusEnable, ExpiresAt: &past,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AuthenticateAPIKey(created.Key, "8.8.8.8"); err == nil {
+		t.Fatal("expired key accepted")
+	}
+
+	if err := keys.Update(dto.APIKeyUpdate{
+		ID: created.ID, Access: constant.APIKeyAccessRead, Status: constant.StatusDisable,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AuthenticateAPIKey(created.Key, "8.8.8.8"); err == nil {
+		t.Fatal("disabled key accepted")
+	}
+	if err := keys.Update(dto.APIKeyUpdate{
+		ID: created.ID, Access: constant.APIKeyAccessRead, Status: constant.StatusEnable,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	account, err = AuthenticateAPIKey(created.Key, "8.8.8.8")
+	if err != nil || account.Role != constant.RoleReadOnly || len(account.Modules) != 0 {
+		t.Fatalf("read-only key = %#v, %v", account, err)
+	}
+}
@@ -20,6 +20,14 @@ type IAuthService interface {
 	IsInitialized() bool
 	UpdatePassword(userName string, info dto.PasswordUpdate) error
 	GetLoginSetting() (*dto.LoginSetting, error)
+	GetAccount(userName string) (*dto.AccountInfo, error)
+
+	MFALogin(info dto.MFALogin) (*dto.UserLoginInfo, error)
+	GetMFAStatus(userName string) (*dto.MFAStatus, error)
+	InitMFA(userName string) (*dto.MFAInitInfo, error)
+	BindMFA(userName string, req dto.MFABind) (*dto.MFARecoveryCodes, error)
+	DisableMFA(userName string, req dto.MFADisable) error
+	RegenerateRecoveryCodes(userName string, req dto.MFAVerify) (*dto.MFARecoveryCodes, error)
 }
 
 // NewIAuthService 创建认证服务实例
@@ -33,51 +41,76 @@ const rememberLoginTimeout = 86400 * 30 // 30 天
 
 var settingRepo = repo.NewISettingRepo()
 var logRepo = repo.NewILogRepo()
+var userRepo = repo.NewIUserRepo()
 
 func (a *AuthService) Login(info dto.Login) (*dto.UserLoginInfo, error) {
-	// 校验用户名
+	name, err := a.checkCredentials(info.Name, info.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查 MFA 状态：开启时仅返回状态，由 /auth/mfa-login 校验动态码后签发 Token
+	state, err := loadMFAState(name)
+	if err != nil {
+		return nil, err
+	}
+	if state.Status == constant.StatusEnable {
+		return &dto.UserLoginInfo{
+			Name:      name,
+			MfaStatus: state.Status,
+		}, nil
+	}
+
+	return a.issueLoginToken(name, info.Remember, info.IP, info.Agent)
+}
+
+// checkCredentials 校验用户名和密码（内置管理员或子账户），返回已存储的用户名
+func (a *AuthService) checkCredentials(name, password string) (string, error) {
 	nameSetting, err := settingRepo.Get(repo.WithByKey("UserName"))
 	if err != nil {
-		return nil, buserr.New(constant.ErrUserNotFound)
+		return "", buserr.New(constant.ErrUserNotFound)
 	}
-	if nameSetting.Value != info.Name {
-		return nil, buserr.New(constant.ErrAuth)
+	if nameSetting.Value != name {
+		user, err := userRepo.Get(repo.WithByName(name))
+		if err != nil || !encrypt.CheckPassword(password, user.Password) {
+			return "", buserr.New(constant.ErrAuth)
+		}
+		if user.Status != constant.StatusEnable {
+			return "", buserr.New(constant.ErrUserDisabled)
+		}
+		return user.Name, nil
 	}
 
-	// 校验密码
 	passwordSetting, err := settingRepo.Get(repo.WithByKey("Password"))
 	if err != nil {
-		return nil, buserr.New(constant.ErrAuth)
+		return "", buserr.New(constant.ErrAuth)
 	}
 	if passwordSetting.Value == "" {
-		return nil, buserr.New(constant.ErrInitialPassword)
+		return "", buserr.New(constant.ErrInitialPassword)
 	}
-	if !encrypt.CheckPassword(info.Password, passwordSetting.Value) {
-		return nil, buserr.New(constant.ErrAuth)
+	if !encrypt.CheckPassword(password, passwordSetting.Value) {
+		return "", buserr.New(constant.ErrAuth)
 	}
+	return nameSetting.Value, nil
+}
 
-	// 检查 MFA 状态
-	mfaSetting, _ := settingRepo.Get(repo.WithByKey("MFAStatus"))
-	if mfaSetting.Value == constant.StatusEnable {
-		return &dto.UserLoginInfo{
-			Name:      nameSetting.Value,
-			MfaStatus: mfaSetting.Value,
-		}, nil
+// issueLoginToken 登记会话并生成 JWT Token；保持登录用于可信设备，关闭浏览器后仍可恢复登录态。
+func (a *AuthService) issueLoginToken(name string, remember bool, ip, agent string) (*dto.UserLoginInfo, error) {
+	timeout := sessionTimeoutSeconds()
+	if remember {
+		timeout = rememberLoginTimeout
 	}
-
-	// 生成 JWT Token；保持登录用于可信设备，关闭浏览器后仍可恢复登录态。
-	var token string
-	if info.Remember {
-		token, err = jwtUtil.GenerateTokenWithTimeout(info.Name, rememberLoginTimeout)
-	} else {
-		token, err = jwtUtil.GenerateToken(info.Name)
+	sessionID, err := createSession(name, ip, agent, remember, timeout)
+	if err != nil {
+		return nil, buserr.WithErr(constant.ErrInternalServer, err)
 	}
+	token, err := jwtUtil.GenerateSessionToken(name, sessionID, timeout)
 	if err != nil {
 		return nil, buserr.WithErr(constant.ErrInternalServer, err)
 	}
 
 	return &dto.UserLoginInfo{
-		Name:  nameSetting.Value,
+		Name:  name,
 		Token: token,
 	}, nil
 }
@@ -115,11 +148,7 @@ func (a *AuthService) IsInitialized() bool {
 
 func (a *AuthService) UpdatePassword(userName string, info dto.PasswordUpdate) error {
 	// 校验旧密码
-	passwordSetting, err := settingRepo.Get(repo.WithByKey("Password"))
-	if err != nil {
-		return err
-	}
-	if !encrypt.CheckPassword(info.OldPassword, passwordSetting.Value) {
+	if !verifyAccountPassword(userName, info.OldPassword) {
 		return buserr.New(constant.ErrPasswordWrong)
 	}
 
@@ -128,7 +157,34 @@ func (a *AuthService) UpdatePassword(userName string, info dto.PasswordUpdate) e
 	if err != nil {
 		return buserr.WithErr(constant.ErrInternalServer, err)
 	}
-	return settingRepo.Update("Password", hashed)
+	if isBuiltinAdmin(userName) {
+		err = settingRepo.Update("Password", hashed)
+	} else {
+		user, getErr := userRepo.Get(repo.WithByName(userName))
+		if getErr != nil {
+			return buserr.New(constant.ErrUserNotFound)
+		}
+		err = userRepo.Update(user.ID, map[string]interface{}{"password": hashed})
+	}
+	if err != nil {
+		return err
+	}
+	// 改密后所有设备需重新登录
+	return RevokeUserSessions(userName)
+}
+
+func (a *AuthService) GetAccount(userName string) (*dto.AccountInfo, error) {
+	return ResolveAccount(userName)
+}
+
+// verifyAccountPassword 校验当前账户密码（内置管理员或子账户）
+func verifyAccountPassword(userName, password string) bool {
+	if isBuiltinAdmin(userName) {
+		hash, err := settingRepo.GetValueByKey("Password")
+		return err == nil && encrypt.CheckPassword(password, hash)
+	}
+	user, err := userRepo.Get(repo.WithByName(userName))
+	return err == nil && encrypt.CheckPassword(password, user.Password)
 }
 
 func (a *AuthService) GetLoginSetting() (*dto.LoginSetting, error) {
@@ -144,10 +200,11 @@ func (a *AuthService) GetLoginSetting() (*dto.LoginSetting, error) {
 }
 
 // SaveLoginLog 保存登录日志（供 API 层调用）
-func SaveLoginLog(ip, agent string, err error) {
+func SaveLoginLog(userName, ip, agent string, err error) {
 	log := &model.LoginLog{
-		IP:    ip,
-		Agent: agent,
+		UserName: userName,
+		IP:       ip,
+		Agent:    agent,
 	}
 	if err != nil {
 		log.Status = constant.StatusFailed
@@ -162,7 +219,7 @@ func SaveLoginLog(ip, agent string, err error) {
 		CreateNotification(dto.NotificationCreate{
 			Type:      "error",
 			Event:     "security.login.failed",
-			Title:     fmt.Sprintf("面板登录失败（%s）", ip),
+			Title:     fmt.Sprintf("面板登录失败（%s，%s）", userName, ip),
 			Content:   err.Error(),
 			Source:    "security",
 			TargetURL: "/log/login",
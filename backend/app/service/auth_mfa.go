@@ -0,0 +1,304 @@
+package service
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/utils/mfa"
+)
+
+const (
+	mfaStatusKey        = "MFAStatus"
+	mfaSecretKey        = "MFASecret"
+	mfaPendingSecretKey = "MFAPendingSecret"
+	mfaRecoveryCodesKey = "MFARecoveryCodes"
+	mfaLastStepKey      = "MFALastStep"
+
+	mfaRecoveryCodeCount = 10
+)
+
+// mfaMu 串行化动态码校验与恢复码消费，避免同一动态码/恢复码被并发重复使用
+var mfaMu sync.Mutex
+
+// mfaState 账户的两步验证状态；内置管理员存于 Setting，子账户存于 users 表
+type mfaState struct {
+	Status        string
+	Secret        string
+	PendingSecret string
+	RecoveryCodes string
+	LastStep      int64
+}
+
+// MFALogin 二次校验用户名密码后校验动态码或恢复码，通过后签发 Token
+func (a *AuthService) MFALogin(info dto.MFALogin) (*dto.UserLoginInfo, error) {
+	name, err := a.checkCredentials(info.Name, info.Password)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyMFACode(name, info.Code); err != nil {
+		return nil, err
+	}
+	return a.issueLoginToken(name, info.Remember, info.IP, info.Agent)
+}
+
+func (a *AuthService) GetMFAStatus(userName string) (*dto.MFAStatus, error) {
+	state, err := loadMFAState(userName)
+	if err != nil {
+		return nil, err
+	}
+	hashes, err := parseRecoveryCodeHashes(state.RecoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.MFAStatus{
+		Enabled:                state.Status == constant.StatusEnable,
+		RecoveryCodesRemaining: len(hashes),
+	}, nil
+}
+
+// InitMFA 生成待绑定的密钥，绑定成功前不会影响当前登录方式
+func (a *AuthService) InitMFA(userName string) (*dto.MFAInitInfo, error) {
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+
+	state, err := loadMFAState(userName)
+	if err != nil {
+		return nil, err
+	}
+	if state.Status == constant.StatusEnable {
+		return nil, buserr.New(constant.ErrMFAAlreadyEnabled)
+	}
+	secret, err := mfa.GenerateSecret()
+	if err != nil {
+		return nil, buserr.WithErr(constant.ErrInternalServer, err)
+	}
+	state.PendingSecret = secret
+	if err := saveMFAState(userName, state); err != nil {
+		return nil, err
+	}
+	issuer, _ := settingRepo.GetValueByKey("PanelName")
+	if strings.TrimSpace(issuer) == "" {
+		issuer = constant.DefaultPanelName
+	}
+	return &dto.MFAInitInfo{
+		Secret: secret,
+		URI:    mfa.BuildURI(issuer, userName, secret),
+	}, nil
+}
+
+// BindMFA 用认证器生成的动态码确认待绑定密钥，启用 MFA 并一次性返回恢复码
+func (a *AuthService) BindMFA(userName string, req dto.MFABind) (*dto.MFARecoveryCodes, error) {
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+
+	state, err := loadMFAState(userName)
+	if err != nil {
+		return nil, err
+	}
+	if state.PendingSecret == "" {
+		return nil, buserr.New(constant.ErrMFANotInitialized)
+	}
+	step, ok := mfa.Validate(state.PendingSecret, req.Code, time.Now(), 0)
+	if !ok {
+		return nil, buserr.New(constant.ErrMFACodeInvalid)
+	}
+	codes, hashed, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := saveMFAState(userName, mfaState{
+		Status:        constant.StatusEnable,
+		Secret:        state.PendingSecret,
+		RecoveryCodes: hashed,
+		LastStep:      step,
+	}); err != nil {
+		return nil, err
+	}
+	return &dto.MFARecoveryCodes{Codes: codes}, nil
+}
+
+// DisableMFA 关闭 MFA，需同时提供登录密码与动态码（或恢复码）
+func (a *AuthService) DisableMFA(userName string, req dto.MFADisable) error {
+	if !verifyAccountPassword(userName, req.Password) {
+		return buserr.New(constant.ErrPasswordWrong)
+	}
+	if err := verifyMFACode(userName, req.Code); err != nil {
+		return err
+	}
+	return ResetMFA(userName)
+}
+
+// RegenerateRecoveryCodes 作废旧恢复码并生成一组新的
+func (a *AuthService) RegenerateRecoveryCodes(userName string, req dto.MFAVerify) (*dto.MFARecoveryCodes, error) {
+	if err := verifyMFACode(userName, req.Code); err != nil {
+		return nil, err
+	}
+
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+	state, err := loadMFAState(userName)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashed, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	state.RecoveryCodes = hashed
+	if err := saveMFAState(userName, state); err != nil {
+		return nil, err
+	}
+	return &dto.MFARecoveryCodes{Codes: codes}, nil
+}
+
+// ResetMFA 清空账户的 MFA 密钥与恢复码并关闭 MFA（供 API 与 CLI 共用）；
+// userName 为空时表示内置管理员
+func ResetMFA(userName string) error {
+	if userName == "" {
+		userName, _ = settingRepo.GetValueByKey("UserName")
+	}
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+	return saveMFAState(userName, mfaState{Status: constant.StatusDisable})
+}
+
+// verifyMFACode 优先按 TOTP 动态码校验，失败后尝试消费一次性恢复码
+func verifyMFACode(userName, code string) error {
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+
+	state, err := loadMFAState(userName)
+	if err != nil {
+		return err
+	}
+	if state.Status != constant.StatusEnable {
+		return buserr.New(constant.ErrMFANotEnabled)
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return buserr.New(constant.ErrMFACodeInvalid)
+	}
+	if state.Secret != "" {
+		if step, ok := mfa.Validate(state.Secret, code, time.Now(), state.LastStep); ok {
+			state.LastStep = step
+			return saveMFAState(userName, state)
+		}
+	}
+
+	hashes, err := parseRecoveryCodeHashes(state.RecoveryCodes)
+	if err != nil {
+		return err
+	}
+	target := mfa.HashRecoveryCode(code)
+	for i, hash := range hashes {
+		if hash != target {
+			continue
+		}
+		remaining := append(hashes[:i:i], hashes[i+1:]...)
+		data, err := json.Marshal(remaining)
+		if err != nil {
+			return err
+		}
+		state.RecoveryCodes = string(data)
+		return saveMFAState(userName, state)
+	}
+	return buserr.New(constant.ErrMFACodeInvalid)
+}
+
+func loadMFAState(userName string) (mfaState, error) {
+	if isBuiltinAdmin(userName) {
+		settings, err := settingRepo.GetList(repo.WithByKeys(
+			mfaStatusKey, mfaSecretKey, mfaPendingSecretKey, mfaRecoveryCodesKey, mfaLastStepKey,
+		))
+		if err != nil {
+			return mfaState{}, err
+		}
+		values := make(map[string]string, len(settings))
+		for _, item := range settings {
+			values[item.Key] = item.Value
+		}
+		lastStep, _ := strconv.ParseInt(values[mfaLastStepKey], 10, 64)
+		return mfaState{
+			Status:        values[mfaStatusKey],
+			Secret:        values[mfaSecretKey],
+			PendingSecret: values[mfaPendingSecretKey],
+			RecoveryCodes: values[mfaRecoveryCodesKey],
+			LastStep:      lastStep,
+		}, nil
+	}
+	user, err := userRepo.Get(repo.WithByName(userName))
+	if err != nil {
+		return mfaState{}, buserr.New(constant.ErrUserNotFound)
+	}
+	return mfaState{
+		Status:        user.MFAStatus,
+		Secret:        user.MFASecret,
+		PendingSecret: user.MFAPendingSecret,
+		RecoveryCodes: user.MFARecoveryCodes,
+		LastStep:      user.MFALastStep,
+	}, nil
+}
+
+func saveMFAState(userName string, state mfaState) error {
+	if state.Status == "" {
+		state.Status = constant.StatusDisable
+	}
+	if isBuiltinAdmin(userName) {
+		lastStep := ""
+		if state.LastStep > 0 {
+			lastStep = strconv.FormatInt(state.LastStep, 10)
+		}
+		return settingRepo.CreateOrUpdateMany(map[string]string{
+			mfaStatusKey:        state.Status,
+			mfaSecretKey:        state.Secret,
+			mfaPendingSecretKey: state.PendingSecret,
+			mfaRecoveryCodesKey: state.RecoveryCodes,
+			mfaLastStepKey:      lastStep,
+		})
+	}
+	user, err := userRepo.Get(repo.WithByName(userName))
+	if err != nil {
+		return buserr.New(constant.ErrUserNotFound)
+	}
+	return userRepo.Update(user.ID, map[string]interface{}{
+		"mfa_status":         state.Status,
+		"mfa_secret":         state.Secret,
+		"mfa_pending_secret": state.PendingSecret,
+		"mfa_recovery_codes": state.RecoveryCodes,
+		"mfa_last_step":      state.LastStep,
+	})
+}
+
+func newRecoveryCodes() ([]string, string, error) {
+	codes, err := mfa.GenerateRecoveryCodes(mfaRecoveryCodeCount)
+	if err != nil {
+		return nil, "", buserr.WithErr(constant.ErrInternalServer, err)
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, mfa.HashRecoveryCode(code))
+	}
+	data, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, "", err
+	}
+	return codes, string(data), nil
+}
+
+func parseRecoveryCodeHashes(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var hashes []string
+	if err := json.Unmarshal([]byte(value), &hashes); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
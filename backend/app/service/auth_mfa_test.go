@@ -0,0 +1,90 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/utils/encrypt"
+	"xpanel/utils/mfa"
+)
+
+func TestMFAEnrollmentLoginAndRecoveryCodes(t *testing.T) {
+	openSettingServiceDatabase(t)
+	if err := global.DB.AutoMigrate(&model.Session{}); err != nil {
+		t.Fatal(err)
+	}
+	previousSecret := global.CONF.System.JwtSecret
+	global.CONF.System.JwtSecret = "mfa-test-secret"
+	t.Cleanup(func() { global.CONF.System.JwtSecret = previousSecret })
+
+	settings := repo.NewISettingRepo()
+	hashed, err := encrypt.HashPassword("password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := settings.CreateOrUpdateMany(map[string]string{
+		"UserName": "admin", "Password": hashed, "MFAStatus": constant.StatusDisable,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	auth := NewIAuthService()
+	enrollment, err := auth.InitMFA("admin")
+	if err != nil {
+		t.Fatalf("init mfa: %v", err)
+	}
+	if status, _ := settings.GetValueByKey("MFAStatus"); status != constant.StatusDisable {
+		t.Fatalf("MFA enabled before bind: %s", status)
+	}
+
+	bindCode, _ := mfa.GenerateCode(enrollment.Secret, mfa.Step(time.Now())-1)
+	recovery, err := auth.BindMFA("admin", dto.MFABind{Code: bindCode})
+	if err != nil {
+		t.Fatalf("bind mfa: %v", err)
+	}
+	if len(recovery.Codes) != mfaRecoveryCodeCount {
+		t.Fatalf("recovery codes = %d", len(recovery.Codes))
+	}
+
+	info, err := auth.Login(dto.Login{Name: "admin", Password: "password123"})
+	if err != nil || info.Token != "" || info.MfaStatus != constant.StatusEnable {
+		t.Fatalf("password login with MFA = %#v, %v", info, err)
+	}
+
+	code, _ := mfa.GenerateCode(enrollment.Secret, mfa.Step(time.Now()))
+	info, err = auth.MFALogin(dto.MFALogin{Name: "admin", Password: "password123", Code: code})
+	if err != nil || info.Token == "" {
+		t.Fatalf("mfa login = %#v, %v", info, err)
+	}
+	if _, err := auth.MFALogin(dto.MFALogin{Name: "admin", Password: "password123", Code: code}); err == nil {
+		t.Fatal("replayed TOTP code was accepted")
+	}
+
+	login := dto.MFALogin{Name: "admin", Password: "password123", Code: recovery.Codes[0]}
+	if _, err := auth.MFALogin(login); err != nil {
+		t.Fatalf("recovery code login: %v", err)
+	}
+	if _, err := auth.MFALogin(login); err == nil {
+		t.Fatal("recovery code was accepted twice")
+	}
+	status, err := auth.GetMFAStatus("admin")
+	if err != nil || !status.Enabled || status.RecoveryCodesRemaining != mfaRecoveryCodeCount-1 {
+		t.Fatalf("mfa status = %#v, %v", status, err)
+	}
+
+	if err := auth.DisableMFA("admin", dto.MFADisable{Password: "password123", Code: recovery.Codes[1]}); err != nil {
+		t.Fatalf("disable mfa: %v", err)
+	}
+	if secret, _ := settings.GetValueByKey("MFASecret"); secret != "" {
+		t.Fatal("MFA secret retained after disable")
+	}
+	info, err = auth.Login(dto.Login{Name: "admin", Password: "password123"})
+	if err != nil || info.Token == "" {
+		t.Fatalf("password login after disable = %#v, %v", info, err)
+	}
+}
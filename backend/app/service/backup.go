@@ -34,9 +34,17 @@ type IBackupService interface {
 	SearchRecords(req dto.BackupRecordSearch) (int64, []dto.BackupRecordInfo, error)
 	DeleteRecord(id uint) error
 	PrepareRecordFile(id uint) (string, func(), error)
+	RestoreRecord(req dto.BackupRecordRestore) (*dto.BackupRecordRestoreResult, error)
+	RestoreRecordAsync(req dto.BackupRecordRestore) (*dto.BackupRecordRestoreResult, error)
+	VerifyRecord(id uint) error
+	VerifyRecordAsync(id uint) (*FileTaskStatus, error)
+	RecentVerifiableRecords(cronjobID uint, limit int) ([]model.BackupRecord, error)
 	CreateRecordForFile(backupType, name string, accountID uint, cronjobID uint, filePath string, size int64, status string, message string) error
 	CreateRecordFromOutput(backupType, name string, accountID uint, cronjobID uint, output *BackupOutput, status, message string) error
 	CleanSuccessfulRecords(cronjobID uint, retainCopies uint) error
+	ApplyRetention(job *model.Cronjob) error
+	PreviewRetention(req dto.BackupRetentionPreviewReq) (*dto.BackupRetentionPreview, error)
+	PrepareIncremental(job *model.Cronjob, backupType, name string) (*BackupChain, error)
 	ListStorageObjects(req dto.BackupStorageReq) ([]dto.BackupStorageObject, error)
 	ReadStorageObject(req dto.BackupStorageReq) (string, error)
 	SaveStorageObject(req dto.BackupStorageReq) error
@@ -58,6 +66,7 @@ type BackupOutput struct {
 	SHA256    string
 	LocalPath string
 	Log       string
+	Chain     *BackupChain
 }
 
 type BackupJobOptions struct {
@@ -66,6 +75,7 @@ type BackupJobOptions struct {
 	ExclusionRules  string
 	DeleteLocal     bool
 	SourcePath      string
+	Chain           *BackupChain
 }
 
 type backupLog struct {
@@ -102,14 +112,14 @@ func (s *BackupService) CreateAccount(req dto.BackupAccountCreate) error {
 	return s.repo.CreateAccount(&model.BackupAccount{
 		Name: req.Name, Type: req.Type, Bucket: req.Bucket,
 		AccessKey: req.AccessKey, Credential: req.Credential,
-		BackupPath: req.BackupPath, Vars: req.Vars,
+		BackupPath: req.BackupPath, Vars: req.Vars, QuotaBytes: req.QuotaBytes,
 	})
 }
 
 func (s *BackupService) UpdateAccount(req dto.BackupAccountUpdate) error {
 	fields := map[string]interface{}{
 		"name": req.Name, "bucket": req.Bucket,
-		"backup_path": req.BackupPath, "vars": req.Vars,
+		"backup_path": req.BackupPath, "vars": req.Vars, "quota_bytes": req.QuotaBytes,
 	}
 	if req.AccessKey != "" {
 		fields["access_key"] = req.AccessKey
@@ -178,7 +188,7 @@ func (s *BackupService) ListAccounts() ([]dto.BackupAccountInfo, error) {
 	for _, a := range accounts {
 		items = append(items, dto.BackupAccountInfo{
 			ID: a.ID, CreatedAt: a.CreatedAt, Name: a.Name,
-			Type: a.Type, Bucket: a.Bucket, BackupPath: a.BackupPath, Vars: a.Vars,
+			Type: a.Type, Bucket: a.Bucket, BackupPath: a.BackupPath, Vars: a.Vars, QuotaBytes: a.QuotaBytes,
 		})
 	}
 	return items, nil
@@ -426,7 +436,11 @@ func (s *BackupService) performBackupWithOptions(backupType, name, dbType, sourc
 	if err != nil {
 		return nil, err
 	}
-	return s.uploadLocalBackup(client, account.Type, localFile, targetPath, opts, log)
+	output, err := s.uploadLocalBackup(client, account.Type, localFile, targetPath, opts, log)
+	if output != nil {
+		output.Chain = opts.Chain
+	}
+	return output, err
 }
 
 func (s *BackupService) PerformDatabaseInstanceBackupWithInfo(instanceID uint, accountID uint) (*BackupOutput, error) {
@@ -489,6 +503,7 @@ func (s *BackupService) UploadExistingFile(accountID uint, localFile, targetPath
 		output = &BackupOutput{LocalPath: localFile}
 	}
 	output.Log = log.String()
+	output.Chain = opts.Chain
 	return output, err
 }
 
@@ -592,6 +607,7 @@ func (s *BackupService) backupWebsite(name, tmpDir, timestamp string, opts Backu
 		CompressFormat:  opts.CompressFormat,
 		EncryptPassword: opts.EncryptPassword,
 		ExclusionRules:  opts.ExclusionRules,
+		SnapshotFile:    opts.Chain.snapshotFile(),
 	})
 	if err != nil {
 		return "", "", err
@@ -716,7 +732,7 @@ func (s *BackupService) backupDatabase(name, dbType, tmpDir, timestamp string) (
 		}
 	}
 	if targetServer == nil {
-		targetServer = &servers[0]
+		return "", "", fmt.Errorf("no %s server hosts database %s", dbType, name)
 	}
 
 	fileName := fmt.Sprintf("db_%s_%s_%s.sql", name, dbType, timestamp)
@@ -803,6 +819,7 @@ func (s *BackupService) backupDirectory(sourceDir, name, tmpDir, timestamp strin
 		CompressFormat:  opts.CompressFormat,
 		EncryptPassword: opts.EncryptPassword,
 		ExclusionRules:  opts.ExclusionRules,
+		SnapshotFile:    opts.Chain.snapshotFile(),
 	})
 	if err != nil {
 		return "", "", err
@@ -827,7 +844,8 @@ func (s *BackupService) SearchRecords(req dto.BackupRecordSearch) (int64, []dto.
 			ID: r.ID, CreatedAt: r.CreatedAt, Type: r.Type,
 			Name: r.Name, AccountID: r.AccountID, CronjobID: r.CronjobID, FileName: r.FileName,
 			FileDir: r.FileDir, Size: r.Size, SHA256: r.SHA256, SourcePath: r.SourcePath,
-			Status: r.Status, Message: r.Message,
+			Status: r.Status, Message: r.Message, ChainID: r.ChainID, ParentID: r.ParentID, Level: r.Level,
+			VerifyStatus: r.VerifyStatus, VerifyMessage: r.VerifyMessage, VerifiedAt: r.VerifiedAt,
 		})
 	}
 	return total, items, nil
@@ -838,11 +856,18 @@ func (s *BackupService) DeleteRecord(id uint) error {
 	if err != nil {
 		return buserr.New(constant.ErrRecordNotFound)
 	}
+	if record.ChainID != 0 {
+		children, _ := s.repo.ListRecords(repo.WithBackupParentID(record.ID))
+		if len(children) > 0 {
+			return buserr.New(constant.ErrBackupChainInUse)
+		}
+	}
 	if record.Status == constant.StatusSuccess {
 		if err := s.deleteRecordFile(record); err != nil {
 			return fmt.Errorf("delete backup file failed: %v", err)
 		}
 	}
+	removeChainSnapshot(record)
 	return s.repo.DeleteRecord(id)
 }
 
@@ -877,7 +902,10 @@ func (s *BackupService) CreateRecordFromOutput(backupType, name string, accountI
 			record.Size = s.recordFileSize(accountID, record.FileDir, record.FileName)
 		}
 	}
-	return s.repo.CreateRecord(record)
+	if err := s.repo.CreateRecord(record); err != nil {
+		return err
+	}
+	return s.attachChain(record, output.Chain)
 }
 
 func (s *BackupService) CleanSuccessfulRecords(cronjobID uint, retainCopies uint) error {
@@ -891,17 +919,8 @@ func (s *BackupService) CleanSuccessfulRecords(cronjobID uint, retainCopies uint
 	if err != nil {
 		return err
 	}
-	retained := make(map[string]uint)
-	for _, record := range records {
-		key := fmt.Sprintf("%s/%s/%d", record.Type, record.Name, record.AccountID)
-		retained[key]++
-		if retained[key] <= retainCopies {
-			continue
-		}
-		if err := s.deleteRecordFile(&record); err != nil {
-			global.LOG.Warnf("delete retained backup file failed: %v", err)
-		}
-		_ = s.repo.DeleteRecord(record.ID)
+	for _, record := range expiredBackupRecords(records, retainCopies) {
+		s.pruneRecord(&record)
 	}
 	return nil
 }
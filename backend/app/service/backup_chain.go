@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+)
+
+const (
+	BackupModeFull        = "full"
+	BackupModeIncremental = "incremental"
+
+	defaultBackupChainLength uint = 7
+)
+
+// BackupChain 描述一次增量备份在链中的位置。
+// SnapshotFile 是 tar --listed-incremental 状态文件的工作副本，备份成功并落库后
+// 才会转存为该记录的状态文件，失败时链保持原样。
+type BackupChain struct {
+	ChainID      uint
+	ParentID     uint
+	Level        int
+	SnapshotFile string
+}
+
+// Release 清理未转存的状态文件工作副本。
+func (c *BackupChain) Release() {
+	if c != nil && c.SnapshotFile != "" {
+		_ = os.Remove(c.SnapshotFile)
+	}
+}
+
+func isIncrementalJob(job *model.Cronjob) bool {
+	return job.BackupMode == BackupModeIncremental && (job.Type == "website" || job.Type == "directory")
+}
+
+func chainLength(job *model.Cronjob) uint {
+	if job.ChainLength == 0 {
+		return defaultBackupChainLength
+	}
+	return job.ChainLength
+}
+
+func (c *BackupChain) snapshotFile() string {
+	if c == nil {
+		return ""
+	}
+	return c.SnapshotFile
+}
+
+func removeChainSnapshot(record *model.BackupRecord) {
+	if record.ChainID != 0 {
+		_ = os.Remove(chainSnapshotPath(record.ID))
+	}
+}
+
+// chainSnapshotPath 记录对应的 tar 增量状态文件，只保存在本机
+func chainSnapshotPath(recordID uint) string {
+	return filepath.Join(durableBackupDir("incremental"), fmt.Sprintf("%d.snar", recordID))
+}
+
+// PrepareIncremental 为计划任务选择下一次增量备份的父记录；链已满、无可用链或
+// 父记录的状态文件丢失时开始新的全量链。
+func (s *BackupService) PrepareIncremental(job *model.Cronjob, backupType, name string) (*BackupChain, error) {
+	if err := os.MkdirAll(backupTempDir(), 0750); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(backupTempDir(), "chain-*.snar")
+	if err != nil {
+		return nil, err
+	}
+	chain := &BackupChain{SnapshotFile: tmp.Name()}
+	_ = tmp.Close()
+
+	parent := s.latestChainRecord(job.ID, backupType, name)
+	if parent == nil || uint(parent.Level+1) >= chainLength(job) {
+		return chain, nil
+	}
+	if err := copyFile(chainSnapshotPath(parent.ID), chain.SnapshotFile); err != nil {
+		return chain, nil
+	}
+	chain.ChainID = parent.ChainID
+	chain.ParentID = parent.ID
+	chain.Level = parent.Level + 1
+	return chain, nil
+}
+
+func (s *BackupService) latestChainRecord(cronjobID uint, backupType, name string) *model.BackupRecord {
+	records, err := s.repo.ListRecords(
+		repo.WithBackupCronjobID(cronjobID),
+		repo.WithBackupType(backupType),
+		repo.WithBackupName(name),
+		repo.WithBackupStatus(constant.StatusSuccess),
+	)
+	if err != nil {
+		return nil
+	}
+	var latest *model.BackupRecord
+	for i := range records {
+		if records[i].ChainID == 0 {
+			continue
+		}
+		if latest == nil || records[i].ID > latest.ID {
+			latest = &records[i]
+		}
+	}
+	return latest
+}
+
+// attachChain 将链信息写入新建记录；成功的链首记录指向自身，状态文件随之转存。
+func (s *BackupService) attachChain(record *model.BackupRecord, chain *BackupChain) error {
+	if chain == nil || record.Status != constant.StatusSuccess {
+		return nil
+	}
+	chainID := chain.ChainID
+	if chainID == 0 {
+		chainID = record.ID
+	}
+	if err := s.repo.UpdateRecord(record.ID, map[string]interface{}{
+		"chain_id":  chainID,
+		"parent_id": chain.ParentID,
+		"level":     chain.Level,
+	}); err != nil {
+		return err
+	}
+	record.ChainID, record.ParentID, record.Level = chainID, chain.ParentID, chain.Level
+	target := chainSnapshotPath(record.ID)
+	if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		return err
+	}
+	if err := copyFile(chain.SnapshotFile, target); err != nil {
+		return err
+	}
+	chain.Release()
+	return nil
+}
+
+// recordChain 从目标记录回溯到链首，按恢复顺序返回（全量在前）
+func (s *BackupService) recordChain(record *model.BackupRecord) ([]*model.BackupRecord, error) {
+	chain := []*model.BackupRecord{record}
+	current := record
+	for current.ChainID != 0 && current.Level > 0 {
+		parent, err := s.repo.GetRecord(current.ParentID)
+		if err != nil || parent.ChainID != record.ChainID || parent.Level >= current.Level || parent.Status != constant.StatusSuccess {
+			return nil, buserr.WithDetail(constant.ErrBackupChainBroken, current.ParentID, err)
+		}
+		chain = append(chain, parent)
+		current = parent
+	}
+	sort.Slice(chain, func(i, j int) bool { return chain[i].Level < chain[j].Level })
+	return chain, nil
+}
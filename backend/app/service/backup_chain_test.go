@@ -0,0 +1,125 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/constant"
+	"xpanel/global"
+)
+
+func runIncrementalDirectoryBackup(t *testing.T, job *model.Cronjob) model.BackupRecord {
+	t.Helper()
+	if msg, status, _ := (&CronjobService{}).execDirectoryBackup(job); status != constant.StatusSuccess {
+		t.Fatalf("backup failed: %s", msg)
+	}
+	// 归档文件名精确到秒；同时让后续修改的 mtime 明确晚于本次快照时间
+	time.Sleep(1100 * time.Millisecond)
+	var record model.BackupRecord
+	if err := global.DB.Order("id desc").First(&record).Error; err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+func TestIncrementalBackupChainRestoresFullState(t *testing.T) {
+	svc := installBackupRestoreDB(t)
+	src := filepath.Join(t.TempDir(), "data")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("a.txt", "a1")
+	writeFile("sub/old.txt", "old")
+	job := &model.Cronjob{
+		BaseModel: model.BaseModel{ID: 9}, Type: "directory", SourceDir: src,
+		BackupMode: BackupModeIncremental, ChainLength: 3,
+	}
+
+	full := runIncrementalDirectoryBackup(t, job)
+	writeFile("a.txt", "a2")
+	writeFile("sub/new.txt", "new")
+	if err := os.Remove(filepath.Join(src, "sub", "old.txt")); err != nil {
+		t.Fatal(err)
+	}
+	inc1 := runIncrementalDirectoryBackup(t, job)
+	writeFile("b.txt", "b")
+	inc2 := runIncrementalDirectoryBackup(t, job)
+	next := runIncrementalDirectoryBackup(t, job)
+
+	if full.ChainID != full.ID || full.Level != 0 {
+		t.Fatalf("full record chain = %d level = %d, want head of own chain", full.ChainID, full.Level)
+	}
+	if inc1.ChainID != full.ID || inc1.ParentID != full.ID || inc1.Level != 1 {
+		t.Fatalf("inc1 = chain %d parent %d level %d", inc1.ChainID, inc1.ParentID, inc1.Level)
+	}
+	if inc2.ParentID != inc1.ID || inc2.Level != 2 {
+		t.Fatalf("inc2 = parent %d level %d", inc2.ParentID, inc2.Level)
+	}
+	if next.ChainID != next.ID || next.Level != 0 {
+		t.Fatalf("chain length 3 should start a new chain, got chain %d level %d", next.ChainID, next.Level)
+	}
+
+	target := filepath.Join(t.TempDir(), "restored")
+	if _, err := svc.RestoreRecord(dto.BackupRecordRestore{ID: inc2.ID, TargetPath: target}); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	_ = filepath.Walk(target, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			content, _ := os.ReadFile(path)
+			rel, _ := filepath.Rel(target, path)
+			got = append(got, rel+"="+string(content))
+		}
+		return nil
+	})
+	sort.Strings(got)
+	want := []string{"a.txt=a2", "b.txt=b", "sub/new.txt=new"}
+	if len(got) != len(want) {
+		t.Fatalf("restored files = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("restored files = %v, want %v", got, want)
+		}
+	}
+
+	if err := svc.DeleteRecord(inc1.ID); err == nil {
+		t.Fatal("deleting a record with dependent increments should fail")
+	}
+	if err := svc.CleanSuccessfulRecords(job.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	var remaining []model.BackupRecord
+	global.DB.Find(&remaining)
+	if len(remaining) != 1 || remaining[0].ID != next.ID {
+		t.Fatalf("retention should drop the whole old chain, remaining %+v", remaining)
+	}
+	if _, err := os.Stat(chainSnapshotPath(inc2.ID)); !os.IsNotExist(err) {
+		t.Fatalf("expired chain snapshot should be removed, stat err: %v", err)
+	}
+}
+
+func TestExpiredBackupRecordsKeepsWholeChains(t *testing.T) {
+	records := []model.BackupRecord{
+		{BaseModel: model.BaseModel{ID: 1}, Type: "directory", Name: "data", ChainID: 1},
+		{BaseModel: model.BaseModel{ID: 2}, Type: "directory", Name: "data", ChainID: 1, Level: 1},
+		{BaseModel: model.BaseModel{ID: 3}, Type: "directory", Name: "data", ChainID: 3},
+		{BaseModel: model.BaseModel{ID: 4}, Type: "directory", Name: "data", ChainID: 3, Level: 1},
+		{BaseModel: model.BaseModel{ID: 5}, Type: "directory", Name: "data", ChainID: 5},
+	}
+	expired := expiredBackupRecords(records, 2)
+	if len(expired) != 2 || expired[0].ID != 2 || expired[1].ID != 1 {
+		t.Fatalf("expired = %+v, want chain 1 only", expired)
+	}
+}
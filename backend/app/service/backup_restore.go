@@ -0,0 +1,314 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	archiveUtil "xpanel/utils/backup"
+	"xpanel/utils/checksum"
+)
+
+// restorePreviewLimit 预览时最多返回的文件条目数，Total 仍为完整数量
+const restorePreviewLimit = 500
+
+// RestoreRecord 将网站/目录备份恢复到原目录或指定目录。
+// 流程：下载 → 校验 SHA256 → 解密 → 检查归档路径 → 快照现有目录 → 解压覆盖。
+// 增量记录会从链首全量开始逐层回放，得到该记录时刻的完整状态。
+// DryRun 时只返回将要写入的文件列表，不改动磁盘。
+func (s *BackupService) RestoreRecord(req dto.BackupRecordRestore) (*dto.BackupRecordRestoreResult, error) {
+	record, target, err := s.loadRestoreRecord(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.restoreRecord(record, target, req, &backupLog{})
+}
+
+// RestoreRecordAsync 同步完成参数校验后在后台执行恢复，返回任务 ID。
+func (s *BackupService) RestoreRecordAsync(req dto.BackupRecordRestore) (*dto.BackupRecordRestoreResult, error) {
+	record, target, err := s.loadRestoreRecord(req)
+	if err != nil {
+		return nil, err
+	}
+	result := &dto.BackupRecordRestoreResult{TargetPath: target}
+	task := StartFileTaskWithNotification("backup_restore", fmt.Sprintf("恢复备份 %s", record.Name), FileTaskNotification{
+		Source:         "backup",
+		TargetURL:      "/backup",
+		SuccessTitle:   fmt.Sprintf("备份「%s」恢复完成", record.Name),
+		SuccessContent: fmt.Sprintf("已恢复到 %s", target),
+		FailedTitle:    fmt.Sprintf("备份「%s」恢复失败", record.Name),
+	}, func() error {
+		_, err := s.restoreRecord(record, target, req, &backupLog{})
+		return err
+	})
+	result.TaskID = task.ID
+	return result, nil
+}
+
+func (s *BackupService) loadRestoreRecord(req dto.BackupRecordRestore) (*model.BackupRecord, string, error) {
+	record, err := s.repo.GetRecord(req.ID)
+	if err != nil {
+		return nil, "", buserr.New(constant.ErrRecordNotFound)
+	}
+	if record.Type != "website" && record.Type != "directory" {
+		return nil, "", buserr.New(constant.ErrBackupRestoreType)
+	}
+	target, err := resolveRestoreTarget(record, req.TargetPath)
+	if err != nil {
+		return nil, "", err
+	}
+	return record, target, nil
+}
+
+func (s *BackupService) restoreRecord(record *model.BackupRecord, target string, req dto.BackupRecordRestore, log *backupLog) (*dto.BackupRecordRestoreResult, error) {
+	log.step("restore %s backup %s -> %s", record.Type, record.FileName, target)
+	chain, err := s.recordChain(record)
+	if err != nil {
+		return nil, err
+	}
+	// 先下载并校验整条链，避免恢复到一半才发现某层损坏
+	var layers []restoreLayer
+	defer func() {
+		for _, layer := range layers {
+			layer.release()
+		}
+	}()
+	var files []string
+	seen := make(map[string]bool)
+	for _, item := range chain {
+		layer, err := s.openRestoreLayer(item, req.Password, log)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+		for _, file := range layer.files {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	result := &dto.BackupRecordRestoreResult{TargetPath: target, Total: len(files), Entries: files}
+	if len(files) > restorePreviewLimit {
+		result.Entries = files[:restorePreviewLimit]
+	}
+	if req.DryRun {
+		return result, nil
+	}
+
+	if !req.SkipSnapshot {
+		snapshot, err := s.snapshotRestoreTarget(record, target)
+		if err != nil {
+			return nil, fmt.Errorf("pre-restore snapshot failed: %v", err)
+		}
+		if snapshot != "" {
+			log.step("pre-restore snapshot saved %s", snapshot)
+		}
+		result.Snapshot = snapshot
+	}
+
+	for i, layer := range layers {
+		if err := archiveUtil.ExtractArchive(layer.file, target, archiveUtil.ExtractOptions{
+			StripComponents: 1,
+			Incremental:     chain[i].ChainID != 0,
+		}); err != nil {
+			return nil, err
+		}
+		log.step("extracted level %d %s", chain[i].Level, chain[i].FileName)
+	}
+	log.step("restore finished, %d entries", len(files))
+	return result, nil
+}
+
+type restoreLayer struct {
+	file    string
+	files   []string
+	release func()
+}
+
+// openRestoreLayer 下载单个备份文件，校验 SHA256、解密并检查归档条目。
+func (s *BackupService) openRestoreLayer(record *model.BackupRecord, password string, log *backupLog) (restoreLayer, error) {
+	file, release, err := s.PrepareRecordFile(record.ID)
+	if err != nil {
+		return restoreLayer{}, err
+	}
+	layer := restoreLayer{file: file, release: release}
+	fail := func(err error) (restoreLayer, error) {
+		layer.release()
+		return restoreLayer{}, err
+	}
+
+	if err := verifyRecordChecksum(record, file, log); err != nil {
+		return fail(err)
+	}
+
+	if strings.HasSuffix(record.FileName, ".enc") {
+		decrypted, cleanup, err := decryptRestoreFile(record, file, password)
+		if err != nil {
+			return fail(err)
+		}
+		downloaded := layer.release
+		layer.file = decrypted
+		layer.release = func() {
+			cleanup()
+			downloaded()
+		}
+		log.step("archive decrypted %s", record.FileName)
+	}
+
+	entries, err := archiveUtil.ListArchive(layer.file)
+	if err != nil {
+		return fail(err)
+	}
+	if layer.files, err = restoreEntries(entries); err != nil {
+		return fail(err)
+	}
+	return layer, nil
+}
+
+// verifyRecordChecksum 记录没有 SHA256（早期备份）时跳过校验
+func verifyRecordChecksum(record *model.BackupRecord, file string, log *backupLog) error {
+	if record.SHA256 == "" {
+		return nil
+	}
+	hash, err := checksum.FileSHA256(file)
+	if err != nil {
+		return fmt.Errorf("hash backup file failed: %v", err)
+	}
+	if !strings.EqualFold(hash, record.SHA256) {
+		return buserr.New(constant.ErrBackupChecksum)
+	}
+	log.step("sha256 verified %s %s", record.FileName, hash)
+	return nil
+}
+
+// resolveRestoreTarget 优先使用请求指定的目录，其次网站目录或备份记录的源目录。
+func resolveRestoreTarget(record *model.BackupRecord, targetPath string) (string, error) {
+	target := strings.TrimSpace(targetPath)
+	if target == "" {
+		target = defaultRestoreTarget(record)
+	}
+	if target == "" {
+		return "", buserr.WithDetail(constant.ErrBackupRestoreTarget, "无法确定原目录，请指定恢复目录", nil)
+	}
+	if !filepath.IsAbs(target) {
+		return "", buserr.WithDetail(constant.ErrBackupRestoreTarget, "必须是绝对路径", nil)
+	}
+	target = filepath.Clean(target)
+	if isProtectedPath(target) {
+		return "", buserr.WithDetail(constant.ErrBackupRestoreTarget, target, nil)
+	}
+	if info, err := os.Stat(target); err == nil && !info.IsDir() {
+		return "", buserr.WithDetail(constant.ErrBackupRestoreTarget, target, nil)
+	}
+	return target, nil
+}
+
+func defaultRestoreTarget(record *model.BackupRecord) string {
+	if record.Type == "website" {
+		website, err := repo.NewIWebsiteRepo().Get(repo.WithByPrimaryDomain(record.Name))
+		if err == nil && website.SiteDir != "" {
+			return website.SiteDir
+		}
+		return filepath.Join("/var/www", record.Name)
+	}
+	// 手动备份记录的是源目录，计划任务记录的是本地归档路径，需要回查任务配置
+	if filepath.IsAbs(record.SourcePath) && !isBackupTempArchive(record.SourcePath) {
+		return record.SourcePath
+	}
+	if record.CronjobID > 0 {
+		if job, err := repo.NewICronjobRepo().Get(record.CronjobID); err == nil {
+			return job.SourceDir
+		}
+	}
+	return ""
+}
+
+func decryptRestoreFile(record *model.BackupRecord, file, password string) (string, func(), error) {
+	if password == "" && record.CronjobID > 0 {
+		if job, err := repo.NewICronjobRepo().Get(record.CronjobID); err == nil {
+			password = job.EncryptPassword
+		}
+	}
+	if password == "" {
+		return "", nil, buserr.New(constant.ErrBackupPasswordMissing)
+	}
+	// 保留 .tar.gz 等后缀，解压时依据扩展名选择压缩格式
+	tmp, err := os.CreateTemp("", "xpanel-restore-*-"+strings.TrimSuffix(record.FileName, ".enc"))
+	if err != nil {
+		return "", nil, err
+	}
+	tmpPath := tmp.Name()
+	_ = tmp.Close()
+	cleanup := func() { _ = os.Remove(tmpPath) }
+	if err := archiveUtil.DecryptFile(file, tmpPath, password); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmpPath, cleanup, nil
+}
+
+// restoreEntries 去掉归档顶层目录并拒绝绝对路径和 ".." 条目。
+func restoreEntries(entries []string) ([]string, error) {
+	var files []string
+	for _, entry := range entries {
+		if path.IsAbs(entry) {
+			return nil, buserr.WithDetail(constant.ErrBackupArchiveUnsafe, entry, nil)
+		}
+		for _, part := range strings.Split(entry, "/") {
+			if part == ".." {
+				return nil, buserr.WithDetail(constant.ErrBackupArchiveUnsafe, entry, nil)
+			}
+		}
+		_, rest, _ := strings.Cut(strings.TrimPrefix(entry, "./"), "/")
+		if rest != "" {
+			files = append(files, rest)
+		}
+	}
+	return files, nil
+}
+
+// snapshotRestoreTarget 打包当前目录作为本地备份记录，恢复出错时可再恢复回来。
+func (s *BackupService) snapshotRestoreTarget(record *model.BackupRecord, target string) (string, error) {
+	items, err := os.ReadDir(target)
+	if err != nil || len(items) == 0 {
+		return "", nil
+	}
+	outDir := filepath.Join(durableBackupDir(record.Type), "pre-restore")
+	if err := ensureBackupTempSpace(target, outDir); err != nil {
+		return "", err
+	}
+	fileName := fmt.Sprintf("%s%s_%s.tar.gz", restoreSnapshotPrefix, filepath.Base(target), time.Now().Format("20060102150405"))
+	snapshot, err := archiveUtil.CreateArchive(archiveUtil.ArchiveOptions{
+		SourceDir: target,
+		OutFile:   filepath.Join(outDir, fileName),
+	})
+	if err != nil {
+		return "", err
+	}
+	hash, _ := checksum.FileSHA256(snapshot)
+	err = s.repo.CreateRecord(&model.BackupRecord{
+		Type:       record.Type,
+		Name:       record.Name,
+		FileName:   filepath.Base(snapshot),
+		FileDir:    filepath.Dir(snapshot),
+		Size:       fileSize(snapshot),
+		SHA256:     hash,
+		SourcePath: target,
+		Status:     constant.StatusSuccess,
+		Message:    fmt.Sprintf("restore snapshot before record %d", record.ID),
+	})
+	if err != nil {
+		return snapshot, err
+	}
+	s.pruneRestoreSnapshots(record.Type, record.Name)
+	return snapshot, nil
+}
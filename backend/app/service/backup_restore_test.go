@@ -0,0 +1,199 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	archiveUtil "xpanel/utils/backup"
+	"xpanel/utils/checksum"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func installBackupRestoreDB(t *testing.T) *BackupService {
+	t.Helper()
+	database, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.AutoMigrate(&model.BackupRecord{}, &model.BackupAccount{}); err != nil {
+		t.Fatal(err)
+	}
+	previousDB, previousDataDir := global.DB, global.CONF.System.DataDir
+	global.DB = database
+	global.CONF.System.DataDir = t.TempDir()
+	t.Cleanup(func() {
+		global.DB = previousDB
+		global.CONF.System.DataDir = previousDataDir
+	})
+	return &BackupService{repo: repo.NewIBackupRepo()}
+}
+
+// createLocalDirectoryBackup 打包 index.html + assets/app.js 并登记为本地备份记录
+func createLocalDirectoryBackup(t *testing.T, svc *BackupService, password string) *model.BackupRecord {
+	t.Helper()
+	src := filepath.Join(t.TempDir(), "site")
+	if err := os.MkdirAll(filepath.Join(src, "assets"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "index.html"), []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "assets", "app.js"), []byte("js"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := archiveUtil.CreateArchive(archiveUtil.ArchiveOptions{
+		SourceDir:       src,
+		OutFile:         filepath.Join(t.TempDir(), "dir_site.tar.gz"),
+		EncryptPassword: password,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := checksum.FileSHA256(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := &model.BackupRecord{
+		Type:       "directory",
+		Name:       "site",
+		FileName:   filepath.Base(archive),
+		FileDir:    filepath.Dir(archive),
+		SHA256:     hash,
+		SourcePath: src,
+		Status:     constant.StatusSuccess,
+	}
+	if err := svc.repo.CreateRecord(record); err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+func TestRestoreRecordDryRunListsFilesWithoutWriting(t *testing.T) {
+	svc := installBackupRestoreDB(t)
+	record := createLocalDirectoryBackup(t, svc, "")
+	target := filepath.Join(t.TempDir(), "restored")
+
+	result, err := svc.RestoreRecord(dto.BackupRecordRestore{ID: record.ID, TargetPath: target, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"index.html": true, "assets/": true, "assets/app.js": true}
+	for _, entry := range result.Entries {
+		delete(want, entry)
+	}
+	if len(want) != 0 || result.Total != len(result.Entries) {
+		t.Fatalf("entries = %v, missing %v", result.Entries, want)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatalf("dry run must not create target, stat err: %v", err)
+	}
+}
+
+func TestRestoreRecordSnapshotsAndOverwritesSource(t *testing.T) {
+	svc := installBackupRestoreDB(t)
+	record := createLocalDirectoryBackup(t, svc, "")
+	if err := os.WriteFile(filepath.Join(record.SourcePath, "index.html"), []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := svc.RestoreRecord(dto.BackupRecordRestore{ID: record.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TargetPath != record.SourcePath {
+		t.Fatalf("target = %q, want original source %q", result.TargetPath, record.SourcePath)
+	}
+	content, _ := os.ReadFile(filepath.Join(record.SourcePath, "index.html"))
+	if string(content) != "v1" {
+		t.Fatalf("index.html = %q, want restored v1", content)
+	}
+	if result.Snapshot == "" {
+		t.Fatal("expected pre-restore snapshot")
+	}
+	entries, err := archiveUtil.ListArchive(result.Snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("snapshot archive is empty")
+	}
+	records, _ := svc.repo.ListRecords()
+	if len(records) != 2 {
+		t.Fatalf("records = %d, want original plus snapshot", len(records))
+	}
+}
+
+func TestRestoreRecordDecryptsToAlternatePath(t *testing.T) {
+	svc := installBackupRestoreDB(t)
+	record := createLocalDirectoryBackup(t, svc, "secret")
+	target := filepath.Join(t.TempDir(), "restored")
+
+	_, err := svc.RestoreRecord(dto.BackupRecordRestore{ID: record.ID, TargetPath: target})
+	assertBackupErr(t, err, constant.ErrBackupPasswordMissing)
+
+	if _, err := svc.RestoreRecord(dto.BackupRecordRestore{ID: record.ID, TargetPath: target, Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(filepath.Join(target, "assets", "app.js"))
+	if string(content) != "js" {
+		t.Fatalf("app.js = %q, want js", content)
+	}
+}
+
+func TestRestoreRecordRejectsChecksumMismatch(t *testing.T) {
+	svc := installBackupRestoreDB(t)
+	record := createLocalDirectoryBackup(t, svc, "")
+	if err := global.DB.Model(&model.BackupRecord{}).Where("id = ?", record.ID).Update("sha256", "deadbeef").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := svc.RestoreRecord(dto.BackupRecordRestore{ID: record.ID, DryRun: true})
+	assertBackupErr(t, err, constant.ErrBackupChecksum)
+}
+
+func TestRestoreRecordRejectsDatabaseAndProtectedTargets(t *testing.T) {
+	svc := installBackupRestoreDB(t)
+	record := createLocalDirectoryBackup(t, svc, "")
+
+	_, err := svc.RestoreRecord(dto.BackupRecordRestore{ID: record.ID, TargetPath: "/etc"})
+	assertBackupErr(t, err, constant.ErrBackupRestoreTarget)
+
+	dbRecord := &model.BackupRecord{Type: "database", Name: "app", FileName: "db.sql", Status: constant.StatusSuccess}
+	if err := svc.repo.CreateRecord(dbRecord); err != nil {
+		t.Fatal(err)
+	}
+	_, err = svc.RestoreRecord(dto.BackupRecordRestore{ID: dbRecord.ID, TargetPath: t.TempDir()})
+	assertBackupErr(t, err, constant.ErrBackupRestoreType)
+}
+
+func TestRestoreEntriesRejectsTraversal(t *testing.T) {
+	for _, entry := range []string{"/etc/passwd", "site/../../etc/passwd"} {
+		_, err := restoreEntries([]string{"site/", entry})
+		assertBackupErr(t, err, constant.ErrBackupArchiveUnsafe)
+	}
+	files, err := restoreEntries([]string{"site/", "site/a.txt", "./site/b/c.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0] != "a.txt" || files[1] != "b/c.txt" {
+		t.Fatalf("files = %v", files)
+	}
+}
+
+func assertBackupErr(t *testing.T, err error, key string) {
+	t.Helper()
+	var bizErr buserr.BusinessError
+	if !errors.As(err, &bizErr) || bizErr.Msg != key {
+		t.Fatalf("err = %v, want %s", err, key)
+	}
+}
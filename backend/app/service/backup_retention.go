@@ -0,0 +1,308 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+)
+
+const (
+	RetentionPolicyCount = "count"
+	RetentionPolicyGFS   = "gfs"
+
+	pruneReasonRetention = "retention"
+	pruneReasonQuota     = "quota"
+
+	// restoreSnapshotPrefix 恢复前快照的文件名前缀，快照不属于任何计划任务或远程账户
+	restoreSnapshotPrefix = "pre-restore_"
+	// restoreSnapshotRetain 每个备份对象保留的恢复前快照份数
+	restoreSnapshotRetain = 3
+)
+
+// retentionUnit 保留策略的最小删除单位：普通记录单独成组，增量链整条成组，
+// 保证清理永远不会留下缺少全量的增量层。
+type retentionUnit struct {
+	key     string
+	at      time.Time
+	size    int64
+	records []model.BackupRecord
+}
+
+type pruneCandidate struct {
+	record model.BackupRecord
+	reason string
+}
+
+// buildRetentionUnits 按备份对象分组，每组内按最新记录时间倒序
+func buildRetentionUnits(records []model.BackupRecord) map[string][]retentionUnit {
+	chains := make(map[uint]*retentionUnit)
+	grouped := make(map[string][]*retentionUnit)
+	for _, record := range records {
+		key := fmt.Sprintf("%s/%s/%d", record.Type, record.Name, record.AccountID)
+		unit := chains[record.ChainID]
+		if record.ChainID == 0 || unit == nil {
+			unit = &retentionUnit{key: key}
+			grouped[key] = append(grouped[key], unit)
+			if record.ChainID != 0 {
+				chains[record.ChainID] = unit
+			}
+		}
+		unit.records = append(unit.records, record)
+		unit.size += record.Size
+		if record.CreatedAt.After(unit.at) {
+			unit.at = record.CreatedAt
+		}
+	}
+	result := make(map[string][]retentionUnit, len(grouped))
+	for key, units := range grouped {
+		sort.SliceStable(units, func(i, j int) bool {
+			if units[i].at.Equal(units[j].at) {
+				return units[i].records[0].ID > units[j].records[0].ID
+			}
+			return units[i].at.After(units[j].at)
+		})
+		for _, unit := range units {
+			result[key] = append(result[key], *unit)
+		}
+	}
+	return result
+}
+
+// expiredBackupRecords 按份数保留：最新的 retain 个单元（链按整条计数）保留，其余删除
+func expiredBackupRecords(records []model.BackupRecord, retain uint) []model.BackupRecord {
+	var expired []model.BackupRecord
+	for _, units := range buildRetentionUnits(records) {
+		for i, unit := range units {
+			if uint(i) >= retain {
+				expired = append(expired, unit.records...)
+			}
+		}
+	}
+	sort.SliceStable(expired, func(i, j int) bool { return expired[i].ID > expired[j].ID })
+	return expired
+}
+
+// gfsKeep 祖父-父-子保留：在最近 N 个日/周/月/年周期内各保留该周期最新的一个单元，
+// 最新单元始终保留。
+func gfsKeep(units []retentionUnit, job *model.Cronjob) []bool {
+	keep := make([]bool, len(units))
+	if len(units) > 0 {
+		keep[0] = true
+	}
+	rules := []struct {
+		count  uint
+		bucket func(time.Time) string
+	}{
+		{job.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{job.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{job.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+		{job.KeepYearly, func(t time.Time) string { return t.Format("2006") }},
+	}
+	for _, rule := range rules {
+		seen := make(map[string]bool)
+		for i, unit := range units {
+			bucket := rule.bucket(unit.at.Local())
+			if seen[bucket] {
+				continue
+			}
+			if uint(len(seen)) >= rule.count {
+				break
+			}
+			seen[bucket] = true
+			keep[i] = true
+		}
+	}
+	return keep
+}
+
+// planJobRetention 计算计划任务按保留策略应删除的记录
+func (s *BackupService) planJobRetention(job *model.Cronjob) ([]pruneCandidate, error) {
+	records, err := s.repo.ListRecords(
+		repo.WithBackupCronjobID(job.ID),
+		repo.WithBackupStatus(constant.StatusSuccess),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []pruneCandidate
+	if job.RetentionPolicy == RetentionPolicyGFS {
+		for _, units := range buildRetentionUnits(records) {
+			keep := gfsKeep(units, job)
+			for i, unit := range units {
+				if keep[i] {
+					continue
+				}
+				for _, record := range unit.records {
+					candidates = append(candidates, pruneCandidate{record: record, reason: pruneReasonRetention})
+				}
+			}
+		}
+		return candidates, nil
+	}
+	if job.RetainCopies == 0 {
+		return nil, nil
+	}
+	for _, record := range expiredBackupRecords(records, job.RetainCopies) {
+		candidates = append(candidates, pruneCandidate{record: record, reason: pruneReasonRetention})
+	}
+	return candidates, nil
+}
+
+// planQuotaPrune 账户超出配额时从 cronjobID 自己最旧的单元开始删除，直到回到配额内；
+// 用量按整个账户统计，但不会删除其他任务或手动备份的记录，每个备份对象最新的单元也不参与配额清理。
+// cronjobID 为 0 时只统计用量。pruned 为已确定删除的记录。
+func (s *BackupService) planQuotaPrune(accountID, cronjobID uint, pruned map[uint]bool) ([]pruneCandidate, int64, int64, error) {
+	if accountID == 0 {
+		return nil, 0, 0, nil
+	}
+	account, err := s.repo.GetAccount(accountID)
+	if err != nil {
+		return nil, 0, 0, buserr.New(constant.ErrRecordNotFound)
+	}
+	records, err := s.repo.ListRecords(
+		repo.WithAccountID(accountID),
+		repo.WithBackupStatus(constant.StatusSuccess),
+	)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	var remaining []model.BackupRecord
+	var used int64
+	for _, record := range records {
+		if pruned[record.ID] {
+			continue
+		}
+		used += record.Size
+		if cronjobID > 0 && record.CronjobID == cronjobID {
+			remaining = append(remaining, record)
+		}
+	}
+	if cronjobID == 0 || account.QuotaBytes <= 0 || used <= account.QuotaBytes {
+		return nil, used, account.QuotaBytes, nil
+	}
+
+	var evictable []retentionUnit
+	for _, units := range buildRetentionUnits(remaining) {
+		evictable = append(evictable, units[1:]...)
+	}
+	sort.SliceStable(evictable, func(i, j int) bool { return evictable[i].at.Before(evictable[j].at) })
+	var candidates []pruneCandidate
+	for _, unit := range evictable {
+		if used <= account.QuotaBytes {
+			break
+		}
+		for _, record := range unit.records {
+			candidates = append(candidates, pruneCandidate{record: record, reason: pruneReasonQuota})
+		}
+		used -= unit.size
+	}
+	return candidates, used, account.QuotaBytes, nil
+}
+
+// planPrune job 为空时只统计账户用量，配额清理只针对具体任务
+func (s *BackupService) planPrune(job *model.Cronjob, accountID uint) ([]pruneCandidate, int64, int64, error) {
+	var candidates []pruneCandidate
+	var cronjobID uint
+	if job != nil {
+		var err error
+		if candidates, err = s.planJobRetention(job); err != nil {
+			return nil, 0, 0, err
+		}
+		if accountID == 0 {
+			accountID = job.TargetAccountID
+		}
+		cronjobID = job.ID
+	}
+	pruned := make(map[uint]bool, len(candidates))
+	for _, candidate := range candidates {
+		pruned[candidate.record.ID] = true
+	}
+	quota, used, limit, err := s.planQuotaPrune(accountID, cronjobID, pruned)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return append(candidates, quota...), used, limit, nil
+}
+
+// PreviewRetention 列出保留策略与账户配额将要删除的记录，不做任何删除
+func (s *BackupService) PreviewRetention(req dto.BackupRetentionPreviewReq) (*dto.BackupRetentionPreview, error) {
+	if req.CronjobID == 0 && req.AccountID == 0 {
+		return nil, buserr.New(constant.ErrInvalidParams)
+	}
+	var job *model.Cronjob
+	if req.CronjobID > 0 {
+		var err error
+		if job, err = repo.NewICronjobRepo().Get(req.CronjobID); err != nil {
+			return nil, buserr.New(constant.ErrRecordNotFound)
+		}
+	}
+	candidates, used, limit, err := s.planPrune(job, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	preview := &dto.BackupRetentionPreview{Items: []dto.BackupPruneItem{}, UsedBytes: used, QuotaBytes: limit}
+	for _, candidate := range candidates {
+		r := candidate.record
+		preview.Items = append(preview.Items, dto.BackupPruneItem{
+			ID: r.ID, CreatedAt: r.CreatedAt, Type: r.Type, Name: r.Name, AccountID: r.AccountID,
+			CronjobID: r.CronjobID, FileName: r.FileName, Size: r.Size, ChainID: r.ChainID, Reason: candidate.reason,
+		})
+		preview.PruneBytes += r.Size
+	}
+	return preview, nil
+}
+
+// ApplyRetention 计划任务执行后按保留策略和目标账户配额清理备份
+func (s *BackupService) ApplyRetention(job *model.Cronjob) error {
+	candidates, _, _, err := s.planPrune(job, job.TargetAccountID)
+	if err != nil {
+		return err
+	}
+	for _, candidate := range candidates {
+		s.pruneRecord(&candidate.record)
+	}
+	return nil
+}
+
+// pruneRestoreSnapshots 每个备份对象只保留最新的 restoreSnapshotRetain 份恢复前快照
+func (s *BackupService) pruneRestoreSnapshots(backupType, name string) {
+	records, err := s.repo.ListRecords(repo.WithBackupType(backupType), repo.WithBackupName(name))
+	if err != nil {
+		global.LOG.Warnf("list restore snapshots failed: %v", err)
+		return
+	}
+	var snapshots []model.BackupRecord
+	for _, record := range records {
+		if record.CronjobID == 0 && record.AccountID == 0 && strings.HasPrefix(record.FileName, restoreSnapshotPrefix) {
+			snapshots = append(snapshots, record)
+		}
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		if snapshots[i].CreatedAt.Equal(snapshots[j].CreatedAt) {
+			return snapshots[i].ID > snapshots[j].ID
+		}
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	for i := restoreSnapshotRetain; i < len(snapshots); i++ {
+		s.pruneRecord(&snapshots[i])
+	}
+}
+
+func (s *BackupService) pruneRecord(record *model.BackupRecord) {
+	if err := s.deleteRecordFile(record); err != nil {
+		global.LOG.Warnf("delete retained backup file failed: %v", err)
+	}
+	removeChainSnapshot(record)
+	_ = s.repo.DeleteRecord(record.ID)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/constant"
+	"xpanel/global"
+)
+
+func TestGFSKeepSelectsNewestPerBucket(t *testing.T) {
+	base := time.Date(2026, 3, 31, 12, 0, 0, 0, time.Local)
+	var records []model.BackupRecord
+	for i := 0; i < 60; i++ {
+		records = append(records, model.BackupRecord{
+			BaseModel: model.BaseModel{ID: uint(100 - i), CreatedAt: base.AddDate(0, 0, -i)},
+			Type:      "directory", Name: "data",
+		})
+	}
+	units := buildRetentionUnits(records)["directory/data/0"]
+	keep := gfsKeep(units, &model.Cronjob{KeepDaily: 3, KeepMonthly: 2})
+
+	var kept []string
+	for i, unit := range units {
+		if keep[i] {
+			kept = append(kept, unit.at.Format("2006-01-02"))
+		}
+	}
+	// 最近 3 天 + 3 月、2 月各自最新的一份（3 月已由 03-31 覆盖）
+	want := []string{"2026-03-31", "2026-03-30", "2026-03-29", "2026-02-28"}
+	if len(kept) != len(want) {
+		t.Fatalf("kept = %v, want %v", kept, want)
+	}
+	for i := range want {
+		if kept[i] != want[i] {
+			t.Fatalf("kept = %v, want %v", kept, want)
+		}
+	}
+}
+
+func TestPreviewRetentionQuotaKeepsNewestAndWholeChains(t *testing.T) {
+	svc := installBackupRestoreDB(t)
+	if err := global.DB.AutoMigrate(&model.Cronjob{}); err != nil {
+		t.Fatal(err)
+	}
+	account := &model.BackupAccount{Name: "nas", Type: "local", QuotaBytes: 250}
+	if err := global.DB.Create(account).Error; err != nil {
+		t.Fatal(err)
+	}
+	job := &model.Cronjob{Name: "data", Type: "directory", Spec: "0 2 * * *", TargetAccountID: account.ID}
+	other := &model.Cronjob{Name: "blog", Type: "website", Spec: "0 3 * * *", TargetAccountID: account.ID}
+	for _, item := range []*model.Cronjob{job, other} {
+		if err := global.DB.Create(item).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	base := time.Now().Add(-time.Hour)
+	records := []model.BackupRecord{
+		// 更旧的手动备份与其他任务的记录只计入用量，不会被本任务的配额清理删除
+		{Type: "directory", Name: "manual", Size: 50},
+		{Type: "website", Name: "old-blog", Size: 50, CronjobID: other.ID},
+		{Type: "directory", Name: "data", Size: 100, ChainID: 1, CronjobID: job.ID},
+		{Type: "directory", Name: "data", Size: 20, ChainID: 1, ParentID: 1, Level: 1, CronjobID: job.ID},
+		{Type: "directory", Name: "data", Size: 100, CronjobID: job.ID},
+		{Type: "website", Name: "blog", Size: 200, CronjobID: other.ID},
+	}
+	for i := range records {
+		records[i].AccountID = account.ID
+		records[i].Status = constant.StatusSuccess
+		records[i].FileName = "f.tar.gz"
+		records[i].CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := svc.repo.CreateRecord(&records[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	preview, err := svc.PreviewRetention(dto.BackupRetentionPreviewReq{CronjobID: job.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Items) != 2 || preview.PruneBytes != 120 || preview.UsedBytes != 400 {
+		t.Fatalf("preview = %+v, want only the job's old chain pruned", preview)
+	}
+	for _, item := range preview.Items {
+		if item.ChainID != 1 || item.CronjobID != job.ID || item.Reason != pruneReasonQuota {
+			t.Fatalf("unexpected prune item %+v", item)
+		}
+	}
+	accountPreview, err := svc.PreviewRetention(dto.BackupRetentionPreviewReq{AccountID: account.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accountPreview.Items) != 0 || accountPreview.UsedBytes != 520 || accountPreview.QuotaBytes != 250 {
+		t.Fatalf("account preview = %+v, want usage only", accountPreview)
+	}
+	var count int64
+	global.DB.Model(&model.BackupRecord{}).Count(&count)
+	if count != int64(len(records)) {
+		t.Fatalf("preview must not delete records, %d left", count)
+	}
+
+	if _, err := svc.PreviewRetention(dto.BackupRetentionPreviewReq{}); err == nil {
+		t.Fatal("preview without cronjob or account should fail")
+	}
+}
+
+func TestRestoreSnapshotsKeepNewest(t *testing.T) {
+	svc := installBackupRestoreDB(t)
+	dir := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+	var records []model.BackupRecord
+	for i := 0; i < restoreSnapshotRetain+2; i++ {
+		name := fmt.Sprintf("%ssite_%d.tar.gz", restoreSnapshotPrefix, i)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		record := model.BackupRecord{Type: "website", Name: "blog", FileName: name, FileDir: dir, Status: constant.StatusSuccess}
+		record.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := svc.repo.CreateRecord(&record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	// 同名对象的普通备份不受快照保留影响
+	regular := model.BackupRecord{Type: "website", Name: "blog", FileName: "blog.tar.gz", FileDir: dir, Status: constant.StatusSuccess}
+	regular.CreatedAt = base
+	if err := svc.repo.CreateRecord(&regular); err != nil {
+		t.Fatal(err)
+	}
+
+	svc.pruneRestoreSnapshots("website", "blog")
+	for i, record := range records {
+		_, err := svc.repo.GetRecord(record.ID)
+		kept := i >= len(records)-restoreSnapshotRetain
+		if kept != (err == nil) {
+			t.Fatalf("snapshot %d kept = %v, want %v", i, err == nil, kept)
+		}
+		if _, statErr := os.Stat(filepath.Join(dir, record.FileName)); kept != (statErr == nil) {
+			t.Fatalf("snapshot file %d exists = %v, want %v", i, statErr == nil, kept)
+		}
+	}
+	if _, err := svc.repo.GetRecord(regular.ID); err != nil {
+		t.Fatalf("regular backup should be kept: %v", err)
+	}
+}
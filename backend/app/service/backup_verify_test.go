@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/security/credentials"
+)
+
+func installBackupVerifyDB(t *testing.T) *BackupService {
+	t.Helper()
+	svc := installBackupRestoreDB(t)
+	if err := global.DB.AutoMigrate(&model.Setting{}, &model.Notification{}, &model.DatabaseServer{}, &model.DatabaseInstance{}, &model.Cronjob{}); err != nil {
+		t.Fatal(err)
+	}
+	svc.dbRepo = repo.NewIDatabaseRepo()
+	return svc
+}
+
+func TestVerifyRecordMarksDirectoryBackupVerified(t *testing.T) {
+	svc := installBackupVerifyDB(t)
+	record := createLocalDirectoryBackup(t, svc, "secret")
+
+	if err := svc.VerifyRecord(record.ID); err == nil {
+		t.Fatal("encrypted backup without password should fail verification")
+	}
+	manager, _, err := credentials.LoadOrCreate(filepath.Join(t.TempDir(), "credential-keyring.json"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousCredentials := global.CREDENTIALS
+	global.CREDENTIALS = manager
+	t.Cleanup(func() { global.CREDENTIALS = previousCredentials })
+	job := &model.Cronjob{Name: "site", Type: "directory", Spec: "@daily", EncryptPassword: "secret"}
+	if err := repo.NewICronjobRepo().Create(job); err != nil {
+		t.Fatal(err)
+	}
+	global.DB.Model(&model.BackupRecord{}).Where("id = ?", record.ID).Update("cronjob_id", job.ID)
+
+	if err := svc.VerifyRecord(record.ID); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := svc.repo.GetRecord(record.ID)
+	if stored.VerifyStatus != constant.StatusSuccess || stored.VerifiedAt == nil {
+		t.Fatalf("verify status = %q at %v", stored.VerifyStatus, stored.VerifiedAt)
+	}
+	scratch, _ := filepath.Glob(filepath.Join(backupTempDir(), "verify-*"))
+	if len(scratch) != 0 {
+		t.Fatalf("scratch dirs left behind: %v", scratch)
+	}
+}
+
+func TestVerifyRecordFailureNotifies(t *testing.T) {
+	svc := installBackupVerifyDB(t)
+	record := createLocalDirectoryBackup(t, svc, "")
+	if err := os.WriteFile(filepath.Join(record.FileDir, record.FileName), []byte("corrupted"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.VerifyRecord(record.ID); err == nil {
+		t.Fatal("expected checksum failure")
+	}
+	stored, _ := svc.repo.GetRecord(record.ID)
+	if stored.VerifyStatus != constant.StatusFailed || stored.VerifyMessage == "" {
+		t.Fatalf("verify status = %q message = %q", stored.VerifyStatus, stored.VerifyMessage)
+	}
+	var notifications []model.Notification
+	global.DB.Where("event = ?", "backup.verify.failed").Find(&notifications)
+	if len(notifications) != 1 {
+		t.Fatalf("notifications = %d, want 1", len(notifications))
+	}
+}
+
+func TestVerifyDatabaseRecordRestoresOnMatchingServer(t *testing.T) {
+	svc := installBackupVerifyDB(t)
+	servers := []model.DatabaseServer{
+		{Name: "primary", Type: "mysql", Address: "127.0.0.1", Port: 3306},
+		{Name: "reports", Type: "mysql", Address: "127.0.0.2", Port: 3306},
+	}
+	for i := range servers {
+		if err := global.DB.Create(&servers[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := global.DB.Create(&model.DatabaseInstance{ServerID: servers[1].ID, Name: "shop"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	dump := filepath.Join(t.TempDir(), "db_shop_mysql_20260101000000.sql")
+	if err := os.WriteFile(dump, []byte("CREATE TABLE t (id int);"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	record := &model.BackupRecord{
+		Type: "database", Name: "shop", FileName: filepath.Base(dump), FileDir: filepath.Dir(dump), Status: constant.StatusSuccess,
+	}
+	if err := svc.repo.CreateRecord(record); err != nil {
+		t.Fatal(err)
+	}
+
+	var restoredOn string
+	original := verifyDatabaseDump
+	verifyDatabaseDump = func(server *model.DatabaseServer, file string) error {
+		restoredOn = server.Name
+		if file != dump {
+			return fmt.Errorf("unexpected dump %s", file)
+		}
+		return nil
+	}
+	t.Cleanup(func() { verifyDatabaseDump = original })
+
+	if err := svc.VerifyRecord(record.ID); err != nil {
+		t.Fatal(err)
+	}
+	if restoredOn != "reports" {
+		t.Fatalf("restored on %q, want server hosting the instance", restoredOn)
+	}
+
+	// 实例已不存在时不能退回到任意一台服务器
+	dump = filepath.Join(filepath.Dir(dump), "db_gone_mysql_20260101000000.sql")
+	if err := os.WriteFile(dump, []byte("CREATE TABLE t (id int);"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	orphan := &model.BackupRecord{
+		Type: "database", Name: "gone", FileName: filepath.Base(dump), FileDir: filepath.Dir(dump), Status: constant.StatusSuccess,
+	}
+	if err := svc.repo.CreateRecord(orphan); err != nil {
+		t.Fatal(err)
+	}
+	restoredOn = ""
+	if err := svc.VerifyRecord(orphan.ID); err == nil {
+		t.Fatal("record without a matching server should fail")
+	}
+	if restoredOn != "" {
+		t.Fatalf("orphan record was restored on %q", restoredOn)
+	}
+}
+
+func TestExecBackupVerifyChecksRecentRecords(t *testing.T) {
+	svc := installBackupVerifyDB(t)
+	older := createLocalDirectoryBackup(t, svc, "")
+	newer := createLocalDirectoryBackup(t, svc, "")
+	global.DB.Model(&model.BackupRecord{}).Where("id = ?", older.ID).Update("created_at", older.CreatedAt.Add(-1e9))
+
+	msg, status := (&CronjobService{}).execBackupVerify(&model.Cronjob{Type: "verify", VerifyCount: 1})
+	if status != constant.StatusSuccess {
+		t.Fatalf("status = %s: %s", status, msg)
+	}
+	first, _ := svc.repo.GetRecord(newer.ID)
+	second, _ := svc.repo.GetRecord(older.ID)
+	if first.VerifyStatus != constant.StatusSuccess || second.VerifyStatus != "" {
+		t.Fatalf("newer = %q, older = %q; only the newest record should be verified", first.VerifyStatus, second.VerifyStatus)
+	}
+}
@@ -0,0 +1,610 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	sslutil "xpanel/utils/ssl"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	certificateDeploySSH     = "ssh"
+	certificateDeployLocal   = "local"
+	certificateDeployCommand = "command"
+
+	certificateDeployTimeout = 5 * time.Minute
+	certificateDeployLogKeep = 50
+	defaultJKSAlias          = "xpanel"
+)
+
+type certificateDeployTrigger string
+
+const (
+	certificateDeployApply  certificateDeployTrigger = "apply"
+	certificateDeployRenew  certificateDeployTrigger = "renew"
+	certificateDeploySync   certificateDeployTrigger = "sync"
+	certificateDeployManual certificateDeployTrigger = "manual"
+)
+
+type ICertificateDeployService interface {
+	List(certID uint) ([]dto.CertificateDeploymentInfo, error)
+	Create(req dto.CertificateDeploymentCreate, admin bool) error
+	Update(req dto.CertificateDeploymentUpdate, admin bool) error
+	Delete(id uint) error
+	Run(id uint) error
+	SearchLogs(req dto.SearchCertificateDeployLogReq) (int64, []model.CertificateDeployLog, error)
+}
+
+type CertificateDeployService struct {
+	deployRepo repo.ICertificateDeploymentRepo
+	certRepo   repo.ICertificateRepo
+	hostRepo   repo.IHostRepo
+}
+
+func NewICertificateDeployService() ICertificateDeployService {
+	return &CertificateDeployService{
+		deployRepo: repo.NewICertificateDeploymentRepo(),
+		certRepo:   repo.NewICertificateRepo(),
+		hostRepo:   repo.NewIHostRepo(),
+	}
+}
+
+// certificateDeployDestination 部署目标所在的机器：本机或通过 SSH 连接的远程主机
+type certificateDeployDestination interface {
+	WriteFile(name string, data []byte, mode os.FileMode) error
+	Run(command string, env map[string]string) (string, error)
+	Close() error
+}
+
+// openCertificateDeployDestination 便于测试替换 SSH 连接
+var openCertificateDeployDestination = func(deployment model.CertificateDeployment) (certificateDeployDestination, error) {
+	if deployment.Type != certificateDeploySSH {
+		return localDeployDestination{}, nil
+	}
+	client, err := NewIHostService().ConnSSH(deployment.HostID)
+	if err != nil {
+		return nil, err
+	}
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("sftp new client failed: %v", err)
+	}
+	return &sshDeployDestination{client: client, sftp: sftpClient}, nil
+}
+
+var certificateDeployLocks sync.Map
+
+func lockCertificateDeployment(id uint) func() {
+	value, _ := certificateDeployLocks.LoadOrStore(id, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+func (s *CertificateDeployService) List(certID uint) ([]dto.CertificateDeploymentInfo, error) {
+	items, err := s.deployRepo.GetList(repo.WithByCertificateID(certID))
+	if err != nil {
+		return nil, err
+	}
+	hostNames := make(map[uint]string)
+	result := make([]dto.CertificateDeploymentInfo, 0, len(items))
+	for _, item := range items {
+		info := dto.CertificateDeploymentInfo{
+			ID:            item.ID,
+			CertificateID: item.CertificateID,
+			Name:          item.Name,
+			Type:          item.Type,
+			Enabled:       item.Enabled,
+			HostID:        item.HostID,
+			Format:        item.Format,
+			CertPath:      item.CertPath,
+			KeyPath:       item.KeyPath,
+			PasswordSet:   item.Password != "",
+			Alias:         item.Alias,
+			Command:       item.Command,
+			LastDeployAt:  item.LastDeployAt,
+			LastStatus:    item.LastStatus,
+			LastMessage:   item.LastMessage,
+			CreatedAt:     item.CreatedAt,
+		}
+		if item.HostID > 0 {
+			name, ok := hostNames[item.HostID]
+			if !ok {
+				if host, err := s.hostRepo.Get(repo.WithByID(item.HostID)); err == nil {
+					name = host.Name
+				}
+				hostNames[item.HostID] = name
+			}
+			info.HostName = name
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// Create 新建部署目标；本机文件、钩子命令与 SSH 目标都以 root 写入任意路径或执行命令，仅管理员可配置
+func (s *CertificateDeployService) Create(req dto.CertificateDeploymentCreate, admin bool) error {
+	if !admin {
+		return buserr.New(constant.ErrPermissionDeny)
+	}
+	if _, err := s.certRepo.Get(repo.WithByID(req.CertificateID)); err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	item := model.CertificateDeployment{
+		CertificateID: req.CertificateID,
+		Name:          req.Name,
+		Type:          req.Type,
+		Enabled:       req.Enabled,
+		HostID:        req.HostID,
+		Format:        req.Format,
+		CertPath:      req.CertPath,
+		KeyPath:       req.KeyPath,
+		Password:      req.Password,
+		Alias:         req.Alias,
+		Command:       req.Command,
+	}
+	if err := s.normalizeDeployment(&item); err != nil {
+		return err
+	}
+	return s.deployRepo.Create(&item)
+}
+
+func (s *CertificateDeployService) Update(req dto.CertificateDeploymentUpdate, admin bool) error {
+	if !admin {
+		return buserr.New(constant.ErrPermissionDeny)
+	}
+	item, err := s.deployRepo.Get(repo.WithByID(req.ID))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	item.Name = req.Name
+	item.Type = req.Type
+	item.Enabled = req.Enabled
+	item.HostID = req.HostID
+	item.Format = req.Format
+	item.CertPath = req.CertPath
+	item.KeyPath = req.KeyPath
+	item.Alias = req.Alias
+	item.Command = req.Command
+	if req.Password != "" {
+		item.Password = req.Password
+	}
+	if err := s.normalizeDeployment(&item); err != nil {
+		return err
+	}
+	unlock := lockCertificateDeployment(item.ID)
+	defer unlock()
+	return s.deployRepo.Save(&item)
+}
+
+func (s *CertificateDeployService) Delete(id uint) error {
+	if _, err := s.deployRepo.Get(repo.WithByID(id)); err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	unlock := lockCertificateDeployment(id)
+	defer unlock()
+	if err := s.deployRepo.DeleteLogs(repo.WithByDeploymentID(id)); err != nil {
+		return err
+	}
+	return s.deployRepo.Delete(repo.WithByID(id))
+}
+
+// Run 手动部署一次，停用的目标也可以执行，便于配置后先验证
+func (s *CertificateDeployService) Run(id uint) error {
+	deployment, err := s.deployRepo.Get(repo.WithByID(id))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	cert, err := s.certRepo.Get(repo.WithByID(deployment.CertificateID))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	if err := s.deploy(deployment, cert, certificateDeployManual); err != nil {
+		return buserr.WithDetail(constant.ErrSSLDeploy, err.Error(), err)
+	}
+	return nil
+}
+
+func (s *CertificateDeployService) SearchLogs(req dto.SearchCertificateDeployLogReq) (int64, []model.CertificateDeployLog, error) {
+	var opts []repo.DBOption
+	if req.CertificateID > 0 {
+		opts = append(opts, repo.WithByCertificateID(req.CertificateID))
+	}
+	if req.DeploymentID > 0 {
+		opts = append(opts, repo.WithByDeploymentID(req.DeploymentID))
+	}
+	return s.deployRepo.PageLogs(req.Page, req.PageSize, opts...)
+}
+
+// normalizeDeployment 校验部署目标并清理与类型、格式无关的字段
+func (s *CertificateDeployService) normalizeDeployment(item *model.CertificateDeployment) error {
+	item.Name = strings.TrimSpace(item.Name)
+	item.CertPath = strings.TrimSpace(item.CertPath)
+	item.KeyPath = strings.TrimSpace(item.KeyPath)
+	item.Alias = strings.TrimSpace(item.Alias)
+	item.Command = strings.TrimSpace(item.Command)
+	invalid := func(detail string) error {
+		return buserr.WithDetail(constant.ErrInvalidParams, detail, nil)
+	}
+
+	if item.Type == certificateDeployCommand {
+		if item.Command == "" {
+			return invalid("部署钩子命令不能为空")
+		}
+		item.HostID = 0
+		item.Format = sslutil.ExportFormatPEM
+		item.CertPath, item.KeyPath, item.Password, item.Alias = "", "", "", ""
+		return nil
+	}
+
+	isAbs := filepath.IsAbs
+	if item.Type == certificateDeploySSH {
+		if item.HostID == 0 {
+			return invalid("请选择远程主机")
+		}
+		if _, err := s.hostRepo.Get(repo.WithByID(item.HostID)); err != nil {
+			return invalid("远程主机不存在")
+		}
+		isAbs = path.IsAbs
+	} else {
+		item.HostID = 0
+	}
+	if item.Format == "" {
+		item.Format = sslutil.ExportFormatPEM
+	}
+	if !isAbs(item.CertPath) {
+		return invalid("证书文件路径必须是绝对路径")
+	}
+	switch item.Format {
+	case sslutil.ExportFormatPEM:
+		if !isAbs(item.KeyPath) {
+			return invalid("私钥文件路径必须是绝对路径")
+		}
+		if item.KeyPath == item.CertPath {
+			return invalid("证书与私钥不能写入同一个文件")
+		}
+	case sslutil.ExportFormatPKCS12:
+		if item.Password == "" {
+			return invalid("PKCS#12 文件需要设置密码")
+		}
+	case sslutil.ExportFormatJKS:
+		if len(item.Password) < sslutil.MinJKSPasswordLength {
+			return invalid(fmt.Sprintf("JKS 密码至少 %d 位", sslutil.MinJKSPasswordLength))
+		}
+		if item.Alias == "" {
+			item.Alias = defaultJKSAlias
+		}
+	}
+	if item.Format != sslutil.ExportFormatPEM {
+		item.KeyPath = ""
+	}
+	if item.Format != sslutil.ExportFormatPKCS12 && item.Format != sslutil.ExportFormatJKS {
+		item.Password = ""
+	}
+	if item.Format != sslutil.ExportFormatJKS {
+		item.Alias = ""
+	}
+	return nil
+}
+
+// startCertificateDeployments 证书内容更新后在后台依次推送到所有启用的部署目标，返回目标数量
+func startCertificateDeployments(certID uint, trigger certificateDeployTrigger) int {
+	s := NewICertificateDeployService().(*CertificateDeployService)
+	deployments, err := s.deployRepo.GetList(repo.WithByCertificateID(certID))
+	if err != nil {
+		global.LOG.Warnf("[cert-deploy] Failed to list deployments of certificate %d: %v", certID, err)
+		return 0
+	}
+	enabled := deployments[:0]
+	for _, deployment := range deployments {
+		if deployment.Enabled {
+			enabled = append(enabled, deployment)
+		}
+	}
+	if len(enabled) == 0 {
+		return 0
+	}
+	go func() {
+		cert, err := s.certRepo.Get(repo.WithByID(certID))
+		if err != nil {
+			global.LOG.Warnf("[cert-deploy] Failed to load certificate %d: %v", certID, err)
+			return
+		}
+		for _, deployment := range enabled {
+			if err := s.deploy(deployment, cert, trigger); err != nil {
+				global.LOG.Warnf("[cert-deploy] Deploy %s to %s failed: %v", cert.PrimaryDomain, deployment.Name, err)
+			}
+		}
+	}()
+	return len(enabled)
+}
+
+// removeCertificateDeployments 删除证书后清理其部署目标与执行记录
+func removeCertificateDeployments(certIDs []uint) {
+	if err := repo.NewICertificateDeploymentRepo().DeleteByCertificates(certIDs); err != nil {
+		global.LOG.Warnf("[cert-deploy] Failed to remove deployments of certificates %v: %v", certIDs, err)
+	}
+}
+
+// deploy 执行一个部署目标并写入执行记录；自动触发的失败会发送通知
+func (s *CertificateDeployService) deploy(deployment model.CertificateDeployment, cert model.Certificate, trigger certificateDeployTrigger) error {
+	unlock := lockCertificateDeployment(deployment.ID)
+	defer unlock()
+
+	var lines []string
+	logf := func(format string, args ...interface{}) {
+		lines = append(lines, time.Now().Format("15:04:05")+" "+fmt.Sprintf(format, args...))
+	}
+	logf("部署 %s -> %s (%s, 触发: %s)", cert.PrimaryDomain, deployment.Name, deployment.Type, trigger)
+	err := s.runDeployment(deployment, cert, logf)
+	status, message := constant.StatusSuccess, ""
+	if err != nil {
+		status, message = constant.StatusFailed, err.Error()
+		logf("[失败] %v", err)
+	} else {
+		logf("[完成] 部署成功")
+	}
+
+	now := time.Now()
+	record := &model.CertificateDeployLog{
+		DeploymentID:  deployment.ID,
+		CertificateID: cert.ID,
+		Trigger:       string(trigger),
+		Status:        status,
+		Log:           strings.Join(lines, "\n"),
+		FinishedAt:    now,
+	}
+	if createErr := s.deployRepo.CreateLog(record); createErr != nil {
+		global.LOG.Warnf("[cert-deploy] Failed to save deploy log of %s: %v", deployment.Name, createErr)
+	} else if trimErr := s.deployRepo.TrimLogs(deployment.ID, certificateDeployLogKeep); trimErr != nil {
+		global.LOG.Warnf("[cert-deploy] Failed to trim deploy logs of %s: %v", deployment.Name, trimErr)
+	}
+	if updateErr := s.deployRepo.Update(deployment.ID, map[string]interface{}{
+		"last_deploy_at": now,
+		"last_status":    status,
+		"last_message":   message,
+	}); updateErr != nil {
+		global.LOG.Warnf("[cert-deploy] Failed to update deployment %s: %v", deployment.Name, updateErr)
+	}
+	if err != nil && trigger != certificateDeployManual {
+		notifySSLDeployFailed(cert.PrimaryDomain, deployment.Name, err)
+	}
+	return err
+}
+
+func (s *CertificateDeployService) runDeployment(deployment model.CertificateDeployment, cert model.Certificate, logf func(string, ...interface{})) error {
+	if strings.TrimSpace(cert.Pem) == "" || strings.TrimSpace(cert.PrivateKey) == "" {
+		return fmt.Errorf("证书尚未签发")
+	}
+	env := certificateDeployEnv(cert, deployment)
+	if deployment.Type == certificateDeployCommand {
+		certPath, keyPath, err := NewICertificateService().ResolveCertFilePaths(cert.ID)
+		if err != nil {
+			return err
+		}
+		env["XPANEL_CERT_FILE"] = certPath
+		env["XPANEL_KEY_FILE"] = keyPath
+	}
+	files, err := renderCertificateDeployFiles(deployment, []byte(cert.Pem), []byte(cert.PrivateKey))
+	if err != nil {
+		return err
+	}
+
+	if deployment.Type == certificateDeploySSH {
+		logf("连接远程主机 (ID=%d)", deployment.HostID)
+	}
+	destination, err := openCertificateDeployDestination(deployment)
+	if err != nil {
+		return err
+	}
+	defer destination.Close()
+
+	for _, file := range files {
+		if err := destination.WriteFile(file.Path, file.Data, file.Mode); err != nil {
+			return fmt.Errorf("写入 %s 失败: %v", file.Path, err)
+		}
+		logf("已写入 %s (%d 字节)", file.Path, len(file.Data))
+	}
+	if deployment.Command == "" {
+		return nil
+	}
+	logf("执行命令: %s", deployment.Command)
+	output, err := destination.Run(deployment.Command, env)
+	if output != "" {
+		logf("输出:\n%s", indentHookOutput(output))
+	}
+	if err != nil {
+		return fmt.Errorf("命令执行失败: %v", err)
+	}
+	return nil
+}
+
+type certificateDeployFile struct {
+	Path string
+	Data []byte
+	Mode os.FileMode
+}
+
+// renderCertificateDeployFiles 按目标格式生成要写入的文件；含私钥的文件一律仅属主可读
+func renderCertificateDeployFiles(deployment model.CertificateDeployment, certPEM, keyPEM []byte) ([]certificateDeployFile, error) {
+	if deployment.Type == certificateDeployCommand {
+		return nil, nil
+	}
+	switch deployment.Format {
+	case sslutil.ExportFormatPEM, "":
+		return []certificateDeployFile{
+			{Path: deployment.CertPath, Data: certPEM, Mode: 0644},
+			{Path: deployment.KeyPath, Data: keyPEM, Mode: 0600},
+		}, nil
+	case sslutil.ExportFormatBundle:
+		return []certificateDeployFile{{Path: deployment.CertPath, Data: sslutil.BundlePEM(certPEM, keyPEM), Mode: 0600}}, nil
+	case sslutil.ExportFormatPKCS12:
+		data, err := sslutil.EncodePKCS12(certPEM, keyPEM, deployment.Password)
+		if err != nil {
+			return nil, err
+		}
+		return []certificateDeployFile{{Path: deployment.CertPath, Data: data, Mode: 0600}}, nil
+	case sslutil.ExportFormatJKS:
+		data, err := sslutil.EncodeJKS(certPEM, keyPEM, deployment.Alias, deployment.Password)
+		if err != nil {
+			return nil, err
+		}
+		return []certificateDeployFile{{Path: deployment.CertPath, Data: data, Mode: 0600}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", deployment.Format)
+	}
+}
+
+// certificateDeployEnv 传给重载命令与部署钩子的环境变量；ssh/local 目标的文件路径指向写入后的位置
+func certificateDeployEnv(cert model.Certificate, deployment model.CertificateDeployment) map[string]string {
+	env := map[string]string{
+		"XPANEL_CERT_ID":     fmt.Sprintf("%d", cert.ID),
+		"XPANEL_CERT_DOMAIN": cert.PrimaryDomain,
+		"XPANEL_CERT_EXPIRE": cert.ExpireDate.Format(time.RFC3339),
+		"XPANEL_DEPLOY_NAME": deployment.Name,
+	}
+	domains := []string{cert.PrimaryDomain}
+	for _, domain := range strings.Split(cert.Domains, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" && domain != cert.PrimaryDomain {
+			domains = append(domains, domain)
+		}
+	}
+	env["XPANEL_CERT_DOMAINS"] = strings.Join(domains, ",")
+	if deployment.CertPath != "" {
+		env["XPANEL_CERT_FILE"] = deployment.CertPath
+	}
+	if deployment.KeyPath != "" {
+		env["XPANEL_KEY_FILE"] = deployment.KeyPath
+	}
+	return env
+}
+
+type localDeployDestination struct{}
+
+func (localDeployDestination) WriteFile(name string, data []byte, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(name, data, mode)
+}
+
+func (localDeployDestination) Run(command string, env map[string]string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), certificateDeployTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "bash", "-c", command)
+	// 不继承面板进程的环境变量，避免泄露面板配置中的密钥
+	cmd.Env = deployBaseEnv(os.Getenv("HOME"))
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	err := cmd.Run()
+	output := strings.TrimSpace(buf.String())
+	if ctx.Err() == context.DeadlineExceeded {
+		return output, fmt.Errorf("timeout after %s", certificateDeployTimeout)
+	}
+	return output, err
+}
+
+func (localDeployDestination) Close() error { return nil }
+
+type sshDeployDestination struct {
+	client *ssh.Client
+	sftp   *sftp.Client
+}
+
+// WriteFile 先写临时文件再重命名，远端服务不会读到写了一半的证书
+func (d *sshDeployDestination) WriteFile(name string, data []byte, mode os.FileMode) error {
+	if err := d.sftp.MkdirAll(path.Dir(name)); err != nil {
+		return err
+	}
+	temporary := path.Join(path.Dir(name), "."+path.Base(name)+".xpanel-tmp")
+	file, err := d.sftp.OpenFile(temporary, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	if err := file.Chmod(mode); err != nil {
+		file.Close()
+		d.sftp.Remove(temporary)
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		d.sftp.Remove(temporary)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		d.sftp.Remove(temporary)
+		return err
+	}
+	if err := d.sftp.PosixRename(temporary, name); err == nil {
+		return nil
+	}
+	_ = d.sftp.Remove(name)
+	if err := d.sftp.Rename(temporary, name); err != nil {
+		d.sftp.Remove(temporary)
+		return err
+	}
+	return nil
+}
+
+// Run sshd 默认不接受客户端传入的环境变量，因此以 export 前缀的方式传给远端 shell
+func (d *sshDeployDestination) Run(command string, env map[string]string) (string, error) {
+	session, err := d.client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var script strings.Builder
+	for _, key := range keys {
+		script.WriteString("export " + key + "=" + shellQuote(env[key]) + "\n")
+	}
+	script.WriteString(command)
+
+	var buf bytes.Buffer
+	session.Stdout = &buf
+	session.Stderr = &buf
+	done := make(chan error, 1)
+	go func() { done <- session.Run("bash -c " + shellQuote(script.String())) }()
+	select {
+	case err := <-done:
+		return strings.TrimSpace(buf.String()), err
+	case <-time.After(certificateDeployTimeout):
+		// 断开连接让远端命令随之退出，此时输出仍可能在写入，不再读取
+		session.Close()
+		d.client.Close()
+		return "", fmt.Errorf("timeout after %s", certificateDeployTimeout)
+	}
+}
+
+func (d *sshDeployDestination) Close() error {
+	d.sftp.Close()
+	return d.client.Close()
+}
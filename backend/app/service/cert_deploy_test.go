@@ -0,0 +1,288 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/security/credentials"
+)
+
+func setupCertificateDeployTest(t *testing.T) (*CertificateDeployService, model.Certificate) {
+	t.Helper()
+	setupCertificateStatusTest(t)
+	if err := global.DB.AutoMigrate(&model.Host{}); err != nil {
+		t.Fatal(err)
+	}
+	manager, _, err := credentials.LoadOrCreate(filepath.Join(t.TempDir(), "credential-keyring.json"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousCredentials := global.CREDENTIALS
+	global.CREDENTIALS = manager
+	t.Cleanup(func() { global.CREDENTIALS = previousCredentials })
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(7),
+		Subject:      pkix.Name{CommonName: "deploy.example.test"},
+		DNSNames:     []string{"deploy.example.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	cert := model.Certificate{
+		PrimaryDomain: "deploy.example.test",
+		Domains:       "deploy.example.test,www.deploy.example.test",
+		Pem:           string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		PrivateKey:    string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+		Status:        "applied",
+		ExpireDate:    tmpl.NotAfter,
+	}
+	if err := repo.NewICertificateRepo().Create(&cert); err != nil {
+		t.Fatal(err)
+	}
+	return NewICertificateDeployService().(*CertificateDeployService), cert
+}
+
+func TestNormalizeCertificateDeployment(t *testing.T) {
+	svc, cert := setupCertificateDeployTest(t)
+	host := model.Host{Name: "edge", Addr: "10.0.0.2", Port: 22, User: "root", AuthMode: "password"}
+	if err := global.DB.Create(&host).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []model.CertificateDeployment{
+		{Type: certificateDeployLocal, Format: "pem", CertPath: "/etc/ssl/site.crt"},
+		{Type: certificateDeployLocal, Format: "pem", CertPath: "site.crt", KeyPath: "/etc/ssl/site.key"},
+		{Type: certificateDeployLocal, Format: "jks", CertPath: "/opt/app/keystore.jks", Password: "short"},
+		{Type: certificateDeployLocal, Format: "pkcs12", CertPath: "/opt/app/site.p12"},
+		{Type: certificateDeploySSH, Format: "bundle", CertPath: "/etc/haproxy/site.pem"},
+		{Type: certificateDeploySSH, HostID: host.ID + 1, Format: "bundle", CertPath: "/etc/haproxy/site.pem"},
+		{Type: certificateDeployCommand},
+	}
+	for i, item := range invalid {
+		item.CertificateID = cert.ID
+		var bizErr buserr.BusinessError
+		if err := svc.normalizeDeployment(&item); !errors.As(err, &bizErr) || bizErr.Msg != constant.ErrInvalidParams {
+			t.Errorf("case %d should be rejected as invalid params, got %v", i, err)
+		}
+	}
+
+	jks := model.CertificateDeployment{Type: certificateDeploySSH, HostID: host.ID, Format: "jks",
+		CertPath: "/opt/app/keystore.jks", KeyPath: "/opt/app/unused.key", Password: "changeit"}
+	if err := svc.normalizeDeployment(&jks); err != nil {
+		t.Fatal(err)
+	}
+	if jks.KeyPath != "" || jks.Alias != defaultJKSAlias {
+		t.Fatalf("JKS target should drop the key path and default the alias: %+v", jks)
+	}
+
+	hook := model.CertificateDeployment{Type: certificateDeployCommand, HostID: host.ID, CertPath: "/tmp/x",
+		Password: "secret", Command: " systemctl reload app "}
+	if err := svc.normalizeDeployment(&hook); err != nil {
+		t.Fatal(err)
+	}
+	if hook.HostID != 0 || hook.CertPath != "" || hook.Password != "" || hook.Command != "systemctl reload app" {
+		t.Fatalf("hook target should only keep its command: %+v", hook)
+	}
+}
+
+func TestRunLocalCertificateDeployment(t *testing.T) {
+	svc, cert := setupCertificateDeployTest(t)
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "reloaded")
+	item := model.CertificateDeployment{
+		CertificateID: cert.ID,
+		Name:          "app keystore",
+		Type:          certificateDeployLocal,
+		Enabled:       true,
+		Format:        "pkcs12",
+		CertPath:      filepath.Join(dir, "conf", "site.p12"),
+		Password:      "secret",
+		Command:       `echo "$XPANEL_CERT_DOMAINS $XPANEL_CERT_FILE" > ` + marker,
+	}
+	if err := svc.normalizeDeployment(&item); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.deployRepo.Create(&item); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.Run(item.ID); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(item.CertPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("PKCS#12 file mode = %v", info.Mode().Perm())
+	}
+	reloaded, _ := os.ReadFile(marker)
+	if got := strings.TrimSpace(string(reloaded)); got != "deploy.example.test,www.deploy.example.test "+item.CertPath {
+		t.Fatalf("reload command environment: %q", got)
+	}
+
+	stored, err := svc.deployRepo.Get(repo.WithByID(item.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Password != "secret" || stored.LastStatus != constant.StatusSuccess || stored.LastDeployAt == nil {
+		t.Fatalf("deployment after run: %+v", stored)
+	}
+	total, logs, err := svc.deployRepo.PageLogs(1, 10, repo.WithByDeploymentID(item.ID))
+	if err != nil || total != 1 || logs[0].Trigger != string(certificateDeployManual) || logs[0].Status != constant.StatusSuccess {
+		t.Fatalf("deploy logs: %d %+v %v", total, logs, err)
+	}
+}
+
+type fakeDeployDestination struct {
+	files   map[string][]byte
+	modes   map[string]os.FileMode
+	command string
+	env     map[string]string
+	runErr  error
+}
+
+func (d *fakeDeployDestination) WriteFile(name string, data []byte, mode os.FileMode) error {
+	d.files[name] = data
+	d.modes[name] = mode
+	return nil
+}
+
+func (d *fakeDeployDestination) Run(command string, env map[string]string) (string, error) {
+	d.command, d.env = command, env
+	return "reload failed: config test", d.runErr
+}
+
+func (d *fakeDeployDestination) Close() error { return nil }
+
+func TestAutomaticDeploymentFailureIsLoggedAndNotified(t *testing.T) {
+	svc, cert := setupCertificateDeployTest(t)
+	destination := &fakeDeployDestination{
+		files:  map[string][]byte{},
+		modes:  map[string]os.FileMode{},
+		runErr: errors.New("exit status 1"),
+	}
+	previous := openCertificateDeployDestination
+	openCertificateDeployDestination = func(model.CertificateDeployment) (certificateDeployDestination, error) {
+		return destination, nil
+	}
+	t.Cleanup(func() { openCertificateDeployDestination = previous })
+
+	item := model.CertificateDeployment{
+		CertificateID: cert.ID, Name: "edge", Type: certificateDeploySSH, HostID: 1, Enabled: true,
+		Format: "pem", CertPath: "/etc/nginx/ssl/site.crt", KeyPath: "/etc/nginx/ssl/site.key",
+		Command: "nginx -s reload",
+	}
+	if err := svc.deployRepo.Create(&item); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.deploy(item, cert, certificateDeployRenew); err == nil {
+		t.Fatal("failing reload command should fail the deployment")
+	}
+
+	if string(destination.files[item.CertPath]) != cert.Pem || destination.modes[item.KeyPath] != 0600 {
+		t.Fatalf("uploaded files: %v", destination.modes)
+	}
+	if destination.env["XPANEL_KEY_FILE"] != item.KeyPath || destination.env["XPANEL_CERT_DOMAIN"] != cert.PrimaryDomain {
+		t.Fatalf("reload command environment: %v", destination.env)
+	}
+	stored, _ := svc.deployRepo.Get(repo.WithByID(item.ID))
+	if stored.LastStatus != constant.StatusFailed || !strings.Contains(stored.LastMessage, "exit status 1") {
+		t.Fatalf("deployment after failure: %+v", stored)
+	}
+	_, logs, _ := svc.deployRepo.PageLogs(1, 10, repo.WithByDeploymentID(item.ID))
+	if len(logs) != 1 || logs[0].Trigger != string(certificateDeployRenew) || !strings.Contains(logs[0].Log, "config test") {
+		t.Fatalf("deploy log should keep the command output: %+v", logs)
+	}
+	var notifications []model.Notification
+	global.DB.Where("event = ?", "ssl.deploy.failed").Find(&notifications)
+	if len(notifications) != 1 {
+		t.Fatalf("expected a deploy failure notification, got %d", len(notifications))
+	}
+}
+
+func TestCertificateDeployLogsAreTrimmedAndRemovedWithCertificate(t *testing.T) {
+	svc, cert := setupCertificateDeployTest(t)
+	item := model.CertificateDeployment{CertificateID: cert.ID, Name: "hook", Type: certificateDeployCommand, Command: "true"}
+	if err := svc.deployRepo.Create(&item); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := svc.deployRepo.CreateLog(&model.CertificateDeployLog{DeploymentID: item.ID, CertificateID: cert.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := svc.deployRepo.TrimLogs(item.ID, 3); err != nil {
+		t.Fatal(err)
+	}
+	if total, _, _ := svc.deployRepo.PageLogs(1, 10, repo.WithByDeploymentID(item.ID)); total != 3 {
+		t.Fatalf("logs after trim = %d", total)
+	}
+
+	certService := &CertificateService{certRepo: repo.NewICertificateRepo(), settingRepo: repo.NewISettingRepo()}
+	if err := certService.Delete(cert.ID); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := svc.deployRepo.GetList(repo.WithByCertificateID(cert.ID)); len(items) != 0 {
+		t.Fatalf("deployments should be removed with the certificate: %+v", items)
+	}
+	if total, _, _ := svc.deployRepo.PageLogs(1, 10, repo.WithByCertificateID(cert.ID)); total != 0 {
+		t.Fatalf("deploy logs should be removed with the certificate, %d left", total)
+	}
+}
+
+func TestDeploymentsRequireAdmin(t *testing.T) {
+	svc, cert := setupCertificateDeployTest(t)
+	host := model.Host{Name: "edge", Addr: "10.0.0.2", Port: 22, User: "root", AuthMode: "password"}
+	if err := global.DB.Create(&host).Error; err != nil {
+		t.Fatal(err)
+	}
+	var bizErr buserr.BusinessError
+	edge := dto.CertificateDeploymentCreate{CertificateID: cert.ID, Name: "edge", Type: certificateDeploySSH, HostID: host.ID,
+		Format: "bundle", CertPath: "/etc/haproxy/site.pem"}
+	for _, req := range []dto.CertificateDeploymentCreate{
+		{CertificateID: cert.ID, Name: "local", Type: certificateDeployLocal, Format: "bundle", CertPath: "/etc/haproxy/site.pem"},
+		{CertificateID: cert.ID, Name: "hook", Type: certificateDeployCommand, Command: "systemctl reload app"},
+		edge,
+	} {
+		if err := svc.Create(req, false); !errors.As(err, &bizErr) || bizErr.Msg != constant.ErrPermissionDeny {
+			t.Fatalf("operators should not create %s deployments, got %v", req.Name, err)
+		}
+	}
+
+	if err := svc.Create(edge, true); err != nil {
+		t.Fatal(err)
+	}
+	item, _ := svc.deployRepo.Get(repo.WithByCertificateID(cert.ID))
+	update := dto.CertificateDeploymentUpdate{ID: item.ID, Name: "edge", Type: certificateDeploySSH, HostID: host.ID, Format: "bundle",
+		CertPath: "/root/.ssh/authorized_keys"}
+	if err := svc.Update(update, false); !errors.As(err, &bizErr) || bizErr.Msg != constant.ErrPermissionDeny {
+		t.Fatalf("operators should not change the remote path, got %v", err)
+	}
+	if stored, _ := svc.deployRepo.Get(repo.WithByID(item.ID)); stored.CertPath != edge.CertPath {
+		t.Fatalf("denied update was saved: %+v", stored)
+	}
+}
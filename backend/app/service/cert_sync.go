@@ -341,6 +341,7 @@ func (s *CertSourceService) syncFromSource(source model.CertSource) error {
 			localCert.LineageUID = remote.LineageUID
 			applySyncedCertificateMetadata(&localCert, source.ID, source.Name)
 			applyRemoteRenewalMetadata(&localCert, remote)
+			clearCertificateMonitorState(&localCert)
 			localCert.Status = "applied"
 			localCert.Message = fmt.Sprintf("从 %s 同步 (%s)", source.Name, remote.ExpireDate.Format("2006-01-02"))
 			fileTx, err := prepareSyncedCertFileTransaction(sslDir, localCert, true)
@@ -375,6 +376,7 @@ func (s *CertSourceService) syncFromSource(source model.CertSource) error {
 			logEntry.Message = fmt.Sprintf("证书已更新 (新到期 %s)", remote.ExpireDate.Format("2006-01-02"))
 			logEntry.CertificateID = localCert.ID
 			certIDsToRefresh[localCert.ID] = struct{}{}
+			startCertificateDeployments(localCert.ID, certificateDeploySync)
 			s.logRepo.Create(&logEntry)
 			updatedCount++
 		} else {
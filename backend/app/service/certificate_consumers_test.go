@@ -30,7 +30,7 @@ func installFakeNginx(t *testing.T, running bool) string {
 	if err := os.WriteFile(filepath.Join(root, "sbin", "nginx"), []byte(script), 0o755); err != nil {
 		t.Fatalf("write fake nginx: %v", err)
 	}
-	if err := os.WriteFile(filepath.Join(root, "conf", "nginx.conf"), []byte("events {}\n"), 0o644); err != nil {
+	if err := os.WriteFile(filepath.Join(root, "conf", "nginx.conf"), []byte("events {}\nhttp {\n}\n"), 0o644); err != nil {
 		t.Fatalf("write fake nginx config: %v", err)
 	}
 	if running {
@@ -100,6 +100,7 @@ func (s *CertificateService) deleteCertificates(requested []uint, certs []model.
 			result.Failed = append(result.Failed, certificateDeleteIssue(id, cert.PrimaryDomain, "删除证书记录失败: "+err.Error()))
 			continue
 		}
+		removeCertificateDeployments([]uint{id})
 		result.DeletedCount++
 	}
 	return result, nil
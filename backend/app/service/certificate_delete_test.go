@@ -27,6 +27,8 @@ func setupCertificateDeleteTest(t *testing.T) (*CertificateService, string) {
 		&model.HAProxyLB{},
 		&model.GostService{},
 		&model.Setting{},
+		&model.CertificateDeployment{},
+		&model.CertificateDeployLog{},
 	); err != nil {
 		t.Fatalf("migrate: %v", err)
 	}
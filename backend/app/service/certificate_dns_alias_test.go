@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+
+	"xpanel/app/dto"
+	"xpanel/app/repo"
+	"xpanel/global"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestCertificateDNSAliasRequiresDNSProvider(t *testing.T) {
+	svc, _ := setupCertificateDeleteTest(t)
+	previousLog := global.LOG
+	global.LOG = logrus.New()
+	t.Cleanup(func() { global.LOG = previousLog })
+
+	if err := svc.Create(dto.CertificateCreate{PrimaryDomain: "customer.com", Provider: "http", DnsAlias: "validation.example.net"}); err == nil {
+		t.Fatal("alias mode should be rejected for http validation")
+	}
+	if err := svc.Create(dto.CertificateCreate{PrimaryDomain: "customer.com", Provider: "dns", DnsAlias: "bad alias"}); err == nil {
+		t.Fatal("invalid alias domain should be rejected")
+	}
+	if err := svc.Create(dto.CertificateCreate{PrimaryDomain: "customer.com", Provider: "dns", DnsAlias: "_acme-challenge.Validation.Example.NET."}); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := svc.certRepo.Get(repo.WithLikeDomain("customer.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.DnsAlias != "validation.example.net" {
+		t.Fatalf("alias should be normalized, got %q", cert.DnsAlias)
+	}
+
+	if err := svc.Update(dto.CertificateUpdate{ID: cert.ID, AutoRenew: true}); err != nil {
+		t.Fatal(err)
+	}
+	if cert, _ = svc.certRepo.Get(repo.WithByID(cert.ID)); cert.DnsAlias != "validation.example.net" {
+		t.Fatalf("update without alias should keep it, got %q", cert.DnsAlias)
+	}
+	empty := ""
+	if err := svc.Update(dto.CertificateUpdate{ID: cert.ID, DnsAlias: &empty}); err != nil {
+		t.Fatal(err)
+	}
+	if cert, _ = svc.certRepo.Get(repo.WithByID(cert.ID)); cert.DnsAlias != "" {
+		t.Fatalf("empty alias should turn alias mode off, got %q", cert.DnsAlias)
+	}
+}
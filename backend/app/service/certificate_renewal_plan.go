@@ -6,6 +6,7 @@ import (
 	"xpanel/app/dto"
 	"xpanel/app/model"
 	"xpanel/app/repo"
+	sslutil "xpanel/utils/ssl"
 )
 
 const certificateRenewBefore = 15 * 24 * time.Hour
@@ -16,6 +17,7 @@ const (
 	renewalManagementManual = "manual"
 
 	renewalPlanExpired        = "expired"
+	renewalPlanRevoked        = "revoked"
 	renewalPlanApplying       = "applying"
 	renewalPlanSyncPaused     = "sync_paused"
 	renewalPlanRenewError     = "renew_error"
@@ -37,7 +39,24 @@ func nextLocalAutoRenewAt(cert model.Certificate, now time.Time, location *time.
 	}
 
 	now = now.In(location)
-	windowStart := cert.ExpireDate.In(location).Add(-certificateRenewBefore)
+	// 吊销与 ARI 续签由每小时 15 分的状态检查任务执行
+	if cert.RevocationStatus == sslutil.RevocationRevoked {
+		next := nextCertificateStatusCheckAt(now)
+		return &next
+	}
+	windowStart := cert.ExpireDate.In(location).Add(-certificateRenewWindow(cert, certificateRenewBefore))
+	if cert.RenewalScheduledAt != nil {
+		windowStart = cert.RenewalScheduledAt.In(location)
+		// 续签失败的证书只由每天凌晨的任务重试
+		if cert.Status != "error" {
+			target := windowStart
+			if target.Before(now) {
+				target = now
+			}
+			next := nextCertificateStatusCheckAt(target)
+			return &next
+		}
+	}
 	var next time.Time
 	if now.Before(windowStart) {
 		next = time.Date(windowStart.Year(), windowStart.Month(), windowStart.Day(), 2, 0, 0, 0, location)
@@ -53,6 +72,14 @@ func nextLocalAutoRenewAt(cert model.Certificate, now time.Time, location *time.
 	return &next
 }
 
+func nextCertificateStatusCheckAt(target time.Time) time.Time {
+	next := time.Date(target.Year(), target.Month(), target.Day(), target.Hour(), 15, 0, 0, target.Location())
+	if next.Before(target) {
+		next = next.Add(time.Hour)
+	}
+	return next
+}
+
 func nextCertificateSyncAt(source model.CertSource, now time.Time, location *time.Location) *time.Time {
 	if !source.Enabled || source.ResumeRequired || source.SyncInterval <= 0 {
 		return nil
@@ -143,6 +170,12 @@ func buildCertificateRenewalPlanItem(
 			item.Status = renewalPlanApplying
 			item.StatusMessage = "证书正在申请或续签"
 			item.NextAutoRenewAt = nil
+		case cert.RevocationStatus == sslutil.RevocationRevoked:
+			item.Status = renewalPlanRevoked
+			item.StatusMessage = "证书已被吊销，等待重新签发"
+			if !cert.AutoRenew {
+				item.StatusMessage = "证书已被吊销，请重新申请"
+			}
 		case cert.Status == "error":
 			item.Status = renewalPlanRenewError
 			item.StatusMessage = cert.Message
@@ -152,7 +185,7 @@ func buildCertificateRenewalPlanItem(
 		case item.NextAutoRenewAt == nil:
 			item.Status = renewalPlanManual
 			item.StatusMessage = "本机自动续签未启用或证书材料不完整"
-		case !cert.ExpireDate.After(now.Add(certificateRenewBefore)):
+		case certificateRenewalDue(cert, now, certificateRenewBefore):
 			item.Status = renewalPlanRenewDue
 			item.StatusMessage = "已进入自动续签窗口，等待执行或重试"
 		default:
@@ -211,6 +244,9 @@ func buildCertificateRenewalPlanItem(
 		if expired {
 			item.Status = renewalPlanExpired
 			item.StatusMessage = "证书已过期"
+		} else if cert.RevocationStatus == sslutil.RevocationRevoked {
+			item.Status = renewalPlanRevoked
+			item.StatusMessage = "证书已被吊销，请手动更换"
 		} else if !cert.ExpireDate.IsZero() && !cert.ExpireDate.After(now.Add(certificateRenewBefore)) {
 			item.Status = renewalPlanExpiringManual
 			item.StatusMessage = "证书即将到期，请手动更换"
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	sslutil "xpanel/utils/ssl"
+)
+
+var revokeCertificateAtCA = func(acme model.AcmeAccount, certPEM string, reason uint) error {
+	client, err := sslutil.NewAcmeClient(acme.Email, acme.PrivateKey, acme.KeyType, acme.Type, acme.CaDirURL, acme.URL)
+	if err != nil {
+		return err
+	}
+	return client.RevokeCertificate([]byte(certPEM), reason)
+}
+
+var refreshRevokedCertificateConsumers = refreshUpdatedCertificateConsumers
+
+// Revoke 向 CA 吊销本机签发的证书，私有 CA 签发的证书写入其 CRL。选择重新签发时在后台用新私钥补签，完成后刷新所有使用方；
+// 否则关闭自动续签并立即刷新使用方，让 nginx 丢弃缓存的 OCSP 装订响应
+func (s *CertificateService) Revoke(req dto.CertificateRevoke) error {
+	release, err := acquireCertificateRenewal(req.ID)
+	if err != nil {
+		return buserr.WithDetail(constant.ErrSSLRevoke, err.Error(), err)
+	}
+	cert, err := s.certRepo.Get(repo.WithByID(req.ID))
+	if err != nil {
+		release()
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	private := cert.SourceType == certificateSourcePrivate
+	if !isRenewableCertificate(cert) || (cert.AcmeAccountID == 0 && !private) || strings.TrimSpace(cert.Pem) == "" {
+		release()
+		return buserr.New(constant.ErrSSLRevokeUnsupported)
+	}
+	if cert.Status == "applying" {
+		release()
+		return buserr.WithDetail(constant.ErrSSLRevoke, errCertificateRenewalInProgress.Error(), errCertificateRenewalInProgress)
+	}
+	revoke := func() error {
+		return NewIPrivateCAService().(*PrivateCAService).revokeCertificate(cert, int(req.Reason))
+	}
+	if !private {
+		acme, err := s.acmeRepo.Get(repo.WithByID(cert.AcmeAccountID))
+		if err != nil {
+			release()
+			return buserr.WithDetail(constant.ErrSSLRevoke, "ACME 账户不存在", err)
+		}
+		revoke = func() error { return revokeCertificateAtCA(acme, cert.Pem, req.Reason) }
+	}
+
+	logger, logFile := s.openSSLLog(cert)
+	if logFile != nil {
+		defer logFile.Close()
+	}
+	logger.Printf("[开始] 吊销证书: %s (原因码 %d)", cert.PrimaryDomain, req.Reason)
+	if err := revoke(); err != nil {
+		release()
+		logger.Printf("[错误] 吊销失败: %v", err)
+		return buserr.WithDetail(constant.ErrSSLRevoke, err.Error(), err)
+	}
+	logger.Printf("[成功] CA 已吊销证书")
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"revocation_status":     sslutil.RevocationRevoked,
+		"revocation_reason":     int(req.Reason),
+		"revoked_at":            now,
+		"revocation_checked_at": now,
+		"message":               fmt.Sprintf("证书已于 %s 吊销", now.Format("2006-01-02 15:04:05")),
+	}
+	if !req.Renew {
+		updates["auto_renew"] = false
+	}
+	err = persistCertificateUpdate(s.certRepo.Update, cert.ID, updates)
+	release()
+	if err != nil {
+		logger.Printf("[错误] %v", err)
+		return err
+	}
+
+	if req.Renew {
+		go func() {
+			if err := s.renew(cert.ID, certificateRenewalManual); err != nil {
+				global.LOG.Errorf("Reissue revoked certificate %s failed: %v", cert.PrimaryDomain, err)
+				return
+			}
+			if err := refreshRevokedCertificateConsumers([]uint{cert.ID}); err != nil {
+				global.LOG.Warnf("Refresh consumers of revoked certificate %s failed: %v", cert.PrimaryDomain, err)
+			}
+		}()
+		return nil
+	}
+	if err := refreshRevokedCertificateConsumers([]uint{cert.ID}); err != nil {
+		logger.Printf("[警告] 刷新证书使用方失败: %v", err)
+		return fmt.Errorf("证书已吊销，但刷新使用方失败: %w", err)
+	}
+	logger.Printf("[完成] 证书吊销流程结束")
+	return nil
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/global"
+	sslutil "xpanel/utils/ssl"
+)
+
+const (
+	renewalInfoDefaultRetry   = 6 * time.Hour
+	renewalInfoMinRetry       = time.Hour
+	renewalInfoMaxRetry       = 24 * time.Hour
+	renewalInfoUnsupportedTTL = 7 * 24 * time.Hour // CA 之后可能开启 ARI，隔一段时间再试
+	revocationCheckInterval   = 6 * time.Hour
+)
+
+// getCertificateRenewalInfo 向签发证书的 ACME 账户所在 CA 查询 ARI
+var getCertificateRenewalInfo = func(acme model.AcmeAccount, certPEM string) (*sslutil.RenewalInfo, error) {
+	client, err := sslutil.NewAcmeClient(acme.Email, acme.PrivateKey, acme.KeyType, acme.Type, acme.CaDirURL, acme.URL)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetRenewalInfo([]byte(certPEM))
+}
+
+var checkCertificateRevocation = func(certPEM string) (*sslutil.RevocationStatus, error) {
+	return sslutil.CheckRevocation([]byte(certPEM))
+}
+
+// CheckCertificateStatus 定时任务：刷新 ACME 证书的 ARI 续签窗口并检查所有证书的吊销状态，
+// 被吊销或已到 ARI 续签时间的证书立即续签，不必等每天凌晨的例行续签
+func CheckCertificateStatus() {
+	certService := NewICertificateService().(*CertificateService)
+	certs, err := certService.certRepo.GetList()
+	if err != nil {
+		global.LOG.Warnf("[cert-status] Failed to list certificates: %v", err)
+		return
+	}
+
+	now := time.Now()
+	var due []model.Certificate
+	for i := range certs {
+		cert := &certs[i]
+		wasRevoked := cert.RevocationStatus == sslutil.RevocationRevoked
+		if !certService.refreshCertificateStatus(cert, now) {
+			continue
+		}
+		if statusJobShouldRenew(*cert, wasRevoked, now) {
+			due = append(due, *cert)
+		}
+	}
+	certService.autoRenewCertificates(due)
+}
+
+// statusJobShouldRenew 续签失败的证书仍由每日任务重试，避免每小时向 CA 重复发起注定失败的请求；
+// 只有本次刚发现被吊销的证书不论此前状态都立即重新签发一次
+func statusJobShouldRenew(cert model.Certificate, wasRevoked bool, now time.Time) bool {
+	newlyRevoked := !wasRevoked && cert.RevocationStatus == sslutil.RevocationRevoked
+	if cert.Status == "error" && !newlyRevoked {
+		return false
+	}
+	return shouldAutoRenewCertificate(cert, now, certificateRenewBefore)
+}
+
+// refreshCertificateStatus 在续签锁内刷新单个证书的状态，避免把旧证书的结果写到刚续签好的记录上；
+// 证书正在续签时返回 false
+func (s *CertificateService) refreshCertificateStatus(cert *model.Certificate, now time.Time) bool {
+	release, err := acquireCertificateRenewal(cert.ID)
+	if err != nil {
+		return false
+	}
+	defer release()
+	if cert.Status == "applying" || strings.TrimSpace(cert.Pem) == "" {
+		return false
+	}
+	if updates := s.renewalInfoUpdates(*cert, now); len(updates) > 0 {
+		s.persistCertificateStatus(cert, updates)
+	}
+	if updates := revocationUpdates(*cert, now); len(updates) > 0 {
+		s.persistCertificateStatus(cert, updates)
+	}
+	return true
+}
+
+func (s *CertificateService) persistCertificateStatus(cert *model.Certificate, updates map[string]interface{}) {
+	if err := s.certRepo.Update(cert.ID, updates); err != nil {
+		global.LOG.Warnf("[cert-status] Failed to save status of %s: %v", cert.PrimaryDomain, err)
+		return
+	}
+	applyCertificateStatusUpdates(cert, updates)
+}
+
+// renewalInfoUpdates 到了 CA 要求的查询时间才向其请求 ARI，仅处理本机自动续签的 ACME 证书
+func (s *CertificateService) renewalInfoUpdates(cert model.Certificate, now time.Time) map[string]interface{} {
+	if !cert.AutoRenew || !isRenewableCertificate(cert) || cert.AcmeAccountID == 0 ||
+		cert.ExpireDate.IsZero() || !cert.ExpireDate.After(now) ||
+		(cert.RenewalInfoCheckAt != nil && cert.RenewalInfoCheckAt.After(now)) {
+		return nil
+	}
+	acme, err := s.acmeRepo.Get(repo.WithByID(cert.AcmeAccountID))
+	if err != nil {
+		return nil
+	}
+	info, err := getCertificateRenewalInfo(acme, cert.Pem)
+	switch {
+	case errors.Is(err, sslutil.ErrRenewalInfoUnsupported):
+		// 不支持 ARI 的 CA 回到按到期时间续签
+		return map[string]interface{}{
+			"renewal_window_start":    nil,
+			"renewal_window_end":      nil,
+			"renewal_scheduled_at":    nil,
+			"renewal_explanation_url": "",
+			"renewal_info_check_at":   now.Add(renewalInfoUnsupportedTTL),
+		}
+	case err != nil:
+		global.LOG.Warnf("[cert-status] Failed to get renewal info of %s: %v", cert.PrimaryDomain, err)
+		return map[string]interface{}{"renewal_info_check_at": now.Add(renewalInfoDefaultRetry)}
+	}
+	return renewalInfoScheduleUpdates(cert, info, now, rand.Int63n)
+}
+
+// renewalInfoScheduleUpdates 窗口变化时在新窗口内随机选一个续签时间（RFC 9773 4.2），
+// 让大量证书分散续签；窗口已过去时立即续签。randInt63n 便于测试替换
+func renewalInfoScheduleUpdates(
+	cert model.Certificate,
+	info *sslutil.RenewalInfo,
+	now time.Time,
+	randInt63n func(int64) int64,
+) map[string]interface{} {
+	retry := info.RetryAfter
+	if retry <= 0 {
+		retry = renewalInfoDefaultRetry
+	}
+	retry = min(max(retry, renewalInfoMinRetry), renewalInfoMaxRetry)
+	updates := map[string]interface{}{
+		"renewal_info_check_at":   now.Add(retry),
+		"renewal_explanation_url": info.ExplanationURL,
+	}
+
+	start, end := info.WindowStart, info.WindowEnd
+	if cert.RenewalScheduledAt != nil && cert.RenewalWindowStart != nil && cert.RenewalWindowEnd != nil &&
+		cert.RenewalWindowStart.Equal(start) && cert.RenewalWindowEnd.Equal(end) {
+		return updates
+	}
+	scheduled := start
+	if window := end.Sub(start); window > 0 {
+		scheduled = start.Add(time.Duration(randInt63n(int64(window))))
+	}
+	if scheduled.Before(now) {
+		scheduled = now
+	}
+	updates["renewal_window_start"] = start
+	updates["renewal_window_end"] = end
+	updates["renewal_scheduled_at"] = scheduled
+	return updates
+}
+
+// revocationUpdates 定期通过 OCSP/CRL 检查证书是否被吊销；已确认吊销的证书不再重复检查，直到被替换
+func revocationUpdates(cert model.Certificate, now time.Time) map[string]interface{} {
+	// 私有 CA 签发的证书由面板自己吊销，无需查询
+	if cert.SourceType == certificateSourcePrivate || cert.RevocationStatus == sslutil.RevocationRevoked ||
+		cert.ExpireDate.IsZero() || !cert.ExpireDate.After(now) ||
+		(cert.RevocationCheckedAt != nil && now.Sub(*cert.RevocationCheckedAt) < revocationCheckInterval) {
+		return nil
+	}
+	updates := map[string]interface{}{"revocation_checked_at": now}
+	status, err := checkCertificateRevocation(cert.Pem)
+	switch {
+	case errors.Is(err, sslutil.ErrNoRevocationEndpoint):
+		updates["revocation_status"] = sslutil.RevocationUnknown
+		return updates
+	case err != nil:
+		// 吊销服务暂时不可用时保留上次的结果
+		global.LOG.Warnf("[cert-status] Failed to check revocation of %s: %v", cert.PrimaryDomain, err)
+		return updates
+	}
+	updates["revocation_status"] = status.Status
+	if status.Status != sslutil.RevocationRevoked {
+		return updates
+	}
+
+	revokedAt := status.RevokedAt
+	if revokedAt.IsZero() {
+		revokedAt = now
+	}
+	updates["revoked_at"] = revokedAt
+	updates["revocation_reason"] = status.Reason
+	global.LOG.Warnf("[cert-status] Certificate %s (ID=%d) was revoked at %s (reason %d, via %s)",
+		cert.PrimaryDomain, cert.ID, revokedAt.Format(time.RFC3339), status.Reason, status.Source)
+	content := fmt.Sprintf("吊销时间 %s，原因码 %d", revokedAt.Local().Format("2006-01-02 15:04:05"), status.Reason)
+	if cert.AutoRenew && isRenewableCertificate(cert) && strings.TrimSpace(cert.PrivateKey) != "" {
+		content += "，正在自动重新签发"
+	} else {
+		content += "，请尽快更换证书"
+	}
+	notifySSLRevoked(cert.PrimaryDomain, content)
+	return updates
+}
+
+func applyCertificateStatusUpdates(cert *model.Certificate, updates map[string]interface{}) {
+	timeField := func(key string, target **time.Time) {
+		value, ok := updates[key]
+		if !ok {
+			return
+		}
+		if t, ok := value.(time.Time); ok {
+			*target = &t
+		} else {
+			*target = nil
+		}
+	}
+	timeField("renewal_window_start", &cert.RenewalWindowStart)
+	timeField("renewal_window_end", &cert.RenewalWindowEnd)
+	timeField("renewal_scheduled_at", &cert.RenewalScheduledAt)
+	timeField("renewal_info_check_at", &cert.RenewalInfoCheckAt)
+	timeField("revoked_at", &cert.RevokedAt)
+	timeField("revocation_checked_at", &cert.RevocationCheckedAt)
+	if value, ok := updates["renewal_explanation_url"].(string); ok {
+		cert.RenewalExplanationURL = value
+	}
+	if value, ok := updates["revocation_status"].(string); ok {
+		cert.RevocationStatus = value
+	}
+	if value, ok := updates["revocation_reason"].(int); ok {
+		cert.RevocationReason = value
+	}
+}
+
+// addCertificateMonitorReset 证书内容更换后，旧证书的 ARI 窗口与吊销状态不再适用
+func addCertificateMonitorReset(updates map[string]interface{}) {
+	updates["renewal_window_start"] = nil
+	updates["renewal_window_end"] = nil
+	updates["renewal_scheduled_at"] = nil
+	updates["renewal_info_check_at"] = nil
+	updates["renewal_explanation_url"] = ""
+	updates["revocation_status"] = ""
+	updates["revocation_reason"] = 0
+	updates["revoked_at"] = nil
+	updates["revocation_checked_at"] = nil
+}
+
+func clearCertificateMonitorState(cert *model.Certificate) {
+	cert.RenewalWindowStart = nil
+	cert.RenewalWindowEnd = nil
+	cert.RenewalScheduledAt = nil
+	cert.RenewalInfoCheckAt = nil
+	cert.RenewalExplanationURL = ""
+	cert.RevocationStatus = ""
+	cert.RevocationReason = 0
+	cert.RevokedAt = nil
+	cert.RevocationCheckedAt = nil
+}
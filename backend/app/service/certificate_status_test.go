@@ -0,0 +1,280 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	sslutil "xpanel/utils/ssl"
+
+	"github.com/sirupsen/logrus"
+)
+
+func setupCertificateStatusTest(t *testing.T) *CertificateService {
+	t.Helper()
+	svc, _ := setupCertificateDeleteTest(t)
+	if err := global.DB.AutoMigrate(&model.AcmeAccount{}, &model.Notification{}); err != nil {
+		t.Fatal(err)
+	}
+	svc.acmeRepo = repo.NewIAcmeAccountRepo()
+	previousLog := global.LOG
+	global.LOG = logrus.New()
+	t.Cleanup(func() { global.LOG = previousLog })
+	return svc
+}
+
+func TestShouldAutoRenewCertificateFollowsRenewalInfoAndRevocation(t *testing.T) {
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(48*time.Hour)
+	base := model.Certificate{
+		Type:       "autoApply",
+		SourceType: "acme",
+		AutoRenew:  true,
+		Pem:        "pem",
+		PrivateKey: "key",
+		ExpireDate: now.Add(60 * 24 * time.Hour),
+	}
+
+	scheduled := base
+	scheduled.RenewalScheduledAt = &past
+	if !shouldAutoRenewCertificate(scheduled, now, certificateRenewBefore) {
+		t.Fatal("ARI scheduled time has passed, certificate should renew before the fixed window")
+	}
+	deferred := base
+	deferred.ExpireDate = now.Add(24 * time.Hour)
+	deferred.RenewalScheduledAt = &future
+	if shouldAutoRenewCertificate(deferred, now, certificateRenewBefore) {
+		t.Fatal("the CA's suggested window takes precedence over the fixed window")
+	}
+	revoked := base
+	revoked.RevocationStatus = sslutil.RevocationRevoked
+	if !shouldAutoRenewCertificate(revoked, now, certificateRenewBefore) {
+		t.Fatal("revoked certificate should renew immediately")
+	}
+	revoked.AutoRenew = false
+	if shouldAutoRenewCertificate(revoked, now, certificateRenewBefore) {
+		t.Fatal("revoked certificate without auto renew must be left alone")
+	}
+}
+
+func TestStatusJobRetriesFailedReissueOnlyWhenNewlyRevoked(t *testing.T) {
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	cert := model.Certificate{
+		Type: "autoApply", SourceType: "acme", AutoRenew: true, Pem: "pem", PrivateKey: "key",
+		ExpireDate: now.Add(60 * 24 * time.Hour), RevocationStatus: sslutil.RevocationRevoked,
+	}
+	if !statusJobShouldRenew(cert, false, now) || !statusJobShouldRenew(cert, true, now) {
+		t.Fatal("revoked certificate should be reissued by the status job")
+	}
+	cert.Status = "error"
+	if statusJobShouldRenew(cert, true, now) {
+		t.Fatal("failed reissue of a known revoked certificate should wait for the daily job")
+	}
+	if !statusJobShouldRenew(cert, false, now) {
+		t.Fatal("certificate revoked after a failed renewal should be reissued once immediately")
+	}
+}
+
+func TestRenewalInfoScheduleUpdates(t *testing.T) {
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	start, end := now.Add(24*time.Hour), now.Add(48*time.Hour)
+	info := &sslutil.RenewalInfo{WindowStart: start, WindowEnd: end, RetryAfter: 10 * time.Minute}
+	half := func(n int64) int64 { return n / 2 }
+
+	updates := renewalInfoScheduleUpdates(model.Certificate{}, info, now, half)
+	if got := updates["renewal_scheduled_at"]; got != start.Add(12*time.Hour) {
+		t.Fatalf("scheduled at %v, want a point inside the window", got)
+	}
+	if got := updates["renewal_info_check_at"]; got != now.Add(renewalInfoMinRetry) {
+		t.Fatalf("Retry-After below the minimum should be clamped, got %v", got)
+	}
+
+	var cert model.Certificate
+	applyCertificateStatusUpdates(&cert, updates)
+	updates = renewalInfoScheduleUpdates(cert, info, now, func(int64) int64 { return 0 })
+	if _, ok := updates["renewal_scheduled_at"]; ok {
+		t.Fatal("unchanged window must keep the previously chosen time")
+	}
+
+	// CA 把窗口提前到过去（如批量吊销事件）时立即续签
+	moved := &sslutil.RenewalInfo{WindowStart: now.Add(-2 * time.Hour), WindowEnd: now.Add(-time.Hour)}
+	updates = renewalInfoScheduleUpdates(cert, moved, now, half)
+	if got := updates["renewal_scheduled_at"]; got != now {
+		t.Fatalf("window in the past should renew now, got %v", got)
+	}
+	if got := updates["renewal_info_check_at"]; got != now.Add(renewalInfoDefaultRetry) {
+		t.Fatalf("missing Retry-After should use the default interval, got %v", got)
+	}
+}
+
+func TestRefreshCertificateStatusStoresRenewalInfoAndRevocation(t *testing.T) {
+	svc := setupCertificateStatusTest(t)
+	now := time.Now()
+	acme := model.AcmeAccount{Email: "ops@example.com", Type: "letsencrypt", URL: "https://acme.test/acct/1"}
+	if err := global.DB.Create(&acme).Error; err != nil {
+		t.Fatal(err)
+	}
+	cert := model.Certificate{
+		PrimaryDomain: "www.example.com", Provider: "dns", Type: "autoApply", SourceType: "acme",
+		AcmeAccountID: acme.ID, AutoRenew: true, Pem: "pem", Status: "applied",
+		ExpireDate: now.Add(60 * 24 * time.Hour),
+	}
+	if err := global.DB.Create(&cert).Error; err != nil {
+		t.Fatal(err)
+	}
+	cert.PrivateKey = "key" // 私钥加密存储，测试只在内存中补上
+
+	previousARI, previousCheck := getCertificateRenewalInfo, checkCertificateRevocation
+	t.Cleanup(func() { getCertificateRenewalInfo, checkCertificateRevocation = previousARI, previousCheck })
+	ariCalls, revocationCalls := 0, 0
+	getCertificateRenewalInfo = func(account model.AcmeAccount, certPEM string) (*sslutil.RenewalInfo, error) {
+		ariCalls++
+		if account.ID != acme.ID || certPEM != "pem" {
+			t.Fatalf("unexpected ARI request for %d %q", account.ID, certPEM)
+		}
+		return &sslutil.RenewalInfo{WindowStart: now.Add(-time.Hour), WindowEnd: now.Add(-time.Minute), RetryAfter: 3 * time.Hour}, nil
+	}
+	revokedAt := now.Add(-30 * time.Minute).Truncate(time.Second)
+	checkCertificateRevocation = func(string) (*sslutil.RevocationStatus, error) {
+		revocationCalls++
+		return &sslutil.RevocationStatus{Status: sslutil.RevocationRevoked, Source: "crl", RevokedAt: revokedAt, Reason: 1}, nil
+	}
+
+	if !svc.refreshCertificateStatus(&cert, now) {
+		t.Fatal("certificate should be checked")
+	}
+	stored, _ := svc.certRepo.Get(repo.WithByID(cert.ID))
+	if stored.RenewalScheduledAt == nil || stored.RenewalInfoCheckAt == nil || !stored.RenewalInfoCheckAt.Equal(now.Add(3*time.Hour)) {
+		t.Fatalf("renewal info not stored: %+v", stored)
+	}
+	if stored.RevocationStatus != sslutil.RevocationRevoked || stored.RevokedAt == nil || !stored.RevokedAt.Equal(revokedAt) || stored.RevocationReason != 1 {
+		t.Fatalf("revocation not stored: %+v", stored)
+	}
+	if cert.RevocationStatus != stored.RevocationStatus || cert.RenewalScheduledAt == nil {
+		t.Fatal("in-memory certificate should reflect stored status")
+	}
+	if !shouldAutoRenewCertificate(cert, now, certificateRenewBefore) {
+		t.Fatal("revoked certificate should be renewed by the status job")
+	}
+
+	// 未到 Retry-After 与检查间隔时不再请求；已确认吊销的证书不再轮询
+	svc.refreshCertificateStatus(&cert, now.Add(time.Hour))
+	if ariCalls != 1 || revocationCalls != 1 {
+		t.Fatalf("ARI calls %d, revocation calls %d", ariCalls, revocationCalls)
+	}
+
+	release, _ := acquireCertificateRenewal(cert.ID)
+	defer release()
+	if svc.refreshCertificateStatus(&cert, now.Add(24*time.Hour)) {
+		t.Fatal("certificate being renewed must be skipped")
+	}
+}
+
+func TestRevocationUpdatesKeepsStatusWhenCheckFails(t *testing.T) {
+	previous := checkCertificateRevocation
+	t.Cleanup(func() { checkCertificateRevocation = previous })
+	previousLog := global.LOG
+	global.LOG = logrus.New()
+	t.Cleanup(func() { global.LOG = previousLog })
+
+	now := time.Now()
+	cert := model.Certificate{Pem: "pem", RevocationStatus: sslutil.RevocationGood, ExpireDate: now.Add(time.Hour)}
+	checkCertificateRevocation = func(string) (*sslutil.RevocationStatus, error) { return nil, errors.New("timeout") }
+	updates := revocationUpdates(cert, now)
+	if _, ok := updates["revocation_status"]; ok || updates["revocation_checked_at"] != now {
+		t.Fatalf("failed check should only record the attempt: %v", updates)
+	}
+	checkCertificateRevocation = func(string) (*sslutil.RevocationStatus, error) { return nil, sslutil.ErrNoRevocationEndpoint }
+	if updates := revocationUpdates(cert, now); updates["revocation_status"] != sslutil.RevocationUnknown {
+		t.Fatalf("certificate without endpoints should be unknown: %v", updates)
+	}
+}
+
+func TestRevokeCertificate(t *testing.T) {
+	svc := setupCertificateStatusTest(t)
+	acme := model.AcmeAccount{Email: "ops@example.com", Type: "letsencrypt", URL: "https://acme.test/acct/1"}
+	if err := global.DB.Create(&acme).Error; err != nil {
+		t.Fatal(err)
+	}
+	cert := model.Certificate{
+		PrimaryDomain: "www.example.com", Provider: "dns", Type: "autoApply", SourceType: "acme",
+		AcmeAccountID: acme.ID, AutoRenew: true, Pem: "pem", Status: "applied",
+		ExpireDate: time.Now().Add(60 * 24 * time.Hour),
+	}
+	uploaded := model.Certificate{PrimaryDomain: "upload.example.com", Provider: "manual", Type: "upload", Pem: "pem", Status: "applied"}
+	if err := global.DB.Create(&cert).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := global.DB.Create(&uploaded).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	previousRevoke, previousRefresh := revokeCertificateAtCA, refreshRevokedCertificateConsumers
+	t.Cleanup(func() { revokeCertificateAtCA, refreshRevokedCertificateConsumers = previousRevoke, previousRefresh })
+	var revokedReason uint
+	revokeCertificateAtCA = func(account model.AcmeAccount, certPEM string, reason uint) error {
+		revokedReason = reason
+		return nil
+	}
+	var refreshed []uint
+	refreshRevokedCertificateConsumers = func(ids []uint) error {
+		refreshed = append(refreshed, ids...)
+		return nil
+	}
+
+	err := svc.Revoke(dto.CertificateRevoke{ID: uploaded.ID})
+	var bizErr buserr.BusinessError
+	if !errors.As(err, &bizErr) || bizErr.Msg != constant.ErrSSLRevokeUnsupported {
+		t.Fatalf("uploaded certificate cannot be revoked through ACME, got %v", err)
+	}
+
+	if err := svc.Revoke(dto.CertificateRevoke{ID: cert.ID, Reason: 5}); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := svc.certRepo.Get(repo.WithByID(cert.ID))
+	if revokedReason != 5 || stored.RevocationStatus != sslutil.RevocationRevoked || stored.RevocationReason != 5 || stored.RevokedAt == nil {
+		t.Fatalf("revocation not recorded: reason %d, %+v", revokedReason, stored)
+	}
+	if stored.AutoRenew {
+		t.Fatal("revoking without reissue should stop automatic renewal")
+	}
+	if !reflect.DeepEqual(refreshed, []uint{cert.ID}) {
+		t.Fatalf("consumers refreshed for %v", refreshed)
+	}
+
+	revokeCertificateAtCA = func(model.AcmeAccount, string, uint) error { return errors.New("unauthorized") }
+	if err := svc.Revoke(dto.CertificateRevoke{ID: cert.ID}); err == nil {
+		t.Fatal("CA failure must be reported")
+	}
+}
+
+func TestNextLocalAutoRenewAtUsesRenewalInfoSchedule(t *testing.T) {
+	location := time.UTC
+	now := time.Date(2026, 10, 18, 10, 30, 0, 0, location)
+	scheduled := time.Date(2026, 10, 20, 13, 40, 0, 0, location)
+	cert := model.Certificate{
+		Type: "autoApply", SourceType: "acme", AutoRenew: true, Pem: "pem", PrivateKey: "key",
+		ExpireDate: now.Add(60 * 24 * time.Hour), RenewalScheduledAt: &scheduled,
+	}
+	next := nextLocalAutoRenewAt(cert, now, location)
+	if want := time.Date(2026, 10, 20, 14, 15, 0, 0, location); next == nil || !next.Equal(want) {
+		t.Fatalf("next = %v, want the status check after the ARI time %v", next, want)
+	}
+
+	cert.RevocationStatus = sslutil.RevocationRevoked
+	next = nextLocalAutoRenewAt(cert, now, location)
+	if want := time.Date(2026, 10, 18, 11, 15, 0, 0, location); next == nil || !next.Equal(want) {
+		t.Fatalf("revoked certificate next = %v, want %v", next, want)
+	}
+	item := buildCertificateRenewalPlanItem(cert, nil, now, location)
+	if item.Status != renewalPlanRevoked {
+		t.Fatalf("plan status = %s", item.Status)
+	}
+}
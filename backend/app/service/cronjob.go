@@ -20,8 +20,8 @@ import (
 )
 
 type ICronjobService interface {
-	Create(req dto.CronjobCreate) error
-	Update(req dto.CronjobUpdate) error
+	Create(req dto.CronjobCreate, admin bool) error
+	Update(req dto.CronjobUpdate, admin bool) error
 	Delete(id uint) error
 	Get(id uint) (*dto.CronjobInfo, error)
 	SearchWithPage(req dto.CronjobSearch) (int64, []dto.CronjobInfo, error)
@@ -45,7 +45,7 @@ type CronjobService struct {
 	operateCompose func(dto.ComposeOperate) error
 }
 
-func (s *CronjobService) Create(req dto.CronjobCreate) error {
+func (s *CronjobService) Create(req dto.CronjobCreate, admin bool) error {
 	job := &model.Cronjob{
 		Name:                   req.Name,
 		Type:                   req.Type,
@@ -60,6 +60,13 @@ func (s *CronjobService) Create(req dto.CronjobCreate) error {
 		SourceDir:              req.SourceDir,
 		TargetAccountID:        req.TargetAccountID,
 		RetainCopies:           req.RetainCopies,
+		RetentionPolicy:        req.RetentionPolicy,
+		KeepDaily:              req.KeepDaily,
+		KeepWeekly:             req.KeepWeekly,
+		KeepMonthly:            req.KeepMonthly,
+		KeepYearly:             req.KeepYearly,
+		BackupMode:             req.BackupMode,
+		ChainLength:            req.ChainLength,
 		ExclusionRules:         req.ExclusionRules,
 		CompressFormat:         req.CompressFormat,
 		EncryptPassword:        req.EncryptPassword,
@@ -68,6 +75,11 @@ func (s *CronjobService) Create(req dto.CronjobCreate) error {
 		PostCommand:            req.PostCommand,
 		ComposeName:            req.ComposeName,
 		ComposeOperation:       req.ComposeOperation,
+		VerifyCronjobID:        req.VerifyCronjobID,
+		VerifyCount:            req.VerifyCount,
+	}
+	if !admin && cronjobRequiresAdmin(*job) {
+		return buserr.New(constant.ErrPermissionDeny)
 	}
 	if err := s.validateJobConfig(job); err != nil {
 		return err
@@ -81,13 +93,16 @@ func (s *CronjobService) Create(req dto.CronjobCreate) error {
 	return nil
 }
 
-func (s *CronjobService) Update(req dto.CronjobUpdate) error {
+func (s *CronjobService) Update(req dto.CronjobUpdate, admin bool) error {
 	job, err := s.cronjobRepo.Get(req.ID)
 	if err != nil {
 		return buserr.New(constant.ErrRecordNotFound)
 	}
-	s.removeCronJob(job)
 	fields, updatedJob := buildCronjobUpdate(job, req)
+	if !admin && (cronjobRequiresAdmin(*job) || cronjobRequiresAdmin(updatedJob)) {
+		return buserr.New(constant.ErrPermissionDeny)
+	}
+	s.removeCronJob(job)
 	if err := s.validateJobConfig(&updatedJob); err != nil {
 		return err
 	}
@@ -117,6 +132,13 @@ func buildCronjobUpdate(job *model.Cronjob, req dto.CronjobUpdate) (map[string]i
 		"source_dir":                req.SourceDir,
 		"target_account_id":         req.TargetAccountID,
 		"retain_copies":             req.RetainCopies,
+		"retention_policy":          req.RetentionPolicy,
+		"keep_daily":                req.KeepDaily,
+		"keep_weekly":               req.KeepWeekly,
+		"keep_monthly":              req.KeepMonthly,
+		"keep_yearly":               req.KeepYearly,
+		"backup_mode":               req.BackupMode,
+		"chain_length":              req.ChainLength,
 		"exclusion_rules":           req.ExclusionRules,
 		"compress_format":           req.CompressFormat,
 		"delete_local_after_upload": req.DeleteLocalAfterUpload,
@@ -124,6 +146,8 @@ func buildCronjobUpdate(job *model.Cronjob, req dto.CronjobUpdate) (map[string]i
 		"post_command":              req.PostCommand,
 		"compose_name":              req.ComposeName,
 		"compose_operation":         req.ComposeOperation,
+		"verify_cronjob_id":         req.VerifyCronjobID,
+		"verify_count":              req.VerifyCount,
 	}
 	updatedJob := *job
 	updatedJob.Name = req.Name
@@ -138,6 +162,13 @@ func buildCronjobUpdate(job *model.Cronjob, req dto.CronjobUpdate) (map[string]i
 	updatedJob.SourceDir = req.SourceDir
 	updatedJob.TargetAccountID = req.TargetAccountID
 	updatedJob.RetainCopies = req.RetainCopies
+	updatedJob.RetentionPolicy = req.RetentionPolicy
+	updatedJob.KeepDaily = req.KeepDaily
+	updatedJob.KeepWeekly = req.KeepWeekly
+	updatedJob.KeepMonthly = req.KeepMonthly
+	updatedJob.KeepYearly = req.KeepYearly
+	updatedJob.BackupMode = req.BackupMode
+	updatedJob.ChainLength = req.ChainLength
 	updatedJob.ExclusionRules = req.ExclusionRules
 	updatedJob.CompressFormat = req.CompressFormat
 	updatedJob.DeleteLocalAfterUpload = req.DeleteLocalAfterUpload
@@ -145,6 +176,8 @@ func buildCronjobUpdate(job *model.Cronjob, req dto.CronjobUpdate) (map[string]i
 	updatedJob.PostCommand = req.PostCommand
 	updatedJob.ComposeName = req.ComposeName
 	updatedJob.ComposeOperation = req.ComposeOperation
+	updatedJob.VerifyCronjobID = req.VerifyCronjobID
+	updatedJob.VerifyCount = req.VerifyCount
 	if req.EncryptPassword != "" {
 		fields["encrypt_password"] = req.EncryptPassword
 		updatedJob.EncryptPassword = req.EncryptPassword
@@ -261,6 +294,11 @@ func (s *CronjobService) addCronJob(job *model.Cronjob) error {
 	return s.cronjobRepo.Update(job.ID, map[string]interface{}{"entry_id": int(entryID)})
 }
 
+// cronjobRequiresAdmin Shell 任务与备份前后置命令以 root 执行任意命令，仅管理员可配置
+func cronjobRequiresAdmin(job model.Cronjob) bool {
+	return job.Type == "shell" || strings.TrimSpace(job.PreCommand) != "" || strings.TrimSpace(job.PostCommand) != ""
+}
+
 func (s *CronjobService) validateJobConfig(job *model.Cronjob) error {
 	if strings.TrimSpace(job.Spec) == "" {
 		return fmt.Errorf("cron spec is empty")
@@ -299,9 +337,28 @@ func (s *CronjobService) validateJobConfig(job *model.Cronjob) error {
 		if job.ComposeOperation != "pull" && job.ComposeOperation != "update" {
 			return fmt.Errorf("compose operation must be pull or update")
 		}
+	case "verify":
 	default:
 		return fmt.Errorf("unsupported job type: %s", job.Type)
 	}
+	switch job.BackupMode {
+	case "", BackupModeFull:
+	case BackupModeIncremental:
+		if job.Type != "website" && job.Type != "directory" {
+			return fmt.Errorf("incremental mode only supports website and directory backups")
+		}
+	default:
+		return fmt.Errorf("unsupported backup mode: %s", job.BackupMode)
+	}
+	switch job.RetentionPolicy {
+	case "", RetentionPolicyCount:
+	case RetentionPolicyGFS:
+		if job.KeepDaily+job.KeepWeekly+job.KeepMonthly+job.KeepYearly == 0 {
+			return fmt.Errorf("gfs retention requires at least one keep rule")
+		}
+	default:
+		return fmt.Errorf("unsupported retention policy: %s", job.RetentionPolicy)
+	}
 	return nil
 }
 
@@ -330,6 +387,8 @@ func (s *CronjobService) executeJob(job *model.Cronjob) {
 		msg, status, file = s.execDirectoryBackup(job)
 	case "compose":
 		msg, status = s.execCompose(job)
+	case "verify":
+		msg, status = s.execBackupVerify(job)
 	default:
 		msg = fmt.Sprintf("unsupported job type: %s", job.Type)
 		status = constant.StatusFailed
@@ -350,7 +409,9 @@ func (s *CronjobService) executeJob(job *model.Cronjob) {
 	s.notifyJobResult(job, status, msg)
 	if job.RetainCopies > 0 {
 		_ = s.cronjobRepo.CleanRecords(job.ID, int(job.RetainCopies))
-		_ = NewIBackupService().CleanSuccessfulRecords(job.ID, job.RetainCopies)
+	}
+	if err := NewIBackupService().ApplyRetention(job); err != nil {
+		global.LOG.Warnf("apply backup retention for cronjob %d failed: %v", job.ID, err)
 	}
 }
 
@@ -489,13 +550,18 @@ func (s *CronjobService) execWebsiteBackup(job *model.Cronjob) (string, string,
 		return "website name is empty", constant.StatusFailed, ""
 	}
 	backupService := NewIBackupService()
+	opts, err := s.prepareBackupJobOptions(job, backupService, "website", job.Website)
+	if err != nil {
+		return fmt.Sprintf("prepare incremental backup failed: %v", err), constant.StatusFailed, ""
+	}
+	defer opts.Chain.Release()
 	if job.TargetAccountID > 0 {
-		output, err := backupService.PerformBackupWithOptions("website", job.Website, "", "", job.TargetAccountID, s.backupJobOptions(job))
+		output, err := backupService.PerformBackupWithOptions("website", job.Website, "", "", job.TargetAccountID, opts)
 		return recordAccountBackup(backupService, "website", job.Website, job, output, err)
 	}
-	msg, status := s.localBackupTar(job, "website", job.Website, "")
+	msg, status := s.localBackupTar(job, "website", job.Website, "", opts.Chain)
 	file := extractBackupFile(msg)
-	_ = backupService.CreateRecordForFile("website", job.Website, 0, job.ID, file, 0, status, msg)
+	_ = backupService.CreateRecordFromOutput("website", job.Website, 0, job.ID, &BackupOutput{Path: file, Chain: opts.Chain}, status, msg)
 	return msg, status, file
 }
 
@@ -505,10 +571,15 @@ func (s *CronjobService) execDirectoryBackup(job *model.Cronjob) (string, string
 	}
 	backupService := NewIBackupService()
 	name := filepath.Base(job.SourceDir)
+	opts, err := s.prepareBackupJobOptions(job, backupService, "directory", name)
+	if err != nil {
+		return fmt.Sprintf("prepare incremental backup failed: %v", err), constant.StatusFailed, ""
+	}
+	defer opts.Chain.Release()
 
 	var packMsg, packFile string
 	hooks := runDirectoryBackupHooks(job.PreCommand, job.PostCommand, func() error {
-		msg, status := s.localBackupTar(job, "directory", job.SourceDir, job.SourceDir)
+		msg, status := s.localBackupTar(job, "directory", job.SourceDir, job.SourceDir, opts.Chain)
 		packMsg = msg
 		packFile = extractBackupFile(msg)
 		if status != constant.StatusSuccess {
@@ -527,12 +598,12 @@ func (s *CronjobService) execDirectoryBackup(job *model.Cronjob) (string, string
 	}
 
 	if job.TargetAccountID == 0 {
-		_ = backupService.CreateRecordForFile("directory", name, 0, job.ID, packFile, 0, constant.StatusSuccess, log)
+		_ = backupService.CreateRecordFromOutput("directory", name, 0, job.ID, &BackupOutput{Path: packFile, Chain: opts.Chain}, constant.StatusSuccess, log)
 		return log, constant.StatusSuccess, packFile
 	}
 
 	targetPath := filepath.ToSlash(filepath.Join("directory", name, filepath.Base(packFile)))
-	output, err := backupService.UploadExistingFile(job.TargetAccountID, packFile, targetPath, s.backupJobOptions(job))
+	output, err := backupService.UploadExistingFile(job.TargetAccountID, packFile, targetPath, opts)
 	if output != nil && output.Log != "" {
 		log = strings.TrimSpace(log + "\n" + output.Log)
 	}
@@ -544,6 +615,33 @@ func (s *CronjobService) execDirectoryBackup(job *model.Cronjob) (string, string
 	return log, constant.StatusSuccess, output.Path
 }
 
+// execBackupVerify 依次试恢复最近的备份记录，任一失败即任务失败
+func (s *CronjobService) execBackupVerify(job *model.Cronjob) (string, string) {
+	count := int(job.VerifyCount)
+	if count <= 0 {
+		count = 1
+	}
+	backupService := NewIBackupService()
+	records, err := backupService.RecentVerifiableRecords(job.VerifyCronjobID, count)
+	if err != nil {
+		return fmt.Sprintf("load backup records failed: %v", err), constant.StatusFailed
+	}
+	if len(records) == 0 {
+		return "no backup records to verify", constant.StatusSuccess
+	}
+	status := constant.StatusSuccess
+	var lines []string
+	for _, record := range records {
+		if err := backupService.VerifyRecord(record.ID); err != nil {
+			status = constant.StatusFailed
+			lines = append(lines, fmt.Sprintf("#%d %s: failed: %v", record.ID, record.FileName, err))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("#%d %s: verified", record.ID, record.FileName))
+	}
+	return strings.Join(lines, "\n"), status
+}
+
 func (s *CronjobService) backupJobOptions(job *model.Cronjob) BackupJobOptions {
 	return BackupJobOptions{
 		CompressFormat:  job.CompressFormat,
@@ -554,6 +652,20 @@ func (s *CronjobService) backupJobOptions(job *model.Cronjob) BackupJobOptions {
 	}
 }
 
+// prepareBackupJobOptions 增量模式下附带备份链位置，Chain 为 nil 表示全量备份
+func (s *CronjobService) prepareBackupJobOptions(job *model.Cronjob, backupService IBackupService, backupType, name string) (BackupJobOptions, error) {
+	opts := s.backupJobOptions(job)
+	if !isIncrementalJob(job) {
+		return opts, nil
+	}
+	chain, err := backupService.PrepareIncremental(job, backupType, name)
+	if err != nil {
+		return opts, err
+	}
+	opts.Chain = chain
+	return opts, nil
+}
+
 func recordAccountBackup(backupService IBackupService, backupType, name string, job *model.Cronjob, output *BackupOutput, err error) (string, string, string) {
 	if err != nil {
 		_ = backupService.CreateRecordFromOutput(backupType, name, job.TargetAccountID, job.ID, output, constant.StatusFailed, backupFailureMessage(output, err))
@@ -563,7 +675,7 @@ func recordAccountBackup(backupService IBackupService, backupType, name string,
 	return output.Log, constant.StatusSuccess, output.Path
 }
 
-func (s *CronjobService) localBackupTar(job *model.Cronjob, backupType, name, sourceDir string) (string, string) {
+func (s *CronjobService) localBackupTar(job *model.Cronjob, backupType, name, sourceDir string, chain *BackupChain) (string, string) {
 	backupDir := fmt.Sprintf("%s/backup/%s", global.CONF.System.DataDir, backupType)
 	timestamp := time.Now().Format("20060102150405")
 
@@ -592,6 +704,7 @@ func (s *CronjobService) localBackupTar(job *model.Cronjob, backupType, name, so
 		CompressFormat:  job.CompressFormat,
 		EncryptPassword: job.EncryptPassword,
 		ExclusionRules:  job.ExclusionRules,
+		SnapshotFile:    chain.snapshotFile(),
 	})
 	if err != nil {
 		return fmt.Sprintf("backup failed: %v", err), constant.StatusFailed
@@ -632,6 +745,13 @@ func toCronjobInfo(j *model.Cronjob) *dto.CronjobInfo {
 		SourceDir:              j.SourceDir,
 		TargetAccountID:        j.TargetAccountID,
 		RetainCopies:           j.RetainCopies,
+		RetentionPolicy:        j.RetentionPolicy,
+		KeepDaily:              j.KeepDaily,
+		KeepWeekly:             j.KeepWeekly,
+		KeepMonthly:            j.KeepMonthly,
+		KeepYearly:             j.KeepYearly,
+		BackupMode:             j.BackupMode,
+		ChainLength:            j.ChainLength,
 		ExclusionRules:         j.ExclusionRules,
 		CompressFormat:         j.CompressFormat,
 		EncryptPasswordSet:     j.EncryptPassword != "",
@@ -640,6 +760,8 @@ func toCronjobInfo(j *model.Cronjob) *dto.CronjobInfo {
 		PostCommand:            j.PostCommand,
 		ComposeName:            j.ComposeName,
 		ComposeOperation:       j.ComposeOperation,
+		VerifyCronjobID:        j.VerifyCronjobID,
+		VerifyCount:            j.VerifyCount,
 	}
 }
 
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/buserr"
+	"xpanel/constant"
+)
+
+func TestShellCronjobRequiresAdmin(t *testing.T) {
+	svc := &CronjobService{}
+	err := svc.Create(dto.CronjobCreate{Name: "shell", Type: "shell", Spec: "0 2 * * *", Script: "id"}, false)
+	var bizErr buserr.BusinessError
+	if !errors.As(err, &bizErr) || bizErr.Msg != constant.ErrPermissionDeny {
+		t.Fatalf("non-admin shell cronjob should be denied, got %v", err)
+	}
+	if !cronjobRequiresAdmin(model.Cronjob{Type: "directory", PreCommand: "systemctl stop app"}) {
+		t.Fatal("backup pre-command should require admin")
+	}
+	if cronjobRequiresAdmin(model.Cronjob{Type: "directory", SourceDir: "/srv/data"}) {
+		t.Fatal("plain directory backup should not require admin")
+	}
+}
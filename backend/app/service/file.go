@@ -97,6 +97,48 @@ func isProtectedPath(path string) bool {
 	return protectedPaths[cleanPath]
 }
 
+// CheckPanelDataAccess 面板数据目录包含数据库、凭据密钥与证书私钥，非管理员不能读取、复制或打包其中的文件
+func CheckPanelDataAccess(admin bool, paths ...string) error {
+	if admin {
+		return nil
+	}
+	for _, path := range paths {
+		if isPanelDataPath(path) {
+			return buserr.New(constant.ErrPermissionDeny)
+		}
+	}
+	return nil
+}
+
+func isPanelDataPath(path string) bool {
+	target := resolveRealPath(path)
+	for _, root := range []string{global.CONF.System.DataDir, global.CONF.System.DbPath, global.CONF.System.CredentialKeyPath} {
+		if root != "" && isUnderDir(target, resolveRealPath(root)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveRealPath 解析符号链接，避免通过链接绕过目录限制；路径不存在时解析最近的已存在上级目录
+func resolveRealPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = filepath.Clean(path)
+	}
+	for dir, rest := abs, ""; ; {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(real, rest)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return abs
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+}
+
 // invalidChars 文件名中不允许的字符
 var invalidChars = []string{"\x00", "\n", "\r"}
 
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"xpanel/global"
+)
+
+func TestPanelDataAccessDeniedForNonAdmin(t *testing.T) {
+	dataDir := t.TempDir()
+	previous := global.CONF.System
+	global.CONF.System.DataDir = dataDir
+	global.CONF.System.DbPath = filepath.Join(dataDir, "db", "xpanel.db")
+	t.Cleanup(func() { global.CONF.System = previous })
+
+	link := filepath.Join(t.TempDir(), "data")
+	if err := os.Symlink(dataDir, link); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{global.CONF.System.DbPath, filepath.Join(link, "db", "xpanel.db"), dataDir} {
+		if err := CheckPanelDataAccess(false, path); err == nil {
+			t.Errorf("non-admin access to %s should be denied", path)
+		}
+		if err := CheckPanelDataAccess(true, path); err != nil {
+			t.Errorf("admin access to %s: %v", path, err)
+		}
+	}
+	if err := CheckPanelDataAccess(false, dataDir+"-other/file"); err != nil {
+		t.Errorf("sibling directory should be allowed: %v", err)
+	}
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/utils/logrotate"
+)
+
+const (
+	logRotateCategorySite  = "site"
+	logRotateCategoryNginx = "nginx"
+	logRotateCategoryPanel = "panel"
+	logRotateCategorySSL   = "ssl"
+)
+
+var logRotateMu sync.Mutex
+
+// systemLogrotateConfigs 系统 logrotate 配置，其中已声明的日志（如发行版 nginx 的 /var/log/nginx/*.log）不再由面板轮转
+var systemLogrotateConfigs = []string{"/etc/logrotate.conf", "/etc/logrotate.d"}
+
+// defaultLogRotatePolicy 未配置全局策略时使用：默认不启用，启用后每天或超过 256MB 时轮转，保留 14 份并压缩
+var defaultLogRotatePolicy = model.LogRotatePolicy{
+	Enable:   false,
+	Interval: logrotate.IntervalDaily,
+	MaxSize:  256,
+	Keep:     14,
+	Compress: true,
+}
+
+// reopenNginxLogs 向 nginx master 发送 USR1，使其重新打开日志文件；nginx 未运行时忽略
+var reopenNginxLogs = func() error {
+	nc := global.CONF.Nginx
+	if !nc.IsInstalled() {
+		return nil
+	}
+	data, err := os.ReadFile(nc.GetPidPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return nil
+	}
+	if err := syscall.Kill(pid, syscall.SIGUSR1); err != nil && err != syscall.ESRCH {
+		return err
+	}
+	return nil
+}
+
+// ILogRotateService 日志轮转服务接口
+type ILogRotateService interface {
+	GetPolicy(req dto.LogRotatePolicyReq) (*dto.LogRotatePolicy, error)
+	SavePolicy(req dto.LogRotatePolicy) error
+	ListFiles() ([]dto.LogRotateFile, error)
+	Run() (*dto.LogRotateResult, error)
+}
+
+// NewILogRotateService 创建日志轮转服务实例
+func NewILogRotateService() ILogRotateService {
+	return &LogRotateService{
+		policyRepo:  repo.NewILogRotateRepo(),
+		websiteRepo: repo.NewIWebsiteRepo(),
+	}
+}
+
+type LogRotateService struct {
+	policyRepo  repo.ILogRotateRepo
+	websiteRepo repo.IWebsiteRepo
+}
+
+// logRotateTarget 一个需要轮转的日志文件
+type logRotateTarget struct {
+	category  string
+	websiteID uint
+	path      string
+	policy    logrotate.Policy
+}
+
+// RotateLogs 定时任务：按策略轮转网站、nginx、面板与证书日志
+func RotateLogs() {
+	result, err := NewILogRotateService().Run()
+	if err != nil {
+		global.LOG.Errorf("Rotate logs failed: %v", err)
+		return
+	}
+	for _, failed := range result.Failed {
+		global.LOG.Warnf("Rotate log failed: %s", failed)
+	}
+}
+
+// GetPolicy 获取全局或网站的轮转策略；网站没有单独策略时返回全局策略并标记 UseGlobal
+func (s *LogRotateService) GetPolicy(req dto.LogRotatePolicyReq) (*dto.LogRotatePolicy, error) {
+	policy, err := s.globalPolicy()
+	if err != nil {
+		return nil, err
+	}
+	useGlobal := false
+	if req.WebsiteID != 0 {
+		if _, err := s.websiteRepo.Get(repo.WithByID(req.WebsiteID)); err != nil {
+			return nil, err
+		}
+		item, err := s.policyRepo.GetByWebsite(req.WebsiteID)
+		if err != nil {
+			return nil, err
+		}
+		if item.ID == 0 {
+			useGlobal = true
+		} else {
+			policy = item
+		}
+	}
+	return &dto.LogRotatePolicy{
+		WebsiteID: req.WebsiteID,
+		UseGlobal: useGlobal,
+		Enable:    policy.Enable,
+		Interval:  policy.Interval,
+		MaxSize:   policy.MaxSize,
+		Keep:      policy.Keep,
+		MaxAge:    policy.MaxAge,
+		Compress:  policy.Compress,
+	}, nil
+}
+
+// SavePolicy 保存全局或网站的轮转策略
+func (s *LogRotateService) SavePolicy(req dto.LogRotatePolicy) error {
+	if req.WebsiteID != 0 {
+		if _, err := s.websiteRepo.Get(repo.WithByID(req.WebsiteID)); err != nil {
+			return err
+		}
+		if req.UseGlobal {
+			return s.policyRepo.DeleteByWebsite(req.WebsiteID)
+		}
+	}
+	if req.Interval == logrotate.IntervalNone && req.MaxSize == 0 {
+		return buserr.New(constant.ErrInvalidParams)
+	}
+	item, err := s.policyRepo.GetByWebsite(req.WebsiteID)
+	if err != nil {
+		return err
+	}
+	item.Enable = req.Enable
+	item.Interval = req.Interval
+	item.MaxSize = req.MaxSize
+	item.Keep = req.Keep
+	item.MaxAge = req.MaxAge
+	item.Compress = req.Compress
+	return s.policyRepo.Save(&item)
+}
+
+// ListFiles 列出纳入轮转的日志文件及其历史文件占用
+func (s *LogRotateService) ListFiles() ([]dto.LogRotateFile, error) {
+	targets, err := s.loadTargets(true)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]dto.LogRotateFile, 0, len(targets))
+	for _, target := range targets {
+		file := dto.LogRotateFile{Category: target.category, WebsiteID: target.websiteID, Path: target.path}
+		if info, err := os.Stat(target.path); err == nil {
+			file.Size = info.Size()
+		}
+		for _, item := range logrotate.List(target.path) {
+			file.History++
+			file.HistorySize += item.Size
+		}
+		if file.Size == 0 && file.History == 0 {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// Run 立即执行一次轮转：先改名所有到期的日志，统一通知 nginx 重新打开后再压缩和清理历史文件
+func (s *LogRotateService) Run() (*dto.LogRotateResult, error) {
+	logRotateMu.Lock()
+	defer logRotateMu.Unlock()
+
+	targets, err := s.loadTargets(false)
+	if err != nil {
+		return nil, err
+	}
+	result := &dto.LogRotateResult{Rotated: []string{}, Failed: []string{}}
+	now := time.Now()
+	reopen := false
+	for _, target := range targets {
+		due, err := logrotate.Due(target.path, target.policy, now)
+		if err != nil {
+			result.Failed = append(result.Failed, target.path+": "+err.Error())
+			continue
+		}
+		if !due {
+			continue
+		}
+		if _, err := logrotate.Rotate(target.path, target.policy, now); err != nil {
+			result.Failed = append(result.Failed, target.path+": "+err.Error())
+			continue
+		}
+		result.Rotated = append(result.Rotated, target.path)
+		if !target.policy.CopyTruncate {
+			reopen = true
+		}
+	}
+	if reopen {
+		if err := reopenNginxLogs(); err != nil {
+			result.Failed = append(result.Failed, "nginx reopen: "+err.Error())
+		}
+	}
+	for _, target := range targets {
+		compressed, removed, err := logrotate.Cleanup(target.path, target.policy, now)
+		result.Compressed += compressed
+		result.Removed += removed
+		if err != nil {
+			result.Failed = append(result.Failed, target.path+": "+err.Error())
+		}
+	}
+	return result, nil
+}
+
+func (s *LogRotateService) globalPolicy() (model.LogRotatePolicy, error) {
+	item, err := s.policyRepo.GetByWebsite(0)
+	if err != nil || item.ID != 0 {
+		return item, err
+	}
+	return defaultLogRotatePolicy, nil
+}
+
+// loadTargets 收集各类日志及其生效的策略；includeDisabled 为 false 时跳过未启用轮转的文件
+func (s *LogRotateService) loadTargets(includeDisabled bool) ([]logRotateTarget, error) {
+	globalPolicy, err := s.globalPolicy()
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.policyRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	sitePolicies := make(map[uint]model.LogRotatePolicy, len(overrides))
+	for _, item := range overrides {
+		if item.WebsiteID != 0 {
+			sitePolicies[item.WebsiteID] = item
+		}
+	}
+
+	var targets []logRotateTarget
+	seen := make(map[string]bool)
+	systemPatterns := logrotate.SystemPatterns(systemLogrotateConfigs...)
+	add := func(category string, websiteID uint, path string, policy model.LogRotatePolicy, copyTruncate bool) {
+		if path == "" || seen[path] || (!policy.Enable && !includeDisabled) || logrotate.Covered(systemPatterns, path) {
+			return
+		}
+		seen[path] = true
+		targets = append(targets, logRotateTarget{
+			category:  category,
+			websiteID: websiteID,
+			path:      path,
+			policy: logrotate.Policy{
+				Interval:     policy.Interval,
+				MaxSize:      int64(policy.MaxSize) << 20,
+				Keep:         policy.Keep,
+				MaxAge:       policy.MaxAge,
+				Compress:     policy.Compress,
+				CopyTruncate: copyTruncate,
+			},
+		})
+	}
+
+	nc := global.CONF.Nginx
+	if nc.IsInstalled() {
+		sites, err := s.websiteRepo.GetList()
+		if err != nil {
+			return nil, err
+		}
+		websiteService := &WebsiteService{}
+		for _, site := range sites {
+			policy, ok := sitePolicies[site.ID]
+			if !ok {
+				policy = globalPolicy
+			}
+			add(logRotateCategorySite, site.ID, websiteService.getWebsiteLogPath(site, "access"), policy, false)
+			add(logRotateCategorySite, site.ID, websiteService.getWebsiteLogPath(site, "error"), policy, false)
+			add(logRotateCategorySite, site.ID, wafLogPath(site), policy, false)
+		}
+		add(logRotateCategoryNginx, 0, filepath.Join(nc.GetLogDir(), "access.log"), globalPolicy, false)
+		add(logRotateCategoryNginx, 0, filepath.Join(nc.GetLogDir(), "error.log"), globalPolicy, false)
+	}
+
+	// 面板进程以追加方式持有自身日志，只能复制后截断
+	if global.CONF.Log.Path != "" {
+		add(logRotateCategoryPanel, 0, filepath.Join(global.CONF.Log.Path, "xpanel.log"), globalPolicy, true)
+	}
+	add(logRotateCategoryPanel, 0, (&UpgradeService{}).getLogPath(), globalPolicy, true)
+	sslDir := (&CertificateService{settingRepo: repo.NewISettingRepo()}).GetSSLDir()
+	sslLogs, _ := filepath.Glob(filepath.Join(sslDir, "logs", "*.log"))
+	sort.Strings(sslLogs)
+	for _, path := range sslLogs {
+		add(logRotateCategorySSL, 0, path, globalPolicy, true)
+	}
+	return targets, nil
+}
+
+func removeLogRotatePolicy(site model.Website) {
+	if err := repo.NewILogRotateRepo().DeleteByWebsite(site.ID); err != nil {
+		global.LOG.Warnf("Delete log rotate policy of %s failed: %v", site.PrimaryDomain, err)
+	}
+}
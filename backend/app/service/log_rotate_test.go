@@ -0,0 +1,160 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/global"
+	"xpanel/utils/logrotate"
+)
+
+func TestLogRotateRunsSitePolicyAndReopensNginx(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	installFakeNginx(t, false)
+	global.CONF.System.DataDir = t.TempDir()
+	global.CONF.Log.Path = t.TempDir()
+	reopened := 0
+	previousReopen := reopenNginxLogs
+	reopenNginxLogs = func() error { reopened++; return nil }
+	t.Cleanup(func() { reopenNginxLogs = previousReopen })
+	useSystemLogrotateConfigs(t)
+
+	logDir := t.TempDir()
+	noisy := &model.Website{PrimaryDomain: "noisy.example.com", Alias: "noisy_example_com", Type: "static", Status: "running", AccessLogPath: filepath.Join(logDir, "noisy.access.log")}
+	quiet := &model.Website{PrimaryDomain: "quiet.example.com", Alias: "quiet_example_com", Type: "static", Status: "running", AccessLogPath: filepath.Join(logDir, "quiet.access.log")}
+	for _, site := range []*model.Website{noisy, quiet} {
+		if err := svc.websiteRepo.Create(site); err != nil {
+			t.Fatal(err)
+		}
+		appendAccessLog(t, site.AccessLogPath, accessLogLine("203.0.113.1", "/", 200, ""))
+	}
+	panelLog := filepath.Join(global.CONF.Log.Path, "xpanel.log")
+	appendAccessLog(t, panelLog, "panel started\n")
+
+	rotateService := NewILogRotateService()
+	// 全局只按大小轮转，小文件不触发；noisy 站点单独按天轮转
+	if err := rotateService.SavePolicy(dto.LogRotatePolicy{Enable: true, Interval: logrotate.IntervalNone, MaxSize: 1, Keep: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rotateService.SavePolicy(dto.LogRotatePolicy{WebsiteID: noisy.ID, Enable: true, Interval: logrotate.IntervalDaily, Keep: 2, Compress: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rotateService.SavePolicy(dto.LogRotatePolicy{Enable: true, Interval: logrotate.IntervalNone, Keep: 3}); err == nil {
+		t.Fatal("policy without any trigger should be rejected")
+	}
+
+	// 更早轮转出的历史文件：超出保留份数的被删除，其余压缩
+	for _, suffix := range []string{"-20261001-000000", "-20261002-000000"} {
+		appendAccessLog(t, noisy.AccessLogPath+suffix, accessLogLine("198.51.100.1", "/old", 200, ""))
+	}
+
+	result, err := rotateService.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rotated) != 1 || result.Rotated[0] != noisy.AccessLogPath || len(result.Failed) != 0 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if reopened != 1 {
+		t.Fatalf("nginx should reopen logs once, got %d", reopened)
+	}
+	history := logrotate.List(noisy.AccessLogPath)
+	if result.Compressed != 1 || result.Removed != 1 || len(history) != 2 || history[0].Compressed || !history[1].Compressed {
+		t.Fatalf("unexpected history %+v, result %+v", history, result)
+	}
+	if _, err := os.Stat(quiet.AccessLogPath); err != nil {
+		t.Fatal("site on the global policy should not rotate below the size limit")
+	}
+
+	// 同一天内不再轮转；nginx 重新打开后继续写入当前文件
+	appendAccessLog(t, noisy.AccessLogPath, accessLogLine("203.0.113.2", "/new", 200, ""))
+	if result, _ := rotateService.Run(); len(result.Rotated) != 0 || reopened != 1 {
+		t.Fatalf("daily policy rotated twice: %+v", result)
+	}
+
+	entries, err := parseAccessLogHistory(noisy.AccessLogPath, time.Now().Add(-time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[2].URL != "/new" {
+		t.Fatalf("history should include rotated and compressed logs: %+v", entries)
+	}
+
+	files, err := rotateService.ListFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Category+":"+filepath.Base(file.Path))
+	}
+	if strings.Join(paths, ",") != "site:quiet.access.log,site:noisy.access.log,panel:xpanel.log" {
+		t.Fatalf("unexpected files %v", paths)
+	}
+
+	if err := rotateService.SavePolicy(dto.LogRotatePolicy{WebsiteID: noisy.ID, UseGlobal: true, Interval: logrotate.IntervalDaily}); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := rotateService.GetPolicy(dto.LogRotatePolicyReq{WebsiteID: noisy.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !policy.UseGlobal || policy.Interval != logrotate.IntervalNone || policy.MaxSize != 1 {
+		t.Fatalf("site should fall back to the global policy: %+v", policy)
+	}
+}
+
+func useSystemLogrotateConfigs(t *testing.T, configs ...string) {
+	t.Helper()
+	previous := systemLogrotateConfigs
+	systemLogrotateConfigs = configs
+	t.Cleanup(func() { systemLogrotateConfigs = previous })
+}
+
+func TestLogRotateIsOptInAndSkipsSystemManagedLogs(t *testing.T) {
+	installWebsiteLocationDB(t)
+	installFakeNginx(t, false)
+	global.CONF.System.DataDir = t.TempDir()
+	global.CONF.Log.Path = ""
+	previousReopen := reopenNginxLogs
+	reopenNginxLogs = func() error { return nil }
+	t.Cleanup(func() { reopenNginxLogs = previousReopen })
+
+	logDir := global.CONF.Nginx.GetLogDir()
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	accessLog, errorLog := filepath.Join(logDir, "access.log"), filepath.Join(logDir, "error.log")
+	appendAccessLog(t, accessLog, accessLogLine("203.0.113.1", "/", 200, ""))
+	appendAccessLog(t, errorLog, "error\n")
+	config := filepath.Join(t.TempDir(), "nginx")
+	if err := os.WriteFile(config, []byte(filepath.Join(logDir, "access.log")+" {\n\tdaily\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	useSystemLogrotateConfigs(t, config)
+
+	rotateService := NewILogRotateService()
+	if result, err := rotateService.Run(); err != nil || len(result.Rotated) != 0 {
+		t.Fatalf("rotation should be off until enabled: %+v %v", result, err)
+	}
+	if err := rotateService.SavePolicy(dto.LogRotatePolicy{Enable: true, Interval: logrotate.IntervalNone, MaxSize: 1, Keep: 3}); err != nil {
+		t.Fatal(err)
+	}
+	files, err := rotateService.ListFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if file.Path == accessLog {
+			t.Fatalf("log managed by system logrotate should not be listed: %+v", files)
+		}
+	}
+	if len(files) != 1 || files[0].Path != errorLog {
+		t.Fatalf("unexpected files %+v", files)
+	}
+}
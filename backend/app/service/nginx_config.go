@@ -16,14 +16,35 @@ import (
 )
 
 type NginxConfigGenerator struct {
-	certRepo    repo.ICertificateRepo
-	settingRepo repo.ISettingRepo
+	certRepo     repo.ICertificateRepo
+	settingRepo  repo.ISettingRepo
+	locationRepo repo.IWebsiteLocationRepo
+	upstreamRepo repo.IWebsiteUpstreamRepo
+	wafRepo      repo.IWebsiteWAFRepo
+	accessRepo   repo.IWebsiteAccessRepo
+	deployRepo   repo.IWebsiteDeployRepo
+	caRepo       repo.ICertificateAuthorityRepo
+}
+
+// siteRoutes 网站的结构化 location、其引用的 upstream、WAF、访问控制与 Git 部署配置
+type siteRoutes struct {
+	locations []model.WebsiteLocation
+	upstreams map[uint]model.WebsiteUpstream
+	waf       model.WebsiteWAF
+	access    model.WebsiteAccess
+	deploy    model.WebsiteDeploy
 }
 
 func NewNginxConfigGenerator() *NginxConfigGenerator {
 	return &NginxConfigGenerator{
-		certRepo:    repo.NewICertificateRepo(),
-		settingRepo: repo.NewISettingRepo(),
+		certRepo:     repo.NewICertificateRepo(),
+		settingRepo:  repo.NewISettingRepo(),
+		locationRepo: repo.NewIWebsiteLocationRepo(),
+		upstreamRepo: repo.NewIWebsiteUpstreamRepo(),
+		wafRepo:      repo.NewIWebsiteWAFRepo(),
+		accessRepo:   repo.NewIWebsiteAccessRepo(),
+		deployRepo:   repo.NewIWebsiteDeployRepo(),
+		caRepo:       repo.NewICertificateAuthorityRepo(),
 	}
 }
 
@@ -43,12 +64,48 @@ func (g *NginxConfigGenerator) Generate(site model.Website) (string, error) {
 			return "", fmt.Errorf("获取证书路径失败: %v", err)
 		}
 	}
+	var clientCA *privateCAClientFiles
+	if hasSSL && site.ClientCAID > 0 && (site.ClientVerify == clientVerifyOn || site.ClientVerify == clientVerifyOptional) {
+		var err error
+		if clientCA, err = g.getClientCAFiles(site.ClientCAID); err != nil {
+			return "", fmt.Errorf("获取客户端证书 CA 失败: %v", err)
+		}
+	}
+
+	routes := siteRoutes{upstreams: map[uint]model.WebsiteUpstream{}}
+	var upstreams []model.WebsiteUpstream
+	if site.ID > 0 {
+		var err error
+		if routes.locations, err = g.locationRepo.ListByWebsite(site.ID); err != nil {
+			return "", fmt.Errorf("读取 location 配置失败: %v", err)
+		}
+		sortWebsiteLocations(routes.locations)
+		if upstreams, err = g.upstreamRepo.ListByWebsite(site.ID); err != nil {
+			return "", fmt.Errorf("读取 upstream 配置失败: %v", err)
+		}
+		for _, upstream := range upstreams {
+			routes.upstreams[upstream.ID] = upstream
+		}
+		if routes.waf, err = g.wafRepo.GetByWebsite(site.ID); err != nil {
+			return "", fmt.Errorf("读取 WAF 配置失败: %v", err)
+		}
+		if routes.access, err = g.accessRepo.GetByWebsite(site.ID); err != nil {
+			return "", fmt.Errorf("读取访问控制配置失败: %v", err)
+		}
+		if routes.deploy, err = g.deployRepo.GetByWebsite(site.ID); err != nil {
+			return "", fmt.Errorf("读取 Git 部署配置失败: %v", err)
+		}
+	}
 
 	needsHTTPRedirect := hasSSL && site.HttpConfig == "HTTPSRedirect"
 	needsHTTPBlock := !hasSSL || site.HttpConfig == "httpOnly" || site.HttpConfig == "HTTPAlso" || site.HttpConfig == "HTTPSRedirect"
 	needsHTTPSBlock := hasSSL && site.HttpConfig != "httpOnly"
 
-	// Upstream block (before server blocks)
+	// Structured upstreams, then the raw upstream block (before server blocks)
+	for _, upstream := range upstreams {
+		b.WriteString(renderUpstream(site, upstream))
+		b.WriteString("\n")
+	}
 	if site.Upstream != "" {
 		for _, line := range strings.Split(site.Upstream, "\n") {
 			if line != "" {
@@ -58,6 +115,25 @@ func (g *NginxConfigGenerator) Generate(site model.Website) (string, error) {
 		b.WriteString("\n")
 	}
 
+	// proxy_cache zone (http context, shared by both server blocks)
+	if locationsNeedCache(routes.locations) {
+		cacheDir := filepath.Join(global.CONF.Nginx.GetCacheDir(), "xpanel", site.Alias)
+		os.MkdirAll(cacheDir, 0755)
+		fmt.Fprintf(&b, "proxy_cache_path %s levels=1:2 keys_zone=%s:10m max_size=1g inactive=60m use_temp_path=off;\n\n", cacheDir, locationCacheZone(site))
+	}
+
+	// WAF rule chain, blocked-request log format and CC zone (http context)
+	if routes.waf.Enabled {
+		b.WriteString(renderWAFHTTP(site, routes.waf))
+		b.WriteString("\n")
+	}
+
+	// Geo access control (http context); limit_req zones live in the shared include
+	if routes.access.GeoEnable {
+		b.WriteString(renderAccessHTTP(site, routes.access))
+		b.WriteString("\n")
+	}
+
 	// HTTP -> HTTPS redirect server block
 	if needsHTTPRedirect {
 		b.WriteString("server {\n")
@@ -75,7 +151,7 @@ func (g *NginxConfigGenerator) Generate(site model.Website) (string, error) {
 		g.writeListenHTTP(&b, site)
 		fmt.Fprintf(&b, "    server_name %s;\n", serverName)
 		b.WriteString("\n")
-		g.writeServerBody(&b, site, false, "", "")
+		g.writeServerBody(&b, site, routes, false, "", "")
 		b.WriteString("}\n")
 		if needsHTTPSBlock {
 			b.WriteString("\n")
@@ -88,8 +164,8 @@ func (g *NginxConfigGenerator) Generate(site model.Website) (string, error) {
 		g.writeListenHTTPS(&b, site)
 		fmt.Fprintf(&b, "    server_name %s;\n", serverName)
 		b.WriteString("\n")
-		g.writeSSLBlock(&b, site, certPath, keyPath)
-		g.writeServerBody(&b, site, true, certPath, keyPath)
+		g.writeSSLBlock(&b, site, certPath, keyPath, clientCA)
+		g.writeServerBody(&b, site, routes, true, certPath, keyPath)
 		b.WriteString("}\n")
 	}
 
@@ -167,6 +243,17 @@ func customHasLocationRoot(customNginx string) bool {
 	return false
 }
 
+// customHasPHPLocation 自定义配置中是否已有处理 .php 的正则 location
+func customHasPHPLocation(customNginx string) bool {
+	for _, line := range strings.Split(customNginx, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "location ~") && strings.Contains(trimmed, `\.php`) {
+			return true
+		}
+	}
+	return false
+}
+
 // customHasDirective 检查自定义配置中是否已包含某个 Nginx 指令
 func customHasDirective(customNginx, directive string) bool {
 	for _, line := range strings.Split(customNginx, "\n") {
@@ -178,7 +265,7 @@ func customHasDirective(customNginx, directive string) bool {
 	return false
 }
 
-func (g *NginxConfigGenerator) writeSSLBlock(b *strings.Builder, site model.Website, certPath, keyPath string) {
+func (g *NginxConfigGenerator) writeSSLBlock(b *strings.Builder, site model.Website, certPath, keyPath string, clientCA *privateCAClientFiles) {
 	custom := site.CustomNginx
 
 	if site.Http2Enable && !customHasDirective(custom, "http2") {
@@ -223,6 +310,14 @@ func (g *NginxConfigGenerator) writeSSLBlock(b *strings.Builder, site model.Webs
 		fmt.Fprintf(b, "    ssl_trusted_certificate %s;\n", certPath)
 	}
 
+	// 要求客户端出示私有 CA 签发的证书，吊销列表覆盖整条 CA 链
+	if clientCA != nil && !customHasDirective(custom, "ssl_verify_client") {
+		fmt.Fprintf(b, "    ssl_client_certificate %s;\n", clientCA.chainPath)
+		fmt.Fprintf(b, "    ssl_verify_client %s;\n", site.ClientVerify)
+		fmt.Fprintf(b, "    ssl_verify_depth %d;\n", clientCA.depth)
+		fmt.Fprintf(b, "    ssl_crl %s;\n", clientCA.crlPath)
+	}
+
 	if !customHasDirective(custom, "resolver") {
 		b.WriteString("    resolver 1.1.1.1 8.8.8.8 valid=300s;\n")
 		b.WriteString("    resolver_timeout 5s;\n")
@@ -290,7 +385,7 @@ func (g *NginxConfigGenerator) writeStaticCacheBlock(b *strings.Builder, site mo
 	}
 	b.WriteString("    # Static file caching\n")
 	b.WriteString("    location ~* \\.(jpg|jpeg|png|gif|ico|webp|avif|svg|svgz)$ {\n")
-	if site.Type == "static" {
+	if site.Type == "static" || site.Type == "php" {
 		b.WriteString("        expires 30d;\n")
 	} else {
 		b.WriteString("        proxy_pass " + site.ProxyPass + ";\n")
@@ -301,7 +396,7 @@ func (g *NginxConfigGenerator) writeStaticCacheBlock(b *strings.Builder, site mo
 	b.WriteString("    }\n\n")
 
 	b.WriteString("    location ~* \\.(css|js|woff|woff2|ttf|otf|eot)$ {\n")
-	if site.Type == "static" {
+	if site.Type == "static" || site.Type == "php" {
 		b.WriteString("        expires 7d;\n")
 	} else {
 		b.WriteString("        proxy_pass " + site.ProxyPass + ";\n")
@@ -312,11 +407,13 @@ func (g *NginxConfigGenerator) writeStaticCacheBlock(b *strings.Builder, site mo
 	b.WriteString("    }\n\n")
 }
 
-func (g *NginxConfigGenerator) writeServerBody(b *strings.Builder, site model.Website, isHTTPS bool, certPath, keyPath string) {
+func (g *NginxConfigGenerator) writeServerBody(b *strings.Builder, site model.Website, routes siteRoutes, isHTTPS bool, certPath, keyPath string) {
 	logDir := g.getSiteLogDir()
 	if site.AccessLog {
-		fmt.Fprintf(b, "    access_log %s/%s.access.log;\n", logDir, site.PrimaryDomain)
-	} else {
+		// 日志格式定义在共享 zone 文件中，写入网站配置前由 applyConfig 确保其存在
+		fmt.Fprintf(b, "    access_log %s/%s.access.log %s;\n", logDir, site.PrimaryDomain, accessStatsLogFormat)
+	} else if !routes.waf.Enabled {
+		// access_log off 会同时关闭 WAF 拦截日志
 		b.WriteString("    access_log off;\n")
 	}
 	if site.ErrorLog {
@@ -326,6 +423,16 @@ func (g *NginxConfigGenerator) writeServerBody(b *strings.Builder, site model.We
 	}
 	b.WriteString("\n")
 
+	if routes.waf.Enabled {
+		writeWAFServer(b, site, routes.waf)
+	}
+	if routes.access.GeoEnable || routes.access.LimitReqEnable {
+		writeAccessServer(b, site, routes.access)
+	}
+	if (routes.waf.Enabled && routes.waf.CCEnable) || routes.access.LimitReqEnable {
+		b.WriteString("    limit_req_status 429;\n\n")
+	}
+
 	g.writeACMEChallengeBlock(b)
 
 	// Basic auth
@@ -373,15 +480,23 @@ func (g *NginxConfigGenerator) writeServerBody(b *strings.Builder, site model.We
 	g.writeSecurityHeaders(b, site)
 
 	// Site type specific config (skip managed location / if custom config defines one)
-	hasCustomRootLocation := customHasLocationRoot(site.CustomNginx)
-	if !hasCustomRootLocation {
+	// PHP 站点只让出冲突的 location，root、index 与 FastCGI 仍需保留
+	hasCustomRootLocation := customHasLocationRoot(site.CustomNginx) || locationsHaveRoot(routes.locations)
+	if site.ProxyPass == "" && site.Type == "php" {
+		g.writePHPSite(b, site, hasCustomRootLocation, customHasPHPLocation(site.CustomNginx) || locationsHavePHP(routes.locations))
+	} else if !hasCustomRootLocation {
 		if site.ProxyPass != "" {
 			g.writeReverseProxy(b, site)
 		} else if site.Type == "static" {
-			g.writeStaticSite(b, site)
+			g.writeStaticSite(b, site, routes.deploy)
 		}
 	}
 
+	// Structured locations
+	for _, location := range routes.locations {
+		g.writeLocation(b, site, location, routes.upstreams)
+	}
+
 	// Static file caching (after site-specific config)
 	g.writeStaticCacheBlock(b, site)
 
@@ -418,16 +533,12 @@ func (g *NginxConfigGenerator) writeACMEChallengeBlock(b *strings.Builder) {
 	b.WriteString("    }\n\n")
 }
 
-func (g *NginxConfigGenerator) writeStaticSite(b *strings.Builder, site model.Website) {
-	siteDir := site.SiteDir
-	if siteDir == "" {
-		siteDir = fmt.Sprintf("/var/www/%s", site.PrimaryDomain)
-	}
+func (g *NginxConfigGenerator) writeStaticSite(b *strings.Builder, site model.Website, deploy model.WebsiteDeploy) {
 	indexFile := site.IndexFile
 	if indexFile == "" {
 		indexFile = "index.html index.htm"
 	}
-	fmt.Fprintf(b, "    root %s;\n", siteDir)
+	fmt.Fprintf(b, "    root %s;\n", staticDocumentRoot(site, deploy))
 	fmt.Fprintf(b, "    index %s;\n", indexFile)
 	b.WriteString("\n")
 	b.WriteString("    location / {\n")
@@ -435,6 +546,64 @@ func (g *NginxConfigGenerator) writeStaticSite(b *strings.Builder, site model.We
 	b.WriteString("    }\n\n")
 }
 
+// writePHPSite 生成 PHP 站点的入口规则与 FastCGI location，按框架预设调整伪静态；
+// skipRoot/skipFastCGI 表示自定义配置或结构化 location 已定义对应的 location，避免重复
+func (g *NginxConfigGenerator) writePHPSite(b *strings.Builder, site model.Website, skipRoot, skipFastCGI bool) {
+	indexFile := site.IndexFile
+	if indexFile == "" || indexFile == "index.html index.htm" {
+		indexFile = "index.php index.html index.htm"
+	}
+	fmt.Fprintf(b, "    root %s;\n", phpDocumentRoot(site))
+	fmt.Fprintf(b, "    index %s;\n", indexFile)
+	if !customHasDirective(site.CustomNginx, "client_max_body_size") {
+		fmt.Fprintf(b, "    client_max_body_size %s;\n", phpUploadMaxSize(site))
+	}
+	b.WriteString("\n")
+
+	if !skipRoot {
+		b.WriteString("    location / {\n")
+		switch site.PHPFramework {
+		case PHPFrameworkWordPress:
+			b.WriteString("        try_files $uri $uri/ /index.php?$args;\n")
+		case PHPFrameworkLaravel:
+			b.WriteString("        try_files $uri $uri/ /index.php?$query_string;\n")
+		case PHPFrameworkThinkPHP:
+			b.WriteString("        try_files $uri $uri/ /index.php?s=$uri&$args;\n")
+		default:
+			b.WriteString("        try_files $uri $uri/ =404;\n")
+		}
+		b.WriteString("    }\n\n")
+	}
+
+	if site.PHPFramework == PHPFrameworkWordPress {
+		b.WriteString("    location = /xmlrpc.php {\n")
+		b.WriteString("        deny all;\n")
+		b.WriteString("    }\n\n")
+		b.WriteString("    location ~* /wp-content/uploads/.*\\.php$ {\n")
+		b.WriteString("        deny all;\n")
+		b.WriteString("    }\n\n")
+	}
+
+	if !skipFastCGI {
+		b.WriteString("    location ~ [^/]\\.php(/|$) {\n")
+		b.WriteString("        fastcgi_split_path_info ^(.+?\\.php)(/.*)$;\n")
+		b.WriteString("        if (!-f $document_root$fastcgi_script_name) {\n")
+		b.WriteString("            return 404;\n")
+		b.WriteString("        }\n")
+		fmt.Fprintf(b, "        fastcgi_pass unix:%s;\n", phpPoolSocket(site))
+		b.WriteString("        fastcgi_index index.php;\n")
+		b.WriteString("        include fastcgi_params;\n")
+		b.WriteString("        fastcgi_param SCRIPT_FILENAME $document_root$fastcgi_script_name;\n")
+		b.WriteString("        fastcgi_param PATH_INFO $fastcgi_path_info;\n")
+		b.WriteString("        fastcgi_read_timeout 300s;\n")
+		b.WriteString("    }\n\n")
+	}
+
+	b.WriteString("    location ~ /\\.(?!well-known) {\n")
+	b.WriteString("        deny all;\n")
+	b.WriteString("    }\n\n")
+}
+
 func (g *NginxConfigGenerator) writeReverseProxy(b *strings.Builder, site model.Website) {
 	if site.ProxyPass == "" {
 		return
@@ -463,6 +632,107 @@ func (g *NginxConfigGenerator) writeReverseProxy(b *strings.Builder, site model.
 	b.WriteString("    }\n\n")
 }
 
+// writeLocation 生成结构化 location：反向代理带独立的超时、缓冲、缓存与请求头，静态目录用 alias
+func (g *NginxConfigGenerator) writeLocation(b *strings.Builder, site model.Website, location model.WebsiteLocation, upstreams map[uint]model.WebsiteUpstream) {
+	if location.Remark != "" {
+		fmt.Fprintf(b, "    # %s\n", strings.ReplaceAll(location.Remark, "\n", " "))
+	}
+	fmt.Fprintf(b, "    location %s%s {\n", locationMatchModifiers[location.Match], location.Path)
+	if location.ClientMaxBodySize != "" {
+		fmt.Fprintf(b, "        client_max_body_size %s;\n", location.ClientMaxBodySize)
+	}
+	if location.Type == "static" {
+		if location.Path == "/" {
+			fmt.Fprintf(b, "        root %s;\n", location.Root)
+			b.WriteString("        try_files $uri $uri/ =404;\n")
+		} else {
+			root := location.Root
+			if strings.HasSuffix(location.Path, "/") && !strings.HasSuffix(root, "/") {
+				root += "/"
+			}
+			fmt.Fprintf(b, "        alias %s;\n", root)
+		}
+		b.WriteString("        index index.html index.htm;\n")
+		g.writeLocationResponseHeaders(b, location)
+		b.WriteString("    }\n\n")
+		return
+	}
+
+	host := location.ProxyHost
+	if host == "" {
+		host = "$host"
+	}
+	proxyPass := location.ProxyPass
+	keepalive := false
+	if location.UpstreamID > 0 {
+		upstream, ok := upstreams[location.UpstreamID]
+		if !ok {
+			b.WriteString("        return 502;\n")
+			b.WriteString("    }\n\n")
+			return
+		}
+		proxyPass = "http://" + upstreamName(site, upstream)
+		keepalive = upstream.Keepalive > 0
+	}
+	fmt.Fprintf(b, "        proxy_pass %s;\n", proxyPass)
+	fmt.Fprintf(b, "        proxy_set_header Host %s;\n", host)
+	b.WriteString("        proxy_set_header X-Real-IP $remote_addr;\n")
+	b.WriteString("        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;\n")
+	b.WriteString("        proxy_set_header X-Forwarded-Proto $scheme;\n")
+	b.WriteString("        proxy_http_version 1.1;\n")
+	if location.WebSocket {
+		b.WriteString("        proxy_set_header Upgrade $http_upgrade;\n")
+		b.WriteString("        proxy_set_header Connection \"upgrade\";\n")
+	} else if keepalive {
+		b.WriteString("        proxy_set_header Connection \"\";\n")
+	}
+	orDefault := func(value, fallback int) int {
+		if value <= 0 {
+			return fallback
+		}
+		return value
+	}
+	longTimeout := 600
+	if location.WebSocket {
+		longTimeout = 86400
+	}
+	fmt.Fprintf(b, "        proxy_connect_timeout %ds;\n", orDefault(location.ConnectTimeout, 60))
+	fmt.Fprintf(b, "        proxy_send_timeout %ds;\n", orDefault(location.SendTimeout, longTimeout))
+	fmt.Fprintf(b, "        proxy_read_timeout %ds;\n", orDefault(location.ReadTimeout, longTimeout))
+	if location.DisableBuffering || location.WebSocket {
+		b.WriteString("        proxy_buffering off;\n")
+	} else {
+		b.WriteString("        proxy_buffering on;\n")
+	}
+	if location.CacheEnable {
+		valid := location.CacheValid
+		if valid == "" {
+			valid = "10m"
+		}
+		fmt.Fprintf(b, "        proxy_cache %s;\n", locationCacheZone(site))
+		fmt.Fprintf(b, "        proxy_cache_valid 200 301 302 %s;\n", valid)
+		b.WriteString("        proxy_cache_use_stale error timeout updating http_500 http_502 http_503 http_504;\n")
+		b.WriteString("        add_header X-Cache-Status $upstream_cache_status always;\n")
+	}
+	for _, header := range parseLocationHeaders(location.RequestHeaders) {
+		fmt.Fprintf(b, "        proxy_set_header %s \"%s\";\n", header.Name, header.Value)
+	}
+	g.writeLocationResponseHeaders(b, location)
+	b.WriteString("    }\n\n")
+}
+
+func (g *NginxConfigGenerator) writeLocationResponseHeaders(b *strings.Builder, location model.WebsiteLocation) {
+	for _, header := range parseLocationHeaders(location.ResponseHeaders) {
+		if header.Value == "" {
+			if location.Type == "proxy" {
+				fmt.Fprintf(b, "        proxy_hide_header %s;\n", header.Name)
+			}
+			continue
+		}
+		fmt.Fprintf(b, "        add_header %s \"%s\" always;\n", header.Name, header.Value)
+	}
+}
+
 func (g *NginxConfigGenerator) generateRedirects(redirectsJSON string) string {
 	// 简单解析 JSON 格式的重定向规则，生成 Nginx location 块
 	// 格式: [{"source":"/old","target":"https://new.com/path","type":301}]
@@ -511,6 +781,11 @@ func (g *NginxConfigGenerator) getCertPaths(certID uint) (string, string, error)
 	return certPath, keyPath, nil
 }
 
+func (g *NginxConfigGenerator) getClientCAFiles(caID uint) (*privateCAClientFiles, error) {
+	svc := &PrivateCAService{caRepo: g.caRepo, certRepo: g.certRepo, settingRepo: g.settingRepo}
+	return svc.clientVerifyFiles(caID)
+}
+
 func (g *NginxConfigGenerator) getSSLDir() string {
 	dir, err := g.settingRepo.GetValueByKey("SSLDir")
 	if err != nil || dir == "" {
@@ -536,8 +811,35 @@ func GetSiteConfPath(alias string) string {
 	return filepath.Join(global.CONF.Nginx.GetSitesDir(), alias+".conf")
 }
 
-// EnsureNginxInclude 确保 nginx.conf 包含站点配置目录
+// EnsureNginxInclude 确保 nginx.conf 包含站点配置目录与面板共享的 zone 定义
 func EnsureNginxInclude() error {
+	if err := ensureSitesInclude(); err != nil {
+		return err
+	}
+	return ensureSharedZonesInclude()
+}
+
+// ensureSharedZonesInclude 生成缺失或旧版本（缺少访问统计日志格式）的共享 zone 文件并在 http 块中 include
+func ensureSharedZonesInclude() error {
+	path := SharedZonesPath()
+	if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), accessStatsLogFormatDirective) {
+		if err := writeSharedZones(); err != nil {
+			return err
+		}
+	}
+	mainConf := global.CONF.Nginx.GetMainConf()
+	data, err := os.ReadFile(mainConf)
+	if err != nil {
+		return nil
+	}
+	content := string(data)
+	if strings.Contains(content, path) {
+		return nil
+	}
+	return insertNginxInclude(mainConf, content, "include "+path+";")
+}
+
+func ensureSitesInclude() error {
 	nc := global.CONF.Nginx
 
 	// System mode: Debian/Ubuntu nginx already includes sites-enabled/*
@@ -18,6 +18,7 @@ import (
 	"xpanel/constant"
 	"xpanel/global"
 	"xpanel/utils/iplocation"
+	"xpanel/utils/logrotate"
 )
 
 type INginxLogService interface {
@@ -26,6 +27,11 @@ type INginxLogService interface {
 	AnalyzeSite(req dto.NginxLogAnalyzeReq) (*dto.NginxLogAnalysis, error)
 	TailLog(req dto.NginxLogTailReq) (*dto.NginxLogTailResp, error)
 	Drilldown(req dto.NginxLogDrilldownReq) (*dto.NginxLogDrilldownResp, error)
+
+	// 后台采集的网站访问统计
+	SiteStats(req dto.WebsiteAccessStatsReq) (*dto.WebsiteAccessStats, error)
+	GetStatsSetting() (*dto.AccessStatsSetting, error)
+	UpdateStatsSetting(req dto.AccessStatsSetting) error
 }
 
 type NginxLogService struct {
@@ -41,47 +47,35 @@ var combinedLogRe = regexp.MustCompile(
 	`^(\S+)\s+\S+\s+\S+\s+\[([^\]]+)\]\s+"([^"]*?)"\s+(\d{3})\s+(\d+)\s+"[^"]*"\s+"([^"]*)"`,
 )
 
+// statsLogTailRe 面板统计日志格式在 combined 之后追加的 "$upstream_response_time" $request_time
+var statsLogTailRe = regexp.MustCompile(`^\s+"([^"]*)"\s+([\d.]+)`)
+
 type logEntry struct {
-	IP        string
-	Time      time.Time
-	Method    string
-	URL       string
-	Status    int
-	Bytes     int64
-	UserAgent string
+	IP           string
+	Time         time.Time
+	Method       string
+	URL          string
+	Status       int
+	Bytes        int64
+	UserAgent    string
+	UpstreamTime float64 // 秒
+	HasUpstream  bool
 }
 
-// Analyze handles legacy site-ID based analysis
+// Analyze 返回面板网站的访问分析，数据来自后台采集的聚合统计
 func (s *NginxLogService) Analyze(req dto.NginxLogAnalysisReq) (*dto.NginxLogAnalysis, error) {
 	site, err := s.websiteRepo.Get(repo.WithByID(req.SiteID))
 	if err != nil {
 		return nil, buserr.New(constant.ErrRecordNotFound)
 	}
 
-	nc := global.CONF.Nginx
-	if !nc.IsInstalled() {
-		return nil, buserr.New(constant.ErrNginxNotInstalled)
-	}
-
-	logDir := fmt.Sprintf("%s/sites", nc.GetLogDir())
-	logPath := fmt.Sprintf("%s/%s.access.log", logDir, site.PrimaryDomain)
-	if strings.TrimSpace(site.AccessLogPath) != "" {
-		logPath = strings.TrimSpace(site.AccessLogPath)
-	}
-	if _, err := os.Stat(logPath); err != nil {
-		return &dto.NginxLogAnalysis{StatusCodes: make(map[string]int64)}, nil
-	}
-
 	days := req.Days
 	if days <= 0 {
 		days = 1
 	}
-	cutoff := time.Now().AddDate(0, 0, -days)
-	entries, err := parseAccessLog(logPath, cutoff, 0)
-	if err != nil {
-		return nil, fmt.Errorf("parse log: %v", err)
-	}
-	return aggregate(entries, days, false, nil), nil
+	end := time.Now().UTC().Add(time.Minute)
+	start := end.AddDate(0, 0, -days).Truncate(time.Hour)
+	return analyzeAccessStats(site.ID, start, end)
 }
 
 // DetectSites scans Nginx config files and extracts server_name + log paths
@@ -160,10 +154,7 @@ func (s *NginxLogService) AnalyzeSite(req dto.NginxLogAnalyzeReq) (*dto.NginxLog
 	dedupPaths := dedupStrings(logPaths)
 	var allEntries []logEntry
 	for _, p := range dedupPaths {
-		if _, err := os.Stat(p); err != nil {
-			continue
-		}
-		entries, err := parseAccessLog(p, cutoff, maxLines)
+		entries, err := parseAccessLogHistory(p, cutoff, maxLines)
 		if err != nil {
 			continue
 		}
@@ -264,10 +255,7 @@ func (s *NginxLogService) Drilldown(req dto.NginxLogDrilldownReq) (*dto.NginxLog
 	dedupPaths := dedupStrings(logPaths)
 	var allEntries []logEntry
 	for _, p := range dedupPaths {
-		if _, err := os.Stat(p); err != nil {
-			continue
-		}
-		entries, err := parseAccessLog(p, cutoff, maxLines)
+		entries, err := parseAccessLogHistory(p, cutoff, maxLines)
 		if err != nil {
 			continue
 		}
@@ -399,66 +387,164 @@ func parseNginxConfForSites(confPath, defaultLogDir string) []dto.NginxDetectedS
 // --- Log parsing ---
 
 func parseAccessLog(path string, cutoff time.Time, maxLines int) ([]logEntry, error) {
-	f, err := os.Open(path)
+	var (
+		lines []string
+		err   error
+	)
+	if maxLines > 0 && !strings.HasSuffix(path, ".gz") {
+		lines, err = readLastFileLines(path, maxLines)
+	} else {
+		lines, err = scanLogLines(path, maxLines)
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-
-	var lines []string
-	if maxLines > 0 {
-		lines, err = readLastLines(f, maxLines)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		scanner := bufio.NewScanner(f)
-		buf := make([]byte, 0, 256*1024)
-		scanner.Buffer(buf, 1024*1024)
-		for scanner.Scan() {
-			lines = append(lines, scanner.Text())
-		}
-		if err := scanner.Err(); err != nil {
-			return nil, err
-		}
-	}
 
 	var entries []logEntry
 	for _, line := range lines {
-		m := combinedLogRe.FindStringSubmatch(line)
-		if m == nil {
+		entry, ok := parseAccessLine(line)
+		if !ok || entry.Time.Before(cutoff) {
 			continue
 		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
 
-		t, err := time.Parse("02/Jan/2006:15:04:05 -0700", m[2])
+// parseAccessLogHistory 读取当前日志，不足 maxLines 时继续从新到旧读取轮转出的历史文件（含 .gz），
+// 直到历史文件的最后写入早于 cutoff
+func parseAccessLogHistory(path string, cutoff time.Time, maxLines int) ([]logEntry, error) {
+	entries, err := parseAccessLog(path, cutoff, maxLines)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, rotated := range rotatedAccessLogs(path) {
+		if maxLines > 0 && len(entries) >= maxLines {
+			break
+		}
+		if rotated.modTime.Before(cutoff) {
+			break
+		}
+		remaining := 0
+		if maxLines > 0 {
+			remaining = maxLines - len(entries)
+		}
+		older, err := parseAccessLog(rotated.path, cutoff, remaining)
 		if err != nil {
 			continue
 		}
-		if t.Before(cutoff) {
+		entries = append(older, entries...)
+	}
+	return entries, nil
+}
+
+// rotatedLogSuffixRe 匹配面板轮转（-20060102-150405）与系统 logrotate（.1、-20060102）生成的历史文件
+var rotatedLogSuffixRe = regexp.MustCompile(`^(\.\d+|-\d{8}(-\d{6})?)(\.gz)?$`)
+
+type rotatedLogFile struct {
+	path    string
+	modTime time.Time
+}
+
+// rotatedAccessLogs 返回 path 的历史文件，按最后写入时间从新到旧排序
+func rotatedAccessLogs(path string) []rotatedLogFile {
+	matches, _ := filepath.Glob(path + "?*")
+	var files []rotatedLogFile
+	for _, match := range matches {
+		if !rotatedLogSuffixRe.MatchString(strings.TrimPrefix(match, path)) {
 			continue
 		}
+		if info, err := os.Stat(match); err == nil && !info.IsDir() {
+			files = append(files, rotatedLogFile{path: match, modTime: info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	return files
+}
+
+// parseAccessLine 解析 combined 格式的一行；面板统计格式追加的 upstream 耗时存在时一并解析
+func parseAccessLine(line string) (logEntry, bool) {
+	m := combinedLogRe.FindStringSubmatch(line)
+	if m == nil {
+		return logEntry{}, false
+	}
+	t, err := time.Parse("02/Jan/2006:15:04:05 -0700", m[2])
+	if err != nil {
+		return logEntry{}, false
+	}
+
+	status, _ := strconv.Atoi(m[4])
+	bytes, _ := strconv.ParseInt(m[5], 10, 64)
+
+	parts := strings.SplitN(m[3], " ", 3)
+	method, url := "", ""
+	if len(parts) >= 2 {
+		method = parts[0]
+		url = parts[1]
+	}
 
-		status, _ := strconv.Atoi(m[4])
-		bytes, _ := strconv.ParseInt(m[5], 10, 64)
+	entry := logEntry{
+		IP:        m[1],
+		Time:      t,
+		Method:    method,
+		URL:       url,
+		Status:    status,
+		Bytes:     bytes,
+		UserAgent: m[6],
+	}
+	if tail := statsLogTailRe.FindStringSubmatch(line[len(m[0]):]); tail != nil {
+		entry.UpstreamTime, entry.HasUpstream = parseUpstreamTime(tail[1])
+	}
+	return entry, true
+}
 
-		parts := strings.SplitN(m[3], " ", 3)
-		method, url := "", ""
-		if len(parts) >= 2 {
-			method = parts[0]
-			url = parts[1]
+// parseUpstreamTime 请求经过多个 upstream（重试或内部跳转）时 nginx 以 ", " 或 " : " 分隔，累加为总耗时
+func parseUpstreamTime(value string) (float64, bool) {
+	var total float64
+	found := false
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ':' || r == ' ' }) {
+		if v, err := strconv.ParseFloat(field, 64); err == nil {
+			total += v
+			found = true
 		}
+	}
+	return total, found
+}
 
-		entries = append(entries, logEntry{
-			IP:        m[1],
-			Time:      t,
-			Method:    method,
-			URL:       url,
-			Status:    status,
-			Bytes:     bytes,
-			UserAgent: m[6],
-		})
+func readLastFileLines(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
-	return entries, nil
+	defer f.Close()
+	return readLastLines(f, n)
+}
+
+// scanLogLines 顺序读取整个文件（.gz 透明解压），maxLines 大于 0 时只保留最后 maxLines 行
+func scanLogLines(path string, maxLines int) ([]string, error) {
+	r, err := logrotate.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	buf := make([]byte, 0, 256*1024)
+	scanner.Buffer(buf, 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if maxLines > 0 && len(lines) >= 2*maxLines {
+			lines = append(lines[:0], lines[len(lines)-maxLines:]...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if maxLines > 0 && len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	return lines, nil
 }
 
 func readLastLines(f *os.File, n int) ([]string, error) {
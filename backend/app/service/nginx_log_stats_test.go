@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/global"
+)
+
+func accessLogLine(ip, path string, status int, upstream string) string {
+	line := fmt.Sprintf(`%s - - [%s] "GET %s HTTP/1.1" %d 100 "-" "curl/8.0"`, ip, time.Now().Format("02/Jan/2006:15:04:05 -0700"), path, status)
+	if upstream != "" {
+		line += fmt.Sprintf(` "%s" 0.020`, upstream)
+	}
+	return line + "\n"
+}
+
+func appendAccessLog(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectAccessStatsFollowsRotation(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	logPath := filepath.Join(t.TempDir(), "access.log")
+	site := &model.Website{PrimaryDomain: "shop.example.com", Alias: "shop_example_com", Type: "proxy", Status: "running", AccessLog: true, AccessLogPath: logPath}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+
+	partial := accessLogLine("203.0.113.9", "/cart", 200, "")
+	appendAccessLog(t, logPath, accessLogLine("203.0.113.1", "/api/items?page=2", 200, "0.010, 0.030")+
+		accessLogLine("203.0.113.1", "/missing", 404, "")+partial[:len(partial)-1])
+	CollectAccessStats()
+
+	statRepo := repo.NewIAccessStatRepo()
+	end := time.Now().UTC().Add(time.Hour)
+	minutes, _ := statRepo.ListStats(site.ID, accessStatPeriodMinute, end.Add(-2*time.Hour), end)
+	total := sumAccessStats(minutes)
+	if total.Requests != 2 || total.Status404 != 1 || total.UpstreamCount != 1 || total.UpstreamTime < 0.039 || total.UpstreamTime > 0.041 {
+		t.Fatalf("unexpected stats after first run: %+v", total)
+	}
+
+	// 未写完的行补齐后日志被轮转，新文件继续写入
+	appendAccessLog(t, logPath, "\n"+accessLogLine("203.0.113.2", "/", 200, "0.005"))
+	if err := os.Rename(logPath, logPath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendAccessLog(t, logPath, accessLogLine("203.0.113.3", "/", 502, "0.100"))
+	CollectAccessStats()
+	CollectAccessStats()
+
+	hours, _ := statRepo.ListStats(site.ID, accessStatPeriodHour, end.Add(-3*time.Hour), end)
+	total = sumAccessStats(hours)
+	if total.Requests != 5 || total.Status5xx != 1 || total.UpstreamCount != 3 {
+		t.Fatalf("rotated log not followed: %+v", total)
+	}
+
+	analysis, err := (&NginxLogService{websiteRepo: svc.websiteRepo}).Analyze(dto.NginxLogAnalysisReq{SiteID: site.ID, Days: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.TotalRequests != 5 || analysis.StatusCodes["2xx"] != 3 || analysis.UniqueIPs != 4 {
+		t.Fatalf("unexpected analysis %+v", analysis)
+	}
+	if len(analysis.TopURLs) == 0 || analysis.TopURLs[0].Name != "/" || analysis.TopURLs[0].Count != 2 {
+		t.Fatalf("query string should be stripped from top urls: %+v", analysis.TopURLs)
+	}
+
+	alerts, err := svc.GetLogAlerts(dto.WebsiteLogAlertReq{ID: site.ID, TimeRange: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 2 || alerts[0].Type != "5xx" || alerts[1].Type != "hot_ip" {
+		t.Fatalf("unexpected alerts %+v", alerts)
+	}
+}
+
+func TestParseAccessLineReadsUpstreamTime(t *testing.T) {
+	entry, ok := parseAccessLine(accessLogLine("198.51.100.7", "/", 200, "0.010, 0.020 : 0.005"))
+	if !ok || !entry.HasUpstream || entry.UpstreamTime < 0.0349 || entry.UpstreamTime > 0.0351 {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	entry, ok = parseAccessLine(accessLogLine("198.51.100.7", "/", 200, "-"))
+	if !ok || entry.HasUpstream {
+		t.Fatalf("static request should have no upstream time: %+v", entry)
+	}
+}
+
+func TestUpgradeAccessStatsLogFormatRerendersDeployedSites(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	installFakeNginx(t, false)
+	previousDataDir := global.CONF.System.DataDir
+	global.CONF.System.DataDir = t.TempDir()
+	t.Cleanup(func() { global.CONF.System.DataDir = previousDataDir })
+	site := &model.Website{PrimaryDomain: "old.example.com", Alias: "old_example_com", Type: "static", Status: "running",
+		SiteDir: t.TempDir(), AccessLog: true}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.applyConfig(*site); err != nil {
+		t.Fatal(err)
+	}
+	// 模拟升级前部署的网站：访问日志未使用统计格式
+	confPath := GetSiteConfPath(site.Alias)
+	content, _ := os.ReadFile(confPath)
+	legacy := strings.Replace(string(content), ".access.log "+accessStatsLogFormat+";", ".access.log;", 1)
+	if legacy == string(content) {
+		t.Fatalf("rendered config should use the stats log format:\n%s", content)
+	}
+	if err := os.WriteFile(confPath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	upgradeAccessStatsLogFormat()
+	if drift, err := svc.recordConfigDrift(*site); err != nil || drift.ID != 0 {
+		t.Fatalf("upgraded site config should match the generator: %+v %v", drift, err)
+	}
+	zones, _ := os.ReadFile(SharedZonesPath())
+	if !strings.Contains(string(zones), accessStatsLogFormatDirective) {
+		t.Fatalf("shared zones should define the stats log format:\n%s", zones)
+	}
+
+	// 手工修改过的旧配置不能被覆盖，只记录漂移
+	edited := strings.Replace(legacy, "server {", "server {\n    client_max_body_size 64m;", 1)
+	if err := os.WriteFile(confPath, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	upgradeAccessStatsLogFormat()
+	if data, _ := os.ReadFile(confPath); string(data) != edited {
+		t.Fatalf("hand-edited config was overwritten:\n%s", data)
+	}
+	if drift, err := repo.NewIWebsiteConfigDriftRepo().GetByWebsite(site.ID); err != nil || drift.ID == 0 {
+		t.Fatalf("hand-edited config should be recorded as drift: %+v %v", drift, err)
+	}
+}
+
+func TestUpdateStatsSettingRejectsNonPositiveRetention(t *testing.T) {
+	for _, req := range []dto.AccessStatsSetting{{MinuteDays: 0, StoreDays: 30}, {MinuteDays: 2, StoreDays: 0}, {MinuteDays: -1, StoreDays: -1}} {
+		if err := (&NginxLogService{}).UpdateStatsSetting(req); err == nil {
+			t.Errorf("retention %+v should be rejected", req)
+		}
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"xpanel/app/dto"
 	"xpanel/app/model"
 	"xpanel/app/repo"
+	"xpanel/utils/notify"
 )
 
 const notificationPreferenceKey = "NotificationPreferences"
@@ -40,22 +42,31 @@ func (s *NotificationService) Create(req dto.NotificationCreate) error {
 	event := strings.ToLower(strings.TrimSpace(req.Event))
 	pref, _ := s.GetPreference()
 	rule := notificationRuleFor(pref, event)
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = "系统通知"
+	}
+	dispatchNotification(rule.Channels, notify.Message{
+		Event:     event,
+		Type:      normalizeNotificationType(req.Type),
+		Title:     title,
+		Content:   strings.TrimSpace(req.Content),
+		TargetURL: strings.TrimSpace(req.TargetURL),
+		Time:      time.Now(),
+	})
 	if !rule.Center {
 		return nil
 	}
 	notification := &model.Notification{
 		Type:      normalizeNotificationType(req.Type),
 		Event:     event,
-		Title:     strings.TrimSpace(req.Title),
+		Title:     title,
 		Content:   strings.TrimSpace(req.Content),
 		Source:    strings.TrimSpace(req.Source),
 		TargetURL: strings.TrimSpace(req.TargetURL),
 		ShowBadge: rule.Badge,
 		Popup:     rule.Popup,
 	}
-	if notification.Title == "" {
-		notification.Title = "系统通知"
-	}
 	if err := s.notificationRepo.Create(notification); err != nil {
 		return err
 	}
@@ -166,6 +177,28 @@ func notifySSLRenewFailed(domain string, err error) {
 	})
 }
 
+func notifySSLRevoked(domain string, content string) {
+	CreateNotification(dto.NotificationCreate{
+		Type:      "error",
+		Event:     "ssl.revoked",
+		Title:     fmt.Sprintf("证书「%s」已被 CA 吊销", domain),
+		Content:   content,
+		Source:    "system",
+		TargetURL: "/website/ssl",
+	})
+}
+
+func notifySSLDeployFailed(domain, target string, err error) {
+	CreateNotification(dto.NotificationCreate{
+		Type:      "error",
+		Event:     "ssl.deploy.failed",
+		Title:     fmt.Sprintf("证书「%s」部署到「%s」失败", domain, target),
+		Content:   err.Error(),
+		Source:    "system",
+		TargetURL: "/website/ssl",
+	})
+}
+
 func normalizeNotificationType(t string) string {
 	switch t {
 	case "success", "warning", "error":
@@ -189,7 +222,12 @@ func defaultNotificationPreference() dto.NotificationPreference {
 			"cronjob.success":         {Center: true, Badge: false, Popup: false},
 			"cronjob.failed":          {Center: true, Badge: true, Popup: true},
 			"ssl.renew.failed":        {Center: true, Badge: true, Popup: true},
+			"ssl.revoked":             {Center: true, Badge: true, Popup: true},
+			"ssl.deploy.failed":       {Center: true, Badge: true, Popup: true},
 			"security.login.failed":   {Center: true, Badge: true, Popup: true},
+			"monitor.alert.firing":    {Center: true, Badge: true, Popup: true},
+			"monitor.alert.resolved":  {Center: true, Badge: false, Popup: false},
+			"backup.verify.failed":    {Center: true, Badge: true, Popup: true},
 		},
 	}
 }
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/utils/notify"
+)
+
+const (
+	notificationChannelDefaultRate = 20
+	notificationSendAttempts       = 3
+	notificationSendTimeout        = 20 * time.Second
+)
+
+// notificationRetryBackoff 第 n 次重试前的等待时长（测试中可缩短）
+var notificationRetryBackoff = func(attempt int) time.Duration {
+	return time.Duration(attempt) * 5 * time.Second
+}
+
+var notificationChannelRepo = repo.NewINotificationChannelRepo()
+
+// INotificationChannelService 外发通知渠道管理（仅管理员）
+type INotificationChannelService interface {
+	List() ([]dto.NotificationChannelInfo, error)
+	Create(req dto.NotificationChannelCreate) error
+	Update(req dto.NotificationChannelUpdate) error
+	Delete(id uint) error
+	Test(id uint) error
+}
+
+type NotificationChannelService struct{}
+
+func NewINotificationChannelService() INotificationChannelService {
+	return &NotificationChannelService{}
+}
+
+func (s *NotificationChannelService) List() ([]dto.NotificationChannelInfo, error) {
+	channels, err := notificationChannelRepo.GetList()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]dto.NotificationChannelInfo, 0, len(channels))
+	for _, channel := range channels {
+		config := decodeChannelConfig(channel.Config)
+		secretSet := []string{}
+		for _, key := range notify.SecretKeys[channel.Type] {
+			if config[key] != "" {
+				secretSet = append(secretSet, key)
+			}
+			delete(config, key)
+		}
+		items = append(items, dto.NotificationChannelInfo{
+			ID:         channel.ID,
+			Name:       channel.Name,
+			Type:       channel.Type,
+			Config:     config,
+			SecretSet:  secretSet,
+			Status:     channel.Status,
+			RateLimit:  channel.RateLimit,
+			LastStatus: channel.LastStatus,
+			LastError:  channel.LastError,
+			LastSentAt: channel.LastSentAt,
+		})
+	}
+	return items, nil
+}
+
+func (s *NotificationChannelService) Create(req dto.NotificationChannelCreate) error {
+	name := strings.TrimSpace(req.Name)
+	if _, err := notificationChannelRepo.Get(repo.WithByName(name)); err == nil {
+		return buserr.New(constant.ErrRecordExist)
+	}
+	config := trimChannelConfig(req.Config)
+	if err := notify.Validate(req.Type, config); err != nil {
+		return buserr.WithDetail(constant.ErrInvalidParams, err.Error(), err)
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	channel := model.NotificationChannel{
+		Name:      name,
+		Type:      req.Type,
+		Config:    string(data),
+		Status:    constant.StatusEnable,
+		RateLimit: normalizeChannelRate(req.RateLimit),
+	}
+	if err := notificationChannelRepo.Create(&channel); err != nil {
+		return buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	global.LOG.Infof("Notification channel created: %s (%s)", name, req.Type)
+	return nil
+}
+
+func (s *NotificationChannelService) Update(req dto.NotificationChannelUpdate) error {
+	existing, err := notificationChannelRepo.Get(repo.WithByID(req.ID))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	name := strings.TrimSpace(req.Name)
+	if other, err := notificationChannelRepo.Get(repo.WithByName(name)); err == nil && other.ID != req.ID {
+		return buserr.New(constant.ErrRecordExist)
+	}
+	config := trimChannelConfig(req.Config)
+	if existing.Type == req.Type {
+		// 留空的密钥沿用已保存的值
+		previous := decodeChannelConfig(existing.Config)
+		for _, key := range notify.SecretKeys[req.Type] {
+			if config[key] == "" && previous[key] != "" {
+				config[key] = previous[key]
+			}
+		}
+	}
+	if err := notify.Validate(req.Type, config); err != nil {
+		return buserr.WithDetail(constant.ErrInvalidParams, err.Error(), err)
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return notificationChannelRepo.Update(req.ID, map[string]interface{}{
+		"name":       name,
+		"type":       req.Type,
+		"config":     string(data),
+		"status":     req.Status,
+		"rate_limit": normalizeChannelRate(req.RateLimit),
+	})
+}
+
+func (s *NotificationChannelService) Delete(id uint) error {
+	if _, err := notificationChannelRepo.Get(repo.WithByID(id)); err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	return notificationChannelRepo.Delete(repo.WithByID(id))
+}
+
+// Test 立即发送一条测试消息，不重试、不计入限流，失败原因直接返回
+func (s *NotificationChannelService) Test(id uint) error {
+	channel, err := notificationChannelRepo.Get(repo.WithByID(id))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	msg := notify.Message{
+		Event:   "notification.test",
+		Type:    "info",
+		Title:   "测试通知",
+		Content: "这是一条来自面板的测试通知，收到说明渠道「" + channel.Name + "」配置正确。",
+		Time:    time.Now(),
+	}
+	err = sendToChannel(channel, msg)
+	recordChannelResult(channel.ID, err)
+	if err != nil {
+		return buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	return nil
+}
+
+// dispatchNotification 将通知投递到规则指定的外发渠道，异步执行，失败按退避重试
+func dispatchNotification(channelIDs []uint, msg notify.Message) {
+	if len(channelIDs) == 0 {
+		return
+	}
+	channels, err := notificationChannelRepo.GetList(repo.WithByIDs(channelIDs))
+	if err != nil {
+		global.LOG.Errorf("Failed to load notification channels: %v", err)
+		return
+	}
+	for _, channel := range channels {
+		if channel.Status != constant.StatusEnable {
+			continue
+		}
+		if !channelLimiter.allow(channel.ID, channel.RateLimit, msg.Time) {
+			global.LOG.Warnf("Notification channel %s rate limited, dropped event %s", channel.Name, msg.Event)
+			recordChannelResult(channel.ID, errRateLimited)
+			continue
+		}
+		go deliverWithRetry(channel, msg)
+	}
+}
+
+func deliverWithRetry(channel model.NotificationChannel, msg notify.Message) {
+	var err error
+	for attempt := 1; attempt <= notificationSendAttempts; attempt++ {
+		if err = sendToChannel(channel, msg); err == nil {
+			break
+		}
+		if attempt < notificationSendAttempts {
+			time.Sleep(notificationRetryBackoff(attempt))
+		}
+	}
+	if err != nil {
+		global.LOG.Errorf("Notification channel %s failed after %d attempts: %v", channel.Name, notificationSendAttempts, err)
+	}
+	recordChannelResult(channel.ID, err)
+}
+
+func sendToChannel(channel model.NotificationChannel, msg notify.Message) error {
+	sender, err := notify.NewSender(channel.Type, decodeChannelConfig(channel.Config))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notificationSendTimeout)
+	defer cancel()
+	return sender.Send(ctx, msg)
+}
+
+func recordChannelResult(id uint, err error) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"last_status":  constant.StatusSuccess,
+		"last_error":   "",
+		"last_sent_at": &now,
+	}
+	if err != nil {
+		updates["last_status"] = constant.StatusFailed
+		updates["last_error"] = err.Error()
+	}
+	if e := notificationChannelRepo.Update(id, updates); e != nil {
+		global.LOG.Warnf("Failed to record notification channel result: %v", e)
+	}
+}
+
+var errRateLimited = buserr.New(constant.ErrNotificationRateLimited)
+
+// channelRateLimiter 每个渠道一分钟滑动窗口限流，防止告警风暴刷屏
+type channelRateLimiter struct {
+	mu   sync.Mutex
+	sent map[uint][]time.Time
+}
+
+var channelLimiter = &channelRateLimiter{sent: map[uint][]time.Time{}}
+
+func (l *channelRateLimiter) allow(id uint, limit int, now time.Time) bool {
+	limit = normalizeChannelRate(limit)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	window := now.Add(-time.Minute)
+	recent := slices.DeleteFunc(l.sent[id], func(t time.Time) bool { return !t.After(window) })
+	if len(recent) >= limit {
+		l.sent[id] = recent
+		return false
+	}
+	l.sent[id] = append(recent, now)
+	return true
+}
+
+func normalizeChannelRate(rate int) int {
+	if rate <= 0 {
+		return notificationChannelDefaultRate
+	}
+	return rate
+}
+
+func trimChannelConfig(config map[string]string) map[string]string {
+	result := make(map[string]string, len(config))
+	for key, value := range config {
+		if value = strings.TrimSpace(value); value != "" {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func decodeChannelConfig(raw string) map[string]string {
+	config := map[string]string{}
+	if strings.TrimSpace(raw) != "" {
+		_ = json.Unmarshal([]byte(raw), &config)
+	}
+	return config
+}
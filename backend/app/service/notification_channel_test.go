@@ -0,0 +1,157 @@
+package service
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/utils/notify"
+
+	"github.com/sirupsen/logrus"
+)
+
+func openNotificationChannelDatabase(t *testing.T) {
+	t.Helper()
+	openSettingServiceDatabase(t)
+	if err := global.DB.AutoMigrate(&model.Notification{}, &model.NotificationChannel{}); err != nil {
+		t.Fatal(err)
+	}
+	previousLog, previousBackoff := global.LOG, notificationRetryBackoff
+	global.LOG = logrus.New()
+	notificationRetryBackoff = func(int) time.Duration { return time.Millisecond }
+	channelLimiter = &channelRateLimiter{sent: map[uint][]time.Time{}}
+	t.Cleanup(func() {
+		global.LOG = previousLog
+		notificationRetryBackoff = previousBackoff
+	})
+}
+
+func TestNotificationRoutedToWebhookWithRetry(t *testing.T) {
+	openNotificationChannelDatabase(t)
+	var calls atomic.Int32
+	delivered := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		delivered <- r.Header.Get(notify.HeaderSignature) + "|" + string(body)
+	}))
+	defer server.Close()
+
+	channels := NewINotificationChannelService()
+	if err := channels.Create(dto.NotificationChannelCreate{
+		Name: "ops-hook", Type: notify.TypeWebhook,
+		Config: map[string]string{"url": server.URL, "secret": "hook-secret"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := channels.List()
+	if err != nil || len(list) != 1 {
+		t.Fatalf("list = %#v, %v", list, err)
+	}
+	if _, leaked := list[0].Config["secret"]; leaked || len(list[0].SecretSet) != 1 {
+		t.Fatalf("secret exposed in list: %#v", list[0])
+	}
+
+	svc := NewINotificationService()
+	if err := svc.UpdatePreference(dto.NotificationPreference{
+		Defaults: dto.NotificationPreferenceRule{Center: true},
+		Events: map[string]dto.NotificationPreferenceRule{
+			"cronjob.failed": {Center: true, Channels: []uint{list[0].ID}},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	CreateNotification(dto.NotificationCreate{Type: "error", Event: "cronjob.failed", Title: "backup failed"})
+
+	select {
+	case got := <-delivered:
+		if got[:7] != "sha256=" {
+			t.Fatalf("delivery missing signature: %s", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification was not delivered")
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("calls = %d, want retry after first failure", calls.Load())
+	}
+	waitChannelStatus(t, list[0].ID, constant.StatusSuccess)
+}
+
+// waitChannelStatus 等待异步投递写回结果，避免测试结束后 goroutine 访问已关闭的数据库
+func waitChannelStatus(t *testing.T, id uint, status string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		channel, err := notificationChannelRepo.Get(repo.WithByID(id))
+		if err == nil && channel.LastStatus == status {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("channel %d status did not become %s", id, status)
+}
+
+func TestNotificationChannelUpdateKeepsSecretAndTestSend(t *testing.T) {
+	openNotificationChannelDatabase(t)
+	var lastQuery atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastQuery.Store(r.URL.RawQuery)
+		_, _ = w.Write([]byte(`{"errcode":0}`))
+	}))
+	defer server.Close()
+
+	channels := NewINotificationChannelService()
+	if err := channels.Create(dto.NotificationChannelCreate{
+		Name: "ding", Type: notify.TypeDingTalk,
+		Config: map[string]string{"webhook": server.URL + "/robot?access_token=abc"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	list, _ := channels.List()
+	if err := channels.Update(dto.NotificationChannelUpdate{
+		ID: list[0].ID, Name: "ding", Type: notify.TypeDingTalk, Status: constant.StatusEnable,
+		Config: map[string]string{"webhook": ""},
+	}); err != nil {
+		t.Fatalf("update with blank secret: %v", err)
+	}
+	if err := channels.Test(list[0].ID); err != nil {
+		t.Fatalf("test send: %v", err)
+	}
+	if q, _ := lastQuery.Load().(string); q != "access_token=abc" {
+		t.Fatalf("webhook secret lost on update, query = %q", q)
+	}
+	list, _ = channels.List()
+	if list[0].LastStatus != constant.StatusSuccess || list[0].LastSentAt == nil {
+		t.Fatalf("result not recorded: %#v", list[0])
+	}
+}
+
+func TestChannelRateLimiterWindow(t *testing.T) {
+	limiter := &channelRateLimiter{sent: map[uint][]time.Time{}}
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if !limiter.allow(1, 2, now) {
+			t.Fatalf("message %d limited", i)
+		}
+	}
+	if limiter.allow(1, 2, now.Add(time.Second)) {
+		t.Fatal("third message within a minute allowed")
+	}
+	if !limiter.allow(2, 2, now) {
+		t.Fatal("limit shared across channels")
+	}
+	if !limiter.allow(1, 2, now.Add(61*time.Second)) {
+		t.Fatal("window did not slide")
+	}
+}
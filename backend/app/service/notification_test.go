@@ -126,7 +126,7 @@ func TestSaveLoginLogCreatesSecurityNotification(t *testing.T) {
 	if err := global.DB.AutoMigrate(&model.LoginLog{}); err != nil {
 		t.Fatal(err)
 	}
-	SaveLoginLog("1.2.3.4", "test-agent", errors.New("invalid password"))
+	SaveLoginLog("admin", "1.2.3.4", "test-agent", errors.New("invalid password"))
 	items, err := NewINotificationService().Recent(10)
 	if err != nil {
 		t.Fatal(err)
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/utils/cmd"
+)
+
+const (
+	PHPFrameworkNone      = "none"
+	PHPFrameworkWordPress = "wordpress"
+	PHPFrameworkLaravel   = "laravel"
+	PHPFrameworkThinkPHP  = "thinkphp"
+
+	phpPoolPrefix = "xpanel-"
+)
+
+var phpPoolUserPattern = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// validatePHPSettings 运行用户、open_basedir 与上传大小会原样写入进程池和 nginx 配置，拒绝可能注入指令的取值
+func validatePHPSettings(poolUser, openBasedir, uploadMaxSize string) error {
+	invalid := func(format string, args ...interface{}) error {
+		return buserr.WithDetail(constant.ErrPHPSettingInvalid, fmt.Sprintf(format, args...), nil)
+	}
+	if poolUser != "" && !phpPoolUserPattern.MatchString(poolUser) {
+		return invalid("invalid pool user %q", poolUser)
+	}
+	if openBasedir != "" {
+		if strings.ContainsAny(openBasedir, ";{}\"\\") || strings.IndexFunc(openBasedir, unicode.IsControl) >= 0 {
+			return invalid("open_basedir %q contains forbidden characters", openBasedir)
+		}
+		for _, dir := range strings.Split(openBasedir, ":") {
+			if !filepath.IsAbs(dir) {
+				return invalid("open_basedir entry %q must be an absolute path", dir)
+			}
+		}
+	}
+	if uploadMaxSize != "" && !locationBodySize.MatchString(uploadMaxSize) {
+		return invalid("invalid upload size %q", uploadMaxSize)
+	}
+	return nil
+}
+
+// php-fpm 安装布局（Debian/Ubuntu：/etc/php/<ver>/fpm/pool.d + /usr/sbin/php-fpm<ver>），测试中可替换
+var (
+	phpFPMEtcDir    = "/etc/php"
+	phpFPMBinDir    = "/usr/sbin"
+	phpFPMSocketDir = "/run/php"
+	phpFPMCommand   = cmd.ExecWithOutput
+)
+
+// ListPHPVersions 检测已安装的 php-fpm 版本，新版本在前
+func ListPHPVersions() []dto.PHPVersionInfo {
+	dirs, _ := filepath.Glob(filepath.Join(phpFPMEtcDir, "*", "fpm", "pool.d"))
+	var versions []dto.PHPVersionInfo
+	for _, dir := range dirs {
+		version := filepath.Base(filepath.Dir(filepath.Dir(dir)))
+		binary := filepath.Join(phpFPMBinDir, "php-fpm"+version)
+		if _, err := os.Stat(binary); err != nil {
+			continue
+		}
+		versions = append(versions, dto.PHPVersionInfo{
+			Version: version,
+			Binary:  binary,
+			PoolDir: dir,
+			Service: "php" + version + "-fpm",
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return comparePHPVersion(versions[i].Version, versions[j].Version) > 0
+	})
+	return versions
+}
+
+func comparePHPVersion(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x > y {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+func findPHPRuntime(version string) (*dto.PHPVersionInfo, error) {
+	for _, runtime := range ListPHPVersions() {
+		if runtime.Version == version {
+			return &runtime, nil
+		}
+	}
+	return nil, buserr.WithDetail(constant.ErrPHPVersionNotFound, version, nil)
+}
+
+func phpPoolName(site model.Website) string {
+	return phpPoolPrefix + site.Alias
+}
+
+func phpPoolSocket(site model.Website) string {
+	return filepath.Join(phpFPMSocketDir, phpPoolName(site)+".sock")
+}
+
+func phpPoolPath(runtime *dto.PHPVersionInfo, site model.Website) string {
+	return filepath.Join(runtime.PoolDir, phpPoolName(site)+".conf")
+}
+
+// phpDocumentRoot Laravel/ThinkPHP 的入口在项目的 public 目录
+func phpDocumentRoot(site model.Website) string {
+	siteDir := site.SiteDir
+	if siteDir == "" {
+		siteDir = fmt.Sprintf("/var/www/%s", site.PrimaryDomain)
+	}
+	switch site.PHPFramework {
+	case PHPFrameworkLaravel, PHPFrameworkThinkPHP:
+		return filepath.Join(siteDir, "public")
+	}
+	return siteDir
+}
+
+func phpUploadMaxSize(site model.Website) string {
+	if site.PHPUploadMaxSize == "" {
+		return "64M"
+	}
+	return site.PHPUploadMaxSize
+}
+
+// firstExisting 返回第一个存在的系统用户（或组）
+func firstExisting(names []string, lookup func(string) error) string {
+	for _, name := range names {
+		if lookup(name) == nil {
+			return name
+		}
+	}
+	return names[0]
+}
+
+func defaultPHPPoolUser() string {
+	return firstExisting([]string{"www-data", "nginx", "nobody"}, func(name string) error {
+		_, err := user.Lookup(name)
+		return err
+	})
+}
+
+// nginxWorkerGroup socket 属组，需与 nginx worker 一致才能连接 0660 的 socket
+func nginxWorkerGroup() string {
+	candidates := []string{"nogroup", "nobody"}
+	if global.CONF.Nginx.IsSystemMode() {
+		candidates = []string{"www-data", "nginx"}
+	}
+	return firstExisting(candidates, func(name string) error {
+		_, err := user.LookupGroup(name)
+		return err
+	})
+}
+
+// renderPHPPool 生成站点专属的 php-fpm 进程池配置
+func renderPHPPool(site model.Website) string {
+	poolUser := site.PHPPoolUser
+	if poolUser == "" {
+		poolUser = defaultPHPPoolUser()
+	}
+	pm := site.PHPPM
+	if pm == "" {
+		pm = "dynamic"
+	}
+	orDefault := func(value, fallback int) int {
+		if value <= 0 {
+			return fallback
+		}
+		return value
+	}
+	siteDir := site.SiteDir
+	if siteDir == "" {
+		siteDir = fmt.Sprintf("/var/www/%s", site.PrimaryDomain)
+	}
+	openBasedir := site.PHPOpenBasedir
+	if openBasedir == "" {
+		openBasedir = siteDir + ":/tmp"
+	}
+	upload := phpUploadMaxSize(site)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "; Managed by xpanel for %s, changes will be overwritten\n", site.PrimaryDomain)
+	fmt.Fprintf(&b, "[%s]\n", phpPoolName(site))
+	fmt.Fprintf(&b, "user = %s\n", poolUser)
+	fmt.Fprintf(&b, "group = %s\n", poolUser)
+	fmt.Fprintf(&b, "listen = %s\n", phpPoolSocket(site))
+	fmt.Fprintf(&b, "listen.owner = %s\n", poolUser)
+	fmt.Fprintf(&b, "listen.group = %s\n", nginxWorkerGroup())
+	b.WriteString("listen.mode = 0660\n")
+	fmt.Fprintf(&b, "pm = %s\n", pm)
+	fmt.Fprintf(&b, "pm.max_children = %d\n", orDefault(site.PHPMaxChildren, 10))
+	switch pm {
+	case "dynamic":
+		fmt.Fprintf(&b, "pm.start_servers = %d\n", orDefault(site.PHPStartServers, 2))
+		fmt.Fprintf(&b, "pm.min_spare_servers = %d\n", orDefault(site.PHPMinSpareServers, 1))
+		fmt.Fprintf(&b, "pm.max_spare_servers = %d\n", orDefault(site.PHPMaxSpareServers, 3))
+	case "ondemand":
+		b.WriteString("pm.process_idle_timeout = 10s\n")
+	}
+	b.WriteString("pm.max_requests = 500\n")
+	fmt.Fprintf(&b, "chdir = %s\n", siteDir)
+	fmt.Fprintf(&b, "php_admin_value[open_basedir] = %s\n", openBasedir)
+	fmt.Fprintf(&b, "php_admin_value[upload_max_filesize] = %s\n", upload)
+	fmt.Fprintf(&b, "php_admin_value[post_max_size] = %s\n", upload)
+	return b.String()
+}
+
+// applyPHPPool 写入进程池并重载对应版本的 php-fpm；配置未变化时跳过，
+// 配置测试失败时回滚。
+func applyPHPPool(site model.Website) error {
+	runtime, err := findPHPRuntime(site.PHPVersion)
+	if err != nil {
+		return err
+	}
+	poolPath := phpPoolPath(runtime, site)
+	content := renderPHPPool(site)
+	previous, readErr := os.ReadFile(poolPath)
+	if readErr == nil && string(previous) == content {
+		return nil
+	}
+	if err := os.MkdirAll(phpFPMSocketDir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(poolPath, []byte(content), 0644); err != nil {
+		return buserr.WithDetail(constant.ErrPHPPoolApply, err.Error(), err)
+	}
+	if output, err := phpFPMCommand(runtime.Binary, "-t"); err != nil {
+		if readErr == nil {
+			_ = os.WriteFile(poolPath, previous, 0644)
+		} else {
+			_ = os.Remove(poolPath)
+		}
+		return buserr.WithDetail(constant.ErrPHPPoolApply, strings.TrimSpace(output+" "+err.Error()), err)
+	}
+	return reloadPHPFPM(runtime)
+}
+
+// removePHPPool 删除站点进程池；对应版本已卸载或进程池不存在时忽略
+func removePHPPool(site model.Website) error {
+	if site.Type != "php" || site.PHPVersion == "" {
+		return nil
+	}
+	runtime, err := findPHPRuntime(site.PHPVersion)
+	if err != nil {
+		return nil
+	}
+	if err := os.Remove(phpPoolPath(runtime, site)); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return reloadPHPFPM(runtime)
+}
+
+func reloadPHPFPM(runtime *dto.PHPVersionInfo) error {
+	if output, err := phpFPMCommand("systemctl", "reload", runtime.Service); err != nil {
+		return buserr.WithDetail(constant.ErrPHPPoolApply, strings.TrimSpace(output+" "+err.Error()), err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xpanel/app/model"
+	"xpanel/global"
+)
+
+// installFakePHPFPM 构造 Debian 布局的 php-fpm，返回记录的命令
+func installFakePHPFPM(t *testing.T, versions ...string) *[]string {
+	t.Helper()
+	root := t.TempDir()
+	previousEtc, previousBin, previousSocket, previousCommand := phpFPMEtcDir, phpFPMBinDir, phpFPMSocketDir, phpFPMCommand
+	phpFPMEtcDir = filepath.Join(root, "etc", "php")
+	phpFPMBinDir = filepath.Join(root, "sbin")
+	phpFPMSocketDir = filepath.Join(root, "run", "php")
+	t.Cleanup(func() {
+		phpFPMEtcDir, phpFPMBinDir, phpFPMSocketDir, phpFPMCommand = previousEtc, previousBin, previousSocket, previousCommand
+	})
+	if err := os.MkdirAll(phpFPMBinDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range versions {
+		if err := os.MkdirAll(filepath.Join(phpFPMEtcDir, version, "fpm", "pool.d"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(phpFPMBinDir, "php-fpm"+version), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	calls := &[]string{}
+	phpFPMCommand = func(name string, args ...string) (string, error) {
+		*calls = append(*calls, filepath.Base(name)+" "+strings.Join(args, " "))
+		return "", nil
+	}
+	return calls
+}
+
+func TestListPHPVersionsDetectsInstalledFPM(t *testing.T) {
+	installFakePHPFPM(t, "7.4", "8.10", "8.3")
+	// 只有配置目录、没有 php-fpm 二进制的版本不算安装
+	if err := os.MkdirAll(filepath.Join(phpFPMEtcDir, "5.6", "fpm", "pool.d"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, version := range ListPHPVersions() {
+		got = append(got, version.Version+"="+version.Service)
+	}
+	want := "8.10=php8.10-fpm 8.3=php8.3-fpm 7.4=php7.4-fpm"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("versions = %v, want %s", got, want)
+	}
+}
+
+func TestApplyPHPPoolWritesPoolAndReloads(t *testing.T) {
+	calls := installFakePHPFPM(t, "8.2")
+	site := model.Website{
+		PrimaryDomain: "blog.example.com", Alias: "blog_example_com", Type: "php", SiteDir: "/var/www/blog",
+		PHPVersion: "8.2", PHPPoolUser: "deploy", PHPPM: "ondemand", PHPMaxChildren: 4, PHPUploadMaxSize: "128M",
+	}
+	if err := applyPHPPool(site); err != nil {
+		t.Fatal(err)
+	}
+	poolPath := filepath.Join(phpFPMEtcDir, "8.2", "fpm", "pool.d", "xpanel-blog_example_com.conf")
+	content, err := os.ReadFile(poolPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"[xpanel-blog_example_com]",
+		"user = deploy",
+		"listen = " + filepath.Join(phpFPMSocketDir, "xpanel-blog_example_com.sock"),
+		"pm = ondemand",
+		"pm.max_children = 4",
+		"php_admin_value[open_basedir] = /var/www/blog:/tmp",
+		"php_admin_value[upload_max_filesize] = 128M",
+	} {
+		if !strings.Contains(string(content), line+"\n") {
+			t.Fatalf("pool config missing %q:\n%s", line, content)
+		}
+	}
+	if strings.Join(*calls, ";") != "php-fpm8.2 -t;systemctl reload php8.2-fpm" {
+		t.Fatalf("calls = %v, want config test before reload", *calls)
+	}
+
+	// 配置未变化时不重复重载
+	if err := applyPHPPool(site); err != nil {
+		t.Fatal(err)
+	}
+	if len(*calls) != 2 {
+		t.Fatalf("unchanged pool should not reload, calls = %v", *calls)
+	}
+
+	site.PHPMaxChildren = 8
+	phpFPMCommand = func(name string, args ...string) (string, error) {
+		return "ERROR: invalid pool", fmt.Errorf("exit status 78")
+	}
+	if err := applyPHPPool(site); err == nil {
+		t.Fatal("failed config test should return an error")
+	}
+	restored, _ := os.ReadFile(poolPath)
+	if string(restored) != string(content) {
+		t.Fatalf("pool config should be rolled back, got:\n%s", restored)
+	}
+
+	if _, err := findPHPRuntime("7.0"); err == nil {
+		t.Fatal("missing php version should be rejected")
+	}
+}
+
+func TestGeneratePHPSiteFrameworkPresets(t *testing.T) {
+	installFakePHPFPM(t, "8.3")
+	previousConf := global.CONF
+	global.CONF.Nginx = global.NginxConfig{InstallDir: t.TempDir(), Mode: "prefix"}
+	t.Cleanup(func() { global.CONF = previousConf })
+
+	site := model.Website{
+		PrimaryDomain: "shop.example.com", Alias: "shop_example_com", Type: "php", SiteDir: "/var/www/shop",
+		PHPVersion: "8.3", PHPFramework: PHPFrameworkLaravel, HttpConfig: "HTTPSRedirect",
+	}
+	config, err := NewNginxConfigGenerator().Generate(site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"root /var/www/shop/public;",
+		"index index.php index.html index.htm;",
+		"try_files $uri $uri/ /index.php?$query_string;",
+		"fastcgi_pass unix:" + filepath.Join(phpFPMSocketDir, "xpanel-shop_example_com.sock") + ";",
+		"client_max_body_size 64M;",
+	} {
+		if !strings.Contains(config, want) {
+			t.Fatalf("config missing %q:\n%s", want, config)
+		}
+	}
+
+	site.PHPFramework = PHPFrameworkWordPress
+	config, err = NewNginxConfigGenerator().Generate(site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploads := strings.Index(config, "/wp-content/uploads/")
+	fastcgi := strings.Index(config, "location ~ [^/]\\.php(/|$)")
+	if !strings.Contains(config, "root /var/www/shop;") || uploads < 0 || fastcgi < 0 || uploads > fastcgi {
+		t.Fatalf("wordpress preset should deny uploads php before the fastcgi location:\n%s", config)
+	}
+}
+
+func TestApplyPHPSettingsRejectsDirectiveInjection(t *testing.T) {
+	cases := []struct{ poolUser, openBasedir, upload string }{
+		{"www-data\nphp_admin_value[auto_prepend_file] = /tmp/x.php", "", ""},
+		{"root;", "", ""},
+		{"", "/var/www/shop:/tmp\nphp_admin_value[auto_prepend_file] = /tmp/x.php", ""},
+		{"", "/var/www/shop;", ""},
+		{"", "var/www/shop", ""},
+		{"", "", "64M; autoindex on"},
+	}
+	for _, tc := range cases {
+		var site model.Website
+		if err := applyPHPSettings(&site, "8.3", "", tc.poolUser, "", 0, 0, 0, 0, tc.openBasedir, tc.upload); err == nil {
+			t.Errorf("settings %+v should be rejected", tc)
+		}
+	}
+	var site model.Website
+	if err := applyPHPSettings(&site, "8.3", "", " www-data ", "", 0, 0, 0, 0, "/var/www/shop:/tmp", "128M"); err != nil {
+		t.Fatal(err)
+	}
+	if site.PHPPoolUser != "www-data" || site.PHPFramework != PHPFrameworkNone || site.PHPPM != "dynamic" {
+		t.Fatalf("applied settings: %+v", site)
+	}
+}
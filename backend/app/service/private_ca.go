@@ -0,0 +1,672 @@
+package service
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/google/uuid"
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	sslutil "xpanel/utils/ssl"
+)
+
+const (
+	certificateSourcePrivate = "private"
+	privateCertUsageServer   = "server"
+	privateCertUsageClient   = "client"
+
+	clientVerifyOff      = "off"
+	clientVerifyOn       = "on"
+	clientVerifyOptional = "optional"
+
+	defaultPrivateCertValidity = 365 * 24 * time.Hour
+)
+
+// privateCertNamePattern 内网主机名，允许单标签名称（如 db）与通配符
+var privateCertNamePattern = regexp.MustCompile(`^(\*\.)?([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)*[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+type IPrivateCAService interface {
+	List() ([]dto.PrivateCAInfo, error)
+	Create(req dto.PrivateCACreate) error
+	Update(req dto.PrivateCAUpdate) error
+	Delete(id uint) error
+	// Issue 由私有 CA 签发服务端或客户端证书，证书记录与 ACME/上传证书一样出现在证书管理中
+	Issue(req dto.PrivateCAIssue) (*dto.CertificateInfo, error)
+	// Export 导出私有 CA 签发的证书与私钥，返回文件内容与文件名
+	Export(req dto.PrivateCAExport) ([]byte, string, error)
+	PublishCRL(id uint) error
+	// GetCRL 返回 DER 编码的 CRL，供公开的 CRL 分发点使用
+	GetCRL(id uint) ([]byte, error)
+}
+
+type PrivateCAService struct {
+	caRepo      repo.ICertificateAuthorityRepo
+	certRepo    repo.ICertificateRepo
+	settingRepo repo.ISettingRepo
+}
+
+func NewIPrivateCAService() IPrivateCAService {
+	return &PrivateCAService{
+		caRepo:      repo.NewICertificateAuthorityRepo(),
+		certRepo:    repo.NewICertificateRepo(),
+		settingRepo: repo.NewISettingRepo(),
+	}
+}
+
+func (s *PrivateCAService) certService() *CertificateService {
+	return &CertificateService{certRepo: s.certRepo, settingRepo: s.settingRepo}
+}
+
+func (s *PrivateCAService) List() ([]dto.PrivateCAInfo, error) {
+	items, err := s.caRepo.GetList()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]dto.PrivateCAInfo, 0, len(items))
+	for _, item := range items {
+		var issued int64
+		global.DB.Model(&model.Certificate{}).Where("authority_id = ?", item.ID).Count(&issued)
+		result = append(result, dto.PrivateCAInfo{
+			ID:           item.ID,
+			Name:         item.Name,
+			ParentID:     item.ParentID,
+			CommonName:   item.CommonName,
+			Organization: item.Organization,
+			KeyType:      item.KeyType,
+			Pem:          item.Pem,
+			SerialNumber: item.SerialNumber,
+			Fingerprint:  item.Fingerprint,
+			NotBefore:    item.NotBefore,
+			NotAfter:     item.NotAfter,
+			CRLURL:       item.CRLURL,
+			CRLNumber:    item.CRLNumber,
+			CRLUpdatedAt: item.CRLUpdatedAt,
+			Description:  item.Description,
+			IssuedCount:  issued,
+			CreatedAt:    item.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// Create 生成根 CA，或由根 CA 签发中间 CA；中间 CA 证书的 CRL 分发点取自上级 CA
+func (s *PrivateCAService) Create(req dto.PrivateCACreate) error {
+	name := strings.TrimSpace(req.Name)
+	if exist, _ := s.caRepo.Get(repo.WithByName(name)); exist.ID > 0 {
+		return buserr.New(constant.ErrRecordExist)
+	}
+	caReq := sslutil.CARequest{
+		CommonName:   strings.TrimSpace(req.CommonName),
+		Organization: strings.TrimSpace(req.Organization),
+		KeyType:      req.KeyType,
+		Validity:     time.Duration(req.ValidityDays) * 24 * time.Hour,
+	}
+	var (
+		certPEM, keyPEM []byte
+		err             error
+	)
+	if req.ParentID > 0 {
+		parent, err := s.caRepo.Get(repo.WithByID(req.ParentID))
+		if err != nil {
+			return buserr.New(constant.ErrRecordNotFound)
+		}
+		if parent.ParentID != 0 {
+			return buserr.WithDetail(constant.ErrInvalidParams, "中间 CA 只能由根 CA 签发", nil)
+		}
+		caReq.CRLURL = parent.CRLURL
+		certPEM, keyPEM, err = sslutil.CreateIntermediateCA([]byte(parent.Pem), []byte(parent.PrivateKey), caReq)
+		if err != nil {
+			return buserr.WithDetail(constant.ErrPrivateCA, err.Error(), err)
+		}
+	} else {
+		certPEM, keyPEM, err = sslutil.CreateRootCA(caReq)
+		if err != nil {
+			return buserr.WithDetail(constant.ErrPrivateCA, err.Error(), err)
+		}
+	}
+	parsed, err := parseCertPEM(string(certPEM))
+	if err != nil {
+		return buserr.WithDetail(constant.ErrPrivateCA, err.Error(), err)
+	}
+
+	ca := model.CertificateAuthority{
+		Name:         name,
+		ParentID:     req.ParentID,
+		CommonName:   caReq.CommonName,
+		Organization: caReq.Organization,
+		KeyType:      req.KeyType,
+		Pem:          string(certPEM),
+		PrivateKey:   string(keyPEM),
+		SerialNumber: parsed.serialNumber,
+		Fingerprint:  parsed.fingerprint,
+		NotBefore:    parsed.startDate,
+		NotAfter:     parsed.expireDate,
+		CRLURL:       strings.TrimSpace(req.CRLURL),
+		Description:  req.Description,
+	}
+	if err := s.caRepo.Create(&ca); err != nil {
+		return buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	// CRL 与链文件必须在 CA 可被网站引用前写入，发布失败则撤销创建
+	if err := s.publish(ca, time.Now()); err != nil {
+		if delErr := s.caRepo.Delete(repo.WithByID(ca.ID)); delErr != nil {
+			global.LOG.Errorf("Remove private CA %s after publish failure failed: %v", ca.Name, delErr)
+		}
+		_ = os.RemoveAll(privateCADir(s.certService().GetSSLDir(), ca.ID))
+		return buserr.WithDetail(constant.ErrPrivateCA, err.Error(), err)
+	}
+	return nil
+}
+
+// Update 修改后的 CRL 地址只写入之后签发的证书
+func (s *PrivateCAService) Update(req dto.PrivateCAUpdate) error {
+	if _, err := s.caRepo.Get(repo.WithByID(req.ID)); err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	name := strings.TrimSpace(req.Name)
+	if exist, _ := s.caRepo.Get(repo.WithByName(name)); exist.ID > 0 && exist.ID != req.ID {
+		return buserr.New(constant.ErrRecordExist)
+	}
+	return s.caRepo.Update(req.ID, map[string]interface{}{
+		"name":        name,
+		"crl_url":     strings.TrimSpace(req.CRLURL),
+		"description": req.Description,
+	})
+}
+
+// Delete 仍有下级 CA、已签发证书或被网站用于客户端证书校验的 CA 不能删除
+func (s *PrivateCAService) Delete(id uint) error {
+	ca, err := s.caRepo.Get(repo.WithByID(id))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	if children, _ := s.caRepo.Count(repo.WithByParentID(id)); children > 0 {
+		return buserr.WithDetail(constant.ErrPrivateCAInUse, fmt.Sprintf("存在 %d 个中间 CA", children), nil)
+	}
+	var issued int64
+	if err := global.DB.Model(&model.Certificate{}).Where("authority_id = ?", id).Count(&issued).Error; err != nil {
+		return buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	if issued > 0 {
+		return buserr.WithDetail(constant.ErrPrivateCAInUse, fmt.Sprintf("仍有 %d 张已签发的证书", issued), nil)
+	}
+	var sites []model.Website
+	if err := global.DB.Select("primary_domain").Where("client_ca_id = ?", id).Limit(1).Find(&sites).Error; err != nil {
+		return buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	if len(sites) > 0 {
+		return buserr.WithDetail(constant.ErrPrivateCAInUse, "网站 "+sites[0].PrimaryDomain+" 用于校验客户端证书", nil)
+	}
+
+	if err := s.caRepo.DeleteRevocations(repo.WithByAuthorityID(id)); err != nil {
+		return buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	if err := s.caRepo.Delete(repo.WithByID(id)); err != nil {
+		return buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	if err := os.RemoveAll(privateCADir(s.certService().GetSSLDir(), ca.ID)); err != nil {
+		global.LOG.Warnf("Remove files of private CA %s failed: %v", ca.Name, err)
+	}
+	return nil
+}
+
+func (s *PrivateCAService) Issue(req dto.PrivateCAIssue) (*dto.CertificateInfo, error) {
+	ca, err := s.caRepo.Get(repo.WithByID(req.AuthorityID))
+	if err != nil {
+		return nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	commonName := strings.TrimSpace(req.CommonName)
+	if req.Usage == privateCertUsageServer {
+		commonName = strings.ToLower(commonName)
+	}
+	names, err := privateCertificateNames(req.Usage, commonName, req.Names)
+	if err != nil {
+		return nil, buserr.WithDetail(constant.ErrInvalidParams, err.Error(), err)
+	}
+
+	cert := model.Certificate{
+		LineageUID:    uuid.NewString(),
+		PrimaryDomain: commonName,
+		Domains:       strings.Join(names, ","),
+		Provider:      certificateSourcePrivate,
+		Type:          certificateSourcePrivate,
+		KeyType:       req.KeyType,
+		SourceType:    certificateSourcePrivate,
+		SourceName:    ca.Name,
+		AuthorityID:   ca.ID,
+		Usage:         req.Usage,
+		AutoRenew:     req.AutoRenew,
+		Status:        "applied",
+		Description:   req.Description,
+	}
+	issued, err := s.issue(ca, cert, time.Duration(req.ValidityDays)*24*time.Hour)
+	if err != nil {
+		return nil, buserr.WithDetail(constant.ErrPrivateCA, err.Error(), err)
+	}
+	cert.Pem = string(issued.Certificate)
+	cert.PrivateKey = string(issued.PrivateKey)
+	parsed, err := parseCertPEM(cert.Pem)
+	if err != nil {
+		return nil, buserr.WithDetail(constant.ErrPrivateCA, err.Error(), err)
+	}
+	applyParsedMetadata(&cert, parsed)
+	if err := s.certRepo.Create(&cert); err != nil {
+		return nil, buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	if err := s.certService().saveCertFiles(cert); err != nil {
+		global.LOG.Warnf("Save cert files failed: %v", err)
+	}
+	info := certificateToInfo(cert)
+	return &info, nil
+}
+
+// issue 按证书记录中的名称与用途签发，总是生成新私钥；证书链包含终端证书与中间 CA，不含根 CA
+func (s *PrivateCAService) issue(ca model.CertificateAuthority, cert model.Certificate, validity time.Duration) (*certificate.Resource, error) {
+	req := sslutil.LeafRequest{
+		CommonName: cert.PrimaryDomain,
+		Client:     cert.Usage == privateCertUsageClient,
+		KeyType:    cert.KeyType,
+		Validity:   validity,
+		CRLURL:     ca.CRLURL,
+	}
+	if !req.Client {
+		for _, name := range append([]string{cert.PrimaryDomain}, splitPrivateCertNames(cert.Domains)...) {
+			if ip := net.ParseIP(name); ip != nil {
+				req.IPAddresses = append(req.IPAddresses, ip)
+			} else {
+				req.DNSNames = append(req.DNSNames, name)
+			}
+		}
+	}
+	certPEM, keyPEM, err := sslutil.IssueCertificate([]byte(ca.Pem), []byte(ca.PrivateKey), req)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := s.authorityChain(ca)
+	if err != nil {
+		return nil, err
+	}
+	fullchain := bytes.NewBuffer(certPEM)
+	for _, item := range chain[:len(chain)-1] {
+		fullchain.WriteString(strings.TrimSpace(item.Pem) + "\n")
+	}
+	return &certificate.Resource{
+		Domain:      cert.PrimaryDomain,
+		Certificate: fullchain.Bytes(),
+		PrivateKey:  keyPEM,
+	}, nil
+}
+
+// reissuePrivateCertificate 续签私有 CA 签发的证书：沿用原名称、用途与有效期
+func reissuePrivateCertificate(cert model.Certificate) (*certificate.Resource, error) {
+	svc := NewIPrivateCAService().(*PrivateCAService)
+	ca, err := svc.caRepo.Get(repo.WithByID(cert.AuthorityID))
+	if err != nil {
+		return nil, fmt.Errorf("私有 CA 不存在")
+	}
+	validity := cert.ExpireDate.Sub(cert.StartDate).Round(24 * time.Hour)
+	if validity <= 0 {
+		validity = defaultPrivateCertValidity
+	}
+	return svc.issue(ca, cert, validity)
+}
+
+// revokeCertificate 记录吊销并立即重新发布 CRL，再重载启用了客户端证书校验的网站
+func (s *PrivateCAService) revokeCertificate(cert model.Certificate, reason int) error {
+	ca, err := s.caRepo.Get(repo.WithByID(cert.AuthorityID))
+	if err != nil {
+		return fmt.Errorf("私有 CA 不存在")
+	}
+	block, _ := pem.Decode([]byte(cert.Pem))
+	if block == nil {
+		return fmt.Errorf("invalid PEM")
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := s.caRepo.CreateRevocation(&model.CertificateAuthorityRevocation{
+		AuthorityID:   ca.ID,
+		CertificateID: cert.ID,
+		SerialNumber:  leaf.SerialNumber.String(),
+		Reason:        reason,
+		RevokedAt:     now,
+		NotAfter:      leaf.NotAfter,
+	}); err != nil {
+		return err
+	}
+	if err := s.publish(ca, now); err != nil {
+		return err
+	}
+	if err := reloadClientVerifyWebsites(); err != nil {
+		global.LOG.Warnf("Reload nginx after revoking %s failed: %v", cert.PrimaryDomain, err)
+	}
+	return nil
+}
+
+func (s *PrivateCAService) Export(req dto.PrivateCAExport) ([]byte, string, error) {
+	cert, err := s.certRepo.Get(repo.WithByID(req.ID))
+	if err != nil {
+		return nil, "", buserr.New(constant.ErrRecordNotFound)
+	}
+	if cert.SourceType != certificateSourcePrivate || strings.TrimSpace(cert.PrivateKey) == "" {
+		return nil, "", buserr.WithDetail(constant.ErrInvalidParams, "仅能导出私有 CA 签发的证书", nil)
+	}
+	name := safeDomainDir(strings.NewReplacer("/", "_", "\\", "_", " ", "_").Replace(cert.PrimaryDomain))
+	switch req.Format {
+	case sslutil.ExportFormatPKCS12:
+		if req.Password == "" {
+			return nil, "", buserr.WithDetail(constant.ErrInvalidParams, "PKCS#12 文件需要设置密码", nil)
+		}
+		data, err := sslutil.EncodePKCS12([]byte(cert.Pem), []byte(cert.PrivateKey), req.Password)
+		if err != nil {
+			return nil, "", buserr.WithDetail(constant.ErrPrivateCA, err.Error(), err)
+		}
+		return data, name + ".p12", nil
+	default:
+		return sslutil.BundlePEM([]byte(cert.Pem), []byte(cert.PrivateKey)), name + ".pem", nil
+	}
+}
+
+func (s *PrivateCAService) PublishCRL(id uint) error {
+	ca, err := s.caRepo.Get(repo.WithByID(id))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	if err := s.publish(ca, time.Now()); err != nil {
+		return buserr.WithDetail(constant.ErrPrivateCA, err.Error(), err)
+	}
+	if err := reloadClientVerifyWebsites(); err != nil {
+		return buserr.WithDetail(constant.ErrPrivateCA, err.Error(), err)
+	}
+	return nil
+}
+
+// GetCRL 返回已发布 CRL 的 DER 数据；CA 不存在或 CRL 尚未发布时返回 ErrPrivateCACRLNotFound
+func (s *PrivateCAService) GetCRL(id uint) ([]byte, error) {
+	ca, err := s.caRepo.Get(repo.WithByID(id))
+	if err != nil {
+		return nil, buserr.New(constant.ErrPrivateCACRLNotFound)
+	}
+	// 只返回已发布的 CRL，发布由创建 CA、吊销证书与定时任务完成
+	data, err := os.ReadFile(filepath.Join(privateCADir(s.certService().GetSSLDir(), ca.ID), "crl.pem"))
+	if os.IsNotExist(err) {
+		return nil, buserr.New(constant.ErrPrivateCACRLNotFound)
+	}
+	if err != nil {
+		return nil, buserr.WithDetail(constant.ErrPrivateCA, err.Error(), err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, buserr.WithDetail(constant.ErrPrivateCA, "invalid CRL file", nil)
+	}
+	return block.Bytes, nil
+}
+
+// --- CRL 与 nginx 使用的 CA 文件 ---
+//
+// 每个 CA 的文件位于 <SSLDir>/ca/ca-<id>/：
+//   - ca.pem：CA 证书
+//   - chain.pem：CA 至根 CA 的证书链，作为 ssl_client_certificate
+//   - crl.pem：CA 自己的 CRL
+//   - crl-chain.pem：链上每个 CA 的 CRL，nginx 启用 ssl_crl 后要求整条链都有 CRL
+
+func privateCADir(sslDir string, id uint) string {
+	return filepath.Join(sslDir, "ca", fmt.Sprintf("ca-%d", id))
+}
+
+// publish 生成新的 CRL 并刷新该 CA 及其中间 CA 的链文件
+func (s *PrivateCAService) publish(ca model.CertificateAuthority, now time.Time) error {
+	sslDir := s.certService().GetSSLDir()
+	dir := privateCADir(sslDir, ca.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	revocations, err := s.caRepo.ListActiveRevocations(ca.ID, now)
+	if err != nil {
+		return err
+	}
+	entries := make([]sslutil.RevokedEntry, 0, len(revocations))
+	for _, item := range revocations {
+		serial, ok := new(big.Int).SetString(item.SerialNumber, 10)
+		if !ok {
+			continue
+		}
+		entries = append(entries, sslutil.RevokedEntry{SerialNumber: serial, RevokedAt: item.RevokedAt, Reason: item.Reason})
+	}
+	number := ca.CRLNumber + 1
+	crl, err := sslutil.CreateCRL([]byte(ca.Pem), []byte(ca.PrivateKey), number, entries, now)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, "crl.pem"), crl, 0644); err != nil {
+		return err
+	}
+	if err := s.caRepo.Update(ca.ID, map[string]interface{}{"crl_number": number, "crl_updated_at": now}); err != nil {
+		return err
+	}
+
+	if ca.ParentID > 0 {
+		parentCRL := filepath.Join(privateCADir(sslDir, ca.ParentID), "crl.pem")
+		if _, err := os.Stat(parentCRL); os.IsNotExist(err) {
+			parent, err := s.caRepo.Get(repo.WithByID(ca.ParentID))
+			if err != nil {
+				return fmt.Errorf("上级 CA 不存在")
+			}
+			return s.publish(parent, now)
+		}
+	}
+	if err := s.writeAuthorityBundles(sslDir, ca); err != nil {
+		return err
+	}
+	children, err := s.caRepo.GetList(repo.WithByParentID(ca.ID))
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if _, err := os.Stat(filepath.Join(privateCADir(sslDir, child.ID), "crl.pem")); err != nil {
+			continue
+		}
+		if err := s.writeAuthorityBundles(sslDir, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *PrivateCAService) writeAuthorityBundles(sslDir string, ca model.CertificateAuthority) error {
+	chain, err := s.authorityChain(ca)
+	if err != nil {
+		return err
+	}
+	var certs, crls bytes.Buffer
+	for _, item := range chain {
+		certs.WriteString(strings.TrimSpace(item.Pem) + "\n")
+		crl, err := os.ReadFile(filepath.Join(privateCADir(sslDir, item.ID), "crl.pem"))
+		if err != nil {
+			return fmt.Errorf("read CRL of %s: %w", item.Name, err)
+		}
+		crls.Write(crl)
+	}
+	dir := privateCADir(sslDir, ca.ID)
+	files := map[string][]byte{
+		"ca.pem":        []byte(ca.Pem),
+		"chain.pem":     certs.Bytes(),
+		"crl-chain.pem": crls.Bytes(),
+	}
+	for name, data := range files {
+		if err := writeFileAtomic(filepath.Join(dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorityChain 返回从 ca 到根 CA 的链，ca 在前
+func (s *PrivateCAService) authorityChain(ca model.CertificateAuthority) ([]model.CertificateAuthority, error) {
+	chain := []model.CertificateAuthority{ca}
+	for current := ca; current.ParentID > 0; {
+		parent, err := s.caRepo.Get(repo.WithByID(current.ParentID))
+		if err != nil {
+			return nil, fmt.Errorf("上级 CA 不存在")
+		}
+		chain = append(chain, parent)
+		current = parent
+	}
+	return chain, nil
+}
+
+// privateCAClientFiles nginx 校验客户端证书所需的文件
+type privateCAClientFiles struct {
+	chainPath string
+	crlPath   string
+	depth     int
+}
+
+// clientVerifyFiles 返回校验 caID 所签发客户端证书需要的文件，只读取不发布，文件由 CA 创建、
+// 网站启用客户端证书校验、吊销与定时刷新生成
+func (s *PrivateCAService) clientVerifyFiles(caID uint) (*privateCAClientFiles, error) {
+	ca, err := s.caRepo.Get(repo.WithByID(caID))
+	if err != nil {
+		return nil, fmt.Errorf("私有 CA 不存在")
+	}
+	chain, err := s.authorityChain(ca)
+	if err != nil {
+		return nil, err
+	}
+	dir := privateCADir(s.certService().GetSSLDir(), ca.ID)
+	files := &privateCAClientFiles{
+		chainPath: filepath.Join(dir, "chain.pem"),
+		crlPath:   filepath.Join(dir, "crl-chain.pem"),
+		depth:     len(chain),
+	}
+	return files, nil
+}
+
+// ensurePublished 网站启用客户端证书校验前确保配置引用的链文件与 CRL 已经存在，缺失时立即发布
+func (s *PrivateCAService) ensurePublished(ca model.CertificateAuthority) error {
+	dir := privateCADir(s.certService().GetSSLDir(), ca.ID)
+	for _, name := range []string{"chain.pem", "crl-chain.pem"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return s.publish(ca, time.Now())
+		}
+	}
+	return nil
+}
+
+// RefreshPrivateCACRLs 定时任务：在 CRL 过期前重新发布所有私有 CA 的 CRL
+func RefreshPrivateCACRLs() {
+	svc := NewIPrivateCAService().(*PrivateCAService)
+	items, err := svc.caRepo.GetList()
+	if err != nil {
+		global.LOG.Warnf("[private-ca] Failed to list CAs: %v", err)
+		return
+	}
+	if len(items) == 0 {
+		return
+	}
+	now := time.Now()
+	for _, item := range items {
+		if err := svc.publish(item, now); err != nil {
+			global.LOG.Warnf("[private-ca] Failed to publish CRL of %s: %v", item.Name, err)
+		}
+	}
+	if err := reloadClientVerifyWebsites(); err != nil {
+		global.LOG.Warnf("[private-ca] Failed to reload nginx: %v", err)
+	}
+}
+
+// reloadClientVerifyWebsites CRL 更新后只有启用了客户端证书校验的网站需要重载 nginx
+func reloadClientVerifyWebsites() error {
+	var count int64
+	if err := global.DB.Model(&model.Website{}).
+		Where("ssl_enable = ? AND client_ca_id > 0 AND client_verify IN ?", true, []string{clientVerifyOn, clientVerifyOptional}).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+	return reloadNginxGlobal()
+}
+
+// normalizeWebsiteClientVerify 校验网站的客户端证书设置，关闭时清空 CA
+func normalizeWebsiteClientVerify(sslEnable bool, mode string, caID uint) (string, uint, error) {
+	if mode == "" || mode == clientVerifyOff {
+		return clientVerifyOff, 0, nil
+	}
+	if !sslEnable {
+		return "", 0, buserr.WithDetail(constant.ErrInvalidParams, "校验客户端证书需要先启用 HTTPS", nil)
+	}
+	if caID == 0 {
+		return "", 0, buserr.WithDetail(constant.ErrInvalidParams, "请选择签发客户端证书的私有 CA", nil)
+	}
+	ca, err := repo.NewICertificateAuthorityRepo().Get(repo.WithByID(caID))
+	if err != nil {
+		return "", 0, buserr.WithDetail(constant.ErrInvalidParams, "私有 CA 不存在", err)
+	}
+	if err := NewIPrivateCAService().(*PrivateCAService).ensurePublished(ca); err != nil {
+		return "", 0, buserr.WithDetail(constant.ErrPrivateCA, err.Error(), err)
+	}
+	return mode, caID, nil
+}
+
+// privateCertificateNames 校验并整理服务端证书的 DNS 名称与 IP，返回除 commonName 外的名称；
+// 客户端证书只使用 commonName 标识身份
+func privateCertificateNames(usage, commonName string, extra []string) ([]string, error) {
+	if commonName == "" || strings.ContainsAny(commonName, ",\r\n") {
+		return nil, fmt.Errorf("名称不合法: %q", commonName)
+	}
+	if usage == privateCertUsageClient {
+		if len(extra) > 0 {
+			return nil, fmt.Errorf("客户端证书不支持附加名称")
+		}
+		return nil, nil
+	}
+	seen := map[string]struct{}{}
+	var names []string
+	for i, name := range append([]string{commonName}, extra...) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if ip := net.ParseIP(name); ip != nil {
+			name = ip.String()
+		} else if !privateCertNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("名称不合法: %s", name)
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		if i > 0 {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func splitPrivateCertNames(domains string) []string {
+	var names []string
+	for _, name := range strings.Split(domains, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/security/credentials"
+)
+
+func setupPrivateCATest(t *testing.T) (*PrivateCAService, string) {
+	t.Helper()
+	svc := setupCertificateStatusTest(t)
+	if err := global.DB.AutoMigrate(&model.CertificateAuthority{}, &model.CertificateAuthorityRevocation{}); err != nil {
+		t.Fatal(err)
+	}
+	manager, _, err := credentials.LoadOrCreate(filepath.Join(t.TempDir(), "credential-keyring.json"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousCredentials := global.CREDENTIALS
+	global.CREDENTIALS = manager
+	t.Cleanup(func() { global.CREDENTIALS = previousCredentials })
+	return NewIPrivateCAService().(*PrivateCAService), svc.GetSSLDir()
+}
+
+func createTestAuthorities(t *testing.T, svc *PrivateCAService) (model.CertificateAuthority, model.CertificateAuthority) {
+	t.Helper()
+	if err := svc.Create(dto.PrivateCACreate{Name: "root", CommonName: "Internal Root", KeyType: "P256", ValidityDays: 3650,
+		CRLURL: "http://panel.internal/api/v1/pki/crl/1"}); err != nil {
+		t.Fatal(err)
+	}
+	root, _ := svc.caRepo.Get(repo.WithByName("root"))
+	if err := svc.Create(dto.PrivateCACreate{Name: "services", ParentID: root.ID, CommonName: "Internal Services", KeyType: "P256", ValidityDays: 1825}); err != nil {
+		t.Fatal(err)
+	}
+	inter, _ := svc.caRepo.Get(repo.WithByName("services"))
+	return root, inter
+}
+
+func parseServiceTestCertificates(t *testing.T, data string) []*x509.Certificate {
+	t.Helper()
+	var certs []*x509.Certificate
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return certs
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certs = append(certs, cert)
+	}
+}
+
+func TestPrivateCAIssueRenewAndRevoke(t *testing.T) {
+	svc, sslDir := setupPrivateCATest(t)
+	root, inter := createTestAuthorities(t, svc)
+	if inter.PrivateKey == "" || inter.CRLNumber != 1 {
+		t.Fatalf("intermediate CA should be stored with its key and an initial CRL: %+v", inter)
+	}
+	var stored string
+	global.DB.Raw("SELECT private_key FROM certificate_authorities WHERE id = ?", inter.ID).Scan(&stored)
+	if strings.Contains(stored, "PRIVATE KEY") {
+		t.Fatal("CA private key must be encrypted at rest")
+	}
+
+	info, err := svc.Issue(dto.PrivateCAIssue{AuthorityID: inter.ID, Usage: "server", CommonName: "API.internal",
+		Names: []string{"10.0.0.5", "api.internal", "grpc.internal"}, KeyType: "P256", ValidityDays: 90, AutoRenew: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := svc.certRepo.Get(repo.WithByID(info.ID))
+	if cert.SourceType != certificateSourcePrivate || cert.PrimaryDomain != "api.internal" || cert.Domains != "10.0.0.5,grpc.internal" {
+		t.Fatalf("issued certificate record: %+v", info)
+	}
+	chain := parseServiceTestCertificates(t, cert.Pem)
+	if len(chain) != 2 || chain[1].Subject.CommonName != "Internal Services" {
+		t.Fatalf("fullchain should carry the intermediate CA, got %d certificates", len(chain))
+	}
+	if len(chain[0].IPAddresses) != 1 || chain[0].CRLDistributionPoints != nil {
+		t.Fatalf("leaf names: %v %v", chain[0].IPAddresses, chain[0].CRLDistributionPoints)
+	}
+	if _, err := os.Stat(filepath.Join(certDirPath(sslDir, cert), "fullchain.pem")); err != nil {
+		t.Fatal(err)
+	}
+
+	certService := &CertificateService{certRepo: svc.certRepo, settingRepo: svc.settingRepo}
+	if err := certService.Renew(cert.ID); err != nil {
+		t.Fatal(err)
+	}
+	renewed, _ := svc.certRepo.Get(repo.WithByID(cert.ID))
+	if renewed.SerialNumber == cert.SerialNumber || renewed.Domains != cert.Domains || renewed.Status != "applied" {
+		t.Fatalf("renewal should reissue with the same names: %+v", renewed)
+	}
+
+	if err := certService.Revoke(dto.CertificateRevoke{ID: cert.ID, Reason: 1}); err != nil {
+		t.Fatal(err)
+	}
+	revoked, _ := svc.certRepo.Get(repo.WithByID(cert.ID))
+	if revoked.AutoRenew || revoked.RevocationStatus != "revoked" {
+		t.Fatalf("revoked certificate: %+v", revoked)
+	}
+	der, err := svc.GetCRL(inter.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(crl.RevokedCertificateEntries) != 1 || crl.RevokedCertificateEntries[0].SerialNumber.String() != renewed.SerialNumber ||
+		crl.Number.Int64() != 2 {
+		t.Fatalf("CRL after revocation: number %v, entries %+v", crl.Number, crl.RevokedCertificateEntries)
+	}
+	crlChain, _ := os.ReadFile(filepath.Join(privateCADir(sslDir, inter.ID), "crl-chain.pem"))
+	if strings.Count(string(crlChain), "BEGIN X509 CRL") != 2 {
+		t.Fatalf("CRL chain should hold the CRLs of the intermediate and root CA:\n%s", crlChain)
+	}
+	if updates := revocationUpdates(revoked, revoked.ExpireDate.Add(-1)); updates != nil {
+		t.Fatalf("private certificates should not be checked over OCSP/CRL: %v", updates)
+	}
+	if _, err := svc.Issue(dto.PrivateCAIssue{AuthorityID: root.ID, Usage: "server", CommonName: "bad name",
+		KeyType: "P256", ValidityDays: 30}); err == nil {
+		t.Fatal("invalid host name should be rejected")
+	}
+}
+
+func TestPrivateCADeleteRefusesWhileInUse(t *testing.T) {
+	svc, sslDir := setupPrivateCATest(t)
+	root, inter := createTestAuthorities(t, svc)
+	info, err := svc.Issue(dto.PrivateCAIssue{AuthorityID: inter.ID, Usage: "client", CommonName: "alice", KeyType: "P256", ValidityDays: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := global.DB.Create(&model.Website{PrimaryDomain: "mtls.example.com", Alias: "mtls", SSLEnable: true,
+		ClientVerify: clientVerifyOn, ClientCAID: inter.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []uint{root.ID, inter.ID} {
+		var bizErr buserr.BusinessError
+		if err := svc.Delete(id); !errors.As(err, &bizErr) || bizErr.Msg != constant.ErrPrivateCAInUse {
+			t.Fatalf("CA %d in use should not be deleted, got %v", id, err)
+		}
+	}
+	if err := global.DB.Where("client_ca_id = ?", inter.ID).Delete(&model.Website{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.certRepo.Delete(repo.WithByID(info.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Delete(inter.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(privateCADir(sslDir, inter.ID)); !os.IsNotExist(err) {
+		t.Fatalf("CA files should be removed, stat err = %v", err)
+	}
+}
+
+func TestNginxRendersClientCertificateVerification(t *testing.T) {
+	svc, sslDir := setupPrivateCATest(t)
+	_, inter := createTestAuthorities(t, svc)
+	previousConf := global.CONF
+	global.CONF.Nginx = global.NginxConfig{InstallDir: t.TempDir(), Mode: "prefix"}
+	t.Cleanup(func() { global.CONF = previousConf })
+
+	if _, _, err := normalizeWebsiteClientVerify(false, clientVerifyOn, inter.ID); err == nil {
+		t.Fatal("client verification without HTTPS should be rejected")
+	}
+	if _, _, err := normalizeWebsiteClientVerify(true, clientVerifyOn, inter.ID+10); err == nil {
+		t.Fatal("unknown CA should be rejected")
+	}
+	if mode, caID, err := normalizeWebsiteClientVerify(false, "", inter.ID); err != nil || mode != clientVerifyOff || caID != 0 {
+		t.Fatalf("disabled client verification should clear the CA: %q %d %v", mode, caID, err)
+	}
+
+	info, err := svc.Issue(dto.PrivateCAIssue{AuthorityID: inter.ID, Usage: "server", CommonName: "mtls.internal", KeyType: "P256", ValidityDays: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 生成配置与下载 CRL 都是只读操作，缺失的 CA 文件由定时任务重新发布
+	if err := os.RemoveAll(filepath.Join(sslDir, "ca")); err != nil {
+		t.Fatal(err)
+	}
+	before, err := svc.caRepo.Get(repo.WithByID(inter.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	site := model.Website{PrimaryDomain: "mtls.internal", Alias: "mtls_internal", Type: "proxy", ProxyPass: "http://127.0.0.1:8080",
+		SSLEnable: true, CertificateID: info.ID, HttpConfig: "httpsOnly", ClientVerify: clientVerifyOptional, ClientCAID: inter.ID}
+	config, err := NewNginxConfigGenerator().Generate(site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caDir := privateCADir(sslDir, inter.ID)
+	for _, want := range []string{
+		"ssl_client_certificate " + filepath.Join(caDir, "chain.pem") + ";",
+		"ssl_verify_client optional;",
+		"ssl_verify_depth 2;",
+		"ssl_crl " + filepath.Join(caDir, "crl-chain.pem") + ";",
+	} {
+		if !strings.Contains(config, want) {
+			t.Fatalf("config should contain %q:\n%s", want, config)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(caDir, "crl-chain.pem")); !os.IsNotExist(err) {
+		t.Fatalf("generating config should not publish the CRL: %v", err)
+	}
+	var bizErr buserr.BusinessError
+	if _, err := svc.GetCRL(inter.ID); !errors.As(err, &bizErr) || bizErr.Msg != constant.ErrPrivateCACRLNotFound {
+		t.Fatalf("unpublished CRL should not be found: %v", err)
+	}
+	after, err := svc.caRepo.Get(repo.WithByID(inter.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.CRLNumber != before.CRLNumber {
+		t.Fatalf("CRL number changed from %d to %d", before.CRLNumber, after.CRLNumber)
+	}
+
+	// 启用客户端证书校验时先写入配置引用的文件，不等定时任务发布
+	if _, _, err := normalizeWebsiteClientVerify(true, clientVerifyOptional, inter.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"chain.pem", "crl-chain.pem"} {
+		if _, err := os.Stat(filepath.Join(caDir, name)); err != nil {
+			t.Fatalf("enabling client verification should publish %s: %v", name, err)
+		}
+	}
+	if _, err := svc.GetCRL(inter.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	RefreshPrivateCACRLs()
+	if _, err := os.Stat(filepath.Join(caDir, "crl-chain.pem")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShortLivedPrivateCertificateRenewsAtTwoThirdsOfLifetime(t *testing.T) {
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	cert := model.Certificate{Type: certificateSourcePrivate, SourceType: certificateSourcePrivate, AutoRenew: true,
+		Pem: "pem", PrivateKey: "key", StartDate: now, ExpireDate: now.Add(9 * 24 * time.Hour)}
+	if shouldAutoRenewCertificate(cert, now.Add(time.Hour), certificateRenewBefore) {
+		t.Fatal("freshly issued short-lived certificate should not be due")
+	}
+	if shouldAutoRenewCertificate(cert, now.Add(5*24*time.Hour), certificateRenewBefore) {
+		t.Fatal("certificate should not renew before two thirds of its lifetime")
+	}
+	if !shouldAutoRenewCertificate(cert, now.Add(6*24*time.Hour), certificateRenewBefore) {
+		t.Fatal("certificate should renew after two thirds of its lifetime")
+	}
+	cert.ExpireDate = now.Add(90 * 24 * time.Hour)
+	if shouldAutoRenewCertificate(cert, now.Add(70*24*time.Hour), certificateRenewBefore) ||
+		!shouldAutoRenewCertificate(cert, now.Add(75*24*time.Hour), certificateRenewBefore) {
+		t.Fatal("long-lived private certificates keep the fixed renewal window")
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+
+	"github.com/google/uuid"
+)
+
+// sessionTouchInterval 最近活跃时间的最小写入间隔，避免每个请求都写库
+const sessionTouchInterval = time.Minute
+
+var sessionRepo = repo.NewISessionRepo()
+
+// ISessionService 当前账户的登录会话管理
+type ISessionService interface {
+	List(userName, currentID string) ([]dto.SessionInfo, error)
+	Revoke(userName string, id uint) error
+	RevokeAll(userName, keepID string) error
+}
+
+type SessionService struct{}
+
+func NewISessionService() ISessionService {
+	return &SessionService{}
+}
+
+func (s *SessionService) List(userName, currentID string) ([]dto.SessionInfo, error) {
+	sessions, err := sessionRepo.GetList(repo.WithByUserName(userName))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	items := make([]dto.SessionInfo, 0, len(sessions))
+	for _, item := range sessions {
+		if sessionExpired(item, now) {
+			continue
+		}
+		items = append(items, dto.SessionInfo{
+			ID:         item.ID,
+			IP:         item.IP,
+			Agent:      item.Agent,
+			Remember:   item.Remember,
+			Current:    item.TokenID == currentID,
+			CreatedAt:  item.CreatedAt,
+			LastSeenAt: item.LastSeenAt,
+			ExpiresAt:  item.ExpiresAt,
+		})
+	}
+	return items, nil
+}
+
+func (s *SessionService) Revoke(userName string, id uint) error {
+	if _, err := sessionRepo.Get(repo.WithByID(id), repo.WithByUserName(userName)); err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	return sessionRepo.Delete(repo.WithByID(id))
+}
+
+// RevokeAll 退出该账户的所有设备；keepID 非空时保留当前会话
+func (s *SessionService) RevokeAll(userName, keepID string) error {
+	opts := []repo.DBOption{repo.WithByUserName(userName)}
+	if keepID != "" {
+		opts = append(opts, repo.WithNotTokenID(keepID))
+	}
+	return sessionRepo.Delete(opts...)
+}
+
+// createSession 登记新会话并返回写入 JWT 的会话 ID
+func createSession(userName, ip, agent string, remember bool, timeout int) (string, error) {
+	now := time.Now()
+	if err := sessionRepo.Delete(repo.WithExpiredBefore(now)); err != nil {
+		global.LOG.Warnf("Failed to prune expired sessions: %v", err)
+	}
+	session := model.Session{
+		TokenID:    uuid.NewString(),
+		UserName:   userName,
+		IP:         ip,
+		Agent:      agent,
+		Remember:   remember,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Duration(timeout) * time.Second),
+	}
+	if err := sessionRepo.Create(&session); err != nil {
+		return "", err
+	}
+	return session.TokenID, nil
+}
+
+// ValidateSession 校验 Token 对应的会话仍然有效：未被吊销、未过期且未超过空闲时长
+func ValidateSession(tokenID, userName string) error {
+	if tokenID == "" {
+		return buserr.New(constant.ErrTokenInvalid)
+	}
+	session, err := sessionRepo.Get(repo.WithByTokenID(tokenID))
+	if err != nil || session.UserName != userName {
+		return buserr.New(constant.ErrTokenInvalid)
+	}
+	now := time.Now()
+	if sessionExpired(session, now) {
+		_ = sessionRepo.Delete(repo.WithByID(session.ID))
+		return buserr.New(constant.ErrTokenInvalid)
+	}
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := sessionRepo.Update(session.ID, map[string]interface{}{"last_seen_at": now}); err != nil {
+			global.LOG.Warnf("Failed to update session activity: %v", err)
+		}
+	}
+	return nil
+}
+
+// RevokeSession 吊销单个会话（退出登录）
+func RevokeSession(tokenID string) error {
+	if tokenID == "" {
+		return nil
+	}
+	return sessionRepo.Delete(repo.WithByTokenID(tokenID))
+}
+
+// RevokeUserSessions 吊销账户的全部会话，用于改密、禁用或删除账户
+func RevokeUserSessions(userName string) error {
+	return sessionRepo.Delete(repo.WithByUserName(userName))
+}
+
+// RevokeAllSessions 吊销所有账户的会话，用于凭据密钥轮换等安全事件
+func RevokeAllSessions() error {
+	return sessionRepo.Delete()
+}
+
+// sessionExpired 记住登录的会话仅受绝对有效期约束；普通会话额外受面板“会话超时”空闲时长约束
+func sessionExpired(session model.Session, now time.Time) bool {
+	if !now.Before(session.ExpiresAt) {
+		return true
+	}
+	if session.Remember {
+		return false
+	}
+	return now.Sub(session.LastSeenAt) > sessionIdleTimeout()
+}
+
+func sessionIdleTimeout() time.Duration {
+	return time.Duration(sessionTimeoutSeconds()) * time.Second
+}
+
+// sessionTimeoutSeconds 签发 Token 与校验空闲都以面板“会话超时”设置为准，未设置时回退到配置文件
+func sessionTimeoutSeconds() int {
+	value, _ := settingRepo.GetValueByKey("SessionTimeout")
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		seconds = global.CONF.System.SessionTimeout
+	}
+	if seconds <= 0 {
+		seconds = constant.DefaultSessionTimeout
+	}
+	return seconds
+}
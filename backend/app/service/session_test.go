@@ -0,0 +1,109 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/global"
+	jwtUtil "xpanel/utils/jwt"
+)
+
+func loginSession(t *testing.T, name, password string, remember bool) string {
+	t.Helper()
+	info, err := NewIAuthService().Login(dto.Login{Name: name, Password: password, Remember: remember, IP: "10.0.0.1", Agent: "test"})
+	if err != nil || info.Token == "" {
+		t.Fatalf("login %s = %#v, %v", name, info, err)
+	}
+	claims, err := jwtUtil.ParseToken(info.Token)
+	if err != nil || claims.ID == "" {
+		t.Fatalf("parse token = %#v, %v", claims, err)
+	}
+	if err := ValidateSession(claims.ID, name); err != nil {
+		t.Fatalf("fresh session rejected: %v", err)
+	}
+	return claims.ID
+}
+
+func TestSessionRevocationAndLogoutAllDevices(t *testing.T) {
+	openPanelUserDatabase(t)
+	sessions := NewISessionService()
+
+	first := loginSession(t, "admin", "admin-password", false)
+	second := loginSession(t, "admin", "admin-password", true)
+	if err := ValidateSession(first, "someone-else"); err == nil {
+		t.Fatal("session accepted for a different user")
+	}
+
+	items, err := sessions.List("admin", first)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("list sessions = %#v, %v", items, err)
+	}
+	var currentCount int
+	for _, item := range items {
+		if item.Current {
+			currentCount++
+		}
+	}
+	if currentCount != 1 {
+		t.Fatalf("current sessions = %d", currentCount)
+	}
+
+	if err := sessions.RevokeAll("admin", first); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateSession(second, "admin"); err == nil {
+		t.Fatal("other device still valid after logout-all")
+	}
+	if err := ValidateSession(first, "admin"); err != nil {
+		t.Fatalf("current device revoked: %v", err)
+	}
+	if err := RevokeSession(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateSession(first, "admin"); err == nil {
+		t.Fatal("session still valid after logout")
+	}
+}
+
+func TestSessionRevokedOnPasswordChange(t *testing.T) {
+	openPanelUserDatabase(t)
+	tokenID := loginSession(t, "admin", "admin-password", true)
+	if err := NewIAuthService().UpdatePassword("admin", dto.PasswordUpdate{
+		OldPassword: "admin-password", NewPassword: "admin-password-2",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateSession(tokenID, "admin"); err == nil {
+		t.Fatal("session survived password change")
+	}
+}
+
+func TestSessionIdleTimeout(t *testing.T) {
+	openPanelUserDatabase(t)
+	if err := settingRepo.CreateOrUpdateMany(map[string]string{"SessionTimeout": "600"}); err != nil {
+		t.Fatal(err)
+	}
+	short := loginSession(t, "admin", "admin-password", false)
+	remembered := loginSession(t, "admin", "admin-password", true)
+
+	var session model.Session
+	if err := global.DB.Where("token_id = ?", short).First(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+	if lifetime := session.ExpiresAt.Sub(session.LastSeenAt); lifetime > 11*time.Minute || lifetime < 9*time.Minute {
+		t.Fatalf("session lifetime should follow the SessionTimeout setting, got %s", lifetime)
+	}
+
+	idle := time.Now().Add(-time.Hour)
+	if err := global.DB.Model(&model.Session{}).Where("1 = 1").Update("last_seen_at", idle).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateSession(short, "admin"); err == nil {
+		t.Fatal("idle session still valid")
+	}
+	if err := ValidateSession(remembered, "admin"); err != nil {
+		t.Fatalf("remembered session expired by idle timeout: %v", err)
+	}
+}
@@ -94,6 +94,15 @@ func (s *SettingService) Update(req dto.SettingUpdate) error {
 	} else if credentials.IsSecretSetting(req.Key) && req.Value == "" {
 		return nil
 	}
+	if req.Key == "UserName" {
+		// 内置管理员不能与子账户重名，否则登录与权限解析会产生歧义
+		if strings.TrimSpace(req.Value) == "" {
+			return buserr.New(constant.ErrInvalidParams)
+		}
+		if _, err := userRepo.Get(repo.WithByName(req.Value)); err == nil {
+			return buserr.New(constant.ErrUserNameExist)
+		}
+	}
 
 	if err := settingRepo.Update(req.Key, req.Value); err != nil {
 		return err
@@ -39,6 +39,9 @@ func MapCertificateSourceType(cert *model.Certificate, bound bool) string {
 	if cert.Type == "upload" || cert.SourceType == "upload" {
 		return "upload"
 	}
+	if cert.SourceType == certificateSourcePrivate {
+		return certificateSourcePrivate
+	}
 	return "acme"
 }
 
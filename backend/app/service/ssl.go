@@ -40,6 +40,7 @@ type ICertificateService interface {
 	GetDetail(id uint) (*dto.CertificateDetail, error)
 	Apply(id uint) error
 	Renew(id uint) error
+	Revoke(req dto.CertificateRevoke) error
 	GetSSLDir() string
 	UpdateSSLDir(dir string) error
 	GetLog(id uint) (string, error)
@@ -64,6 +65,10 @@ func NewICertificateService() ICertificateService {
 }
 
 func (s *CertificateService) Create(req dto.CertificateCreate) error {
+	dnsAlias, err := certificateDNSAlias(req.Provider, req.DnsAlias)
+	if err != nil {
+		return err
+	}
 	cert := model.Certificate{
 		LineageUID:    uuid.NewString(),
 		PrimaryDomain: req.PrimaryDomain,
@@ -72,6 +77,7 @@ func (s *CertificateService) Create(req dto.CertificateCreate) error {
 		Type:          "autoApply",
 		AcmeAccountID: req.AcmeAccountID,
 		DnsAccountID:  req.DnsAccountID,
+		DnsAlias:      dnsAlias,
 		WebsiteID:     req.WebsiteID,
 		KeyType:       req.KeyType,
 		AutoRenew:     req.AutoRenew,
@@ -107,9 +113,35 @@ func (s *CertificateService) Update(req dto.CertificateUpdate) error {
 	if req.OtherDomains != "" {
 		updates["domains"] = req.OtherDomains
 	}
+	if req.DnsAlias != nil {
+		cert, err := s.certRepo.Get(repo.WithByID(req.ID))
+		if err != nil {
+			return buserr.New(constant.ErrRecordNotFound)
+		}
+		dnsAlias, err := certificateDNSAlias(cert.Provider, *req.DnsAlias)
+		if err != nil {
+			return err
+		}
+		updates["dns_alias"] = dnsAlias
+	}
 	return s.certRepo.Update(req.ID, updates)
 }
 
+// certificateDNSAlias 校验并整理别名域，别名模式只适用于 DNS 验证
+func certificateDNSAlias(provider, alias string) (string, error) {
+	alias = sslutil.NormalizeDNSAlias(alias)
+	if alias == "" {
+		return "", nil
+	}
+	if provider != "dns" {
+		return "", buserr.WithDetail(constant.ErrInvalidParams, "DNS 别名仅适用于 DNS 验证", nil)
+	}
+	if err := sslutil.ValidateDNSAlias(alias); err != nil {
+		return "", buserr.WithDetail(constant.ErrInvalidParams, err.Error(), err)
+	}
+	return alias, nil
+}
+
 func (s *CertificateService) Upload(req dto.CertificateUpload) error {
 	if _, err := tls.X509KeyPair([]byte(req.Certificate), []byte(req.PrivateKey)); err != nil {
 		return buserr.WithDetail(constant.ErrInvalidParams, "证书和私钥不匹配: "+err.Error(), err)
@@ -248,6 +280,9 @@ func (s *CertificateService) Apply(id uint) error {
 	if err != nil {
 		return buserr.New(constant.ErrRecordNotFound)
 	}
+	if cert.SourceType == certificateSourcePrivate {
+		return s.renew(id, certificateRenewalManual)
+	}
 
 	// 更新状态为 applying
 	s.certRepo.Update(id, map[string]interface{}{"status": "applying", "message": ""})
@@ -297,12 +332,16 @@ func (s *CertificateService) Apply(id uint) error {
 		}
 		logger.Printf("[信息] DNS 账户: %s (%s)", dns.Name, dns.Type)
 		logger.Printf("[信息] 正在配置 DNS 验证提供商...")
-		if err := client.SetDNSProvider(dns.Type, dns.Authorization); err != nil {
+		if err := client.SetDNSProvider(dns.Type, dns.Authorization, cert.DnsAlias); err != nil {
 			logger.Printf("[错误] DNS 提供商配置失败: %v", err)
 			s.certRepo.Update(id, map[string]interface{}{"status": "error", "message": err.Error()})
 			return err
 		}
 		logger.Printf("[成功] DNS 提供商配置完成")
+		if err := checkCertificateDNSAlias(cert, domains, logger); err != nil {
+			s.certRepo.Update(id, map[string]interface{}{"status": "error", "message": err.Error()})
+			return err
+		}
 	case "http":
 		logger.Printf("[信息] 正在配置 HTTP-01 验证提供商...")
 		if err := s.prepareHTTP01Website(cert); err != nil {
@@ -370,6 +409,7 @@ func (s *CertificateService) Apply(id uint) error {
 		dbUpdates["start_date"] = certInfo.startDate
 		addParsedMetadataUpdates(dbUpdates, certInfo)
 	}
+	addCertificateMonitorReset(dbUpdates)
 	if err := persistCertificateUpdate(s.certRepo.Update, id, dbUpdates); err != nil {
 		logger.Printf("[错误] 证书已写入磁盘，但数据库持久化失败: %v", err)
 		return err
@@ -396,6 +436,10 @@ func (s *CertificateService) Apply(id uint) error {
 		}
 	}
 
+	if count := startCertificateDeployments(id, certificateDeployApply); count > 0 {
+		logger.Printf("[信息] 正在后台推送到 %d 个部署目标", count)
+	}
+
 	logger.Printf("[完成] 证书申请流程结束")
 	global.LOG.Infof("Certificate applied successfully for: %s", cert.PrimaryDomain)
 	return nil
@@ -435,6 +479,17 @@ func (s *CertificateService) renew(id uint, trigger certificateRenewalTrigger) e
 	logger.Printf("[开始] 续签证书: %s", cert.PrimaryDomain)
 	s.certRepo.Update(id, map[string]interface{}{"status": "applying", "message": ""})
 
+	if cert.SourceType == certificateSourcePrivate {
+		logger.Printf("[信息] 由私有 CA %s 重新签发", cert.SourceName)
+		renewed, err := reissuePrivateCertificate(cert)
+		if err != nil {
+			logger.Printf("[错误] 私有 CA 签发失败: %v", err)
+			s.certRepo.Update(id, map[string]interface{}{"status": "error", "message": err.Error()})
+			return err
+		}
+		return s.completeCertificateRenewal(cert, renewed, trigger, logger)
+	}
+
 	acme, err := s.acmeRepo.Get(repo.WithByID(cert.AcmeAccountID))
 	if err != nil {
 		logger.Printf("[错误] ACME 账户不存在")
@@ -466,11 +521,15 @@ func (s *CertificateService) renew(id uint, trigger certificateRenewalTrigger) e
 			return fmt.Errorf("DNS account not found")
 		}
 		logger.Printf("[信息] DNS 账户: %s (%s)", dns.Name, dns.Type)
-		if err := client.SetDNSProvider(dns.Type, dns.Authorization); err != nil {
+		if err := client.SetDNSProvider(dns.Type, dns.Authorization, cert.DnsAlias); err != nil {
 			logger.Printf("[错误] DNS 提供商配置失败: %v", err)
 			s.certRepo.Update(id, map[string]interface{}{"status": "error", "message": err.Error()})
 			return err
 		}
+		if err := checkCertificateDNSAlias(cert, renewDomains, logger); err != nil {
+			s.certRepo.Update(id, map[string]interface{}{"status": "error", "message": err.Error()})
+			return err
+		}
 	case "http":
 		logger.Printf("[信息] 正在配置 HTTP-01 验证提供商...")
 		if err := s.prepareHTTP01Website(cert); err != nil {
@@ -509,7 +568,14 @@ func (s *CertificateService) renew(id uint, trigger certificateRenewalTrigger) e
 			PrivateKey:  []byte(cert.PrivateKey),
 		}
 	}
-	renewed, err := client.RenewCertificate(renewDomains, cert.KeyType, existingCertRes, renewLogWriter)
+	var renewed *certificate.Resource
+	if cert.RevocationStatus == sslutil.RevocationRevoked || cert.RenewalScheduledAt != nil {
+		// 被吊销的证书不能沿用旧私钥；支持 ARI 的 CA 需在订单中声明替换关系才按续签处理
+		logger.Printf("[信息] 使用新私钥重新签发并声明替换旧证书")
+		renewed, err = client.ReplaceCertificate(renewDomains, cert.KeyType, []byte(cert.Pem), renewLogWriter)
+	} else {
+		renewed, err = client.RenewCertificate(renewDomains, cert.KeyType, existingCertRes, renewLogWriter)
+	}
 	if err != nil {
 		logger.Printf("[ERROR] Renewal failed: %v", err)
 		s.certRepo.Update(id, map[string]interface{}{"status": "error", "message": "renewal failed: " + err.Error()})
@@ -517,6 +583,17 @@ func (s *CertificateService) renew(id uint, trigger certificateRenewalTrigger) e
 	}
 	logger.Printf("[OK] Certificate renewed successfully!")
 
+	return s.completeCertificateRenewal(cert, renewed, trigger, logger)
+}
+
+// completeCertificateRenewal 写入续签得到的新证书并持久化，随后重载 nginx、推送部署目标
+func (s *CertificateService) completeCertificateRenewal(
+	cert model.Certificate,
+	renewed *certificate.Resource,
+	trigger certificateRenewalTrigger,
+	logger *log.Logger,
+) error {
+	id := cert.ID
 	certInfo, _ := parseCertPEM(string(renewed.Certificate))
 	if certInfo != nil {
 		logger.Printf("[INFO] New cert validity: %s to %s", certInfo.startDate.Format("2006-01-02"), certInfo.expireDate.Format("2006-01-02"))
@@ -544,8 +621,14 @@ func (s *CertificateService) renew(id uint, trigger certificateRenewalTrigger) e
 		renewUpdates["expire_date"] = certInfo.expireDate
 		renewUpdates["start_date"] = certInfo.startDate
 		addParsedMetadataUpdates(renewUpdates, certInfo)
+		if cert.SourceType == certificateSourcePrivate {
+			// 私有证书的 IP 名称不在 DNSNames 中，名称以签发时的记录为准
+			delete(renewUpdates, "primary_domain")
+			delete(renewUpdates, "domains")
+		}
 	}
 	addRenewalCompletionMetadata(renewUpdates, trigger, time.Now())
+	addCertificateMonitorReset(renewUpdates)
 	if err := persistCertificateUpdate(s.certRepo.Update, id, renewUpdates); err != nil {
 		logger.Printf("[ERROR] Certificate files were updated, but database persistence failed: %v", err)
 		return err
@@ -561,6 +644,10 @@ func (s *CertificateService) renew(id uint, trigger certificateRenewalTrigger) e
 		}
 	}
 
+	if count := startCertificateDeployments(id, certificateDeployRenew); count > 0 {
+		logger.Printf("[信息] 正在后台推送到 %d 个部署目标", count)
+	}
+
 	logger.Printf("[完成] 证书续签流程结束")
 	global.LOG.Infof("Certificate renewed for: %s", cert.PrimaryDomain)
 	return nil
@@ -600,6 +687,22 @@ func collectCertificateDomains(cert model.Certificate) []string {
 	return domains
 }
 
+var checkDNSAlias = sslutil.CheckDNSAlias
+
+// checkCertificateDNSAlias 别名模式下申请前确认各域名的 _acme-challenge 已 CNAME 到别名记录
+func checkCertificateDNSAlias(cert model.Certificate, domains []string, logger *log.Logger) error {
+	if cert.DnsAlias == "" {
+		return nil
+	}
+	logger.Printf("[信息] DNS 别名模式：TXT 记录写入 _acme-challenge.%s，正在检查各域名的 CNAME...", cert.DnsAlias)
+	if err := checkDNSAlias(domains, cert.DnsAlias); err != nil {
+		logger.Printf("[错误] %v", err)
+		return err
+	}
+	logger.Printf("[成功] CNAME 检查通过")
+	return nil
+}
+
 func validateCertificateProvider(provider string, domains []string) error {
 	switch provider {
 	case "dns", "http":
@@ -972,6 +1075,7 @@ func certificateToInfo(c model.Certificate) dto.CertificateInfo {
 		Type:          c.Type,
 		AcmeAccountID: c.AcmeAccountID,
 		DnsAccountID:  c.DnsAccountID,
+		DnsAlias:      c.DnsAlias,
 		WebsiteID:     c.WebsiteID,
 		KeyType:       c.KeyType,
 		AutoRenew:     c.AutoRenew,
@@ -991,6 +1095,14 @@ func certificateToInfo(c model.Certificate) dto.CertificateInfo {
 		SourceName:    c.SourceName,
 		NotBefore:     c.StartDate,
 		NotAfter:      c.ExpireDate,
+
+		RenewalWindowStart:    c.RenewalWindowStart,
+		RenewalWindowEnd:      c.RenewalWindowEnd,
+		RenewalScheduledAt:    c.RenewalScheduledAt,
+		RenewalExplanationURL: c.RenewalExplanationURL,
+		RevocationStatus:      c.RevocationStatus,
+		RevokedAt:             c.RevokedAt,
+		RevocationCheckedAt:   c.RevocationCheckedAt,
 	}
 }
 
@@ -1026,7 +1138,30 @@ func acquireCertificateRenewal(id uint) (func(), error) {
 func shouldAutoRenewCertificate(cert model.Certificate, now time.Time, renewBefore time.Duration) bool {
 	return cert.AutoRenew && isRenewableCertificate(cert) &&
 		strings.TrimSpace(cert.Pem) != "" && strings.TrimSpace(cert.PrivateKey) != "" &&
-		!cert.ExpireDate.IsZero() && !cert.ExpireDate.After(now.Add(renewBefore))
+		!cert.ExpireDate.IsZero() && certificateRenewalDue(cert, now, renewBefore)
+}
+
+// certificateRenewalDue 已被吊销的证书立即续签；CA 通过 ARI 给出续签时间时以其为准，否则按到期前 renewBefore 续签
+func certificateRenewalDue(cert model.Certificate, now time.Time, renewBefore time.Duration) bool {
+	if cert.RevocationStatus == sslutil.RevocationRevoked {
+		return true
+	}
+	if cert.RenewalScheduledAt != nil {
+		return !cert.RenewalScheduledAt.After(now)
+	}
+	return !cert.ExpireDate.After(now.Add(certificateRenewWindow(cert, renewBefore)))
+}
+
+// certificateRenewWindow 私有 CA 证书的有效期可能短于固定续签窗口，此时在有效期过去 2/3 时续签，
+// 避免刚签发的证书立即再次到期续签
+func certificateRenewWindow(cert model.Certificate, renewBefore time.Duration) time.Duration {
+	if cert.SourceType != certificateSourcePrivate || cert.StartDate.IsZero() {
+		return renewBefore
+	}
+	if window := cert.ExpireDate.Sub(cert.StartDate) / 3; window > 0 && window < renewBefore {
+		return window
+	}
+	return renewBefore
 }
 
 // AutoRenewCerts 自动续期即将过期的证书（由 cron 调用）
@@ -1039,14 +1174,20 @@ func AutoRenewCerts() {
 	}
 
 	now := time.Now()
+	var due []model.Certificate
+	for _, cert := range certs {
+		if shouldAutoRenewCertificate(cert, now, certificateRenewBefore) {
+			due = append(due, cert)
+		}
+	}
+	certService.autoRenewCertificates(due)
+}
+
+func (s *CertificateService) autoRenewCertificates(certs []model.Certificate) {
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, 3) // 最多 3 个并发续签
 
 	for _, cert := range certs {
-		if !shouldAutoRenewCertificate(cert, now, certificateRenewBefore) {
-			continue
-		}
-
 		wg.Add(1)
 		sem <- struct{}{}
 		go func(c model.Certificate) {
@@ -1055,15 +1196,21 @@ func AutoRenewCerts() {
 
 			global.LOG.Infof("[auto-renew] Certificate %s (ID=%d) expires at %s, renewing...",
 				c.PrimaryDomain, c.ID, c.ExpireDate.Format("2006-01-02"))
-			if err := certService.renew(c.ID, certificateRenewalAuto); err != nil {
+			if err := s.renew(c.ID, certificateRenewalAuto); err != nil {
 				if errors.Is(err, errCertificateRenewalInProgress) {
 					global.LOG.Infof("[auto-renew] Certificate %d (%s) is already being renewed, skipping", c.ID, c.PrimaryDomain)
 					return
 				}
 				global.LOG.Errorf("[auto-renew] Failed to renew %s: %v", c.PrimaryDomain, err)
 				notifySSLRenewFailed(c.PrimaryDomain, err)
-			} else {
-				global.LOG.Infof("[auto-renew] Successfully renewed %s", c.PrimaryDomain)
+				return
+			}
+			global.LOG.Infof("[auto-renew] Successfully renewed %s", c.PrimaryDomain)
+			// 被吊销的证书须确保 HAProxy、GOST 等所有使用方都已换下旧证书
+			if c.RevocationStatus == sslutil.RevocationRevoked {
+				if err := refreshUpdatedCertificateConsumers([]uint{c.ID}); err != nil {
+					global.LOG.Warnf("[auto-renew] Refresh consumers of %s failed: %v", c.PrimaryDomain, err)
+				}
 			}
 		}(cert)
 	}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/utils/encrypt"
+
+	"gorm.io/gorm"
+)
+
+// IUserService 面板子账户管理
+type IUserService interface {
+	SearchWithPage(req dto.SearchWithPage) (int64, []dto.PanelUserInfo, error)
+	Create(req dto.UserCreate) error
+	Update(req dto.UserUpdate) error
+	Delete(id uint) error
+	ResetPassword(req dto.UserPasswordReset) error
+	ResetMFA(id uint) error
+}
+
+type UserService struct {
+	userRepo repo.IUserRepo
+}
+
+func NewIUserService() IUserService {
+	return &UserService{userRepo: repo.NewIUserRepo()}
+}
+
+func (s *UserService) SearchWithPage(req dto.SearchWithPage) (int64, []dto.PanelUserInfo, error) {
+	total, users, err := s.userRepo.Page(req.Page, req.PageSize, repo.WithLikeName(req.Info))
+	if err != nil {
+		return 0, nil, err
+	}
+	items := make([]dto.PanelUserInfo, 0, len(users))
+	for _, u := range users {
+		items = append(items, dto.PanelUserInfo{
+			ID:          u.ID,
+			Name:        u.Name,
+			Role:        u.Role,
+			Modules:     splitModules(u.Modules),
+			Status:      u.Status,
+			Description: u.Description,
+			MFAEnabled:  u.MFAStatus == constant.StatusEnable,
+			CreatedAt:   u.CreatedAt,
+		})
+	}
+	return total, items, nil
+}
+
+func (s *UserService) Create(req dto.UserCreate) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return buserr.New(constant.ErrInvalidParams)
+	}
+	if isBuiltinAdmin(name) {
+		return buserr.New(constant.ErrUserNameExist)
+	}
+	if _, err := s.userRepo.Get(repo.WithByName(name)); err == nil {
+		return buserr.New(constant.ErrUserNameExist)
+	}
+	modules, err := normalizeModules(req.Modules)
+	if err != nil {
+		return err
+	}
+	hashed, err := encrypt.HashPassword(req.Password)
+	if err != nil {
+		return buserr.WithErr(constant.ErrInternalServer, err)
+	}
+	user := model.User{
+		Name:        name,
+		Password:    hashed,
+		Role:        req.Role,
+		Modules:     modules,
+		Status:      constant.StatusEnable,
+		Description: req.Description,
+		MFAStatus:   constant.StatusDisable,
+	}
+	if err := s.userRepo.Create(&user); err != nil {
+		return buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	global.LOG.Infof("Panel user created: %s (%s)", name, req.Role)
+	return nil
+}
+
+func (s *UserService) Update(req dto.UserUpdate) error {
+	user, err := s.userRepo.Get(repo.WithByID(req.ID))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	modules, err := normalizeModules(req.Modules)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.Update(req.ID, map[string]interface{}{
+		"role":        req.Role,
+		"modules":     modules,
+		"status":      req.Status,
+		"description": req.Description,
+	}); err != nil {
+		return err
+	}
+	if req.Status != constant.StatusEnable {
+		return RevokeUserSessions(user.Name)
+	}
+	return nil
+}
+
+func (s *UserService) Delete(id uint) error {
+	user, err := s.userRepo.Get(repo.WithByID(id))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	if err := s.userRepo.Delete(repo.WithByID(id)); err != nil {
+		return err
+	}
+	return RevokeUserSessions(user.Name)
+}
+
+func (s *UserService) ResetPassword(req dto.UserPasswordReset) error {
+	user, err := s.userRepo.Get(repo.WithByID(req.ID))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	hashed, err := encrypt.HashPassword(req.Password)
+	if err != nil {
+		return buserr.WithErr(constant.ErrInternalServer, err)
+	}
+	if err := s.userRepo.Update(req.ID, map[string]interface{}{"password": hashed}); err != nil {
+		return err
+	}
+	return RevokeUserSessions(user.Name)
+}
+
+func (s *UserService) ResetMFA(id uint) error {
+	user, err := s.userRepo.Get(repo.WithByID(id))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	return ResetMFA(user.Name)
+}
+
+// ResolveAccount 按用户名解析账户及权限；子账户被删除或禁用时返回错误，使已签发的 Token 立即失效
+func ResolveAccount(name string) (*dto.AccountInfo, error) {
+	if name == "" {
+		return nil, buserr.New(constant.ErrUserNotFound)
+	}
+	if isBuiltinAdmin(name) {
+		return &dto.AccountInfo{Name: name, Role: constant.RoleAdmin, Builtin: true}, nil
+	}
+	user, err := userRepo.Get(repo.WithByName(name))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, buserr.New(constant.ErrUserNotFound)
+		}
+		return nil, err
+	}
+	if user.Status != constant.StatusEnable {
+		return nil, buserr.New(constant.ErrUserDisabled)
+	}
+	return &dto.AccountInfo{
+		Name:    user.Name,
+		Role:    user.Role,
+		Modules: splitModules(user.Modules),
+	}, nil
+}
+
+// isBuiltinAdmin 判断是否为 Setting 中保存的内置管理员
+func isBuiltinAdmin(name string) bool {
+	adminName, err := settingRepo.GetValueByKey("UserName")
+	return err == nil && adminName != "" && adminName == name
+}
+
+func normalizeModules(modules []string) (string, error) {
+	result := make([]string, 0, len(modules))
+	for _, module := range modules {
+		module = strings.TrimSpace(module)
+		if module == "" || slices.Contains(result, module) {
+			continue
+		}
+		if !slices.Contains(constant.BusinessModules, module) {
+			return "", buserr.WithDetail(constant.ErrInvalidParams, "unknown module: "+module, nil)
+		}
+		result = append(result, module)
+	}
+	return strings.Join(result, ","), nil
+}
+
+func splitModules(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
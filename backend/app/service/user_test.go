@@ -0,0 +1,106 @@
+package service
+
+import (
+	"testing"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/utils/encrypt"
+
+	"github.com/sirupsen/logrus"
+)
+
+func openPanelUserDatabase(t *testing.T) {
+	t.Helper()
+	openSettingServiceDatabase(t)
+	if err := global.DB.AutoMigrate(&model.User{}, &model.Session{}); err != nil {
+		t.Fatalf("migrate users: %v", err)
+	}
+	hashed, err := encrypt.HashPassword("admin-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.NewISettingRepo().CreateOrUpdateMany(map[string]string{
+		"UserName": "admin", "Password": hashed, "MFAStatus": constant.StatusDisable,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	previousSecret, previousLog := global.CONF.System.JwtSecret, global.LOG
+	global.CONF.System.JwtSecret = "panel-user-test-secret"
+	global.LOG = logrus.New()
+	t.Cleanup(func() {
+		global.CONF.System.JwtSecret = previousSecret
+		global.LOG = previousLog
+	})
+}
+
+func TestPanelUserLoginAndAccountResolution(t *testing.T) {
+	openPanelUserDatabase(t)
+	users := NewIUserService()
+	if err := users.Create(dto.UserCreate{
+		Name: "ops", Password: "ops-password", Role: constant.RoleOperator,
+		Modules: []string{constant.ModuleWebsite, constant.ModuleWebsite},
+	}); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if err := users.Create(dto.UserCreate{Name: "admin", Password: "whatever", Role: constant.RoleReadOnly}); err == nil {
+		t.Fatal("sub-user shadowing the built-in admin was accepted")
+	}
+	if err := users.Create(dto.UserCreate{
+		Name: "bad", Password: "whatever", Role: constant.RoleReadOnly, Modules: []string{"unknown"},
+	}); err == nil {
+		t.Fatal("unknown module was accepted")
+	}
+
+	info, err := NewIAuthService().Login(dto.Login{Name: "ops", Password: "ops-password"})
+	if err != nil || info.Token == "" || info.Name != "ops" {
+		t.Fatalf("sub-user login = %#v, %v", info, err)
+	}
+	account, err := ResolveAccount("ops")
+	if err != nil || account.Role != constant.RoleOperator || len(account.Modules) != 1 || account.Builtin {
+		t.Fatalf("resolve sub-user = %#v, %v", account, err)
+	}
+	admin, err := ResolveAccount("admin")
+	if err != nil || admin.Role != constant.RoleAdmin || !admin.Builtin {
+		t.Fatalf("resolve admin = %#v, %v", admin, err)
+	}
+
+	_, items, err := users.SearchWithPage(dto.SearchWithPage{PageInfo: dto.PageInfo{Page: 1, PageSize: 10}})
+	if err != nil || len(items) != 1 {
+		t.Fatalf("search users = %#v, %v", items, err)
+	}
+	if err := users.Update(dto.UserUpdate{
+		ID: items[0].ID, Role: constant.RoleOperator, Status: constant.StatusDisable,
+	}); err != nil {
+		t.Fatalf("disable user: %v", err)
+	}
+	if _, err := ResolveAccount("ops"); err == nil {
+		t.Fatal("disabled user still resolves")
+	}
+	if _, err := NewIAuthService().Login(dto.Login{Name: "ops", Password: "ops-password"}); err == nil {
+		t.Fatal("disabled user could log in")
+	}
+}
+
+func TestPanelUserPasswordChangeDoesNotTouchBuiltinAdmin(t *testing.T) {
+	openPanelUserDatabase(t)
+	if err := NewIUserService().Create(dto.UserCreate{
+		Name: "viewer", Password: "viewer-password", Role: constant.RoleReadOnly,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewIAuthService().UpdatePassword("viewer", dto.PasswordUpdate{
+		OldPassword: "viewer-password", NewPassword: "viewer-new-password",
+	}); err != nil {
+		t.Fatalf("update sub-user password: %v", err)
+	}
+	if _, err := NewIAuthService().Login(dto.Login{Name: "viewer", Password: "viewer-new-password"}); err != nil {
+		t.Fatalf("login with new password: %v", err)
+	}
+	if _, err := NewIAuthService().Login(dto.Login{Name: "admin", Password: "admin-password"}); err != nil {
+		t.Fatalf("built-in admin password changed: %v", err)
+	}
+}
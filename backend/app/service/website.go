@@ -31,12 +31,35 @@ type IWebsiteService interface {
 	CheckWebsiteCertificateHealthBatch(req dto.WebsiteCertificateHealthBatchReq) ([]dto.WebsiteCertificateHealthResp, error)
 	Update(req dto.WebsiteUpdate) error
 	Delete(id uint) error
+	Clone(req dto.WebsiteClone) (*dto.WebsiteCloneResult, error)
+	Promote(req dto.WebsitePromote) (*dto.WebsitePromoteResult, error)
+	GetDeploy(websiteID uint) (*dto.WebsiteDeployInfo, error)
+	SaveDeploy(req dto.WebsiteDeployConfig, admin bool) (*dto.WebsiteDeployInfo, error)
+	Deploy(websiteID uint) (*model.WebsiteDeployRecord, error)
+	TriggerDeployWebhook(websiteID uint, header http.Header, body []byte) (*model.WebsiteDeployRecord, error)
+	RollbackDeploy(req dto.WebsiteDeployRollback) (*model.WebsiteDeployRecord, error)
+	SearchDeployRecords(req dto.WebsiteDeployRecordSearch) (int64, []model.WebsiteDeployRecord, error)
+	CheckConfigDrift(id uint) (*dto.WebsiteConfigDriftInfo, error)
+	ResolveConfigDrift(req dto.WebsiteConfigDriftResolve) (*dto.WebsiteConfigDriftResolveResult, error)
 	SearchWithPage(req dto.WebsiteSearch) (int64, []dto.WebsiteInfo, error)
 	GetDetail(id uint) (*dto.WebsiteDetail, error)
 	Enable(id uint) error
 	Disable(id uint) error
 	GetNginxConfig(id uint) (string, error)
 	GetSiteLog(req dto.WebsiteLogReq) (string, error)
+	ListLocations(websiteID uint) ([]dto.WebsiteLocationItem, error)
+	SaveLocations(req dto.WebsiteLocationSave) error
+	ListUpstreams(websiteID uint) ([]dto.WebsiteUpstreamItem, error)
+	SaveUpstreams(req dto.WebsiteUpstreamSave) error
+	UpstreamHealth(websiteID uint) ([]dto.WebsiteUpstreamHealth, error)
+	ListWAFRuleSets() []dto.WebsiteWAFRuleSet
+	GetWAF(websiteID uint) (*dto.WebsiteWAFConfig, error)
+	SaveWAF(req dto.WebsiteWAFConfig) error
+	SearchWAFLog(req dto.WebsiteWAFLogSearch) (int64, []dto.WebsiteWAFLogItem, error)
+	BanWAFIP(req dto.WebsiteWAFBanReq) error
+	GetAccess(websiteID uint) (*dto.WebsiteAccessConfig, error)
+	SaveAccess(req dto.WebsiteAccessConfig) error
+	ListPHPVersions() ([]dto.PHPVersionInfo, error)
 
 	// Source-mode config editing
 	GetSiteConfContent(id uint) (*dto.SiteConfContentResp, error)
@@ -99,6 +122,12 @@ func (s *WebsiteService) Create(req dto.WebsiteCreate) error {
 		return buserr.WithDetail(constant.ErrRecordExist, "alias already exists: "+alias, nil)
 	}
 
+	if req.Type == "php" {
+		if _, err := findPHPRuntime(req.PHPVersion); err != nil {
+			return err
+		}
+	}
+
 	site := model.Website{
 		PrimaryDomain:     req.PrimaryDomain,
 		Domains:           req.Domains,
@@ -122,6 +151,14 @@ func (s *WebsiteService) Create(req dto.WebsiteCreate) error {
 		SecurityHeaders:   true,
 		StaticCacheEnable: false,
 	}
+	if site.Type == "php" {
+		if err := applyPHPSettings(&site, req.PHPVersion, req.PHPFramework, req.PHPPoolUser, req.PHPPM,
+			req.PHPMaxChildren, req.PHPStartServers, req.PHPMinSpareServers, req.PHPMaxSpareServers,
+			req.PHPOpenBasedir, req.PHPUploadMaxSize); err != nil {
+			return err
+		}
+		site.IndexFile = "index.php index.html index.htm"
+	}
 	if site.ConfigMode == "" {
 		site.ConfigMode = "managed"
 	}
@@ -129,7 +166,7 @@ func (s *WebsiteService) Create(req dto.WebsiteCreate) error {
 		site.Status = "running"
 	}
 
-	if site.Type == "static" && site.SiteDir == "" {
+	if (site.Type == "static" || site.Type == "php") && site.SiteDir == "" {
 		// 优先用 alias 作为目录名，更短且不含特殊字符
 		site.SiteDir = fmt.Sprintf("/var/www/%s", alias)
 	}
@@ -146,6 +183,14 @@ func (s *WebsiteService) Create(req dto.WebsiteCreate) error {
 			os.WriteFile(indexPath, []byte(defaultHTML), 0644)
 		}
 	}
+	if site.ConfigMode != "source" && site.Type == "php" && site.PHPFramework == PHPFrameworkNone {
+		os.MkdirAll(site.SiteDir, 0755)
+		indexPath := filepath.Join(site.SiteDir, "index.php")
+		if _, err := os.Stat(indexPath); os.IsNotExist(err) {
+			defaultPHP := fmt.Sprintf("<!DOCTYPE html>\n<html>\n<head><title>%s</title></head>\n<body>\n<h1>Welcome to %s</h1>\n<p>PHP <?php echo PHP_VERSION; ?> is working.</p>\n</body>\n</html>\n", site.PrimaryDomain, site.PrimaryDomain)
+			os.WriteFile(indexPath, []byte(defaultPHP), 0644)
+		}
+	}
 
 	global.LOG.Infof("Website created: %s (%s)", site.PrimaryDomain, site.Type)
 
@@ -189,6 +234,18 @@ func (s *WebsiteService) Update(req dto.WebsiteUpdate) error {
 		site.Alias = domainToAlias(req.PrimaryDomain)
 	}
 
+	previous := site
+	if site.Type == "php" {
+		if _, err := findPHPRuntime(req.PHPVersion); err != nil {
+			return err
+		}
+		if err := applyPHPSettings(&site, req.PHPVersion, req.PHPFramework, req.PHPPoolUser, req.PHPPM,
+			req.PHPMaxChildren, req.PHPStartServers, req.PHPMinSpareServers, req.PHPMaxSpareServers,
+			req.PHPOpenBasedir, req.PHPUploadMaxSize); err != nil {
+			return err
+		}
+	}
+
 	site.Domains = req.Domains
 	site.SiteDir = req.SiteDir
 	site.IndexFile = req.IndexFile
@@ -202,6 +259,10 @@ func (s *WebsiteService) Update(req dto.WebsiteUpdate) error {
 	site.HSTS = req.HSTS
 	site.Http2Enable = req.Http2Enable
 	site.SSLProtocols = req.SSLProtocols
+	site.ClientVerify, site.ClientCAID, err = normalizeWebsiteClientVerify(req.SSLEnable, req.ClientVerify, req.ClientCAID)
+	if err != nil {
+		return err
+	}
 	site.BasicAuth = req.BasicAuth
 	site.BasicUser = req.BasicUser
 	site.AntiLeech = req.AntiLeech
@@ -221,6 +282,14 @@ func (s *WebsiteService) Update(req dto.WebsiteUpdate) error {
 	site.CustomNginx = req.CustomNginx
 	site.DefaultServer = req.DefaultServer
 	site.Remark = req.Remark
+	if req.DatabaseID != nil {
+		if *req.DatabaseID > 0 && *req.DatabaseID != site.DatabaseID {
+			if _, err := repo.NewIDatabaseRepo().GetInstance(*req.DatabaseID); err != nil {
+				return buserr.New(constant.ErrRecordNotFound)
+			}
+		}
+		site.DatabaseID = *req.DatabaseID
+	}
 	if req.ConfigMode != "" {
 		site.ConfigMode = req.ConfigMode
 	}
@@ -238,6 +307,13 @@ func (s *WebsiteService) Update(req dto.WebsiteUpdate) error {
 		return buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
 	}
 
+	// 切换 PHP 版本或域名后旧进程池不再使用
+	if previous.Type == "php" && (previous.PHPVersion != site.PHPVersion || previous.Alias != site.Alias) {
+		if err := removePHPPool(previous); err != nil {
+			global.LOG.Warnf("Remove php-fpm pool of %s failed: %v", previous.PrimaryDomain, err)
+		}
+	}
+
 	// 如果网站是运行中的且为托管模式，自动重新生成配置并 reload
 	if site.Status == "running" && site.ConfigMode != "source" {
 		if err := s.applyConfig(site); err != nil {
@@ -255,6 +331,8 @@ func (s *WebsiteService) Delete(id uint) error {
 		return buserr.New(constant.ErrRecordNotFound)
 	}
 	if site.NginxConfPath != "" {
+		removeAccessStats(site)
+		removeLogRotatePolicy(site)
 		return s.websiteRepo.Delete(repo.WithByID(id))
 	}
 
@@ -278,6 +356,34 @@ func (s *WebsiteService) Delete(id uint) error {
 	if needReload {
 		s.reloadNginx()
 	}
+	if site.ConfigMode != "source" {
+		if err := removePHPPool(site); err != nil {
+			global.LOG.Warnf("Remove php-fpm pool of %s failed: %v", site.PrimaryDomain, err)
+		}
+	}
+	if err := repo.NewIWebsiteLocationRepo().DeleteByWebsite(site.ID); err != nil {
+		global.LOG.Warnf("Delete locations of %s failed: %v", site.PrimaryDomain, err)
+	}
+	if err := repo.NewIWebsiteUpstreamRepo().DeleteByWebsite(site.ID); err != nil {
+		global.LOG.Warnf("Delete upstreams of %s failed: %v", site.PrimaryDomain, err)
+	}
+	if err := repo.NewIWebsiteWAFRepo().DeleteByWebsite(site.ID); err != nil {
+		global.LOG.Warnf("Delete waf of %s failed: %v", site.PrimaryDomain, err)
+	}
+	removeWAFFiles(site)
+	if err := repo.NewIWebsiteAccessRepo().DeleteByWebsite(site.ID); err != nil {
+		global.LOG.Warnf("Delete access control of %s failed: %v", site.PrimaryDomain, err)
+	}
+	removeAccessFiles(site)
+	removeDeployData(site)
+	if err := repo.NewIWebsiteConfigDriftRepo().DeleteByWebsite(site.ID); err != nil {
+		global.LOG.Warnf("Delete config drift of %s failed: %v", site.PrimaryDomain, err)
+	}
+	removeAccessStats(site)
+	removeLogRotatePolicy(site)
+	if err := writeSharedZones(); err != nil {
+		global.LOG.Warnf("Write shared nginx zones failed: %v", err)
+	}
 
 	return s.websiteRepo.Delete(repo.WithByID(id))
 }
@@ -299,6 +405,7 @@ func (s *WebsiteService) SearchWithPage(req dto.WebsiteSearch) (int64, []dto.Web
 		return 0, nil, err
 	}
 
+	drifted := configDriftSites()
 	var items []dto.WebsiteInfo
 	for _, site := range sites {
 		configActive, configIssues := s.externalConfigState(site)
@@ -315,8 +422,10 @@ func (s *WebsiteService) SearchWithPage(req dto.WebsiteSearch) (int64, []dto.Web
 			Remark:                site.Remark,
 			SiteDir:               site.SiteDir,
 			NginxConfPath:         site.NginxConfPath,
+			StagingOf:             site.StagingOf,
 			ConfigActive:          configActive,
 			ConfigIssues:          configIssues,
+			ConfigDrift:           drifted[site.ID],
 			ConfiguredCertificate: configuredCertificate,
 			CreatedAt:             site.CreatedAt,
 		})
@@ -343,6 +452,16 @@ func (s *WebsiteService) GetDetail(id uint) (*dto.WebsiteDetail, error) {
 		IndexFile:             site.IndexFile,
 		HttpPort:              site.HttpPort,
 		HttpsPort:             site.HttpsPort,
+		PHPVersion:            site.PHPVersion,
+		PHPFramework:          site.PHPFramework,
+		PHPPoolUser:           site.PHPPoolUser,
+		PHPPM:                 site.PHPPM,
+		PHPMaxChildren:        site.PHPMaxChildren,
+		PHPStartServers:       site.PHPStartServers,
+		PHPMinSpareServers:    site.PHPMinSpareServers,
+		PHPMaxSpareServers:    site.PHPMaxSpareServers,
+		PHPOpenBasedir:        site.PHPOpenBasedir,
+		PHPUploadMaxSize:      site.PHPUploadMaxSize,
 		ProxyPass:             site.ProxyPass,
 		WebSocket:             site.WebSocket,
 		SSLEnable:             site.SSLEnable,
@@ -351,6 +470,8 @@ func (s *WebsiteService) GetDetail(id uint) (*dto.WebsiteDetail, error) {
 		HSTS:                  site.HSTS,
 		Http2Enable:           site.Http2Enable,
 		SSLProtocols:          site.SSLProtocols,
+		ClientVerify:          site.ClientVerify,
+		ClientCAID:            site.ClientCAID,
 		BasicAuth:             site.BasicAuth,
 		BasicUser:             site.BasicUser,
 		BasicPasswordSet:      site.BasicPassword != "",
@@ -371,6 +492,8 @@ func (s *WebsiteService) GetDetail(id uint) (*dto.WebsiteDetail, error) {
 		CustomNginx:           site.CustomNginx,
 		DefaultServer:         site.DefaultServer,
 		Remark:                site.Remark,
+		StagingOf:             site.StagingOf,
+		DatabaseID:            site.DatabaseID,
 		ConfigMode:            site.ConfigMode,
 		NginxConfPath:         site.NginxConfPath,
 		ConfigActive:          configActive,
@@ -457,6 +580,11 @@ func (s *WebsiteService) Disable(id uint) error {
 
 	s.removeConfig(site)
 	s.reloadNginx()
+	if site.ConfigMode != "source" {
+		if err := removePHPPool(site); err != nil {
+			global.LOG.Warnf("Remove php-fpm pool of %s failed: %v", site.PrimaryDomain, err)
+		}
+	}
 
 	site.Status = "stopped"
 	return s.websiteRepo.Save(&site)
@@ -520,6 +648,14 @@ func (s *WebsiteService) getWebsiteLogPath(site model.Website, logType string) s
 	return filepath.Join(logDir, site.PrimaryDomain+".access.log")
 }
 
+func (s *WebsiteService) ListPHPVersions() ([]dto.PHPVersionInfo, error) {
+	versions := ListPHPVersions()
+	if versions == nil {
+		versions = []dto.PHPVersionInfo{}
+	}
+	return versions, nil
+}
+
 // --- 源码模式配置编辑 ---
 
 func (s *WebsiteService) GetSiteConfContent(id uint) (*dto.SiteConfContentResp, error) {
@@ -1135,49 +1271,35 @@ func (s *WebsiteService) DetectLogPaths(id uint) (*dto.WebsiteLogPathDetectResp,
 	return resp, nil
 }
 
+// GetLogAlerts 根据后台采集的访问统计给出异常提示
 func (s *WebsiteService) GetLogAlerts(req dto.WebsiteLogAlertReq) ([]dto.WebsiteLogAlert, error) {
 	site, err := s.websiteRepo.Get(repo.WithByID(req.ID))
 	if err != nil {
 		return nil, buserr.New(constant.ErrRecordNotFound)
 	}
-	logPath := s.getWebsiteLogPath(site, "access")
-	cutoff := parseCutoff(req.TimeRange)
-	entries, err := parseAccessLog(logPath, cutoff, maxLinesForRange(req.TimeRange))
+	period, start, end := accessStatWindow(req.TimeRange)
+	stats, err := repo.NewIAccessStatRepo().ListStats(site.ID, period, start, end)
 	if err != nil {
-		return []dto.WebsiteLogAlert{}, nil
+		return nil, err
 	}
-	if len(entries) == 0 {
+	total := sumAccessStats(stats)
+	if total.Requests == 0 {
 		return []dto.WebsiteLogAlert{}, nil
 	}
 	var alerts []dto.WebsiteLogAlert
-	var errors, notFound, threats int64
-	ipCount := make(map[string]int64)
-	for _, e := range entries {
-		if e.Status >= 500 {
-			errors++
-		}
-		if e.Status == 404 {
-			notFound++
-		}
-		if classifyThreat(e.URL) != "" {
-			threats++
-		}
-		ipCount[e.IP]++
+	if float64(total.Status5xx)/float64(total.Requests) > 0.05 {
+		alerts = append(alerts, dto.WebsiteLogAlert{Level: "danger", Type: "5xx", Message: "5xx 错误比例超过 5%", Count: total.Status5xx})
 	}
-	total := int64(len(entries))
-	if float64(errors)/float64(total) > 0.05 {
-		alerts = append(alerts, dto.WebsiteLogAlert{Level: "danger", Type: "5xx", Message: "5xx 错误比例超过 5%", Count: errors})
+	if total.Status404 > 100 || float64(total.Status404)/float64(total.Requests) > 0.2 {
+		alerts = append(alerts, dto.WebsiteLogAlert{Level: "warning", Type: "404", Message: "404 请求偏多，可能存在资源缺失或扫描", Count: total.Status404})
 	}
-	if notFound > 100 || float64(notFound)/float64(total) > 0.2 {
-		alerts = append(alerts, dto.WebsiteLogAlert{Level: "warning", Type: "404", Message: "404 请求偏多，可能存在资源缺失或扫描", Count: notFound})
+	if total.Threats > 0 {
+		alerts = append(alerts, dto.WebsiteLogAlert{Level: "warning", Type: "threat", Message: "发现疑似恶意探测请求", Count: total.Threats})
 	}
-	if threats > 0 {
-		alerts = append(alerts, dto.WebsiteLogAlert{Level: "warning", Type: "threat", Message: "发现疑似恶意探测请求", Count: threats})
-	}
-	for ip, count := range ipCount {
-		if count > 1000 || float64(count)/float64(total) > 0.35 {
-			alerts = append(alerts, dto.WebsiteLogAlert{Level: "warning", Type: "hot_ip", Message: "单个 IP 请求占比过高: " + ip, Count: count})
-			break
+	// 排行按小时累计，范围不足一小时时按所在整点统计
+	if tops := accessTopItems(site.ID, "ip", start, end, 1); len(tops) > 0 {
+		if count := tops[0].Count; count > 1000 || float64(count)/float64(total.Requests) > 0.35 {
+			alerts = append(alerts, dto.WebsiteLogAlert{Level: "warning", Type: "hot_ip", Message: "单个 IP 请求占比过高: " + tops[0].Name, Count: count})
 		}
 	}
 	return alerts, nil
@@ -1186,6 +1308,17 @@ func (s *WebsiteService) GetLogAlerts(req dto.WebsiteLogAlertReq) ([]dto.Website
 // --- 内部方法 ---
 
 func (s *WebsiteService) applyConfig(site model.Website) error {
+	// 网站访问日志引用共享 zone 文件中的统计日志格式
+	if err := ensureSharedZonesInclude(); err != nil {
+		return err
+	}
+	// 先就绪进程池，nginx 重载后即可连接 socket
+	if site.Type == "php" {
+		if err := applyPHPPool(site); err != nil {
+			return err
+		}
+	}
+
 	gen := NewNginxConfigGenerator()
 	config, err := gen.Generate(site)
 	if err != nil {
@@ -1328,6 +1461,32 @@ func (s *WebsiteService) writeHtpasswd(site model.Website) {
 	os.WriteFile(htpasswdPath, []byte(line), 0644)
 }
 
+// applyPHPSettings 校验并写入 PHP 站点参数，空值回落为默认值
+func applyPHPSettings(site *model.Website, version, framework, poolUser, pm string,
+	maxChildren, startServers, minSpare, maxSpare int, openBasedir, uploadMaxSize string) error {
+	poolUser, openBasedir, uploadMaxSize = strings.TrimSpace(poolUser), strings.TrimSpace(openBasedir), strings.TrimSpace(uploadMaxSize)
+	if err := validatePHPSettings(poolUser, openBasedir, uploadMaxSize); err != nil {
+		return err
+	}
+	if framework == "" {
+		framework = PHPFrameworkNone
+	}
+	if pm == "" {
+		pm = "dynamic"
+	}
+	site.PHPVersion = version
+	site.PHPFramework = framework
+	site.PHPPoolUser = poolUser
+	site.PHPPM = pm
+	site.PHPMaxChildren = maxChildren
+	site.PHPStartServers = startServers
+	site.PHPMinSpareServers = minSpare
+	site.PHPMaxSpareServers = maxSpare
+	site.PHPOpenBasedir = openBasedir
+	site.PHPUploadMaxSize = uploadMaxSize
+	return nil
+}
+
 func domainToAlias(domain string) string {
 	alias := strings.ReplaceAll(domain, ".", "_")
 	alias = strings.ReplaceAll(alias, "*", "wildcard")
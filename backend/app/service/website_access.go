@@ -0,0 +1,444 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/utils/iplocation"
+)
+
+// geoPrivateNetworks 默认拒绝或国家白名单模式下始终放行的内网地址
+var geoPrivateNetworks = []string{"127.0.0.0/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "::1/128", "fc00::/7"}
+
+// geoCountryNetworks 解析国家代码对应的网段，测试中替换
+var geoCountryNetworks = func(codes []string) (map[string][]string, error) {
+	return iplocation.GetService().CountryNetworks(codes)
+}
+
+// countryGeoGroup geo 块中的一组取值：Networks 原样写入，Countries 展开为各国网段
+type countryGeoGroup struct {
+	Value     string
+	Networks  []string
+	Countries []string
+}
+
+// writeCountryGeoFile 生成供 geo 块 include 的网段文件，按分组顺序写入
+func writeCountryGeoFile(path string, groups ...countryGeoGroup) error {
+	var codes []string
+	for _, group := range groups {
+		codes = append(codes, group.Countries...)
+	}
+	networks, err := geoCountryNetworks(codes)
+	if err != nil {
+		return fmt.Errorf("resolve country networks: %v", err)
+	}
+	var b bytes.Buffer
+	for _, group := range groups {
+		for _, network := range group.Networks {
+			fmt.Fprintf(&b, "%s %s;\n", network, group.Value)
+		}
+		for _, code := range group.Countries {
+			for _, network := range networks[code] {
+				fmt.Fprintf(&b, "%s %s;\n", network, group.Value)
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b.Bytes(), 0644)
+}
+
+var accessPathUnsafe = regexp.MustCompile(`[\s";{}\\]`)
+
+func (s *WebsiteService) GetAccess(websiteID uint) (*dto.WebsiteAccessConfig, error) {
+	if _, err := s.websiteRepo.Get(repo.WithByID(websiteID)); err != nil {
+		return nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	access, err := repo.NewIWebsiteAccessRepo().GetByWebsite(websiteID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.WebsiteAccessConfig{
+		WebsiteID:       websiteID,
+		LimitReqEnable:  access.LimitReqEnable,
+		LimitReqRate:    access.LimitReqRate,
+		LimitReqUnit:    accessRateUnit(access.LimitReqUnit),
+		LimitReqBurst:   access.LimitReqBurst,
+		LimitReqNodelay: access.LimitReqNodelay,
+		LimitReqPaths:   parseAccessPaths(access.LimitReqPaths),
+		GeoEnable:       access.GeoEnable,
+		GeoDefault:      accessGeoDefault(access.GeoDefault),
+		GeoRules:        parseAccessGeoRules(access.GeoRules),
+	}, nil
+}
+
+// SaveAccess 保存网站的限速与地域访问控制，重新生成共享的 zone include；
+// 运行中的托管网站立即应用，配置测试失败时恢复原配置。
+func (s *WebsiteService) SaveAccess(req dto.WebsiteAccessConfig) error {
+	site, err := s.websiteRepo.Get(repo.WithByID(req.WebsiteID))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	if site.NginxConfPath != "" {
+		return buserr.New(constant.ErrWebsiteExternalOperationDenied)
+	}
+	accessRepo := repo.NewIWebsiteAccessRepo()
+	previous, err := accessRepo.GetByWebsite(site.ID)
+	if err != nil {
+		return err
+	}
+	access, err := buildWebsiteAccess(req)
+	if err != nil {
+		return err
+	}
+	access.ID = previous.ID
+	access.CreatedAt = previous.CreatedAt
+
+	countryPath := accessCountryFilePath(site)
+	previousCountry, readErr := os.ReadFile(countryPath)
+	if access.GeoEnable {
+		if err := writeAccessCountryFile(site, access); err != nil {
+			return err
+		}
+	}
+	restore := func() {
+		if previous.ID > 0 {
+			_ = accessRepo.Save(&previous)
+		} else {
+			_ = accessRepo.DeleteByWebsite(site.ID)
+		}
+		if readErr == nil {
+			_ = os.WriteFile(countryPath, previousCountry, 0644)
+		} else {
+			_ = os.Remove(countryPath)
+		}
+		_ = writeSharedZones()
+	}
+	if err := accessRepo.Save(&access); err != nil {
+		restore()
+		return buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	if err := writeSharedZones(); err != nil {
+		restore()
+		return buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	if site.Status != "running" || site.ConfigMode == "source" {
+		return nil
+	}
+	if err := EnsureNginxInclude(); err != nil {
+		global.LOG.Warnf("Ensure nginx include failed: %v", err)
+	}
+	if err := s.applyConfig(site); err != nil {
+		restore()
+		return err
+	}
+	return nil
+}
+
+func buildWebsiteAccess(req dto.WebsiteAccessConfig) (model.WebsiteAccess, error) {
+	access := model.WebsiteAccess{
+		WebsiteID:       req.WebsiteID,
+		LimitReqEnable:  req.LimitReqEnable,
+		LimitReqRate:    req.LimitReqRate,
+		LimitReqUnit:    accessRateUnit(req.LimitReqUnit),
+		LimitReqBurst:   req.LimitReqBurst,
+		LimitReqNodelay: req.LimitReqNodelay,
+		GeoEnable:       req.GeoEnable,
+		GeoDefault:      accessGeoDefault(req.GeoDefault),
+	}
+	if req.LimitReqEnable && req.LimitReqRate == 0 && len(req.LimitReqPaths) == 0 {
+		return access, accessInvalid("rate limiting needs a site rate or at least one path")
+	}
+	if err := validateAccessRate(req.LimitReqRate, access.LimitReqUnit, req.LimitReqBurst); err != nil {
+		return access, err
+	}
+
+	paths := make([]dto.WebsiteRateLimitPath, 0, len(req.LimitReqPaths))
+	seenPaths := make(map[string]bool, len(req.LimitReqPaths))
+	for _, item := range req.LimitReqPaths {
+		item.Path = strings.TrimSpace(item.Path)
+		item.Unit = accessRateUnit(item.Unit)
+		if !strings.HasPrefix(item.Path, "/") || accessPathUnsafe.MatchString(item.Path) {
+			return access, accessInvalid("invalid path %q", item.Path)
+		}
+		if seenPaths[item.Path] {
+			return access, accessInvalid("duplicate path %s", item.Path)
+		}
+		seenPaths[item.Path] = true
+		if item.Rate < 1 {
+			return access, accessInvalid("rate of %s must be positive", item.Path)
+		}
+		if err := validateAccessRate(item.Rate, item.Unit, item.Burst); err != nil {
+			return access, err
+		}
+		paths = append(paths, item)
+	}
+
+	rules := make([]dto.WebsiteGeoRule, 0, len(req.GeoRules))
+	seenRules := make(map[string]bool, len(req.GeoRules))
+	for _, rule := range req.GeoRules {
+		rule.Value = strings.TrimSpace(rule.Value)
+		switch rule.Type {
+		case "cidr":
+			if _, _, err := net.ParseCIDR(rule.Value); err != nil && net.ParseIP(rule.Value) == nil {
+				return access, accessInvalid("invalid ip %q", rule.Value)
+			}
+		case "country":
+			rule.Value = strings.ToUpper(rule.Value)
+			if !wafCountryCode.MatchString(rule.Value) {
+				return access, accessInvalid("invalid country code %q", rule.Value)
+			}
+		default:
+			return access, accessInvalid("unknown rule type %s", rule.Type)
+		}
+		if rule.Action != "allow" && rule.Action != "deny" {
+			return access, accessInvalid("unknown action %s", rule.Action)
+		}
+		if seenRules[rule.Value] {
+			return access, accessInvalid("duplicate rule %s", rule.Value)
+		}
+		seenRules[rule.Value] = true
+		rules = append(rules, rule)
+	}
+
+	pathsJSON, _ := json.Marshal(paths)
+	rulesJSON, _ := json.Marshal(rules)
+	access.LimitReqPaths = string(pathsJSON)
+	access.GeoRules = string(rulesJSON)
+	return access, nil
+}
+
+func validateAccessRate(rate int, unit string, burst int) error {
+	if rate < 0 || rate > 100000 {
+		return accessInvalid("rate must be between 1 and 100000")
+	}
+	if unit != "r/s" && unit != "r/m" {
+		return accessInvalid("unknown rate unit %s", unit)
+	}
+	if burst < 0 || burst > 100000 {
+		return accessInvalid("burst must be between 0 and 100000")
+	}
+	return nil
+}
+
+func accessInvalid(format string, args ...interface{}) error {
+	return buserr.WithDetail(constant.ErrWebsiteAccessInvalid, fmt.Sprintf(format, args...), nil)
+}
+
+func accessRateUnit(unit string) string {
+	if unit == "" {
+		return "r/s"
+	}
+	return unit
+}
+
+func accessGeoDefault(action string) string {
+	if action == "" {
+		return "allow"
+	}
+	return action
+}
+
+func parseAccessPaths(raw string) []dto.WebsiteRateLimitPath {
+	paths := []dto.WebsiteRateLimitPath{}
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &paths)
+	}
+	return paths
+}
+
+func parseAccessGeoRules(raw string) []dto.WebsiteGeoRule {
+	rules := []dto.WebsiteGeoRule{}
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &rules)
+	}
+	return rules
+}
+
+// accessVar 网站访问控制的变量与 zone 前缀，按 ID 命名以免别名变更后共享 include 失效
+func accessVar(websiteID uint) string {
+	return fmt.Sprintf("xpanel_access_%d", websiteID)
+}
+
+func accessCountryFilePath(site model.Website) string {
+	return filepath.Join(global.CONF.Nginx.GetConfDir(), "access", fmt.Sprintf("%d.country.conf", site.ID))
+}
+
+func writeAccessCountryFile(site model.Website, access model.WebsiteAccess) error {
+	allow := countryGeoGroup{Value: "1"}
+	deny := countryGeoGroup{Value: "0"}
+	if access.GeoDefault == "deny" {
+		allow.Networks = geoPrivateNetworks
+	}
+	for _, rule := range parseAccessGeoRules(access.GeoRules) {
+		if rule.Type != "country" {
+			continue
+		}
+		if rule.Action == "allow" {
+			allow.Countries = append(allow.Countries, rule.Value)
+		} else {
+			deny.Countries = append(deny.Countries, rule.Value)
+		}
+	}
+	if err := writeCountryGeoFile(accessCountryFilePath(site), allow, deny); err != nil {
+		return accessInvalid("%v", err)
+	}
+	return nil
+}
+
+func removeAccessFiles(site model.Website) {
+	_ = os.Remove(accessCountryFilePath(site))
+}
+
+// SharedZonesPath 面板共享的 http 级 include，集中存放各网站的 limit_req_zone
+func SharedZonesPath() string {
+	return filepath.Join(global.CONF.Nginx.GetConfDir(), "xpanel-zones.conf")
+}
+
+// writeSharedZones 按数据库中的访问控制配置重新生成共享 zone 文件
+func writeSharedZones() error {
+	items, err := repo.NewIWebsiteAccessRepo().List()
+	if err != nil {
+		return err
+	}
+	content := renderSharedZones(items, !nginxDefinesZone("perip"))
+	path := SharedZonesPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// renderSharedZones 生成共享 zone 文件。文件被 nginx.conf 直接 include，
+// 只能引用内置变量或文件内定义的变量，不能依赖网站配置（网站可能已停用）。
+func renderSharedZones(items []model.WebsiteAccess, definePerip bool) string {
+	var b strings.Builder
+	b.WriteString("# Managed by xpanel, do not edit.\n")
+	// 网站访问日志使用的格式：combined 末尾追加 upstream 耗时与请求耗时，供访问统计采集
+	b.WriteString(accessStatsLogFormatDirective + "\n")
+	if definePerip {
+		// 网站的 limit_conn perip 使用此 zone
+		b.WriteString("limit_conn_zone $binary_remote_addr zone=perip:10m;\n")
+	}
+	for _, access := range items {
+		if !access.LimitReqEnable {
+			continue
+		}
+		v := accessVar(access.WebsiteID)
+		paths := parseAccessPaths(access.LimitReqPaths)
+		fmt.Fprintf(&b, "\n# website %d\n", access.WebsiteID)
+		if access.LimitReqRate > 0 {
+			key := "$binary_remote_addr"
+			if len(paths) > 0 {
+				// 单独限速的路径不再计入全站限速
+				key = "$" + v + "_key"
+				fmt.Fprintf(&b, "map $uri %s {\n    default $binary_remote_addr;\n", key)
+				for _, path := range paths {
+					fmt.Fprintf(&b, "    \"~^%s\" \"\";\n", regexp.QuoteMeta(path.Path))
+				}
+				b.WriteString("}\n")
+			}
+			fmt.Fprintf(&b, "limit_req_zone %s zone=%s:10m rate=%d%s;\n", key, v, access.LimitReqRate, accessRateUnit(access.LimitReqUnit))
+		}
+		for i, path := range paths {
+			zone := fmt.Sprintf("%s_p%d", v, i+1)
+			fmt.Fprintf(&b, "map $uri $%s_key {\n    default \"\";\n    \"~^%s\" $binary_remote_addr;\n}\n", zone, regexp.QuoteMeta(path.Path))
+			fmt.Fprintf(&b, "limit_req_zone $%s_key zone=%s:10m rate=%d%s;\n", zone, zone, path.Rate, accessRateUnit(path.Unit))
+		}
+	}
+	return b.String()
+}
+
+// nginxDefinesZone 检查主配置与 conf.d 是否已自行定义同名 zone，避免重复定义
+func nginxDefinesZone(name string) bool {
+	nc := global.CONF.Nginx
+	files := []string{nc.GetMainConf()}
+	if matches, err := filepath.Glob(filepath.Join(nc.GetConfDir(), "conf.d", "*.conf")); err == nil {
+		files = append(files, matches...)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err == nil && strings.Contains(string(data), "zone="+name+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// renderAccessHTTP 生成地域访问控制的 geo 与判定 map；ACME 验证始终放行
+func renderAccessHTTP(site model.Website, access model.WebsiteAccess) string {
+	v := accessVar(site.ID)
+	rules := parseAccessGeoRules(access.GeoRules)
+	var b strings.Builder
+	b.WriteString("# Access control\n")
+	fallback := "1"
+	if access.GeoDefault == "deny" {
+		fallback = "0"
+	}
+	fmt.Fprintf(&b, "geo $%s_geo {\n    default %s;\n", v, fallback)
+	for _, rule := range rules {
+		if rule.Type == "country" {
+			fmt.Fprintf(&b, "    include %s;\n", accessCountryFilePath(site))
+			break
+		}
+	}
+	// 手动网段写在国家网段之后，同一网段以手动规则为准
+	for _, rule := range rules {
+		if rule.Type == "cidr" {
+			value := "1"
+			if rule.Action == "deny" {
+				value = "0"
+			}
+			fmt.Fprintf(&b, "    %s %s;\n", rule.Value, value)
+		}
+	}
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "map \"$%[1]s_geo$uri\" $%[1]s_denied {\n    default 0;\n", v)
+	b.WriteString("    \"~^0/\\.well-known/acme-challenge/\" 0;\n")
+	b.WriteString("    \"~^0\" 1;\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func writeAccessServer(b *strings.Builder, site model.Website, access model.WebsiteAccess) {
+	v := accessVar(site.ID)
+	b.WriteString("    # Access control\n")
+	if access.GeoEnable {
+		fmt.Fprintf(b, "    if ($%s_denied) {\n", v)
+		b.WriteString("        return 403;\n")
+		b.WriteString("    }\n")
+	}
+	if access.LimitReqEnable {
+		if access.LimitReqRate > 0 {
+			writeLimitReq(b, v, access.LimitReqBurst, access.LimitReqNodelay)
+		}
+		for i, path := range parseAccessPaths(access.LimitReqPaths) {
+			writeLimitReq(b, fmt.Sprintf("%s_p%d", v, i+1), path.Burst, path.Nodelay)
+		}
+	}
+	b.WriteString("\n")
+}
+
+func writeLimitReq(b *strings.Builder, zone string, burst int, nodelay bool) {
+	fmt.Fprintf(b, "    limit_req zone=%s", zone)
+	if burst > 0 {
+		fmt.Fprintf(b, " burst=%d", burst)
+	}
+	if nodelay {
+		b.WriteString(" nodelay")
+	}
+	b.WriteString(";\n")
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/global"
+)
+
+func TestSaveAccessRendersZonesAndGeoRules(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	original := geoCountryNetworks
+	geoCountryNetworks = func(codes []string) (map[string][]string, error) {
+		return map[string][]string{"CN": {"1.0.1.0/24"}}, nil
+	}
+	t.Cleanup(func() { geoCountryNetworks = original })
+
+	site := &model.Website{PrimaryDomain: "r.example.com", Alias: "r_example_com", Type: "static", Status: "stopped", SiteDir: "/var/www/r"}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+	err := svc.SaveAccess(dto.WebsiteAccessConfig{
+		WebsiteID: site.ID, LimitReqEnable: true, LimitReqRate: 10, LimitReqBurst: 20, LimitReqNodelay: true,
+		LimitReqPaths: []dto.WebsiteRateLimitPath{{Path: "/wp-login.php", Rate: 5, Unit: "r/m", Burst: 2}},
+		GeoEnable:     true, GeoDefault: "deny",
+		GeoRules: []dto.WebsiteGeoRule{
+			{Type: "country", Value: "cn", Action: "allow"},
+			{Type: "cidr", Value: "1.0.1.7", Action: "deny"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := accessVar(site.ID)
+	zones, err := os.ReadFile(SharedZonesPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"limit_conn_zone $binary_remote_addr zone=perip:10m;",
+		"map $uri $" + v + "_key {\n    default $binary_remote_addr;\n    \"~^/wp-login\\.php\" \"\";\n}",
+		"limit_req_zone $" + v + "_key zone=" + v + ":10m rate=10r/s;",
+		"limit_req_zone $" + v + "_p1_key zone=" + v + "_p1:10m rate=5r/m;",
+	} {
+		if !strings.Contains(string(zones), want) {
+			t.Fatalf("zones missing %q:\n%s", want, zones)
+		}
+	}
+
+	config, err := NewNginxConfigGenerator().Generate(*site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"geo $" + v + "_geo {\n    default 0;\n    include " + accessCountryFilePath(*site) + ";\n    1.0.1.7 0;\n}",
+		"\"~^0/\\.well-known/acme-challenge/\" 0;\n    \"~^0\" 1;",
+		"if ($" + v + "_denied) {\n        return 403;",
+		"limit_req zone=" + v + " burst=20 nodelay;\n    limit_req zone=" + v + "_p1 burst=2;\n",
+		"limit_req_status 429;",
+	} {
+		if !strings.Contains(config, want) {
+			t.Fatalf("config missing %q:\n%s", want, config)
+		}
+	}
+	if strings.Contains(config, "limit_req_zone") {
+		t.Fatalf("limit_req_zone belongs in the shared include:\n%s", config)
+	}
+	countries, _ := os.ReadFile(accessCountryFilePath(*site))
+	if !strings.HasPrefix(string(countries), "127.0.0.0/8 1;\n") || !strings.HasSuffix(string(countries), "1.0.1.0/24 1;\n") {
+		t.Fatalf("country file = %q", countries)
+	}
+
+	saved, _ := svc.GetAccess(site.ID)
+	if len(saved.LimitReqPaths) != 1 || saved.GeoRules[0].Value != "CN" {
+		t.Fatalf("saved access = %+v", saved)
+	}
+
+	if err := svc.Delete(site.ID); err != nil {
+		t.Fatal(err)
+	}
+	zones, _ = os.ReadFile(SharedZonesPath())
+	if strings.Contains(string(zones), v) {
+		t.Fatalf("zones of deleted website should be removed:\n%s", zones)
+	}
+}
+
+func TestSaveAccessRejectsInvalidConfig(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	site := &model.Website{PrimaryDomain: "x.example.com", Alias: "x_example_com", Type: "static", Status: "stopped"}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+	cases := []dto.WebsiteAccessConfig{
+		{LimitReqEnable: true},
+		{LimitReqEnable: true, LimitReqRate: 10, LimitReqUnit: "r/h"},
+		{LimitReqEnable: true, LimitReqPaths: []dto.WebsiteRateLimitPath{{Path: "/a\" 1; }", Rate: 1}}},
+		{LimitReqEnable: true, LimitReqPaths: []dto.WebsiteRateLimitPath{{Path: "/a", Rate: 1}, {Path: "/a", Rate: 2}}},
+		{GeoEnable: true, GeoRules: []dto.WebsiteGeoRule{{Type: "cidr", Value: "10.0.0.0/33", Action: "deny"}}},
+		{GeoEnable: true, GeoRules: []dto.WebsiteGeoRule{{Type: "country", Value: "China", Action: "deny"}}},
+		{GeoEnable: true, GeoRules: []dto.WebsiteGeoRule{{Type: "country", Value: "CN", Action: "block"}}},
+	}
+	for _, req := range cases {
+		req.WebsiteID = site.ID
+		if err := svc.SaveAccess(req); err == nil {
+			t.Fatalf("access %+v should be rejected", req)
+		}
+	}
+	if access, _ := repo.NewIWebsiteAccessRepo().GetByWebsite(site.ID); access.ID != 0 {
+		t.Fatalf("rejected saves must not persist, got %+v", access)
+	}
+}
+
+func TestEnsureNginxIncludeAddsSharedZones(t *testing.T) {
+	installWebsiteLocationDB(t)
+	mainConf := global.CONF.Nginx.GetMainConf()
+	if err := os.MkdirAll(filepath.Dir(mainConf), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 主配置已自行定义 perip 时，共享文件不再重复定义
+	if err := os.WriteFile(mainConf, []byte("http {\n    limit_conn_zone $binary_remote_addr zone=perip:10m;\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnsureNginxInclude(); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(mainConf)
+	if !strings.Contains(string(content), "include conf.d/*.conf;") || !strings.Contains(string(content), "include "+SharedZonesPath()+";") {
+		t.Fatalf("nginx.conf = %s", content)
+	}
+	zones, err := os.ReadFile(SharedZonesPath())
+	if err != nil || strings.Contains(string(zones), "zone=perip") {
+		t.Fatalf("zones = %q, err = %v", zones, err)
+	}
+
+	// 重复调用不会重复插入
+	if err := EnsureNginxInclude(); err != nil {
+		t.Fatal(err)
+	}
+	again, _ := os.ReadFile(mainConf)
+	if string(again) != string(content) {
+		t.Fatalf("second call changed nginx.conf:\n%s", again)
+	}
+}
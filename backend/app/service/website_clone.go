@@ -0,0 +1,621 @@
+package service
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+)
+
+var cloneDatabaseNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,64}$`)
+
+// 数据库克隆、快照恢复与推送前备份依赖外部服务，测试中可替换
+var (
+	cloneSiteDatabase   = cloneDatabaseInstance
+	dropSiteDatabase    = func(id uint) error { return NewIDatabaseService().DeleteInstance(id) }
+	restoreSiteSnapshot = restoreWebsiteSnapshot
+	promoteBackup       = backupWebsiteBeforePromote
+)
+
+func cloneInvalid(format string, args ...interface{}) error {
+	return buserr.WithDetail(constant.ErrWebsiteCloneInvalid, fmt.Sprintf(format, args...), nil)
+}
+
+// Clone 以新域名复制一个托管网站作为 staging：复制网站配置及 location、upstream、WAF、
+// 访问控制，站点文件取自来源网站当前目录或其备份记录，可选克隆关联的数据库。
+// 任一步骤失败时删除已创建的网站、目录和数据库。
+func (s *WebsiteService) Clone(req dto.WebsiteClone) (*dto.WebsiteCloneResult, error) {
+	source, err := s.websiteRepo.Get(repo.WithByID(req.ID))
+	if err != nil {
+		return nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	if source.NginxConfPath != "" {
+		return nil, buserr.New(constant.ErrWebsiteExternalOperationDenied)
+	}
+	if source.ConfigMode == "source" {
+		return nil, cloneInvalid("source-mode website %s cannot be cloned", source.PrimaryDomain)
+	}
+
+	domain := strings.TrimSpace(req.PrimaryDomain)
+	exist, _ := s.websiteRepo.Get(repo.WithByPrimaryDomain(domain))
+	if exist.ID > 0 {
+		return nil, buserr.New(constant.ErrWebsiteDomainExist)
+	}
+	alias := strings.TrimSpace(req.Alias)
+	if alias == "" {
+		alias = domainToAlias(domain)
+	}
+	existAlias, _ := s.websiteRepo.Get(repo.WithByAlias(alias))
+	if existAlias.ID > 0 {
+		return nil, buserr.WithDetail(constant.ErrRecordExist, "alias already exists: "+alias, nil)
+	}
+
+	fileSource := req.FileSource
+	if fileSource == "" {
+		fileSource = "copy"
+	}
+	siteDir := ""
+	if source.SiteDir != "" {
+		siteDir = strings.TrimSpace(req.SiteDir)
+		if siteDir == "" {
+			siteDir = fmt.Sprintf("/var/www/%s", alias)
+		}
+		if err := validateCloneDir(source.SiteDir, siteDir); err != nil {
+			return nil, err
+		}
+		siteDir = filepath.Clean(siteDir)
+		if entries, err := os.ReadDir(siteDir); err == nil && len(entries) > 0 {
+			return nil, cloneInvalid("site directory %s is not empty", siteDir)
+		}
+	} else if fileSource == "snapshot" {
+		return nil, cloneInvalid("website %s has no site directory", source.PrimaryDomain)
+	}
+	if fileSource == "snapshot" {
+		record, err := repo.NewIBackupRepo().GetRecord(req.BackupRecordID)
+		if err != nil {
+			return nil, buserr.New(constant.ErrRecordNotFound)
+		}
+		if record.Type != "website" || record.Name != source.PrimaryDomain {
+			return nil, cloneInvalid("backup record %d is not a backup of %s", record.ID, source.PrimaryDomain)
+		}
+	}
+
+	databaseName := strings.TrimSpace(req.DatabaseName)
+	if req.CloneDatabase {
+		if source.DatabaseID == 0 {
+			return nil, cloneInvalid("website %s has no linked database", source.PrimaryDomain)
+		}
+		if databaseName == "" {
+			instance, err := repo.NewIDatabaseRepo().GetInstance(source.DatabaseID)
+			if err != nil {
+				return nil, buserr.New(constant.ErrRecordNotFound)
+			}
+			databaseName = instance.Name + "_staging"
+		}
+		if !cloneDatabaseNamePattern.MatchString(databaseName) {
+			return nil, cloneInvalid("invalid database name %q", databaseName)
+		}
+	}
+
+	clone := source
+	clone.BaseModel = model.BaseModel{}
+	clone.PrimaryDomain = domain
+	clone.Domains = ""
+	clone.Alias = alias
+	clone.Status = "stopped"
+	clone.SiteDir = siteDir
+	clone.PHPOpenBasedir = rebaseSitePath(source.PHPOpenBasedir, source.SiteDir, siteDir)
+	clone.SSLEnable = false
+	clone.CertificateID = 0
+	clone.DefaultServer = false
+	clone.AccessLogPath = ""
+	clone.ErrorLogPath = ""
+	clone.StagingOf = source.ID
+	clone.DatabaseID = 0
+	if err := s.websiteRepo.Create(&clone); err != nil {
+		return nil, buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+
+	result := &dto.WebsiteCloneResult{ID: clone.ID, PrimaryDomain: clone.PrimaryDomain, SiteDir: clone.SiteDir}
+	rollback := func() {
+		if result.DatabaseID > 0 {
+			if err := dropSiteDatabase(result.DatabaseID); err != nil {
+				global.LOG.Warnf("Drop cloned database %s failed: %v", result.DatabaseName, err)
+			}
+		}
+		if siteDir != "" {
+			_ = os.RemoveAll(siteDir)
+		}
+		if err := s.Delete(clone.ID); err != nil {
+			global.LOG.Warnf("Delete cloned website %s failed: %v", clone.PrimaryDomain, err)
+		}
+	}
+
+	if siteDir != "" {
+		if fileSource == "snapshot" {
+			err = restoreSiteSnapshot(req.BackupRecordID, req.BackupPassword, siteDir)
+		} else if _, statErr := os.Stat(source.SiteDir); statErr == nil {
+			err = copyDirStreaming(source.SiteDir, siteDir, nil)
+		} else {
+			err = os.MkdirAll(siteDir, 0755)
+		}
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+	}
+
+	if req.CloneDatabase {
+		instance, password, err := cloneSiteDatabase(source.DatabaseID, databaseName)
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+		result.DatabaseID = instance.ID
+		result.DatabaseName = instance.Name
+		result.DatabaseUser = instance.Username
+		result.DatabasePassword = password
+		// staging 代码必须改用克隆库，找不到可改写的配置时不能报告成功，否则 staging 仍会读写生产库
+		sourceInstance, err := repo.NewIDatabaseRepo().GetInstance(source.DatabaseID)
+		if err != nil {
+			rollback()
+			return nil, buserr.New(constant.ErrRecordNotFound)
+		}
+		files, err := rewriteStagingDatabaseConfig(siteDir, sourceInstance.Name, instance.Name, instance.Username, password)
+		if err != nil {
+			rollback()
+			return nil, buserr.WithDetail(constant.ErrWebsiteCloneInvalid, err.Error(), err)
+		}
+		if len(files) == 0 {
+			rollback()
+			return nil, cloneInvalid("no wp-config.php or .env in %s uses database %s, the staging site would keep using the production database", siteDir, sourceInstance.Name)
+		}
+		result.DatabaseConfigFiles = files
+		clone.DatabaseID = instance.ID
+		if err := s.websiteRepo.Save(&clone); err != nil {
+			rollback()
+			return nil, buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+		}
+	}
+
+	if err := s.copySiteSettings(source, clone); err != nil {
+		rollback()
+		return nil, err
+	}
+
+	global.LOG.Infof("Website %s cloned to %s", source.PrimaryDomain, clone.PrimaryDomain)
+
+	if global.CONF.Nginx.IsInstalled() {
+		if err := s.autoEnable(&clone); err != nil {
+			global.LOG.Warnf("Auto-enable website %s failed: %v", clone.PrimaryDomain, err)
+		}
+	}
+	return result, nil
+}
+
+// copySiteSettings 复制 upstream、location、WAF 与访问控制；location 引用的 upstream
+// 按名称映射到克隆网站的新记录，指向来源站点目录的 root 改为克隆目录。
+func (s *WebsiteService) copySiteSettings(source, clone model.Website) error {
+	upstreams, err := s.ListUpstreams(source.ID)
+	if err != nil {
+		return err
+	}
+	upstreamNames := make(map[uint]string, len(upstreams))
+	for i := range upstreams {
+		upstreamNames[upstreams[i].ID] = upstreams[i].Name
+		upstreams[i].ID = 0
+	}
+	if len(upstreams) > 0 {
+		if err := s.SaveUpstreams(dto.WebsiteUpstreamSave{WebsiteID: clone.ID, Upstreams: upstreams}); err != nil {
+			return err
+		}
+	}
+	cloned, err := s.ListUpstreams(clone.ID)
+	if err != nil {
+		return err
+	}
+	upstreamIDs := make(map[string]uint, len(cloned))
+	for _, upstream := range cloned {
+		upstreamIDs[upstream.Name] = upstream.ID
+	}
+
+	locations, err := s.ListLocations(source.ID)
+	if err != nil {
+		return err
+	}
+	for i := range locations {
+		locations[i].ID = 0
+		if locations[i].UpstreamID > 0 {
+			locations[i].UpstreamID = upstreamIDs[upstreamNames[locations[i].UpstreamID]]
+		}
+		locations[i].Root = rebaseSitePath(locations[i].Root, source.SiteDir, clone.SiteDir)
+	}
+	if len(locations) > 0 {
+		if err := s.SaveLocations(dto.WebsiteLocationSave{WebsiteID: clone.ID, Locations: locations}); err != nil {
+			return err
+		}
+	}
+
+	if waf, err := repo.NewIWebsiteWAFRepo().GetByWebsite(source.ID); err != nil {
+		return err
+	} else if waf.ID > 0 {
+		config, err := s.GetWAF(source.ID)
+		if err != nil {
+			return err
+		}
+		config.WebsiteID = clone.ID
+		if err := s.SaveWAF(*config); err != nil {
+			return err
+		}
+	}
+
+	if access, err := repo.NewIWebsiteAccessRepo().GetByWebsite(source.ID); err != nil {
+		return err
+	} else if access.ID > 0 {
+		config, err := s.GetAccess(source.ID)
+		if err != nil {
+			return err
+		}
+		config.WebsiteID = clone.ID
+		if err := s.SaveAccess(*config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Promote 把 staging 网站的文件推送到其生产网站。推送前先备份生产网站，
+// excludes 匹配的文件保持生产环境原样；数据库不会自动推送，避免覆盖生产数据，
+// staging 使用克隆库时其 wp-config.php 与 .env 也不推送。
+func (s *WebsiteService) Promote(req dto.WebsitePromote) (*dto.WebsitePromoteResult, error) {
+	staging, err := s.websiteRepo.Get(repo.WithByID(req.ID))
+	if err != nil {
+		return nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	if staging.StagingOf == 0 {
+		return nil, cloneInvalid("website %s is not a staging copy", staging.PrimaryDomain)
+	}
+	production, err := s.websiteRepo.Get(repo.WithByID(staging.StagingOf))
+	if err != nil {
+		return nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	if production.NginxConfPath != "" {
+		return nil, buserr.New(constant.ErrWebsiteExternalOperationDenied)
+	}
+	if staging.SiteDir == "" || production.SiteDir == "" {
+		return nil, cloneInvalid("both websites need a site directory")
+	}
+	if err := validateCloneDir(staging.SiteDir, production.SiteDir); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(staging.SiteDir); err != nil {
+		return nil, cloneInvalid("staging directory %s: %v", staging.SiteDir, err)
+	}
+	excludes := make([]string, 0, len(req.Excludes))
+	for _, pattern := range req.Excludes {
+		pattern = strings.Trim(filepath.ToSlash(strings.TrimSpace(pattern)), "/")
+		if pattern == "" {
+			continue
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, cloneInvalid("invalid exclude pattern %q", pattern)
+		}
+		excludes = append(excludes, pattern)
+	}
+	// 克隆时 staging 的数据库配置已改为克隆库，推送时保留生产环境自己的配置
+	if staging.DatabaseID > 0 && staging.DatabaseID != production.DatabaseID {
+		excludes = append(excludes, "wp-config.php", ".env")
+	}
+
+	backupPath, err := promoteBackup(production, req.BackupAccountID)
+	if err != nil {
+		return nil, err
+	}
+	copied, removed, err := syncSiteFiles(staging.SiteDir, production.SiteDir, excludes, req.DeleteExtra)
+	if err != nil {
+		return nil, buserr.WithDetail(constant.ErrWebsiteCloneInvalid, err.Error(), err)
+	}
+	global.LOG.Infof("Website %s promoted to %s: %d copied, %d removed", staging.PrimaryDomain, production.PrimaryDomain, copied, removed)
+	return &dto.WebsitePromoteResult{ProductionID: production.ID, BackupPath: backupPath, Copied: copied, Removed: removed}, nil
+}
+
+// validateCloneDir 目标目录必须是绝对路径，且与来源目录互不包含
+func validateCloneDir(source, target string) error {
+	if !filepath.IsAbs(target) {
+		return cloneInvalid("site directory %s must be an absolute path", target)
+	}
+	source, target = filepath.Clean(source), filepath.Clean(target)
+	if isProtectedPath(target) {
+		return cloneInvalid("site directory %s is protected", target)
+	}
+	if source == target || strings.HasPrefix(target, source+"/") || strings.HasPrefix(source, target+"/") {
+		return cloneInvalid("site directory %s overlaps %s", target, source)
+	}
+	return nil
+}
+
+// rebaseSitePath 把位于 from 目录内的路径改到 to 目录，open_basedir 等以冒号分隔的列表逐项处理；
+// 只匹配完整的路径分量，/var/www/a 不会改写 /var/www/ab
+func rebaseSitePath(value, from, to string) string {
+	if value == "" || from == "" || to == "" {
+		return value
+	}
+	from, to = filepath.Clean(from), filepath.Clean(to)
+	parts := strings.Split(value, ":")
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		clean := filepath.Clean(part)
+		var rebased string
+		switch {
+		case clean == from:
+			rebased = to
+		case strings.HasPrefix(clean, from+"/"):
+			rebased = to + strings.TrimPrefix(clean, from)
+		default:
+			continue
+		}
+		if strings.HasSuffix(part, "/") {
+			rebased += "/"
+		}
+		parts[i] = rebased
+	}
+	return strings.Join(parts, ":")
+}
+
+var (
+	wpConfigDatabasePattern  = regexp.MustCompile(`(define\(\s*['"]DB_(NAME|USER|PASSWORD)['"]\s*,\s*['"])([^'"]*)(['"])`)
+	envConfigDatabasePattern = regexp.MustCompile(`(?m)^([ \t]*DB_(DATABASE|USERNAME|PASSWORD)[ \t]*=[ \t]*)(.*?)[ \t]*$`)
+)
+
+// rewriteStagingDatabaseConfig 在 staging 目录及其一级子目录中查找 WordPress 的 wp-config.php
+// 与 Laravel 等框架的 .env，把指向来源数据库的库名、用户和密码改为克隆库，返回改写的文件
+func rewriteStagingDatabaseConfig(siteDir, sourceName, name, user, password string) ([]string, error) {
+	if siteDir == "" {
+		return nil, nil
+	}
+	var files []string
+	for _, pattern := range []string{"wp-config.php", ".env", "*/wp-config.php", "*/.env"} {
+		matches, _ := filepath.Glob(filepath.Join(siteDir, pattern))
+		files = append(files, matches...)
+	}
+	var rewritten []string
+	for _, file := range files {
+		info, err := os.Lstat(file)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return rewritten, err
+		}
+		values := map[string]string{"NAME": name, "USER": user, "PASSWORD": password}
+		pattern := wpConfigDatabasePattern
+		if filepath.Base(file) == ".env" {
+			values = map[string]string{"DATABASE": name, "USERNAME": user, "PASSWORD": password}
+			pattern = envConfigDatabasePattern
+		}
+		usesSource := false
+		for _, match := range pattern.FindAllStringSubmatch(string(content), -1) {
+			if (match[2] == "NAME" || match[2] == "DATABASE") && strings.Trim(match[3], `"' `) == sourceName {
+				usesSource = true
+			}
+		}
+		if !usesSource {
+			continue
+		}
+		updated := pattern.ReplaceAllStringFunc(string(content), func(item string) string {
+			match := pattern.FindStringSubmatch(item)
+			if len(match) < 4 {
+				return item
+			}
+			return match[1] + values[match[2]] + strings.Join(match[4:], "")
+		})
+		if err := os.WriteFile(file, []byte(updated), info.Mode().Perm()); err != nil {
+			return rewritten, err
+		}
+		rewritten = append(rewritten, file)
+	}
+	return rewritten, nil
+}
+
+func restoreWebsiteSnapshot(recordID uint, password, target string) error {
+	_, err := NewIBackupService().RestoreRecord(dto.BackupRecordRestore{
+		ID: recordID, TargetPath: target, Password: password, SkipSnapshot: true,
+	})
+	return err
+}
+
+// cloneDatabaseInstance 在来源数据库所在服务器上新建同类型数据库并导入来源数据，
+// 新库使用独立的用户和随机密码。
+func cloneDatabaseInstance(sourceID uint, name string) (*model.DatabaseInstance, string, error) {
+	databaseRepo := repo.NewIDatabaseRepo()
+	source, err := databaseRepo.GetInstance(sourceID)
+	if err != nil {
+		return nil, "", buserr.New(constant.ErrRecordNotFound)
+	}
+	existing, err := databaseRepo.ListInstancesByServerID(source.ServerID)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, instance := range existing {
+		if instance.Name == name {
+			return nil, "", cloneInvalid("database %s already exists", name)
+		}
+	}
+
+	databaseService := NewIDatabaseService()
+	dump, err := databaseService.BackupInstance(sourceID)
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.Remove(dump)
+
+	username := name
+	if len(username) > 32 {
+		username = username[:32]
+	}
+	password := generateRandomPassword(20)
+	if err := databaseService.CreateInstance(dto.DatabaseInstanceCreate{
+		ServerID: source.ServerID, Name: name, Charset: source.Charset,
+		Username: username, Password: password, Permission: source.Permission,
+	}); err != nil {
+		return nil, "", err
+	}
+	instances, err := databaseRepo.ListInstancesByServerID(source.ServerID)
+	if err != nil {
+		return nil, "", err
+	}
+	var created *model.DatabaseInstance
+	for i := range instances {
+		if instances[i].Name == name {
+			created = &instances[i]
+			break
+		}
+	}
+	if created == nil {
+		return nil, "", cloneInvalid("database %s was not recorded", name)
+	}
+	if err := databaseService.RestoreInstance(dto.DatabaseInstanceRestore{ID: created.ID, File: dump}); err != nil {
+		_ = databaseService.DeleteInstance(created.ID)
+		return nil, "", err
+	}
+	return created, password, nil
+}
+
+// backupWebsiteBeforePromote 备份生产网站目录并写入备份记录
+func backupWebsiteBeforePromote(site model.Website, accountID uint) (string, error) {
+	backupService := NewIBackupService()
+	output, err := backupService.PerformBackupWithOptions("website", site.PrimaryDomain, "", "", accountID, BackupJobOptions{})
+	if err != nil {
+		_ = backupService.CreateRecordFromOutput("website", site.PrimaryDomain, accountID, 0, output, constant.StatusFailed, backupFailureMessage(output, err))
+		return "", err
+	}
+	if err := backupService.CreateRecordFromOutput("website", site.PrimaryDomain, accountID, 0, output, constant.StatusSuccess, ""); err != nil {
+		return "", err
+	}
+	return output.Path, nil
+}
+
+// syncSiteFiles 将 src 同步到 dst，大小与修改时间相同的文件跳过；deleteExtra 时删除
+// dst 中 src 不存在的条目。excludes 按相对路径或文件名匹配，匹配的条目两侧都不处理。
+func syncSiteFiles(src, dst string, excludes []string, deleteExtra bool) (int, int, error) {
+	excluded := func(rel string) bool {
+		rel = filepath.ToSlash(rel)
+		for _, pattern := range excludes {
+			if ok, _ := filepath.Match(pattern, rel); ok {
+				return true
+			}
+			if ok, _ := filepath.Match(pattern, filepath.Base(rel)); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	copied := 0
+	err := filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if rel == "." {
+			return os.MkdirAll(dst, 0755)
+		}
+		if excluded(rel) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		current, statErr := os.Lstat(target)
+		switch {
+		case entry.IsDir():
+			if statErr == nil && !current.IsDir() {
+				if err := os.Remove(target); err != nil {
+					return err
+				}
+			}
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if statErr == nil {
+				if existing, _ := os.Readlink(target); existing == link {
+					return nil
+				}
+				if err := os.RemoveAll(target); err != nil {
+					return err
+				}
+			}
+			copied++
+			return os.Symlink(link, target)
+		case !info.Mode().IsRegular():
+			return nil
+		}
+		if statErr == nil {
+			if current.Mode().IsRegular() && current.Size() == info.Size() && current.ModTime().Equal(info.ModTime()) {
+				return nil
+			}
+			if !current.Mode().IsRegular() {
+				if err := os.RemoveAll(target); err != nil {
+					return err
+				}
+			}
+		}
+		if err := copyFileStreaming(path, target, info, nil); err != nil {
+			return err
+		}
+		copied++
+		return os.Chtimes(target, info.ModTime(), info.ModTime())
+	})
+	if err != nil || !deleteExtra {
+		return copied, 0, err
+	}
+
+	removed := 0
+	err = filepath.WalkDir(dst, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dst, path)
+		if rel == "." {
+			return nil
+		}
+		if excluded(rel) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, err := os.Lstat(filepath.Join(src, rel)); !os.IsNotExist(err) {
+			return nil
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		removed++
+		if entry.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return copied, removed, err
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/global"
+)
+
+func TestCloneCopiesSiteFilesAndSettings(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	root := t.TempDir()
+	sourceDir := filepath.Join(root, "prod")
+	if err := os.MkdirAll(filepath.Join(sourceDir, "static"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "index.html"), []byte("prod"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	source := &model.Website{
+		PrimaryDomain: "shop.example.com", Alias: "shop_example_com", Type: "static", Status: "stopped",
+		SiteDir: sourceDir, SSLEnable: true, CertificateID: 3, DefaultServer: true,
+	}
+	if err := svc.websiteRepo.Create(source); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SaveUpstreams(dto.WebsiteUpstreamSave{WebsiteID: source.ID, Upstreams: []dto.WebsiteUpstreamItem{
+		{Name: "api", Servers: []dto.WebsiteUpstreamServer{{Address: "127.0.0.1:8080"}}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	upstreams, _ := svc.ListUpstreams(source.ID)
+	if err := svc.SaveLocations(dto.WebsiteLocationSave{WebsiteID: source.ID, Locations: []dto.WebsiteLocationItem{
+		{Path: "/api/", Type: "proxy", UpstreamID: upstreams[0].ID},
+		{Path: "/assets/", Type: "static", Root: filepath.Join(sourceDir, "static")},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SaveAccess(dto.WebsiteAccessConfig{WebsiteID: source.ID, LimitReqEnable: true, LimitReqRate: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	cloneDir := filepath.Join(root, "staging")
+	result, err := svc.Clone(dto.WebsiteClone{ID: source.ID, PrimaryDomain: "staging.shop.example.com", SiteDir: cloneDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(cloneDir, "index.html")); string(content) != "prod" {
+		t.Fatalf("site files not copied: %q", content)
+	}
+
+	clone, _ := svc.websiteRepo.Get(repo.WithByID(result.ID))
+	if clone.StagingOf != source.ID || clone.Alias != "staging_shop_example_com" || clone.SiteDir != cloneDir {
+		t.Fatalf("unexpected clone %+v", clone)
+	}
+	if clone.SSLEnable || clone.CertificateID != 0 || clone.DefaultServer {
+		t.Fatalf("clone must not inherit certificate or default server: %+v", clone)
+	}
+
+	clonedUpstreams, _ := svc.ListUpstreams(clone.ID)
+	if len(clonedUpstreams) != 1 || clonedUpstreams[0].ID == upstreams[0].ID {
+		t.Fatalf("upstreams not copied: %+v", clonedUpstreams)
+	}
+	locations, _ := svc.ListLocations(clone.ID)
+	if len(locations) != 2 || locations[0].UpstreamID != clonedUpstreams[0].ID {
+		t.Fatalf("location upstream not remapped: %+v", locations)
+	}
+	if locations[1].Root != filepath.Join(cloneDir, "static") {
+		t.Fatalf("location root not rebased: %s", locations[1].Root)
+	}
+	access, _ := svc.GetAccess(clone.ID)
+	if !access.LimitReqEnable || access.LimitReqRate != 10 {
+		t.Fatalf("access control not copied: %+v", access)
+	}
+}
+
+func TestCloneRollsBackWhenDatabaseCloneFails(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	original := cloneSiteDatabase
+	cloneSiteDatabase = func(sourceID uint, name string) (*model.DatabaseInstance, string, error) {
+		if name != "shop_test" {
+			t.Fatalf("unexpected database name %s", name)
+		}
+		return nil, "", errors.New("dump failed")
+	}
+	t.Cleanup(func() { cloneSiteDatabase = original })
+
+	root := t.TempDir()
+	source := &model.Website{
+		PrimaryDomain: "shop.example.com", Alias: "shop_example_com", Type: "static", Status: "stopped",
+		SiteDir: filepath.Join(root, "prod"), DatabaseID: 7,
+	}
+	if err := svc.websiteRepo.Create(source); err != nil {
+		t.Fatal(err)
+	}
+	cloneDir := filepath.Join(root, "staging")
+	_, err := svc.Clone(dto.WebsiteClone{
+		ID: source.ID, PrimaryDomain: "staging.shop.example.com", SiteDir: cloneDir,
+		CloneDatabase: true, DatabaseName: "shop_test",
+	})
+	if err == nil {
+		t.Fatal("expected clone to fail")
+	}
+	if exist, _ := svc.websiteRepo.Get(repo.WithByPrimaryDomain("staging.shop.example.com")); exist.ID > 0 {
+		t.Fatal("cloned website should be removed on failure")
+	}
+	if _, err := os.Stat(cloneDir); !os.IsNotExist(err) {
+		t.Fatalf("cloned directory should be removed, stat err = %v", err)
+	}
+
+	if _, err := svc.Clone(dto.WebsiteClone{ID: source.ID, PrimaryDomain: "a.example.com", CloneDatabase: true, DatabaseName: "bad-name"}); err == nil {
+		t.Fatal("expected invalid database name to be rejected")
+	}
+}
+
+func TestCloneDatabaseRewritesStagingConfig(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	if err := global.DB.AutoMigrate(&model.DatabaseInstance{}); err != nil {
+		t.Fatal(err)
+	}
+	sourceDB := &model.DatabaseInstance{ServerID: 1, Name: "shop", Username: "shop"}
+	if err := global.DB.Create(sourceDB).Error; err != nil {
+		t.Fatal(err)
+	}
+	original, originalDrop := cloneSiteDatabase, dropSiteDatabase
+	cloneSiteDatabase = func(sourceID uint, name string) (*model.DatabaseInstance, string, error) {
+		instance := &model.DatabaseInstance{ServerID: 1, Name: name, Username: name}
+		return instance, "5f3a9c", global.DB.Create(instance).Error
+	}
+	var dropped []uint
+	dropSiteDatabase = func(id uint) error {
+		dropped = append(dropped, id)
+		return nil
+	}
+	t.Cleanup(func() { cloneSiteDatabase, dropSiteDatabase = original, originalDrop })
+
+	root := t.TempDir()
+	sourceDir := filepath.Join(root, "prod")
+	files := map[string]string{
+		"wp-config.php":    "<?php\ndefine( 'DB_NAME', 'shop' );\ndefine( 'DB_USER', 'shop' );\ndefine( 'DB_PASSWORD', 'prod-pass' );\n",
+		"app/.env":         "APP_NAME=shop\nDB_DATABASE=\"shop\"\nDB_USERNAME=shop\nDB_PASSWORD=prod-pass\n\nMAIL_HOST=smtp\n",
+		"legacy/.env":      "DB_DATABASE=other\nDB_PASSWORD=keep\n",
+		"public/index.php": "<?php echo 1;",
+	}
+	for rel, content := range files {
+		path := filepath.Join(sourceDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	source := &model.Website{
+		PrimaryDomain: "shop.example.com", Alias: "shop_example_com", Type: "php", Status: "stopped",
+		SiteDir: sourceDir, DatabaseID: sourceDB.ID,
+	}
+	if err := svc.websiteRepo.Create(source); err != nil {
+		t.Fatal(err)
+	}
+
+	cloneDir := filepath.Join(root, "staging")
+	result, err := svc.Clone(dto.WebsiteClone{ID: source.ID, PrimaryDomain: "staging.shop.example.com", SiteDir: cloneDir, CloneDatabase: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.DatabaseConfigFiles) != 2 {
+		t.Fatalf("rewritten configs = %v", result.DatabaseConfigFiles)
+	}
+	wp, _ := os.ReadFile(filepath.Join(cloneDir, "wp-config.php"))
+	if !strings.Contains(string(wp), "define( 'DB_NAME', 'shop_staging' );") || !strings.Contains(string(wp), "define( 'DB_USER', 'shop_staging' );") ||
+		!strings.Contains(string(wp), "define( 'DB_PASSWORD', '5f3a9c' );") {
+		t.Fatalf("wp-config.php not rewritten:\n%s", wp)
+	}
+	env, _ := os.ReadFile(filepath.Join(cloneDir, "app", ".env"))
+	if string(env) != "APP_NAME=shop\nDB_DATABASE=shop_staging\nDB_USERNAME=shop_staging\nDB_PASSWORD=5f3a9c\n\nMAIL_HOST=smtp\n" {
+		t.Fatalf(".env not rewritten:\n%s", env)
+	}
+	if legacy, _ := os.ReadFile(filepath.Join(cloneDir, "legacy", ".env")); string(legacy) != files["legacy/.env"] {
+		t.Fatalf("config of another database should be kept:\n%s", legacy)
+	}
+	if info, _ := os.Stat(filepath.Join(cloneDir, "wp-config.php")); info.Mode().Perm() != 0640 {
+		t.Fatalf("config mode changed to %v", info.Mode().Perm())
+	}
+	if prod, _ := os.ReadFile(filepath.Join(sourceDir, "wp-config.php")); string(prod) != files["wp-config.php"] {
+		t.Fatal("production config must not change")
+	}
+
+	// 推送时不能把指向克隆库的配置带回生产环境
+	promoteOriginal := promoteBackup
+	promoteBackup = func(site model.Website, accountID uint) (string, error) { return "", nil }
+	t.Cleanup(func() { promoteBackup = promoteOriginal })
+	if _, err := svc.Promote(dto.WebsitePromote{ID: result.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if prod, _ := os.ReadFile(filepath.Join(sourceDir, "wp-config.php")); string(prod) != files["wp-config.php"] {
+		t.Fatalf("promote overwrote the production database config:\n%s", prod)
+	}
+
+	// 没有可识别的配置时回滚，不报告成功
+	if err := os.Remove(filepath.Join(sourceDir, "wp-config.php")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(sourceDir, "app")); err != nil {
+		t.Fatal(err)
+	}
+	bareDir := filepath.Join(root, "bare")
+	if _, err := svc.Clone(dto.WebsiteClone{ID: source.ID, PrimaryDomain: "bare.shop.example.com", SiteDir: bareDir,
+		CloneDatabase: true, DatabaseName: "shop_bare"}); err == nil {
+		t.Fatal("clone without a rewritable database config should fail")
+	}
+	if len(dropped) != 1 {
+		t.Fatalf("cloned database should be dropped on failure, dropped %v", dropped)
+	}
+	if _, err := os.Stat(bareDir); !os.IsNotExist(err) {
+		t.Fatalf("cloned directory should be removed, stat err = %v", err)
+	}
+}
+
+func TestRebaseSitePathMatchesWholeComponents(t *testing.T) {
+	cases := map[string]string{
+		"/var/www/a":                    "/var/www/b",
+		"/var/www/a/public/":            "/var/www/b/public/",
+		"/var/www/ab/public":            "/var/www/ab/public",
+		"/srv/var/www/a":                "/srv/var/www/a",
+		"/var/www/a/:/tmp/:/var/www/a2": "/var/www/b/:/tmp/:/var/www/a2",
+	}
+	for value, want := range cases {
+		if got := rebaseSitePath(value, "/var/www/a", "/var/www/b"); got != want {
+			t.Errorf("rebaseSitePath(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestPromoteBacksUpAndSyncsFiles(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	root := t.TempDir()
+	prodDir, stagingDir := filepath.Join(root, "prod"), filepath.Join(root, "staging")
+	files := map[string]string{
+		filepath.Join(prodDir, "index.php"):          "old",
+		filepath.Join(prodDir, ".env"):               "prod-secret",
+		filepath.Join(prodDir, "stale.txt"):          "stale",
+		filepath.Join(prodDir, "uploads", "a.jpg"):   "upload",
+		filepath.Join(stagingDir, "index.php"):       "new",
+		filepath.Join(stagingDir, ".env"):            "staging-secret",
+		filepath.Join(stagingDir, "lib", "util.php"): "util",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	production := &model.Website{PrimaryDomain: "shop.example.com", Alias: "shop_example_com", Type: "php", Status: "stopped", SiteDir: prodDir}
+	if err := svc.websiteRepo.Create(production); err != nil {
+		t.Fatal(err)
+	}
+	staging := &model.Website{PrimaryDomain: "staging.shop.example.com", Alias: "staging_shop_example_com", Type: "php", Status: "stopped", SiteDir: stagingDir, StagingOf: production.ID}
+	if err := svc.websiteRepo.Create(staging); err != nil {
+		t.Fatal(err)
+	}
+
+	backedUp := false
+	original := promoteBackup
+	promoteBackup = func(site model.Website, accountID uint) (string, error) {
+		if site.ID != production.ID || accountID != 2 {
+			t.Fatalf("unexpected backup of %s to %d", site.PrimaryDomain, accountID)
+		}
+		if content, _ := os.ReadFile(filepath.Join(prodDir, "index.php")); string(content) != "old" {
+			t.Fatal("backup must run before files are promoted")
+		}
+		backedUp = true
+		return "website/shop.example.com/backup.tar.gz", nil
+	}
+	t.Cleanup(func() { promoteBackup = original })
+
+	result, err := svc.Promote(dto.WebsitePromote{ID: staging.ID, BackupAccountID: 2, Excludes: []string{".env", "uploads"}, DeleteExtra: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !backedUp || result.ProductionID != production.ID || result.Copied != 2 || result.Removed != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	expected := map[string]string{"index.php": "new", ".env": "prod-secret", "lib/util.php": "util", "uploads/a.jpg": "upload"}
+	for rel, content := range expected {
+		if got, _ := os.ReadFile(filepath.Join(prodDir, rel)); string(got) != content {
+			t.Fatalf("%s = %q, want %q", rel, got, content)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(prodDir, "stale.txt")); !os.IsNotExist(err) {
+		t.Fatal("stale file should be removed")
+	}
+
+	if _, err := svc.Promote(dto.WebsitePromote{ID: production.ID, BackupAccountID: 2}); err == nil {
+		t.Fatal("expected promote of a non-staging website to fail")
+	}
+}
+
+func TestUpdateKeepsDatabaseLinkUnlessProvided(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	site := &model.Website{
+		PrimaryDomain: "db.example.com", Alias: "db_example_com", Type: "static", Status: "stopped",
+		SiteDir: t.TempDir(), DatabaseID: 7,
+	}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+	req := dto.WebsiteUpdate{ID: site.ID, PrimaryDomain: site.PrimaryDomain, SiteDir: site.SiteDir}
+	if err := svc.Update(req); err != nil {
+		t.Fatal(err)
+	}
+	if updated, _ := svc.websiteRepo.Get(repo.WithByID(site.ID)); updated.DatabaseID != 7 {
+		t.Fatalf("update without databaseID should keep the link, got %d", updated.DatabaseID)
+	}
+	unlink := uint(0)
+	req.DatabaseID = &unlink
+	if err := svc.Update(req); err != nil {
+		t.Fatal(err)
+	}
+	if updated, _ := svc.websiteRepo.Get(repo.WithByID(site.ID)); updated.DatabaseID != 0 {
+		t.Fatalf("databaseID 0 should unlink the database, got %d", updated.DatabaseID)
+	}
+}
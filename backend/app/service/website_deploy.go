@@ -0,0 +1,597 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+)
+
+const (
+	deployReleasesDir    = "releases"
+	deployCurrentLink    = "current"
+	deployGitTimeout     = 10 * time.Minute
+	deployBuildTimeout   = 30 * time.Minute
+	deployOutputLimit    = 16 * 1024
+	defaultKeepReleases  = 5
+	deployWebhookPathFmt = "/api/v1/websites/deploy/webhook/%d"
+)
+
+var (
+	deployRepoURLPattern = regexp.MustCompile(`^(https?://|ssh://|file://|[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:)[^\s]+$`)
+	deployBranchPattern  = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
+	deployReleasePattern = regexp.MustCompile(`^\d{14}(-\d+)?$`)
+
+	// 同一网站的部署与回滚串行执行
+	deployLocks sync.Map
+)
+
+// deployCommand 在 dir 中执行部署命令并返回合并输出，测试中可替换
+var deployCommand = runDeployCommand
+
+func runDeployCommand(dir string, env []string, timeout time.Duration, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(deployBaseEnv(dir), env...)
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	return string(output), err
+}
+
+// deployBaseEnv 部署命令不继承面板进程的环境变量，避免泄露面板配置中的密钥
+func deployBaseEnv(dir string) []string {
+	path := os.Getenv("PATH")
+	if path == "" {
+		path = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+	}
+	return []string{"PATH=" + path, "HOME=" + dir, "LANG=C.UTF-8"}
+}
+
+func deployInvalid(format string, args ...interface{}) error {
+	return buserr.WithDetail(constant.ErrWebsiteDeployInvalid, fmt.Sprintf(format, args...), nil)
+}
+
+// deployRepoDir 仓库工作副本放在面板数据目录，关闭部署后也不会经站点目录暴露 .git
+func deployRepoDir(site model.Website) string {
+	return filepath.Join(global.CONF.System.DataDir, "deploy", fmt.Sprintf("%d", site.ID), "repo")
+}
+
+// staticDocumentRoot 启用 Git 部署且已有发布版本的静态网站以 current 软链接作为 root，
+// 首次发布成功前仍使用原站点目录
+func staticDocumentRoot(site model.Website, deploy model.WebsiteDeploy) string {
+	siteDir := site.SiteDir
+	if siteDir == "" {
+		siteDir = fmt.Sprintf("/var/www/%s", site.PrimaryDomain)
+	}
+	if deploy.Enabled && deploy.CurrentRelease != "" {
+		return filepath.Join(siteDir, deployCurrentLink)
+	}
+	return siteDir
+}
+
+func (s *WebsiteService) GetDeploy(websiteID uint) (*dto.WebsiteDeployInfo, error) {
+	site, err := s.websiteRepo.Get(repo.WithByID(websiteID))
+	if err != nil {
+		return nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	deploy, err := repo.NewIWebsiteDeployRepo().GetByWebsite(websiteID)
+	if err != nil {
+		return nil, err
+	}
+	info := &dto.WebsiteDeployInfo{
+		WebsiteDeployConfig: dto.WebsiteDeployConfig{
+			WebsiteID:    websiteID,
+			Enabled:      deploy.Enabled,
+			RepoURL:      deploy.RepoURL,
+			Branch:       deployBranch(deploy.Branch),
+			SSHKey:       deploy.SSHKey,
+			BuildCommand: deploy.BuildCommand,
+			PublishDir:   deploy.PublishDir,
+			KeepReleases: deployKeepReleases(deploy.KeepReleases),
+		},
+		CurrentRelease: deploy.CurrentRelease,
+		WebhookPath:    fmt.Sprintf(deployWebhookPathFmt, websiteID),
+		HasSecret:      deploy.WebhookSecret != "",
+		Releases:       listDeployReleases(site, deploy.CurrentRelease),
+	}
+	return info, nil
+}
+
+// SaveDeploy 保存 Git 部署配置。已有发布版本时启用或关闭会切换 nginx root，运行中的网站立即应用，
+// 失败时恢复原配置。首次保存或 ResetSecret 时生成 webhook 密钥并仅此一次返回。
+// 构建命令以 root 执行，仅管理员可设置；已配置构建命令时非管理员也不能更换其构建的仓库与分支。
+func (s *WebsiteService) SaveDeploy(req dto.WebsiteDeployConfig, admin bool) (*dto.WebsiteDeployInfo, error) {
+	site, err := s.websiteRepo.Get(repo.WithByID(req.WebsiteID))
+	if err != nil {
+		return nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	if site.NginxConfPath != "" {
+		return nil, buserr.New(constant.ErrWebsiteExternalOperationDenied)
+	}
+	if site.Type != "static" || site.ConfigMode == "source" || site.SiteDir == "" {
+		return nil, deployInvalid("git deployment is only available for managed static websites with a site directory")
+	}
+	deployRepo := repo.NewIWebsiteDeployRepo()
+	previous, err := deployRepo.GetByWebsite(site.ID)
+	if err != nil {
+		return nil, err
+	}
+	deploy, err := buildWebsiteDeploy(req)
+	if err != nil {
+		return nil, err
+	}
+	if !admin && deployCommandChanged(previous, deploy) {
+		return nil, buserr.New(constant.ErrPermissionDeny)
+	}
+	deploy.ID = previous.ID
+	deploy.CreatedAt = previous.CreatedAt
+	deploy.CurrentRelease = previous.CurrentRelease
+	deploy.WebhookSecret = previous.WebhookSecret
+	generated := ""
+	if deploy.WebhookSecret == "" || req.ResetSecret {
+		generated = generateRandomPassword(32)
+		deploy.WebhookSecret = generated
+	}
+
+	if err := deployRepo.Save(&deploy); err != nil {
+		return nil, buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	if previous.Enabled != deploy.Enabled && deploy.CurrentRelease != "" && site.Status == "running" {
+		if err := s.applyConfig(site); err != nil {
+			if previous.ID > 0 {
+				_ = deployRepo.Save(&previous)
+			} else {
+				_ = deployRepo.DeleteByWebsite(site.ID)
+			}
+			return nil, err
+		}
+	}
+
+	info, err := s.GetDeploy(site.ID)
+	if err != nil {
+		return nil, err
+	}
+	info.WebhookSecret = generated
+	return info, nil
+}
+
+// deployCommandChanged 构建命令或其执行的代码来源是否变化
+func deployCommandChanged(previous, deploy model.WebsiteDeploy) bool {
+	if deploy.BuildCommand != previous.BuildCommand {
+		return true
+	}
+	return deploy.BuildCommand != "" && (deploy.RepoURL != previous.RepoURL || deployBranch(deploy.Branch) != deployBranch(previous.Branch))
+}
+
+func buildWebsiteDeploy(req dto.WebsiteDeployConfig) (model.WebsiteDeploy, error) {
+	deploy := model.WebsiteDeploy{
+		WebsiteID:    req.WebsiteID,
+		Enabled:      req.Enabled,
+		RepoURL:      strings.TrimSpace(req.RepoURL),
+		Branch:       deployBranch(strings.TrimSpace(req.Branch)),
+		SSHKey:       strings.TrimSpace(req.SSHKey),
+		BuildCommand: strings.TrimSpace(req.BuildCommand),
+		PublishDir:   strings.Trim(filepath.ToSlash(strings.TrimSpace(req.PublishDir)), "/"),
+		KeepReleases: deployKeepReleases(req.KeepReleases),
+	}
+	if deploy.Enabled && deploy.RepoURL == "" {
+		return deploy, deployInvalid("repository URL is required")
+	}
+	if deploy.RepoURL != "" && (!deployRepoURLPattern.MatchString(deploy.RepoURL) || strings.HasPrefix(deploy.RepoURL, "-")) {
+		return deploy, deployInvalid("unsupported repository URL %q", deploy.RepoURL)
+	}
+	if !deployBranchPattern.MatchString(deploy.Branch) || strings.HasPrefix(deploy.Branch, "-") || strings.Contains(deploy.Branch, "..") {
+		return deploy, deployInvalid("invalid branch %q", deploy.Branch)
+	}
+	if deploy.SSHKey != "" {
+		if strings.ContainsAny(deploy.SSHKey, `/\`) || strings.HasPrefix(deploy.SSHKey, ".") {
+			return deploy, deployInvalid("invalid deploy key %q", deploy.SSHKey)
+		}
+		if _, err := os.Stat(filepath.Join(sshKeyDir(), deploy.SSHKey)); err != nil {
+			return deploy, deployInvalid("deploy key %s not found", deploy.SSHKey)
+		}
+	}
+	for _, part := range strings.Split(deploy.PublishDir, "/") {
+		if part == ".." {
+			return deploy, deployInvalid("publish directory must stay inside the repository")
+		}
+	}
+	return deploy, nil
+}
+
+func deployBranch(branch string) string {
+	if branch == "" {
+		return "main"
+	}
+	return branch
+}
+
+func deployKeepReleases(keep int) int {
+	if keep <= 0 {
+		return defaultKeepReleases
+	}
+	return keep
+}
+
+// Deploy 手动触发部署，部署在后台执行，返回运行中的部署记录
+func (s *WebsiteService) Deploy(websiteID uint) (*model.WebsiteDeployRecord, error) {
+	return s.startDeploy(websiteID, "manual")
+}
+
+// TriggerDeployWebhook 校验 webhook 签名后触发部署，推送的分支与配置不一致时忽略并返回 nil。
+// 支持 GitHub/Gitea 的 HMAC-SHA256 签名头与 GitLab 的 X-Gitlab-Token。
+func (s *WebsiteService) TriggerDeployWebhook(websiteID uint, header http.Header, body []byte) (*model.WebsiteDeployRecord, error) {
+	deploy, err := repo.NewIWebsiteDeployRepo().GetByWebsite(websiteID)
+	if err != nil {
+		return nil, err
+	}
+	if deploy.ID == 0 || deploy.WebhookSecret == "" || !verifyDeployWebhook(deploy.WebhookSecret, header, body) {
+		return nil, buserr.New(constant.ErrWebsiteDeploySignature)
+	}
+	var payload struct {
+		Ref string `json:"ref"`
+	}
+	_ = json.Unmarshal(body, &payload)
+	if payload.Ref != "" && payload.Ref != "refs/heads/"+deployBranch(deploy.Branch) {
+		return nil, nil
+	}
+	return s.startDeploy(websiteID, "webhook")
+}
+
+func verifyDeployWebhook(secret string, header http.Header, body []byte) bool {
+	if token := header.Get("X-Gitlab-Token"); token != "" {
+		return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+	}
+	signature := strings.TrimPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+	if signature == "" {
+		signature = header.Get("X-Gitea-Signature")
+	}
+	if signature == "" {
+		return false
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+func deployLock(websiteID uint) *sync.Mutex {
+	lock, _ := deployLocks.LoadOrStore(websiteID, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
+func (s *WebsiteService) startDeploy(websiteID uint, trigger string) (*model.WebsiteDeployRecord, error) {
+	site, deploy, err := s.loadDeploy(websiteID)
+	if err != nil {
+		return nil, err
+	}
+	lock := deployLock(site.ID)
+	if !lock.TryLock() {
+		return nil, buserr.New(constant.ErrWebsiteDeployRunning)
+	}
+	record := &model.WebsiteDeployRecord{WebsiteID: site.ID, Trigger: trigger, Status: constant.StatusRunning}
+	if err := repo.NewIWebsiteDeployRepo().CreateRecord(record); err != nil {
+		lock.Unlock()
+		return nil, buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	started := *record
+	go func() {
+		defer lock.Unlock()
+		s.runDeploy(site, deploy, record)
+	}()
+	return &started, nil
+}
+
+func (s *WebsiteService) loadDeploy(websiteID uint) (model.Website, model.WebsiteDeploy, error) {
+	site, err := s.websiteRepo.Get(repo.WithByID(websiteID))
+	if err != nil {
+		return site, model.WebsiteDeploy{}, buserr.New(constant.ErrRecordNotFound)
+	}
+	deploy, err := repo.NewIWebsiteDeployRepo().GetByWebsite(websiteID)
+	if err != nil {
+		return site, deploy, err
+	}
+	if !deploy.Enabled || site.SiteDir == "" {
+		return site, deploy, deployInvalid("git deployment is not enabled for %s", site.PrimaryDomain)
+	}
+	return site, deploy, nil
+}
+
+type deployLog struct {
+	b strings.Builder
+}
+
+func (l *deployLog) step(format string, args ...any) {
+	fmt.Fprintf(&l.b, "%s %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+}
+
+func (l *deployLog) output(out string) {
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return
+	}
+	if len(out) > deployOutputLimit {
+		out = "...\n" + out[len(out)-deployOutputLimit:]
+	}
+	l.b.WriteString(out + "\n")
+}
+
+// runDeploy 拉取代码、构建并发布新版本，结果写回部署记录
+func (s *WebsiteService) runDeploy(site model.Website, deploy model.WebsiteDeploy, record *model.WebsiteDeployRecord) {
+	log := &deployLog{}
+	err := s.publishRelease(site, deploy, record, log)
+	record.Status = constant.StatusSuccess
+	if err != nil {
+		log.step("deploy failed: %v", err)
+		record.Status = constant.StatusFailed
+		global.LOG.Warnf("Deploy website %s failed: %v", site.PrimaryDomain, err)
+	} else {
+		global.LOG.Infof("Website %s deployed release %s (%s)", site.PrimaryDomain, record.Release, record.Commit)
+	}
+	record.Log = log.b.String()
+	record.FinishedAt = time.Now()
+	if err := repo.NewIWebsiteDeployRepo().SaveRecord(record); err != nil {
+		global.LOG.Warnf("Save deploy record of %s failed: %v", site.PrimaryDomain, err)
+	}
+}
+
+func (s *WebsiteService) publishRelease(site model.Website, deploy model.WebsiteDeploy, record *model.WebsiteDeployRecord, log *deployLog) error {
+	repoDir := deployRepoDir(site)
+	env := deployGitEnv(deploy)
+	branch := deployBranch(deploy.Branch)
+	git := func(args ...string) (string, error) {
+		log.step("git %s", strings.Join(args, " "))
+		out, err := deployCommand(repoDir, env, deployGitTimeout, "git", args...)
+		log.output(out)
+		return out, err
+	}
+
+	if _, err := os.Stat(filepath.Join(repoDir, ".git")); err == nil {
+		if _, err := git("remote", "set-url", "origin", deploy.RepoURL); err != nil {
+			return err
+		}
+		if _, err := git("fetch", "--depth", "1", "origin", branch); err != nil {
+			return err
+		}
+		if _, err := git("reset", "--hard", "FETCH_HEAD"); err != nil {
+			return err
+		}
+		if _, err := git("clean", "-ffdx"); err != nil {
+			return err
+		}
+	} else {
+		_ = os.RemoveAll(repoDir)
+		if err := os.MkdirAll(filepath.Dir(repoDir), 0700); err != nil {
+			return err
+		}
+		log.step("git clone %s (%s)", deploy.RepoURL, branch)
+		out, err := deployCommand(filepath.Dir(repoDir), env, deployGitTimeout, "git", "clone", "--depth", "1", "--branch", branch, "--", deploy.RepoURL, repoDir)
+		log.output(out)
+		if err != nil {
+			return err
+		}
+	}
+	commit, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	record.Commit = strings.TrimSpace(commit)
+	if subject, err := git("log", "-1", "--format=%s"); err == nil {
+		record.CommitMsg = strings.TrimSpace(subject)
+	}
+
+	if deploy.BuildCommand != "" {
+		log.step("build: %s", deploy.BuildCommand)
+		out, err := deployCommand(repoDir, env, deployBuildTimeout, "sh", "-c", deploy.BuildCommand)
+		log.output(out)
+		if err != nil {
+			return fmt.Errorf("build command failed: %v", err)
+		}
+	}
+
+	publishDir := filepath.Join(repoDir, filepath.FromSlash(deploy.PublishDir))
+	if info, err := os.Stat(publishDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("publish directory %s not found", deploy.PublishDir)
+	}
+	releasesDir := filepath.Join(site.SiteDir, deployReleasesDir)
+	if err := os.MkdirAll(releasesDir, 0755); err != nil {
+		return err
+	}
+	release := time.Now().Format("20060102150405")
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(filepath.Join(releasesDir, release)); os.IsNotExist(err) {
+			break
+		}
+		release = fmt.Sprintf("%s-%d", time.Now().Format("20060102150405"), i)
+	}
+	releaseDir := filepath.Join(releasesDir, release)
+	copied, _, err := syncSiteFiles(publishDir, releaseDir, []string{".git"}, false)
+	if err != nil {
+		_ = os.RemoveAll(releaseDir)
+		return err
+	}
+	log.step("published %d files to %s", copied, releaseDir)
+
+	if err := switchDeployRelease(site, release); err != nil {
+		_ = os.RemoveAll(releaseDir)
+		return err
+	}
+	record.Release = release
+	if err := repo.NewIWebsiteDeployRepo().SetCurrentRelease(site.ID, release); err != nil {
+		return err
+	}
+	log.step("current -> %s", release)
+	// 首次发布后 nginx root 才切换到 current
+	if deploy.CurrentRelease == "" && site.Status == "running" {
+		deploy.CurrentRelease = release
+		if err := s.applyConfig(site); err != nil {
+			return err
+		}
+		log.step("nginx root -> %s", filepath.Join(site.SiteDir, deployCurrentLink))
+	}
+	for _, removed := range pruneDeployReleases(site, deployKeepReleases(deploy.KeepReleases), release) {
+		log.step("removed old release %s", removed)
+	}
+	return nil
+}
+
+func deployGitEnv(deploy model.WebsiteDeploy) []string {
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+	if deploy.SSHKey != "" {
+		knownHosts := filepath.Join(sshKeyDir(), "known_hosts")
+		env = append(env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %s -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=%s",
+			shellQuote(filepath.Join(sshKeyDir(), deploy.SSHKey)), shellQuote(knownHosts)))
+	}
+	return env
+}
+
+// switchDeployRelease 先创建临时软链接再 rename 覆盖 current，切换过程对 nginx 是原子的
+func switchDeployRelease(site model.Website, release string) error {
+	current := filepath.Join(site.SiteDir, deployCurrentLink)
+	if info, err := os.Lstat(current); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%s exists and is not a symlink", current)
+	}
+	tmp := current + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(filepath.Join(deployReleasesDir, release), tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, current); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func listDeployReleases(site model.Website, current string) []dto.WebsiteDeployRelease {
+	releases := []dto.WebsiteDeployRelease{}
+	if site.SiteDir == "" {
+		return releases
+	}
+	entries, err := os.ReadDir(filepath.Join(site.SiteDir, deployReleasesDir))
+	if err != nil {
+		return releases
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !deployReleasePattern.MatchString(entry.Name()) {
+			continue
+		}
+		item := dto.WebsiteDeployRelease{Name: entry.Name(), Current: entry.Name() == current}
+		if info, err := entry.Info(); err == nil {
+			item.CreatedAt = info.ModTime()
+		}
+		releases = append(releases, item)
+	}
+	sort.Slice(releases, func(i, j int) bool { return releases[i].Name > releases[j].Name })
+	return releases
+}
+
+// pruneDeployReleases 保留最新的 keep 个版本，当前版本始终保留
+func pruneDeployReleases(site model.Website, keep int, current string) []string {
+	var removed []string
+	for i, release := range listDeployReleases(site, current) {
+		if i < keep || release.Name == current {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(site.SiteDir, deployReleasesDir, release.Name)); err == nil {
+			removed = append(removed, release.Name)
+		}
+	}
+	return removed
+}
+
+// RollbackDeploy 将 current 切回保留的历史版本
+func (s *WebsiteService) RollbackDeploy(req dto.WebsiteDeployRollback) (*model.WebsiteDeployRecord, error) {
+	site, deploy, err := s.loadDeploy(req.WebsiteID)
+	if err != nil {
+		return nil, err
+	}
+	if !deployReleasePattern.MatchString(req.Release) {
+		return nil, deployInvalid("invalid release %q", req.Release)
+	}
+	if info, err := os.Stat(filepath.Join(site.SiteDir, deployReleasesDir, req.Release)); err != nil || !info.IsDir() {
+		return nil, deployInvalid("release %s not found", req.Release)
+	}
+	lock := deployLock(site.ID)
+	if !lock.TryLock() {
+		return nil, buserr.New(constant.ErrWebsiteDeployRunning)
+	}
+	defer lock.Unlock()
+
+	deployRepo := repo.NewIWebsiteDeployRepo()
+	record := &model.WebsiteDeployRecord{WebsiteID: site.ID, Trigger: "rollback", Release: req.Release, Status: constant.StatusSuccess}
+	log := &deployLog{}
+	err = switchDeployRelease(site, req.Release)
+	if err == nil {
+		err = deployRepo.SetCurrentRelease(site.ID, req.Release)
+	}
+	if err != nil {
+		record.Status = constant.StatusFailed
+		log.step("rollback failed: %v", err)
+	} else {
+		log.step("current -> %s (was %s)", req.Release, deploy.CurrentRelease)
+	}
+	record.Log = log.b.String()
+	record.FinishedAt = time.Now()
+	if createErr := deployRepo.CreateRecord(record); createErr != nil {
+		global.LOG.Warnf("Save deploy record of %s failed: %v", site.PrimaryDomain, createErr)
+	}
+	if err != nil {
+		return nil, buserr.WithDetail(constant.ErrWebsiteDeployInvalid, err.Error(), err)
+	}
+	return record, nil
+}
+
+func (s *WebsiteService) SearchDeployRecords(req dto.WebsiteDeployRecordSearch) (int64, []model.WebsiteDeployRecord, error) {
+	if _, err := s.websiteRepo.Get(repo.WithByID(req.WebsiteID)); err != nil {
+		return 0, nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	return repo.NewIWebsiteDeployRepo().PageRecords(req.WebsiteID, req.Page, req.PageSize)
+}
+
+// removeDeployData 删除网站的部署配置、记录与仓库工作副本，发布目录随站点目录保留
+func removeDeployData(site model.Website) {
+	deployRepo := repo.NewIWebsiteDeployRepo()
+	if err := deployRepo.DeleteByWebsite(site.ID); err != nil {
+		global.LOG.Warnf("Delete git deployment of %s failed: %v", site.PrimaryDomain, err)
+	}
+	if err := deployRepo.DeleteRecordsByWebsite(site.ID); err != nil {
+		global.LOG.Warnf("Delete deploy records of %s failed: %v", site.PrimaryDomain, err)
+	}
+	if global.CONF.System.DataDir != "" {
+		_ = os.RemoveAll(filepath.Dir(deployRepoDir(site)))
+	}
+}
+
+// IsDeploySignatureError 判断 webhook 是否因签名校验失败被拒绝
+func IsDeploySignatureError(err error) bool {
+	var businessErr buserr.BusinessError
+	return errors.As(err, &businessErr) && businessErr.Msg == constant.ErrWebsiteDeploySignature
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/security/credentials"
+)
+
+func installWebsiteDeployTest(t *testing.T) (*WebsiteService, *model.Website) {
+	t.Helper()
+	svc := installWebsiteLocationDB(t)
+	global.CONF.System.DataDir = t.TempDir()
+	manager, _, err := credentials.LoadOrCreate(filepath.Join(t.TempDir(), "credential-keyring.json"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousCredentials := global.CREDENTIALS
+	global.CREDENTIALS = manager
+	t.Cleanup(func() { global.CREDENTIALS = previousCredentials })
+
+	site := &model.Website{PrimaryDomain: "docs.example.com", Alias: "docs_example_com", Type: "static", Status: "stopped", SiteDir: filepath.Join(t.TempDir(), "docs")}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+	return svc, site
+}
+
+func gitTestRepo(t *testing.T) (string, func(file, content string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q", "-b", "main")
+	commit := func(file, content string) {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run("add", "-A")
+		run("commit", "-q", "-m", "update "+file)
+	}
+	return dir, commit
+}
+
+func runDeployForTest(t *testing.T, svc *WebsiteService, siteID uint) *model.WebsiteDeployRecord {
+	t.Helper()
+	site, deploy, err := svc.loadDeploy(siteID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := &model.WebsiteDeployRecord{WebsiteID: siteID, Trigger: "manual", Status: constant.StatusRunning}
+	if err := repo.NewIWebsiteDeployRepo().CreateRecord(record); err != nil {
+		t.Fatal(err)
+	}
+	svc.runDeploy(site, deploy, record)
+	return record
+}
+
+func TestDeployPublishesReleasesAndRollsBack(t *testing.T) {
+	svc, site := installWebsiteDeployTest(t)
+	repoDir, commit := gitTestRepo(t)
+	commit("dist/index.html", "v1")
+
+	info, err := svc.SaveDeploy(dto.WebsiteDeployConfig{
+		WebsiteID: site.ID, Enabled: true, RepoURL: "file://" + repoDir, Branch: "main",
+		BuildCommand: "echo built > dist/build.txt", PublishDir: "dist", KeepReleases: 2,
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.WebhookSecret) != 32 || !info.HasSecret {
+		t.Fatalf("webhook secret should be generated once: %+v", info)
+	}
+
+	// 首次发布成功前 current 尚不存在，继续使用原站点目录
+	config, err := NewNginxConfigGenerator().Generate(*site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(config, "root "+site.SiteDir+";") {
+		t.Fatalf("nginx root should stay on the site directory before the first release:\n%s", config)
+	}
+
+	first := runDeployForTest(t, svc, site.ID)
+	if first.Status != constant.StatusSuccess || first.Commit == "" || first.CommitMsg != "update dist/index.html" {
+		t.Fatalf("unexpected first deploy %+v", first)
+	}
+	current := filepath.Join(site.SiteDir, "current")
+	if content, _ := os.ReadFile(filepath.Join(current, "index.html")); string(content) != "v1" {
+		t.Fatalf("current release content = %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(current, "build.txt")); err != nil {
+		t.Fatalf("build output missing: %v", err)
+	}
+
+	commit("dist/index.html", "v2")
+	second := runDeployForTest(t, svc, site.ID)
+	commit("dist/index.html", "v3")
+	third := runDeployForTest(t, svc, site.ID)
+	if second.Status != constant.StatusSuccess || third.Status != constant.StatusSuccess {
+		t.Fatalf("deploys failed:\n%s\n%s", second.Log, third.Log)
+	}
+	if content, _ := os.ReadFile(filepath.Join(current, "index.html")); string(content) != "v3" {
+		t.Fatalf("current release content = %q", content)
+	}
+	detail, _ := svc.GetDeploy(site.ID)
+	if len(detail.Releases) != 2 || detail.CurrentRelease != third.Release || detail.WebhookSecret != "" {
+		t.Fatalf("unexpected releases after pruning: %+v", detail)
+	}
+
+	if _, err := svc.RollbackDeploy(dto.WebsiteDeployRollback{WebsiteID: site.ID, Release: second.Release}); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(current, "index.html")); string(content) != "v2" {
+		t.Fatalf("rolled back content = %q", content)
+	}
+	if _, err := svc.RollbackDeploy(dto.WebsiteDeployRollback{WebsiteID: site.ID, Release: first.Release}); err == nil {
+		t.Fatal("pruned release should not be available for rollback")
+	}
+
+	config, err = NewNginxConfigGenerator().Generate(*site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(config, "root "+current+";") {
+		t.Fatalf("nginx root should point at current release:\n%s", config)
+	}
+}
+
+func TestDeployWebhookVerifiesSignatureAndBranch(t *testing.T) {
+	svc, site := installWebsiteDeployTest(t)
+	info, err := svc.SaveDeploy(dto.WebsiteDeployConfig{WebsiteID: site.ID, Enabled: true, RepoURL: "https://git.example.com/docs.git", Branch: "main"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte(`{"ref":"refs/heads/develop"}`)
+	sign := func(secret string) http.Header {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return http.Header{"X-Hub-Signature-256": {"sha256=" + hex.EncodeToString(mac.Sum(nil))}}
+	}
+
+	if _, err := svc.TriggerDeployWebhook(site.ID, sign("wrong"), body); !IsDeploySignatureError(err) {
+		t.Fatalf("expected signature error, got %v", err)
+	}
+	if _, err := svc.TriggerDeployWebhook(site.ID, http.Header{"X-Gitlab-Token": {"wrong"}}, body); !IsDeploySignatureError(err) {
+		t.Fatalf("expected gitlab token error, got %v", err)
+	}
+	record, err := svc.TriggerDeployWebhook(site.ID, sign(info.WebhookSecret), body)
+	if err != nil || record != nil {
+		t.Fatalf("push to another branch should be ignored, got %+v %v", record, err)
+	}
+
+	if _, err := svc.SaveDeploy(dto.WebsiteDeployConfig{WebsiteID: site.ID, Enabled: true, RepoURL: "--upload-pack=touch /tmp/x"}, true); err == nil {
+		t.Fatal("option-like repository URL should be rejected")
+	}
+	if _, err := svc.SaveDeploy(dto.WebsiteDeployConfig{WebsiteID: site.ID, Enabled: true, RepoURL: "https://git.example.com/docs.git", PublishDir: "../etc"}, true); err == nil {
+		t.Fatal("publish directory outside the repository should be rejected")
+	}
+}
+
+func TestDeployBuildCommandRequiresAdmin(t *testing.T) {
+	svc, site := installWebsiteDeployTest(t)
+	config := dto.WebsiteDeployConfig{WebsiteID: site.ID, Enabled: true, RepoURL: "https://git.example.com/docs.git", Branch: "main"}
+	if _, err := svc.SaveDeploy(config, false); err != nil {
+		t.Fatalf("operators may configure deployments without a build command: %v", err)
+	}
+	config.BuildCommand = "npm ci && npm run build"
+	var bizErr buserr.BusinessError
+	if _, err := svc.SaveDeploy(config, false); !errors.As(err, &bizErr) || bizErr.Msg != constant.ErrPermissionDeny {
+		t.Fatalf("operators should not set the build command, got %v", err)
+	}
+	if _, err := svc.SaveDeploy(config, true); err != nil {
+		t.Fatal(err)
+	}
+	config.RepoURL = "https://git.example.com/other.git"
+	if _, err := svc.SaveDeploy(config, false); !errors.As(err, &bizErr) || bizErr.Msg != constant.ErrPermissionDeny {
+		t.Fatalf("operators should not change the repository built by an admin command, got %v", err)
+	}
+	config.RepoURL = "https://git.example.com/docs.git"
+	config.KeepReleases = 3
+	if _, err := svc.SaveDeploy(config, false); err != nil {
+		t.Fatalf("operators may change other settings: %v", err)
+	}
+}
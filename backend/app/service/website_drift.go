@@ -0,0 +1,361 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/utils/diff"
+)
+
+const (
+	driftActionAdopt     = "adopt"
+	driftActionOverwrite = "overwrite"
+	driftActionSource    = "source"
+
+	driftDiffContext = 3
+)
+
+func driftInvalid(format string, args ...interface{}) error {
+	return buserr.WithDetail(constant.ErrWebsiteConfigDrift, fmt.Sprintf(format, args...), nil)
+}
+
+// driftTracked 只有运行中、由面板生成配置的网站才检测漂移：
+// 停止的网站在 prefix 模式下没有配置文件，源码模式和外部网站本就不由面板渲染
+func driftTracked(site model.Website) bool {
+	return site.NginxConfPath == "" && site.ConfigMode != "source" && site.Status == "running"
+}
+
+// CheckWebsiteConfigDrift 定时任务：重新渲染所有托管网站并与磁盘配置比较，记录漂移
+func CheckWebsiteConfigDrift() {
+	if !global.CONF.Nginx.IsInstalled() {
+		return
+	}
+	s := &WebsiteService{websiteRepo: repo.NewIWebsiteRepo(), certRepo: repo.NewICertificateRepo()}
+	sites, err := s.websiteRepo.GetList()
+	if err != nil {
+		global.LOG.Errorf("Load websites for config drift check failed: %v", err)
+		return
+	}
+	for _, site := range sites {
+		if _, err := s.recordConfigDrift(site); err != nil {
+			global.LOG.Warnf("Check nginx config drift of %s failed: %v", site.PrimaryDomain, err)
+		}
+	}
+}
+
+// CheckConfigDrift 立即检测单个网站的配置漂移
+func (s *WebsiteService) CheckConfigDrift(id uint) (*dto.WebsiteConfigDriftInfo, error) {
+	site, err := s.websiteRepo.Get(repo.WithByID(id))
+	if err != nil {
+		return nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	if site.NginxConfPath != "" {
+		return nil, buserr.New(constant.ErrWebsiteExternalOperationDenied)
+	}
+	drift, err := s.recordConfigDrift(site)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.WebsiteConfigDriftInfo{
+		WebsiteID:  site.ID,
+		ConfPath:   s.getSiteConfWritePath(site.Alias),
+		Drifted:    drift.ID > 0,
+		Missing:    drift.Missing,
+		Diff:       drift.Diff,
+		DetectedAt: drift.DetectedAt,
+		CheckedAt:  drift.CheckedAt,
+	}, nil
+}
+
+// recordConfigDrift 比较渲染结果与磁盘文件并更新漂移记录；无漂移时删除记录并返回零值
+func (s *WebsiteService) recordConfigDrift(site model.Website) (model.WebsiteConfigDrift, error) {
+	driftRepo := repo.NewIWebsiteConfigDriftRepo()
+	if !driftTracked(site) {
+		return model.WebsiteConfigDrift{}, driftRepo.DeleteByWebsite(site.ID)
+	}
+	rendered, onDisk, missing, err := s.loadSiteConfigs(site)
+	if err != nil {
+		return model.WebsiteConfigDrift{}, err
+	}
+	confPath := s.getSiteConfWritePath(site.Alias)
+	changes := ""
+	if !missing {
+		changes = diff.Unified(confPath+" (rendered)", confPath, rendered, onDisk, driftDiffContext)
+	}
+	if !missing && changes == "" {
+		return model.WebsiteConfigDrift{}, driftRepo.DeleteByWebsite(site.ID)
+	}
+
+	drift, err := driftRepo.GetByWebsite(site.ID)
+	if err != nil {
+		return drift, err
+	}
+	now := time.Now()
+	if drift.ID == 0 {
+		drift.DetectedAt = now
+		global.LOG.Warnf("Nginx config of %s was modified outside the panel: %s", site.PrimaryDomain, confPath)
+	}
+	drift.Missing = missing
+	drift.Diff = changes
+	drift.CheckedAt = now
+	return drift, driftRepo.Save(&drift)
+}
+
+// loadSiteConfigs 返回面板渲染的配置与磁盘上的配置
+func (s *WebsiteService) loadSiteConfigs(site model.Website) (rendered, onDisk string, missing bool, err error) {
+	if rendered, err = NewNginxConfigGenerator().Generate(site); err != nil {
+		return "", "", false, err
+	}
+	data, err := os.ReadFile(s.getSiteConfWritePath(site.Alias))
+	if errors.Is(err, os.ErrNotExist) {
+		return rendered, "", true, nil
+	}
+	if err != nil {
+		return "", "", false, err
+	}
+	return rendered, string(data), false, nil
+}
+
+// configDriftSites 返回存在漂移记录的网站 ID，用于网站列表标记
+func configDriftSites() map[uint]bool {
+	drifts, err := repo.NewIWebsiteConfigDriftRepo().List()
+	if err != nil {
+		return nil
+	}
+	result := make(map[uint]bool, len(drifts))
+	for _, drift := range drifts {
+		result[drift.WebsiteID] = true
+	}
+	return result
+}
+
+// ResolveConfigDrift 处理漂移：adopt 将磁盘上新增的 server 级指令并入 CustomNginx 后重新生成，
+// overwrite 直接用渲染结果覆盖，source 切换为源码模式保留磁盘文件
+func (s *WebsiteService) ResolveConfigDrift(req dto.WebsiteConfigDriftResolve) (*dto.WebsiteConfigDriftResolveResult, error) {
+	site, err := s.websiteRepo.Get(repo.WithByID(req.WebsiteID))
+	if err != nil {
+		return nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	if site.NginxConfPath != "" {
+		return nil, buserr.New(constant.ErrWebsiteExternalOperationDenied)
+	}
+	if !driftTracked(site) {
+		return nil, driftInvalid("website is not running in managed mode")
+	}
+
+	result := &dto.WebsiteConfigDriftResolveResult{Action: req.Action}
+	switch req.Action {
+	case driftActionOverwrite:
+		err = s.applyConfig(site)
+	case driftActionSource:
+		err = s.SwitchConfigMode(site.ID, "source")
+	case driftActionAdopt:
+		err = s.adoptConfigDrift(site, result)
+	default:
+		return nil, buserr.New(constant.ErrInvalidParams)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.NewIWebsiteConfigDriftRepo().DeleteByWebsite(site.ID); err != nil {
+		global.LOG.Warnf("Clear config drift of %s failed: %v", site.PrimaryDomain, err)
+	}
+	return result, nil
+}
+
+func (s *WebsiteService) adoptConfigDrift(site model.Website, result *dto.WebsiteConfigDriftResolveResult) error {
+	rendered, onDisk, missing, err := s.loadSiteConfigs(site)
+	if err != nil {
+		return err
+	}
+	if missing {
+		return driftInvalid("config file %s does not exist", s.getSiteConfWritePath(site.Alias))
+	}
+	result.Adopted, result.Dropped = splitDriftChanges(diff.SplitLines(rendered), diff.SplitLines(onDisk))
+	if len(result.Adopted) == 0 {
+		return driftInvalid("no server-level directives to adopt, use overwrite or source mode instead")
+	}
+
+	previous := site.CustomNginx
+	custom := strings.TrimRight(previous, "\n")
+	for _, block := range result.Adopted {
+		if custom != "" {
+			custom += "\n"
+		}
+		custom += block
+	}
+	site.CustomNginx = custom
+	if err := s.websiteRepo.Save(&site); err != nil {
+		return err
+	}
+	if err := s.applyConfig(site); err != nil {
+		site.CustomNginx = previous
+		if restoreErr := s.websiteRepo.Save(&site); restoreErr != nil {
+			global.LOG.Errorf("Restore custom nginx of %s failed: %v", site.PrimaryDomain, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// splitDriftChanges 将磁盘相对渲染结果新增的连续行按块归类：位于 server 块顶层且括号配平的块可以并入
+// CustomNginx（相同块只保留一份，HTTP/HTTPS 两个 server 常被同时修改）；其余改动无法保留
+func splitDriftChanges(rendered, onDisk []string) (adopted, dropped []string) {
+	contexts := serverLevelLines(onDisk)
+	seen := map[string]bool{}
+	var chunk []string
+	chunkAdoptable := false
+	flush := func() {
+		if len(chunk) == 0 {
+			return
+		}
+		if chunkAdoptable && bracesBalanced(chunk) {
+			block := dedentLines(chunk)
+			if key := normalizeDriftBlock(block); key != "" && !seen[key] {
+				seen[key] = true
+				adopted = append(adopted, block)
+			}
+		} else {
+			for _, line := range chunk {
+				if strings.TrimSpace(line) != "" {
+					dropped = append(dropped, "+"+strings.TrimSpace(line))
+				}
+			}
+		}
+		chunk = nil
+	}
+
+	// 紧跟在删除行之后的同等数量新增行视为对原指令的修改，CustomNginx 只能追加指令，无法表达修改
+	diskIndex, replaced := 0, 0
+	for _, line := range diff.Lines(rendered, onDisk) {
+		switch line.Kind {
+		case diff.Insert:
+			if replaced > 0 {
+				replaced--
+				if strings.TrimSpace(line.Text) != "" {
+					dropped = append(dropped, "+"+strings.TrimSpace(line.Text))
+				}
+			} else {
+				if len(chunk) == 0 {
+					chunkAdoptable = contexts[diskIndex]
+				}
+				chunk = append(chunk, line.Text)
+			}
+			diskIndex++
+		case diff.Delete:
+			flush()
+			replaced++
+			if strings.TrimSpace(line.Text) != "" {
+				dropped = append(dropped, "-"+strings.TrimSpace(line.Text))
+			}
+		default:
+			flush()
+			replaced = 0
+			diskIndex++
+		}
+	}
+	flush()
+	return adopted, dropped
+}
+
+// serverLevelLines 标记每一行开始时是否正处于 server 块的顶层
+func serverLevelLines(lines []string) []bool {
+	result := make([]bool, len(lines))
+	var stack []string
+	for i, line := range lines {
+		result[i] = len(stack) == 1 && stack[0] == "server"
+		code := stripNginxComment(line)
+		for pos, ch := range code {
+			switch ch {
+			case '{':
+				name := ""
+				if fields := strings.Fields(code[:pos]); len(fields) > 0 {
+					name = fields[0]
+				}
+				stack = append(stack, name)
+			case '}':
+				if len(stack) > 0 {
+					stack = stack[:len(stack)-1]
+				}
+			}
+		}
+	}
+	return result
+}
+
+func bracesBalanced(lines []string) bool {
+	depth := 0
+	for _, line := range lines {
+		code := stripNginxComment(line)
+		for _, ch := range code {
+			switch ch {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth < 0 {
+					return false
+				}
+			}
+		}
+	}
+	return depth == 0
+}
+
+func stripNginxComment(line string) string {
+	quote := rune(0)
+	for i, ch := range line {
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// dedentLines 去掉块的公共缩进，写入 CustomNginx 时由生成器统一缩进
+func dedentLines(lines []string) string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || width < indent {
+			indent = width
+		}
+	}
+	var out []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		out = append(out, strings.TrimRight(line[indent:], " \t"))
+	}
+	return strings.Join(out, "\n")
+}
+
+func normalizeDriftBlock(block string) string {
+	var lines []string
+	for _, line := range strings.Split(block, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/global"
+)
+
+func TestConfigDriftDetectsAndAdoptsServerDirectives(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	installFakeNginx(t, false)
+	global.CONF.System.DataDir = t.TempDir()
+	site := &model.Website{PrimaryDomain: "blog.example.com", Alias: "blog_example_com", Type: "static", Status: "running", SiteDir: t.TempDir()}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.applyConfig(*site); err != nil {
+		t.Fatal(err)
+	}
+	if drift, err := svc.recordConfigDrift(*site); err != nil || drift.ID != 0 {
+		t.Fatalf("freshly rendered config should not drift: %+v %v", drift, err)
+	}
+
+	confPath := GetSiteConfPath(site.Alias)
+	content, err := os.ReadFile(confPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverName := "    server_name blog.example.com;\n"
+	if !strings.Contains(string(content), serverName) {
+		t.Fatalf("unexpected rendered config:\n%s", content)
+	}
+	edited := strings.Replace(string(content), serverName, serverName+"    add_header X-Debug 1;\n", 1)
+	edited += "upstream extra {\n    server 127.0.0.1:9000;\n}\n"
+	if err := os.WriteFile(confPath, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	CheckWebsiteConfigDrift()
+	info, err := svc.CheckConfigDrift(site.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Drifted || !strings.Contains(info.Diff, "+    add_header X-Debug 1;") {
+		t.Fatalf("drift not detected: %+v", info)
+	}
+	_, items, _ := svc.SearchWithPage(dto.WebsiteSearch{PageInfo: dto.PageInfo{Page: 1, PageSize: 10}})
+	if len(items) != 1 || !items[0].ConfigDrift {
+		t.Fatalf("site list should flag drift: %+v", items)
+	}
+
+	result, err := svc.ResolveConfigDrift(dto.WebsiteConfigDriftResolve{WebsiteID: site.ID, Action: "adopt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Adopted) != 1 || result.Adopted[0] != "add_header X-Debug 1;" {
+		t.Fatalf("unexpected adopted blocks %+v", result.Adopted)
+	}
+	if len(result.Dropped) != 3 || !strings.Contains(strings.Join(result.Dropped, "\n"), "+upstream extra {") {
+		t.Fatalf("http-level block should be dropped: %+v", result.Dropped)
+	}
+	saved, _ := svc.websiteRepo.Get(repo.WithByID(site.ID))
+	if saved.CustomNginx != "add_header X-Debug 1;" {
+		t.Fatalf("custom nginx = %q", saved.CustomNginx)
+	}
+	if info, _ := svc.CheckConfigDrift(site.ID); info.Drifted {
+		t.Fatalf("drift should be resolved after adopting:\n%s", info.Diff)
+	}
+
+	if err := os.Remove(confPath); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := svc.CheckConfigDrift(site.ID); !info.Drifted || !info.Missing {
+		t.Fatalf("missing config should be reported: %+v", info)
+	}
+	if _, err := svc.ResolveConfigDrift(dto.WebsiteConfigDriftResolve{WebsiteID: site.ID, Action: "overwrite"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(confPath); err != nil {
+		t.Fatalf("overwrite should rewrite the config: %v", err)
+	}
+	if drift, _ := repo.NewIWebsiteConfigDriftRepo().GetByWebsite(site.ID); drift.ID != 0 {
+		t.Fatal("drift record should be cleared after overwrite")
+	}
+}
+
+func TestSplitDriftChangesKeepsOnlyServerLevelBlocks(t *testing.T) {
+	rendered := []string{
+		"server {",
+		"    listen 80;",
+		"    location / {",
+		"        try_files $uri $uri/ =404;",
+		"    }",
+		"}",
+		"server {",
+		"    listen 443 ssl;",
+		"}",
+	}
+	onDisk := []string{
+		"server {",
+		"    listen 8080;",
+		"    location /status {",
+		"        stub_status;",
+		"    }",
+		"    location / {",
+		"        try_files $uri $uri/ =404;",
+		"        expires 1h;",
+		"    }",
+		"}",
+		"server {",
+		"    listen 443 ssl;",
+		"    location /status {",
+		"        stub_status;",
+		"    }",
+		"}",
+	}
+	adopted, dropped := splitDriftChanges(rendered, onDisk)
+	if len(adopted) != 1 || adopted[0] != "location /status {\n    stub_status;\n}" {
+		t.Fatalf("unexpected adopted blocks %q", adopted)
+	}
+	want := []string{"-listen 80;", "+listen 8080;", "+expires 1h;"}
+	if strings.Join(dropped, "|") != strings.Join(want, "|") {
+		t.Fatalf("dropped = %q, want %q", dropped, want)
+	}
+}
@@ -23,7 +23,7 @@ func openWebsiteExternalTestDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatalf("open website test database: %v", err)
 	}
-	if err := db.AutoMigrate(&model.Website{}, &model.Certificate{}, &model.Setting{}); err != nil {
+	if err := db.AutoMigrate(&model.Website{}, &model.Certificate{}, &model.Setting{}, &model.AccessStat{}, &model.AccessStatTop{}, &model.AccessLogCursor{}, &model.LogRotatePolicy{}); err != nil {
 		t.Fatalf("migrate website: %v", err)
 	}
 	previous := global.DB
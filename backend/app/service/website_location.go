@@ -0,0 +1,267 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+)
+
+var (
+	locationHeaderName = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+	locationCacheValid = regexp.MustCompile(`^\d+[smhd]?$`)
+	locationBodySize   = regexp.MustCompile(`^\d+[kKmMgG]?$`)
+)
+
+// locationMatchModifiers location 匹配方式对应的 nginx 修饰符
+var locationMatchModifiers = map[string]string{
+	"prefix":   "",
+	"priority": "^~ ",
+	"exact":    "= ",
+	"regex":    "~ ",
+	"regex_i":  "~* ",
+}
+
+func (s *WebsiteService) ListLocations(websiteID uint) ([]dto.WebsiteLocationItem, error) {
+	if _, err := s.websiteRepo.Get(repo.WithByID(websiteID)); err != nil {
+		return nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	locations, err := repo.NewIWebsiteLocationRepo().ListByWebsite(websiteID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]dto.WebsiteLocationItem, 0, len(locations))
+	for _, location := range locations {
+		items = append(items, toWebsiteLocationItem(location))
+	}
+	return items, nil
+}
+
+// SaveLocations 整体替换网站的 location 列表；运行中的托管网站立即应用，
+// 配置测试失败时恢复原列表。
+func (s *WebsiteService) SaveLocations(req dto.WebsiteLocationSave) error {
+	site, err := s.websiteRepo.Get(repo.WithByID(req.WebsiteID))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	if site.NginxConfPath != "" {
+		return buserr.New(constant.ErrWebsiteExternalOperationDenied)
+	}
+	locations, err := buildWebsiteLocations(req.Locations)
+	if err != nil {
+		return err
+	}
+	upstreams, err := repo.NewIWebsiteUpstreamRepo().ListByWebsite(site.ID)
+	if err != nil {
+		return err
+	}
+	owned := make(map[uint]bool, len(upstreams))
+	for _, upstream := range upstreams {
+		owned[upstream.ID] = true
+	}
+	for _, location := range locations {
+		if location.UpstreamID > 0 && !owned[location.UpstreamID] {
+			return locationInvalid("upstream %d of %s does not belong to this website", location.UpstreamID, location.Path)
+		}
+	}
+
+	locationRepo := repo.NewIWebsiteLocationRepo()
+	previous, err := locationRepo.ListByWebsite(site.ID)
+	if err != nil {
+		return err
+	}
+	if err := locationRepo.ReplaceForWebsite(site.ID, locations); err != nil {
+		return buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	if site.Status != "running" || site.ConfigMode == "source" {
+		return nil
+	}
+	if err := s.applyConfig(site); err != nil {
+		_ = locationRepo.ReplaceForWebsite(site.ID, previous)
+		return err
+	}
+	return nil
+}
+
+func buildWebsiteLocations(items []dto.WebsiteLocationItem) ([]model.WebsiteLocation, error) {
+	seen := make(map[string]bool, len(items))
+	locations := make([]model.WebsiteLocation, 0, len(items))
+	for i, item := range items {
+		if item.Match == "" {
+			item.Match = "prefix"
+		}
+		if err := validateWebsiteLocation(item); err != nil {
+			return nil, err
+		}
+		key := item.Match + " " + item.Path
+		if seen[key] {
+			return nil, locationInvalid("duplicate location %s", item.Path)
+		}
+		seen[key] = true
+		requestHeaders, _ := json.Marshal(item.RequestHeaders)
+		responseHeaders, _ := json.Marshal(item.ResponseHeaders)
+		sortValue := item.Sort
+		if sortValue == 0 {
+			sortValue = i + 1
+		}
+		locations = append(locations, model.WebsiteLocation{
+			Path:              strings.TrimSpace(item.Path),
+			Match:             item.Match,
+			Type:              item.Type,
+			Sort:              sortValue,
+			ProxyPass:         strings.TrimSpace(item.ProxyPass),
+			UpstreamID:        item.UpstreamID,
+			ProxyHost:         strings.TrimSpace(item.ProxyHost),
+			WebSocket:         item.WebSocket,
+			ConnectTimeout:    item.ConnectTimeout,
+			SendTimeout:       item.SendTimeout,
+			ReadTimeout:       item.ReadTimeout,
+			DisableBuffering:  item.DisableBuffering,
+			RequestHeaders:    string(requestHeaders),
+			ResponseHeaders:   string(responseHeaders),
+			CacheEnable:       item.CacheEnable,
+			CacheValid:        strings.TrimSpace(item.CacheValid),
+			Root:              strings.TrimSpace(item.Root),
+			ClientMaxBodySize: strings.TrimSpace(item.ClientMaxBodySize),
+			Remark:            item.Remark,
+		})
+	}
+	return locations, nil
+}
+
+func locationInvalid(format string, args ...interface{}) error {
+	return buserr.WithDetail(constant.ErrWebsiteLocationInvalid, fmt.Sprintf(format, args...), nil)
+}
+
+// validateWebsiteLocation 所有字段都会原样写入 nginx 配置，拒绝可能跳出指令的字符
+func validateWebsiteLocation(item dto.WebsiteLocationItem) error {
+	path := strings.TrimSpace(item.Path)
+	if _, ok := locationMatchModifiers[item.Match]; !ok {
+		return locationInvalid("unknown match %s", item.Match)
+	}
+	values := []string{path, item.ProxyPass, item.ProxyHost, item.CacheValid, item.Root, item.ClientMaxBodySize}
+	for _, header := range append(append([]dto.WebsiteLocationHeader{}, item.RequestHeaders...), item.ResponseHeaders...) {
+		if !locationHeaderName.MatchString(header.Name) {
+			return locationInvalid("invalid header name %q", header.Name)
+		}
+		values = append(values, header.Value)
+	}
+	for _, value := range values {
+		if strings.ContainsAny(value, ";{}\"\r\n") {
+			return locationInvalid("%q contains forbidden characters", value)
+		}
+	}
+	if path == "" || strings.ContainsAny(path, " \t") {
+		return locationInvalid("invalid path %q", item.Path)
+	}
+	if !strings.HasPrefix(item.Match, "regex") && !strings.HasPrefix(path, "/") {
+		return locationInvalid("path %s must start with /", path)
+	}
+	switch item.Type {
+	case "proxy":
+		if item.UpstreamID > 0 {
+			break
+		}
+		if !strings.HasPrefix(item.ProxyPass, "http://") && !strings.HasPrefix(item.ProxyPass, "https://") {
+			return locationInvalid("proxy_pass of %s must start with http:// or https:// or use an upstream", path)
+		}
+	case "static":
+		if !filepath.IsAbs(item.Root) {
+			return locationInvalid("root of %s must be an absolute path", path)
+		}
+		if strings.HasPrefix(item.Match, "regex") {
+			return locationInvalid("static location %s cannot use a regex match", path)
+		}
+	default:
+		return locationInvalid("unknown type %s", item.Type)
+	}
+	if item.CacheValid != "" && !locationCacheValid.MatchString(item.CacheValid) {
+		return locationInvalid("invalid cache duration %s", item.CacheValid)
+	}
+	if item.ClientMaxBodySize != "" && !locationBodySize.MatchString(item.ClientMaxBodySize) {
+		return locationInvalid("invalid body size %s", item.ClientMaxBodySize)
+	}
+	return nil
+}
+
+func toWebsiteLocationItem(location model.WebsiteLocation) dto.WebsiteLocationItem {
+	return dto.WebsiteLocationItem{
+		ID:                location.ID,
+		Path:              location.Path,
+		Match:             location.Match,
+		Type:              location.Type,
+		Sort:              location.Sort,
+		ProxyPass:         location.ProxyPass,
+		UpstreamID:        location.UpstreamID,
+		ProxyHost:         location.ProxyHost,
+		WebSocket:         location.WebSocket,
+		ConnectTimeout:    location.ConnectTimeout,
+		SendTimeout:       location.SendTimeout,
+		ReadTimeout:       location.ReadTimeout,
+		DisableBuffering:  location.DisableBuffering,
+		RequestHeaders:    parseLocationHeaders(location.RequestHeaders),
+		ResponseHeaders:   parseLocationHeaders(location.ResponseHeaders),
+		CacheEnable:       location.CacheEnable,
+		CacheValid:        location.CacheValid,
+		Root:              location.Root,
+		ClientMaxBodySize: location.ClientMaxBodySize,
+		Remark:            location.Remark,
+	}
+}
+
+func parseLocationHeaders(raw string) []dto.WebsiteLocationHeader {
+	headers := []dto.WebsiteLocationHeader{}
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &headers)
+	}
+	return headers
+}
+
+func sortWebsiteLocations(locations []model.WebsiteLocation) {
+	sort.SliceStable(locations, func(i, j int) bool {
+		if locations[i].Sort != locations[j].Sort {
+			return locations[i].Sort < locations[j].Sort
+		}
+		return locations[i].ID < locations[j].ID
+	})
+}
+
+func locationsHaveRoot(locations []model.WebsiteLocation) bool {
+	for _, location := range locations {
+		if location.Path == "/" && (location.Match == "prefix" || location.Match == "priority") {
+			return true
+		}
+	}
+	return false
+}
+
+// locationsHavePHP 结构化 location 中是否已有处理 .php 的正则 location
+func locationsHavePHP(locations []model.WebsiteLocation) bool {
+	for _, location := range locations {
+		if strings.HasPrefix(location.Match, "regex") && strings.Contains(location.Path, `\.php`) {
+			return true
+		}
+	}
+	return false
+}
+
+func locationsNeedCache(locations []model.WebsiteLocation) bool {
+	for _, location := range locations {
+		if location.Type == "proxy" && location.CacheEnable {
+			return true
+		}
+	}
+	return false
+}
+
+func locationCacheZone(site model.Website) string {
+	return "xpanel_" + site.Alias
+}
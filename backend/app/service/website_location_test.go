@@ -0,0 +1,159 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/global"
+
+	"github.com/glebarez/sqlite"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func installWebsiteLocationDB(t *testing.T) *WebsiteService {
+	t.Helper()
+	database, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := database.AutoMigrate(&model.Website{}, &model.WebsiteLocation{}, &model.WebsiteUpstream{}, &model.WebsiteWAF{}, &model.WebsiteAccess{}, &model.WebsiteDeploy{}, &model.WebsiteDeployRecord{}, &model.WebsiteConfigDrift{}, &model.AccessStat{}, &model.AccessStatTop{}, &model.AccessLogCursor{}, &model.LogRotatePolicy{}); err != nil {
+		t.Fatalf("migrate websites: %v", err)
+	}
+	previousDB, previousConf, previousLog := global.DB, global.CONF, global.LOG
+	global.DB = database
+	global.LOG = logrus.New()
+	global.CONF.Nginx = global.NginxConfig{InstallDir: t.TempDir(), Mode: "prefix"}
+	t.Cleanup(func() {
+		global.DB = previousDB
+		global.CONF = previousConf
+		global.LOG = previousLog
+	})
+	return NewIWebsiteService().(*WebsiteService)
+}
+
+func TestSaveLocationsRendersPerLocationOptions(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	site := &model.Website{
+		PrimaryDomain: "app.example.com", Alias: "app_example_com", Type: "static", Status: "stopped",
+		SiteDir: "/var/www/app", HttpConfig: "HTTPSRedirect",
+	}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+	err := svc.SaveLocations(dto.WebsiteLocationSave{WebsiteID: site.ID, Locations: []dto.WebsiteLocationItem{
+		{
+			Path: "/api/", Type: "proxy", ProxyPass: "http://127.0.0.1:8080", ReadTimeout: 30,
+			CacheEnable: true, CacheValid: "5m", ClientMaxBodySize: "20m",
+			RequestHeaders:  []dto.WebsiteLocationHeader{{Name: "X-Env", Value: "prod"}},
+			ResponseHeaders: []dto.WebsiteLocationHeader{{Name: "X-Powered-By"}},
+		},
+		{Path: "/ws", Type: "proxy", ProxyPass: "http://127.0.0.1:9000", WebSocket: true},
+		{Path: "/", Type: "static", Root: "/srv/app/dist"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := svc.ListLocations(site.ID)
+	if err != nil || len(items) != 3 || items[0].RequestHeaders[0].Value != "prod" {
+		t.Fatalf("locations = %+v, err = %v", items, err)
+	}
+
+	config, err := NewNginxConfigGenerator().Generate(*site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"proxy_cache_path ",
+		"keys_zone=xpanel_app_example_com:10m",
+		"location /api/ {\n        client_max_body_size 20m;\n        proxy_pass http://127.0.0.1:8080;",
+		"proxy_read_timeout 30s;",
+		"proxy_cache_valid 200 301 302 5m;",
+		"proxy_set_header X-Env \"prod\";",
+		"proxy_hide_header X-Powered-By;",
+		"location /ws {",
+		"proxy_set_header Upgrade $http_upgrade;",
+		"proxy_read_timeout 86400s;",
+		"root /srv/app/dist;",
+	} {
+		if !strings.Contains(config, want) {
+			t.Fatalf("config missing %q:\n%s", want, config)
+		}
+	}
+	// 结构化的 location / 取代托管的静态站点入口
+	if strings.Count(config, "location / {") != 1 || strings.Contains(config, "root /var/www/app;") {
+		t.Fatalf("managed root location should be replaced:\n%s", config)
+	}
+}
+
+func TestSaveLocationsRejectsUnsafeValues(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	site := &model.Website{PrimaryDomain: "b.example.com", Alias: "b_example_com", Type: "static", Status: "stopped"}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+	cases := []dto.WebsiteLocationItem{
+		{Path: "/api", Type: "proxy", ProxyPass: "http://a; include /etc/passwd"},
+		{Path: "api", Type: "proxy", ProxyPass: "http://a"},
+		{Path: "/x", Type: "proxy", ProxyPass: "ftp://a"},
+		{Path: "/x", Type: "proxy", ProxyPass: "http://a", RequestHeaders: []dto.WebsiteLocationHeader{{Name: "X Bad", Value: "1"}}},
+		{Path: "/x", Type: "static", Root: "relative/dir"},
+		{Path: "\\.php$", Match: "regex", Type: "static", Root: "/srv"},
+		{Path: "/x", Type: "proxy", ProxyPass: "http://a", CacheValid: "forever"},
+	}
+	for _, item := range cases {
+		if err := svc.SaveLocations(dto.WebsiteLocationSave{WebsiteID: site.ID, Locations: []dto.WebsiteLocationItem{item}}); err == nil {
+			t.Fatalf("location %+v should be rejected", item)
+		}
+	}
+	duplicate := []dto.WebsiteLocationItem{
+		{Path: "/a", Type: "proxy", ProxyPass: "http://a"},
+		{Path: "/a", Type: "proxy", ProxyPass: "http://b"},
+	}
+	if err := svc.SaveLocations(dto.WebsiteLocationSave{WebsiteID: site.ID, Locations: duplicate}); err == nil {
+		t.Fatal("duplicate locations should be rejected")
+	}
+	stored, _ := repo.NewIWebsiteLocationRepo().ListByWebsite(site.ID)
+	if len(stored) != 0 {
+		t.Fatalf("rejected saves must not persist, got %+v", stored)
+	}
+}
+
+func TestPHPSiteKeepsFastCGIWithRootLocation(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	installFakePHPFPM(t, "8.3")
+	site := &model.Website{
+		PrimaryDomain: "php.example.com", Alias: "php_example_com", Type: "php", Status: "stopped",
+		SiteDir: "/var/www/php", PHPVersion: "8.3", HttpConfig: "HTTPSRedirect",
+	}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SaveLocations(dto.WebsiteLocationSave{WebsiteID: site.ID, Locations: []dto.WebsiteLocationItem{
+		{Path: "/", Type: "proxy", ProxyPass: "http://127.0.0.1:8080"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	config, err := NewNginxConfigGenerator().Generate(*site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 只让出 location /，PHP 文件仍交给 php-fpm
+	if strings.Count(config, "location / {") != 1 || !strings.Contains(config, "root /var/www/php;") ||
+		!strings.Contains(config, "location ~ [^/]\\.php(/|$) {") {
+		t.Fatalf("php site should keep root and fastcgi with a custom root location:\n%s", config)
+	}
+
+	site.CustomNginx = "location ~ \\.php$ {\n    return 403;\n}"
+	config, err = NewNginxConfigGenerator().Generate(*site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(config, "fastcgi_pass") {
+		t.Fatalf("custom php location should replace the managed fastcgi block:\n%s", config)
+	}
+}
@@ -0,0 +1,332 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+)
+
+const upstreamErrorLogLines = 5000
+
+var (
+	upstreamNamePattern    = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	upstreamAddressPattern = regexp.MustCompile(`^(unix:/[^\s;{}"]+|[A-Za-z0-9.\-]+(:\d+)?|\[[0-9A-Fa-f:.]+\](:\d+)?)$`)
+	upstreamHashKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_$ ]+$`)
+
+	// 错误日志：2026/01/02 15:04:05 [error] 123#0: *45 connect() failed (...) while connecting to upstream, ..., upstream: "http://10.0.0.1:8080/", host: "..."
+	errorLogTimePattern     = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}) \[\w+\] \d+#\d+: (?:\*\d+ )?(.*?)(?:, client: |$)`)
+	errorLogUpstreamPattern = regexp.MustCompile(`upstream: "[a-z]+://([^/"]+)`)
+)
+
+// upstreamProbe 主动探测单个后端，测试中可替换
+var upstreamProbe = probeUpstreamServer
+
+func upstreamName(site model.Website, upstream model.WebsiteUpstream) string {
+	return site.Alias + "_" + upstream.Name
+}
+
+func (s *WebsiteService) ListUpstreams(websiteID uint) ([]dto.WebsiteUpstreamItem, error) {
+	if _, err := s.websiteRepo.Get(repo.WithByID(websiteID)); err != nil {
+		return nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	upstreams, err := repo.NewIWebsiteUpstreamRepo().ListByWebsite(websiteID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]dto.WebsiteUpstreamItem, 0, len(upstreams))
+	for _, upstream := range upstreams {
+		items = append(items, dto.WebsiteUpstreamItem{
+			ID:         upstream.ID,
+			Name:       upstream.Name,
+			Balance:    upstream.Balance,
+			HashKey:    upstream.HashKey,
+			Keepalive:  upstream.Keepalive,
+			HealthPath: upstream.HealthPath,
+			Servers:    parseUpstreamServers(upstream.Servers),
+			Remark:     upstream.Remark,
+		})
+	}
+	return items, nil
+}
+
+// SaveUpstreams 保存网站的 upstream 列表；仍被 location 引用的 upstream 不允许删除，
+// 运行中的托管网站立即应用，失败时恢复原列表。
+func (s *WebsiteService) SaveUpstreams(req dto.WebsiteUpstreamSave) error {
+	site, err := s.websiteRepo.Get(repo.WithByID(req.WebsiteID))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	if site.NginxConfPath != "" {
+		return buserr.New(constant.ErrWebsiteExternalOperationDenied)
+	}
+	upstreamRepo := repo.NewIWebsiteUpstreamRepo()
+	previous, err := upstreamRepo.ListByWebsite(site.ID)
+	if err != nil {
+		return err
+	}
+	owned := make(map[uint]bool, len(previous))
+	for _, upstream := range previous {
+		owned[upstream.ID] = true
+	}
+
+	upstreams := make([]model.WebsiteUpstream, 0, len(req.Upstreams))
+	kept := make(map[uint]bool)
+	names := make(map[string]bool)
+	for _, item := range req.Upstreams {
+		if item.ID > 0 && !owned[item.ID] {
+			return upstreamInvalid("upstream %d does not belong to this website", item.ID)
+		}
+		if names[item.Name] {
+			return upstreamInvalid("duplicate upstream %s", item.Name)
+		}
+		names[item.Name] = true
+		upstream, err := buildWebsiteUpstream(item)
+		if err != nil {
+			return err
+		}
+		kept[item.ID] = true
+		upstreams = append(upstreams, upstream)
+	}
+
+	locations, err := repo.NewIWebsiteLocationRepo().ListByWebsite(site.ID)
+	if err != nil {
+		return err
+	}
+	for _, location := range locations {
+		if location.UpstreamID > 0 && !kept[location.UpstreamID] {
+			return buserr.WithDetail(constant.ErrWebsiteUpstreamInUse, location.Path, nil)
+		}
+	}
+
+	if err := upstreamRepo.SaveForWebsite(site.ID, upstreams); err != nil {
+		return buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	if site.Status != "running" || site.ConfigMode == "source" {
+		return nil
+	}
+	if err := s.applyConfig(site); err != nil {
+		_ = upstreamRepo.SaveForWebsite(site.ID, previous)
+		return err
+	}
+	return nil
+}
+
+func upstreamInvalid(format string, args ...interface{}) error {
+	return buserr.WithDetail(constant.ErrWebsiteUpstreamInvalid, fmt.Sprintf(format, args...), nil)
+}
+
+func buildWebsiteUpstream(item dto.WebsiteUpstreamItem) (model.WebsiteUpstream, error) {
+	if item.Balance == "" {
+		item.Balance = "round_robin"
+	}
+	if !upstreamNamePattern.MatchString(item.Name) {
+		return model.WebsiteUpstream{}, upstreamInvalid("invalid upstream name %q", item.Name)
+	}
+	if item.Balance == "hash" && !upstreamHashKeyPattern.MatchString(item.HashKey) {
+		return model.WebsiteUpstream{}, upstreamInvalid("hash balance of %s needs a key such as $request_uri", item.Name)
+	}
+	if item.HealthPath != "" && (!strings.HasPrefix(item.HealthPath, "/") || strings.ContainsAny(item.HealthPath, " \r\n")) {
+		return model.WebsiteUpstream{}, upstreamInvalid("invalid health path %q", item.HealthPath)
+	}
+	if len(item.Servers) == 0 {
+		return model.WebsiteUpstream{}, upstreamInvalid("upstream %s has no servers", item.Name)
+	}
+	primary := 0
+	for _, server := range item.Servers {
+		if !upstreamAddressPattern.MatchString(server.Address) {
+			return model.WebsiteUpstream{}, upstreamInvalid("invalid server address %q", server.Address)
+		}
+		// nginx 的 ip_hash/hash 不支持 backup 参数
+		if server.Backup && (item.Balance == "ip_hash" || item.Balance == "hash") {
+			return model.WebsiteUpstream{}, upstreamInvalid("backup servers cannot be used with %s", item.Balance)
+		}
+		if !server.Backup && !server.Down {
+			primary++
+		}
+	}
+	if primary == 0 {
+		return model.WebsiteUpstream{}, upstreamInvalid("upstream %s needs at least one active primary server", item.Name)
+	}
+	servers, _ := json.Marshal(item.Servers)
+	return model.WebsiteUpstream{
+		BaseModel:  model.BaseModel{ID: item.ID},
+		Name:       item.Name,
+		Balance:    item.Balance,
+		HashKey:    strings.TrimSpace(item.HashKey),
+		Keepalive:  item.Keepalive,
+		HealthPath: item.HealthPath,
+		Servers:    string(servers),
+		Remark:     item.Remark,
+	}, nil
+}
+
+func parseUpstreamServers(raw string) []dto.WebsiteUpstreamServer {
+	servers := []dto.WebsiteUpstreamServer{}
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &servers)
+	}
+	return servers
+}
+
+// renderUpstream 生成 upstream 块
+func renderUpstream(site model.Website, upstream model.WebsiteUpstream) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "upstream %s {\n", upstreamName(site, upstream))
+	switch upstream.Balance {
+	case "least_conn", "ip_hash":
+		fmt.Fprintf(&b, "    %s;\n", upstream.Balance)
+	case "hash":
+		fmt.Fprintf(&b, "    hash %s consistent;\n", upstream.HashKey)
+	}
+	for _, server := range parseUpstreamServers(upstream.Servers) {
+		line := "    server " + server.Address
+		if server.Weight > 0 && server.Weight != 1 {
+			line += fmt.Sprintf(" weight=%d", server.Weight)
+		}
+		if server.MaxFails > 0 {
+			line += fmt.Sprintf(" max_fails=%d", server.MaxFails)
+		}
+		if server.FailTimeout > 0 {
+			line += fmt.Sprintf(" fail_timeout=%ds", server.FailTimeout)
+		}
+		if server.Backup {
+			line += " backup"
+		}
+		if server.Down {
+			line += " down"
+		}
+		b.WriteString(line + ";\n")
+	}
+	if upstream.Keepalive > 0 {
+		fmt.Fprintf(&b, "    keepalive %d;\n", upstream.Keepalive)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// UpstreamHealth 汇总每个后端的被动失败记录（站点错误日志）与面板主动探测结果
+func (s *WebsiteService) UpstreamHealth(websiteID uint) ([]dto.WebsiteUpstreamHealth, error) {
+	site, err := s.websiteRepo.Get(repo.WithByID(websiteID))
+	if err != nil {
+		return nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	upstreams, err := repo.NewIWebsiteUpstreamRepo().ListByWebsite(site.ID)
+	if err != nil {
+		return nil, err
+	}
+	passive := scanUpstreamErrors(s.getWebsiteLogPath(site, "error"))
+
+	result := make([]dto.WebsiteUpstreamHealth, len(upstreams))
+	var wg sync.WaitGroup
+	for i, upstream := range upstreams {
+		health := dto.WebsiteUpstreamHealth{ID: upstream.ID, Name: upstream.Name, CheckedAt: time.Now()}
+		if stat, ok := passive[upstreamName(site, upstream)]; ok {
+			health.NoLive = stat.count
+		}
+		servers := parseUpstreamServers(upstream.Servers)
+		health.Servers = make([]dto.WebsiteUpstreamServerHealth, len(servers))
+		for j, server := range servers {
+			item := dto.WebsiteUpstreamServerHealth{Address: server.Address, Backup: server.Backup, Down: server.Down}
+			if stat, ok := passive[server.Address]; ok {
+				item.Failures, item.LastFailureAt, item.LastFailure = stat.count, stat.lastAt, stat.lastMessage
+			}
+			health.Servers[j] = item
+			wg.Add(1)
+			go func(target *dto.WebsiteUpstreamServerHealth, healthPath string) {
+				defer wg.Done()
+				upstreamProbe(target, healthPath)
+			}(&health.Servers[j], upstream.HealthPath)
+		}
+		result[i] = health
+	}
+	wg.Wait()
+	return result, nil
+}
+
+type upstreamErrorStat struct {
+	count       int64
+	lastAt      string
+	lastMessage string
+}
+
+// scanUpstreamErrors 统计错误日志尾部中与 upstream 相关的错误，按 upstream 字段中的地址
+// （或 no live upstreams 时的 upstream 名）归类
+func scanUpstreamErrors(path string) map[string]*upstreamErrorStat {
+	stats := make(map[string]*upstreamErrorStat)
+	f, err := os.Open(path)
+	if err != nil {
+		return stats
+	}
+	defer f.Close()
+	lines, err := readLastLines(f, upstreamErrorLogLines)
+	if err != nil {
+		return stats
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "upstream") {
+			continue
+		}
+		target := errorLogUpstreamPattern.FindStringSubmatch(line)
+		if target == nil {
+			continue
+		}
+		stat := stats[target[1]]
+		if stat == nil {
+			stat = &upstreamErrorStat{}
+			stats[target[1]] = stat
+		}
+		stat.count++
+		if match := errorLogTimePattern.FindStringSubmatch(line); match != nil {
+			stat.lastAt, stat.lastMessage = match[1], match[2]
+		}
+	}
+	return stats
+}
+
+func probeUpstreamServer(target *dto.WebsiteUpstreamServerHealth, healthPath string) {
+	network, address := "tcp", target.Address
+	if strings.HasPrefix(address, "unix:") {
+		network, address = "unix", strings.TrimPrefix(address, "unix:")
+	} else if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(strings.Trim(address, "[]"), "80")
+	}
+	start := time.Now()
+	conn, err := net.DialTimeout(network, address, 3*time.Second)
+	target.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		target.ProbeError = err.Error()
+		return
+	}
+	_ = conn.Close()
+	target.Reachable = true
+	if healthPath == "" || network == "unix" {
+		return
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	start = time.Now()
+	resp, err := client.Get("http://" + address + healthPath)
+	target.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		target.Reachable = false
+		target.ProbeError = err.Error()
+		return
+	}
+	_ = resp.Body.Close()
+	target.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 500 {
+		target.Reachable = false
+		target.ProbeError = resp.Status
+	}
+}
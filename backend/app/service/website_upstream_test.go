@@ -0,0 +1,129 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+)
+
+func TestSaveUpstreamsRendersAndKeepsReferences(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	site := &model.Website{
+		PrimaryDomain: "api.example.com", Alias: "api_example_com", Type: "reverse_proxy", Status: "stopped",
+		HttpConfig: "HTTPSRedirect",
+	}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+	err := svc.SaveUpstreams(dto.WebsiteUpstreamSave{WebsiteID: site.ID, Upstreams: []dto.WebsiteUpstreamItem{{
+		Name: "api", Balance: "least_conn", Keepalive: 16,
+		Servers: []dto.WebsiteUpstreamServer{
+			{Address: "10.0.0.1:8080", Weight: 3, MaxFails: 2, FailTimeout: 10},
+			{Address: "10.0.0.2:8080", Backup: true},
+		},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstreams, _ := svc.ListUpstreams(site.ID)
+	if len(upstreams) != 1 || len(upstreams[0].Servers) != 2 {
+		t.Fatalf("upstreams = %+v", upstreams)
+	}
+	upstreamID := upstreams[0].ID
+	if err := svc.SaveLocations(dto.WebsiteLocationSave{WebsiteID: site.ID, Locations: []dto.WebsiteLocationItem{
+		{Path: "/api/", Type: "proxy", UpstreamID: upstreamID},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := NewNginxConfigGenerator().Generate(*site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"upstream api_example_com_api {\n    least_conn;\n    server 10.0.0.1:8080 weight=3 max_fails=2 fail_timeout=10s;\n    server 10.0.0.2:8080 backup;\n    keepalive 16;\n}",
+		"proxy_pass http://api_example_com_api;",
+		"proxy_set_header Connection \"\";",
+	} {
+		if !strings.Contains(config, want) {
+			t.Fatalf("config missing %q:\n%s", want, config)
+		}
+	}
+
+	// 更新保持 ID，location 的引用不受影响
+	upstreams[0].Servers = upstreams[0].Servers[:1]
+	if err := svc.SaveUpstreams(dto.WebsiteUpstreamSave{WebsiteID: site.ID, Upstreams: upstreams}); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := svc.ListUpstreams(site.ID)
+	if len(updated) != 1 || updated[0].ID != upstreamID || len(updated[0].Servers) != 1 {
+		t.Fatalf("updated upstreams = %+v", updated)
+	}
+	if err := svc.SaveUpstreams(dto.WebsiteUpstreamSave{WebsiteID: site.ID}); err == nil {
+		t.Fatal("deleting an upstream referenced by a location should fail")
+	}
+
+	invalid := []dto.WebsiteUpstreamItem{
+		{Name: "bad name", Servers: []dto.WebsiteUpstreamServer{{Address: "10.0.0.1:80"}}},
+		{Name: "a", Servers: []dto.WebsiteUpstreamServer{{Address: "10.0.0.1:80; include x"}}},
+		{Name: "a", Balance: "ip_hash", Servers: []dto.WebsiteUpstreamServer{{Address: "10.0.0.1:80"}, {Address: "10.0.0.2:80", Backup: true}}},
+		{Name: "a", Servers: []dto.WebsiteUpstreamServer{{Address: "10.0.0.1:80", Backup: true}}},
+		{Name: "a", Balance: "hash", Servers: []dto.WebsiteUpstreamServer{{Address: "10.0.0.1:80"}}},
+	}
+	for _, item := range invalid {
+		if _, err := buildWebsiteUpstream(item); err == nil {
+			t.Fatalf("upstream %+v should be rejected", item)
+		}
+	}
+}
+
+func TestUpstreamHealthCombinesErrorLogAndProbe(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	logPath := filepath.Join(t.TempDir(), "error.log")
+	site := &model.Website{
+		PrimaryDomain: "h.example.com", Alias: "h_example_com", Type: "reverse_proxy", Status: "stopped", ErrorLogPath: logPath,
+	}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SaveUpstreams(dto.WebsiteUpstreamSave{WebsiteID: site.ID, Upstreams: []dto.WebsiteUpstreamItem{{
+		Name:    "app",
+		Servers: []dto.WebsiteUpstreamServer{{Address: "10.0.0.1:8080"}, {Address: "10.0.0.2:8080"}},
+	}}}); err != nil {
+		t.Fatal(err)
+	}
+	log := strings.Join([]string{
+		`2026/01/02 15:04:05 [error] 12#12: *1 connect() failed (111: Connection refused) while connecting to upstream, client: 1.2.3.4, server: h.example.com, request: "GET / HTTP/1.1", upstream: "http://10.0.0.2:8080/", host: "h.example.com"`,
+		`2026/01/02 15:05:00 [error] 12#12: *2 upstream timed out (110: Connection timed out) while reading response header from upstream, client: 1.2.3.4, server: h.example.com, request: "GET /a HTTP/1.1", upstream: "http://10.0.0.2:8080/a", host: "h.example.com"`,
+		`2026/01/02 15:06:00 [error] 12#12: *3 no live upstreams while connecting to upstream, client: 1.2.3.4, server: h.example.com, request: "GET / HTTP/1.1", upstream: "http://h_example_com_app/", host: "h.example.com"`,
+		`2026/01/02 15:07:00 [error] 12#12: *4 open() "/var/www/favicon.ico" failed (2: No such file or directory), client: 1.2.3.4`,
+	}, "\n") + "\n"
+	if err := os.WriteFile(logPath, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+	original := upstreamProbe
+	upstreamProbe = func(target *dto.WebsiteUpstreamServerHealth, healthPath string) {
+		target.Reachable = target.Address == "10.0.0.1:8080"
+	}
+	t.Cleanup(func() { upstreamProbe = original })
+
+	health, err := svc.UpstreamHealth(site.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(health) != 1 || health[0].NoLive != 1 || len(health[0].Servers) != 2 {
+		t.Fatalf("health = %+v", health)
+	}
+	healthy, dead := health[0].Servers[0], health[0].Servers[1]
+	if !healthy.Reachable || healthy.Failures != 0 {
+		t.Fatalf("healthy server = %+v", healthy)
+	}
+	if dead.Reachable || dead.Failures != 2 || dead.LastFailureAt != "2026/01/02 15:05:00" ||
+		!strings.HasPrefix(dead.LastFailure, "upstream timed out") {
+		t.Fatalf("dead server = %+v", dead)
+	}
+}
@@ -0,0 +1,448 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+	"xpanel/buserr"
+	"xpanel/constant"
+	"xpanel/global"
+	"xpanel/utils/iplocation"
+)
+
+// wafRuleSets 内置规则集。nginx 以 ~* 匹配未解码的 $request_uri 或 User-Agent，
+// 因此正则同时覆盖常见的百分号编码形式。
+var wafRuleSets = []struct {
+	Name        string
+	Description string
+	Target      string
+	Pattern     string
+}{
+	{"sqli", "SQL 注入", "uri", `(union(%20|\+|\s|/\*.*\*/)+(all(%20|\+|\s)+)?select|(%27|')(%20|\+|\s)*(or|and)(%20|\+|\s)+|information_schema|(sleep|benchmark|extractvalue|updatexml)(%20|\s)*(\(|%28))`},
+	{"xss", "跨站脚本", "uri", `((<|%3c)(%2f|/)?(script|iframe|svg|img|body)|javascript(:|%3a)|on(error|load|mouseover)(%20|\+|\s)*(=|%3d))`},
+	{"traversal", "路径遍历", "uri", `(\.\./|\.\.%2f|%2e%2e(/|%2f)|\.\.\\|%2e%2e%5c)`},
+	{"rce", "命令注入", "uri", `(/etc/(passwd|shadow)|/bin/(ba)?sh|cmd(=|%3d)|(;|%3b|\||%7c)(%20|\+)*(wget|curl|nc|bash)(%20|\+))`},
+	{"sensitive_file", "敏感文件", "uri", `(/\.(env|git|svn|hg|htaccess|htpasswd|ds_store)|\.(sql|bak|swp|old)(\?|$)|/web\.config)`},
+	{"scanner_path", "扫描器路径", "uri", `/(phpmyadmin|pma|actuator|solr|manager/html|cgi-bin/|boaform|vendor/phpunit)`},
+	{"scanner_ua", "扫描器 UA", "user_agent", `(sqlmap|nikto|nmap|masscan|zgrab|nuclei|dirbuster|gobuster|wpscan|acunetix|netsparker)`},
+}
+
+var (
+	wafCountryCode = regexp.MustCompile(`^[A-Z]{2}$`)
+	wafVarUnsafe   = regexp.MustCompile(`[^A-Za-z0-9_]`)
+	wafLogLine     = regexp.MustCompile(`^(\S+) \[([^\]]+)\] "([^"]*)" (\d{3}) "([^"]*)" "([^"]*)"$`)
+)
+
+// wafBlockedLogLines 封禁日志检索读取的最大行数
+const wafBlockedLogLines = 20000
+
+func (s *WebsiteService) ListWAFRuleSets() []dto.WebsiteWAFRuleSet {
+	items := make([]dto.WebsiteWAFRuleSet, 0, len(wafRuleSets))
+	for _, rule := range wafRuleSets {
+		items = append(items, dto.WebsiteWAFRuleSet{Name: rule.Name, Description: rule.Description, Target: rule.Target})
+	}
+	return items
+}
+
+func (s *WebsiteService) GetWAF(websiteID uint) (*dto.WebsiteWAFConfig, error) {
+	if _, err := s.websiteRepo.Get(repo.WithByID(websiteID)); err != nil {
+		return nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	waf, err := repo.NewIWebsiteWAFRepo().GetByWebsite(websiteID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.WebsiteWAFConfig{
+		WebsiteID:        websiteID,
+		Enabled:          waf.Enabled,
+		Rules:            splitWAFList(waf.Rules, ","),
+		IPWhitelist:      splitWAFList(waf.IPWhitelist, "\n"),
+		IPBlacklist:      splitWAFList(waf.IPBlacklist, "\n"),
+		UABlacklist:      splitWAFList(waf.UABlacklist, "\n"),
+		URLWhitelist:     splitWAFList(waf.URLWhitelist, "\n"),
+		URLBlacklist:     splitWAFList(waf.URLBlacklist, "\n"),
+		CountryWhitelist: splitWAFList(waf.CountryWhitelist, ","),
+		CountryBlacklist: splitWAFList(waf.CountryBlacklist, ","),
+		CCEnable:         waf.CCEnable,
+		CCRate:           waf.CCRate,
+		CCBurst:          waf.CCBurst,
+	}, nil
+}
+
+// SaveWAF 保存网站的 WAF 配置并生成国家网段文件；运行中的托管网站立即应用，
+// 配置测试失败时恢复原配置。
+func (s *WebsiteService) SaveWAF(req dto.WebsiteWAFConfig) error {
+	site, err := s.websiteRepo.Get(repo.WithByID(req.WebsiteID))
+	if err != nil {
+		return buserr.New(constant.ErrRecordNotFound)
+	}
+	if site.NginxConfPath != "" {
+		return buserr.New(constant.ErrWebsiteExternalOperationDenied)
+	}
+	wafRepo := repo.NewIWebsiteWAFRepo()
+	previous, err := wafRepo.GetByWebsite(site.ID)
+	if err != nil {
+		return err
+	}
+	waf, err := buildWebsiteWAF(req)
+	if err != nil {
+		return err
+	}
+	waf.ID = previous.ID
+	waf.CreatedAt = previous.CreatedAt
+
+	countryPath := wafCountryFilePath(site)
+	previousCountry, readErr := os.ReadFile(countryPath)
+	if waf.Enabled && (waf.CountryWhitelist != "" || waf.CountryBlacklist != "") {
+		if err := writeWAFCountryFile(site, waf); err != nil {
+			return err
+		}
+	}
+	restore := func() {
+		if previous.ID > 0 {
+			_ = wafRepo.Save(&previous)
+		} else {
+			_ = wafRepo.DeleteByWebsite(site.ID)
+		}
+		if readErr == nil {
+			_ = os.WriteFile(countryPath, previousCountry, 0644)
+		} else {
+			_ = os.Remove(countryPath)
+		}
+	}
+	if err := wafRepo.Save(&waf); err != nil {
+		restore()
+		return buserr.WithDetail(constant.ErrInternalServer, err.Error(), err)
+	}
+	if site.Status != "running" || site.ConfigMode == "source" {
+		return nil
+	}
+	if err := s.applyConfig(site); err != nil {
+		restore()
+		return err
+	}
+	return nil
+}
+
+func buildWebsiteWAF(req dto.WebsiteWAFConfig) (model.WebsiteWAF, error) {
+	waf := model.WebsiteWAF{WebsiteID: req.WebsiteID, Enabled: req.Enabled, CCEnable: req.CCEnable, CCRate: req.CCRate, CCBurst: req.CCBurst}
+
+	known := make(map[string]bool, len(wafRuleSets))
+	for _, rule := range wafRuleSets {
+		known[rule.Name] = true
+	}
+	rules := cleanWAFList(req.Rules)
+	for _, rule := range rules {
+		if !known[rule] {
+			return waf, wafInvalid("unknown rule set %s", rule)
+		}
+	}
+
+	ipWhitelist, ipBlacklist := cleanWAFList(req.IPWhitelist), cleanWAFList(req.IPBlacklist)
+	for _, ip := range append(append([]string{}, ipWhitelist...), ipBlacklist...) {
+		if _, _, err := net.ParseCIDR(ip); err != nil && net.ParseIP(ip) == nil {
+			return waf, wafInvalid("invalid ip %q", ip)
+		}
+	}
+
+	uaBlacklist, urlWhitelist, urlBlacklist := cleanWAFList(req.UABlacklist), cleanWAFList(req.URLWhitelist), cleanWAFList(req.URLBlacklist)
+	for _, pattern := range append(append(append([]string{}, uaBlacklist...), urlWhitelist...), urlBlacklist...) {
+		if err := validateWAFPattern(pattern); err != nil {
+			return waf, err
+		}
+	}
+
+	countryWhitelist, countryBlacklist := cleanWAFList(req.CountryWhitelist), cleanWAFList(req.CountryBlacklist)
+	allowed := make(map[string]bool, len(countryWhitelist))
+	for i, code := range countryWhitelist {
+		countryWhitelist[i] = strings.ToUpper(code)
+		allowed[countryWhitelist[i]] = true
+	}
+	for i, code := range countryBlacklist {
+		countryBlacklist[i] = strings.ToUpper(code)
+		if allowed[countryBlacklist[i]] {
+			return waf, wafInvalid("country %s is in both whitelist and blacklist", countryBlacklist[i])
+		}
+	}
+	for _, code := range append(append([]string{}, countryWhitelist...), countryBlacklist...) {
+		if !wafCountryCode.MatchString(code) {
+			return waf, wafInvalid("invalid country code %q", code)
+		}
+	}
+
+	if req.CCEnable && (req.CCRate < 1 || req.CCRate > 10000) {
+		return waf, wafInvalid("cc rate must be between 1 and 10000 requests per second")
+	}
+
+	waf.Rules = strings.Join(rules, ",")
+	waf.IPWhitelist = strings.Join(ipWhitelist, "\n")
+	waf.IPBlacklist = strings.Join(ipBlacklist, "\n")
+	waf.UABlacklist = strings.Join(uaBlacklist, "\n")
+	waf.URLWhitelist = strings.Join(urlWhitelist, "\n")
+	waf.URLBlacklist = strings.Join(urlBlacklist, "\n")
+	waf.CountryWhitelist = strings.Join(countryWhitelist, ",")
+	waf.CountryBlacklist = strings.Join(countryBlacklist, ",")
+	return waf, nil
+}
+
+// validateWAFPattern 正则写入 nginx 的双引号字符串，拒绝可能跳出引号或跨行的内容
+func validateWAFPattern(pattern string) error {
+	if strings.ContainsAny(pattern, "\"\r\n") || strings.HasSuffix(pattern, `\`) {
+		return wafInvalid("%q contains forbidden characters", pattern)
+	}
+	if _, err := regexp.Compile("(?i)" + pattern); err != nil {
+		return wafInvalid("invalid pattern %q", pattern)
+	}
+	return nil
+}
+
+func wafInvalid(format string, args ...interface{}) error {
+	return buserr.WithDetail(constant.ErrWebsiteWAFInvalid, fmt.Sprintf(format, args...), nil)
+}
+
+func cleanWAFList(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		result = append(result, item)
+	}
+	return result
+}
+
+func splitWAFList(raw, sep string) []string {
+	if raw == "" {
+		return []string{}
+	}
+	return strings.Split(raw, sep)
+}
+
+// wafVar 网站 WAF 变量前缀，nginx 变量名只允许字母、数字与下划线
+func wafVar(site model.Website) string {
+	return "xpanel_waf_" + wafVarUnsafe.ReplaceAllString(site.Alias, "_")
+}
+
+func wafLogPath(site model.Website) string {
+	return filepath.Join(global.CONF.Nginx.GetLogDir(), "sites", site.PrimaryDomain+".waf.log")
+}
+
+func wafCountryFilePath(site model.Website) string {
+	return filepath.Join(global.CONF.Nginx.GetConfDir(), "waf", fmt.Sprintf("%d.country.conf", site.ID))
+}
+
+// writeWAFCountryFile 把国家名单展开为 geo 网段列表，白名单国家与内网放行，黑名单国家标记拦截
+func writeWAFCountryFile(site model.Website, waf model.WebsiteWAF) error {
+	whitelist := countryGeoGroup{Value: `""`, Countries: splitWAFList(waf.CountryWhitelist, ",")}
+	if len(whitelist.Countries) > 0 {
+		whitelist.Networks = geoPrivateNetworks
+	}
+	blacklist := countryGeoGroup{Value: `"country_blacklist|"`, Countries: splitWAFList(waf.CountryBlacklist, ",")}
+	if err := writeCountryGeoFile(wafCountryFilePath(site), whitelist, blacklist); err != nil {
+		return wafInvalid("%v", err)
+	}
+	return nil
+}
+
+func removeWAFFiles(site model.Website) {
+	_ = os.Remove(wafCountryFilePath(site))
+}
+
+// renderWAFHTTP 生成 http 上下文的 WAF 规则链：各项检查产出 "原因|"，
+// 汇总后白名单 IP/URL 清空结果，否则取第一个原因作为 $xpanel_waf_<alias>。
+func renderWAFHTTP(site model.Website, waf model.WebsiteWAF) string {
+	v := wafVar(site)
+	var b strings.Builder
+	b.WriteString("# WAF\n")
+
+	fmt.Fprintf(&b, "geo $%s_allow_ip {\n    default 0;\n", v)
+	for _, ip := range splitWAFList(waf.IPWhitelist, "\n") {
+		fmt.Fprintf(&b, "    %s 1;\n", ip)
+	}
+	b.WriteString("}\n")
+
+	fmt.Fprintf(&b, "geo $%s_deny_ip {\n    default \"\";\n", v)
+	for _, ip := range splitWAFList(waf.IPBlacklist, "\n") {
+		fmt.Fprintf(&b, "    %s \"ip_blacklist|\";\n", ip)
+	}
+	b.WriteString("}\n")
+
+	countryVar := ""
+	if waf.CountryWhitelist != "" || waf.CountryBlacklist != "" {
+		countryVar = "$" + v + "_country"
+		fallback := ""
+		if waf.CountryWhitelist != "" {
+			fallback = "country_whitelist|"
+		}
+		fmt.Fprintf(&b, "geo %s {\n    default \"%s\";\n    include %s;\n}\n", countryVar, fallback, wafCountryFilePath(site))
+	}
+
+	// ACME 验证始终放行，避免国家白名单等规则阻断证书签发
+	fmt.Fprintf(&b, "map $request_uri $%s_allow_uri {\n    default 0;\n    \"~^/\\.well-known/acme-challenge/\" 1;\n", v)
+	for _, pattern := range splitWAFList(waf.URLWhitelist, "\n") {
+		fmt.Fprintf(&b, "    \"~*%s\" 1;\n", pattern)
+	}
+	b.WriteString("}\n")
+
+	enabled := make(map[string]bool)
+	for _, rule := range splitWAFList(waf.Rules, ",") {
+		enabled[rule] = true
+	}
+	fmt.Fprintf(&b, "map $request_uri $%s_uri {\n    default \"\";\n", v)
+	for _, pattern := range splitWAFList(waf.URLBlacklist, "\n") {
+		fmt.Fprintf(&b, "    \"~*%s\" \"url_blacklist|\";\n", pattern)
+	}
+	for _, rule := range wafRuleSets {
+		if rule.Target == "uri" && enabled[rule.Name] {
+			fmt.Fprintf(&b, "    \"~*%s\" \"%s|\";\n", rule.Pattern, rule.Name)
+		}
+	}
+	b.WriteString("}\n")
+
+	fmt.Fprintf(&b, "map $http_user_agent $%s_ua {\n    default \"\";\n", v)
+	for _, pattern := range splitWAFList(waf.UABlacklist, "\n") {
+		fmt.Fprintf(&b, "    \"~*%s\" \"ua_blacklist|\";\n", pattern)
+	}
+	for _, rule := range wafRuleSets {
+		if rule.Target == "user_agent" && enabled[rule.Name] {
+			fmt.Fprintf(&b, "    \"~*%s\" \"%s|\";\n", rule.Pattern, rule.Name)
+		}
+	}
+	b.WriteString("}\n")
+
+	fmt.Fprintf(&b, "map \"$%[1]s_allow_ip$%[1]s_allow_uri:$%[1]s_deny_ip%[2]s$%[1]s_uri$%[1]s_ua\" $%[1]s {\n", v, countryVar)
+	b.WriteString("    default \"\";\n")
+	b.WriteString("    \"~^00:([a-z_]+)\" $1;\n")
+	b.WriteString("}\n")
+
+	// 被拦截（原因非空）或被 CC 限流（429）的请求写入 WAF 日志
+	fmt.Fprintf(&b, "map \"$status:$%[1]s\" $%[1]s_log {\n    default 1;\n    \"~:$\" 0;\n", v)
+	if waf.CCEnable {
+		b.WriteString("    \"429:\" 1;\n")
+	}
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "log_format %s '$remote_addr [$time_local] \"$request\" $status \"$http_user_agent\" \"$%s\"';\n", v, v)
+
+	if waf.CCEnable {
+		// 白名单 IP/URL 的键为空，不参与限流计数
+		fmt.Fprintf(&b, "map \"$%[1]s_allow_ip$%[1]s_allow_uri\" $%[1]s_cc_key {\n    default \"\";\n    \"00\" $binary_remote_addr;\n}\n", v)
+		fmt.Fprintf(&b, "limit_req_zone $%[1]s_cc_key zone=%[1]s:10m rate=%[2]dr/s;\n", v, waf.CCRate)
+	}
+	return b.String()
+}
+
+func writeWAFServer(b *strings.Builder, site model.Website, waf model.WebsiteWAF) {
+	v := wafVar(site)
+	b.WriteString("    # WAF\n")
+	fmt.Fprintf(b, "    access_log %s %s if=$%s_log;\n", wafLogPath(site), v, v)
+	fmt.Fprintf(b, "    if ($%s) {\n", v)
+	b.WriteString("        return 403;\n")
+	b.WriteString("    }\n")
+	if waf.CCEnable {
+		writeLimitReq(b, v, waf.CCBurst, waf.CCBurst > 0)
+	}
+	b.WriteString("\n")
+}
+
+// SearchWAFLog 检索网站最近的 WAF 拦截记录，按时间倒序分页
+func (s *WebsiteService) SearchWAFLog(req dto.WebsiteWAFLogSearch) (int64, []dto.WebsiteWAFLogItem, error) {
+	site, err := s.websiteRepo.Get(repo.WithByID(req.WebsiteID))
+	if err != nil {
+		return 0, nil, buserr.New(constant.ErrRecordNotFound)
+	}
+	f, err := os.Open(wafLogPath(site))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, []dto.WebsiteWAFLogItem{}, nil
+		}
+		return 0, nil, err
+	}
+	defer f.Close()
+	lines, err := readLastLines(f, wafBlockedLogLines)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	keyword := strings.ToLower(strings.TrimSpace(req.Keyword))
+	var matched []dto.WebsiteWAFLogItem
+	for i := len(lines) - 1; i >= 0; i-- {
+		item, ok := parseWAFLogLine(lines[i])
+		if !ok {
+			continue
+		}
+		if req.IP != "" && item.IP != req.IP {
+			continue
+		}
+		if req.Reason != "" && item.Reason != req.Reason {
+			continue
+		}
+		if keyword != "" && !strings.Contains(strings.ToLower(item.URI), keyword) &&
+			!strings.Contains(strings.ToLower(item.UserAgent), keyword) {
+			continue
+		}
+		matched = append(matched, item)
+	}
+
+	total := int64(len(matched))
+	start := (req.Page - 1) * req.PageSize
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + req.PageSize
+	if end > len(matched) {
+		end = len(matched)
+	}
+	page := append([]dto.WebsiteWAFLogItem{}, matched[start:end]...)
+	if len(page) > 0 {
+		banned := loadBannedIPSet()
+		geo := iplocation.GetService()
+		for i := range page {
+			page[i].Banned = banned[page[i].IP]
+			page[i].Country = geo.Lookup(page[i].IP).Country
+		}
+	}
+	return total, page, nil
+}
+
+func parseWAFLogLine(line string) (dto.WebsiteWAFLogItem, bool) {
+	m := wafLogLine.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return dto.WebsiteWAFLogItem{}, false
+	}
+	t, err := time.Parse("02/Jan/2006:15:04:05 -0700", m[2])
+	if err != nil {
+		return dto.WebsiteWAFLogItem{}, false
+	}
+	status, _ := strconv.Atoi(m[4])
+	item := dto.WebsiteWAFLogItem{Time: t, IP: m[1], Status: status, UserAgent: m[5], Reason: m[6]}
+	parts := strings.SplitN(m[3], " ", 3)
+	if len(parts) >= 2 {
+		item.Method, item.URI = parts[0], parts[1]
+	} else {
+		item.URI = m[3]
+	}
+	if item.Reason == "" && status == 429 {
+		item.Reason = "cc"
+	}
+	return item, true
+}
+
+// BanWAFIP 将拦截日志中的来源 IP 加入面板封禁集合
+func (s *WebsiteService) BanWAFIP(req dto.WebsiteWAFBanReq) error {
+	if net.ParseIP(req.IP) == nil {
+		return wafInvalid("invalid ip %q", req.IP)
+	}
+	return NewIFail2banService().Ban(dto.Fail2banBanReq{IP: req.IP, Jail: req.Jail})
+}
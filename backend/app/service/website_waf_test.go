@@ -0,0 +1,202 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"xpanel/app/dto"
+	"xpanel/app/model"
+	"xpanel/app/repo"
+)
+
+func TestSaveWAFRendersRuleChain(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	original := geoCountryNetworks
+	geoCountryNetworks = func(codes []string) (map[string][]string, error) {
+		return map[string][]string{"RU": {"5.8.0.0/21", "2a00:1fa0::/29"}}, nil
+	}
+	t.Cleanup(func() { geoCountryNetworks = original })
+
+	site := &model.Website{
+		PrimaryDomain: "shop.example.com", Alias: "shop-example", Type: "static", Status: "stopped",
+		SiteDir: "/var/www/shop", AccessLog: false,
+	}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+	err := svc.SaveWAF(dto.WebsiteWAFConfig{
+		WebsiteID: site.ID, Enabled: true, Rules: []string{"sqli", "scanner_ua"},
+		IPWhitelist: []string{"10.0.0.0/8"}, IPBlacklist: []string{" 1.2.3.4 ", "1.2.3.4"},
+		UABlacklist: []string{"BadBot"}, URLWhitelist: []string{"^/api/callback"},
+		CountryBlacklist: []string{"ru"},
+		CCEnable:         true, CCRate: 20, CCBurst: 40,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := svc.GetWAF(site.ID)
+	if len(saved.IPBlacklist) != 1 || saved.CountryBlacklist[0] != "RU" {
+		t.Fatalf("saved waf = %+v", saved)
+	}
+
+	config, err := NewNginxConfigGenerator().Generate(*site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"geo $xpanel_waf_shop_example_allow_ip {\n    default 0;\n    10.0.0.0/8 1;\n}",
+		"    1.2.3.4 \"ip_blacklist|\";\n}",
+		"include " + wafCountryFilePath(*site) + ";",
+		"\"~^/\\.well-known/acme-challenge/\" 1;\n    \"~*^/api/callback\" 1;",
+		"\"sqli|\";",
+		"\"~*BadBot\" \"ua_blacklist|\";",
+		"$xpanel_waf_shop_example_country$xpanel_waf_shop_example_uri",
+		"\"429:\" 1;",
+		"limit_req_zone $xpanel_waf_shop_example_cc_key zone=xpanel_waf_shop_example:10m rate=20r/s;",
+		"access_log " + wafLogPath(*site) + " xpanel_waf_shop_example if=$xpanel_waf_shop_example_log;",
+		"if ($xpanel_waf_shop_example) {\n        return 403;",
+		"limit_req zone=xpanel_waf_shop_example burst=40 nodelay;",
+	} {
+		if !strings.Contains(config, want) {
+			t.Fatalf("config missing %q:\n%s", want, config)
+		}
+	}
+	// access_log off 会连同 WAF 日志一起关闭
+	if strings.Contains(config, "access_log off;") || strings.Contains(config, "xss|") {
+		t.Fatalf("unexpected directives in config:\n%s", config)
+	}
+	countries, err := os.ReadFile(wafCountryFilePath(*site))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(countries) != "5.8.0.0/21 \"country_blacklist|\";\n2a00:1fa0::/29 \"country_blacklist|\";\n" {
+		t.Fatalf("country file = %q", countries)
+	}
+
+	if err := svc.Delete(site.ID); err != nil {
+		t.Fatal(err)
+	}
+	if waf, _ := repo.NewIWebsiteWAFRepo().GetByWebsite(site.ID); waf.ID != 0 {
+		t.Fatalf("waf should be deleted with the website, got %+v", waf)
+	}
+	if _, err := os.Stat(wafCountryFilePath(*site)); !os.IsNotExist(err) {
+		t.Fatalf("country file should be removed, err = %v", err)
+	}
+}
+
+func TestWAFRuleSetsMatchEncodedAttacks(t *testing.T) {
+	samples := map[string]string{
+		"sqli":           "/item?id=1%20UNION%20SELECT%20password%20FROM%20users",
+		"xss":            "/search?q=%3Cscript%3Ealert(1)%3C/script%3E",
+		"traversal":      "/download?file=..%2F..%2Fetc%2Fpasswd",
+		"rce":            "/ping?host=127.0.0.1%3B%20curl%20http://x",
+		"sensitive_file": "/.env",
+		"scanner_path":   "/phpmyadmin/index.php",
+		"scanner_ua":     "sqlmap/1.7.2#stable (https://sqlmap.org)",
+	}
+	benign := []string{"/products?id=12&sort=price", "/blog/2026/10/selection-guide", "/static/app.js?v=3"}
+	for _, rule := range wafRuleSets {
+		// nginx 的 ~* 不区分大小写
+		pattern := regexp.MustCompile("(?i)" + rule.Pattern)
+		sample, ok := samples[rule.Name]
+		if !ok {
+			t.Fatalf("rule set %s has no sample", rule.Name)
+		}
+		if !pattern.MatchString(sample) {
+			t.Fatalf("rule set %s should match %q", rule.Name, sample)
+		}
+		if rule.Target != "uri" {
+			continue
+		}
+		for _, uri := range benign {
+			if pattern.MatchString(uri) {
+				t.Fatalf("rule set %s should not match %q", rule.Name, uri)
+			}
+		}
+	}
+}
+
+func TestSaveWAFRejectsInvalidConfig(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	site := &model.Website{PrimaryDomain: "w.example.com", Alias: "w_example_com", Type: "static", Status: "stopped"}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+	cases := []dto.WebsiteWAFConfig{
+		{Rules: []string{"modsecurity"}},
+		{IPBlacklist: []string{"1.2.3.4; include /etc/passwd"}},
+		{UABlacklist: []string{`bad" 1; }`}},
+		{URLBlacklist: []string{"(unclosed"}},
+		{URLWhitelist: []string{`/trailing\`}},
+		{CountryBlacklist: []string{"CHN"}},
+		{CountryWhitelist: []string{"CN"}, CountryBlacklist: []string{"cn"}},
+		{CCEnable: true},
+	}
+	for _, req := range cases {
+		req.WebsiteID, req.Enabled = site.ID, true
+		if err := svc.SaveWAF(req); err == nil {
+			t.Fatalf("waf %+v should be rejected", req)
+		}
+	}
+	if waf, _ := repo.NewIWebsiteWAFRepo().GetByWebsite(site.ID); waf.ID != 0 {
+		t.Fatalf("rejected saves must not persist, got %+v", waf)
+	}
+}
+
+func TestSearchWAFLogFiltersAndPages(t *testing.T) {
+	svc := installWebsiteLocationDB(t)
+	site := &model.Website{PrimaryDomain: "l.example.com", Alias: "l_example_com", Type: "static", Status: "stopped"}
+	if err := svc.websiteRepo.Create(site); err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{
+		`1.1.1.1 [18/Oct/2026:10:00:00 +0800] "GET /?id=1%20union%20select%201 HTTP/1.1" 403 "curl/8.0" "sqli"`,
+		`2.2.2.2 [18/Oct/2026:10:00:01 +0800] "GET /wp-login.php HTTP/1.1" 403 "sqlmap/1.7" "scanner_ua"`,
+		`garbage line`,
+		`3.3.3.3 [18/Oct/2026:10:00:02 +0800] "POST /login HTTP/1.1" 429 "Mozilla/5.0" ""`,
+		`1.1.1.1 [18/Oct/2026:10:00:03 +0800] "GET /.env HTTP/1.1" 403 "curl/8.0" "sensitive_file"`,
+	}
+	if err := os.MkdirAll(filepath.Dir(wafLogPath(*site)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(wafLogPath(*site), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(req dto.WebsiteWAFLogSearch) (int64, []dto.WebsiteWAFLogItem) {
+		t.Helper()
+		req.WebsiteID = site.ID
+		if req.Page == 0 {
+			req.Page, req.PageSize = 1, 10
+		}
+		total, items, err := svc.SearchWAFLog(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return total, items
+	}
+
+	total, items := search(dto.WebsiteWAFLogSearch{})
+	if total != 4 || items[0].URI != "/.env" || items[1].Reason != "cc" || items[1].Method != "POST" {
+		t.Fatalf("total = %d, items = %+v", total, items)
+	}
+	if total, items = search(dto.WebsiteWAFLogSearch{IP: "1.1.1.1"}); total != 2 {
+		t.Fatalf("ip filter: total = %d, items = %+v", total, items)
+	}
+	if total, items = search(dto.WebsiteWAFLogSearch{Reason: "scanner_ua"}); total != 1 || items[0].IP != "2.2.2.2" {
+		t.Fatalf("reason filter: total = %d, items = %+v", total, items)
+	}
+	if total, items = search(dto.WebsiteWAFLogSearch{Keyword: "SQLMAP"}); total != 1 {
+		t.Fatalf("keyword filter: total = %d, items = %+v", total, items)
+	}
+	if total, items = search(dto.WebsiteWAFLogSearch{PageInfo: dto.PageInfo{Page: 2, PageSize: 3}}); total != 4 || len(items) != 1 || items[0].IP != "1.1.1.1" {
+		t.Fatalf("second page: total = %d, items = %+v", total, items)
+	}
+
+	if err := svc.BanWAFIP(dto.WebsiteWAFBanReq{IP: "1.1.1.1; rm -rf /"}); err == nil {
+		t.Fatal("invalid ip should not be banned")
+	}
+}
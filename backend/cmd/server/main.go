@@ -15,6 +15,7 @@ func run(
 	setup func([]string),
 	bootstrapConfig func([]string),
 	credentialsCommand func([]string),
+	mfaCommand func([]string),
 	showVersion func(),
 	updateCommand func([]string) error,
 	invokeCommand func([]string),
@@ -30,6 +31,9 @@ func run(
 		case "credentials":
 			credentialsCommand(args[1:])
 			return nil
+		case "mfa":
+			mfaCommand(args[1:])
+			return nil
 		case "migrate":
 			migrate()
 			return nil
@@ -58,6 +62,7 @@ func main() {
 		runSetup,
 		runBootstrapConfig,
 		runCredentials,
+		runMFA,
 		printVersion,
 		runUpdate,
 		runInvoke,
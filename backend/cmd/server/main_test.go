@@ -11,6 +11,7 @@ func TestRunDispatchesMigrateWithoutStartingServer(t *testing.T) {
 		func([]string) {},
 		func([]string) {},
 		func([]string) {},
+		func([]string) {},
 		func() {},
 		func([]string) error { return nil },
 		func([]string) {},
@@ -29,6 +30,7 @@ func TestRunDispatchesSetupArguments(t *testing.T) {
 		func(args []string) { got = args },
 		func([]string) {},
 		func([]string) {},
+		func([]string) {},
 		func() {},
 		func([]string) error { return nil },
 		func([]string) {},
@@ -47,6 +49,7 @@ func TestRunDispatchesVersionWithoutStartingServer(t *testing.T) {
 		func([]string) {},
 		func([]string) {},
 		func([]string) {},
+		func([]string) {},
 		func() { printed = true },
 		func([]string) error { return nil },
 		func([]string) {},
@@ -65,6 +68,7 @@ func TestRunDispatchesBootstrapConfigArguments(t *testing.T) {
 		func([]string) {},
 		func(args []string) { got = args },
 		func([]string) {},
+		func([]string) {},
 		func() {},
 		func([]string) error { return nil },
 		func([]string) {},
@@ -83,6 +87,7 @@ func TestRunDispatchesCredentialsArguments(t *testing.T) {
 		func([]string) {},
 		func([]string) {},
 		func(args []string) { got = args },
+		func([]string) {},
 		func() {},
 		func([]string) error { return nil },
 		func([]string) {},
@@ -92,6 +97,29 @@ func TestRunDispatchesCredentialsArguments(t *testing.T) {
 	}
 }
 
+func TestRunDispatchesMFAArguments(t *testing.T) {
+	var started bool
+	var got []string
+	err := run(
+		[]string{"mfa", "disable"},
+		func() { started = true },
+		func() {},
+		func([]string) {},
+		func([]string) {},
+		func([]string) {},
+		func(args []string) { got = append([]string(nil), args...) },
+		func() {},
+		func([]string) error { return nil },
+		func([]string) {},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started || len(got) != 1 || got[0] != "disable" {
+		t.Fatalf("started=%v mfa args=%#v", started, got)
+	}
+}
+
 func TestRunDispatchesUpdateLatestWithoutStartingServer(t *testing.T) {
 	var started bool
 	var got []string
@@ -102,6 +130,7 @@ func TestRunDispatchesUpdateLatestWithoutStartingServer(t *testing.T) {
 		func([]string) {},
 		func([]string) {},
 		func([]string) {},
+		func([]string) {},
 		func() {},
 		func(args []string) error {
 			got = append([]string(nil), args...)
@@ -127,6 +156,7 @@ func TestRunDispatchesInvokeWithoutStartingServer(t *testing.T) {
 		func([]string) {},
 		func([]string) {},
 		func([]string) {},
+		func([]string) {},
 		func() {},
 		func([]string) error { return nil },
 		func(args []string) { got = append([]string(nil), args...) },
@@ -149,6 +179,7 @@ func TestRunDispatchesInvokeArguments(t *testing.T) {
 		func([]string) {},
 		func([]string) {},
 		func([]string) {},
+		func([]string) {},
 		func() {},
 		func([]string) error { return nil },
 		func(args []string) { got = append([]string(nil), args...) },
@@ -170,6 +201,7 @@ func TestRunUnknownCommandDoesNotStartServer(t *testing.T) {
 		func([]string) {},
 		func([]string) {},
 		func([]string) {},
+		func([]string) {},
 		func() {},
 		func([]string) error { return nil },
 		func([]string) {},
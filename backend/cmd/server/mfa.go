@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"xpanel/app/service"
+)
+
+// runMFA 离线管理两步验证，用于丢失认证器且无恢复码时解锁面板
+// 用法: xpanel mfa disable [--username <子账户>]
+func runMFA(args []string) {
+	if len(args) == 0 || args[0] != "disable" {
+		fmt.Fprintln(os.Stderr, "用法: xpanel mfa disable [--username <子账户>]")
+		os.Exit(1)
+	}
+	fs := flag.NewFlagSet("mfa disable", flag.ExitOnError)
+	username := fs.String("username", "", "要关闭两步验证的账户，默认为内置管理员")
+	fs.Parse(args[1:])
+
+	initializeOfflineDatabase()
+	if err := service.ResetMFA(*username); err != nil {
+		fmt.Fprintf(os.Stderr, "关闭两步验证失败: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("✓ 两步验证已关闭，密钥与恢复码已清除")
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"xpanel/app/service"
 	"xpanel/global"
 	initCredential "xpanel/init/credential"
 	initDB "xpanel/init/db"
@@ -77,6 +78,11 @@ func runSetup(args []string) {
 		os.Exit(1)
 	}
 
+	// 重置密码后吊销该账户已登录的会话
+	if err := service.RevokeUserSessions(*username); err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 吊销已登录会话失败: %v\n", err)
+	}
+
 	fmt.Printf("✓ 管理员账户已设置: %s\n", *username)
 }
 
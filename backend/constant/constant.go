@@ -5,6 +5,7 @@ const (
 	StatusDisable = "Disable"
 	StatusSuccess = "Success"
 	StatusFailed  = "Failed"
+	StatusRunning = "Running"
 
 	DateTimeLayout = "2006-01-02 15:04:05"
 	DateLayout     = "2006-01-02"
@@ -14,6 +15,10 @@ const (
 	JWTTokenPrefix = "Bearer "
 	JWTIssuer      = "xpanel"
 
+	// API Key：X-API-Key 请求头携带，格式 xpk_{keyID}_{secret}
+	APIKeyHeader = "X-API-Key"
+	APIKeyPrefix = "xpk_"
+
 	// Default settings
 	DefaultLanguage       = "zh"
 	DefaultSessionTimeout = 86400 // 24h in seconds
@@ -14,6 +14,28 @@ const (
 	ErrPasswordWrong   = "ErrPasswordWrong"
 	ErrUserNotFound    = "ErrUserNotFound"
 	ErrInitialPassword = "ErrInitialPassword"
+	ErrUserDisabled    = "ErrUserDisabled"
+	ErrUserNameExist   = "ErrUserNameExist"
+	ErrPermissionDeny  = "ErrPermissionDeny"
+	ErrAPIKeyInvalid   = "ErrAPIKeyInvalid"
+
+	// 通知
+	ErrNotificationRateLimited = "ErrNotificationRateLimited"
+
+	// 备份
+	ErrBackupRestoreType     = "ErrBackupRestoreType"
+	ErrBackupRestoreTarget   = "ErrBackupRestoreTarget"
+	ErrBackupChecksum        = "ErrBackupChecksum"
+	ErrBackupPasswordMissing = "ErrBackupPasswordMissing"
+	ErrBackupArchiveUnsafe   = "ErrBackupArchiveUnsafe"
+	ErrBackupChainBroken     = "ErrBackupChainBroken"
+	ErrBackupChainInUse      = "ErrBackupChainInUse"
+
+	// MFA
+	ErrMFANotEnabled     = "ErrMFANotEnabled"
+	ErrMFAAlreadyEnabled = "ErrMFAAlreadyEnabled"
+	ErrMFANotInitialized = "ErrMFANotInitialized"
+	ErrMFACodeInvalid    = "ErrMFACodeInvalid"
 
 	// 文件管理
 	ErrFileNotExist        = "ErrFileNotExist"
@@ -31,6 +53,12 @@ const (
 	ErrSSLAcmeRegister        = "ErrSSLAcmeRegister"
 	ErrSSLApply               = "ErrSSLApply"
 	ErrSSLRenew               = "ErrSSLRenew"
+	ErrSSLRevoke              = "ErrSSLRevoke"
+	ErrSSLRevokeUnsupported   = "ErrSSLRevokeUnsupported"
+	ErrSSLDeploy              = "ErrSSLDeploy"
+	ErrPrivateCA              = "ErrPrivateCA"
+	ErrPrivateCAInUse         = "ErrPrivateCAInUse"
+	ErrPrivateCACRLNotFound   = "ErrPrivateCACRLNotFound"
 	ErrPanelSSLCertNotReady   = "ErrPanelSSLCertNotReady"
 	ErrPanelSSLCertFiles      = "ErrPanelSSLCertFiles"
 	ErrPanelSSLKeyPairInvalid = "ErrPanelSSLKeyPairInvalid"
@@ -54,6 +82,19 @@ const (
 	ErrWebsiteExternalConfigDuplicate = "ErrWebsiteExternalConfigDuplicate"
 	ErrWebsiteExternalConfigConflict  = "ErrWebsiteExternalConfigConflict"
 	ErrWebsiteExternalOperationDenied = "ErrWebsiteExternalOperationDenied"
+	ErrWebsiteLocationInvalid         = "ErrWebsiteLocationInvalid"
+	ErrWebsiteUpstreamInvalid         = "ErrWebsiteUpstreamInvalid"
+	ErrWebsiteUpstreamInUse           = "ErrWebsiteUpstreamInUse"
+	ErrWebsiteWAFInvalid              = "ErrWebsiteWAFInvalid"
+	ErrWebsiteAccessInvalid           = "ErrWebsiteAccessInvalid"
+	ErrWebsiteCloneInvalid            = "ErrWebsiteCloneInvalid"
+	ErrWebsiteDeployInvalid           = "ErrWebsiteDeployInvalid"
+	ErrWebsiteDeployRunning           = "ErrWebsiteDeployRunning"
+	ErrWebsiteDeploySignature         = "ErrWebsiteDeploySignature"
+	ErrWebsiteConfigDrift             = "ErrWebsiteConfigDrift"
+	ErrPHPVersionNotFound             = "ErrPHPVersionNotFound"
+	ErrPHPPoolApply                   = "ErrPHPPoolApply"
+	ErrPHPSettingInvalid              = "ErrPHPSettingInvalid"
 
 	// 升级
 	ErrUpgradeInProgress = "ErrUpgradeInProgress"
@@ -0,0 +1,41 @@
+package constant
+
+// 面板账户角色
+const (
+	RoleAdmin    = "admin"    // 全部权限，含账户与系统设置
+	RoleOperator = "operator" // 业务模块读写，不含系统管理
+	RoleReadOnly = "readonly" // 业务模块只读
+)
+
+// API Key 访问级别
+const (
+	APIKeyAccessRead  = "read"
+	APIKeyAccessWrite = "write"
+)
+
+// 权限模块：路由按 /api/v1/{group} 归属到模块，子账户可限定可访问的模块
+const (
+	ModuleWebsite   = "website"
+	ModuleContainer = "container"
+	ModuleDatabase  = "database"
+	ModuleFile      = "file"
+	ModuleHost      = "host"
+	ModuleCronjob   = "cronjob"
+	ModuleMonitor   = "monitor"
+	ModuleProxy     = "proxy"
+	ModuleToolbox   = "toolbox"
+	ModuleSystem    = "system" // 仅管理员
+)
+
+// BusinessModules 可分配给子账户的业务模块
+var BusinessModules = []string{
+	ModuleWebsite,
+	ModuleContainer,
+	ModuleDatabase,
+	ModuleFile,
+	ModuleHost,
+	ModuleCronjob,
+	ModuleMonitor,
+	ModuleProxy,
+	ModuleToolbox,
+}
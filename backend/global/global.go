@@ -208,6 +208,14 @@ func (c NginxConfig) GetLogDir() string {
 	return filepath.Join(c.InstallDir, "logs")
 }
 
+// GetCacheDir 返回 proxy_cache 缓存根目录
+func (c NginxConfig) GetCacheDir() string {
+	if c.systemMode {
+		return "/var/cache/nginx"
+	}
+	return filepath.Join(c.InstallDir, "cache")
+}
+
 // GetPidPath 返回 PID 文件路径
 func (c NginxConfig) GetPidPath() string {
 	if c.systemMode {
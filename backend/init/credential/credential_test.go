@@ -134,11 +134,16 @@ func openCredentialDatabase(t *testing.T) *gorm.DB {
 		&model.DnsAccount{},
 		&model.Certificate{},
 		&model.CertSource{},
+		&model.CertificateDeployment{},
+		&model.CertificateAuthority{},
 		&model.Website{},
+		&model.WebsiteDeploy{},
 		&model.GostService{},
 		&model.GostChain{},
 		&model.Cronjob{},
 		&model.HAProxyConfigVersion{},
+		&model.User{},
+		&model.NotificationChannel{},
 	); err != nil {
 		t.Fatalf("migrate credential database: %v", err)
 	}
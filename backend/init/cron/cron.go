@@ -30,12 +30,27 @@ func Init() {
 		}
 	}
 
+	// 每分钟评估监控告警规则
+	global.CRON.AddFunc("* * * * *", func() {
+		service.EvaluateAlertRules()
+	})
+
 	// 每天凌晨 2 点检查证书续期
 	global.CRON.AddFunc("0 2 * * *", func() {
 		service.AutoRenewCerts()
 	})
 	go service.AutoRenewCerts()
 
+	// 每小时刷新 ARI 续签窗口并检查证书吊销状态，被吊销或到达 ARI 续签时间的证书立即续签
+	global.CRON.AddFunc("15 * * * *", func() {
+		service.CheckCertificateStatus()
+	})
+
+	// 每天凌晨 2:30 重新发布私有 CA 的 CRL（有效期 7 天）
+	global.CRON.AddFunc("30 2 * * *", func() {
+		service.RefreshPrivateCACRLs()
+	})
+
 	// 每天凌晨 3:30 自动升级（如果启用）
 	global.CRON.AddFunc("30 3 * * *", func() {
 		autoUpgrade()
@@ -46,6 +61,24 @@ func Init() {
 		service.NewICertSourceService().SyncAll()
 	})
 
+	// 每 10 分钟检测托管网站的 nginx 配置是否被面板外修改
+	global.CRON.AddFunc("*/10 * * * *", func() {
+		service.CheckWebsiteConfigDrift()
+	})
+
+	// 每分钟增量采集网站访问日志，每天凌晨 4:20 清理过期统计
+	global.CRON.AddFunc("* * * * *", func() {
+		service.CollectAccessStats()
+	})
+	global.CRON.AddFunc("20 4 * * *", func() {
+		service.CleanAccessStats()
+	})
+
+	// 每 10 分钟按策略轮转网站、nginx、面板与证书日志
+	global.CRON.AddFunc("*/10 * * * *", func() {
+		service.RotateLogs()
+	})
+
 	global.LOG.Info("Cron scheduler initialized")
 }
 
@@ -22,6 +22,7 @@ func Init() {
 		&model.Setting{},
 		&model.LoginLog{},
 		&model.OperationLog{},
+		&model.LogRotatePolicy{},
 		&model.Host{},
 		&model.Command{},
 		&model.Group{},
@@ -29,6 +30,16 @@ func Init() {
 		&model.DnsAccount{},
 		&model.Certificate{},
 		&model.Website{},
+		&model.WebsiteLocation{},
+		&model.WebsiteUpstream{},
+		&model.WebsiteWAF{},
+		&model.WebsiteAccess{},
+		&model.WebsiteDeploy{},
+		&model.WebsiteDeployRecord{},
+		&model.WebsiteConfigDrift{},
+		&model.AccessStat{},
+		&model.AccessStatTop{},
+		&model.AccessLogCursor{},
 		&model.Cronjob{},
 		&model.CronjobRecord{},
 		&model.DatabaseServer{},
@@ -43,6 +54,10 @@ func Init() {
 		&model.GostChain{},
 		&model.CertSource{},
 		&model.CertSyncLog{},
+		&model.CertificateDeployment{},
+		&model.CertificateDeployLog{},
+		&model.CertificateAuthority{},
+		&model.CertificateAuthorityRevocation{},
 		&model.HAProxyLB{},
 		&model.HAProxyBackend{},
 		&model.HAProxyServer{},
@@ -50,6 +65,11 @@ func Init() {
 		&model.HAProxyConfigVersion{},
 		&model.Notification{},
 		&model.ComposeProject{},
+		&model.User{},
+		&model.Session{},
+		&model.APIKey{},
+		&model.NotificationChannel{},
+		&model.AlertRule{},
 	); err != nil {
 		panic("Failed to auto-migrate database: " + err.Error())
 	}
@@ -324,6 +344,9 @@ func initDefaultSettings() {
 		{Key: "SecurityEntrance", Value: ""},
 		{Key: "MFAStatus", Value: "Disable"},
 		{Key: "MFASecret", Value: ""},
+		{Key: "MFAPendingSecret", Value: ""},
+		{Key: "MFARecoveryCodes", Value: ""},
+		{Key: "MFALastStep", Value: ""},
 		{Key: "SSLDir", Value: ""},
 		{Key: "UpgradeURL", Value: "https://xpanel.qm.mk"},
 		{Key: "GitHubToken", Value: ""},
@@ -337,6 +360,8 @@ func initDefaultSettings() {
 		{Key: "MonitorStatus", Value: "enable"},
 		{Key: "MonitorInterval", Value: "300"},
 		{Key: "MonitorStoreDays", Value: "7"},
+		{Key: "AccessStatsMinuteDays", Value: "2"},
+		{Key: "AccessStatsStoreDays", Value: "30"},
 		{Key: "DefaultNetwork", Value: "all"},
 		{Key: "DefaultIO", Value: "all"},
 		{Key: "ProxyEnable", Value: "disable"},
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"xpanel/app/dto"
+	"xpanel/app/service"
+	"xpanel/constant"
+	"xpanel/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyAuth API Key 认证中间件，须放在 JWTAuth 之前；
+// 请求未携带 API Key 时交由 JWTAuth 处理，认证通过后 JWTAuth 直接放行
+func APIKeyAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := apiKeyFromRequest(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+		account, err := service.AuthenticateAPIKey(key, c.ClientIP())
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, dto.Response{
+				Code:    http.StatusUnauthorized,
+				Message: i18n.GetMsgByKey(constant.ErrAPIKeyInvalid),
+			})
+			c.Abort()
+			return
+		}
+		c.Set("apiKey", true)
+		c.Set("userName", account.Name)
+		c.Set("userRole", account.Role)
+		c.Set("userModules", account.Modules)
+		c.Next()
+	}
+}
+
+// apiKeyFromRequest 优先读取 X-API-Key，也接受 Authorization: Bearer xpk_...
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := strings.TrimSpace(c.GetHeader(constant.APIKeyHeader)); key != "" {
+		return key
+	}
+	token := strings.TrimPrefix(c.GetHeader(constant.JWTHeaderKey), constant.JWTTokenPrefix)
+	if strings.HasPrefix(token, constant.APIKeyPrefix) {
+		return token
+	}
+	return ""
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"xpanel/app/dto"
+	"xpanel/app/service"
 	"xpanel/constant"
 	"xpanel/i18n"
 	jwtUtil "xpanel/utils/jwt"
@@ -14,6 +15,12 @@ import (
 // JWTAuth JWT 认证中间件
 func JWTAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// 已由 APIKeyAuth 认证
+		if c.GetBool("apiKey") {
+			c.Next()
+			return
+		}
+
 		token := c.GetHeader(constant.JWTHeaderKey)
 
 		// 兼容 WebSocket：从 query 参数获取 token
@@ -45,8 +52,32 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
+		// 会话被吊销、过期或空闲超时后 Token 立即失效
+		if err := service.ValidateSession(claims.ID, claims.UserName); err != nil {
+			c.JSON(http.StatusUnauthorized, dto.Response{
+				Code:    http.StatusUnauthorized,
+				Message: i18n.GetMsgByKey(constant.ErrTokenInvalid),
+			})
+			c.Abort()
+			return
+		}
+
+		// 子账户被删除或禁用后，已签发的 Token 立即失效
+		account, err := service.ResolveAccount(claims.UserName)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, dto.Response{
+				Code:    http.StatusUnauthorized,
+				Message: i18n.GetMsgByKey(constant.ErrTokenInvalid),
+			})
+			c.Abort()
+			return
+		}
+
 		// 将用户信息放入上下文
-		c.Set("userName", claims.UserName)
+		c.Set("userName", account.Name)
+		c.Set("userRole", account.Role)
+		c.Set("userModules", account.Modules)
+		c.Set("sessionID", claims.ID)
 		c.Next()
 	}
 }
@@ -46,16 +46,17 @@ func OperationLog() gin.HandlerFunc {
 
 		// 异步写入日志
 		log := &model.OperationLog{
-			Group:   group,
-			Source:  source,
-			Action:  action,
-			IP:      c.ClientIP(),
-			Path:    path,
-			Method:  method,
-			Body:    body,
-			Status:  status,
-			Message: message,
-			Latency: formatLatency(duration),
+			UserName: c.GetString("userName"),
+			Group:    group,
+			Source:   source,
+			Action:   action,
+			IP:       c.ClientIP(),
+			Path:     path,
+			Method:   method,
+			Body:     body,
+			Status:   status,
+			Message:  message,
+			Latency:  formatLatency(duration),
 		}
 
 		go func() {
@@ -21,6 +21,8 @@ const (
 
 var sensitiveOperationPaths = []string{
 	"/api/v1/auth/password",
+	"/api/v1/auth/mfa",
+	"/api/v1/users",
 	"/api/v1/settings",
 	"/api/v1/hosts",
 	"/api/v1/nodes",
@@ -32,6 +34,7 @@ var sensitiveOperationPaths = []string{
 	"/api/v1/certificates",
 	"/api/v1/cert-sources",
 	"/api/v1/cert-server",
+	"/api/v1/pki",
 	"/api/v1/ssl/accounts",
 	"/api/v1/ssh/keys",
 	"/api/v1/host/users",
@@ -50,6 +53,7 @@ var sensitiveOperationPaths = []string{
 	"/api/v1/haproxy/config",
 	"/api/v1/haproxy/stats/settings",
 	"/api/v1/nezha-agent",
+	"/api/v1/notification-channels",
 }
 
 var sensitiveJSONKeys = map[string]struct{}{
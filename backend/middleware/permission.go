@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"xpanel/app/dto"
+	"xpanel/constant"
+	"xpanel/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeModules 将 /api/v1/{group} 映射到权限模块；未登记的分组按系统模块处理（仅管理员）
+var routeModules = map[string]string{
+	"auth":          "",
+	"notifications": "",
+
+	"websites":      constant.ModuleWebsite,
+	"nginx":         constant.ModuleWebsite,
+	"certificates":  constant.ModuleWebsite,
+	"acme-accounts": constant.ModuleWebsite,
+	"dns-accounts":  constant.ModuleWebsite,
+	"ssl":           constant.ModuleWebsite,
+	"cert-sources":  constant.ModuleWebsite,
+	"cert-sync":     constant.ModuleWebsite,
+	"cert-server":   constant.ModuleWebsite,
+	"pki":           constant.ModuleWebsite,
+
+	"containers": constant.ModuleContainer,
+	"databases":  constant.ModuleDatabase,
+	"files":      constant.ModuleFile,
+
+	"hosts":    constant.ModuleHost,
+	"commands": constant.ModuleHost,
+	"groups":   constant.ModuleHost,
+
+	"cronjobs": constant.ModuleCronjob,
+	"backup":   constant.ModuleCronjob,
+
+	"monitor": constant.ModuleMonitor,
+	"alerts":  constant.ModuleMonitor,
+	"traffic": constant.ModuleMonitor,
+	"process": constant.ModuleMonitor,
+
+	"gost":    constant.ModuleProxy,
+	"haproxy": constant.ModuleProxy,
+
+	"toolbox":     constant.ModuleToolbox,
+	"firewall":    constant.ModuleToolbox,
+	"ssh":         constant.ModuleToolbox,
+	"disk":        constant.ModuleToolbox,
+	"host":        constant.ModuleToolbox,
+	"nezha-agent": constant.ModuleToolbox,
+}
+
+// Access 路由所需的访问级别，由路由注册时按分组显式指定
+type Access int
+
+const (
+	// AccessByMethod GET/HEAD/OPTIONS 为只读，其余方法需写权限
+	AccessByMethod Access = iota
+	// AccessRead 以 POST 提交查询条件的只读接口
+	AccessRead
+	// AccessWrite 即便是 GET 也需写权限的接口（导出凭据等）
+	AccessWrite
+	// AccessAdmin 等同主机 root 权限的接口（终端、主机用户等），仅管理员可用
+	AccessAdmin
+)
+
+// Permission 按账户角色、模块授权与路由访问级别校验私有路由，须在 JWTAuth 之后使用
+func Permission(access Access) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("userRole")
+		modules := c.GetStringSlice("userModules")
+		module := resolveRouteModule(c.Request.URL.Path)
+		// API Key 不代表具体账户，不能访问认证、通知等个人接口
+		if c.GetBool("apiKey") && module == "" {
+			module = constant.ModuleSystem
+		}
+		if access == AccessAdmin {
+			module = constant.ModuleSystem
+		}
+		if !allowAccess(role, modules, module, isReadRequest(access, c.Request.Method)) {
+			c.JSON(http.StatusForbidden, dto.Response{
+				Code:    http.StatusForbidden,
+				Message: i18n.GetMsgByKey(constant.ErrPermissionDeny),
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func resolveRouteModule(path string) string {
+	group := strings.SplitN(strings.TrimPrefix(path, "/api/v1/"), "/", 2)[0]
+	if module, ok := routeModules[group]; ok {
+		return module
+	}
+	return constant.ModuleSystem
+}
+
+func isReadRequest(access Access, method string) bool {
+	switch access {
+	case AccessRead:
+		return true
+	case AccessWrite, AccessAdmin:
+		return false
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// allowAccess module 为空表示任意已登录账户可访问（认证、通知等个人接口）
+func allowAccess(role string, modules []string, module string, read bool) bool {
+	if module == "" || role == constant.RoleAdmin {
+		return true
+	}
+	if module == constant.ModuleSystem {
+		return false
+	}
+	if len(modules) > 0 && !slices.Contains(modules, module) {
+		return false
+	}
+	switch role {
+	case constant.RoleOperator:
+		return true
+	case constant.RoleReadOnly:
+		return read
+	default:
+		return false
+	}
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"xpanel/constant"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestResolveRouteModule(t *testing.T) {
+	cases := map[string]string{
+		"/api/v1/websites/search":       constant.ModuleWebsite,
+		"/api/v1/certificates/apply":    constant.ModuleWebsite,
+		"/api/v1/pki/issue":             constant.ModuleWebsite,
+		"/api/v1/containers/operate":    constant.ModuleContainer,
+		"/api/v1/host/users/create":     constant.ModuleToolbox,
+		"/api/v1/hosts/test-conn":       constant.ModuleHost,
+		"/api/v1/auth/password":         "",
+		"/api/v1/notifications/read":    "",
+		"/api/v1/settings/update":       constant.ModuleSystem,
+		"/api/v1/users":                 constant.ModuleSystem,
+		"/api/v1/some-future-module/do": constant.ModuleSystem,
+	}
+	for path, want := range cases {
+		if got := resolveRouteModule(path); got != want {
+			t.Errorf("resolveRouteModule(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestIsReadRequest(t *testing.T) {
+	cases := []struct {
+		access Access
+		method string
+		want   bool
+	}{
+		{AccessByMethod, http.MethodGet, true},
+		{AccessByMethod, http.MethodHead, true},
+		{AccessByMethod, http.MethodPost, false},
+		{AccessByMethod, http.MethodPut, false},
+		{AccessRead, http.MethodPost, true},
+		{AccessWrite, http.MethodGet, false},
+		{AccessAdmin, http.MethodGet, false},
+	}
+	for _, tc := range cases {
+		if got := isReadRequest(tc.access, tc.method); got != tc.want {
+			t.Errorf("isReadRequest(%d, %s) = %v, want %v", tc.access, tc.method, got, tc.want)
+		}
+	}
+}
+
+func TestPermissionByRouteAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		name   string
+		role   string
+		access Access
+		method string
+		path   string
+		want   int
+	}{
+		{"readonly declared read", constant.RoleReadOnly, AccessRead, http.MethodPost, "/api/v1/websites/search", http.StatusOK},
+		{"readonly undeclared post", constant.RoleReadOnly, AccessByMethod, http.MethodPost, "/api/v1/websites/upstreams/health", http.StatusForbidden},
+		{"readonly export", constant.RoleReadOnly, AccessWrite, http.MethodGet, "/api/v1/ssl/accounts/export", http.StatusForbidden},
+		{"operator export", constant.RoleOperator, AccessWrite, http.MethodGet, "/api/v1/ssl/accounts/export", http.StatusOK},
+		{"operator terminal", constant.RoleOperator, AccessAdmin, http.MethodGet, "/api/v1/terminal", http.StatusForbidden},
+		{"operator host users", constant.RoleOperator, AccessAdmin, http.MethodPost, "/api/v1/host/users/create", http.StatusForbidden},
+		{"admin terminal", constant.RoleAdmin, AccessAdmin, http.MethodGet, "/api/v1/terminal", http.StatusOK},
+	}
+	for _, tc := range cases {
+		r := gin.New()
+		r.Handle(tc.method, tc.path, func(c *gin.Context) {
+			c.Set("userRole", tc.role)
+		}, Permission(tc.access), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, w.Code, tc.want)
+		}
+	}
+}
+
+func TestAllowAccessByRoleAndModule(t *testing.T) {
+	websitesOnly := []string{constant.ModuleWebsite}
+	cases := []struct {
+		name    string
+		role    string
+		modules []string
+		module  string
+		read    bool
+		want    bool
+	}{
+		{"admin system write", constant.RoleAdmin, nil, constant.ModuleSystem, false, true},
+		{"operator system read", constant.RoleOperator, nil, constant.ModuleSystem, true, false},
+		{"operator business write", constant.RoleOperator, nil, constant.ModuleContainer, false, true},
+		{"operator scoped allowed", constant.RoleOperator, websitesOnly, constant.ModuleWebsite, false, true},
+		{"operator scoped denied", constant.RoleOperator, websitesOnly, constant.ModuleContainer, true, false},
+		{"readonly read", constant.RoleReadOnly, nil, constant.ModuleDatabase, true, true},
+		{"readonly write", constant.RoleReadOnly, nil, constant.ModuleDatabase, false, false},
+		{"readonly personal write", constant.RoleReadOnly, nil, "", false, true},
+		{"unknown role", "", nil, constant.ModuleWebsite, true, false},
+	}
+	for _, tc := range cases {
+		if got := allowAccess(tc.role, tc.modules, tc.module, tc.read); got != tc.want {
+			t.Errorf("%s: allowAccess = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -32,8 +32,15 @@ func Setup(mode string) *gin.Engine {
 		publicGroup.GET("/auth/is-init", api.CheckIsInitialized)
 		publicGroup.POST("/auth/init", api.InitUser)
 		publicGroup.POST("/auth/login", api.Login)
+		publicGroup.POST("/auth/mfa-login", api.MFALogin)
 		publicGroup.GET("/auth/captcha", api.GetCaptcha)
 
+		// Git 部署 webhook（由签名认证）
+		publicGroup.POST("/websites/deploy/webhook/:id", api.WebsiteDeployWebhook)
+
+		// 私有 CA 的 CRL 分发点
+		publicGroup.GET("/pki/crl/:id", api.GetPrivateCACRL)
+
 		// 版本信息（公开，无需认证）
 		publicGroup.GET("/version", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"code": 200, "data": version.Get()})
@@ -54,28 +61,65 @@ func Setup(mode string) *gin.Engine {
 	// 嵌入的前端静态文件（生产模式）
 	setupFrontend(r)
 
-	// 私有路由
-	privateGroup := r.Group("/api/v1")
-	privateGroup.Use(middleware.JWTAuth())
-	privateGroup.Use(middleware.NodeProxy())
-	privateGroup.Use(middleware.OperationLog())
+	// 私有路由：按注册分组确定访问级别，未单独列出的接口 GET 为只读、其余需写权限
+	authGroup := r.Group("/api/v1")
+	authGroup.Use(middleware.APIKeyAuth())
+	authGroup.Use(middleware.JWTAuth())
+	privateGroup := authGroup.Group("", middleware.Permission(middleware.AccessByMethod), middleware.NodeProxy(), middleware.OperationLog())
+	// 以 POST 提交查询条件的只读接口
+	readGroup := authGroup.Group("", middleware.Permission(middleware.AccessRead), middleware.NodeProxy(), middleware.OperationLog())
+	// 导出凭据等需要写权限的 GET 接口
+	writeGroup := authGroup.Group("", middleware.Permission(middleware.AccessWrite), middleware.NodeProxy(), middleware.OperationLog())
+	// 等同主机 root 权限的接口仅管理员可用
+	adminGroup := authGroup.Group("", middleware.Permission(middleware.AccessAdmin), middleware.NodeProxy(), middleware.OperationLog())
 	{
 		// 认证
 		privateGroup.POST("/auth/logout", api.Logout)
+		privateGroup.GET("/auth/sessions", api.ListSessions)
+		privateGroup.POST("/auth/sessions/revoke", api.RevokeSession)
+		privateGroup.POST("/auth/sessions/revoke-all", api.RevokeAllSessions)
 		privateGroup.POST("/auth/password", api.UpdatePassword)
+		privateGroup.GET("/auth/account", api.GetAccount)
+		privateGroup.GET("/auth/mfa", api.GetMFAStatus)
+		privateGroup.POST("/auth/mfa/init", api.InitMFA)
+		privateGroup.POST("/auth/mfa/bind", api.BindMFA)
+		privateGroup.POST("/auth/mfa/disable", api.DisableMFA)
+		privateGroup.POST("/auth/mfa/recovery-codes", api.RegenerateRecoveryCodes)
+
+		// 面板账户
+		readGroup.POST("/users/search", api.SearchPanelUser)
+		privateGroup.POST("/users", api.CreatePanelUser)
+		privateGroup.POST("/users/update", api.UpdatePanelUser)
+		privateGroup.POST("/users/del", api.DeletePanelUser)
+		privateGroup.POST("/users/password", api.ResetPanelUserPassword)
+		privateGroup.POST("/users/mfa/reset", api.ResetPanelUserMFA)
+
+		// API Key（仅管理员）
+		readGroup.POST("/api-keys/search", api.SearchAPIKey)
+		privateGroup.POST("/api-keys", api.CreateAPIKey)
+		privateGroup.POST("/api-keys/update", api.UpdateAPIKey)
+		privateGroup.POST("/api-keys/del", api.DeleteAPIKey)
 
 		// 通知中心
 		privateGroup.GET("/notifications/summary", api.GetNotificationSummary)
 		privateGroup.GET("/notifications/recent", api.GetRecentNotifications)
 		privateGroup.GET("/notifications/preference", api.GetNotificationPreference)
-		privateGroup.POST("/notifications/preference", api.UpdateNotificationPreference)
-		privateGroup.POST("/notifications/search", api.SearchNotifications)
+		// 通知偏好决定告警的外发渠道路由，仅管理员可修改
+		adminGroup.POST("/notifications/preference", api.UpdateNotificationPreference)
+		readGroup.POST("/notifications/search", api.SearchNotifications)
 		privateGroup.POST("/notifications/read", api.MarkNotificationsRead)
 		privateGroup.POST("/notifications/read-all", api.MarkAllNotificationsRead)
 		privateGroup.POST("/notifications/read/clear", api.DeleteReadNotifications)
 		privateGroup.POST("/notifications/clear-all", api.DeleteAllNotifications)
 		privateGroup.POST("/notifications/del", api.DeleteNotification)
 
+		// 外发通知渠道（仅管理员）
+		privateGroup.GET("/notification-channels", api.ListNotificationChannels)
+		privateGroup.POST("/notification-channels", api.CreateNotificationChannel)
+		privateGroup.POST("/notification-channels/update", api.UpdateNotificationChannel)
+		privateGroup.POST("/notification-channels/del", api.DeleteNotificationChannel)
+		privateGroup.POST("/notification-channels/test", api.TestNotificationChannel)
+
 		// 设置
 		privateGroup.GET("/settings", api.GetSettingInfo)
 		privateGroup.POST("/settings/update", api.Update)
@@ -95,21 +139,25 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.POST("/logs/operation", api.PageOperationLog)
 		privateGroup.POST("/logs/login/clean", api.CleanLoginLog)
 		privateGroup.POST("/logs/operation/clean", api.CleanOperationLog)
+		privateGroup.POST("/logs/rotate/policy", api.GetLogRotatePolicy)
+		privateGroup.POST("/logs/rotate/policy/update", api.SaveLogRotatePolicy)
+		privateGroup.GET("/logs/rotate/files", api.ListLogRotateFiles)
+		privateGroup.POST("/logs/rotate/run", api.RunLogRotate)
 
 		// 文件管理
-		privateGroup.POST("/files/search", api.ListFiles)
+		readGroup.POST("/files/search", api.ListFiles)
 		privateGroup.POST("/files", api.CreateFile)
 		privateGroup.POST("/files/del", api.DeleteFile)
 		privateGroup.POST("/files/batch-del", api.BatchDeleteFile)
 		privateGroup.POST("/files/rename", api.RenameFile)
 		privateGroup.POST("/files/move", api.MoveFile)
-		privateGroup.POST("/files/content", api.GetFileContent)
+		readGroup.POST("/files/content", api.GetFileContent)
 		privateGroup.POST("/files/save", api.SaveFileContent)
 		privateGroup.POST("/files/mode", api.ChangeMode)
 		privateGroup.POST("/files/owner", api.ChangeOwner)
 		privateGroup.POST("/files/compress", api.CompressFile)
 		privateGroup.POST("/files/decompress", api.DecompressFile)
-		privateGroup.POST("/files/archive/list", api.ListArchive)
+		readGroup.POST("/files/archive/list", api.ListArchive)
 		privateGroup.POST("/files/upload", api.UploadFile)
 		privateGroup.POST("/files/upload/preflight", api.PreflightUpload)
 		privateGroup.POST("/files/upload/chunk", api.UploadFileChunk)
@@ -117,19 +165,19 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.POST("/files/upload/chunk/abort", api.AbortFileChunks)
 		privateGroup.GET("/files/download", api.DownloadFile)
 		privateGroup.POST("/files/wget", api.WgetFile)
-		privateGroup.POST("/files/tree", api.GetFileTree)
-		privateGroup.POST("/files/size", api.GetDirSize)
-		privateGroup.POST("/files/user/group", api.GetUsersAndGroups)
+		readGroup.POST("/files/tree", api.GetFileTree)
+		readGroup.POST("/files/size", api.GetDirSize)
+		readGroup.POST("/files/user/group", api.GetUsersAndGroups)
 		privateGroup.GET("/files/task", api.GetFileTaskStatus)
 		privateGroup.POST("/files/task/cancel", api.CancelFileTask)
 		privateGroup.GET("/files/tasks", api.ListFileTasks)
-		privateGroup.POST("/files/check-conflict", api.CheckConflict)
+		readGroup.POST("/files/check-conflict", api.CheckConflict)
 
 		// 主机管理
 		privateGroup.POST("/hosts", api.CreateHost)
 		privateGroup.POST("/hosts/update", api.UpdateHost)
 		privateGroup.POST("/hosts/del", api.DeleteHost)
-		privateGroup.POST("/hosts/search", api.SearchHost)
+		readGroup.POST("/hosts/search", api.SearchHost)
 		privateGroup.GET("/hosts/tree", api.GetHostTree)
 		privateGroup.GET("/hosts/test", api.TestHost)
 		privateGroup.POST("/hosts/test-conn", api.TestHostConn)
@@ -138,7 +186,7 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.POST("/commands", api.CreateCommand)
 		privateGroup.POST("/commands/update", api.UpdateCommand)
 		privateGroup.POST("/commands/del", api.DeleteCommand)
-		privateGroup.POST("/commands/search", api.SearchCommand)
+		readGroup.POST("/commands/search", api.SearchCommand)
 		privateGroup.GET("/commands/tree", api.GetCommandTree)
 
 		// 分组管理
@@ -148,18 +196,37 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.GET("/groups", api.GetGroupList)
 
 		// SSL 证书
-		privateGroup.POST("/certificates/search", api.SearchCertificate)
-		privateGroup.POST("/certificates/renewal-plan/search", api.SearchCertificateRenewalPlan)
+		readGroup.POST("/certificates/search", api.SearchCertificate)
+		readGroup.POST("/certificates/renewal-plan/search", api.SearchCertificateRenewalPlan)
 		privateGroup.POST("/certificates", api.CreateCertificate)
 		privateGroup.POST("/certificates/update", api.UpdateCertificate)
 		privateGroup.POST("/certificates/upload", api.UploadCertificate)
 		privateGroup.POST("/certificates/del", api.DeleteCertificate)
 		privateGroup.POST("/certificates/batch-del", api.BatchDeleteCertificate)
 		privateGroup.POST("/certificates/cleanup-expired", api.CleanupExpiredCertificate)
-		privateGroup.POST("/certificates/detail", api.GetCertificateDetail)
+		readGroup.POST("/certificates/detail", api.GetCertificateDetail)
 		privateGroup.POST("/certificates/apply", api.ApplyCertificate)
 		privateGroup.POST("/certificates/renew", api.RenewCertificate)
-		privateGroup.POST("/certificates/log", api.GetCertificateLog)
+		privateGroup.POST("/certificates/revoke", api.RevokeCertificate)
+		readGroup.POST("/certificates/log", api.GetCertificateLog)
+
+		// 证书部署目标（SSH 主机、本机路径、部署钩子）
+		readGroup.POST("/certificates/deployments/search", api.ListCertificateDeployments)
+		// 部署目标以 root 在本机或远程主机写入任意路径、执行命令，仅管理员可配置
+		adminGroup.POST("/certificates/deployments", api.CreateCertificateDeployment)
+		adminGroup.POST("/certificates/deployments/update", api.UpdateCertificateDeployment)
+		privateGroup.POST("/certificates/deployments/del", api.DeleteCertificateDeployment)
+		privateGroup.POST("/certificates/deployments/run", api.RunCertificateDeployment)
+		readGroup.POST("/certificates/deployments/logs", api.SearchCertificateDeployLogs)
+
+		// 私有 CA（签发内网服务端证书与 mTLS 客户端证书）
+		privateGroup.GET("/pki/authorities", api.ListPrivateCA)
+		privateGroup.POST("/pki/authorities", api.CreatePrivateCA)
+		privateGroup.POST("/pki/authorities/update", api.UpdatePrivateCA)
+		privateGroup.POST("/pki/authorities/del", api.DeletePrivateCA)
+		privateGroup.POST("/pki/authorities/crl", api.PublishPrivateCACRL)
+		privateGroup.POST("/pki/issue", api.IssuePrivateCertificate)
+		privateGroup.POST("/pki/export", api.ExportPrivateCertificate)
 
 		// ACME 账户
 		privateGroup.GET("/acme-accounts", api.ListAcmeAccount)
@@ -173,7 +240,7 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.POST("/dns-accounts/del", api.DeleteDnsAccount)
 
 		// 账户导入导出
-		privateGroup.GET("/ssl/accounts/export", api.ExportAccounts)
+		writeGroup.GET("/ssl/accounts/export", api.ExportAccounts)
 		privateGroup.POST("/ssl/accounts/import", api.ImportAccounts)
 
 		// SSL 设置
@@ -183,15 +250,22 @@ func Setup(mode string) *gin.Engine {
 
 		// 系统监控
 		privateGroup.GET("/monitor/stats", api.GetCurrentStats)
-		privateGroup.POST("/monitor/history", api.LoadMonitorHistory)
+		readGroup.POST("/monitor/history", api.LoadMonitorHistory)
 		privateGroup.GET("/monitor/setting", api.GetMonitorSetting)
 		privateGroup.POST("/monitor/setting/update", api.UpdateMonitorSetting)
 		privateGroup.POST("/monitor/history/clean", api.CleanMonitorData)
 		privateGroup.GET("/monitor/io-options", api.GetIOOptions)
 		privateGroup.GET("/monitor/network-options", api.GetNetworkOptions)
 
+		// 监控告警
+		privateGroup.GET("/alerts/rules", api.ListAlertRules)
+		privateGroup.POST("/alerts/rules", api.CreateAlertRule)
+		privateGroup.POST("/alerts/rules/update", api.UpdateAlertRule)
+		privateGroup.POST("/alerts/rules/del", api.DeleteAlertRule)
+		privateGroup.POST("/alerts/rules/mute", api.MuteAlertRule)
+
 		// 进程管理
-		privateGroup.POST("/process/search", api.ListProcesses)
+		readGroup.POST("/process/search", api.ListProcesses)
 		privateGroup.POST("/process/stop", api.StopProcess)
 		privateGroup.GET("/process/connections", api.ListConnections)
 
@@ -199,7 +273,7 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.GET("/ssh/info", api.GetSSHInfo)
 		privateGroup.POST("/ssh/operate", api.OperateSSH)
 		privateGroup.POST("/ssh/update", api.UpdateSSHConfig)
-		privateGroup.POST("/ssh/log", api.LoadSSHLog)
+		readGroup.POST("/ssh/log", api.LoadSSHLog)
 		privateGroup.GET("/ssh/sshd-config", api.GetSSHDConfig)
 		privateGroup.POST("/ssh/sshd-config", api.SaveSSHDConfig)
 		privateGroup.GET("/ssh/authorized-keys", api.ListAuthorizedKeys)
@@ -209,7 +283,7 @@ func Setup(mode string) *gin.Engine {
 		// 防火墙
 		privateGroup.GET("/firewall/base", api.GetBaseInfo)
 		privateGroup.POST("/firewall/operate", api.Operate)
-		privateGroup.POST("/firewall/port/search", api.ListPortRules)
+		readGroup.POST("/firewall/port/search", api.ListPortRules)
 		privateGroup.POST("/firewall/port", api.CreatePortRule)
 		privateGroup.POST("/firewall/port/del", api.DeletePortRule)
 		privateGroup.GET("/firewall/ip", api.ListIPRules)
@@ -252,30 +326,30 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.GET("/nginx/conf", api.GetNginxMainConf)
 		privateGroup.POST("/nginx/conf", api.SaveNginxMainConf)
 		privateGroup.GET("/nginx/conf-files", api.ListNginxConfFiles)
-		privateGroup.POST("/nginx/conf-file", api.GetNginxConfFile)
+		readGroup.POST("/nginx/conf-file", api.GetNginxConfFile)
 		privateGroup.POST("/nginx/conf-file/save", api.SaveNginxConfFile)
-		privateGroup.POST("/nginx/conf-backups", api.ListNginxConfBackups)
+		readGroup.POST("/nginx/conf-backups", api.ListNginxConfBackups)
 		privateGroup.POST("/nginx/conf-backups/restore", api.RestoreNginxConfBackup)
 
 		// 计划任务
 		privateGroup.POST("/cronjobs", api.CreateCronjob)
 		privateGroup.POST("/cronjobs/update", api.UpdateCronjob)
 		privateGroup.POST("/cronjobs/del", api.DeleteCronjob)
-		privateGroup.POST("/cronjobs/search", api.SearchCronjob)
-		privateGroup.POST("/cronjobs/detail", api.GetCronjob)
+		readGroup.POST("/cronjobs/search", api.SearchCronjob)
+		readGroup.POST("/cronjobs/detail", api.GetCronjob)
 		privateGroup.POST("/cronjobs/status", api.UpdateCronjobStatus)
 		privateGroup.POST("/cronjobs/handle-once", api.HandleOnceCronjob)
-		privateGroup.POST("/cronjobs/records", api.SearchCronjobRecords)
+		readGroup.POST("/cronjobs/records", api.SearchCronjobRecords)
 
 		// 数据库管理
 		privateGroup.POST("/databases/servers", api.CreateDatabaseServer)
 		privateGroup.POST("/databases/servers/update", api.UpdateDatabaseServer)
 		privateGroup.POST("/databases/servers/del", api.DeleteDatabaseServer)
-		privateGroup.POST("/databases/servers/search", api.SearchDatabaseServer)
+		readGroup.POST("/databases/servers/search", api.SearchDatabaseServer)
 		privateGroup.POST("/databases/servers/test", api.TestDatabaseConnection)
 		privateGroup.POST("/databases/instances", api.CreateDatabaseInstance)
 		privateGroup.POST("/databases/instances/del", api.DeleteDatabaseInstance)
-		privateGroup.POST("/databases/instances/search", api.SearchDatabaseInstance)
+		readGroup.POST("/databases/instances/search", api.SearchDatabaseInstance)
 		privateGroup.POST("/databases/instances/sync", api.SyncDatabaseInstances)
 		privateGroup.POST("/databases/instances/password", api.ChangeInstancePassword)
 		privateGroup.POST("/databases/instances/privileges", api.ChangeInstancePrivileges)
@@ -299,9 +373,12 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.POST("/backup/accounts/test", api.TestBackupAccount)
 		privateGroup.POST("/backup/accounts/del", api.DeleteBackupAccount)
 		privateGroup.POST("/backup", api.CreateBackup)
-		privateGroup.POST("/backup/records/search", api.SearchBackupRecords)
+		readGroup.POST("/backup/records/search", api.SearchBackupRecords)
 		privateGroup.POST("/backup/records/del", api.DeleteBackupRecord)
-		privateGroup.POST("/backup/storage/list", api.ListStorageObjects)
+		privateGroup.POST("/backup/records/restore", api.RestoreBackupRecord)
+		privateGroup.POST("/backup/records/verify", api.VerifyBackupRecord)
+		readGroup.POST("/backup/retention/preview", api.PreviewBackupRetention)
+		readGroup.POST("/backup/storage/list", api.ListStorageObjects)
 		privateGroup.POST("/backup/storage/read", api.ReadStorageObject)
 		privateGroup.POST("/backup/storage/save", api.SaveStorageObject)
 		privateGroup.POST("/backup/storage/delete", api.DeleteStorageObject)
@@ -312,10 +389,10 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.GET("/containers/docker/status", api.DockerStatus)
 		privateGroup.POST("/containers/docker/install", api.InstallDocker)
 		privateGroup.GET("/containers/docker/install/log", api.GetDockerInstallLog)
-		privateGroup.POST("/containers/search", api.ListContainers)
+		readGroup.POST("/containers/search", api.ListContainers)
 		privateGroup.POST("/containers", api.CreateContainer)
 		privateGroup.POST("/containers/operate", api.OperateContainer)
-		privateGroup.POST("/containers/logs", api.ContainerLogs)
+		readGroup.POST("/containers/logs", api.ContainerLogs)
 		privateGroup.POST("/containers/del", api.RemoveContainer)
 		privateGroup.GET("/containers/image", api.ListImages)
 		privateGroup.POST("/containers/image/pull", api.PullImage)
@@ -332,7 +409,7 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.GET("/containers/compose/content", api.GetComposeContent)
 		privateGroup.POST("/containers/compose/content", api.UpdateComposeContent)
 		privateGroup.POST("/containers/compose/del", api.DeleteCompose)
-		privateGroup.POST("/containers/inspect", api.Inspect)
+		readGroup.POST("/containers/inspect", api.Inspect)
 		privateGroup.POST("/containers/prune", api.Prune)
 		privateGroup.POST("/containers/rename", api.RenameContainer)
 		privateGroup.POST("/containers/log/clean", api.CleanContainerLog)
@@ -346,7 +423,7 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.POST("/traffic/configs", api.CreateConfig)
 		privateGroup.POST("/traffic/configs/del", api.DeleteConfig)
 		privateGroup.GET("/traffic/interfaces", api.ListInterfaces)
-		privateGroup.POST("/traffic/stats", api.GetStats)
+		readGroup.POST("/traffic/stats", api.GetStats)
 		privateGroup.GET("/traffic/summary", api.GetSummary)
 
 		// GOST 代理管理
@@ -357,12 +434,12 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.POST("/gost/operate", api.OperateGost)
 		privateGroup.GET("/gost/check-update", api.CheckGostUpdate)
 		privateGroup.POST("/gost/upgrade", api.UpgradeGost)
-		privateGroup.POST("/gost/services/search", api.SearchGostService)
+		readGroup.POST("/gost/services/search", api.SearchGostService)
 		privateGroup.POST("/gost/services", api.CreateGostService)
 		privateGroup.POST("/gost/services/update", api.UpdateGostService)
 		privateGroup.POST("/gost/services/del", api.DeleteGostService)
 		privateGroup.POST("/gost/services/toggle", api.ToggleGostService)
-		privateGroup.POST("/gost/chains/search", api.SearchGostChain)
+		readGroup.POST("/gost/chains/search", api.SearchGostChain)
 		privateGroup.POST("/gost/chains", api.CreateGostChain)
 		privateGroup.POST("/gost/chains/update", api.UpdateGostChain)
 		privateGroup.POST("/gost/chains/del", api.DeleteGostChain)
@@ -376,15 +453,15 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.POST("/haproxy/operate", api.OperateHAProxy)
 		privateGroup.GET("/haproxy/check-update", api.CheckHAProxyUpdate)
 		privateGroup.POST("/haproxy/upgrade", api.UpgradeHAProxy)
-		privateGroup.POST("/haproxy/lbs/search", api.SearchHAProxyLB)
+		readGroup.POST("/haproxy/lbs/search", api.SearchHAProxyLB)
 		privateGroup.GET("/haproxy/lbs", api.ListHAProxyLB)
 		privateGroup.POST("/haproxy/lbs", api.CreateHAProxyLB)
 		privateGroup.POST("/haproxy/lbs/update", api.UpdateHAProxyLB)
 		privateGroup.POST("/haproxy/lbs/del", api.DeleteHAProxyLB)
 		privateGroup.POST("/haproxy/lbs/toggle", api.ToggleHAProxyLB)
-		privateGroup.POST("/haproxy/backends/search", api.SearchHAProxyBackend)
+		readGroup.POST("/haproxy/backends/search", api.SearchHAProxyBackend)
 		privateGroup.GET("/haproxy/backends", api.ListHAProxyBackend)
-		privateGroup.POST("/haproxy/backends/detail", api.GetHAProxyBackend)
+		readGroup.POST("/haproxy/backends/detail", api.GetHAProxyBackend)
 		privateGroup.POST("/haproxy/backends", api.CreateHAProxyBackend)
 		privateGroup.POST("/haproxy/backends/update", api.UpdateHAProxyBackend)
 		privateGroup.POST("/haproxy/backends/del", api.DeleteHAProxyBackend)
@@ -393,7 +470,7 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.POST("/haproxy/servers/del", api.DeleteHAProxyServer)
 		privateGroup.POST("/haproxy/servers/toggle-live", api.ToggleHAProxyServerLive)
 		privateGroup.POST("/haproxy/servers/weight-live", api.SetHAProxyServerWeightLive)
-		privateGroup.POST("/haproxy/acls/search", api.ListHAProxyACL)
+		readGroup.POST("/haproxy/acls/search", api.ListHAProxyACL)
 		privateGroup.POST("/haproxy/acls", api.CreateHAProxyACL)
 		privateGroup.POST("/haproxy/acls/update", api.UpdateHAProxyACL)
 		privateGroup.POST("/haproxy/acls/del", api.DeleteHAProxyACL)
@@ -407,7 +484,7 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.GET("/haproxy/config/preview", api.PreviewHAProxyConfig)
 		privateGroup.POST("/haproxy/config/rebuild", api.RebuildHAProxyConfig)
 		privateGroup.GET("/haproxy/config/versions", api.ListHAProxyConfigVersions)
-		privateGroup.POST("/haproxy/config/versions/detail", api.GetHAProxyConfigVersion)
+		readGroup.POST("/haproxy/config/versions/detail", api.GetHAProxyConfigVersion)
 		privateGroup.POST("/haproxy/config/rollback", api.RollbackHAProxyConfig)
 
 		// 工具箱 - Samba
@@ -471,16 +548,16 @@ func Setup(mode string) *gin.Engine {
 
 		// SSH 私钥管理
 		privateGroup.GET("/ssh/keys", api.ListSSHKeys)
-		privateGroup.GET("/ssh/keys/private", api.GetSSHPrivateKey)
+		adminGroup.GET("/ssh/keys/private", api.GetSSHPrivateKey)
 		privateGroup.POST("/ssh/keys/generate", api.GenerateSSHKey)
 		privateGroup.POST("/ssh/keys/import", api.ImportSSHKey)
 		privateGroup.POST("/ssh/keys/delete", api.DeleteSSHKey)
 
 		// 系统用户管理
 		privateGroup.GET("/host/users", api.ListUsers)
-		privateGroup.POST("/host/users/create", api.CreateUser)
-		privateGroup.POST("/host/users/update", api.UpdateUser)
-		privateGroup.POST("/host/users/delete", api.DeleteUser)
+		adminGroup.POST("/host/users/create", api.CreateUser)
+		adminGroup.POST("/host/users/update", api.UpdateUser)
+		adminGroup.POST("/host/users/delete", api.DeleteUser)
 		privateGroup.GET("/host/users/shells", api.ListShells)
 		privateGroup.GET("/host/users/groups", api.ListGroups)
 
@@ -497,7 +574,7 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.POST("/host/system/swap/operate", api.SwapOperate)
 
 		// 网站管理
-		privateGroup.POST("/websites/search", api.SearchWebsite)
+		readGroup.POST("/websites/search", api.SearchWebsite)
 		privateGroup.POST("/websites", api.CreateWebsite)
 		privateGroup.POST("/websites/external/inspect", api.InspectExternalNginxSite)
 		privateGroup.POST("/websites/external", api.CreateExternalNginxSite)
@@ -506,25 +583,50 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.POST("/websites/certificate-health/batch", api.CheckWebsiteCertificateHealthBatch)
 		privateGroup.POST("/websites/update", api.UpdateWebsite)
 		privateGroup.POST("/websites/del", api.DeleteWebsite)
-		privateGroup.POST("/websites/detail", api.GetWebsiteDetail)
+		readGroup.POST("/websites/detail", api.GetWebsiteDetail)
 		privateGroup.POST("/websites/enable", api.EnableWebsite)
 		privateGroup.POST("/websites/disable", api.DisableWebsite)
-		privateGroup.POST("/websites/nginx-config", api.GetWebsiteNginxConfig)
-		privateGroup.POST("/websites/log", api.GetWebsiteLog)
-		privateGroup.POST("/websites/conf-content", api.GetSiteConfContent)
+		readGroup.POST("/websites/nginx-config", api.GetWebsiteNginxConfig)
+		readGroup.POST("/websites/log", api.GetWebsiteLog)
+		privateGroup.GET("/websites/php-versions", api.ListPHPVersions)
+		readGroup.POST("/websites/locations/list", api.ListWebsiteLocations)
+		privateGroup.POST("/websites/locations/save", api.SaveWebsiteLocations)
+		readGroup.POST("/websites/upstreams/list", api.ListWebsiteUpstreams)
+		privateGroup.POST("/websites/upstreams/save", api.SaveWebsiteUpstreams)
+		privateGroup.POST("/websites/upstreams/health", api.CheckWebsiteUpstreamHealth)
+		privateGroup.GET("/websites/waf/rules", api.ListWebsiteWAFRuleSets)
+		readGroup.POST("/websites/waf/detail", api.GetWebsiteWAF)
+		privateGroup.POST("/websites/waf/save", api.SaveWebsiteWAF)
+		readGroup.POST("/websites/waf/log/search", api.SearchWebsiteWAFLog)
+		privateGroup.POST("/websites/waf/ban", api.BanWebsiteWAFIP)
+		readGroup.POST("/websites/access/detail", api.GetWebsiteAccess)
+		privateGroup.POST("/websites/access/save", api.SaveWebsiteAccess)
+		privateGroup.POST("/websites/clone", api.CloneWebsite)
+		privateGroup.POST("/websites/promote", api.PromoteWebsite)
+		readGroup.POST("/websites/deploy/detail", api.GetWebsiteDeploy)
+		privateGroup.POST("/websites/deploy/save", api.SaveWebsiteDeploy)
+		privateGroup.POST("/websites/deploy/run", api.RunWebsiteDeploy)
+		privateGroup.POST("/websites/deploy/rollback", api.RollbackWebsiteDeploy)
+		readGroup.POST("/websites/deploy/records/search", api.SearchWebsiteDeployRecords)
+		privateGroup.POST("/websites/drift/check", api.CheckWebsiteConfigDrift)
+		privateGroup.POST("/websites/drift/resolve", api.ResolveWebsiteConfigDrift)
+		readGroup.POST("/websites/conf-content", api.GetSiteConfContent)
 		privateGroup.POST("/websites/conf-content/save", api.SaveSiteConfContent)
 		privateGroup.POST("/websites/config-mode", api.SwitchConfigMode)
-		privateGroup.POST("/websites/log-analysis", api.AnalyzeNginxLog)
+		readGroup.POST("/websites/log-analysis", api.AnalyzeNginxLog)
+		privateGroup.POST("/websites/access-stats", api.GetWebsiteAccessStats)
+		privateGroup.GET("/websites/access-stats/setting", api.GetAccessStatsSetting)
+		privateGroup.POST("/websites/access-stats/setting/update", api.UpdateAccessStatsSetting)
 		privateGroup.POST("/websites/health", api.CheckWebsiteHealth)
-		privateGroup.POST("/websites/inspect", api.InspectWebsite)
+		readGroup.POST("/websites/inspect", api.InspectWebsite)
 		privateGroup.POST("/websites/log-paths/detect", api.DetectWebsiteLogPaths)
-		privateGroup.POST("/websites/log-alerts", api.GetWebsiteLogAlerts)
+		readGroup.POST("/websites/log-alerts", api.GetWebsiteLogAlerts)
 
 		// Nginx 日志分析（全局）
 		privateGroup.GET("/nginx/log/sites", api.DetectNginxSites)
-		privateGroup.POST("/nginx/log/analyze", api.AnalyzeNginxSiteLog)
-		privateGroup.POST("/nginx/log/tail", api.TailNginxLog)
-		privateGroup.POST("/nginx/log/drilldown", api.DrilldownNginxLog)
+		readGroup.POST("/nginx/log/analyze", api.AnalyzeNginxSiteLog)
+		readGroup.POST("/nginx/log/tail", api.TailNginxLog)
+		readGroup.POST("/nginx/log/drilldown", api.DrilldownNginxLog)
 
 		// 证书同步 - 证书源管理
 		privateGroup.GET("/cert-sources", api.ListCertSources)
@@ -533,7 +635,7 @@ func Setup(mode string) *gin.Engine {
 		privateGroup.POST("/cert-sources/del", api.DeleteCertSource)
 		privateGroup.POST("/cert-sources/sync", api.SyncCertSource)
 		privateGroup.POST("/cert-sources/test", api.TestCertSource)
-		privateGroup.POST("/cert-sync/logs", api.SearchSyncLogs)
+		readGroup.POST("/cert-sync/logs", api.SearchSyncLogs)
 
 		// 证书服务端设置
 		privateGroup.GET("/cert-server/setting", api.GetCertServerSetting)
@@ -553,9 +655,10 @@ func Setup(mode string) *gin.Engine {
 		certServerGroup.GET("/certs", api.ServeCerts)
 	}
 
-	// WebSocket
+	// WebSocket：终端会话等同 root shell，仅管理员可用
 	wsGroup := r.Group("/api/v1")
 	wsGroup.Use(middleware.JWTAuth())
+	wsGroup.Use(middleware.Permission(middleware.AccessAdmin))
 	{
 		wsGroup.GET("/terminal", api.WsTerminal)
 	}
@@ -22,6 +22,8 @@ func TestRegistryContainsApprovedCredentialFields(t *testing.T) {
 		"backup_accounts.access_key",
 		"backup_accounts.credential",
 		"cert_sources.token",
+		"certificate_authorities.private_key",
+		"certificate_deployments.password",
 		"certificates.private_key",
 		"cronjobs.encrypt_password",
 		"database_instances.password",
@@ -35,6 +37,10 @@ func TestRegistryContainsApprovedCredentialFields(t *testing.T) {
 		"hosts.private_key",
 		"nodes.ssh_password",
 		"nodes.token",
+		"notification_channels.config",
+		"users.mfa_pending_secret",
+		"users.mfa_secret",
+		"website_deploys.webhook_secret",
 		"websites.basic_password",
 	}
 	actual := make([]string, 0, len(FieldSpecs))
@@ -47,7 +53,7 @@ func TestRegistryContainsApprovedCredentialFields(t *testing.T) {
 	}
 
 	for _, key := range []string{
-		"MFASecret", "GitHubToken", "AgentToken", "CertServerToken",
+		"MFASecret", "MFAPendingSecret", "GitHubToken", "AgentToken", "CertServerToken",
 		"HAProxyStatsPass", "GostAPIPass", "ProxyAddress", "SecurityEntrance", "NezhaClientSecret",
 	} {
 		if !IsSecretSetting(key) {
@@ -23,15 +23,22 @@ var FieldSpecs = []FieldSpec{
 	{Table: "dns_accounts", Column: "authorization", Scope: "dns_accounts.authorization"},
 	{Table: "certificates", Column: "private_key", Scope: "certificates.private_key"},
 	{Table: "cert_sources", Column: "token", Scope: "cert_sources.token"},
+	{Table: "certificate_deployments", Column: "password", Scope: "certificate_deployments.password"},
+	{Table: "certificate_authorities", Column: "private_key", Scope: "certificate_authorities.private_key"},
 	{Table: "websites", Column: "basic_password", Scope: "websites.basic_password"},
+	{Table: "website_deploys", Column: "webhook_secret", Scope: "website_deploys.webhook_secret"},
 	{Table: "gost_services", Column: "auth_pass", Scope: "gost_services.auth_pass"},
 	{Table: "gost_chains", Column: "hops", Scope: "gost_chains.hops"},
 	{Table: "cronjobs", Column: "encrypt_password", Scope: "cronjobs.encrypt_password"},
 	{Table: "ha_proxy_config_versions", Column: "content", Scope: "ha_proxy_config_versions.content"},
+	{Table: "users", Column: "mfa_secret", Scope: "users.mfa_secret"},
+	{Table: "users", Column: "mfa_pending_secret", Scope: "users.mfa_pending_secret"},
+	{Table: "notification_channels", Column: "config", Scope: "notification_channels.config"},
 }
 
 var SecretSettingKeys = map[string]struct{}{
 	"MFASecret":         {},
+	"MFAPendingSecret":  {},
 	"GitHubToken":       {},
 	"AgentToken":        {},
 	"CertServerToken":   {},
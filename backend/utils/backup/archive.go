@@ -9,11 +9,17 @@ import (
 )
 
 type ArchiveOptions struct {
-	SourceDir      string // directory to archive
-	OutFile        string // output file path (extension auto-adjusted)
-	CompressFormat string // gzip (default), zstd, xz
+	SourceDir       string // directory to archive
+	OutFile         string // output file path (extension auto-adjusted)
+	CompressFormat  string // gzip (default), zstd, xz
 	EncryptPassword string // if set, encrypt with openssl AES-256-CBC
-	ExclusionRules string // newline-separated patterns for tar --exclude
+	ExclusionRules  string // newline-separated patterns for tar --exclude
+	SnapshotFile    string // tar --listed-incremental state; empty means a plain full archive
+}
+
+type ExtractOptions struct {
+	StripComponents int  // leading path elements dropped from every member
+	Incremental     bool // replay a --listed-incremental archive, applying recorded deletions
 }
 
 // CreateArchive creates a compressed (and optionally encrypted) tar archive.
@@ -44,6 +50,9 @@ func CreateArchive(opts ArchiveOptions) (string, error) {
 	}
 
 	args := buildTarArgs(format, tarFile, opts.SourceDir, opts.ExclusionRules)
+	if opts.SnapshotFile != "" {
+		args = append([]string{"--listed-incremental=" + opts.SnapshotFile}, args...)
+	}
 	cmd := exec.Command("tar", args...)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return "", fmt.Errorf("tar failed: %s", strings.TrimSpace(string(output)))
@@ -127,6 +136,56 @@ func DecryptFile(src, dst, password string) error {
 	return nil
 }
 
+// ListArchive returns the member names of a tar archive. Compression is
+// detected from the file extension.
+func ListArchive(file string) ([]string, error) {
+	args := append([]string{"-t"}, decompressFlag(file)...)
+	args = append(args, "-f", file)
+	output, err := exec.Command("tar", args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("list archive failed: %s", strings.TrimSpace(string(output)))
+	}
+	var entries []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			entries = append(entries, line)
+		}
+	}
+	return entries, nil
+}
+
+// ExtractArchive extracts a tar archive into targetDir.
+func ExtractArchive(file, targetDir string, opts ExtractOptions) error {
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("create target dir: %v", err)
+	}
+	args := append([]string{"-x"}, decompressFlag(file)...)
+	args = append(args, "-f", file, "-C", targetDir)
+	if opts.StripComponents > 0 {
+		args = append(args, fmt.Sprintf("--strip-components=%d", opts.StripComponents))
+	}
+	if opts.Incremental {
+		args = append(args, "--listed-incremental=/dev/null")
+	}
+	if output, err := exec.Command("tar", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("extract archive failed: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func decompressFlag(file string) []string {
+	name := strings.TrimSuffix(file, ".enc")
+	switch {
+	case strings.HasSuffix(name, ".zst"):
+		return []string{"--zstd"}
+	case strings.HasSuffix(name, ".xz"):
+		return []string{"-J"}
+	case strings.HasSuffix(name, ".gz"), strings.HasSuffix(name, ".tgz"):
+		return []string{"-z"}
+	}
+	return nil
+}
+
 // SupportedFormats returns the list of supported compression formats.
 func SupportedFormats() []string {
 	return []string{"gzip", "zstd", "xz"}
@@ -0,0 +1,187 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Kind int
+
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+type Line struct {
+	Kind Kind
+	Text string
+}
+
+// maxTableCells 超过该规模时不再求 LCS，直接整体替换，避免超大文件占用过多内存
+const maxTableCells = 4 << 20
+
+// Lines 按行计算从 a 到 b 的差异（最长公共子序列）
+func Lines(a, b []string) []Line {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var out []Line
+	for _, text := range a[:prefix] {
+		out = append(out, Line{Kind: Equal, Text: text})
+	}
+	out = append(out, middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		out = append(out, Line{Kind: Equal, Text: text})
+	}
+	return out
+}
+
+func middle(a, b []string) []Line {
+	var out []Line
+	if (len(a)+1)*(len(b)+1) > maxTableCells {
+		for _, text := range a {
+			out = append(out, Line{Kind: Delete, Text: text})
+		}
+		for _, text := range b {
+			out = append(out, Line{Kind: Insert, Text: text})
+		}
+		return out
+	}
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, Line{Kind: Equal, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, Line{Kind: Delete, Text: a[i]})
+			i++
+		default:
+			out = append(out, Line{Kind: Insert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, Line{Kind: Delete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		out = append(out, Line{Kind: Insert, Text: b[j]})
+	}
+	return out
+}
+
+// SplitLines 按行拆分文本，忽略末尾换行
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Unified 生成 unified diff，context 为每个变更块前后保留的上下文行数；内容相同时返回空串
+func Unified(fromName, toName, from, to string, context int) string {
+	lines := Lines(SplitLines(from), SplitLines(to))
+	changed := false
+	for _, line := range lines {
+		if line.Kind != Equal {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(lines); {
+		// 找到下一处变更，连同上下文组成一个 hunk；相距不超过 2*context 的变更合并
+		first := start
+		for first < len(lines) && lines[first].Kind == Equal {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		begin := max(first-context, start)
+		end := first
+		for end < len(lines) {
+			if lines[end].Kind != Equal {
+				end++
+				continue
+			}
+			next := end
+			for next < len(lines) && lines[next].Kind == Equal {
+				next++
+			}
+			if next == len(lines) || next-end > 2*context {
+				end = min(end+context, len(lines))
+				break
+			}
+			end = next
+		}
+
+		fromLine, toLine := 1, 1
+		for _, line := range lines[:begin] {
+			if line.Kind != Insert {
+				fromLine++
+			}
+			if line.Kind != Delete {
+				toLine++
+			}
+		}
+		fromCount, toCount := 0, 0
+		for _, line := range lines[begin:end] {
+			if line.Kind != Insert {
+				fromCount++
+			}
+			if line.Kind != Delete {
+				toCount++
+			}
+		}
+		if fromCount == 0 {
+			fromLine--
+		}
+		if toCount == 0 {
+			toLine--
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", fromLine, fromCount, toLine, toCount)
+		for _, line := range lines[begin:end] {
+			switch line.Kind {
+			case Equal:
+				b.WriteString(" ")
+			case Delete:
+				b.WriteString("-")
+			case Insert:
+				b.WriteString("+")
+			}
+			b.WriteString(line.Text + "\n")
+		}
+		start = end
+	}
+	return b.String()
+}
@@ -0,0 +1,34 @@
+package diff
+
+import "testing"
+
+func TestUnifiedProducesHunksWithContext(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	to := "a\nb\nc\nD\ne\nf\ng\nh\ni\nj\nk\n"
+	got := Unified("rendered", "disk", from, to, 2)
+	want := "--- rendered\n+++ disk\n" +
+		"@@ -2,5 +2,5 @@\n b\n c\n-d\n+D\n e\n f\n" +
+		"@@ -9,2 +9,3 @@\n i\n j\n+k\n"
+	if got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+	if Unified("a", "b", from, from, 3) != "" {
+		t.Fatal("identical content should produce an empty diff")
+	}
+}
+
+func TestLinesMarksInsertedAndDeletedLines(t *testing.T) {
+	lines := Lines([]string{"server {", "    root /a;", "}"}, []string{"server {", "    root /b;", "    gzip on;", "}"})
+	var inserted, deleted int
+	for _, line := range lines {
+		switch line.Kind {
+		case Insert:
+			inserted++
+		case Delete:
+			deleted++
+		}
+	}
+	if inserted != 2 || deleted != 1 || len(lines) != 5 {
+		t.Fatalf("unexpected diff lines: %+v", lines)
+	}
+}
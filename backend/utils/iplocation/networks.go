@@ -0,0 +1,58 @@
+package iplocation
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// CountryNetworks 遍历数据库，返回各国家代码对应的网段（CIDR），用于生成 nginx geo 规则
+func (s *Service) CountryNetworks(codes []string) (map[string][]string, error) {
+	wanted := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		wanted[strings.ToUpper(code)] = true
+	}
+	result := make(map[string][]string, len(wanted))
+	if len(wanted) == 0 {
+		return result, nil
+	}
+
+	s.mu.RLock()
+	dbPath := s.dbPath
+	s.mu.RUnlock()
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("ip database not found: %s", dbPath)
+	}
+	reader, err := maxminddb.Open(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("open ip database: %w", err)
+	}
+	defer reader.Close()
+
+	var record struct {
+		Country struct {
+			IsoCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+	}
+	networks := reader.Networks(maxminddb.SkipAliasedNetworks)
+	for networks.Next() {
+		record.Country.IsoCode = ""
+		network, err := networks.Network(&record)
+		if err != nil {
+			return nil, fmt.Errorf("read ip database: %w", err)
+		}
+		if code := record.Country.IsoCode; wanted[code] {
+			result[code] = append(result[code], network.String())
+		}
+	}
+	if err := networks.Err(); err != nil {
+		return nil, fmt.Errorf("read ip database: %w", err)
+	}
+	for code := range result {
+		sort.Strings(result[code])
+	}
+	return result, nil
+}
@@ -26,12 +26,18 @@ func GenerateToken(userName string) (string, error) {
 
 // GenerateTokenWithTimeout 按指定秒数生成 JWT Token
 func GenerateTokenWithTimeout(userName string, timeout int) (string, error) {
+	return GenerateSessionToken(userName, "", timeout)
+}
+
+// GenerateSessionToken 生成绑定服务端会话的 JWT Token，sessionID 写入 jti
+func GenerateSessionToken(userName, sessionID string, timeout int) (string, error) {
 	if timeout <= 0 {
 		timeout = constant.DefaultSessionTimeout
 	}
 	claims := Claims{
 		UserName: userName,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(timeout) * time.Second)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    constant.JWTIssuer,
@@ -0,0 +1,251 @@
+package logrotate
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	IntervalDaily   = "daily"
+	IntervalWeekly  = "weekly"
+	IntervalMonthly = "monthly"
+	IntervalNone    = "none"
+
+	// 历史文件命名：<原文件名>-20060102-150405[.gz]
+	timeLayout = "20060102-150405"
+)
+
+var rotatedSuffixRe = regexp.MustCompile(`^-(\d{8}-\d{6})(\.gz)?$`)
+
+// Policy 单个日志文件的轮转策略
+type Policy struct {
+	Interval string // daily | weekly | monthly | none
+	MaxSize  int64  // 字节，0 表示不按大小触发
+	Keep     int    // 保留的历史文件数，0 表示不限
+	MaxAge   int    // 天，0 表示不按时间清理
+	Compress bool
+	// CopyTruncate 复制后截断原文件，用于写入方无法重新打开文件的场景（面板自身日志）
+	CopyTruncate bool
+}
+
+// Rotated 一个历史文件
+type Rotated struct {
+	Path       string
+	Time       time.Time
+	Compressed bool
+	Size       int64
+}
+
+// List 返回 path 的历史文件，按轮转时间从新到旧排序
+func List(path string) []Rotated {
+	matches, _ := filepath.Glob(path + "-*")
+	var items []Rotated
+	for _, match := range matches {
+		m := rotatedSuffixRe.FindStringSubmatch(strings.TrimPrefix(match, path))
+		if m == nil {
+			continue
+		}
+		t, err := time.ParseInLocation(timeLayout, m[1], time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(match)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		items = append(items, Rotated{Path: match, Time: t, Compressed: m[2] != "", Size: info.Size()})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Time.After(items[j].Time) })
+	return items
+}
+
+// Due 判断文件是否需要轮转：空文件不轮转；超过大小上限，或上次轮转早于当前周期的起点时轮转。
+// 从未轮转过的文件在首次检查时即按周期轮转。
+func Due(path string, p Policy, now time.Time) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if info.IsDir() || info.Size() == 0 {
+		return false, nil
+	}
+	if p.MaxSize > 0 && info.Size() >= p.MaxSize {
+		return true, nil
+	}
+	start, ok := periodStart(p.Interval, now)
+	if !ok {
+		return false, nil
+	}
+	history := List(path)
+	return len(history) == 0 || history[0].Time.Before(start), nil
+}
+
+func periodStart(interval string, now time.Time) (time.Time, bool) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch interval {
+	case IntervalDaily:
+		return day, true
+	case IntervalWeekly:
+		// 以周一为一周的开始
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)), true
+	case IntervalMonthly:
+		return day.AddDate(0, 0, 1-day.Day()), true
+	}
+	return time.Time{}, false
+}
+
+// Rotate 将当前文件转为历史文件并返回其路径。
+// 默认直接改名，写入方需要随后重新打开日志（nginx 的 USR1）；CopyTruncate 时复制内容后截断原文件。
+func Rotate(path string, p Policy, now time.Time) (string, error) {
+	dest := ""
+	for i := 0; ; i++ {
+		dest = path + "-" + now.Add(time.Duration(i)*time.Second).Format(timeLayout)
+		if !exists(dest) && !exists(dest+".gz") {
+			break
+		}
+	}
+	if !p.CopyTruncate {
+		if err := os.Rename(path, dest); err != nil {
+			return "", err
+		}
+		return dest, nil
+	}
+	if err := copyFile(path, dest); err != nil {
+		os.Remove(dest)
+		return "", err
+	}
+	if err := os.Truncate(path, 0); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// Cleanup 压缩并清理历史文件，返回压缩与删除的数量。
+// 最新的一份历史文件暂不压缩：改名后写入方在重新打开日志前仍可能向其追加，
+// 访问统计采集器也需要从中读完轮转前的剩余内容。
+func Cleanup(path string, p Policy, now time.Time) (compressed, removed int, err error) {
+	var cutoff time.Time
+	if p.MaxAge > 0 {
+		cutoff = now.AddDate(0, 0, -p.MaxAge)
+	}
+	for i, item := range List(path) {
+		if (p.Keep > 0 && i >= p.Keep) || (!cutoff.IsZero() && item.Time.Before(cutoff)) {
+			if e := os.Remove(item.Path); e != nil {
+				err = e
+				continue
+			}
+			removed++
+			continue
+		}
+		if p.Compress && !item.Compressed && i > 0 {
+			if e := compressFile(item.Path); e != nil {
+				err = e
+				continue
+			}
+			compressed++
+		}
+	}
+	return compressed, removed, err
+}
+
+// Open 打开日志文件，.gz 文件透明解压
+func Open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: zr, file: f}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	zw.Name = filepath.Base(path)
+	zw.ModTime = info.ModTime()
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("compress %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// 保留原文件的修改时间，按时间挑选历史日志时依赖它
+	os.Chtimes(path+".gz", info.ModTime(), info.ModTime())
+	return os.Remove(path)
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,136 @@
+package logrotate
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeLog(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDueBySizeAndInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "site.access.log")
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.Local) // 周三
+
+	if due, _ := Due(path, Policy{Interval: IntervalDaily}, now); due {
+		t.Fatal("missing file should not be due")
+	}
+	writeLog(t, path, "")
+	if due, _ := Due(path, Policy{Interval: IntervalDaily}, now); due {
+		t.Fatal("empty file should not be due")
+	}
+	writeLog(t, path, "0123456789")
+	if due, _ := Due(path, Policy{Interval: IntervalNone, MaxSize: 10}, now); !due {
+		t.Fatal("file reaching max size should be due")
+	}
+	if due, _ := Due(path, Policy{Interval: IntervalNone, MaxSize: 11}, now); due {
+		t.Fatal("file below max size should not be due")
+	}
+	if due, _ := Due(path, Policy{Interval: IntervalDaily}, now); !due {
+		t.Fatal("never rotated file should be due")
+	}
+
+	writeLog(t, path+"-20261013-000000.gz", "x")
+	if due, _ := Due(path, Policy{Interval: IntervalDaily}, now); !due {
+		t.Fatal("rotated yesterday, daily rotation should be due")
+	}
+	if due, _ := Due(path, Policy{Interval: IntervalWeekly}, now); due {
+		t.Fatal("rotated this monday, weekly rotation should wait")
+	}
+	writeLog(t, path+"-20261014-000500", "x")
+	if due, _ := Due(path, Policy{Interval: IntervalDaily}, now); due {
+		t.Fatal("rotated today, daily rotation should wait")
+	}
+}
+
+func TestRotateAndCleanup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "xpanel.log")
+	now := time.Date(2026, 10, 18, 4, 0, 0, 0, time.Local)
+	for _, name := range []string{"xpanel.log-20261001-000000.gz", "xpanel.log-20261016-000000", "xpanel.log-20261017-000000", "xpanel.log.bak"} {
+		writeLog(t, filepath.Join(dir, name), "old\n")
+	}
+
+	writeLog(t, path, "line\n")
+	rotated, err := Rotate(path, Policy{CopyTruncate: true}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated != path+"-20261018-040000" {
+		t.Fatalf("rotated = %s", rotated)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Fatalf("copytruncate should keep an empty original: %v %v", info, err)
+	}
+
+	compressed, removed, err := Cleanup(path, Policy{Keep: 3, MaxAge: 14, Compress: true}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compressed != 2 || removed != 1 {
+		t.Fatalf("compressed=%d removed=%d", compressed, removed)
+	}
+	history := List(path)
+	if len(history) != 3 || history[0].Compressed || !history[1].Compressed || !history[2].Compressed {
+		t.Fatalf("unexpected history %+v", history)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "xpanel.log.bak")); err != nil {
+		t.Fatal("unrelated files must be left alone")
+	}
+
+	r, err := Open(history[1].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	content, _ := io.ReadAll(r)
+	if string(content) != "old\n" {
+		t.Fatalf("decompressed content = %q", content)
+	}
+
+	writeLog(t, path, "next\n")
+	if again, err := Rotate(path, Policy{}, now); err != nil || again != path+"-20261018-040001" {
+		t.Fatalf("second rotation in the same second: %s %v", again, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("rename rotation should move the original away")
+	}
+}
+
+func TestSystemPatternsSkipsDirectivesAndScripts(t *testing.T) {
+	dir := t.TempDir()
+	writeLog(t, filepath.Join(dir, "nginx"), `/var/log/nginx/*.log {
+	daily
+	postrotate
+		[ -f /run/nginx.pid ] && kill -USR1 $(cat /run/nginx.pid) { }
+	endscript
+}
+"/var/log/app/a.log" /var/log/app/b.log
+{
+	weekly
+}
+`)
+	conf := filepath.Join(t.TempDir(), "logrotate.conf")
+	writeLog(t, conf, "weekly\ninclude "+dir+"\n/var/log/wtmp {\n\tmonthly\n}\n")
+
+	patterns := SystemPatterns(conf, dir, filepath.Join(dir, "missing"))
+	want := []string{"/var/log/wtmp", "/var/log/nginx/*.log", "/var/log/app/a.log", "/var/log/app/b.log"}
+	if len(patterns) != len(want) {
+		t.Fatalf("patterns = %v, want %v", patterns, want)
+	}
+	for i := range want {
+		if patterns[i] != want[i] {
+			t.Fatalf("patterns = %v, want %v", patterns, want)
+		}
+	}
+	if !Covered(patterns, "/var/log/nginx/access.log") || Covered(patterns, "/var/log/nginx/sites/a.example.com.access.log") {
+		t.Fatal("glob should match only the top-level nginx logs")
+	}
+}
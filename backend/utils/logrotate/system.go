@@ -0,0 +1,94 @@
+package logrotate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// scriptDirectives 脚本块以 endscript 结束，块内内容不参与解析
+var scriptDirectives = map[string]bool{
+	"prerotate": true, "postrotate": true, "firstaction": true, "lastaction": true, "preremove": true,
+}
+
+// SystemPatterns 读取系统 logrotate 配置（文件或 logrotate.d 目录），返回其中声明的日志路径模式
+func SystemPatterns(configs ...string) []string {
+	var patterns []string
+	for _, config := range configs {
+		info, err := os.Stat(config)
+		if err != nil {
+			continue
+		}
+		files := []string{config}
+		if info.IsDir() {
+			entries, err := os.ReadDir(config)
+			if err != nil {
+				continue
+			}
+			files = files[:0]
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(config, entry.Name()))
+				}
+			}
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			patterns = append(patterns, parsePatterns(string(data))...)
+		}
+	}
+	return patterns
+}
+
+// parsePatterns 取出配置块之前的路径，跳过块内指令与脚本
+func parsePatterns(content string) []string {
+	var patterns []string
+	depth := 0
+	inScript := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+		if inScript {
+			inScript = line != "endscript"
+			continue
+		}
+		fields := strings.Fields(strings.NewReplacer("{", " { ", "}", " } ").Replace(line))
+		if depth > 0 && scriptDirectives[fields[0]] {
+			inScript = true
+			continue
+		}
+		// 顶层的 include 等全局指令不是日志路径
+		if depth == 0 && !strings.HasPrefix(strings.Trim(fields[0], `"'`), "/") && fields[0] != "{" {
+			continue
+		}
+		for _, field := range fields {
+			switch {
+			case field == "{":
+				depth++
+			case field == "}":
+				depth--
+			case depth == 0 && strings.HasPrefix(strings.Trim(field, `"'`), "/"):
+				patterns = append(patterns, strings.Trim(field, `"'`))
+			}
+		}
+	}
+	return patterns
+}
+
+// Covered 判断 path 是否已由系统 logrotate 的某个模式管理
+func Covered(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package mfa
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret 为 RFC 6238 附录 B 的 SHA1 测试密钥 "12345678901234567890"
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateCodeMatchesRFC6238Vectors(t *testing.T) {
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		got, err := GenerateCode(rfc6238Secret, Step(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("GenerateCode(t=%d) = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidateAcceptsSkewAndRejectsReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	previous, _ := GenerateCode(rfc6238Secret, Step(now)-1)
+	step, ok := Validate(rfc6238Secret, previous, now, 0)
+	if !ok || step != Step(now)-1 {
+		t.Fatalf("previous-step code rejected: step=%d ok=%v", step, ok)
+	}
+	if _, ok := Validate(rfc6238Secret, previous, now, step); ok {
+		t.Fatal("already used step was accepted again")
+	}
+	stale, _ := GenerateCode(rfc6238Secret, Step(now)-3)
+	if _, ok := Validate(rfc6238Secret, stale, now, 0); ok {
+		t.Fatal("code outside skew window was accepted")
+	}
+}
+
+func TestRecoveryCodeHashIgnoresFormatting(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 3 || codes[0] == codes[1] {
+		t.Fatalf("unexpected recovery codes: %v", codes)
+	}
+	code := codes[0]
+	compact := strings.ToUpper(strings.ReplaceAll(code, "-", ""))
+	if HashRecoveryCode(code) != HashRecoveryCode(" "+compact+" ") {
+		t.Fatalf("hash differs for %q and %q", code, compact)
+	}
+}
+
+func TestBuildURIContainsSecretAndIssuer(t *testing.T) {
+	uri := BuildURI("X-Panel", "admin", rfc6238Secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/X-Panel:admin?") ||
+		!strings.Contains(uri, "secret="+rfc6238Secret) ||
+		!strings.Contains(uri, "issuer=X-Panel") {
+		t.Fatalf("unexpected uri: %s", uri)
+	}
+}
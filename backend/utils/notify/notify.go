@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 渠道类型
+const (
+	TypeEmail    = "email"
+	TypeWebhook  = "webhook"
+	TypeTelegram = "telegram"
+	TypeDingTalk = "dingtalk"
+	TypeFeishu   = "feishu"
+	TypeWeCom    = "wecom"
+)
+
+// Types 支持的渠道类型
+var Types = []string{TypeEmail, TypeWebhook, TypeTelegram, TypeDingTalk, TypeFeishu, TypeWeCom}
+
+// SecretKeys 各渠道配置中需加密且不回显的字段
+var SecretKeys = map[string][]string{
+	TypeEmail:    {"password"},
+	TypeWebhook:  {"secret"},
+	TypeTelegram: {"botToken"},
+	TypeDingTalk: {"webhook", "secret"},
+	TypeFeishu:   {"webhook", "secret"},
+	TypeWeCom:    {"webhook"},
+}
+
+// requiredKeys 各渠道必填配置
+var requiredKeys = map[string][]string{
+	TypeEmail:    {"host", "port", "from", "to"},
+	TypeWebhook:  {"url"},
+	TypeTelegram: {"botToken", "chatID"},
+	TypeDingTalk: {"webhook"},
+	TypeFeishu:   {"webhook"},
+	TypeWeCom:    {"webhook"},
+}
+
+const requestTimeout = 15 * time.Second
+
+// Message 外发通知内容
+type Message struct {
+	Event     string    `json:"event"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	TargetURL string    `json:"targetUrl"`
+	Time      time.Time `json:"time"`
+}
+
+// Text 纯文本形式，供 IM 机器人与邮件正文使用
+func (m Message) Text() string {
+	var sb strings.Builder
+	sb.WriteString(m.Title)
+	if m.Content != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(m.Content)
+	}
+	if m.Event != "" {
+		sb.WriteString("\n\n事件：")
+		sb.WriteString(m.Event)
+	}
+	sb.WriteString("\n时间：")
+	sb.WriteString(m.Time.Format("2006-01-02 15:04:05"))
+	return sb.String()
+}
+
+// Sender 外发渠道
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Validate 校验渠道类型与必填配置
+func Validate(channelType string, config map[string]string) error {
+	keys, ok := requiredKeys[channelType]
+	if !ok {
+		return fmt.Errorf("unsupported channel type: %s", channelType)
+	}
+	for _, key := range keys {
+		if strings.TrimSpace(config[key]) == "" {
+			return fmt.Errorf("channel config %s is required", key)
+		}
+	}
+	return nil
+}
+
+// NewSender 按渠道类型创建发送器
+func NewSender(channelType string, config map[string]string) (Sender, error) {
+	if err := Validate(channelType, config); err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: requestTimeout}
+	switch channelType {
+	case TypeEmail:
+		return &emailSender{config: config}, nil
+	case TypeWebhook:
+		return &webhookSender{client: client, url: config["url"], secret: config["secret"]}, nil
+	case TypeTelegram:
+		return &telegramSender{client: client, apiURL: config["apiURL"], token: config["botToken"], chatID: config["chatID"]}, nil
+	case TypeDingTalk:
+		return &dingTalkSender{client: client, webhook: config["webhook"], secret: config["secret"]}, nil
+	case TypeFeishu:
+		return &feishuSender{client: client, webhook: config["webhook"], secret: config["secret"]}, nil
+	case TypeWeCom:
+		return &weComSender{client: client, webhook: config["webhook"]}, nil
+	}
+	return nil, fmt.Errorf("unsupported channel type: %s", channelType)
+}
+
+// postJSON 发送 JSON 请求，非 2xx 响应视为失败并返回响应体
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}, headers map[string]string) ([]byte, error) {
+	body, ok := payload.([]byte)
+	if !ok {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return data, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
@@ -0,0 +1,171 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testMessage = Message{
+	Event:   "cronjob.failed",
+	Type:    "error",
+	Title:   "任务失败",
+	Content: "backup exited with 1",
+	Time:    time.Unix(1700000000, 0),
+}
+
+func TestWebhookSignsBody(t *testing.T) {
+	var (
+		gotBody      []byte
+		gotSignature string
+		gotTimestamp string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSignature = r.Header.Get(HeaderSignature)
+		gotTimestamp = r.Header.Get(HeaderTimestamp)
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(TypeWebhook, map[string]string{"url": server.URL, "secret": "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if gotTimestamp != "1700000000" {
+		t.Fatalf("timestamp = %q", gotTimestamp)
+	}
+	if want := "sha256=" + SignWebhook("s3cret", gotTimestamp, gotBody); gotSignature != want {
+		t.Fatalf("signature = %q, want %q", gotSignature, want)
+	}
+	var decoded Message
+	if err := json.Unmarshal(gotBody, &decoded); err != nil || decoded.Event != "cronjob.failed" {
+		t.Fatalf("body = %s, %v", gotBody, err)
+	}
+}
+
+func TestRobotErrorCodes(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		switch r.URL.Path {
+		case "/ok":
+			_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+		case "/feishu":
+			_, _ = w.Write([]byte(`{"code":19021,"msg":"sign match fail"}`))
+		case "/bottoken/sendMessage":
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		default:
+			_, _ = w.Write([]byte(`{"errcode":310000,"errmsg":"keywords not in content"}`))
+		}
+	}))
+	defer server.Close()
+
+	ding, _ := NewSender(TypeDingTalk, map[string]string{"webhook": server.URL + "/ok?access_token=x", "secret": "SEC"})
+	if err := ding.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(query, "access_token=x&") || !strings.Contains(query, "sign=") {
+		t.Fatalf("dingtalk query = %q", query)
+	}
+	wecom, _ := NewSender(TypeWeCom, map[string]string{"webhook": server.URL + "/fail"})
+	if err := wecom.Send(context.Background(), testMessage); err == nil || !strings.Contains(err.Error(), "310000") {
+		t.Fatalf("wecom error = %v", err)
+	}
+	feishu, _ := NewSender(TypeFeishu, map[string]string{"webhook": server.URL + "/feishu", "secret": "x"})
+	if err := feishu.Send(context.Background(), testMessage); err == nil {
+		t.Fatal("feishu error code ignored")
+	}
+	telegram, _ := NewSender(TypeTelegram, map[string]string{"apiURL": server.URL, "botToken": "token", "chatID": "1"})
+	if err := telegram.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateRequiresConfig(t *testing.T) {
+	if err := Validate("pager", nil); err == nil {
+		t.Fatal("unknown type accepted")
+	}
+	if err := Validate(TypeEmail, map[string]string{"host": "smtp", "port": "25", "from": "a@b"}); err == nil {
+		t.Fatal("email without recipients accepted")
+	}
+}
+
+func TestEmailOverPlainSMTP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	received := make(chan string, 1)
+	go serveFakeSMTP(t, listener, received)
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	sender, err := NewSender(TypeEmail, map[string]string{
+		"host": host, "port": port, "security": SecurityNone,
+		"from": "panel@example.com", "to": "ops@example.com; dev@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sender.Send(ctx, testMessage); err != nil {
+		t.Fatal(err)
+	}
+	data := <-received
+	if !strings.Contains(data, "RCPT TO:<dev@example.com>") || !strings.Contains(data, "Subject: =?UTF-8?b?") {
+		t.Fatalf("smtp transcript = %s", data)
+	}
+}
+
+// serveFakeSMTP 最小 SMTP 实现，记录客户端会话
+func serveFakeSMTP(t *testing.T, listener net.Listener, received chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	var transcript strings.Builder
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	reply("220 fake ESMTP")
+	inData := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			received <- transcript.String()
+			return
+		}
+		transcript.WriteString(line)
+		if inData {
+			if line == ".\r\n" {
+				inData = false
+				reply("250 queued")
+			}
+			continue
+		}
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 fake")
+		case cmd == "DATA":
+			inData = true
+			reply("354 go ahead")
+		case cmd == "QUIT":
+			reply("221 bye")
+			received <- transcript.String()
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const defaultTelegramAPI = "https://api.telegram.org"
+
+type telegramSender struct {
+	client *http.Client
+	apiURL string
+	token  string
+	chatID string
+}
+
+func (s *telegramSender) Send(ctx context.Context, msg Message) error {
+	apiURL := strings.TrimRight(s.apiURL, "/")
+	if apiURL == "" {
+		apiURL = defaultTelegramAPI
+	}
+	data, err := postJSON(ctx, s.client, apiURL+"/bot"+s.token+"/sendMessage", map[string]interface{}{
+		"chat_id": s.chatID,
+		"text":    msg.Text(),
+	}, nil)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	if !resp.OK {
+		return fmt.Errorf("telegram: %s", resp.Description)
+	}
+	return nil
+}
+
+// dingTalkSender 钉钉自定义机器人；配置加签密钥时在 URL 上附加 timestamp 与 sign
+type dingTalkSender struct {
+	client  *http.Client
+	webhook string
+	secret  string
+}
+
+func (s *dingTalkSender) Send(ctx context.Context, msg Message) error {
+	target := s.webhook
+	if s.secret != "" {
+		timestamp := strconv.FormatInt(msg.Time.UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(s.secret))
+		mac.Write([]byte(timestamp + "\n" + s.secret))
+		sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		target = appendQuery(target, url.Values{"timestamp": {timestamp}, "sign": {sign}})
+	}
+	data, err := postJSON(ctx, s.client, target, map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": msg.Text()},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	return checkErrCode("dingtalk", data)
+}
+
+// feishuSender 飞书自定义机器人；签名为以 "{timestamp}\n{secret}" 为密钥对空串做 HMAC-SHA256
+type feishuSender struct {
+	client  *http.Client
+	webhook string
+	secret  string
+}
+
+func (s *feishuSender) Send(ctx context.Context, msg Message) error {
+	payload := map[string]interface{}{
+		"msg_type": "text",
+		"content":  map[string]string{"text": msg.Text()},
+	}
+	if s.secret != "" {
+		timestamp := strconv.FormatInt(msg.Time.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(timestamp+"\n"+s.secret))
+		payload["timestamp"] = timestamp
+		payload["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+	data, err := postJSON(ctx, s.client, s.webhook, payload, nil)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Code       *int   `json:"code"`
+		StatusCode *int   `json:"StatusCode"`
+		Msg        string `json:"msg"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	if resp.Code != nil && *resp.Code != 0 {
+		return fmt.Errorf("feishu: %d %s", *resp.Code, resp.Msg)
+	}
+	if resp.StatusCode != nil && *resp.StatusCode != 0 {
+		return fmt.Errorf("feishu: %d %s", *resp.StatusCode, resp.Msg)
+	}
+	return nil
+}
+
+// weComSender 企业微信群机器人
+type weComSender struct {
+	client  *http.Client
+	webhook string
+}
+
+func (s *weComSender) Send(ctx context.Context, msg Message) error {
+	data, err := postJSON(ctx, s.client, s.webhook, map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": msg.Text()},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	return checkErrCode("wecom", data)
+}
+
+// checkErrCode 钉钉与企业微信均以 errcode 非 0 表示失败
+func checkErrCode(provider string, data []byte) error {
+	var resp struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	if resp.ErrCode != 0 {
+		return fmt.Errorf("%s: %d %s", provider, resp.ErrCode, resp.ErrMsg)
+	}
+	return nil
+}
+
+func appendQuery(rawURL string, values url.Values) string {
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + values.Encode()
+}
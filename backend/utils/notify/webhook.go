@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// 通用 Webhook 请求头；配置密钥时签名为 HMAC-SHA256(secret, "{timestamp}.{body}")
+const (
+	HeaderEvent     = "X-XPanel-Event"
+	HeaderTimestamp = "X-XPanel-Timestamp"
+	HeaderSignature = "X-XPanel-Signature"
+)
+
+type webhookSender struct {
+	client *http.Client
+	url    string
+	secret string
+}
+
+func (s *webhookSender) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(msg.Time.Unix(), 10)
+	headers := map[string]string{
+		HeaderEvent:     msg.Event,
+		HeaderTimestamp: timestamp,
+	}
+	if s.secret != "" {
+		headers[HeaderSignature] = "sha256=" + SignWebhook(s.secret, timestamp, body)
+	}
+	_, err = postJSON(ctx, s.client, s.url, body, headers)
+	return err
+}
+
+// SignWebhook 计算通用 Webhook 签名（十六进制），接收方可用同一算法校验
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -13,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-acme/lego/v4/acme/api"
 	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/challenge/dns01"
@@ -144,6 +146,20 @@ func RegisterAccount(email, keyType, caType, caDirURL string) (privateKeyPEM str
 // 删除操作是异步的，如果等待时间太短立即重试，新的 Present 会遇到 DomainRecordDuplicate 错误。
 // 等待 120s 足以让绝大多数 DNS 服务商完成删除操作。
 func (c *AcmeClient) ObtainCertificate(domains []string, keyType string, logWriter ...io.Writer) (*certificate.Resource, error) {
+	return c.obtainCertificate(domains, keyType, "", logWriter...)
+}
+
+// ReplaceCertificate 使用新私钥重新签发证书，并在订单中声明替换旧证书（RFC 9773 replaces），
+// CA 据此把新订单视为续签而不计入新证书配额；证书被吊销后必须走这里，不能沿用旧私钥
+func (c *AcmeClient) ReplaceCertificate(domains []string, keyType string, oldCertPEM []byte, logWriter ...io.Writer) (*certificate.Resource, error) {
+	replaces := ""
+	if leaf, err := parseLeafCertificate(oldCertPEM); err == nil {
+		replaces, _ = certificate.MakeARICertID(leaf)
+	}
+	return c.obtainCertificate(domains, keyType, replaces, logWriter...)
+}
+
+func (c *AcmeClient) obtainCertificate(domains []string, keyType, replaces string, logWriter ...io.Writer) (*certificate.Resource, error) {
 	if len(logWriter) > 0 && logWriter[0] != nil {
 		origOut := log.Writer()
 		origFlags := log.Flags()
@@ -164,9 +180,10 @@ func (c *AcmeClient) ObtainCertificate(domains []string, keyType string, logWrit
 	}
 
 	request := certificate.ObtainRequest{
-		Domains:    domains,
-		Bundle:     true,
-		PrivateKey: privKey,
+		Domains:        domains,
+		Bundle:         true,
+		PrivateKey:     privKey,
+		ReplacesCertID: replaces,
 	}
 
 	cert, err := c.Client.Certificate.Obtain(request)
@@ -191,20 +208,18 @@ func (c *AcmeClient) ObtainCertificate(domains []string, keyType string, logWrit
 	return cert, nil
 }
 
-// SetDNSProvider 设置 DNS 验证提供商
-func (c *AcmeClient) SetDNSProvider(dnsType, authJSON string) error {
+// SetDNSProvider 设置 DNS 验证提供商；alias 非空时使用别名模式，TXT 记录写入 _acme-challenge.<alias>
+func (c *AcmeClient) SetDNSProvider(dnsType, authJSON, alias string) error {
 	provider, err := GetDNSProvider(dnsType, authJSON)
 	if err != nil {
 		return err
 	}
+	if alias != "" {
+		provider = newDNSAliasProvider(provider, alias)
+	}
 	return c.Client.Challenge.SetDNS01Provider(provider,
 		dns01.AddDNSTimeout(5*time.Minute),
-		dns01.AddRecursiveNameservers([]string{
-			"1.1.1.1:53",
-			"8.8.8.8:53",
-			"1.0.0.1:53",
-			"8.8.4.4:53",
-		}),
+		dns01.AddRecursiveNameservers(recursiveNameservers),
 	)
 }
 
@@ -245,6 +260,60 @@ func (c *AcmeClient) RenewCertificate(domains []string, keyType string, existing
 	return c.ObtainCertificate(domains, keyType)
 }
 
+// RenewalInfo CA 通过 ARI（RFC 9773）建议的续签窗口
+type RenewalInfo struct {
+	WindowStart    time.Time
+	WindowEnd      time.Time
+	RetryAfter     time.Duration // CA 建议的下次查询间隔，0 表示未给出
+	ExplanationURL string
+}
+
+// ErrRenewalInfoUnsupported CA 的目录中没有 renewalInfo 端点
+var ErrRenewalInfoUnsupported = errors.New("CA does not support ACME renewal information")
+
+// GetRenewalInfo 查询证书的 ARI 续签窗口
+func (c *AcmeClient) GetRenewalInfo(certPEM []byte) (*RenewalInfo, error) {
+	leaf, err := parseLeafCertificate(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	info, err := c.Client.Certificate.GetRenewalInfo(certificate.RenewalInfoRequest{Cert: leaf})
+	if err != nil {
+		if errors.Is(err, api.ErrNoARI) {
+			return nil, ErrRenewalInfoUnsupported
+		}
+		return nil, fmt.Errorf("get renewal info: %v", err)
+	}
+	if info.SuggestedWindow.Start.IsZero() || info.SuggestedWindow.End.Before(info.SuggestedWindow.Start) {
+		return nil, fmt.Errorf("get renewal info: invalid suggested window")
+	}
+	return &RenewalInfo{
+		WindowStart:    info.SuggestedWindow.Start,
+		WindowEnd:      info.SuggestedWindow.End,
+		RetryAfter:     info.RetryAfter,
+		ExplanationURL: info.ExplanationURL,
+	}, nil
+}
+
+// RevokeCertificate 向 CA 吊销证书，reason 为 RFC 5280 吊销原因码
+func (c *AcmeClient) RevokeCertificate(certPEM []byte, reason uint) error {
+	if err := c.Client.Certificate.RevokeWithReason(certPEM, &reason); err != nil {
+		return fmt.Errorf("revoke certificate: %v", err)
+	}
+	return nil
+}
+
+func parseLeafCertificate(certPEM []byte) (*x509.Certificate, error) {
+	certs, err := certcrypto.ParsePEMBundle(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("parse certificate: %v", err)
+	}
+	if certs[0].IsCA {
+		return nil, fmt.Errorf("certificate bundle starts with a CA certificate")
+	}
+	return certs[0], nil
+}
+
 // EncodePrivateKey 将私钥编码为 PEM
 func EncodePrivateKey(priKey crypto.PrivateKey, keyType string) ([]byte, error) {
 	var (
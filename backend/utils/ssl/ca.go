@@ -0,0 +1,226 @@
+package ssl
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+)
+
+// CRLValidity 私有 CA 吊销列表的有效期，面板每天重新发布
+const CRLValidity = 7 * 24 * time.Hour
+
+// CARequest 创建根 CA 或中间 CA 的参数
+type CARequest struct {
+	CommonName   string
+	Organization string
+	KeyType      string
+	Validity     time.Duration
+	CRLURL       string // 中间 CA 证书中写入的上级 CA 吊销列表地址
+}
+
+// LeafRequest 由私有 CA 签发服务端或客户端证书的参数
+type LeafRequest struct {
+	CommonName  string
+	DNSNames    []string
+	IPAddresses []net.IP
+	Client      bool // 客户端证书（mTLS），否则为服务端证书
+	KeyType     string
+	Validity    time.Duration
+	CRLURL      string
+}
+
+// RevokedEntry CRL 中的一条吊销记录
+type RevokedEntry struct {
+	SerialNumber *big.Int
+	RevokedAt    time.Time
+	Reason       int
+}
+
+// CreateRootCA 生成自签名根 CA
+func CreateRootCA(req CARequest) (certPEM, keyPEM []byte, err error) {
+	key, err := certcrypto.GeneratePrivateKey(certcrypto.KeyType(req.KeyType))
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate key: %v", err)
+	}
+	signer := key.(crypto.Signer)
+	tmpl, err := caTemplate(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, signer.Public(), signer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create root CA: %v", err)
+	}
+	return encodeIssued(der, key, req.KeyType)
+}
+
+// CreateIntermediateCA 由上级 CA 签发中间 CA，中间 CA 只能签发终端证书
+func CreateIntermediateCA(parentCertPEM, parentKeyPEM []byte, req CARequest) (certPEM, keyPEM []byte, err error) {
+	parent, parentKey, err := parseCA(parentCertPEM, parentKeyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := certcrypto.GeneratePrivateKey(certcrypto.KeyType(req.KeyType))
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate key: %v", err)
+	}
+	tmpl, err := caTemplate(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl.MaxPathLen = 0
+	tmpl.MaxPathLenZero = true
+	tmpl.NotAfter = clampNotAfter(tmpl.NotAfter, parent)
+	if req.CRLURL != "" {
+		tmpl.CRLDistributionPoints = []string{req.CRLURL}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.(crypto.Signer).Public(), parentKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create intermediate CA: %v", err)
+	}
+	return encodeIssued(der, key, req.KeyType)
+}
+
+// IssueCertificate 由 CA 签发终端证书，有效期不超过 CA 本身
+func IssueCertificate(caCertPEM, caKeyPEM []byte, req LeafRequest) (certPEM, keyPEM []byte, err error) {
+	ca, caKey, err := parseCA(caCertPEM, caKeyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.CommonName == "" && len(req.DNSNames) == 0 && len(req.IPAddresses) == 0 {
+		return nil, nil, fmt.Errorf("certificate needs at least one name")
+	}
+	key, err := certcrypto.GeneratePrivateKey(certcrypto.KeyType(req.KeyType))
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate key: %v", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: req.CommonName},
+		DNSNames:              req.DNSNames,
+		IPAddresses:           req.IPAddresses,
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              clampNotAfter(now.Add(req.Validity), ca),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	if req.Client {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		// RSA 密钥交换仍需要 KeyEncipherment
+		tmpl.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	if req.CRLURL != "" {
+		tmpl.CRLDistributionPoints = []string{req.CRLURL}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, key.(crypto.Signer).Public(), caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("issue certificate: %v", err)
+	}
+	return encodeIssued(der, key, req.KeyType)
+}
+
+// CreateCRL 生成 CA 的吊销列表（PEM）
+func CreateCRL(caCertPEM, caKeyPEM []byte, number int64, revoked []RevokedEntry, now time.Time) ([]byte, error) {
+	ca, caKey, err := parseCA(caCertPEM, caKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]x509.RevocationListEntry, 0, len(revoked))
+	for _, item := range revoked {
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   item.SerialNumber,
+			RevocationTime: item.RevokedAt,
+			ReasonCode:     item.Reason,
+		})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(number),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(CRLValidity),
+		RevokedCertificateEntries: entries,
+	}, ca, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("create CRL: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), nil
+}
+
+func caTemplate(req CARequest) (*x509.Certificate, error) {
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+	subject := pkix.Name{CommonName: req.CommonName}
+	if req.Organization != "" {
+		subject.Organization = []string{req.Organization}
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               subject,
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.Add(req.Validity),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+	}, nil
+}
+
+func parseCA(certPEM, keyPEM []byte) (*x509.Certificate, crypto.Signer, error) {
+	certs, err := certcrypto.ParsePEMBundle(certPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse CA certificate: %v", err)
+	}
+	if !certs[0].IsCA {
+		return nil, nil, fmt.Errorf("certificate is not a CA")
+	}
+	key, err := certcrypto.ParsePEMPrivateKey(keyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse CA private key: %v", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported CA private key")
+	}
+	return certs[0], signer, nil
+}
+
+func clampNotAfter(notAfter time.Time, issuer *x509.Certificate) time.Time {
+	if notAfter.After(issuer.NotAfter) {
+		return issuer.NotAfter
+	}
+	return notAfter
+}
+
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("generate serial number: %v", err)
+	}
+	return serial, nil
+}
+
+func encodeIssued(der []byte, key crypto.PrivateKey, keyType string) ([]byte, []byte, error) {
+	keyPEM, err := EncodePrivateKey(key, keyType)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), keyPEM, nil
+}
@@ -0,0 +1,104 @@
+package ssl
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"testing"
+	"time"
+)
+
+func parseTestCertificate(t *testing.T, data []byte) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatal("invalid PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestPrivateCAIssuesVerifiableCertificates(t *testing.T) {
+	rootPEM, rootKey, err := CreateRootCA(CARequest{CommonName: "Test Root", Organization: "X", KeyType: string(KeyEC256), Validity: 365 * 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interPEM, interKey, err := CreateIntermediateCA(rootPEM, rootKey, CARequest{
+		CommonName: "Test Intermediate", KeyType: string(KeyRSA2048), Validity: 10 * 365 * 24 * time.Hour,
+		CRLURL: "http://panel.internal/crl/1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, inter := parseTestCertificate(t, rootPEM), parseTestCertificate(t, interPEM)
+	if !inter.NotAfter.Equal(root.NotAfter) {
+		t.Fatalf("intermediate validity should be clamped to the root: %v vs %v", inter.NotAfter, root.NotAfter)
+	}
+	if !inter.MaxPathLenZero || inter.CRLDistributionPoints[0] != "http://panel.internal/crl/1" {
+		t.Fatalf("intermediate constraints: %+v", inter)
+	}
+
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	roots.AddCert(root)
+	intermediates.AddCert(inter)
+
+	serverPEM, _, err := IssueCertificate(interPEM, interKey, LeafRequest{
+		CommonName: "api.internal", DNSNames: []string{"api.internal"}, IPAddresses: []net.IP{net.ParseIP("10.0.0.5")},
+		KeyType: string(KeyEC256), Validity: 90 * 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := parseTestCertificate(t, serverPEM)
+	if _, err := server.Verify(x509.VerifyOptions{DNSName: "10.0.0.5", Roots: roots, Intermediates: intermediates}); err != nil {
+		t.Fatalf("server certificate should verify for its IP: %v", err)
+	}
+
+	clientPEM, _, err := IssueCertificate(interPEM, interKey, LeafRequest{
+		CommonName: "alice", Client: true, KeyType: string(KeyRSA2048), Validity: 30 * 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := parseTestCertificate(t, clientPEM)
+	opts := x509.VerifyOptions{Roots: roots, Intermediates: intermediates, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}
+	if _, err := client.Verify(opts); err != nil {
+		t.Fatalf("client certificate should verify for client auth: %v", err)
+	}
+	if _, err := server.Verify(opts); err == nil {
+		t.Fatal("server certificate must not be accepted for client auth")
+	}
+}
+
+func TestCreateCRLListsRevokedSerials(t *testing.T) {
+	rootPEM, rootKey, err := CreateRootCA(CARequest{CommonName: "CRL Root", KeyType: string(KeyEC256), Validity: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafPEM, _, err := IssueCertificate(rootPEM, rootKey, LeafRequest{CommonName: "svc", KeyType: string(KeyEC256), Validity: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := parseTestCertificate(t, leafPEM)
+	revokedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	crlPEM, err := CreateCRL(rootPEM, rootKey, 3, []RevokedEntry{{SerialNumber: leaf.SerialNumber, RevokedAt: revokedAt, Reason: 1}}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(crlPEM)
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crl.CheckSignatureFrom(parseTestCertificate(t, rootPEM)); err != nil {
+		t.Fatal(err)
+	}
+	if crl.Number.Int64() != 3 || len(crl.RevokedCertificateEntries) != 1 ||
+		crl.RevokedCertificateEntries[0].SerialNumber.Cmp(leaf.SerialNumber) != 0 ||
+		!crl.RevokedCertificateEntries[0].RevocationTime.Equal(revokedAt) {
+		t.Fatalf("CRL content: %+v", crl)
+	}
+}
@@ -0,0 +1,145 @@
+package ssl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/miekg/dns"
+)
+
+const (
+	acmeChallengeLabel = "_acme-challenge."
+	maxCNAMEHops       = 8
+)
+
+// NormalizeDNSAlias 整理别名域：去掉误填的 _acme-challenge. 前缀与结尾的点，统一小写
+func NormalizeDNSAlias(alias string) string {
+	alias = strings.ToLower(strings.TrimSpace(alias))
+	alias = strings.TrimSuffix(alias, ".")
+	return strings.TrimPrefix(alias, acmeChallengeLabel)
+}
+
+// ValidateDNSAlias 别名域必须是完整的普通域名
+func ValidateDNSAlias(alias string) error {
+	alias = NormalizeDNSAlias(alias)
+	if _, ok := dns.IsDomainName(alias); !ok || !strings.Contains(alias, ".") || strings.Contains(alias, "*") {
+		return fmt.Errorf("invalid DNS alias domain: %s", alias)
+	}
+	return nil
+}
+
+// DNSAliasRecord 返回证书域名需要添加的 CNAME 记录名与目标
+func DNSAliasRecord(domain, alias string) (name, target string) {
+	domain = strings.TrimPrefix(strings.TrimSpace(domain), "*.")
+	return acmeChallengeLabel + strings.ToLower(domain), acmeChallengeLabel + NormalizeDNSAlias(alias)
+}
+
+// dnsAliasProvider 别名模式：所有域名的 TXT 记录都写到 _acme-challenge.<alias>，
+// 域名自己的 _acme-challenge 由用户 CNAME 过来，CA 校验时沿 CNAME 查到该记录
+type dnsAliasProvider struct {
+	provider challenge.Provider
+	alias    string
+}
+
+func (p *dnsAliasProvider) Present(domain, token, keyAuth string) error {
+	return p.provider.Present(p.alias, token, keyAuth)
+}
+
+func (p *dnsAliasProvider) CleanUp(domain, token, keyAuth string) error {
+	return p.provider.CleanUp(p.alias, token, keyAuth)
+}
+
+func (p *dnsAliasProvider) Timeout() (timeout, interval time.Duration) {
+	if t, ok := p.provider.(challenge.ProviderTimeout); ok {
+		return t.Timeout()
+	}
+	return dns01.DefaultPropagationTimeout, dns01.DefaultPollingInterval
+}
+
+// sequentialDNSAliasProvider 内层提供商要求逐个验证时（如 RFC2136 写入前会清空同名记录集）保留该行为，
+// 别名模式下各域名共用同一个记录名，并发写入会互相覆盖
+type sequentialDNSAliasProvider struct {
+	*dnsAliasProvider
+}
+
+func (p *sequentialDNSAliasProvider) Sequential() time.Duration {
+	return p.provider.(interface{ Sequential() time.Duration }).Sequential()
+}
+
+func newDNSAliasProvider(provider challenge.Provider, alias string) challenge.Provider {
+	base := &dnsAliasProvider{provider: provider, alias: NormalizeDNSAlias(alias)}
+	if _, ok := provider.(interface{ Sequential() time.Duration }); ok {
+		return &sequentialDNSAliasProvider{dnsAliasProvider: base}
+	}
+	return base
+}
+
+// CheckDNSAlias 申请前确认每个域名的 _acme-challenge 已（可经多级）CNAME 到别名记录，
+// 未配置时直接报错并给出需要添加的记录，避免向 CA 发起注定失败的验证
+func CheckDNSAlias(domains []string, alias string) error {
+	var missing []string
+	checked := make(map[string]bool)
+	for _, domain := range domains {
+		name, target := DNSAliasRecord(domain, alias)
+		if checked[name] {
+			continue
+		}
+		checked[name] = true
+		ok, err := cnameReaches(dns.Fqdn(name), dns.Fqdn(target))
+		if err != nil {
+			return fmt.Errorf("查询 %s 的 CNAME 失败: %v", name, err)
+		}
+		if !ok {
+			missing = append(missing, fmt.Sprintf("%s CNAME %s", name, target))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("DNS 别名未生效，请先添加以下记录: %s", strings.Join(missing, "; "))
+	}
+	return nil
+}
+
+func cnameReaches(name, target string) (bool, error) {
+	for range maxCNAMEHops {
+		next, err := lookupCNAME(name)
+		if err != nil || next == "" {
+			return false, err
+		}
+		if strings.EqualFold(next, target) {
+			return true, nil
+		}
+		name = next
+	}
+	return false, nil
+}
+
+// lookupCNAME 依次询问递归 DNS，返回 name 的 CNAME 目标，不存在时返回空串
+func lookupCNAME(name string) (string, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(name, dns.TypeCNAME)
+	m.RecursionDesired = true
+	client := &dns.Client{Timeout: 5 * time.Second}
+
+	var lastErr error
+	for _, ns := range recursiveNameservers {
+		reply, _, err := client.Exchange(m, ns)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if reply.Rcode != dns.RcodeSuccess && reply.Rcode != dns.RcodeNameError {
+			lastErr = fmt.Errorf("%s: %s", ns, dns.RcodeToString[reply.Rcode])
+			continue
+		}
+		for _, rr := range reply.Answer {
+			if cname, ok := rr.(*dns.CNAME); ok && strings.EqualFold(cname.Hdr.Name, name) {
+				return cname.Target, nil
+			}
+		}
+		return "", nil
+	}
+	return "", lastErr
+}
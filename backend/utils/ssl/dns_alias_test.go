@@ -0,0 +1,90 @@
+package ssl
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// startCNAMEServer 启动一个按 records 回答 CNAME 查询的本地递归 DNS，并替换 recursiveNameservers
+func startCNAMEServer(t *testing.T, records map[string]string) {
+	t.Helper()
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		q := r.Question[0]
+		if target, ok := records[strings.ToLower(q.Name)]; ok {
+			m.Answer = append(m.Answer, &dns.CNAME{
+				Hdr:    dns.RR_Header{Name: q.Name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 60},
+				Target: target,
+			})
+		} else {
+			m.Rcode = dns.RcodeNameError
+		}
+		w.WriteMsg(m)
+	})
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &dns.Server{PacketConn: conn, Handler: handler}
+	go server.ActivateAndServe()
+	previous := recursiveNameservers
+	recursiveNameservers = []string{conn.LocalAddr().String()}
+	t.Cleanup(func() {
+		recursiveNameservers = previous
+		server.Shutdown()
+	})
+}
+
+func TestCheckDNSAliasFollowsCNAMEChain(t *testing.T) {
+	startCNAMEServer(t, map[string]string{
+		"_acme-challenge.customer.com.":           "_acme-challenge.validation.example.net.",
+		"_acme-challenge.shop.customer.com.":      "_acme-challenge.shop.proxy.example.org.",
+		"_acme-challenge.shop.proxy.example.org.": "_acme-challenge.Validation.Example.NET.",
+	})
+
+	if err := CheckDNSAlias([]string{"customer.com", "*.customer.com", "shop.customer.com"}, "_acme-challenge.validation.example.net."); err != nil {
+		t.Fatal(err)
+	}
+	err := CheckDNSAlias([]string{"customer.com", "api.customer.com"}, "validation.example.net")
+	if err == nil || !strings.Contains(err.Error(), "_acme-challenge.api.customer.com CNAME _acme-challenge.validation.example.net") {
+		t.Fatalf("missing CNAME should be reported with the record to add: %v", err)
+	}
+	if strings.Contains(err.Error(), "_acme-challenge.customer.com ") {
+		t.Fatalf("configured domain reported as missing: %v", err)
+	}
+}
+
+type recordingProvider struct {
+	presented []string
+}
+
+func (p *recordingProvider) Present(domain, token, keyAuth string) error {
+	p.presented = append(p.presented, domain)
+	return nil
+}
+
+func (p *recordingProvider) CleanUp(domain, token, keyAuth string) error { return nil }
+
+func (p *recordingProvider) Sequential() time.Duration { return time.Second }
+
+func TestDNSAliasProviderWritesToAlias(t *testing.T) {
+	inner := &recordingProvider{}
+	provider := newDNSAliasProvider(inner, "Validation.Example.NET.")
+	if err := provider.Present("customer.com", "token", "key-auth"); err != nil {
+		t.Fatal(err)
+	}
+	if len(inner.presented) != 1 || inner.presented[0] != "validation.example.net" {
+		t.Fatalf("record should be written under the alias: %v", inner.presented)
+	}
+	if seq, ok := provider.(interface{ Sequential() time.Duration }); !ok || seq.Sequential() != time.Second {
+		t.Fatal("sequential behaviour of the inner provider should be kept")
+	}
+	if err := ValidateDNSAlias("*.example.net"); err == nil {
+		t.Fatal("wildcard alias should be rejected")
+	}
+}
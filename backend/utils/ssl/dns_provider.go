@@ -2,16 +2,28 @@ package ssl
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-acme/lego/v4/challenge"
 	"github.com/go-acme/lego/v4/providers/dns/alidns"
 	"github.com/go-acme/lego/v4/providers/dns/cloudflare"
 	"github.com/go-acme/lego/v4/providers/dns/dnspod"
+	"github.com/go-acme/lego/v4/providers/dns/exec"
+	"github.com/go-acme/lego/v4/providers/dns/gandiv5"
 	"github.com/go-acme/lego/v4/providers/dns/godaddy"
+	"github.com/go-acme/lego/v4/providers/dns/httpreq"
 	"github.com/go-acme/lego/v4/providers/dns/huaweicloud"
 	"github.com/go-acme/lego/v4/providers/dns/namesilo"
+	"github.com/go-acme/lego/v4/providers/dns/pdns"
+	"github.com/go-acme/lego/v4/providers/dns/rfc2136"
+	"github.com/go-acme/lego/v4/providers/dns/route53"
 	"github.com/go-acme/lego/v4/providers/dns/tencentcloud"
 )
 
@@ -19,6 +31,14 @@ var (
 	propagationTimeout = 10 * time.Minute // 增加到 10min，适配 NameSilo 等 DNS 更新较慢的服务商
 	pollingInterval    = 5 * time.Second
 	ttl                = 600
+
+	// recursiveNameservers 传播检查与别名预检使用的递归 DNS
+	recursiveNameservers = []string{
+		"1.1.1.1:53",
+		"8.8.8.8:53",
+		"1.0.0.1:53",
+		"8.8.4.4:53",
+	}
 )
 
 // DNSParam DNS 提供商通用参数
@@ -33,19 +53,185 @@ type DNSParam struct {
 	APISecret string `json:"apiSecret"`
 	SecretID  string `json:"secretID"`
 	Region    string `json:"region"`
+
+	// RFC2136
+	Nameserver    string `json:"nameserver"`
+	TSIGKey       string `json:"tsigKey"`
+	TSIGSecret    string `json:"tsigSecret"`
+	TSIGAlgorithm string `json:"tsigAlgorithm"`
+	// PowerDNS
+	APIURL     string `json:"apiURL"`
+	ServerName string `json:"serverName"`
+	// Route53
+	HostedZoneID  string `json:"hostedZoneID"`
+	AssumeRoleArn string `json:"assumeRoleArn"`
+	// 脚本 / HTTP 请求
+	Command  string `json:"command"`
+	Endpoint string `json:"endpoint"`
+	Mode     string `json:"mode"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// DNSField 提供商授权字段的描述，前端据此渲染表单
+type DNSField struct {
+	Name     string   `json:"name"`
+	Label    string   `json:"label"`
+	Type     string   `json:"type"` // text | password | select
+	Required bool     `json:"required"`
+	Options  []string `json:"options,omitempty"`
+	Default  string   `json:"default,omitempty"`
+	Help     string   `json:"help,omitempty"`
+}
+
+// DNSProviderInfo DNS 提供商及其授权字段
+type DNSProviderInfo struct {
+	Value  string     `json:"value"`
+	Label  string     `json:"label"`
+	Fields string     `json:"fields"` // 逗号分隔的字段名，兼容只读取该字段的前端
+	Schema []DNSField `json:"schema"`
+}
+
+var dnsProviders = []DNSProviderInfo{
+	{Value: "CloudFlare", Label: "Cloudflare", Schema: []DNSField{
+		{Name: "email", Label: "邮箱", Type: "text", Help: "留空时 API Key 按 API Token 使用"},
+		{Name: "apiKey", Label: "API Key / Token", Type: "password", Required: true},
+	}},
+	{Value: "AliYun", Label: "阿里云 DNS", Schema: []DNSField{
+		{Name: "accessKey", Label: "AccessKey ID", Type: "text", Required: true},
+		{Name: "secretKey", Label: "AccessKey Secret", Type: "password", Required: true},
+	}},
+	{Value: "DnsPod", Label: "DNSPod", Schema: []DNSField{
+		{Name: "id", Label: "ID", Type: "text", Required: true},
+		{Name: "token", Label: "Token", Type: "password", Required: true},
+	}},
+	{Value: "TencentCloud", Label: "腾讯云 DNS", Schema: []DNSField{
+		{Name: "secretID", Label: "SecretId", Type: "text", Required: true},
+		{Name: "secretKey", Label: "SecretKey", Type: "password", Required: true},
+	}},
+	{Value: "HuaweiCloud", Label: "华为云 DNS", Schema: []DNSField{
+		{Name: "accessKey", Label: "Access Key", Type: "text", Required: true},
+		{Name: "secretKey", Label: "Secret Key", Type: "password", Required: true},
+		{Name: "region", Label: "区域", Type: "text", Default: "cn-north-1"},
+	}},
+	{Value: "NameSilo", Label: "NameSilo", Schema: []DNSField{
+		{Name: "apiKey", Label: "API Key", Type: "password", Required: true},
+	}},
+	{Value: "GoDaddy", Label: "GoDaddy", Schema: []DNSField{
+		{Name: "apiKey", Label: "API Key", Type: "text", Required: true},
+		{Name: "apiSecret", Label: "API Secret", Type: "password", Required: true},
+	}},
+	{Value: "Route53", Label: "AWS Route 53", Schema: []DNSField{
+		{Name: "accessKey", Label: "Access Key ID", Type: "text", Help: "留空时使用环境变量或实例角色中的凭据"},
+		{Name: "secretKey", Label: "Secret Access Key", Type: "password"},
+		{Name: "region", Label: "区域", Type: "text", Default: "us-east-1"},
+		{Name: "hostedZoneID", Label: "Hosted Zone ID", Type: "text", Help: "留空时按域名自动查找"},
+		{Name: "assumeRoleArn", Label: "Assume Role ARN", Type: "text"},
+	}},
+	{Value: "Gandi", Label: "Gandi LiveDNS", Schema: []DNSField{
+		{Name: "token", Label: "Personal Access Token", Type: "password", Required: true},
+	}},
+	{Value: "PowerDNS", Label: "PowerDNS", Schema: []DNSField{
+		{Name: "apiURL", Label: "API 地址", Type: "text", Required: true, Help: "例如 http://127.0.0.1:8081"},
+		{Name: "apiKey", Label: "API Key", Type: "password", Required: true},
+		{Name: "serverName", Label: "Server ID", Type: "text", Default: "localhost"},
+	}},
+	{Value: "RFC2136", Label: "RFC2136 (BIND / Knot)", Schema: []DNSField{
+		{Name: "nameserver", Label: "DNS 服务器", Type: "text", Required: true, Help: "host[:port]，默认端口 53"},
+		{Name: "tsigKey", Label: "TSIG 密钥名", Type: "text"},
+		{Name: "tsigSecret", Label: "TSIG 密钥", Type: "password", Help: "Base64 编码"},
+		{Name: "tsigAlgorithm", Label: "TSIG 算法", Type: "select", Default: "hmac-sha256",
+			Options: []string{"hmac-sha1", "hmac-sha224", "hmac-sha256", "hmac-sha384", "hmac-sha512"}},
+	}},
+	{Value: "Exec", Label: "自定义脚本", Schema: []DNSField{
+		{Name: "command", Label: "脚本路径", Type: "text", Required: true,
+			Help: "以 present|cleanup <fqdn> <value> 调用；RAW 模式以 present|cleanup <domain> <token> <keyAuth> 调用"},
+		{Name: "mode", Label: "模式", Type: "select", Options: []string{"", "RAW"}},
+	}},
+	{Value: "HTTPReq", Label: "HTTP 请求", Schema: []DNSField{
+		{Name: "endpoint", Label: "接口地址", Type: "text", Required: true, Help: "向 <endpoint>/present 与 <endpoint>/cleanup 发送 POST 请求"},
+		{Name: "mode", Label: "模式", Type: "select", Options: []string{"", "RAW"}},
+		{Name: "username", Label: "用户名", Type: "text"},
+		{Name: "password", Label: "密码", Type: "password"},
+	}},
 }
 
 // SupportedDNSProviders 返回支持的 DNS 提供商列表
-func SupportedDNSProviders() []map[string]string {
-	return []map[string]string{
-		{"value": "CloudFlare", "label": "Cloudflare", "fields": "email,apiKey"},
-		{"value": "AliYun", "label": "阿里云 DNS", "fields": "accessKey,secretKey"},
-		{"value": "DnsPod", "label": "DNSPod", "fields": "id,token"},
-		{"value": "TencentCloud", "label": "腾讯云 DNS", "fields": "secretID,secretKey"},
-		{"value": "HuaweiCloud", "label": "华为云 DNS", "fields": "accessKey,secretKey,region"},
-		{"value": "NameSilo", "label": "NameSilo", "fields": "apiKey"},
-		{"value": "GoDaddy", "label": "GoDaddy", "fields": "apiKey,apiSecret"},
+func SupportedDNSProviders() []DNSProviderInfo {
+	items := make([]DNSProviderInfo, 0, len(dnsProviders))
+	for _, provider := range dnsProviders {
+		names := make([]string, 0, len(provider.Schema))
+		for _, field := range provider.Schema {
+			names = append(names, field.Name)
+		}
+		provider.Fields = strings.Join(names, ",")
+		items = append(items, provider)
+	}
+	return items
+}
+
+// ValidateDNSParams 按提供商的字段描述检查授权参数
+func ValidateDNSParams(dnsType string, auth map[string]string) error {
+	var schema []DNSField
+	found := false
+	for _, provider := range dnsProviders {
+		if provider.Value == dnsType {
+			schema, found = provider.Schema, true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("unsupported DNS provider: %s", dnsType)
+	}
+	for _, field := range schema {
+		value := strings.TrimSpace(auth[field.Name])
+		if field.Required && value == "" {
+			return fmt.Errorf("%s is required", field.Name)
+		}
+		if value != "" && len(field.Options) > 0 && !slices.Contains(field.Options, value) {
+			return fmt.Errorf("invalid %s: %s", field.Name, value)
+		}
+	}
+
+	switch dnsType {
+	case "Route53":
+		if (auth["accessKey"] == "") != (auth["secretKey"] == "") {
+			return errors.New("accessKey and secretKey must be set together")
+		}
+	case "RFC2136":
+		if (auth["tsigKey"] == "") != (auth["tsigSecret"] == "") {
+			return errors.New("tsigKey and tsigSecret must be set together")
+		}
+	case "PowerDNS":
+		if _, err := parseDNSEndpoint(auth["apiURL"]); err != nil {
+			return err
+		}
+	case "HTTPReq":
+		if _, err := parseDNSEndpoint(auth["endpoint"]); err != nil {
+			return err
+		}
+	case "Exec":
+		command := auth["command"]
+		if !filepath.IsAbs(command) {
+			return fmt.Errorf("command must be an absolute path: %s", command)
+		}
+		info, err := os.Stat(command)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Mode().Perm()&0o111 == 0 {
+			return fmt.Errorf("command is not executable: %s", command)
+		}
 	}
+	return nil
+}
+
+func parseDNSEndpoint(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL: %s", raw)
+	}
+	return u, nil
 }
 
 // GetDNSProvider 根据类型和参数创建 DNS 验证提供商
@@ -127,6 +313,80 @@ func GetDNSProvider(dnsType, authJSON string) (challenge.Provider, error) {
 		config.TTL = ttl
 		return godaddy.NewDNSProviderConfig(config)
 
+	case "Route53":
+		config := route53.NewDefaultConfig()
+		config.AccessKeyID = param.AccessKey
+		config.SecretAccessKey = param.SecretKey
+		config.Region = param.Region
+		if config.Region == "" {
+			config.Region = "us-east-1"
+		}
+		config.HostedZoneID = param.HostedZoneID
+		config.AssumeRoleArn = param.AssumeRoleArn
+		config.PropagationTimeout = propagationTimeout
+		config.PollingInterval = pollingInterval
+		config.TTL = ttl
+		return route53.NewDNSProviderConfig(config)
+
+	case "Gandi":
+		config := gandiv5.NewDefaultConfig()
+		config.PersonalAccessToken = param.Token
+		config.PropagationTimeout = propagationTimeout
+		config.PollingInterval = pollingInterval
+		config.TTL = ttl
+		return gandiv5.NewDNSProviderConfig(config)
+
+	case "PowerDNS":
+		host, err := parseDNSEndpoint(param.APIURL)
+		if err != nil {
+			return nil, err
+		}
+		config := pdns.NewDefaultConfig()
+		config.Host = host
+		config.APIKey = param.APIKey
+		if param.ServerName != "" {
+			config.ServerName = param.ServerName
+		}
+		config.PropagationTimeout = propagationTimeout
+		config.PollingInterval = pollingInterval
+		config.TTL = ttl
+		return pdns.NewDNSProviderConfig(config)
+
+	case "RFC2136":
+		config := rfc2136.NewDefaultConfig()
+		config.Nameserver = param.Nameserver
+		config.TSIGKey = param.TSIGKey
+		config.TSIGSecret = param.TSIGSecret
+		if param.TSIGAlgorithm != "" {
+			config.TSIGAlgorithm = param.TSIGAlgorithm
+		}
+		config.PropagationTimeout = propagationTimeout
+		config.PollingInterval = pollingInterval
+		config.TTL = ttl
+		return rfc2136.NewDNSProviderConfig(config)
+
+	case "Exec":
+		config := exec.NewDefaultConfig()
+		config.Program = param.Command
+		config.Mode = param.Mode
+		config.PropagationTimeout = propagationTimeout
+		config.PollingInterval = pollingInterval
+		return exec.NewDNSProviderConfig(config)
+
+	case "HTTPReq":
+		endpoint, err := parseDNSEndpoint(param.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		config := httpreq.NewDefaultConfig()
+		config.Endpoint = endpoint
+		config.Mode = param.Mode
+		config.Username = param.Username
+		config.Password = param.Password
+		config.PropagationTimeout = propagationTimeout
+		config.PollingInterval = pollingInterval
+		return httpreq.NewDNSProviderConfig(config)
+
 	default:
 		return nil, fmt.Errorf("unsupported DNS provider: %s", dnsType)
 	}
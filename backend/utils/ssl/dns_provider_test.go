@@ -0,0 +1,148 @@
+package ssl
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// startUpdateServer 启动一个只托管 example.test. 的本地 DNS 服务器，记录通过 TSIG 校验的动态更新
+func startUpdateServer(t *testing.T, keyName, secret string) (addr string, updates func() []dns.RR) {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		records []dns.RR
+	)
+	zone := "example.test."
+	soa := &dns.SOA{
+		Hdr:    dns.RR_Header{Name: zone, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 300},
+		Ns:     "ns1." + zone,
+		Mbox:   "admin." + zone,
+		Serial: 1, Refresh: 3600, Retry: 600, Expire: 86400, Minttl: 60,
+	}
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		switch {
+		case r.Opcode == dns.OpcodeUpdate:
+			if r.IsTsig() == nil || w.TsigStatus() != nil {
+				m.Rcode = dns.RcodeNotAuth
+				break
+			}
+			mu.Lock()
+			for _, rr := range r.Ns {
+				if rr.Header().Class == dns.ClassINET {
+					records = append(records, rr)
+				}
+			}
+			mu.Unlock()
+			m.SetTsig(keyName, dns.HmacSHA256, 300, int64(r.IsTsig().TimeSigned))
+		case r.Question[0].Name == zone && r.Question[0].Qtype == dns.TypeSOA:
+			m.Authoritative = true
+			m.Answer = append(m.Answer, soa)
+		default:
+			m.Authoritative = true
+			m.Rcode = dns.RcodeNameError
+			m.Ns = append(m.Ns, soa)
+		}
+		w.WriteMsg(m)
+	})
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &dns.Server{
+		PacketConn: conn,
+		Handler:    handler,
+		TsigSecret: map[string]string{keyName: secret},
+		// 默认的 MsgAcceptFunc 会以 NOTIMP 拒绝 UPDATE
+		MsgAcceptFunc: func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+	}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+
+	return conn.LocalAddr().String(), func() []dns.RR {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]dns.RR(nil), records...)
+	}
+}
+
+func TestRFC2136ProviderSendsSignedUpdate(t *testing.T) {
+	secret := "c2VjcmV0LWtleS1mb3ItdGVzdHM="
+	addr, updates := startUpdateServer(t, "acme-key.", secret)
+
+	auth := map[string]string{"nameserver": addr, "tsigKey": "acme-key", "tsigSecret": secret, "tsigAlgorithm": "hmac-sha256"}
+	if err := ValidateDNSParams("RFC2136", auth); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := json.Marshal(auth)
+	provider, err := GetDNSProvider("RFC2136", string(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.Present("www.example.test", "token", "key-auth"); err != nil {
+		t.Fatal(err)
+	}
+	rrs := updates()
+	if len(rrs) != 1 {
+		t.Fatalf("expected one inserted record, got %v", rrs)
+	}
+	txt, ok := rrs[0].(*dns.TXT)
+	if !ok || txt.Hdr.Name != "_acme-challenge.www.example.test." || len(txt.Txt) != 1 {
+		t.Fatalf("unexpected record %v", rrs[0])
+	}
+
+	auth["tsigSecret"] = "d3Jvbmctc2VjcmV0"
+	raw, _ = json.Marshal(auth)
+	provider, _ = GetDNSProvider("RFC2136", string(raw))
+	if err := provider.Present("www.example.test", "token", "key-auth"); err == nil {
+		t.Fatal("update signed with a wrong secret should be rejected")
+	}
+}
+
+func TestValidateDNSParams(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "dns-hook.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		dnsType string
+		auth    map[string]string
+		wantErr string
+	}{
+		{"Unknown", nil, "unsupported"},
+		{"PowerDNS", map[string]string{"apiKey": "k"}, "apiURL is required"},
+		{"PowerDNS", map[string]string{"apiURL": "127.0.0.1:8081", "apiKey": "k"}, "invalid URL"},
+		{"PowerDNS", map[string]string{"apiURL": "http://127.0.0.1:8081", "apiKey": "k"}, ""},
+		{"RFC2136", map[string]string{"nameserver": "ns1", "tsigKey": "k"}, "set together"},
+		{"RFC2136", map[string]string{"nameserver": "ns1", "tsigAlgorithm": "md5"}, "invalid tsigAlgorithm"},
+		{"Route53", map[string]string{}, ""},
+		{"Route53", map[string]string{"accessKey": "AKIA"}, "set together"},
+		{"Exec", map[string]string{"command": "dns-hook.sh"}, "absolute path"},
+		{"Exec", map[string]string{"command": script, "mode": "RAW"}, ""},
+		{"HTTPReq", map[string]string{"endpoint": "https://dns.example.com/acme"}, ""},
+	}
+	for _, tc := range cases {
+		err := ValidateDNSParams(tc.dnsType, tc.auth)
+		if tc.wantErr == "" && err != nil {
+			t.Errorf("%s %v: unexpected error %v", tc.dnsType, tc.auth, err)
+		}
+		if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("%s %v: error %v, want %q", tc.dnsType, tc.auth, err, tc.wantErr)
+		}
+	}
+
+	for _, provider := range SupportedDNSProviders() {
+		if provider.Fields == "" || len(provider.Schema) == 0 {
+			t.Errorf("provider %s has no field schema", provider.Value)
+		}
+	}
+}
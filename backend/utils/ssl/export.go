@@ -0,0 +1,92 @@
+package ssl
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/pavlo-v-chernykh/keystore-go/v4"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+const (
+	ExportFormatPEM    = "pem"    // 证书链与私钥分别写入两个文件
+	ExportFormatBundle = "bundle" // 私钥 + 证书链合并为一个 PEM 文件（HAProxy 等）
+	ExportFormatPKCS12 = "pkcs12"
+	ExportFormatJKS    = "jks"
+
+	// MinJKSPasswordLength keytool 要求密钥库密码至少 6 位
+	MinJKSPasswordLength = 6
+)
+
+// BundlePEM 按 HAProxy 的习惯把私钥放在证书链之前
+func BundlePEM(certPEM, keyPEM []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(bytes.TrimSpace(keyPEM))
+	buf.WriteByte('\n')
+	buf.Write(bytes.TrimSpace(certPEM))
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// EncodePKCS12 生成包含私钥与完整证书链的 PKCS#12 文件（AES-256 + PBKDF2，Windows/IIS 等均可导入）
+func EncodePKCS12(certPEM, keyPEM []byte, password string) ([]byte, error) {
+	certs, key, err := parseExportMaterial(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	data, err := pkcs12.Modern.Encode(key, certs[0], certs[1:], password)
+	if err != nil {
+		return nil, fmt.Errorf("encode PKCS#12: %v", err)
+	}
+	return data, nil
+}
+
+// EncodeJKS 生成 Java KeyStore，私钥条目使用与密钥库相同的密码
+func EncodeJKS(certPEM, keyPEM []byte, alias, password string) ([]byte, error) {
+	if len(password) < MinJKSPasswordLength {
+		return nil, fmt.Errorf("JKS password must be at least %d characters", MinJKSPasswordLength)
+	}
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		return nil, fmt.Errorf("JKS alias is required")
+	}
+	certs, key, err := parseExportMaterial(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("marshal private key: %v", err)
+	}
+	chain := make([]keystore.Certificate, 0, len(certs))
+	for _, cert := range certs {
+		chain = append(chain, keystore.Certificate{Type: "X509", Content: cert.Raw})
+	}
+
+	ks := keystore.New()
+	entry := keystore.PrivateKeyEntry{CreationTime: time.Now(), PrivateKey: keyDER, CertificateChain: chain}
+	if err := ks.SetPrivateKeyEntry(alias, entry, []byte(password)); err != nil {
+		return nil, fmt.Errorf("build JKS: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := ks.Store(&buf, []byte(password)); err != nil {
+		return nil, fmt.Errorf("encode JKS: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func parseExportMaterial(certPEM, keyPEM []byte) ([]*x509.Certificate, interface{}, error) {
+	certs, err := certcrypto.ParsePEMBundle(certPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse certificate: %v", err)
+	}
+	key, err := certcrypto.ParsePEMPrivateKey(keyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse private key: %v", err)
+	}
+	return certs, key, nil
+}
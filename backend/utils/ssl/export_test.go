@@ -0,0 +1,97 @@
+package ssl
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/pavlo-v-chernykh/keystore-go/v4"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func exportTestMaterial(t *testing.T) (certPEM, keyPEM []byte, serial *big.Int) {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	serial = big.NewInt(42)
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "export.example.test"},
+		DNSNames:     []string{"export.example.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, serial
+}
+
+func TestBundlePEMPutsKeyFirst(t *testing.T) {
+	certPEM, keyPEM, _ := exportTestMaterial(t)
+	bundle := BundlePEM(certPEM, keyPEM)
+	block, rest := pem.Decode(bundle)
+	if block == nil || block.Type != "EC PRIVATE KEY" {
+		t.Fatalf("first block should be the private key, got %+v", block)
+	}
+	if block, _ = pem.Decode(rest); block == nil || block.Type != "CERTIFICATE" {
+		t.Fatalf("second block should be the certificate, got %+v", block)
+	}
+}
+
+func TestEncodePKCS12(t *testing.T) {
+	certPEM, keyPEM, serial := exportTestMaterial(t)
+	data, err := EncodePKCS12(certPEM, keyPEM, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, cert, _, err := pkcs12.DecodeChain(data, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.SerialNumber.Cmp(serial) != 0 {
+		t.Fatalf("serial = %v", cert.SerialNumber)
+	}
+	if _, ok := key.(*ecdsa.PrivateKey); !ok {
+		t.Fatalf("unexpected key type %T", key)
+	}
+	if _, _, _, err := pkcs12.DecodeChain(data, "wrong"); err == nil {
+		t.Fatal("decoding with a wrong password should fail")
+	}
+}
+
+func TestEncodeJKS(t *testing.T) {
+	certPEM, keyPEM, serial := exportTestMaterial(t)
+	if _, err := EncodeJKS(certPEM, keyPEM, "site", "short"); err == nil {
+		t.Fatal("short JKS password should be rejected")
+	}
+	data, err := EncodeJKS(certPEM, keyPEM, "site", "changeit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := keystore.New()
+	if err := ks.Load(bytes.NewReader(data), []byte("changeit")); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := ks.GetPrivateKeyEntry("site", []byte("changeit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(entry.PrivateKey); err != nil {
+		t.Fatalf("private key: %v", err)
+	}
+	cert, err := x509.ParseCertificate(entry.CertificateChain[0].Content)
+	if err != nil || cert.SerialNumber.Cmp(serial) != 0 {
+		t.Fatalf("certificate chain: %v %v", cert, err)
+	}
+}
@@ -0,0 +1,186 @@
+package ssl
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	RevocationGood    = "good"
+	RevocationRevoked = "revoked"
+	RevocationUnknown = "unknown"
+
+	maxRevocationResponseSize = 32 << 20 // CRL 分片可能有数 MB
+)
+
+// ErrNoRevocationEndpoint 证书既没有 OCSP 地址也没有 CRL 分发点（如自签证书）
+var ErrNoRevocationEndpoint = errors.New("certificate has no OCSP server or CRL distribution point")
+
+var revocationHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// RevocationStatus 证书吊销检查结果
+type RevocationStatus struct {
+	Status    string // good | revoked | unknown
+	Source    string // ocsp | crl
+	RevokedAt time.Time
+	Reason    int // RFC 5280 吊销原因码
+}
+
+// CheckRevocation 检查证书是否已被吊销：优先查询 OCSP，
+// 证书未提供 OCSP 地址（Let's Encrypt 已停止 OCSP）或查询失败时回落到 CRL
+func CheckRevocation(certPEM []byte) (*RevocationStatus, error) {
+	certs, err := certcrypto.ParsePEMBundle(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("parse certificate: %v", err)
+	}
+	leaf := certs[0]
+	if len(leaf.OCSPServer) == 0 && len(leaf.CRLDistributionPoints) == 0 {
+		return nil, ErrNoRevocationEndpoint
+	}
+	issuer, err := issuerCertificate(certs)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	if len(leaf.OCSPServer) > 0 {
+		status, err := checkOCSP(leaf, issuer)
+		if err == nil {
+			return status, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(leaf.CRLDistributionPoints) > 0 {
+		status, err := checkCRL(leaf, issuer)
+		if err == nil {
+			return status, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// issuerCertificate 优先使用证书链中的签发者，链不完整时按 AIA 下载
+func issuerCertificate(certs []*x509.Certificate) (*x509.Certificate, error) {
+	leaf := certs[0]
+	for _, candidate := range certs[1:] {
+		if leaf.CheckSignatureFrom(candidate) == nil {
+			return candidate, nil
+		}
+	}
+	if len(leaf.IssuingCertificateURL) == 0 {
+		return nil, fmt.Errorf("issuer certificate is missing from the chain and no issuing certificate URL")
+	}
+	data, err := fetchRevocationResource(leaf.IssuingCertificateURL[0])
+	if err != nil {
+		return nil, fmt.Errorf("fetch issuer certificate: %v", err)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	issuer, err := x509.ParseCertificate(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse issuer certificate: %v", err)
+	}
+	if err := leaf.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("issuer certificate does not match: %v", err)
+	}
+	return issuer, nil
+}
+
+func checkOCSP(leaf, issuer *x509.Certificate) (*RevocationStatus, error) {
+	req, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create OCSP request: %v", err)
+	}
+	resp, err := revocationHTTPClient.Post(leaf.OCSPServer[0], "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		return nil, fmt.Errorf("OCSP request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OCSP request: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRevocationResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("OCSP response: %v", err)
+	}
+	// ParseResponseForCert 同时校验响应签名与证书序列号
+	parsed, err := ocsp.ParseResponseForCert(data, leaf, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("parse OCSP response: %v", err)
+	}
+
+	status := &RevocationStatus{Status: RevocationUnknown, Source: "ocsp"}
+	switch parsed.Status {
+	case ocsp.Good:
+		status.Status = RevocationGood
+	case ocsp.Revoked:
+		status.Status = RevocationRevoked
+		status.RevokedAt = parsed.RevokedAt
+		status.Reason = parsed.RevocationReason
+	}
+	return status, nil
+}
+
+func checkCRL(leaf, issuer *x509.Certificate) (*RevocationStatus, error) {
+	var errs []error
+	for _, url := range leaf.CRLDistributionPoints {
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			continue
+		}
+		data, err := fetchRevocationResource(url)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("fetch CRL: %v", err))
+			continue
+		}
+		if block, _ := pem.Decode(data); block != nil {
+			data = block.Bytes
+		}
+		crl, err := x509.ParseRevocationList(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parse CRL: %v", err))
+			continue
+		}
+		if err := crl.CheckSignatureFrom(issuer); err != nil {
+			errs = append(errs, fmt.Errorf("verify CRL: %v", err))
+			continue
+		}
+		status := &RevocationStatus{Status: RevocationGood, Source: "crl"}
+		for _, entry := range crl.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+				status.Status = RevocationRevoked
+				status.RevokedAt = entry.RevocationTime
+				status.Reason = entry.ReasonCode
+				break
+			}
+		}
+		return status, nil
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no HTTP CRL distribution point")
+	}
+	return nil, errors.Join(errs...)
+}
+
+func fetchRevocationResource(url string) ([]byte, error) {
+	resp, err := revocationHTTPClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxRevocationResponseSize))
+}
@@ -0,0 +1,164 @@
+package ssl
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+type testCA struct {
+	cert    *x509.Certificate
+	key     crypto.Signer
+	revoked map[int64]time.Time
+	ocspOK  bool
+}
+
+// startRevocationServer 启动一个同时提供签发者证书、OCSP 与 CRL 的本地 CA
+func startRevocationServer(t *testing.T) (*testCA, string) {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, _ := x509.ParseCertificate(der)
+	ca := &testCA{cert: caCert, key: key, revoked: map[int64]time.Time{}, ocspOK: true}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issuer.der", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(caCert.Raw)
+	})
+	mux.HandleFunc("/ocsp", func(w http.ResponseWriter, r *http.Request) {
+		if !ca.ocspOK {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		req, err := ocsp.ParseRequest(body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		tmpl := ocsp.Response{Status: ocsp.Good, SerialNumber: req.SerialNumber, ThisUpdate: time.Now()}
+		if at, ok := ca.revoked[req.SerialNumber.Int64()]; ok {
+			tmpl.Status = ocsp.Revoked
+			tmpl.RevokedAt = at
+			tmpl.RevocationReason = ocsp.KeyCompromise
+		}
+		resp, _ := ocsp.CreateResponse(caCert, caCert, tmpl, key)
+		w.Write(resp)
+	})
+	mux.HandleFunc("/crl", func(w http.ResponseWriter, r *http.Request) {
+		var entries []x509.RevocationListEntry
+		for serial, at := range ca.revoked {
+			entries = append(entries, x509.RevocationListEntry{SerialNumber: big.NewInt(serial), RevocationTime: at, ReasonCode: 4})
+		}
+		crl, _ := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:                    big.NewInt(1),
+			ThisUpdate:                time.Now(),
+			NextUpdate:                time.Now().Add(time.Hour),
+			RevokedCertificateEntries: entries,
+		}, caCert, key)
+		w.Write(crl)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return ca, server.URL
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, ocspURL, crlURL, issuerURL string, bundle bool) []byte {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "www.example.test"},
+		DNSNames:     []string{"www.example.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(12 * time.Hour),
+	}
+	if ocspURL != "" {
+		tmpl.OCSPServer = []string{ocspURL}
+	}
+	if crlURL != "" {
+		tmpl.CRLDistributionPoints = []string{crlURL}
+	}
+	if issuerURL != "" {
+		tmpl.IssuingCertificateURL = []string{issuerURL}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, key.Public(), ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if bundle {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})...)
+	}
+	return out
+}
+
+func TestCheckRevocationOCSP(t *testing.T) {
+	ca, url := startRevocationServer(t)
+	revokedAt := time.Now().Add(-10 * time.Minute).UTC().Truncate(time.Second)
+	ca.revoked[3] = revokedAt
+
+	status, err := CheckRevocation(ca.issue(t, 2, url+"/ocsp", "", "", true))
+	if err != nil || status.Status != RevocationGood || status.Source != "ocsp" {
+		t.Fatalf("good certificate: %+v %v", status, err)
+	}
+
+	// 链中缺少签发者时按 AIA 下载
+	status, err = CheckRevocation(ca.issue(t, 3, url+"/ocsp", "", url+"/issuer.der", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != RevocationRevoked || !status.RevokedAt.Equal(revokedAt) || status.Reason != ocsp.KeyCompromise {
+		t.Fatalf("revoked certificate: %+v", status)
+	}
+}
+
+func TestCheckRevocationFallsBackToCRL(t *testing.T) {
+	ca, url := startRevocationServer(t)
+	ca.revoked[5] = time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	status, err := CheckRevocation(ca.issue(t, 5, "", url+"/crl", "", true))
+	if err != nil || status.Status != RevocationRevoked || status.Source != "crl" || status.Reason != 4 {
+		t.Fatalf("CRL only: %+v %v", status, err)
+	}
+
+	ca.ocspOK = false
+	status, err = CheckRevocation(ca.issue(t, 6, url+"/ocsp", url+"/crl", "", true))
+	if err != nil || status.Status != RevocationGood || status.Source != "crl" {
+		t.Fatalf("OCSP failure should fall back to CRL: %+v %v", status, err)
+	}
+
+	status, err = CheckRevocation(ca.issue(t, 7, url+"/ocsp", "", "", true))
+	if err == nil {
+		t.Fatalf("OCSP failure without CRL must be reported, got %+v", status)
+	}
+
+	if _, err := CheckRevocation(ca.issue(t, 8, "", "", "", true)); !errors.Is(err, ErrNoRevocationEndpoint) {
+		t.Fatalf("certificate without endpoints: %v", err)
+	}
+}